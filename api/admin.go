@@ -0,0 +1,597 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/report"
+	"nofx/store"
+	"nofx/trader"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminAuth 管理接口鉴权（Authorization: Bearer <token> 或 X-Admin-Token 头）
+// 令牌取自当前配置，热加载后立即生效；未配置令牌时管理接口不可用
+func (s *Server) adminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := ""
+		if s.config != nil {
+			token = s.config().Admin.Token
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "管理接口未启用，请配置admin.token"})
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if provided == "" {
+			provided = c.GetHeader("X-Admin-Token")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "管理令牌无效"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// setupAdminRoutes 管理接口路由
+func (s *Server) setupAdminRoutes(admin *gin.RouterGroup) {
+	// 查询（trader_id为空时使用第一个trader）
+	admin.GET("/status", s.handleStatus)
+	admin.GET("/balance", s.handleAccount)
+	admin.GET("/positions", s.handlePositions)
+	admin.GET("/orders", s.handleAdminOrders)
+	admin.GET("/decisions", s.handleLatestDecisions)
+	admin.GET("/config", s.handleAdminConfig)
+	admin.GET("/logs", s.handleAdminLogs)
+	admin.GET("/events", s.handleAdminEvents) // 事件流（Server-Sent Events）
+	admin.GET("/trades", s.handleAdminTrades)
+	admin.GET("/trades/:id/replay", s.handleAdminTradeReplay)
+	admin.GET("/recommendations", s.handleAdminRecommendations)
+	admin.GET("/stats", s.handleAdminStats)
+
+	// 控制（trader_id为空时作用于所有trader）
+	admin.POST("/pause", s.handleAdminPause)
+	admin.POST("/resume", s.handleAdminResume)
+	admin.POST("/flatten", s.handleAdminFlatten)
+	admin.POST("/orders/cancel", s.handleAdminCancelOrders)
+	admin.POST("/protective/snapshot", s.handleAdminSnapshotProtective)
+	admin.POST("/protective/restore", s.handleAdminRestoreProtective)
+	admin.POST("/reload", s.handleAdminReload)
+	admin.POST("/transfer", s.handleAdminTransfer)
+	admin.POST("/leverage", s.handleAdminLeverage)
+	admin.POST("/hedge", s.handleAdminHedge)
+	admin.POST("/unhedge", s.handleAdminUnhedge)
+	admin.POST("/trades/:id/annotate", s.handleAdminAnnotateTrade)
+	admin.POST("/recommendations/:id/confirm", s.handleAdminConfirmClose)
+	admin.POST("/recommendations/:id/dismiss", s.handleAdminDismissClose)
+	admin.POST("/orders/preview", s.handleAdminPreviewOrder) // 只计算不下单
+}
+
+// handleAdminOrders 交易所上的挂单和止损/止盈条件单（symbols=BTCUSDT,ETHUSDT，为空时查询持仓币种）
+func (s *Server) handleAdminOrders(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var symbols []string
+	for _, symbol := range strings.Split(c.Query("symbols"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	orders, triggers, err := t.GetOpenOrders(symbols)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取挂单失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "orders": orders, "trigger_orders": triggers})
+}
+
+// handleAdminStats 本次启动以来的会话统计（决策周期、决策、订单、盈亏、手续费和AI消耗）
+func (s *Server) handleAdminStats(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, t.GetSessionStats())
+}
+
+// handleAdminConfig 当前配置（密钥已脱敏）
+func (s *Server) handleAdminConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, s.config().Redacted())
+}
+
+// handleAdminPause 暂停AI决策和新开仓（止损止盈照常生效，重启后保持暂停）
+// reason为暂停原因，cancel_orders=true时同时撤销没有持仓的币种上的挂单
+func (s *Server) handleAdminPause(c *gin.Context) {
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	result := make([]gin.H, 0, len(traders))
+	for _, t := range traders {
+		cancelled, err := t.Pause(trader.PauseSourceAPI, c.Query("reason"), c.Query("cancel_orders") == "true")
+		item := gin.H{"trader_id": t.GetID(), "paused": t.IsPaused(), "cancelled": cancelled}
+		if err != nil {
+			item["error"] = err.Error()
+			status = http.StatusMultiStatus
+		}
+		result = append(result, item)
+	}
+	c.JSON(status, gin.H{"traders": result})
+}
+
+// handleAdminResume 恢复交易
+func (s *Server) handleAdminResume(c *gin.Context) {
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]gin.H, 0, len(traders))
+	for _, t := range traders {
+		t.Resume()
+		result = append(result, gin.H{"trader_id": t.GetID(), "paused": t.IsPaused()})
+	}
+	c.JSON(http.StatusOK, gin.H{"traders": result})
+}
+
+// handleAdminFlatten 市价平仓（symbol=BTCUSDT；平掉所有币种需显式传 all=true）
+func (s *Server) handleAdminFlatten(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" && c.Query("all") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定symbol，或传all=true平掉所有持仓"})
+		return
+	}
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	result := make([]gin.H, 0, len(traders))
+	for _, t := range traders {
+		closed, err := t.Flatten(symbol)
+		item := gin.H{"trader_id": t.GetID(), "closed": closed}
+		if err != nil {
+			item["error"] = err.Error()
+			status = http.StatusMultiStatus
+		}
+		result = append(result, item)
+	}
+	c.JSON(status, gin.H{"traders": result})
+}
+
+// handleAdminCancelOrders 撤销指定币种的全部挂单（包括止损/止盈单）
+// keep_protection=true时撤单前保存止损/止盈快照，撤单后立即重建
+// 指定kind（entry/trigger）、strategy或side时只逐个撤销范围内的挂单，此时symbol可为空（所有币种）
+func (s *Server) handleAdminCancelOrders(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	scope := trader.CancelScope{Kind: c.Query("kind"), Strategy: c.Query("strategy"), Side: c.Query("side")}
+	if err := scope.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if symbol == "" && scope.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定symbol"})
+		return
+	}
+	keepProtection := c.Query("keep_protection") == "true"
+	if keepProtection && !scope.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keep_protection不能与kind/strategy/side同时使用"})
+		return
+	}
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	result := make([]gin.H, 0, len(traders))
+	for _, t := range traders {
+		item := gin.H{"trader_id": t.GetID()}
+		var err error
+		switch {
+		case !scope.IsZero():
+			item["cancelled"], err = t.CancelOrdersScoped(symbol, scope)
+		case keepProtection:
+			item["restored"], err = t.CancelOrdersKeepingProtection(symbol)
+		default:
+			err = t.CancelOrders(symbol)
+		}
+		if err != nil {
+			item["error"] = err.Error()
+			status = http.StatusMultiStatus
+		}
+		result = append(result, item)
+	}
+	c.JSON(status, gin.H{"traders": result})
+}
+
+// handleAdminSnapshotProtective 保存交易所上所有止损/止盈条件单的快照（改杠杆、切换持仓模式等操作之前调用）
+func (s *Server) handleAdminSnapshotProtective(c *gin.Context) {
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	result := make([]gin.H, 0, len(traders))
+	for _, t := range traders {
+		item := gin.H{"trader_id": t.GetID()}
+		if orders, err := t.SnapshotProtectiveOrders(); err != nil {
+			item["error"] = err.Error()
+			status = http.StatusMultiStatus
+		} else {
+			item["orders"] = orders
+		}
+		result = append(result, item)
+	}
+	c.JSON(status, gin.H{"traders": result})
+}
+
+// handleAdminRestoreProtective 按最近一次快照重建缺失的止损/止盈条件单
+func (s *Server) handleAdminRestoreProtective(c *gin.Context) {
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	result := make([]gin.H, 0, len(traders))
+	for _, t := range traders {
+		restored, err := t.RestoreProtectiveOrders()
+		item := gin.H{"trader_id": t.GetID(), "restored": restored}
+		if err != nil {
+			item["error"] = err.Error()
+			status = http.StatusMultiStatus
+		}
+		result = append(result, item)
+	}
+	c.JSON(status, gin.H{"traders": result})
+}
+
+// handleAdminTrades 已平仓交易及其备注和标签（period=7d&tag=bad fill&limit=50，按平仓时间倒序）
+func (s *Server) handleAdminTrades(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	period, err := report.ParsePeriod(c.DefaultQuery("period", "all"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	trades, err := report.ListTrades(s.traderManager.GetJournal(), traderID, period, c.Query("tag"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("查询交易失败: %v", err)})
+		return
+	}
+	if trades == nil {
+		trades = []store.Position{}
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "period": period, "trades": trades})
+}
+
+// handleAdminTradeReplay 交易回放数据包（K线、决策、订单、成交、止损止盈，interval=15m指定K线周期）
+func (s *Server) handleAdminTradeReplay(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "交易ID无效"})
+		return
+	}
+	replay, err := report.BuildTradeReplay(s.traderManager.GetJournal(), id, report.ReplayOptions{Interval: c.Query("interval")})
+	switch {
+	case errors.Is(err, store.ErrPositionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, replay)
+	}
+}
+
+// annotateRequest 交易备注请求（note省略时不修改备注，空字符串清除备注）
+type annotateRequest struct {
+	Note       *string  `json:"note"`
+	Tags       []string `json:"tags"`        // 追加的标签
+	RemoveTags []string `json:"remove_tags"` // 删除的标签
+}
+
+// handleAdminAnnotateTrade 修改交易的备注和标签（交易ID见 /trades）
+func (s *Server) handleAdminAnnotateTrade(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "交易ID无效"})
+		return
+	}
+	var req annotateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求格式无效: %v", err)})
+		return
+	}
+
+	p, err := s.traderManager.GetJournal().AnnotatePosition(id, req.Note, req.Tags, req.RemoveTags)
+	switch {
+	case errors.Is(err, store.ErrPositionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+// handleAdminPreviewOrder 下单预览：计算张数、名义价值、保证金、手续费、滑点和开仓后的敞口，不下单
+func (s *Server) handleAdminPreviewOrder(c *gin.Context) {
+	var req trader.OrderPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求格式无效: %v", err)})
+		return
+	}
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := t.PreviewOrder(req)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// isPendingConfirm 开仓决策是否因confirm_entries等待人工确认
+func isPendingConfirm(err error) bool {
+	return errors.Is(err, trader.ErrEntryPendingConfirm)
+}
+
+// handleAdminRecommendations 待确认的平仓建议和开仓建议（trader_id为空时列出所有trader的建议）
+func (s *Server) handleAdminRecommendations(c *gin.Context) {
+	traders, err := s.traderManager.SelectTraders(c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	list := []trader.CloseRecommendation{}
+	entries := []trader.EntryProposal{}
+	for _, t := range traders {
+		list = append(list, t.CloseRecommendations()...)
+		entries = append(entries, t.EntryProposals()...)
+	}
+	c.JSON(http.StatusOK, gin.H{"recommendations": list, "entries": entries})
+}
+
+// handleAdminConfirmClose 确认平仓建议并市价平仓，或确认开仓建议并按原决策开仓（建议ID共用编号）
+func (s *Server) handleAdminConfirmClose(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "建议ID无效"})
+		return
+	}
+	for _, t := range s.traderManager.GetAllTraders() {
+		rec, found, err := t.ConfirmClose(id)
+		if !found {
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "recommendation": rec})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"closed": true, "recommendation": rec})
+		return
+	}
+	for _, t := range s.traderManager.GetAllTraders() {
+		p, found, err := t.ConfirmEntry(id)
+		if !found {
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "entry": p})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"opened": true, "entry": p})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("没有待确认的建议 #%d", id)})
+}
+
+// handleAdminDismissClose 忽略平仓建议（该持仓平掉前不再生成建议）或开仓建议（过期前不再生成建议）
+func (s *Server) handleAdminDismissClose(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "建议ID无效"})
+		return
+	}
+	for _, t := range s.traderManager.GetAllTraders() {
+		if rec, found := t.DismissClose(id); found {
+			c.JSON(http.StatusOK, gin.H{"dismissed": true, "recommendation": rec})
+			return
+		}
+		if p, found := t.DismissEntry(id); found {
+			c.JSON(http.StatusOK, gin.H{"dismissed": true, "entry": p})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("没有待确认的建议 #%d", id)})
+}
+
+// handleAdminTransfer 账户间划转（from=spot&to=futures&amount=100&currency=USDT）
+// 划转作用于交易所账户而不是单个trader，trader_id为空时使用第一个trader的API密钥
+func (s *Server) handleAdminTransfer(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil || amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount必须为正数"})
+		return
+	}
+	currency := strings.ToUpper(c.DefaultQuery("currency", "USDT"))
+
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := t.Transfer(c.Query("from"), c.Query("to"), currency, amount); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "from": c.Query("from"), "to": c.Query("to"),
+		"currency": currency, "amount": amount})
+}
+
+// handleAdminLeverage 切换币种杠杆（symbol、leverage必填）；有持仓或开仓挂单时拒绝，force=true时强制切换
+func (s *Server) handleAdminLeverage(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定symbol"})
+		return
+	}
+	leverage, err := strconv.Atoi(c.Query("leverage"))
+	if err != nil || leverage <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "leverage必须为正整数"})
+		return
+	}
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	force := c.Query("force") == "true"
+	if err := t.ChangeLeverage(symbol, leverage, force); err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, trader.ErrLeverageLocked) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "symbol": symbol, "leverage": leverage, "force": force})
+}
+
+// handleAdminHedge 为持仓在相关合约上建立反向对冲（symbol必填，ratio默认1即完全按beta对冲）
+func (s *Server) handleAdminHedge(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定symbol"})
+		return
+	}
+	ratio, err := strconv.ParseFloat(c.DefaultQuery("ratio", "1"), 64)
+	if err != nil || ratio <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ratio必须为正数"})
+		return
+	}
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := t.Hedge(symbol, ratio)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "hedge": pair})
+}
+
+// handleAdminUnhedge 解除持仓的对冲（只平掉对冲腿，持仓不变）
+func (s *Server) handleAdminUnhedge(c *gin.Context) {
+	symbol := strings.ToUpper(c.Query("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定symbol"})
+		return
+	}
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := t.Unhedge(symbol)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trader_id": traderID, "unhedged": pair})
+}
+
+// handleAdminReload 重新加载配置文件，返回已应用和需要重启的变更
+func (s *Server) handleAdminReload(c *gin.Context) {
+	if s.reload == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "未启用热加载"})
+		return
+	}
+
+	applied, restart, err := s.reload()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "applied": applied})
+		return
+	}
+	if applied == nil {
+		applied = []string{}
+	}
+	if restart == nil {
+		restart = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"applied": applied, "restart_required": restart})
+}
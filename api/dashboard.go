@@ -0,0 +1,35 @@
+package api
+
+import (
+	"embed"
+	"net/http"
+	"nofx/logging"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dashboardFS 内置仪表盘（单页，数据通过管理接口获取，令牌保存在浏览器本地）
+//
+//go:embed dashboard/index.html
+var dashboardFS embed.FS
+
+// handleDashboard 内置仪表盘页面
+func (s *Server) handleDashboard(c *gin.Context) {
+	page, err := dashboardFS.ReadFile("dashboard/index.html")
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
+
+// handleAdminLogs 最近的日志（lines默认200，最多保留1000行）
+func (s *Server) handleAdminLogs(c *gin.Context) {
+	lines, err := strconv.Atoi(c.DefaultQuery("lines", "200"))
+	if err != nil || lines <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lines必须是正整数"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"lines": logging.Recent(lines)})
+}
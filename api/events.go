@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"nofx/events"
+	"nofx/store"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	eventStreamBuffer    = 256              // 每个事件流连接的缓冲（浏览器消费过慢时丢弃新事件）
+	eventStreamHeartbeat = 15 * time.Second // 心跳间隔（避免代理因空闲断开连接）
+)
+
+// handleAdminEvents 事件流（Server-Sent Events）：订单、持仓、风控、决策和通知事件
+// trader_id为空表示所有trader，types为逗号分隔的事件类型（为空表示所有类型）
+func (s *Server) handleAdminEvents(c *gin.Context) {
+	filter := events.Filter{TraderID: c.Query("trader_id")}
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter.Types = append(filter.Types, events.Type(t))
+		}
+	}
+
+	sub := s.traderManager.Events().Subscribe(filter, eventStreamBuffer)
+	defer sub.Close()
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // nginx反向代理不缓冲
+	c.Writer.WriteHeader(http.StatusOK)
+	c.Writer.Flush() // 立即返回响应头，客户端不必等到第一个事件
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-s.closing:
+			return false
+		case e := <-sub.C:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return true
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			return true
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		}
+	})
+}
+
+// handleRiskEvents 指定trader最近的风控事件（since为时间范围，默认24h；limit默认100；reason按原因代码过滤）
+func (s *Server) handleRiskEvents(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	since, err := time.ParseDuration(c.DefaultQuery("since", "24h"))
+	if err != nil || since <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since格式无效（如 24h、30m）"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit必须为正整数"})
+		return
+	}
+
+	list, err := s.traderManager.GetJournal().ListRiskEvents(traderID, c.Query("reason"), time.Now().Add(-since), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if list == nil {
+		list = []store.RiskEvent{}
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// handleTimeline 指定trader的账户事件时间线（决策、订单、成交、风控、配置变更和错误，按时间倒序）
+// since为时间范围（默认24h），from/to为起止时间（RFC3339或2006-01-02，指定from时忽略since）；
+// symbol按币种过滤，kinds为逗号分隔的类型；limit默认200
+func (s *Server) handleTimeline(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q := store.TimelineQuery{TraderID: traderID, Symbol: strings.ToUpper(c.Query("symbol"))}
+	since, err := time.ParseDuration(c.DefaultQuery("since", "24h"))
+	if err != nil || since <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since格式无效（如 24h、30m）"})
+		return
+	}
+	q.Since = time.Now().Add(-since)
+	for name, t := range map[string]*time.Time{"from": &q.Since, "to": &q.Until} {
+		if v := c.Query(name); v != "" {
+			if *t, err = parseTimeParam(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s格式无效（RFC3339或2006-01-02）", name)})
+				return
+			}
+		}
+	}
+	for _, kind := range strings.Split(c.Query("kinds"), ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			q.Kinds = append(q.Kinds, kind)
+		}
+	}
+	if q.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "200")); err != nil || q.Limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit必须为正整数"})
+		return
+	}
+
+	list, err := s.traderManager.GetJournal().ListTimeline(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if list == nil {
+		list = []store.TimelineEntry{}
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// parseTimeParam 解析RFC3339时间或日期（日期按UTC当日0点）
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// handleReasons 按原因代码统计指定trader的订单、系统撤单和风控事件（since为时间范围，默认7天）
+func (s *Server) handleReasons(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	since, err := time.ParseDuration(c.DefaultQuery("since", "168h"))
+	if err != nil || since <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since格式无效（如 24h、168h）"})
+		return
+	}
+
+	list, err := s.traderManager.GetJournal().ReasonStats(traderID, time.Now().Add(-since))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if list == nil {
+		list = []store.ReasonCount{}
+	}
+	c.JSON(http.StatusOK, list)
+}
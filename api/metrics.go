@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/apiusage"
+	"nofx/mcp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accountGauges /metrics导出的账户指标（GetAccountInfo字段 → Prometheus指标）
+var accountGauges = []struct {
+	name  string
+	field string
+	help  string
+}{
+	{"nofx_account_equity_usdt", "total_equity", "Account equity (wallet balance plus unrealized PnL) in USDT."},
+	{"nofx_account_available_usdt", "available_balance", "Available balance in USDT."},
+	{"nofx_account_margin_used_usdt", "margin_used", "Margin held by open positions in USDT."},
+	{"nofx_account_margin_utilization_pct", "margin_utilization_pct", "Margin used as a percentage of equity."},
+	{"nofx_account_notional_usdt", "total_notional", "Total notional value of open positions in USDT."},
+	{"nofx_account_effective_leverage", "effective_leverage", "Total notional value divided by equity."},
+	{"nofx_account_positions", "position_count", "Number of open positions."},
+}
+
+// handleMetrics Prometheus文本格式的账户指标（每个trader一组，获取账户信息失败的trader跳过）
+func (s *Server) handleMetrics(c *gin.Context) {
+	ids := s.traderManager.GetTraderIDs()
+	sort.Strings(ids)
+	accounts := make(map[string]map[string]interface{}, len(ids))
+	for _, id := range ids {
+		t, err := s.traderManager.GetTrader(id)
+		if err != nil {
+			continue
+		}
+		if account, err := t.GetAccountInfo(); err == nil {
+			accounts[id] = account
+		}
+	}
+
+	var sb strings.Builder
+	for _, g := range accountGauges {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, id := range ids {
+			account, ok := accounts[id]
+			if !ok {
+				continue
+			}
+			var value float64
+			switch v := account[g.field].(type) {
+			case float64:
+				value = v
+			case int:
+				value = float64(v)
+			default:
+				continue
+			}
+			fmt.Fprintf(&sb, "%s{trader=%q} %g\n", g.name, id, value)
+		}
+	}
+
+	// 交易所请求用量：私有接口按trader统计，公共接口按IP限频，所有trader共用（不带trader标签）
+	type usageSeries struct {
+		labels string
+		usage  apiusage.Usage
+	}
+	hourAgo := time.Now().Add(-time.Hour)
+	var series []usageSeries
+	for _, u := range apiusage.Public.Usage(hourAgo) {
+		series = append(series, usageSeries{fmt.Sprintf("group=%q", u.Group), u})
+	}
+	for _, id := range ids {
+		t, err := s.traderManager.GetTrader(id)
+		if err != nil {
+			continue
+		}
+		usage, _ := t.APIUsage(hourAgo)
+		for _, u := range usage {
+			series = append(series, usageSeries{fmt.Sprintf("trader=%q,group=%q", id, u.Group), u})
+		}
+	}
+	usageMetrics := []struct {
+		name, kind, help string
+		value            func(u apiusage.Usage) float64
+	}{
+		{"nofx_api_requests_total", "counter", "Exchange REST requests since start, by rate-limit group.",
+			func(u apiusage.Usage) float64 { return float64(u.TotalRequests) }},
+		{"nofx_api_rate_limited_total", "counter", "Exchange REST requests rejected with HTTP 429 since start.",
+			func(u apiusage.Usage) float64 { return float64(u.TotalRateLimited) }},
+		{"nofx_api_utilization_pct", "gauge", "Busiest rate-limit window in the last hour as a percentage of the documented limit.",
+			func(u apiusage.Usage) float64 { return u.Utilization }},
+	}
+	for _, m := range usageMetrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, entry := range series {
+			fmt.Fprintf(&sb, "%s{%s} %g\n", m.name, entry.labels, m.value(entry.usage))
+		}
+	}
+
+	// AI客户端：请求、重试、限频、失败、延迟、token和成本（按trader）
+	llm := make(map[string]mcp.Metrics, len(ids))
+	for _, id := range ids {
+		if t, err := s.traderManager.GetTrader(id); err == nil {
+			llm[id] = t.LLMMetrics()
+		}
+	}
+	llmMetrics := []struct {
+		name, kind, help string
+		value            func(m mcp.Metrics) float64
+	}{
+		{"nofx_llm_calls_total", "counter", "LLM calls since start, each including its retries.",
+			func(m mcp.Metrics) float64 { return float64(m.Calls) }},
+		{"nofx_llm_failures_total", "counter", "LLM calls that still failed after retrying.",
+			func(m mcp.Metrics) float64 { return float64(m.Failures) }},
+		{"nofx_llm_requests_total", "counter", "LLM HTTP requests since start, including retries.",
+			func(m mcp.Metrics) float64 { return float64(m.Requests) }},
+		{"nofx_llm_retries_total", "counter", "LLM requests retried after a network error, HTTP 429 or 5xx.",
+			func(m mcp.Metrics) float64 { return float64(m.Retries) }},
+		{"nofx_llm_rate_limited_total", "counter", "LLM requests rejected with HTTP 429.",
+			func(m mcp.Metrics) float64 { return float64(m.RateLimited) }},
+		{"nofx_llm_latency_seconds_sum", "counter", "Total latency of successful LLM requests in seconds.",
+			func(m mcp.Metrics) float64 { return m.LatencySeconds }},
+		{"nofx_llm_latency_seconds_count", "counter", "Number of successful LLM requests.",
+			func(m mcp.Metrics) float64 { return float64(m.LatencyCount) }},
+		{"nofx_llm_prompt_tokens_total", "counter", "Prompt tokens consumed.",
+			func(m mcp.Metrics) float64 { return float64(m.PromptTokens) }},
+		{"nofx_llm_completion_tokens_total", "counter", "Completion tokens consumed.",
+			func(m mcp.Metrics) float64 { return float64(m.CompletionTokens) }},
+		{"nofx_llm_cost_usd_total", "counter", "Estimated LLM cost in USD from built-in model prices.",
+			func(m mcp.Metrics) float64 { return m.Cost }},
+	}
+	for _, m := range llmMetrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, id := range ids {
+			if metrics, ok := llm[id]; ok {
+				fmt.Fprintf(&sb, "%s{trader=%q} %g\n", m.name, id, m.value(metrics))
+			}
+		}
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(sb.String()))
+}
@@ -1,19 +1,50 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net"
 	"net/http"
+	"nofx/config"
+	"nofx/logging"
 	"nofx/manager"
+	"nofx/report"
+	"nofx/webhook"
+	"sort"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
+// apiLog API服务器日志
+var apiLog = logging.For("api")
+
 // Server HTTP API服务器
 type Server struct {
 	router        *gin.Engine
 	traderManager *manager.TraderManager
 	port          int
+	config        func() *config.Config // 当前配置（管理接口令牌、查看配置；未设置时管理接口不可用）
+	reload        ReloadFunc            // 热加载配置（未设置时接口返回503）
+	httpServer    *http.Server
+	closing       chan struct{} // 关闭时通知事件流等长连接结束
+	closeOnce     sync.Once
+	webhooks      *webhook.Receiver // 外部信号接收（时间戳窗口和重放检查）
+}
+
+// ReloadFunc 重新加载配置，返回已应用的变更和需要重启才能生效的变更
+type ReloadFunc func() (applied, restart []string, err error)
+
+// SetConfigSource 设置当前配置的来源（热加载后返回新配置，需在Start之前调用）
+func (s *Server) SetConfigSource(fn func() *config.Config) {
+	s.config = fn
+}
+
+// SetReloadHandler 设置热加载配置的处理函数（需在Start之前调用）
+func (s *Server) SetReloadHandler(fn ReloadFunc) {
+	s.reload = fn
 }
 
 // NewServer 创建API服务器
@@ -30,10 +61,13 @@ func NewServer(traderManager *manager.TraderManager, port int) *Server {
 		router:        router,
 		traderManager: traderManager,
 		port:          port,
+		closing:       make(chan struct{}),
+		webhooks:      webhook.NewReceiver(),
 	}
 
 	// 设置路由
 	s.setupRoutes()
+	s.httpServer = &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: router}
 
 	return s
 }
@@ -43,7 +77,7 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Token")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusOK)
@@ -58,6 +92,12 @@ func corsMiddleware() gin.HandlerFunc {
 func (s *Server) setupRoutes() {
 	// 健康检查
 	s.router.Any("/health", s.handleHealth)
+	s.router.GET("/healthz", s.handleHealthz) // 存活检查（周期卡死时失败，供容器编排重启）
+	s.router.GET("/readyz", s.handleReadyz)   // 就绪检查（交易所连通性、时钟偏差、存储）
+	s.router.GET("/metrics", s.handleMetrics) // Prometheus账户指标（净值、保证金使用率、有效杠杆）
+
+	// 内置仪表盘（数据和操作均通过管理接口，需要admin.token）
+	s.router.GET("/dashboard", s.handleDashboard)
 
 	// API路由组
 	api := s.router.Group("/api")
@@ -65,6 +105,12 @@ func (s *Server) setupRoutes() {
 		// 竞赛总览
 		api.GET("/competition", s.handleCompetition)
 
+		// 跨交易所组合敞口（按标的资产汇总所有账户）
+		api.GET("/exposure", s.handleExposure)
+
+		// 多结算币种组合视图（USDT本位/BTC本位账本按指数价折算为美元）
+		api.GET("/portfolio", s.handlePortfolio)
+
 		// Trader列表
 		api.GET("/traders", s.handleTraderList)
 
@@ -76,10 +122,37 @@ func (s *Server) setupRoutes() {
 		api.GET("/decisions/latest", s.handleLatestDecisions)
 		api.GET("/statistics", s.handleStatistics)
 		api.GET("/equity-history", s.handleEquityHistory)
+		api.GET("/equity-curve", s.handleEquityCurve)
 		api.GET("/performance", s.handlePerformance)
+		api.GET("/pnl", s.handlePnL)
+		api.GET("/execution", s.handleExecution)
+		api.GET("/risk-events", s.handleRiskEvents)
+		api.GET("/reasons", s.handleReasons)
+		api.GET("/timeline", s.handleTimeline)
+
+		// 外部信号Webhook（TradingView警报）
+		api.POST("/webhook/:trader_id", s.handleWebhook)
+
+		// 热加载配置（已废弃，仅限本机访问，请改用/api/admin/reload）
+		api.POST("/reload", s.handleReload)
+
+		// 管理接口（需要admin.token）
+		s.setupAdminRoutes(api.Group("/admin", s.adminAuth()))
 	}
 }
 
+// handleReload 旧的热加载接口：不需要令牌但只允许本机调用，响应与/api/admin/reload相同并带上Deprecation头
+func (s *Server) handleReload(c *gin.Context) {
+	if ip := net.ParseIP(c.RemoteIP()); ip == nil || !ip.IsLoopback() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只允许本机调用"})
+		return
+	}
+	apiLog.Warn("POST /api/reload 已废弃，请改用 POST /api/admin/reload（需要admin.token）")
+	c.Header("Deprecation", "true")
+	c.Header("Link", `</api/admin/reload>; rel="successor-version"`)
+	s.handleAdminReload(c)
+}
+
 // handleHealth 健康检查
 func (s *Server) handleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -88,6 +161,57 @@ func (s *Server) handleHealth(c *gin.Context) {
 	})
 }
 
+// handleHealthz 存活检查：不访问交易所，只检查决策周期是否卡死和存储是否可用
+func (s *Server) handleHealthz(c *gin.Context) {
+	s.respondHealth(c, false)
+}
+
+// handleReadyz 就绪检查：探测每个trader的交易所连通性和时钟偏差
+func (s *Server) handleReadyz(c *gin.Context) {
+	s.respondHealth(c, true)
+}
+
+// respondHealth 汇总所有trader和存储的健康状态，任一检查失败返回503
+func (s *Server) respondHealth(c *gin.Context, readiness bool) {
+	ok := true
+	result := gin.H{}
+
+	if err := s.traderManager.GetJournal().Ping(); err != nil {
+		ok = false
+		result["store"] = err.Error()
+	} else {
+		result["store"] = "ok"
+	}
+
+	ids := s.traderManager.GetTraderIDs()
+	sort.Strings(ids)
+	traders := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		t, err := s.traderManager.GetTrader(id)
+		if err != nil {
+			continue
+		}
+		if readiness {
+			h := t.Readiness()
+			ok = ok && h.Ready
+			traders = append(traders, h)
+		} else {
+			h := t.Liveness()
+			ok = ok && h.Live
+			traders = append(traders, h)
+		}
+	}
+	result["traders"] = traders
+
+	status := http.StatusOK
+	result["status"] = "ok"
+	if !ok {
+		status = http.StatusServiceUnavailable
+		result["status"] = "unavailable"
+	}
+	c.JSON(status, result)
+}
+
 // getTraderFromQuery 从query参数获取trader
 func (s *Server) getTraderFromQuery(c *gin.Context) (*manager.TraderManager, string, error) {
 	traderID := c.Query("trader_id")
@@ -114,6 +238,21 @@ func (s *Server) handleCompetition(c *gin.Context) {
 	c.JSON(http.StatusOK, comparison)
 }
 
+// handleExposure 跨交易所组合敞口：按标的资产汇总所有账户的多空和净敞口
+func (s *Server) handleExposure(c *gin.Context) {
+	book, errs := s.traderManager.NetExposure()
+	c.JSON(http.StatusOK, gin.H{
+		"book":   book,
+		"limits": s.traderManager.BookLimits(),
+		"errors": errs,
+	})
+}
+
+// handlePortfolio 多结算币种组合视图：各账户USDT本位/BTC本位账本的权益、持仓和未实现盈亏按指数价折算为美元后汇总
+func (s *Server) handlePortfolio(c *gin.Context) {
+	c.JSON(http.StatusOK, s.traderManager.Portfolio())
+}
+
 // handleTraderList trader列表
 func (s *Server) handleTraderList(c *gin.Context) {
 	traders := s.traderManager.GetAllTraders()
@@ -162,22 +301,17 @@ func (s *Server) handleAccount(c *gin.Context) {
 		return
 	}
 
-	log.Printf("📊 收到账户信息请求 [%s]", trader.GetName())
 	account, err := trader.GetAccountInfo()
 	if err != nil {
-		log.Printf("❌ 获取账户信息失败 [%s]: %v", trader.GetName(), err)
+		apiLog.Error("获取账户信息失败", "trader", traderID, "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("获取账户信息失败: %v", err),
 		})
 		return
 	}
 
-	log.Printf("✓ 返回账户信息 [%s]: 净值=%.2f, 可用=%.2f, 盈亏=%.2f (%.2f%%)",
-		trader.GetName(),
-		account["total_equity"],
-		account["available_balance"],
-		account["total_pnl"],
-		account["total_pnl_pct"])
+	apiLog.Debug("返回账户信息", "trader", traderID, "equity", account["total_equity"],
+		"available", account["available_balance"], "pnl", account["total_pnl"], "pnl_pct", account["total_pnl_pct"])
 	c.JSON(http.StatusOK, account)
 }
 
@@ -401,23 +535,198 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, performance)
 }
 
+// handleEquityCurve 交易日志中的定时净值快照（?trader_id=xxx&period=7d）
+func (s *Server) handleEquityCurve(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	period, err := report.ParsePeriod(c.DefaultQuery("period", "7d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	snapshots, err := s.traderManager.GetJournal().EquityHistory(traderID, period.Since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取净值曲线失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":       traderID,
+		"period":          period,
+		"high_water_mark": trader.GetEquityHighWater(),
+		"snapshots":       snapshots,
+	})
+}
+
+// handlePnL 盈亏报表（?trader_id=xxx&period=7d）
+func (s *Server) handlePnL(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	period, err := report.ParsePeriod(c.DefaultQuery("period", "all"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 当前持仓的未实现盈亏
+	unrealized := make(map[string]float64)
+	if positions, err := trader.GetPositions(); err == nil {
+		for _, pos := range positions {
+			symbol, _ := pos["symbol"].(string)
+			pnl, _ := pos["unrealized_pnl"].(float64)
+			unrealized[symbol] += pnl
+		}
+	}
+
+	pnl, err := report.ReportPnL(s.traderManager.GetJournal(), traderID, period, unrealized)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("生成盈亏报表失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pnl)
+}
+
+// handleExecution 执行质量报表（?trader_id=xxx&period=7d）
+func (s *Server) handleExecution(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	period, err := report.ParsePeriod(c.DefaultQuery("period", "all"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	execution, err := report.ReportExecution(s.traderManager.GetJournal(), traderID, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("生成执行质量报表失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, execution)
+}
+
+// handleWebhook 接收外部信号（TradingView警报），经风控后执行
+func (s *Server) handleWebhook(c *gin.Context) {
+	trader, err := s.traderManager.GetTrader(c.Param("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg := trader.GetWebhookConfig()
+	if !cfg.Enabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "该trader未启用webhook"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64*1024))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("读取请求失败: %v", err)})
+		return
+	}
+
+	d, err := s.webhooks.Parse(c.Param("trader_id"), cfg, body, c.GetHeader("X-Signature"))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, webhook.ErrUnauthorized):
+			status = http.StatusUnauthorized
+		case errors.Is(err, webhook.ErrReplayed):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := trader.ExecuteExternalDecision(d, "tradingview"); err != nil {
+		if isPendingConfirm(err) {
+			c.JSON(http.StatusAccepted, gin.H{"status": "pending_confirm", "decision": d})
+			return
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "decision": d})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "executed", "decision": d})
+}
+
+// apiRoutes 启动时输出的接口列表（debug级别）
+var apiRoutes = []string{
+	"GET /api/competition - 竞赛总览（对比所有trader）",
+	"GET /api/exposure - 跨交易所组合净敞口（按标的资产）",
+	"GET /api/portfolio - 多结算币种组合视图（按指数价折算为美元）",
+	"GET /api/traders - Trader列表",
+	"GET /api/status?trader_id=xxx - 指定trader的系统状态",
+	"GET /api/account?trader_id=xxx - 指定trader的账户信息",
+	"GET /api/positions?trader_id=xxx - 指定trader的持仓列表",
+	"GET /api/decisions?trader_id=xxx - 指定trader的决策日志",
+	"GET /api/decisions/latest?trader_id=xxx - 指定trader的最新决策",
+	"GET /api/statistics?trader_id=xxx - 指定trader的统计信息",
+	"GET /api/equity-history?trader_id=xxx - 指定trader的收益率历史数据",
+	"GET /api/performance?trader_id=xxx - 指定trader的AI学习表现分析",
+	"GET /api/equity-curve?trader_id=xxx&period=7d - 指定trader的定时净值快照",
+	"GET /api/pnl?trader_id=xxx&period=7d - 指定trader的盈亏报表",
+	"GET /api/execution?trader_id=xxx&period=7d - 指定trader的执行质量报表（滑点）",
+	"GET /api/risk-events?trader_id=xxx - 指定trader最近的风控事件",
+	"GET /api/reasons?trader_id=xxx&since=168h - 指定trader按原因代码统计的下单、撤单和风控事件",
+	"GET /api/timeline?trader_id=xxx&since=24h&symbol=BTCUSDT - 指定trader的账户事件时间线",
+	"POST /api/webhook/:trader_id - 外部信号Webhook（TradingView警报）",
+	"/api/admin/* - 管理接口（Authorization: Bearer <admin.token>）",
+	"POST /api/reload - 热加载配置（已废弃，仅限本机，请改用/api/admin/reload）",
+	"GET /dashboard - 内置仪表盘（持仓、净值曲线、AI决策、下单预览、时间线、日志、暂停/平仓）",
+	"GET /health - 健康检查",
+	"GET /healthz - 存活检查（周期卡死、存储）",
+	"GET /readyz - 就绪检查（交易所连通性、时钟偏差）",
+}
+
 // Start 启动服务器
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%d", s.port)
-	log.Printf("🌐 API服务器启动在 http://localhost%s", addr)
-	log.Printf("📊 API文档:")
-	log.Printf("  • GET  /api/competition      - 竞赛总览（对比所有trader）")
-	log.Printf("  • GET  /api/traders          - Trader列表")
-	log.Printf("  • GET  /api/status?trader_id=xxx     - 指定trader的系统状态")
-	log.Printf("  • GET  /api/account?trader_id=xxx    - 指定trader的账户信息")
-	log.Printf("  • GET  /api/positions?trader_id=xxx  - 指定trader的持仓列表")
-	log.Printf("  • GET  /api/decisions?trader_id=xxx  - 指定trader的决策日志")
-	log.Printf("  • GET  /api/decisions/latest?trader_id=xxx - 指定trader的最新决策")
-	log.Printf("  • GET  /api/statistics?trader_id=xxx - 指定trader的统计信息")
-	log.Printf("  • GET  /api/equity-history?trader_id=xxx - 指定trader的收益率历史数据")
-	log.Printf("  • GET  /api/performance?trader_id=xxx - 指定trader的AI学习表现分析")
-	log.Printf("  • GET  /health               - 健康检查")
-	log.Println()
-
-	return s.router.Run(addr)
+	addr := s.httpServer.Addr
+	apiLog.Info("API服务器启动", "url", "http://localhost"+addr)
+	for _, route := range apiRoutes {
+		apiLog.Debug("API接口", "route", route)
+	}
+
+	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 停止接受新连接，等待进行中的请求完成（受ctx限制）
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.closing) })
+	return s.httpServer.Shutdown(ctx)
 }
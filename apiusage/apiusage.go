@@ -0,0 +1,269 @@
+// Package apiusage 统计交易所REST请求量（按接口组、按小时），与Gate文档的频率限制对比，
+// 用于在触发429之前看到剩余余量（币种数量增加时行情请求随之增长）
+package apiusage
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Group Gate文档的频率限制分组（同组请求共用一个额度）
+type Group struct {
+	Name   string
+	Limit  int           // 每个窗口允许的请求数
+	Window time.Duration // 限制窗口
+}
+
+// Gate API v4文档的频率限制（交易所按单个接口计数，这里按组合计，估算偏保守）
+var (
+	GroupPublic        = Group{"public", 200, 10 * time.Second}    // 公共行情接口（按IP）
+	GroupFuturesOrder  = Group{"futures_order", 100, time.Second}  // 合约下单/改单（按UID）
+	GroupFuturesCancel = Group{"futures_cancel", 200, time.Second} // 合约撤单（按UID）
+	GroupSpotOrder     = Group{"spot_order", 10, time.Second}      // 现货下单/改单（按UID）
+	GroupSpotCancel    = Group{"spot_cancel", 200, time.Second}    // 现货撤单（按UID）
+	GroupPrivate       = Group{"private", 150, 10 * time.Second}   // 其他私有接口（账户、持仓、订单查询等，按UID）
+	groups             = []Group{GroupPublic, GroupFuturesOrder, GroupFuturesCancel, GroupSpotOrder, GroupSpotCancel, GroupPrivate}
+)
+
+// retention 按小时统计的保留时长（覆盖周报周期）
+const retention = 8 * 24 * time.Hour
+
+// Classify 按请求方法和路径判断所属分组（signed为是否带API密钥签名）
+func Classify(method, path string, signed bool) Group {
+	if !signed {
+		return GroupPublic
+	}
+	path = strings.TrimPrefix(path, "/api/v4")
+	switch {
+	case strings.HasPrefix(path, "/futures/") || strings.HasPrefix(path, "/delivery/"):
+		if !isOrderPath(path) {
+			return GroupPrivate
+		}
+		if method == http.MethodDelete || strings.Contains(path, "cancel") {
+			return GroupFuturesCancel
+		}
+		if method != http.MethodGet {
+			return GroupFuturesOrder
+		}
+	case strings.HasPrefix(path, "/spot/"):
+		if !isOrderPath(path) {
+			return GroupPrivate
+		}
+		if method == http.MethodDelete || strings.Contains(path, "cancel") {
+			return GroupSpotCancel
+		}
+		if method != http.MethodGet {
+			return GroupSpotOrder
+		}
+	}
+	return GroupPrivate
+}
+
+// isOrderPath 是否为下单/撤单类接口（普通订单、批量订单、条件单）
+func isOrderPath(path string) bool {
+	return strings.Contains(path, "/orders") || strings.Contains(path, "_order")
+}
+
+// Usage 一个分组在统计周期内的用量
+type Usage struct {
+	Group       string        `json:"group"`
+	Limit       int           `json:"limit"`        // 文档限制（每窗口请求数）
+	Window      time.Duration `json:"window"`       // 限制窗口
+	Requests    int           `json:"requests"`     // 周期内请求数
+	RateLimited int           `json:"rate_limited"` // 周期内返回429的次数
+	Peak        int           `json:"peak"`         // 周期内单个限制窗口的最大请求数
+	Utilization float64       `json:"utilization"`  // 峰值占文档限制的百分比
+
+	// 交易所响应头报告的最近一次剩余额度（X-Gate-RateLimit-*，接口不返回时为0）
+	ReportedLimit  int `json:"reported_limit,omitempty"`
+	ReportedRemain int `json:"reported_remain,omitempty"`
+
+	// 进程启动以来的累计值（Prometheus计数器）
+	TotalRequests    int64 `json:"total_requests"`
+	TotalRateLimited int64 `json:"total_rate_limited"`
+}
+
+// hourBucket 一个小时的统计
+type hourBucket struct {
+	requests    int
+	rateLimited int
+	peak        int
+}
+
+// groupUsage 一个分组的统计状态
+type groupUsage struct {
+	group            Group
+	hours            map[int64]*hourBucket // 小时起点（Unix秒）→ 统计
+	windowStart      time.Time
+	windowCount      int
+	reportedLimit    int
+	reportedRemain   int
+	totalRequests    int64
+	totalRateLimited int64
+}
+
+// Tracker 一组请求的用量统计（并发安全）
+type Tracker struct {
+	mu     sync.Mutex
+	groups map[string]*groupUsage
+	now    func() time.Time
+}
+
+// NewTracker 创建用量统计
+func NewTracker() *Tracker {
+	return &Tracker{groups: make(map[string]*groupUsage), now: time.Now}
+}
+
+// Public 所有公共接口请求共用的统计（Gate公共接口按IP限频，同一进程内所有trader和行情模块共享额度）
+var Public = NewTracker()
+
+// record 记录一次请求
+func (t *Tracker) record(group Group) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	g := t.group(group)
+	if now.Sub(g.windowStart) >= group.Window {
+		g.windowStart = now.Truncate(group.Window)
+		g.windowCount = 0
+	}
+	g.windowCount++
+	g.totalRequests++
+
+	b := g.bucket(now)
+	b.requests++
+	if g.windowCount > b.peak {
+		b.peak = g.windowCount
+	}
+}
+
+// response 记录响应（429次数和响应头报告的剩余额度）
+func (t *Tracker) response(group Group, resp *http.Response) {
+	limit, _ := strconv.Atoi(resp.Header.Get("X-Gate-RateLimit-Limit"))
+	remain, remainErr := strconv.Atoi(resp.Header.Get("X-Gate-RateLimit-Requests-Remain"))
+	if resp.StatusCode != http.StatusTooManyRequests && limit == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	g := t.group(group)
+	if limit > 0 && remainErr == nil {
+		g.reportedLimit, g.reportedRemain = limit, remain
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		g.totalRateLimited++
+		g.bucket(t.now()).rateLimited++
+	}
+}
+
+func (t *Tracker) group(group Group) *groupUsage {
+	g, ok := t.groups[group.Name]
+	if !ok {
+		g = &groupUsage{group: group, hours: make(map[int64]*hourBucket)}
+		t.groups[group.Name] = g
+	}
+	return g
+}
+
+// bucket 当前小时的统计（顺便清理超过保留时长的小时）
+func (g *groupUsage) bucket(now time.Time) *hourBucket {
+	hour := now.Truncate(time.Hour).Unix()
+	b, ok := g.hours[hour]
+	if !ok {
+		b = &hourBucket{}
+		g.hours[hour] = b
+		cutoff := now.Add(-retention).Unix()
+		for h := range g.hours {
+			if h < cutoff {
+				delete(g.hours, h)
+			}
+		}
+	}
+	return b
+}
+
+// Usage since之后（按整小时）各分组的用量，按文档分组顺序排列，没有请求的分组不返回
+func (t *Tracker) Usage(since time.Time) []Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	from := since.Truncate(time.Hour).Unix()
+	var result []Usage
+	for _, group := range groups {
+		g, ok := t.groups[group.Name]
+		if !ok {
+			continue
+		}
+		u := Usage{Group: group.Name, Limit: group.Limit, Window: group.Window,
+			ReportedLimit: g.reportedLimit, ReportedRemain: g.reportedRemain,
+			TotalRequests: g.totalRequests, TotalRateLimited: g.totalRateLimited}
+		for hour, b := range g.hours {
+			if hour < from {
+				continue
+			}
+			u.Requests += b.requests
+			u.RateLimited += b.rateLimited
+			if b.peak > u.Peak {
+				u.Peak = b.peak
+			}
+		}
+		u.Utilization = float64(u.Peak) / float64(group.Limit) * 100
+		result = append(result, u)
+	}
+	return result
+}
+
+// Transport 统计经过的请求（签名请求计入tracker，公共请求计入Public）
+type Transport struct {
+	Base    http.RoundTripper
+	Tracker *Tracker // 私有接口的统计（为nil时不统计私有请求）
+}
+
+// NewTransport 创建统计请求用量的Transport（base为nil时使用http.DefaultTransport）
+func NewTransport(base http.RoundTripper, tracker *Tracker) *Transport {
+	return &Transport{Base: base, Tracker: tracker}
+}
+
+// RoundTrip 记录请求和响应后原样返回
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	group := Classify(req.Method, req.URL.Path, req.Header.Get("KEY") != "")
+	tracker := t.Tracker
+	if group == GroupPublic {
+		tracker = Public
+	}
+	if tracker == nil {
+		return base.RoundTrip(req)
+	}
+	tracker.record(group)
+	resp, err := base.RoundTrip(req)
+	if err == nil {
+		tracker.response(group, resp)
+	}
+	return resp, err
+}
+
+// Format 格式化用量（用于汇总报告）
+func Format(usage []Usage) string {
+	sorted := append([]Usage(nil), usage...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Utilization > sorted[j].Utilization })
+	var sb strings.Builder
+	for i, u := range sorted {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %d次 | 峰值 %d/%s（%.0f%%）", u.Group, u.Requests, u.Peak, u.Window, u.Utilization))
+		if u.RateLimited > 0 {
+			sb.WriteString(fmt.Sprintf(" | 429: %d次", u.RateLimited))
+		}
+	}
+	return sb.String()
+}
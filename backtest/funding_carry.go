@@ -0,0 +1,365 @@
+// Package backtest 策略回测：用历史数据和模拟账户驱动真实的策略代码，并支持参数网格扫描
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"nofx/clock"
+	"nofx/market"
+	"nofx/risk"
+	"nofx/store"
+	"nofx/strategy"
+	"nofx/trader"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultHistoryDays entry_percentile使用的历史分布窗口（天，与funding_history默认一致）
+const defaultHistoryDays = 90
+
+// FundingCarryOptions 资金费率套利回测的成本和数据参数
+type FundingCarryOptions struct {
+	PerpFeeRate float64                       // 永续吃单手续费率（如0.0005=0.05%）
+	SpotFeeRate float64                       // 现货吃单手续费率（按基础币扣除，与Gate.io一致）
+	HistoryDays int                           // entry_percentile的历史分布窗口（天，默认90）
+	Markets     map[string]FundingCarryMarket // symbol -> 合约规格和历史价格（缺少的币种按单位价格1 USDT、1张=1个币成交）
+}
+
+// FundingCarryMarket 回测币种的真实合约规格和历史价格
+// 与模拟交易一样，永续按合约乘数、价格精度和最小下单张数成交，按维持保证金档位检查强平
+type FundingCarryMarket struct {
+	Spec   trader.ContractSpec
+	Tiers  []risk.MaintenanceTier
+	Prices []market.Kline // 按时间升序，成交价取当时最近一根已收盘K线的收盘价
+}
+
+// FundingCarryResult 一组参数的回测结果
+type FundingCarryResult struct {
+	Config       strategy.FundingHarvestConfig `json:"config"`
+	Pairs        int                           `json:"pairs"`        // 建立的对冲次数
+	Open         int                           `json:"open"`         // 回测结束时仍持有的对冲（平仓手续费未计入）
+	Funding      float64                       `json:"funding"`      // 永续空头收取的资金费（USDT）
+	Fees         float64                       `json:"fees"`         // 两条腿的手续费（USDT）
+	PricePnL     float64                       `json:"price_pnl"`    // 两条腿的价格盈亏（合约取整和未对冲的现货零头、强平造成的残差，含未平仓部分）
+	Liquidations int                           `json:"liquidations"` // 永续空头被强平的次数
+	NetPnL       float64                       `json:"net_pnl"`      // 资金费 - 手续费 + 价格盈亏
+	ReturnPct    float64                       `json:"return_pct"`   // 净盈亏 / 最大占用资金（notional_usd × max_positions，%）
+	MaxDrawdown  float64                       `json:"max_drawdown"` // 累计净盈亏的最大回撤（USDT）
+	ExposurePct  float64                       `json:"exposure_pct"` // 持有对冲的结算周期占比（%）
+	Settlements  int                           `json:"settlements"`  // 回放的结算次数
+}
+
+// RunFundingCarry 按历史资金费率回放资金费率套利策略
+// 每次结算先检查永续空头是否被强平并给持有的空头计入实际费率，再把模拟时钟推进到结算时间执行一次Evaluate；
+// 成交价取结算时的历史价格，永续按真实合约规格取整，两条腿的价格变动大体抵消，盈亏主要来自资金费和手续费
+func RunFundingCarry(config strategy.FundingHarvestConfig, rates map[string][]store.FundingRate, opts FundingCarryOptions) (*FundingCarryResult, error) {
+	config.Enabled = true
+	if len(config.Symbols) == 0 {
+		for symbol := range rates {
+			config.Symbols = append(config.Symbols, symbol)
+		}
+		sort.Strings(config.Symbols)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.HistoryDays <= 0 {
+		opts.HistoryDays = defaultHistoryDays
+	}
+
+	history := make(map[string][]store.FundingRate, len(config.Symbols))
+	settlements := make(map[time.Time][]store.FundingRate)
+	for _, symbol := range config.Symbols {
+		symbol = strings.ToUpper(symbol)
+		points := append([]store.FundingRate(nil), rates[symbol]...)
+		sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+		history[symbol] = points
+		for _, r := range points {
+			r.Symbol = symbol
+			settlements[r.Time] = append(settlements[r.Time], r)
+		}
+	}
+	if len(settlements) == 0 {
+		return nil, fmt.Errorf("没有历史资金费率: %v", config.Symbols)
+	}
+	times := make([]time.Time, 0, len(settlements))
+	for t := range settlements {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	account := newSimAccount(opts, times[0])
+	latest := make(map[string]float64, len(history))
+	clk := clock.NewManual(times[0])
+
+	funding := func(symbol string) (float64, error) {
+		rate, ok := latest[symbol]
+		if !ok {
+			return 0, fmt.Errorf("%s尚无结算记录", symbol)
+		}
+		return rate, nil
+	}
+	harvester := strategy.NewFundingHarvester(config, account, account, funding)
+	harvester.SetClock(clk)
+	harvester.SetPercentileSource(func(symbol string, rate float64) (float64, bool) {
+		now := clk.Now()
+		since := now.AddDate(0, 0, -opts.HistoryDays)
+		var values []float64
+		for _, r := range history[symbol] {
+			if !r.Time.Before(now) {
+				break
+			}
+			if !r.Time.Before(since) {
+				values = append(values, r.Rate)
+			}
+		}
+		stats := market.ComputeFundingStats(values, rate, opts.HistoryDays)
+		if stats == nil {
+			return 0, false
+		}
+		return stats.Percentile, true
+	})
+
+	result := &FundingCarryResult{Config: config, Settlements: len(times)}
+	var peak float64
+	exposed := 0
+	for _, t := range times {
+		account.advance(t)
+		for _, r := range settlements[t] {
+			account.settle(r.Symbol, r.Rate)
+			latest[r.Symbol] = r.Rate
+		}
+		clk.Set(t)
+		harvester.Evaluate()
+
+		if account.holding() {
+			exposed++
+		}
+		pnl := account.pnl()
+		peak = math.Max(peak, pnl)
+		result.MaxDrawdown = math.Max(result.MaxDrawdown, peak-pnl)
+	}
+
+	result.Pairs = account.pairs
+	result.Open = len(harvester.GetPositions())
+	result.Funding = account.funding
+	result.Fees = account.fees
+	result.PricePnL = account.pricePnL()
+	result.Liquidations = account.liquidations
+	result.NetPnL = account.pnl()
+	result.ReturnPct = result.NetPnL / (config.NotionalUSD * float64(config.MaxPositions)) * 100
+	result.ExposurePct = float64(exposed) / float64(len(times)) * 100
+	return result, nil
+}
+
+// simAccount 回测用的模拟账户，同时实现永续（strategy.Executor）和现货（strategy.SpotExecutor）执行
+// 数量单位与实盘一致：永续为合约张数，现货为基础币数量
+type simAccount struct {
+	opts         FundingCarryOptions
+	now          time.Time
+	shorts       map[string]*simShort // symbol -> 永续空头
+	spot         map[string]float64   // symbol -> 现货数量
+	spotCost     map[string]float64   // symbol -> 现货持仓成本（USDT，不含手续费）
+	funding      float64
+	fees         float64
+	realized     float64 // 已实现的价格盈亏（USDT）
+	pairs        int
+	liquidations int
+}
+
+// simShort 模拟的永续空头
+type simShort struct {
+	contracts float64
+	entry     float64
+	leverage  int
+}
+
+func newSimAccount(opts FundingCarryOptions, start time.Time) *simAccount {
+	return &simAccount{
+		opts:     opts,
+		now:      start,
+		shorts:   make(map[string]*simShort),
+		spot:     make(map[string]float64),
+		spotCost: make(map[string]float64),
+	}
+}
+
+// market 币种的合约规格和历史价格（未提供时按1张=1个币）
+func (a *simAccount) market(symbol string) FundingCarryMarket {
+	m, ok := a.opts.Markets[symbol]
+	if !ok || m.Spec.Multiplier <= 0 {
+		m.Spec.Multiplier = 1
+	}
+	return m
+}
+
+// price 当前回放时间的价格（最近一根已收盘K线的收盘价，早于第一根K线时取其开盘价，没有价格时为1）
+func (a *simAccount) price(symbol string) float64 {
+	bars := a.market(symbol).Prices
+	if len(bars) == 0 {
+		return 1
+	}
+	now := a.now.UnixMilli()
+	i := sort.Search(len(bars), func(i int) bool { return bars[i].CloseTime >= now })
+	if i == 0 {
+		return bars[0].Open
+	}
+	return bars[i-1].Close
+}
+
+// high (from, to]期间K线的最高价（没有价格时为0）
+func (a *simAccount) high(symbol string, from, to time.Time) float64 {
+	high := 0.0
+	for _, bar := range a.market(symbol).Prices {
+		if bar.CloseTime <= from.UnixMilli() {
+			continue
+		}
+		if bar.OpenTime > to.UnixMilli() {
+			break
+		}
+		high = math.Max(high, bar.High)
+	}
+	return high
+}
+
+// advance 推进回放时间，期间最高价触及强平价的永续空头按逐仓强平（损失全部保证金）
+func (a *simAccount) advance(t time.Time) {
+	for symbol, short := range a.shorts {
+		m := a.market(symbol)
+		coins := m.Spec.BaseQuantity(short.contracts)
+		liquidation := risk.LiquidationPrice("short", short.entry, coins, short.leverage, risk.MarginIsolated, 0, m.Tiers)
+		if liquidation <= 0 || a.high(symbol, a.now, t) < liquidation {
+			continue
+		}
+		a.realized -= coins * short.entry / float64(short.leverage)
+		a.liquidations++
+		delete(a.shorts, symbol)
+	}
+	a.now = t
+}
+
+// settle 按结算费率给永续空头计入资金费（费率为正时空头收取，按结算时的仓位价值计算）
+func (a *simAccount) settle(symbol string, rate float64) {
+	if short, ok := a.shorts[symbol]; ok {
+		a.funding += a.market(symbol).Spec.BaseQuantity(short.contracts) * a.price(symbol) * rate
+	}
+}
+
+// holding 是否持有任何永续空头
+func (a *simAccount) holding() bool {
+	return len(a.shorts) > 0
+}
+
+// pricePnL 两条腿的价格盈亏（已实现 + 按当前价格计算的未实现）
+func (a *simAccount) pricePnL() float64 {
+	pnl := a.realized
+	for symbol, short := range a.shorts {
+		pnl += a.market(symbol).Spec.BaseQuantity(short.contracts) * (short.entry - a.price(symbol))
+	}
+	for symbol, quantity := range a.spot {
+		pnl += quantity*a.price(symbol) - a.spotCost[symbol]
+	}
+	return pnl
+}
+
+// pnl 累计净盈亏
+func (a *simAccount) pnl() float64 {
+	return a.funding - a.fees + a.pricePnL()
+}
+
+func (a *simAccount) GetPositions() ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (a *simAccount) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("回测账户不支持开多")
+}
+
+func (a *simAccount) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	spec := a.market(symbol).Spec
+	contracts, err := spec.OrderSize(quantity)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", symbol, err)
+	}
+	if leverage < 1 {
+		leverage = 1
+	}
+	price := spec.RoundPrice(a.price(symbol))
+	short, ok := a.shorts[symbol]
+	if !ok {
+		short = &simShort{leverage: leverage}
+		a.shorts[symbol] = short
+	}
+	short.entry = (short.entry*short.contracts + price*contracts) / (short.contracts + contracts)
+	short.contracts += contracts
+	a.fees += spec.BaseQuantity(contracts) * price * a.opts.PerpFeeRate
+	a.pairs++
+	return map[string]interface{}{"filledQty": contracts, "avgPrice": price}, nil
+}
+
+func (a *simAccount) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("回测账户不支持平多")
+}
+
+func (a *simAccount) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	spec := a.market(symbol).Spec
+	price := spec.RoundPrice(a.price(symbol))
+	short, ok := a.shorts[symbol]
+	if !ok {
+		// 已被强平，策略按永续腿已平处理
+		return map[string]interface{}{"filledQty": quantity, "avgPrice": price}, nil
+	}
+	if quantity <= 0 || quantity > short.contracts {
+		quantity = short.contracts
+	}
+	quantity = math.Floor(quantity + 1e-9)
+	coins := spec.BaseQuantity(quantity)
+	a.realized += coins * (short.entry - price)
+	a.fees += coins * price * a.opts.PerpFeeRate
+	if short.contracts -= quantity; short.contracts <= 0 {
+		delete(a.shorts, symbol)
+	}
+	return map[string]interface{}{"filledQty": quantity, "avgPrice": price}, nil
+}
+
+func (a *simAccount) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return nil
+}
+
+func (a *simAccount) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return nil
+}
+
+func (a *simAccount) GetSpotPrice(symbol string) (float64, error) {
+	return a.price(symbol), nil
+}
+
+func (a *simAccount) SpotBuy(symbol string, quoteAmount float64) (float64, error) {
+	price := a.price(symbol)
+	quantity := quoteAmount / price
+	fee := quantity * a.opts.SpotFeeRate
+	a.fees += fee * price
+	a.spot[symbol] += quantity - fee
+	a.spotCost[symbol] += (quantity - fee) * price
+	return quantity - fee, nil
+}
+
+func (a *simAccount) SpotSell(symbol string, quantity float64) error {
+	held := a.spot[symbol]
+	quantity = math.Min(quantity, held)
+	if quantity <= 0 {
+		return nil
+	}
+	price := a.price(symbol)
+	cost := a.spotCost[symbol] * quantity / held
+	a.realized += quantity*price - cost
+	a.fees += quantity * price * a.opts.SpotFeeRate
+	a.spot[symbol] = held - quantity
+	a.spotCost[symbol] -= cost
+	return nil
+}
+
+func (a *simAccount) GetContractMultiplier(symbol string) (float64, error) {
+	return a.market(symbol).Spec.Multiplier, nil
+}
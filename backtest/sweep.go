@@ -0,0 +1,166 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"nofx/store"
+	"nofx/strategy"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FundingCarryGrid 资金费率套利的参数网格（回测全部取值组合，为空的维度沿用基础配置）
+type FundingCarryGrid struct {
+	EntryFundingRates []float64
+	ExitFundingRates  []float64
+	NotionalUSD       []float64
+	MaxPositions      []int
+	EntryPercentiles  []float64
+}
+
+// Configs 展开网格为参数组合
+func (g FundingCarryGrid) Configs(base strategy.FundingHarvestConfig) []strategy.FundingHarvestConfig {
+	configs := []strategy.FundingHarvestConfig{base}
+	expand := func(n int, apply func(c *strategy.FundingHarvestConfig, i int)) {
+		if n == 0 {
+			return
+		}
+		next := make([]strategy.FundingHarvestConfig, 0, len(configs)*n)
+		for _, c := range configs {
+			for i := 0; i < n; i++ {
+				c := c
+				apply(&c, i)
+				next = append(next, c)
+			}
+		}
+		configs = next
+	}
+	expand(len(g.EntryFundingRates), func(c *strategy.FundingHarvestConfig, i int) { c.EntryFundingRate = g.EntryFundingRates[i] })
+	expand(len(g.ExitFundingRates), func(c *strategy.FundingHarvestConfig, i int) { c.ExitFundingRate = g.ExitFundingRates[i] })
+	expand(len(g.NotionalUSD), func(c *strategy.FundingHarvestConfig, i int) { c.NotionalUSD = g.NotionalUSD[i] })
+	expand(len(g.MaxPositions), func(c *strategy.FundingHarvestConfig, i int) { c.MaxPositions = g.MaxPositions[i] })
+	expand(len(g.EntryPercentiles), func(c *strategy.FundingHarvestConfig, i int) { c.EntryPercentile = g.EntryPercentiles[i] })
+	return configs
+}
+
+// SweepFundingCarry 用workers个goroutine并行回测网格中的每组参数（workers<=0时按CPU数），
+// 结果按净盈亏从高到低排序，净盈亏相同时回撤小的在前；参数无效的组合（如exit≥entry）跳过并计入skipped
+func SweepFundingCarry(base strategy.FundingHarvestConfig, grid FundingCarryGrid, rates map[string][]store.FundingRate,
+	opts FundingCarryOptions, workers int) (results []FundingCarryResult, skipped int) {
+	configs := grid.Configs(base)
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	runs := make([]*FundingCarryResult, len(configs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := RunFundingCarry(configs[i], rates, opts)
+				if err != nil {
+					continue
+				}
+				runs[i] = result
+			}
+		}()
+	}
+	for i := range configs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, r := range runs {
+		if r == nil {
+			skipped++
+			continue
+		}
+		results = append(results, *r)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].NetPnL != results[j].NetPnL {
+			return results[i].NetPnL > results[j].NetPnL
+		}
+		return results[i].MaxDrawdown < results[j].MaxDrawdown
+	})
+	return results, skipped
+}
+
+// sweepColumns 排名表的列
+var sweepColumns = []string{"rank", "entry_rate_pct", "exit_rate_pct", "notional_usd", "max_positions", "entry_percentile",
+	"pairs", "open", "funding", "fees", "price_pnl", "liquidations", "net_pnl", "return_pct", "max_drawdown", "exposure_pct"}
+
+// sweepRow 一行排名表（数值不带单位，命令行表格和CSV共用）
+func sweepRow(rank int, r FundingCarryResult) []string {
+	f := func(v float64, prec int) string { return strconv.FormatFloat(v, 'f', prec, 64) }
+	return []string{
+		strconv.Itoa(rank),
+		f(r.Config.EntryFundingRate*100, 4),
+		f(r.Config.ExitFundingRate*100, 4),
+		f(r.Config.NotionalUSD, 0),
+		strconv.Itoa(r.Config.MaxPositions),
+		f(r.Config.EntryPercentile, 0),
+		strconv.Itoa(r.Pairs),
+		strconv.Itoa(r.Open),
+		f(r.Funding, 2),
+		f(r.Fees, 2),
+		f(r.PricePnL, 2),
+		strconv.Itoa(r.Liquidations),
+		f(r.NetPnL, 2),
+		f(r.ReturnPct, 2),
+		f(r.MaxDrawdown, 2),
+		f(r.ExposurePct, 1),
+	}
+}
+
+// FormatSweep 格式化参数扫描的排名表（命令行输出，top<=0时输出全部）
+func FormatSweep(results []FundingCarryResult, top int) string {
+	if top <= 0 || top > len(results) {
+		top = len(results)
+	}
+	rows := [][]string{sweepColumns}
+	for i, r := range results[:top] {
+		rows = append(rows, sweepRow(i+1, r))
+	}
+
+	widths := make([]int, len(sweepColumns))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				sb.WriteString("  ")
+			}
+			sb.WriteString(fmt.Sprintf("%*s", widths[i], cell))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// WriteSweepCSV 将完整的排名表写为CSV
+func WriteSweepCSV(w io.Writer, results []FundingCarryResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(sweepColumns); err != nil {
+		return err
+	}
+	for i, r := range results {
+		if err := cw.Write(sweepRow(i+1, r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,654 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"nofx/config"
+	"nofx/logger"
+	"nofx/logging"
+	"nofx/report"
+	"nofx/secrets"
+	"nofx/store"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cliLog 命令行子命令日志
+var cliLog = logging.For("cli")
+
+// runCLI 处理命令行子命令，返回true表示已处理（不启动交易系统）
+//
+//	nofx pnl <trader_id> [period] [config.json]            输出盈亏报表（period: today/24h/7d/30d/all）
+//	nofx execution <trader_id> [period] [config.json]      输出执行质量报表（按币种/成交额分档的平均滑点）
+//	nofx export <trader_id> <file> [period] [config.json]  导出已平仓交易（按扩展名选择csv/xlsx）
+//	nofx ruin <trader_id> [period] [config.json]           蒙特卡洛破产风险分析（--sims --trades --ruin --scale --seed）
+//	nofx trades <trader_id> [period] [config.json]         列出已平仓交易及备注和标签（--tag --limit）
+//	nofx shadow-report <shadow_id> [period] [config.json]  对比影子trader与实盘trader的决策分歧和盈亏（--window）
+//	nofx timeline <trader_id> [period] [config.json]       账户事件时间线：决策、订单、成交、风控、配置变更和错误（--symbol --kind --from --to --limit）
+//	nofx note <trade_id> <备注...>                         为交易添加备注（- 清除备注，--config指定配置文件）
+//	nofx tag | untag <trade_id> <标签1, 标签2...>          为交易添加/删除标签（--config指定配置文件）
+//	nofx replay <trade_id> [file.json]                     导出交易回放数据包（K线、决策、订单、成交、止损止盈，--interval --config）
+//	nofx replay --trader <id> [--period 7d] <dir>          按trader批量导出已平仓交易的回放数据包（每笔一个文件）
+//	nofx funding <SYMBOL...>                              回填历史资金费率并输出当前费率在历史分布中的位置（--days --config）
+//	nofx sweep <SYMBOL...>                                资金费率套利参数网格回测，按净盈亏排名（--entry --exit --notional --max-positions --percentile --workers --csv）
+//	nofx encrypt                                           用口令加密API密钥，输出可写入配置的 enc:... 值
+//	nofx doctor [--config <file>] [--trader <id>]          诊断配置、交易日志、磁盘、缓存和交易所连接，逐项输出通过/失败
+//
+// nofx daemon 以守护进程方式启动交易系统（见 parseRunOptions，由main处理）
+//
+// 手动干预（默认通过管理接口操作运行中的守护进程，--standalone直接连接交易所）:
+//
+//	nofx positions | balance                               查询持仓/余额
+//	nofx close <SYMBOL>                                    市价平掉该币种的持仓
+//	nofx flatten <SYMBOL> | --all                          市价平仓（--all平掉所有持仓）
+//	nofx orders [SYMBOL...]                                查询挂单和止损/止盈条件单
+//	nofx orders cancel <SYMBOL>                            撤销该币种的全部挂单
+//	nofx pause [原因...] [--cancel-orders]                 暂停交易（重启后保持，仅守护进程模式）
+//	nofx resume                                            恢复交易（仅守护进程模式）
+//	nofx transfer <from> <to> <AMOUNT> [CURRENCY]           现货/合约账户间划转（如 spot futures 100）
+//
+// 公共参数: --config <file> --trader <id> --addr <url> --standalone
+func runCLI(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	if opsCommands[args[0]] {
+		if err := runOpsCommand(args[0], args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	}
+
+	switch args[0] {
+	case "pnl":
+		if err := runPnLCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "execution":
+		if err := runExecutionCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "export":
+		if err := runExportCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "ruin":
+		if err := runRuinCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "trades":
+		if err := runTradesCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "shadow-report":
+		if err := runShadowReportCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "timeline":
+		if err := runTimelineCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "note", "tag", "untag":
+		if err := runAnnotateCommand(args[0], args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "replay":
+		if err := runReplayCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "doctor":
+		if err := runDoctorCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "funding":
+		if err := runFundingCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "sweep":
+		if err := runSweepCommand(args[1:]); err != nil {
+			exitWithError(err)
+		}
+		return true
+	case "encrypt":
+		if err := runEncryptCommand(); err != nil {
+			exitWithError(err)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// exitWithError 子命令执行失败时输出错误并以退出码1退出
+func exitWithError(err error) {
+	cliLog.Error("命令执行失败", "err", err)
+	os.Exit(1)
+}
+
+// runPnLCommand 从交易日志生成盈亏报表并输出到终端
+func runPnLCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx pnl <trader_id> [period] [config.json]")
+	}
+	traderID := args[0]
+	periodArg := "all"
+	if len(args) > 1 {
+		periodArg = args[1]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 2 {
+		configFile = args[2]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	pnl, err := report.ReportPnL(journal, traderID, period, nil)
+	if err != nil {
+		return err
+	}
+
+	// 命令行模式不连接交易所，未实现盈亏取最近一次余额快照
+	if snapshot, err := journal.LatestBalance(traderID); err == nil && snapshot != nil {
+		pnl.Total.UnrealizedPnL = snapshot.UnrealizedPnL
+	}
+
+	fmt.Fprint(os.Stdout, pnl.String())
+	return nil
+}
+
+// runExecutionCommand 从交易日志生成执行质量报表并输出到终端
+func runExecutionCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx execution <trader_id> [period] [config.json]")
+	}
+	traderID := args[0]
+	periodArg := "all"
+	if len(args) > 1 {
+		periodArg = args[1]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 2 {
+		configFile = args[2]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	execution, err := report.ReportExecution(journal, traderID, period)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, execution.String())
+	return nil
+}
+
+// runRuinCommand 对交易日志中的已平仓交易做蒙特卡洛模拟，输出回撤分布和破产概率
+// 破产阈值默认取配置的max_drawdown，找不到净值快照时以trader的initial_balance作为开仓时净值
+func runRuinCommand(args []string) error {
+	var opts report.RuinOptions
+	fs := flag.NewFlagSet("ruin", flag.ContinueOnError)
+	fs.IntVar(&opts.Simulations, "sims", 10000, "模拟路径数")
+	fs.IntVar(&opts.Trades, "trades", 0, "每条路径的交易数（默认与历史交易数相同）")
+	fs.Float64Var(&opts.RuinPct, "ruin", 0, "回撤达到该百分比视为破产（默认取max_drawdown，未配置时50）")
+	fs.Float64Var(&opts.SizeScale, "scale", 1, "仓位缩放倍数（如0.5表示仓位减半后的风险）")
+	fs.Int64Var(&opts.Seed, "seed", 0, "随机种子（用于复现结果）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx ruin <trader_id> [period] [config.json] [--sims N] [--trades N] [--ruin PCT] [--scale X] [--seed N]")
+	}
+	traderID := args[0]
+	periodArg := "all"
+	if len(args) > 1 {
+		periodArg = args[1]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 2 {
+		configFile = args[2]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if opts.RuinPct == 0 {
+		opts.RuinPct = cfg.MaxDrawdown
+	}
+	for _, t := range cfg.Traders {
+		if t.ID == traderID {
+			opts.InitialBalance = t.InitialBalance
+		}
+	}
+
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	ruin, err := report.ReportRuin(journal, traderID, period, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, ruin.String())
+	return nil
+}
+
+// runExportCommand 导出已平仓交易到CSV/XLSX
+func runExportCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("用法: nofx export <trader_id> <file.csv|file.xlsx> [period] [config.json]")
+	}
+	traderID, path := args[0], args[1]
+	periodArg := "all"
+	if len(args) > 2 {
+		periodArg = args[2]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 3 {
+		configFile = args[3]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format == "" {
+		format = "csv"
+	}
+
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	count, err := report.ExportTrades(journal, traderID, path, format, period)
+	if err != nil {
+		return err
+	}
+	cliLog.Info("已导出交易", "count", count, "path", path)
+	return nil
+}
+
+// runShadowReportCommand 对比影子trader与其实盘trader的决策记录和交易日志，输出分歧报表
+func runShadowReportCommand(args []string) error {
+	fs := flag.NewFlagSet("shadow-report", flag.ContinueOnError)
+	window := fs.Duration("window", 0, "决策周期配对的时间窗口（默认为实盘trader扫描间隔的一半）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx shadow-report <shadow_id> [period] [config.json] [--window 90s]")
+	}
+	shadowID := args[0]
+	periodArg := "all"
+	if len(args) > 1 {
+		periodArg = args[1]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 2 {
+		configFile = args[2]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	var liveID string
+	for _, t := range cfg.Traders {
+		if t.ID == shadowID {
+			liveID = t.ShadowOf
+		}
+	}
+	if liveID == "" {
+		return fmt.Errorf("trader %s 不是影子trader（未配置shadow_of）", shadowID)
+	}
+	if *window <= 0 {
+		for _, t := range cfg.Traders {
+			if t.ID == liveID {
+				*window = t.GetScanInterval() / 2
+			}
+		}
+	}
+	if *window < time.Minute {
+		*window = time.Minute
+	}
+
+	live, err := logger.NewDecisionLogger(filepath.Join("decision_logs", liveID)).GetRecordsSince(period.Since)
+	if err != nil {
+		return err
+	}
+	shadow, err := logger.NewDecisionLogger(filepath.Join("decision_logs", shadowID)).GetRecordsSince(period.Since)
+	if err != nil {
+		return err
+	}
+
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	result, err := report.ReportShadow(journal, liveID, shadowID, live, shadow, period, *window)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, result.String())
+	return nil
+}
+
+// runTradesCommand 列出已平仓交易（含ID、备注和标签，按平仓时间倒序）
+func runTradesCommand(args []string) error {
+	fs := flag.NewFlagSet("trades", flag.ContinueOnError)
+	tag := fs.String("tag", "", "只列出带该标签的交易")
+	limit := fs.Int("limit", 50, "最多列出的交易数（0表示不限）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx trades <trader_id> [period] [config.json] [--tag 标签] [--limit N]")
+	}
+	traderID := args[0]
+	periodArg := "all"
+	if len(args) > 1 {
+		periodArg = args[1]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 2 {
+		configFile = args[2]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	trades, err := report.ListTrades(journal, traderID, period, *tag, *limit)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, report.FormatTrades(trades))
+	return nil
+}
+
+// runTimelineCommand 按时间先后输出账户事件时间线（period默认24h，--from/--to指定起止时间时忽略period）
+func runTimelineCommand(args []string) error {
+	fs := flag.NewFlagSet("timeline", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "只列出该币种的事件")
+	kinds := fs.String("kind", "", "只列出这些类型（逗号分隔: "+strings.Join(store.TimelineKinds, ",")+"）")
+	from := fs.String("from", "", "起始时间（RFC3339或2006-01-02）")
+	to := fs.String("to", "", "结束时间（RFC3339或2006-01-02）")
+	limit := fs.Int("limit", 200, "最多列出的事件数（最近的N条，0表示不限）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx timeline <trader_id> [period] [config.json] [--symbol BTCUSDT] [--kind order,fill] [--from 时间] [--to 时间] [--limit N]")
+	}
+	periodArg := "24h"
+	if len(args) > 1 {
+		periodArg = args[1]
+	}
+	configFile := config.DefaultFile()
+	if len(args) > 2 {
+		configFile = args[2]
+	}
+
+	period, err := report.ParsePeriod(periodArg)
+	if err != nil {
+		return err
+	}
+	q := store.TimelineQuery{TraderID: args[0], Symbol: strings.ToUpper(*symbol), Since: period.Since, Limit: *limit}
+	for _, t := range []struct {
+		value string
+		dst   *time.Time
+	}{{*from, &q.Since}, {*to, &q.Until}} {
+		if t.value == "" {
+			continue
+		}
+		if *t.dst, err = time.Parse(time.RFC3339, t.value); err != nil {
+			if *t.dst, err = time.ParseInLocation("2006-01-02", t.value, time.Local); err != nil {
+				return fmt.Errorf("时间格式无效（RFC3339或2006-01-02）: %s", t.value)
+			}
+		}
+	}
+	for _, kind := range strings.Split(*kinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			q.Kinds = append(q.Kinds, kind)
+		}
+	}
+
+	journal, err := openJournal(configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	entries, err := journal.ListTimeline(q)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, report.FormatTimeline(entries))
+	return nil
+}
+
+// runAnnotateCommand 修改交易的备注（note）或标签（tag/untag），交易ID见 nofx trades
+func runAnnotateCommand(command string, args []string) error {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := fs.String("config", config.DefaultFile(), "配置文件")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("用法: nofx %s <trade_id> <%s> [--config config.json]", command,
+			map[string]string{"note": "备注...（- 清除备注）", "tag": "标签1, 标签2...", "untag": "标签1, 标签2..."}[command])
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("交易ID无效: %s", args[0])
+	}
+	text := strings.Join(args[1:], " ")
+
+	journal, err := openJournal(*configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	var p *store.Position
+	switch command {
+	case "note":
+		if text == "-" {
+			text = ""
+		}
+		p, err = journal.AnnotatePosition(id, &text, nil, nil)
+	case "tag":
+		p, err = journal.AnnotatePosition(id, nil, []string{text}, nil)
+	default:
+		p, err = journal.AnnotatePosition(id, nil, nil, []string{text})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "✓ [%s] %s\n", p.TraderID, report.FormatTrade(*p))
+	return nil
+}
+
+// runReplayCommand 导出交易回放数据包（JSON，供图表工具复盘入场和出场）
+func runReplayCommand(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	configFile := fs.String("config", config.DefaultFile(), "配置文件")
+	interval := fs.String("interval", "", "K线周期（1m/5m/15m/1h/4h，默认按持仓时长自动选择）")
+	traderID := fs.String("trader", "", "批量导出该trader的已平仓交易")
+	periodArg := fs.String("period", "7d", "批量导出的周期（today/24h/7d/30d/all）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx replay <trade_id> [file.json] [--interval 15m] 或 nofx replay --trader <id> [--period 7d] <dir>")
+	}
+	opts := report.ReplayOptions{Interval: *interval}
+
+	journal, err := openJournal(*configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	if *traderID == "" {
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("交易ID无效: %s", args[0])
+		}
+		path := "-"
+		if len(args) > 1 {
+			path = args[1]
+		}
+		return writeReplay(journal, id, path, opts)
+	}
+
+	period, err := report.ParsePeriod(*periodArg)
+	if err != nil {
+		return err
+	}
+	trades, err := report.ListTrades(journal, *traderID, period, "", 0)
+	if err != nil {
+		return err
+	}
+	dir := args[0]
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建导出目录失败: %w", err)
+	}
+	for _, t := range trades {
+		if err := writeReplay(journal, t.ID, filepath.Join(dir, fmt.Sprintf("trade-%d.json", t.ID)), opts); err != nil {
+			return err
+		}
+	}
+	cliLog.Info("已导出回放数据", "trades", len(trades), "dir", dir)
+	return nil
+}
+
+// writeReplay 生成一笔交易的回放数据包并写入文件（path为"-"时输出到终端）
+func writeReplay(journal *store.Store, id int64, path string, opts report.ReplayOptions) error {
+	replay, err := report.BuildTradeReplay(journal, id, opts)
+	if err != nil {
+		return err
+	}
+	for _, w := range replay.Warnings {
+		cliLog.Warn("回放数据不完整", "trade_id", id, "detail", w)
+	}
+	data, err := json.MarshalIndent(replay, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入回放数据失败: %w", err)
+	}
+	cliLog.Info("回放数据已写入", "trade_id", id, "interval", replay.Interval, "candles", len(replay.Candles), "path", path)
+	return nil
+}
+
+// openJournal 按配置文件打开交易日志存储
+func openJournal(configFile string) (*store.Store, error) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if cfg.StorePath == "-" {
+		return nil, fmt.Errorf("交易日志存储未启用（store_path为\"-\"）")
+	}
+	return store.Open(cfg.StorePath)
+}
+
+// runEncryptCommand 在终端读取密钥和口令（不回显），输出加密后的配置值
+func runEncryptCommand() error {
+	plaintext, err := secrets.Prompt("要加密的密钥: ")
+	if err != nil {
+		return err
+	}
+	if plaintext == "" {
+		return fmt.Errorf("密钥不能为空")
+	}
+
+	passphrase, err := secrets.Prompt("口令: ")
+	if err != nil {
+		return err
+	}
+	if len(passphrase) < 8 {
+		return fmt.Errorf("口令至少8个字符")
+	}
+	confirm, err := secrets.Prompt("再次输入口令: ")
+	if err != nil {
+		return err
+	}
+	if confirm != passphrase {
+		return fmt.Errorf("两次输入的口令不一致")
+	}
+
+	encrypted, err := secrets.Encrypt(plaintext, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Println(encrypted)
+	return nil
+}
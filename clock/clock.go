@@ -0,0 +1,111 @@
+// Package clock 可替换的时钟：实盘使用系统时钟，测试和回测使用手动推进的模拟时钟，
+// 缓存过期、冷却期、下单确认轮询和任务调度都通过Clock取时间和等待，不再依赖真实的sleep
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock 时钟
+type Clock interface {
+	Now() time.Time
+	// Sleep 等待d（模拟时钟下阻塞到时间被推进过d）
+	Sleep(d time.Duration)
+	// After 经过d后向返回的通道发送当时的时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real 系统时钟
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Or c为nil时返回系统时钟
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// waiter 等待中的Sleep/After
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// Manual 手动推进的模拟时钟：时间只在Advance/Set时前进，到期的Sleep/After按到期时间顺序唤醒
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// NewManual 创建模拟时钟
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+// Now 当前模拟时间
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Sleep 阻塞到模拟时间推进过d（d<=0时立即返回）
+func (m *Manual) Sleep(d time.Duration) {
+	<-m.After(d)
+}
+
+// After 模拟时间推进过d后发送当时的时间
+func (m *Manual) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- m.now
+		return ch
+	}
+	m.waiters = append(m.waiters, waiter{at: m.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 推进模拟时间d
+func (m *Manual) Advance(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set 将模拟时间设为t（早于当前时间时忽略），唤醒所有到期的等待
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t.Before(m.now) {
+		return
+	}
+	m.now = t
+
+	sort.SliceStable(m.waiters, func(i, j int) bool { return m.waiters[i].at.Before(m.waiters[j].at) })
+	pending := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.at.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- w.at
+	}
+	m.waiters = pending
+}
+
+// Waiters 等待中的Sleep/After数量（测试中用于确认被测代码已进入等待再推进时间）
+func (m *Manual) Waiters() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.waiters)
+}
@@ -1,9 +1,29 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
+	"nofx/decision"
+	"nofx/fx"
+	"nofx/i18n"
+	"nofx/logging"
+	"nofx/market"
+	"nofx/mcp"
+	"nofx/news"
+	"nofx/notify/discord"
+	"nofx/notify/httphook"
+	"nofx/notify/slack"
+	"nofx/notify/telegram"
+	"nofx/report"
+	"nofx/risk"
+	"nofx/scheduler"
+	"nofx/store"
+	"nofx/strategy"
+	"nofx/symbols"
+	"nofx/tracing"
+	"nofx/webhook"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -45,40 +65,218 @@ type TraderConfig struct {
 	CustomAPIKey    string `json:"custom_api_key,omitempty"`
 	CustomModelName string `json:"custom_model_name,omitempty"`
 
+	// AI客户端：重试退避、超时、流式输出和JSON模式（默认重试3次、不流式、不启用JSON模式）
+	LLM mcp.Options `json:"llm,omitempty"`
+
 	InitialBalance      float64 `json:"initial_balance"`
 	ScanIntervalMinutes int     `json:"scan_interval_minutes"`
+
+	// 策略配置
+	DCA            strategy.DCAConfig            `json:"dca,omitempty"`             // DCA/马丁加仓（默认关闭）
+	Pyramid        strategy.PyramidConfig        `json:"pyramid,omitempty"`         // 顺势加仓（默认关闭，与DCA互斥）
+	FundingHarvest strategy.FundingHarvestConfig `json:"funding_harvest,omitempty"` // 资金费率套利（默认关闭，仅Gate.io）
+	Basis          strategy.BasisConfig          `json:"basis,omitempty"`           // 期现基差监控与套利（默认关闭，仅Gate.io）
+	Hedge          strategy.HedgeConfig          `json:"hedge,omitempty"`           // 相关性对冲（默认关闭）
+
+	// 调度配置（各策略独立周期、交易时段、禁止开仓时间）
+	Schedule scheduler.Config `json:"schedule,omitempty"`
+
+	// 外部信号（TradingView Webhook，默认关闭）
+	Webhook webhook.Config `json:"webhook,omitempty"`
+
+	// 只读（观察模式）：运行AI和策略并记录决策，但禁止一切下单（用于在实盘账户上影子评估新提示词）
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// 止损限价偏移（%）：止损触发后按触发价∓N%挂限价单，防止流动性差的合约插针时以极端价格成交（0表示市价止损）
+	StopLimitOffsetPct float64 `json:"stop_limit_offset_pct,omitempty"`
+
+	// 止损/止盈条件单有效期（秒，默认2592000即30天）：到期后交易所自动撤销，持仓期间临近到期的条件单会自动续期（仅Gate.io）
+	TriggerExpiration int `json:"trigger_expiration,omitempty"`
+
+	// 止损/止盈条件单的触发价格类型（mark默认/last/index）：流动性差的合约标记价格可能偏离成交价，可改用最新成交价触发（仅Gate.io）
+	TriggerPriceType string `json:"trigger_price_type,omitempty"`
+
+	// 分批止盈：按初始风险R的倍数分档部分平仓，剩余仓位挂在AI给出的止盈价（为空表示单一止盈）
+	TakeProfitLadder risk.TakeProfitLadder `json:"take_profit_ladder,omitempty"`
+
+	// 持仓数量变化（部分平仓、加仓、部分成交）时自动按新数量重挂止损/止盈单（WebSocket持仓推送，仅Gate.io）
+	ResizeProtectiveOrders bool `json:"resize_protective_orders,omitempty"`
+
+	// 资金流水监控：出现提现、转出或API密钥权限变化时立即告警（密钥泄露的入侵告警，仅Gate.io）
+	LedgerMonitor bool `json:"ledger_monitor,omitempty"`
+
+	// 历史交易导入：首次运行（交易日志中还没有该trader的交易）时从交易所导入最近N天的平仓记录和成交，0表示不导入（仅Gate.io）
+	HistoryImportDays int `json:"history_import_days,omitempty"`
+
+	// 自动减仓（ADL）预警：持仓的ADL排名达到warn_rank时告警，达到reduce_rank时主动减仓reduce_pct%（仅Gate.io提供排名）
+	ADLGuard risk.ADLGuard `json:"adl_guard,omitempty"`
+
+	// 保证金模式和风险限额：mode为cross时使用全仓（交易所杠杆设为0，下单杠杆作为全仓杠杆上限），
+	// risk_limits按币种设置风险限额（USDT，决定仓位价值上限和最大杠杆，仅Gate.io）
+	Margin risk.MarginSettings `json:"margin,omitempty"`
+
+	// 组合视图汇总的结算币种：如["usdt", "btc"]时同时统计USDT本位和BTC本位合约账户，按指数价折算为美元（仅Gate.io，BTC本位只统计不交易）
+	PortfolioSettles risk.PortfolioSettles `json:"portfolio_settles,omitempty"`
+
+	// 下单数量取整策略：mode为nearest（默认）/floor/ceil；below_min为bump（默认，提高到最小下单数量，
+	// 高价合约上实际风险可能是目标的数倍）或reject（拒绝下单）
+	QuantityRounding risk.QuantityRounding `json:"quantity_rounding,omitempty"`
+
+	// 止损/止盈价格合理性检查：方向错误（如多仓止损高于当前价）时拒绝，偏离当前价超过上限时拒绝或收紧
+	PriceBand risk.PriceBand `json:"price_band,omitempty"`
+
+	// 开仓执行方式：market（默认，IOC市价）、maker（先挂只挂单，超时未成交改市价）、auto（按费率和盘口价差估算成本后选择）、
+	// chase（只挂单追价重挂，超时后剩余数量市价）；信号指定limit_price时挂GTC限价单，limit_timeout_min后撤销未成交部分
+	EntryRouting risk.EntryRouting `json:"entry_routing,omitempty"`
+
+	// 按行情状态过滤开仓：币种当前处于block中的状态（trending/ranging/high_vol，按4小时K线的ADX和已实现波动率判断）时拒绝开仓
+	RegimeFilter risk.RegimeFilter `json:"regime_filter,omitempty"`
+
+	// 信号去重和开仓节流：同一来源的相同动作在dedup_window_min内只执行一次，同一币种两次开仓至少间隔min_entry_interval_min
+	SignalThrottle risk.SignalThrottle `json:"signal_throttle,omitempty"`
+
+	// 多策略资金分配：按净值比例为ai/webhook/funding_harvest分配持仓额度（按订单策略标记统计），定期按各策略近期收益再平衡
+	Allocation risk.Allocation `json:"allocation,omitempty"`
+
+	// 软平仓：指定规则（按时间平仓、DCA总体止损）触发时只生成平仓建议，由操作员通过Telegram按钮或管理接口确认后执行
+	SoftClose risk.SoftClose `json:"soft_close,omitempty"`
+
+	// 开仓确认模式：AI决策和外部信号的开仓只生成附带下单预览的开仓建议，由操作员通过Telegram按钮或管理接口确认后执行
+	EntryConfirm risk.EntryConfirm `json:"confirm_entries,omitempty"`
+
+	// 全部平仓的顺序和节奏：order为risk（默认，距强平价最近的先平）/loss（浮亏最大的先平）/notional（名义价值最大的先平），
+	// 相邻两笔平仓间隔pace_ms毫秒（0表示100毫秒），避免一次性下单触发交易所频率限制
+	Flatten risk.Flatten `json:"flatten,omitempty"`
+
+	// 维护期间的平仓单排队：交易所维护中被拒绝的平仓/减仓单（不包括开仓）排队，恢复后自动重新提交，超过有效期作废
+	DowntimeQueue risk.DowntimeQueue `json:"downtime_queue,omitempty"`
+
+	// 每个决策周期的新开仓数量上限：AI一次给出多个开仓时按信心度从高到低执行，达到上限后其余开仓丢弃或顺延到下一周期
+	EntryLimit risk.EntryLimit `json:"entry_limit,omitempty"`
+
+	// 浸泡测试：模拟交易按计划注入故障（API超时、WebSocket断线、AI畸形输出、部分成交），每个决策周期后检查
+	// 持仓都有止损、同一周期内不重复开仓，不变量被破坏时告警（halt_on_violation时暂停交易），只能用于dry_run或影子trader
+	Soak risk.Soak `json:"soak,omitempty"`
+
+	// 单笔最大亏损硬上限：每笔开仓必须带止损，止损时的亏损（仓位×止损距离，含开平仓手续费）超过max_loss_usdt时
+	// 拒绝开仓（action为reject，默认）或按上限缩小仓位（resize），不论AI或外部信号给出多大的仓位
+	TradeLossCap risk.TradeLossCap `json:"max_trade_loss,omitempty"`
+
+	// 决策过期保护：AI决策所用行情快照距执行超过max_age_seconds、或价格变动超过max_move_pct时拒绝执行
+	Staleness risk.Staleness `json:"staleness,omitempty"`
+
+	// 余额异常检测：钱包余额的实际变化与交易日志推算（平仓盈亏、手续费、资金费、资金划转）连续偏离超过容差时告警并强制对账
+	BalanceAnomaly risk.BalanceAnomaly `json:"balance_anomaly,omitempty"`
+
+	// 数据源故障降级：持仓推送断开时改为REST轮询持仓，K线过期时只平仓不开仓，AI连续不可用时只按规则管理平仓，
+	// 模式切换写日志并告警，数据源恢复后自动回到正常模式（修改后需重启）
+	Degradation risk.Degradation `json:"degradation,omitempty"`
+
+	// 下单频率上限：全局和单个币种每分钟/每小时最多下单数，超过时拒绝开仓并暂停交易（防止程序异常循环下单）
+	OrderRateLimit risk.OrderRateLimit `json:"order_rate_limit,omitempty"`
+
+	// 按策略（ai或外部信号来源）和币种覆盖风控参数：单笔风险、杠杆上限、仓位上限、止损距离范围，逐层合并（default → strategies → symbols）
+	RiskProfiles risk.RiskProfiles `json:"risk_profiles,omitempty"`
+
+	// 影子模式：与指定的实盘trader并行运行候选提示词/策略，始终模拟交易（记录假设盈亏），用 nofx shadow-report 对比两者的决策分歧
+	// 未配置exchange时继承实盘trader的交易所和密钥（只读取行情）
+	ShadowOf string `json:"shadow_of,omitempty"`
+
+	// 候选提示词：version标记决策和交易日志中的提示词版本，rules_file的内容追加到系统提示词末尾
+	Prompt decision.PromptConfig `json:"prompt,omitempty"`
 }
 
 // LeverageConfig 杠杆配置
 type LeverageConfig struct {
-	BTCETHLeverage  int `json:"btc_eth_leverage"` // BTC和ETH的杠杆倍数（主账户建议5-50，子账户≤5）
-	AltcoinLeverage int `json:"altcoin_leverage"` // 山寨币的杠杆倍数（主账户建议5-20，子账户≤5）
+	BTCETHLeverage  int                     `json:"btc_eth_leverage"` // BTC和ETH的杠杆倍数（主账户建议5-50，子账户≤5）
+	AltcoinLeverage int                     `json:"altcoin_leverage"` // 山寨币的杠杆倍数（主账户建议5-20，子账户≤5）
+	Auto            risk.AutoLeverageConfig `json:"auto"`             // 按波动率（ATR）自动计算杠杆，上面两项作为上限
+	Liquidation     risk.LiquidationGuard   `json:"liquidation"`      // 开仓前估算强平价，距入场价不足N倍ATR时拒绝
+	Account         risk.AccountLimits      `json:"account"`          // 开仓后账户有效杠杆和保证金使用率上限
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	Token    string `json:"token"`     // 访问令牌（Authorization: Bearer <token>，为空时管理接口不可用）
+	GRPCPort int    `json:"grpc_port"` // gRPC控制平面端口（0表示不启用，鉴权使用同一令牌）
+}
+
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	AccountTTLSeconds int `json:"account_ttl_seconds"` // 交易器余额/持仓缓存时间（秒，默认15）
 }
 
 // Config 总配置
 type Config struct {
-	Traders            []TraderConfig `json:"traders"`
-	UseDefaultCoins    bool           `json:"use_default_coins"` // 是否使用默认主流币种列表
-	DefaultCoins       []string       `json:"default_coins"`     // 默认主流币种池
-	CoinPoolAPIURL     string         `json:"coin_pool_api_url"`
-	OITopAPIURL        string         `json:"oi_top_api_url"`
-	APIServerPort      int            `json:"api_server_port"`
-	MaxDailyLoss       float64        `json:"max_daily_loss"`
-	MaxDrawdown        float64        `json:"max_drawdown"`
-	StopTradingMinutes int            `json:"stop_trading_minutes"`
-	Leverage           LeverageConfig `json:"leverage"` // 杠杆配置
+	Traders            []TraderConfig       `json:"traders"`
+	UseDefaultCoins    bool                 `json:"use_default_coins"` // 是否使用默认主流币种列表
+	DefaultCoins       []string             `json:"default_coins"`     // 默认主流币种池
+	CoinPoolAPIURL     string               `json:"coin_pool_api_url"`
+	OITopAPIURL        string               `json:"oi_top_api_url"`
+	APIServerPort      int                  `json:"api_server_port"`
+	MaxDailyLoss       float64              `json:"max_daily_loss"`
+	MaxDrawdown        float64              `json:"max_drawdown"`
+	StopTradingMinutes int                  `json:"stop_trading_minutes"`
+	Leverage           LeverageConfig       `json:"leverage"`         // 杠杆配置
+	StorePath          string               `json:"store_path"`       // 交易日志SQLite路径或PostgreSQL连接串（默认data/nofx.db，设为"-"禁用）
+	Log                logging.Config       `json:"log"`              // 日志配置（级别、格式、按模块级别）
+	Tracing            tracing.Config       `json:"tracing"`          // 链路追踪配置（OTLP导出决策周期各阶段耗时）
+	Telegram           telegram.Config      `json:"telegram"`         // Telegram通知与命令机器人
+	Discord            discord.Config       `json:"discord"`          // Discord Webhook通知
+	Slack              slack.Config         `json:"slack"`            // Slack Incoming Webhook通知
+	NotifyWebhooks     []httphook.Config    `json:"notify_webhooks"`  // 通用Webhook通知（按事件类型POST模板化JSON，可HMAC签名）
+	Summary            report.SummaryConfig `json:"summary"`          // 每日/每周汇总报告（通过已配置的通知渠道推送）
+	Cache              CacheConfig          `json:"cache"`            // 缓存时间
+	Admin              AdminConfig          `json:"admin"`            // 管理接口（持仓、挂单、暂停/恢复、平仓、查看配置）
+	DryRun             bool                 `json:"dry_run"`          // 模拟交易：读取真实行情，下单在本地模拟（也可用 --dry-run 启动参数开启）
+	KillSwitchFile     string               `json:"kill_switch_file"` // 哨兵文件：存在时暂停所有trader（默认data/PAUSE，设为"-"禁用）
+	Language           string               `json:"language"`         // 日志和错误消息的语言: zh（默认）/en
+
+	// 跨交易所组合敞口限制：按标的资产汇总所有账户的净敞口，开仓时按所有账户净值合计检查（默认不限制）
+	CombinedExposure risk.BookLimits `json:"combined_exposure"`
+
+	// 交易对符号覆盖：内置命名规则之外的特殊合约名（如某交易所的1000倍合约），需要重启生效
+	SymbolOverrides []symbols.Override `json:"symbol_overrides"`
+
+	// 多周期K线服务：为列出的币种维护1m/15m/1h/4h滚动K线（WebSocket推送+REST回填），行情数据和策略直接读取（仅Gate.io）
+	Bars market.BarConfig `json:"bars"`
+
+	// 新闻和情绪：定时拉取RSS/CryptoPanic新闻，按币种汇总写入市场数据，宏观新闻源写入提示词（默认不启用，需要重启生效）
+	News news.Config `json:"news"`
+
+	// 历史资金费率：按合约回填到交易日志，当前费率相对近N天分布的百分位写入提示词，也用于funding_harvest.entry_percentile（默认不启用，需要重启生效）
+	FundingHistory report.FundingHistoryConfig `json:"funding_history"`
+
+	// 经济日历：高影响事件（CPI、FOMC等）公布前后禁止开新仓，即将公布的事件写入提示词（修改后热加载生效）
+	EconomicCalendar risk.EconomicCalendar `json:"economic_calendar"`
+
+	// 报告币种：汇总报告、盈亏通知和看板在USDT之外同时显示换算为USD/EUR/CNY的金额（默认不换算，需要重启生效）
+	ReportingCurrency fx.Config `json:"reporting_currency"`
 }
 
-// LoadConfig 从文件加载配置
+// forceDryRun 由 --dry-run 启动参数设置，对之后加载的配置（包括热加载）都生效
+var forceDryRun bool
+
+// ForceDryRun 强制开启模拟交易（不论配置文件中的dry_run）
+func ForceDryRun() {
+	forceDryRun = true
+}
+
+// LoadConfig 从文件加载配置（按扩展名支持JSON/YAML/TOML）
 func LoadConfig(filename string) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		return nil, i18n.Errorf("读取配置文件失败: %w", err)
 	}
 
 	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	if err := decodeConfig(filename, data, &config); err != nil {
+		return nil, i18n.Errorf("解析配置文件 %s 失败: %w", filename, err)
+	}
+
+	// 读取环境变量/文件/Vault/AWS中的密钥
+	if err := config.resolveSecrets(); err != nil {
+		return nil, i18n.Errorf("读取密钥失败: %w", err)
 	}
 
 	// 设置默认值：如果use_default_coins未设置（为false）且没有配置coin_pool_api_url，则默认使用默认币种列表
@@ -102,33 +300,40 @@ func LoadConfig(filename string) (*Config, error) {
 
 	// 验证配置
 	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("配置验证失败: %w", err)
+		return nil, i18n.Errorf("配置验证失败: %w", err)
 	}
 
 	return &config, nil
 }
 
+// maxHistoryImportDays 历史交易导入的最大天数
+const maxHistoryImportDays = 365
+
 // Validate 验证配置有效性
 func (c *Config) Validate() error {
 	if len(c.Traders) == 0 {
-		return fmt.Errorf("至少需要配置一个trader")
+		return i18n.Errorf("至少需要配置一个trader")
+	}
+
+	if err := c.resolveShadows(); err != nil {
+		return err
 	}
 
 	traderIDs := make(map[string]bool)
 	for i, trader := range c.Traders {
 		if trader.ID == "" {
-			return fmt.Errorf("trader[%d]: ID不能为空", i)
+			return i18n.Errorf("trader[%d]: ID不能为空", i)
 		}
 		if traderIDs[trader.ID] {
-			return fmt.Errorf("trader[%d]: ID '%s' 重复", i, trader.ID)
+			return i18n.Errorf("trader[%d]: ID '%s' 重复", i, trader.ID)
 		}
 		traderIDs[trader.ID] = true
 
 		if trader.Name == "" {
-			return fmt.Errorf("trader[%d]: Name不能为空", i)
+			return i18n.Errorf("trader[%d]: Name不能为空", i)
 		}
 		if trader.AIModel != "qwen" && trader.AIModel != "deepseek" && trader.AIModel != "custom" {
-			return fmt.Errorf("trader[%d]: ai_model必须是 'qwen', 'deepseek' 或 'custom'", i)
+			return i18n.Errorf("trader[%d]: ai_model必须是 'qwen', 'deepseek' 或 'custom'", i)
 		}
 
 		// 验证交易平台配置
@@ -136,50 +341,244 @@ func (c *Config) Validate() error {
 			trader.Exchange = "binance" // 默认使用币安
 		}
 		if trader.Exchange != "binance" && trader.Exchange != "hyperliquid" && trader.Exchange != "aster" && trader.Exchange != "gate" {
-			return fmt.Errorf("trader[%d]: exchange必须是 'binance', 'hyperliquid', 'aster' 或 'gate'", i)
+			return i18n.Errorf("trader[%d]: exchange必须是 'binance', 'hyperliquid', 'aster' 或 'gate'", i)
 		}
 
 		// 根据平台验证对应的密钥
 		if trader.Exchange == "binance" {
 			if trader.BinanceAPIKey == "" || trader.BinanceSecretKey == "" {
-				return fmt.Errorf("trader[%d]: 使用币安时必须配置binance_api_key和binance_secret_key", i)
+				return i18n.Errorf("trader[%d]: 使用币安时必须配置binance_api_key和binance_secret_key", i)
 			}
 		} else if trader.Exchange == "hyperliquid" {
 			if trader.HyperliquidPrivateKey == "" {
-				return fmt.Errorf("trader[%d]: 使用Hyperliquid时必须配置hyperliquid_private_key", i)
+				return i18n.Errorf("trader[%d]: 使用Hyperliquid时必须配置hyperliquid_private_key", i)
 			}
 		} else if trader.Exchange == "aster" {
 			if trader.AsterUser == "" || trader.AsterSigner == "" || trader.AsterPrivateKey == "" {
-				return fmt.Errorf("trader[%d]: 使用Aster时必须配置aster_user, aster_signer和aster_private_key", i)
+				return i18n.Errorf("trader[%d]: 使用Aster时必须配置aster_user, aster_signer和aster_private_key", i)
 			}
 		} else if trader.Exchange == "gate" {
 			if trader.GateAPIKey == "" || trader.GateSecretKey == "" {
-				return fmt.Errorf("trader[%d]: 使用Gate.io时必须配置gate_api_key和gate_secret_key", i)
+				return i18n.Errorf("trader[%d]: 使用Gate.io时必须配置gate_api_key和gate_secret_key", i)
 			}
 		}
 
 		if trader.AIModel == "qwen" && trader.QwenKey == "" {
-			return fmt.Errorf("trader[%d]: 使用Qwen时必须配置qwen_key", i)
+			return i18n.Errorf("trader[%d]: 使用Qwen时必须配置qwen_key", i)
 		}
 		if trader.AIModel == "deepseek" && trader.DeepSeekKey == "" {
-			return fmt.Errorf("trader[%d]: 使用DeepSeek时必须配置deepseek_key", i)
+			return i18n.Errorf("trader[%d]: 使用DeepSeek时必须配置deepseek_key", i)
 		}
 		if trader.AIModel == "custom" {
 			if trader.CustomAPIURL == "" {
-				return fmt.Errorf("trader[%d]: 使用自定义API时必须配置custom_api_url", i)
+				return i18n.Errorf("trader[%d]: 使用自定义API时必须配置custom_api_url", i)
 			}
 			if trader.CustomAPIKey == "" {
-				return fmt.Errorf("trader[%d]: 使用自定义API时必须配置custom_api_key", i)
+				return i18n.Errorf("trader[%d]: 使用自定义API时必须配置custom_api_key", i)
 			}
 			if trader.CustomModelName == "" {
-				return fmt.Errorf("trader[%d]: 使用自定义API时必须配置custom_model_name", i)
+				return i18n.Errorf("trader[%d]: 使用自定义API时必须配置custom_model_name", i)
 			}
 		}
 		if trader.InitialBalance <= 0 {
-			return fmt.Errorf("trader[%d]: initial_balance必须大于0", i)
+			return i18n.Errorf("trader[%d]: initial_balance必须大于0", i)
 		}
 		if trader.ScanIntervalMinutes <= 0 {
-			trader.ScanIntervalMinutes = 3 // 默认3分钟
+			c.Traders[i].ScanIntervalMinutes = 3 // 默认3分钟
+		}
+		if err := c.Traders[i].Schedule.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := c.Traders[i].Webhook.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := c.Traders[i].DCA.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := c.Traders[i].Pyramid.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.DCA.Enabled && trader.Pyramid.Enabled {
+			return i18n.Errorf("trader[%d]: dca与pyramid不能同时启用（二者都会调整同一持仓的总体止损）", i)
+		}
+		if err := c.Traders[i].FundingHarvest.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.FundingHarvest.Enabled && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: funding_harvest需要现货模块，目前仅支持exchange='gate'", i)
+		}
+		if trader.FundingHarvest.Enabled && trader.ReadOnly {
+			return i18n.Errorf("trader[%d]: 只读模式不支持funding_harvest", i)
+		}
+		if trader.FundingHarvest.Enabled && trader.FundingHarvest.EntryPercentile > 0 && !c.FundingHistory.Enabled {
+			return i18n.Errorf("trader[%d]: funding_harvest.entry_percentile需要启用funding_history", i)
+		}
+		if err := c.Traders[i].Basis.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.Basis.Enabled && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: basis需要现货模块，目前仅支持exchange='gate'", i)
+		}
+		if trader.Basis.Trade && trader.ReadOnly {
+			return i18n.Errorf("trader[%d]: 只读模式不支持basis.trade（可关闭trade只监控基差）", i)
+		}
+		if err := c.Traders[i].Hedge.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.Hedge.Enabled && trader.ReadOnly {
+			return i18n.Errorf("trader[%d]: 只读模式不支持hedge", i)
+		}
+		if trader.Basis.Trade && trader.FundingHarvest.Enabled {
+			for _, symbol := range trader.Basis.Symbols {
+				for _, other := range trader.FundingHarvest.Symbols {
+					if strings.EqualFold(symbol, other) {
+						return i18n.Errorf("trader[%d]: %s 不能同时用于basis.trade和funding_harvest（两者会共用同一永续空头）", i, symbol)
+					}
+				}
+			}
+		}
+		if trader.StopLimitOffsetPct < 0 || trader.StopLimitOffsetPct > 20 {
+			return i18n.Errorf("trader[%d]: stop_limit_offset_pct必须在0-20之间", i)
+		}
+		if err := decision.CheckTriggerPriceType(trader.TriggerPriceType); err != nil {
+			return fmt.Errorf("trader[%d]: trigger_price_type: %w", i, err)
+		}
+		if err := decision.CheckTriggerExpiration(trader.TriggerExpiration); err != nil {
+			return fmt.Errorf("trader[%d]: trigger_expiration: %w", i, err)
+		}
+		if err := trader.TakeProfitLadder.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if len(trader.TakeProfitLadder) > 0 && (trader.DCA.Enabled || trader.Pyramid.Enabled) {
+			return i18n.Errorf("trader[%d]: take_profit_ladder不能与dca/pyramid同时使用（加仓会撤销分批止盈单）", i)
+		}
+		if err := trader.RegimeFilter.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.EntryRouting.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.EntryRouting.Enabled() && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: entry_routing需要只挂单和费率查询，目前仅支持exchange='gate'", i)
+		}
+		if trader.EntryRouting.ZeroFill == risk.ZeroFillLimit && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: entry_routing.zero_fill=limit需要只挂单，目前仅支持exchange='gate'", i)
+		}
+		if err := trader.PriceBand.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.SignalThrottle.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.ResizeProtectiveOrders && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: resize_protective_orders需要WebSocket持仓推送，目前仅支持exchange='gate'", i)
+		}
+		if trader.LedgerMonitor && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: ledger_monitor需要查询账户流水，目前仅支持exchange='gate'", i)
+		}
+		if trader.HistoryImportDays < 0 || trader.HistoryImportDays > maxHistoryImportDays {
+			return i18n.Errorf("trader[%d]: history_import_days必须在0-%d之间", i, maxHistoryImportDays)
+		}
+		if trader.HistoryImportDays > 0 && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: history_import_days需要查询平仓历史，目前仅支持exchange='gate'", i)
+		}
+		if err := trader.ADLGuard.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.ADLGuard.Enabled() && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: adl_guard需要持仓的ADL排名，目前仅支持exchange='gate'", i)
+		}
+		if err := c.Traders[i].QuantityRounding.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := c.Traders[i].Margin.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.Margin.Enabled() && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: margin（全仓模式/风险限额）目前仅支持exchange='gate'", i)
+		}
+		if err := c.Traders[i].PortfolioSettles.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if len(trader.PortfolioSettles) > 0 && trader.Exchange != "gate" {
+			return i18n.Errorf("trader[%d]: portfolio_settles目前仅支持exchange='gate'", i)
+		}
+		if err := c.Traders[i].Allocation.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.SoftClose.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.EntryConfirm.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.Flatten.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.DowntimeQueue.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.EntryLimit.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.Soak.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.Soak.Enabled && !c.DryRun && !forceDryRun && trader.ShadowOf == "" {
+			return i18n.Errorf("trader[%d]: soak只能用于模拟交易（dry_run或影子trader）", i)
+		}
+		for j, fault := range trader.Soak.Faults {
+			if _, err := scheduler.Parse(fault.Schedule); err != nil {
+				return fmt.Errorf("trader[%d]: soak.faults[%d].schedule: %w", i, j, err)
+			}
+		}
+		if err := trader.TradeLossCap.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.Staleness.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.BalanceAnomaly.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.Degradation.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.OrderRateLimit.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := c.Traders[i].RiskProfiles.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.Prompt.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if err := trader.LLM.Validate(); err != nil {
+			return fmt.Errorf("trader[%d]: %w", i, err)
+		}
+		if trader.Allocation.Rebalance != "" {
+			if _, err := scheduler.Parse(trader.Allocation.Rebalance); err != nil {
+				return fmt.Errorf("trader[%d]: allocation.rebalance: %w", i, err)
+			}
+		}
+		if trader.Allocation.Enabled() {
+			enabled := map[string]bool{"ai": true, "webhook": trader.Webhook.Enabled, "funding_harvest": trader.FundingHarvest.Enabled,
+				"basis": trader.Basis.Trade, "hedge": trader.Hedge.Enabled}
+			for _, name := range risk.AllocationStrategies {
+				if _, ok := trader.Allocation.Weights[name]; enabled[name] && !ok {
+					return i18n.Errorf("trader[%d]: allocation.weights缺少已启用策略%s的比例", i, name)
+				}
+			}
+		}
+	}
+
+	if err := c.CombinedExposure.Validate(); err != nil {
+		return err
+	}
+
+	for i, o := range c.SymbolOverrides {
+		if err := o.Validate(); err != nil {
+			return i18n.Errorf("symbol_overrides[%d]: %w", i, err)
 		}
 	}
 
@@ -187,23 +586,129 @@ func (c *Config) Validate() error {
 		c.APIServerPort = 8080 // 默认8080端口
 	}
 
+	if c.StorePath == "" {
+		c.StorePath = "data/nofx.db"
+	}
+	if c.KillSwitchFile == "" {
+		c.KillSwitchFile = "data/PAUSE"
+	}
+	if !i18n.Supported(c.Language) {
+		return i18n.Errorf("language必须是zh或en: %s", c.Language)
+	}
+
+	if forceDryRun {
+		c.DryRun = true
+	}
+	if c.DryRun && store.IsPostgres(c.StorePath) {
+		// 模拟交易不写入共享的PostgreSQL，改用本地SQLite
+		c.StorePath = "data/nofx.db"
+	}
+	if c.DryRun && c.StorePath != "-" {
+		// 模拟交易使用独立的交易日志，避免与实盘记录混在一起
+		ext := filepath.Ext(c.StorePath)
+		c.StorePath = strings.TrimSuffix(c.StorePath, ext) + "-dryrun" + ext
+	}
+
+	if err := c.Log.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Telegram.Validate(); err != nil {
+		return err
+	}
+	if err := c.Discord.Validate(); err != nil {
+		return err
+	}
+	if err := c.Slack.Validate(); err != nil {
+		return err
+	}
+	webhookNames := make(map[string]bool)
+	for i := range c.NotifyWebhooks {
+		hook := &c.NotifyWebhooks[i]
+		if err := hook.Validate(); err != nil {
+			return err
+		}
+		if hook.Enabled {
+			if webhookNames[hook.Name] {
+				return fmt.Errorf("notify_webhooks: 名称%s重复", hook.Name)
+			}
+			webhookNames[hook.Name] = true
+		}
+	}
+	if err := c.Summary.Validate(); err != nil {
+		return err
+	}
+	if err := c.Bars.Validate(); err != nil {
+		return err
+	}
+	if err := c.News.Validate(); err != nil {
+		return err
+	}
+	if err := c.ReportingCurrency.Validate(); err != nil {
+		return err
+	}
+	if err := c.FundingHistory.Validate(); err != nil {
+		return err
+	}
+	if c.FundingHistory.Enabled && c.StorePath == "-" {
+		return i18n.Errorf("funding_history需要启用交易日志存储（store_path不能为\"-\"）")
+	}
+	if err := c.EconomicCalendar.Validate(); err != nil {
+		return err
+	}
+
+	if c.Admin.Token != "" && len(c.Admin.Token) < 16 {
+		return i18n.Errorf("admin.token至少需要16个字符")
+	}
+	if c.Admin.GRPCPort < 0 || c.Admin.GRPCPort > 65535 {
+		return i18n.Errorf("admin.grpc_port无效: %d", c.Admin.GRPCPort)
+	}
+	if c.Admin.GRPCPort > 0 && c.Admin.GRPCPort == c.APIServerPort {
+		return i18n.Errorf("admin.grpc_port不能与api_server_port相同")
+	}
+
+	if c.Cache.AccountTTLSeconds < 0 {
+		return i18n.Errorf("cache.account_ttl_seconds不能为负数")
+	}
+	if c.Cache.AccountTTLSeconds == 0 {
+		c.Cache.AccountTTLSeconds = 15
+	}
+
 	// 设置杠杆默认值（适配币安子账户限制，最大5倍）
 	if c.Leverage.BTCETHLeverage <= 0 {
 		c.Leverage.BTCETHLeverage = 5 // 默认5倍（安全值，适配子账户）
 	}
 	if c.Leverage.BTCETHLeverage > 5 {
-		fmt.Printf("⚠️  警告: BTC/ETH杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n", c.Leverage.BTCETHLeverage)
+		fmt.Printf(i18n.T("⚠️  警告: BTC/ETH杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n"), c.Leverage.BTCETHLeverage)
 	}
 	if c.Leverage.AltcoinLeverage <= 0 {
 		c.Leverage.AltcoinLeverage = 5 // 默认5倍（安全值，适配子账户）
 	}
 	if c.Leverage.AltcoinLeverage > 5 {
-		fmt.Printf("⚠️  警告: 山寨币杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n", c.Leverage.AltcoinLeverage)
+		fmt.Printf(i18n.T("⚠️  警告: 山寨币杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n"), c.Leverage.AltcoinLeverage)
+	}
+	if err := c.Leverage.Auto.Validate(); err != nil {
+		return err
+	}
+	if err := c.Leverage.Liquidation.Validate(); err != nil {
+		return err
+	}
+	if err := c.Leverage.Account.Validate(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// AccountCacheTTL 交易器余额/持仓缓存时间
+func (c *Config) AccountCacheTTL() time.Duration {
+	return time.Duration(c.Cache.AccountTTLSeconds) * time.Second
+}
+
 // GetScanInterval 获取扫描间隔
 func (tc *TraderConfig) GetScanInterval() time.Duration {
 	return time.Duration(tc.ScanIntervalMinutes) * time.Minute
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"nofx/i18n"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// SupportedExtensions 支持的配置文件格式（按扩展名识别）
+var SupportedExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// DefaultFile 未指定配置文件时按 config.json → config.yaml → config.yml → config.toml 的顺序查找第一个存在的文件
+func DefaultFile() string {
+	for _, ext := range SupportedExtensions {
+		if _, err := os.Stat("config" + ext); err == nil {
+			return "config" + ext
+		}
+	}
+	return "config.json"
+}
+
+// decodeConfig 按文件扩展名解析配置
+// YAML/TOML先转换为JSON再解析，所有格式共用json标签定义的字段名；未知字段视为错误（避免拼写错误的风控参数被静默忽略）
+func decodeConfig(filename string, data []byte, config *Config) error {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(filename, ".example")))
+	switch ext {
+	case ".json", "":
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return i18n.Errorf("YAML语法错误: %w", err)
+		}
+		converted, err := json.Marshal(normalizeYAML(doc))
+		if err != nil {
+			return i18n.Errorf("转换YAML失败: %w", err)
+		}
+		data = converted
+	case ".toml":
+		var doc map[string]interface{}
+		if _, err := toml.Decode(string(data), &doc); err != nil {
+			return i18n.Errorf("TOML语法错误: %w", err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return i18n.Errorf("转换TOML失败: %w", err)
+		}
+		data = converted
+	default:
+		return i18n.Errorf("不支持的配置文件格式: %s（支持 %s）", ext, strings.Join(SupportedExtensions, "/"))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return describeDecodeError(err, data, ext)
+	}
+	return nil
+}
+
+// normalizeYAML 将YAML解析出的map[interface{}]interface{}转换为可JSON序列化的map[string]interface{}
+func normalizeYAML(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = normalizeYAML(item)
+		}
+		return value
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			result[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeYAML(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// describeDecodeError 将解析错误转换为带字段路径/行号的提示
+func describeDecodeError(err error, data []byte, ext string) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return i18n.Errorf("字段 %s 类型错误: 期望 %s，实际为 %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && (ext == ".json" || ext == "") {
+		line, col := position(data, syntaxErr.Offset)
+		return i18n.Errorf("JSON语法错误（第%d行第%d列）: %w", line, col, err)
+	}
+
+	// 未知字段: json: unknown field "xxx"
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return i18n.Errorf("未知的配置项 %s（请检查拼写，或参考config.json.example）", name)
+	}
+	return err
+}
+
+// position 将字节偏移转换为行号和列号（从1开始）
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
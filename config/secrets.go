@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"nofx/notify/httphook"
+	"nofx/secrets"
+	"strings"
+)
+
+// secretField 可以写成密钥引用（env:/file:/vault:/aws:）的字段
+type secretField struct {
+	name  string // 配置项路径（用于错误提示）
+	value *string
+}
+
+// secretFields 所有密钥字段
+func (c *Config) secretFields() []secretField {
+	fields := []secretField{
+		{"telegram.bot_token", &c.Telegram.BotToken},
+		{"discord.webhook_url", &c.Discord.WebhookURL},
+		{"slack.webhook_url", &c.Slack.WebhookURL},
+		{"admin.token", &c.Admin.Token},
+	}
+	for i := range c.NotifyWebhooks {
+		hook := &c.NotifyWebhooks[i]
+		prefix := fmt.Sprintf("notify_webhooks[%d].", i)
+		fields = append(fields,
+			secretField{prefix + "url", &hook.URL},
+			secretField{prefix + "secret", &hook.Secret},
+		)
+	}
+	for i := range c.Traders {
+		t := &c.Traders[i]
+		prefix := fmt.Sprintf("traders[%d].", i)
+		fields = append(fields,
+			secretField{prefix + "binance_api_key", &t.BinanceAPIKey},
+			secretField{prefix + "binance_secret_key", &t.BinanceSecretKey},
+			secretField{prefix + "hyperliquid_private_key", &t.HyperliquidPrivateKey},
+			secretField{prefix + "aster_private_key", &t.AsterPrivateKey},
+			secretField{prefix + "gate_api_key", &t.GateAPIKey},
+			secretField{prefix + "gate_secret_key", &t.GateSecretKey},
+			secretField{prefix + "qwen_key", &t.QwenKey},
+			secretField{prefix + "deepseek_key", &t.DeepSeekKey},
+			secretField{prefix + "custom_api_key", &t.CustomAPIKey},
+			secretField{prefix + "webhook.secret", &t.Webhook.Secret},
+		)
+	}
+	return fields
+}
+
+// resolveSecrets 将密钥引用替换为实际密钥（只保存在内存中）
+func (c *Config) resolveSecrets() error {
+	for _, f := range c.secretFields() {
+		if !secrets.IsRef(*f.value) {
+			continue
+		}
+		value, err := secrets.Resolve(*f.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.value = value
+	}
+	return nil
+}
+
+// redactedValue 脱敏后的密钥显示值
+const redactedValue = "******"
+
+// Redacted 返回隐藏所有密钥字段的配置副本（用于管理接口查看配置），notify_webhooks中凭证类请求头的值同样隐藏
+func (c *Config) Redacted() Config {
+	copied := *c
+	copied.Traders = append([]TraderConfig(nil), c.Traders...)
+	copied.NotifyWebhooks = append([]httphook.Config(nil), c.NotifyWebhooks...)
+	for _, f := range copied.secretFields() {
+		if *f.value != "" {
+			*f.value = redactedValue
+		}
+	}
+	for i := range copied.NotifyWebhooks {
+		hook := &copied.NotifyWebhooks[i]
+		if len(hook.Headers) == 0 {
+			continue
+		}
+		headers := make(map[string]string, len(hook.Headers))
+		for name, value := range hook.Headers {
+			if value != "" && sensitiveHeader(name) {
+				value = redactedValue
+			}
+			headers[name] = value
+		}
+		hook.Headers = headers
+	}
+	return copied
+}
+
+// sensitiveHeaderWords 请求头名称包含这些词时视为凭证（如Authorization、X-Api-Key、X-Auth-Token）
+var sensitiveHeaderWords = []string{"auth", "token", "key", "secret", "password", "cookie", "signature"}
+
+// sensitiveHeader 请求头的值是否需要脱敏
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package config
+
+import "nofx/i18n"
+
+// resolveShadows 检查影子trader（shadow_of）指向的实盘trader，未配置exchange时继承实盘trader的交易所和密钥
+func (c *Config) resolveShadows() error {
+	byID := make(map[string]int, len(c.Traders))
+	for i, t := range c.Traders {
+		byID[t.ID] = i
+	}
+
+	for i := range c.Traders {
+		shadow := &c.Traders[i]
+		if shadow.ShadowOf == "" {
+			continue
+		}
+		j, ok := byID[shadow.ShadowOf]
+		if !ok {
+			return i18n.Errorf("trader[%d]: shadow_of指向的trader不存在: %s", i, shadow.ShadowOf)
+		}
+		live := c.Traders[j]
+		if j == i || live.ShadowOf != "" {
+			return i18n.Errorf("trader[%d]: shadow_of必须指向另一个非影子trader: %s", i, shadow.ShadowOf)
+		}
+		if shadow.Exchange != "" {
+			continue
+		}
+		shadow.Exchange = live.Exchange
+		shadow.BinanceAPIKey, shadow.BinanceSecretKey = live.BinanceAPIKey, live.BinanceSecretKey
+		shadow.HyperliquidPrivateKey, shadow.HyperliquidWalletAddr, shadow.HyperliquidTestnet =
+			live.HyperliquidPrivateKey, live.HyperliquidWalletAddr, live.HyperliquidTestnet
+		shadow.AsterUser, shadow.AsterSigner, shadow.AsterPrivateKey = live.AsterUser, live.AsterSigner, live.AsterPrivateKey
+		shadow.GateAPIKey, shadow.GateSecretKey, shadow.GateTestnet = live.GateAPIKey, live.GateSecretKey, live.GateTestnet
+	}
+	return nil
+}
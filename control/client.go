@@ -0,0 +1,174 @@
+package control
+
+import (
+	"context"
+	"nofx/notify"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client 控制平面的类型化客户端
+//
+//	client, err := control.Dial("localhost:9090", token)
+//	positions, err := client.GetPositions(ctx, "gate_deepseek")
+//	events, err := client.WatchEvents(ctx, &control.WatchEventsRequest{})
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// tokenAuth 在每个请求的metadata中携带管理令牌
+type tokenAuth string
+
+func (t tokenAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (tokenAuth) RequireTransportSecurity() bool {
+	return false
+}
+
+// Dial 连接控制平面（默认不加密，opts可覆盖传输凭证，如通过TLS反向代理访问时）
+func Dial(addr, token string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(tokenAuth(token)),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)),
+	}, opts...)
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// call 调用一元方法
+func call[Resp any](ctx context.Context, c *Client, method string, req interface{}) (*Resp, error) {
+	resp := new(Resp)
+	if err := c.conn.Invoke(ctx, "/"+ServiceName+"/"+method, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetStatus trader运行状态（traderID为空时为第一个trader）
+func (c *Client) GetStatus(ctx context.Context, traderID string) (*Status, error) {
+	return call[Status](ctx, c, "GetStatus", &TraderRequest{TraderID: traderID})
+}
+
+// GetBalance 账户余额与盈亏
+func (c *Client) GetBalance(ctx context.Context, traderID string) (*Balance, error) {
+	return call[Balance](ctx, c, "GetBalance", &TraderRequest{TraderID: traderID})
+}
+
+// GetPositions 当前持仓
+func (c *Client) GetPositions(ctx context.Context, traderID string) (*Positions, error) {
+	return call[Positions](ctx, c, "GetPositions", &TraderRequest{TraderID: traderID})
+}
+
+// GetOrders 挂单和止损/止盈条件单
+func (c *Client) GetOrders(ctx context.Context, req *OrdersRequest) (*Orders, error) {
+	return call[Orders](ctx, c, "GetOrders", req)
+}
+
+// GetDecisions 最近的AI决策
+func (c *Client) GetDecisions(ctx context.Context, req *DecisionsRequest) (*Decisions, error) {
+	return call[Decisions](ctx, c, "GetDecisions", req)
+}
+
+// GetConfig 当前配置（密钥已脱敏）
+func (c *Client) GetConfig(ctx context.Context) (*ConfigResponse, error) {
+	return call[ConfigResponse](ctx, c, "GetConfig", &Empty{})
+}
+
+// Pause 暂停AI决策和新开仓（TraderID为空时暂停所有trader）
+func (c *Client) Pause(ctx context.Context, req *PauseRequest) (*ControlResponse, error) {
+	return call[ControlResponse](ctx, c, "Pause", req)
+}
+
+// Resume 恢复交易（traderID为空时恢复所有trader）
+func (c *Client) Resume(ctx context.Context, traderID string) (*ControlResponse, error) {
+	return call[ControlResponse](ctx, c, "Resume", &TraderRequest{TraderID: traderID})
+}
+
+// Flatten 市价平仓
+func (c *Client) Flatten(ctx context.Context, req *FlattenRequest) (*ControlResponse, error) {
+	return call[ControlResponse](ctx, c, "Flatten", req)
+}
+
+// CancelOrders 撤销指定币种的全部挂单
+func (c *Client) CancelOrders(ctx context.Context, req *CancelOrdersRequest) (*ControlResponse, error) {
+	return call[ControlResponse](ctx, c, "CancelOrders", req)
+}
+
+// Reload 热加载配置文件
+func (c *Client) Reload(ctx context.Context) (*ReloadResponse, error) {
+	return call[ReloadResponse](ctx, c, "Reload", &Empty{})
+}
+
+// EventStream WatchEvents的事件流
+type EventStream struct {
+	stream grpc.ClientStream
+}
+
+// Recv 接收下一个事件（ctx取消或连接断开时返回错误）
+func (s *EventStream) Recv() (*notify.Event, error) {
+	e := new(notify.Event)
+	if err := s.stream.RecvMsg(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// WatchEvents 订阅开平仓、止损止盈、风控、错误等事件
+func (c *Client) WatchEvents(ctx context.Context, req *WatchEventsRequest) (*EventStream, error) {
+	stream, err := c.openStream(ctx, "WatchEvents", req)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{stream: stream}, nil
+}
+
+// PositionStream WatchPositions的持仓流
+type PositionStream struct {
+	stream grpc.ClientStream
+}
+
+// Recv 接收下一次持仓变化
+func (s *PositionStream) Recv() (*Positions, error) {
+	p := new(Positions)
+	if err := s.stream.RecvMsg(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WatchPositions 订阅持仓变化（首次立即推送当前持仓）
+func (c *Client) WatchPositions(ctx context.Context, req *WatchPositionsRequest) (*PositionStream, error) {
+	stream, err := c.openStream(ctx, "WatchPositions", req)
+	if err != nil {
+		return nil, err
+	}
+	return &PositionStream{stream: stream}, nil
+}
+
+// openStream 打开服务端流并发送请求
+func (c *Client) openStream(ctx context.Context, method string, req interface{}) (grpc.ClientStream, error) {
+	desc := &grpc.StreamDesc{StreamName: method, ServerStreams: true}
+	stream, err := c.conn.NewStream(ctx, desc, "/"+ServiceName+"/"+method)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
@@ -0,0 +1,46 @@
+package control
+
+import (
+	"nofx/notify"
+	"sync"
+)
+
+// subscriberBuffer 每个订阅者的事件缓冲（消费过慢时丢弃新事件）
+const subscriberBuffer = 64
+
+// Hub 事件广播（实现notify.Notifier，将开平仓、止损止盈、风控等事件推送给WatchEvents订阅者）
+type Hub struct {
+	subs map[chan notify.Event]struct{}
+	mu   sync.Mutex
+}
+
+// NewHub 创建事件广播
+func NewHub() *Hub {
+	return &Hub{subs: make(map[chan notify.Event]struct{})}
+}
+
+// Notify 广播事件（非阻塞）
+func (h *Hub) Notify(e notify.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+			controlLog.Warn("事件订阅者消费过慢，丢弃事件", "kind", e.Kind, "trader", e.TraderID)
+		}
+	}
+}
+
+// subscribe 订阅事件，返回的cancel用于取消订阅
+func (h *Hub) subscribe() (<-chan notify.Event, func()) {
+	ch := make(chan notify.Event, subscriberBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,440 @@
+// Package control gRPC控制平面：与管理接口（/api/admin）对应的查询和控制方法，
+// 以及订单/持仓事件的流式订阅，供将nofx嵌入其他Go系统时使用类型化客户端（见Client）
+//
+// 消息按JSON编码（内容子类型application/grpc+json），鉴权与管理接口相同：
+// metadata中携带 authorization: Bearer <admin.token>
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"nofx/config"
+	"nofx/logging"
+	"nofx/manager"
+	"nofx/trader"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// controlLog 控制平面日志
+var controlLog = logging.For("control")
+
+// ServiceName gRPC服务名
+const ServiceName = "nofx.control.v1.Control"
+
+// defaultWatchInterval WatchPositions默认轮询间隔
+const defaultWatchInterval = 5 * time.Second
+
+// ReloadFunc 重新加载配置，返回已应用的变更和需要重启才能生效的变更
+type ReloadFunc func() (applied, restart []string, err error)
+
+// Server gRPC控制平面服务
+type Server struct {
+	tm     *manager.TraderManager
+	hub    *Hub
+	config func() *config.Config // 当前配置（令牌、查看配置，热加载后返回新配置）
+	reload ReloadFunc            // 热加载配置（为nil时Reload返回Unimplemented）
+	grpc   *grpc.Server
+}
+
+// NewServer 创建控制平面服务
+func NewServer(tm *manager.TraderManager, hub *Hub, configSource func() *config.Config, reload ReloadFunc) *Server {
+	s := &Server{tm: tm, hub: hub, config: configSource, reload: reload}
+	s.grpc = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.authUnary),
+		grpc.ChainStreamInterceptor(s.authStream),
+	)
+	s.grpc.RegisterService(&serviceDesc, s)
+	return s
+}
+
+// Serve 在指定端口上提供服务（阻塞直到Stop）
+func (s *Server) Serve(port int) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	controlLog.Info("gRPC控制平面已启动", "addr", lis.Addr().String(), "service", ServiceName)
+	return s.grpc.Serve(lis)
+}
+
+// Stop 停止服务（等待进行中的请求结束，流式订阅会被中断）
+func (s *Server) Stop() {
+	s.grpc.Stop()
+}
+
+// authorize 校验metadata中的管理令牌
+func (s *Server) authorize(ctx context.Context) error {
+	token := s.config().Admin.Token
+	if token == "" {
+		return status.Error(codes.Unavailable, "管理接口未启用，请配置admin.token")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	provided := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		provided = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		return status.Error(codes.Unauthenticated, "管理令牌无效")
+	}
+	return nil
+}
+
+func (s *Server) authUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) authStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// getTrader 查询类方法的目标trader（id为空时使用第一个trader）
+func (s *Server) getTrader(id string) (*trader.AutoTrader, error) {
+	if id == "" {
+		traders, _ := s.tm.SelectTraders("")
+		if len(traders) == 0 {
+			return nil, status.Error(codes.NotFound, "没有可用的trader")
+		}
+		return traders[0], nil
+	}
+	t, err := s.tm.GetTrader(id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return t, nil
+}
+
+// convert 将交易器返回的map按JSON字段名转换为类型化消息
+func convert(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// GetStatus trader运行状态
+func (s *Server) GetStatus(ctx context.Context, req *TraderRequest) (*Status, error) {
+	t, err := s.getTrader(req.TraderID)
+	if err != nil {
+		return nil, err
+	}
+	var result Status
+	if err := convert(t.GetStatus(), &result); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &result, nil
+}
+
+// GetBalance 账户余额与盈亏
+func (s *Server) GetBalance(ctx context.Context, req *TraderRequest) (*Balance, error) {
+	t, err := s.getTrader(req.TraderID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := t.GetAccountInfo()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "获取账户信息失败: %v", err)
+	}
+	result := Balance{TraderID: t.GetID()}
+	if err := convert(account, &result); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &result, nil
+}
+
+// GetPositions 当前持仓
+func (s *Server) GetPositions(ctx context.Context, req *TraderRequest) (*Positions, error) {
+	t, err := s.getTrader(req.TraderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.positions(t)
+}
+
+// positions 获取并转换持仓
+func (s *Server) positions(t *trader.AutoTrader) (*Positions, error) {
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "获取持仓失败: %v", err)
+	}
+	result := &Positions{TraderID: t.GetID(), Positions: []Position{}}
+	if err := convert(positions, &result.Positions); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return result, nil
+}
+
+// GetOrders 挂单和止损/止盈条件单
+func (s *Server) GetOrders(ctx context.Context, req *OrdersRequest) (*Orders, error) {
+	t, err := s.getTrader(req.TraderID)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]string, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+	orders, triggers, err := t.GetOpenOrders(symbols)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "获取挂单失败: %v", err)
+	}
+	return &Orders{TraderID: t.GetID(), Orders: orders, TriggerOrders: triggers}, nil
+}
+
+// GetDecisions 最近的AI决策
+func (s *Server) GetDecisions(ctx context.Context, req *DecisionsRequest) (*Decisions, error) {
+	t, err := s.getTrader(req.TraderID)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	records, err := t.GetDecisionLogger().GetLatestRecords(limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "读取决策日志失败: %v", err)
+	}
+	return &Decisions{TraderID: t.GetID(), Records: records}, nil
+}
+
+// GetConfig 当前配置（密钥已脱敏）
+func (s *Server) GetConfig(ctx context.Context, req *Empty) (*ConfigResponse, error) {
+	return &ConfigResponse{Config: s.config().Redacted()}, nil
+}
+
+// Pause 暂停AI决策和新开仓（止损止盈照常生效，重启后保持暂停）
+func (s *Server) Pause(ctx context.Context, req *PauseRequest) (*ControlResponse, error) {
+	traders, err := s.tm.SelectTraders(req.TraderID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	result := &ControlResponse{Traders: make([]TraderResult, 0, len(traders))}
+	for _, t := range traders {
+		cancelled, err := t.Pause(trader.PauseSourceGRPC, req.Reason, req.CancelOrders)
+		item := TraderResult{TraderID: t.GetID(), Paused: t.IsPaused(), Cancelled: cancelled}
+		if err != nil {
+			item.Error = err.Error()
+		}
+		result.Traders = append(result.Traders, item)
+	}
+	return result, nil
+}
+
+// Resume 恢复交易
+func (s *Server) Resume(ctx context.Context, req *TraderRequest) (*ControlResponse, error) {
+	traders, err := s.tm.SelectTraders(req.TraderID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	result := &ControlResponse{Traders: make([]TraderResult, 0, len(traders))}
+	for _, t := range traders {
+		t.Resume()
+		result.Traders = append(result.Traders, TraderResult{TraderID: t.GetID(), Paused: t.IsPaused()})
+	}
+	return result, nil
+}
+
+// Flatten 市价平仓
+func (s *Server) Flatten(ctx context.Context, req *FlattenRequest) (*ControlResponse, error) {
+	symbol := strings.ToUpper(req.Symbol)
+	if symbol == "" && !req.All {
+		return nil, status.Error(codes.InvalidArgument, "请指定symbol，或设置all=true平掉所有持仓")
+	}
+	traders, err := s.tm.SelectTraders(req.TraderID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	result := &ControlResponse{Traders: make([]TraderResult, 0, len(traders))}
+	for _, t := range traders {
+		closed, err := t.Flatten(symbol)
+		item := TraderResult{TraderID: t.GetID(), Paused: t.IsPaused(), Closed: closed}
+		if err != nil {
+			item.Error = err.Error()
+		}
+		result.Traders = append(result.Traders, item)
+	}
+	return result, nil
+}
+
+// CancelOrders 撤销挂单
+func (s *Server) CancelOrders(ctx context.Context, req *CancelOrdersRequest) (*ControlResponse, error) {
+	symbol := strings.ToUpper(req.Symbol)
+	if err := req.Scope.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if symbol == "" && req.Scope.IsZero() {
+		return nil, status.Error(codes.InvalidArgument, "请指定symbol")
+	}
+	traders, err := s.tm.SelectTraders(req.TraderID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	result := &ControlResponse{Traders: make([]TraderResult, 0, len(traders))}
+	for _, t := range traders {
+		item := TraderResult{TraderID: t.GetID(), Paused: t.IsPaused()}
+		var err error
+		if req.Scope.IsZero() {
+			err = t.CancelOrders(symbol)
+		} else {
+			item.Cancelled, err = t.CancelOrdersScoped(symbol, req.Scope)
+		}
+		if err != nil {
+			item.Error = err.Error()
+		}
+		result.Traders = append(result.Traders, item)
+	}
+	return result, nil
+}
+
+// Reload 热加载配置文件
+func (s *Server) Reload(ctx context.Context, req *Empty) (*ReloadResponse, error) {
+	if s.reload == nil {
+		return nil, status.Error(codes.Unimplemented, "未启用热加载")
+	}
+	applied, restart, err := s.reload()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &ReloadResponse{Applied: applied, RestartRequired: restart}, nil
+}
+
+// WatchEvents 推送开平仓、止损止盈、风控、错误等事件，直到客户端取消
+func (s *Server) WatchEvents(req *WatchEventsRequest, stream grpc.ServerStream) error {
+	kinds := make(map[string]bool, len(req.Kinds))
+	for _, kind := range req.Kinds {
+		kinds[string(kind)] = true
+	}
+
+	events, cancel := s.hub.subscribe()
+	defer cancel()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e := <-events:
+			if req.TraderID != "" && e.TraderID != req.TraderID {
+				continue
+			}
+			if len(kinds) > 0 && !kinds[string(e.Kind)] {
+				continue
+			}
+			if err := stream.SendMsg(&e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WatchPositions 定期查询持仓，首次及持仓变化时推送，直到客户端取消
+func (s *Server) WatchPositions(req *WatchPositionsRequest, stream grpc.ServerStream) error {
+	t, err := s.getTrader(req.TraderID)
+	if err != nil {
+		return err
+	}
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last []Position
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-stream.Context().Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+
+		positions, err := s.positions(t)
+		if err != nil {
+			controlLog.Warn("WatchPositions获取持仓失败", "trader", t.GetID(), "err", err)
+			continue
+		}
+		if !first && reflect.DeepEqual(positions.Positions, last) {
+			continue
+		}
+		last = positions.Positions
+		if err := stream.SendMsg(positions); err != nil {
+			return err
+		}
+	}
+}
+
+// unary 一元方法描述（请求解码后经拦截器调用fn）
+func unary[Req any, Resp any](name string, fn func(*Server, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return fn(srv.(*Server), ctx, req.(*Req))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+// serverStream 服务端流方法描述（先接收一个请求消息）
+func serverStream[Req any](name string, fn func(*Server, *Req, grpc.ServerStream) error) grpc.StreamDesc {
+	return grpc.StreamDesc{
+		StreamName:    name,
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			req := new(Req)
+			if err := stream.RecvMsg(req); err != nil {
+				return err
+			}
+			return fn(srv.(*Server), req, stream)
+		},
+	}
+}
+
+// serviceDesc 服务描述（手写，等价于protoc生成的代码）
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unary("GetStatus", (*Server).GetStatus),
+		unary("GetBalance", (*Server).GetBalance),
+		unary("GetPositions", (*Server).GetPositions),
+		unary("GetOrders", (*Server).GetOrders),
+		unary("GetDecisions", (*Server).GetDecisions),
+		unary("GetConfig", (*Server).GetConfig),
+		unary("Pause", (*Server).Pause),
+		unary("Resume", (*Server).Resume),
+		unary("Flatten", (*Server).Flatten),
+		unary("CancelOrders", (*Server).CancelOrders),
+		unary("Reload", (*Server).Reload),
+	},
+	Streams: []grpc.StreamDesc{
+		serverStream("WatchEvents", (*Server).WatchEvents),
+		serverStream("WatchPositions", (*Server).WatchPositions),
+	},
+}
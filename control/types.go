@@ -0,0 +1,160 @@
+package control
+
+import (
+	"nofx/config"
+	"nofx/logger"
+	"nofx/notify"
+	"nofx/store"
+	"nofx/trader"
+)
+
+// Empty 空消息
+type Empty struct{}
+
+// TraderRequest 指定trader的请求（查询类为空时使用第一个trader，控制类为空时作用于所有trader）
+type TraderRequest struct {
+	TraderID string `json:"trader_id"`
+}
+
+// Status trader运行状态
+type Status struct {
+	TraderID       string           `json:"trader_id"`
+	TraderName     string           `json:"trader_name"`
+	AIModel        string           `json:"ai_model"`
+	Exchange       string           `json:"exchange"`
+	IsRunning      bool             `json:"is_running"`
+	Paused         bool             `json:"paused"`
+	Pause          store.PauseState `json:"pause"` // 暂停来源、原因和开始时间
+	CallCount      int              `json:"call_count"`
+	RuntimeMinutes int              `json:"runtime_minutes"`
+	StartTime      string           `json:"start_time"`
+	StopUntil      string           `json:"stop_until"` // 风控暂停截止时间
+	DecisionCron   string           `json:"decision_cron"`
+	WatchdogCron   string           `json:"watchdog_cron"`
+}
+
+// Balance 账户余额与盈亏
+type Balance struct {
+	TraderID         string  `json:"trader_id"`
+	TotalEquity      float64 `json:"total_equity"`
+	WalletBalance    float64 `json:"wallet_balance"`
+	UnrealizedProfit float64 `json:"unrealized_profit"`
+	AvailableBalance float64 `json:"available_balance"`
+	TotalPnL         float64 `json:"total_pnl"`
+	TotalPnLPct      float64 `json:"total_pnl_pct"`
+	InitialBalance   float64 `json:"initial_balance"`
+	DailyPnL         float64 `json:"daily_pnl"`
+	PositionCount    int     `json:"position_count"`
+	MarginUsed       float64 `json:"margin_used"`
+	MarginUsedPct    float64 `json:"margin_used_pct"`
+}
+
+// Position 持仓
+type Position struct {
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"` // long/short
+	Quantity         float64 `json:"quantity"`
+	EntryPrice       float64 `json:"entry_price"`
+	EntryVWAP        float64 `json:"entry_vwap,omitempty"`   // 按成交滚动计算的开仓均价
+	EntryTWAP        float64 `json:"entry_twap,omitempty"`   // 各次开仓/加仓订单均价的简单平均
+	EntryOrders      int     `json:"entry_orders,omitempty"` // 开仓加上加仓的订单数
+	MarkPrice        float64 `json:"mark_price"`
+	Leverage         int     `json:"leverage"`
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`
+	UnrealizedPnLPct float64 `json:"unrealized_pnl_pct"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	MarginUsed       float64 `json:"margin_used"`
+	Funding          float64 `json:"funding"`          // 持仓期间累计资金费（正数表示收入）
+	TPLevelsFilled   int     `json:"tp_levels_filled"` // 分批止盈已成交档数
+	TPLevels         int     `json:"tp_levels"`        // 分批止盈总档数（0表示单一止盈）
+}
+
+// Positions 某个trader的全部持仓
+type Positions struct {
+	TraderID  string     `json:"trader_id"`
+	Positions []Position `json:"positions"`
+}
+
+// OrdersRequest 查询挂单（symbols为空时查询持仓币种）
+type OrdersRequest struct {
+	TraderID string   `json:"trader_id"`
+	Symbols  []string `json:"symbols"`
+}
+
+// Orders 挂单和止损/止盈条件单
+type Orders struct {
+	TraderID      string                `json:"trader_id"`
+	Orders        []trader.OpenOrder    `json:"orders"`
+	TriggerOrders []trader.TriggerOrder `json:"trigger_orders"`
+}
+
+// DecisionsRequest 查询最近的AI决策（limit默认10）
+type DecisionsRequest struct {
+	TraderID string `json:"trader_id"`
+	Limit    int    `json:"limit"`
+}
+
+// Decisions 最近的AI决策记录（含思维链和执行日志）
+type Decisions struct {
+	TraderID string                   `json:"trader_id"`
+	Records  []*logger.DecisionRecord `json:"records"`
+}
+
+// FlattenRequest 市价平仓（symbol为空时需设置all=true平掉所有币种）
+type FlattenRequest struct {
+	TraderID string `json:"trader_id"`
+	Symbol   string `json:"symbol"`
+	All      bool   `json:"all"`
+}
+
+// PauseRequest 暂停交易（TraderID为空时暂停所有trader）
+type PauseRequest struct {
+	TraderID     string `json:"trader_id"`
+	Reason       string `json:"reason"`
+	CancelOrders bool   `json:"cancel_orders"` // 同时撤销没有持仓的币种上的挂单
+}
+
+// CancelOrdersRequest 撤销指定币种的全部挂单（包括止损/止盈单）
+// 设置Scope的过滤条件时只撤销范围内的挂单，此时Symbol可为空（所有币种）
+type CancelOrdersRequest struct {
+	TraderID string             `json:"trader_id"`
+	Symbol   string             `json:"symbol"`
+	Scope    trader.CancelScope `json:"scope"`
+}
+
+// TraderResult 控制操作在单个trader上的结果
+type TraderResult struct {
+	TraderID  string `json:"trader_id"`
+	Paused    bool   `json:"paused"`
+	Closed    int    `json:"closed,omitempty"`    // 平仓数量（flatten）
+	Cancelled int    `json:"cancelled,omitempty"` // 撤单的币种数（pause）或按范围撤销的挂单数（cancel）
+	Error     string `json:"error,omitempty"`
+}
+
+// ControlResponse 控制操作结果
+type ControlResponse struct {
+	Traders []TraderResult `json:"traders"`
+}
+
+// ConfigResponse 当前配置（密钥已脱敏）
+type ConfigResponse struct {
+	Config config.Config `json:"config"`
+}
+
+// ReloadResponse 热加载结果
+type ReloadResponse struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// WatchEventsRequest 订阅订单/持仓事件（trader_id为空表示所有trader，kinds为空表示所有类型）
+type WatchEventsRequest struct {
+	TraderID string        `json:"trader_id"`
+	Kinds    []notify.Kind `json:"kinds"`
+}
+
+// WatchPositionsRequest 订阅持仓变化（按interval_seconds轮询，默认5秒，持仓变化时推送）
+type WatchPositionsRequest struct {
+	TraderID        string `json:"trader_id"`
+	IntervalSeconds int    `json:"interval_seconds"`
+}
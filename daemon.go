@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"nofx/config"
+	"nofx/i18n"
+	"nofx/manager"
+	"nofx/trader"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 进程退出码（参考sysexits.h，供systemd的Restart=on-failure和RestartPreventExitStatus使用）
+const (
+	exitOK          = 0
+	exitFailure     = 1  // 运行时错误（可自动重启）
+	exitUsage       = 64 // 命令行参数错误
+	exitUnavailable = 69 // 启动自检失败：交易所不可达、时钟偏差等（稍后重启可能恢复）
+	exitPIDConflict = 73 // PID文件被另一个运行中的实例占用
+	exitConfig      = 78 // 配置错误或API密钥无效（重启无法恢复）
+)
+
+// defaultPIDFile 守护进程模式默认的PID文件
+const defaultPIDFile = "data/nofx.pid"
+
+// runOptions 交易系统启动参数
+type runOptions struct {
+	configFile    string
+	pidFile       string // 为空时不写PID文件
+	daemon        bool   // 守护进程模式：启动自检失败时以对应退出码退出，否则只告警
+	skipSelfCheck bool
+}
+
+// parseRunOptions 解析启动参数
+//
+//	nofx [config.json] [--dry-run]
+//	nofx daemon [--config <file>] [--pid-file <file>] [--dry-run] [--skip-self-check]
+func parseRunOptions(args []string, daemon bool) (runOptions, error) {
+	opts := runOptions{daemon: daemon}
+	fs := flag.NewFlagSet("nofx", flag.ContinueOnError)
+	fs.StringVar(&opts.configFile, "config", config.DefaultFile(), "配置文件")
+	pidDefault := ""
+	if daemon {
+		pidDefault = defaultPIDFile
+	}
+	fs.StringVar(&opts.pidFile, "pid-file", pidDefault, "PID文件（-为不写入）")
+	fs.BoolVar(&opts.skipSelfCheck, "skip-self-check", false, "跳过启动自检")
+	dryRun := fs.Bool("dry-run", false, "模拟交易模式")
+
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return opts, err
+	}
+	switch len(positional) {
+	case 0:
+	case 1:
+		opts.configFile = positional[0]
+	default:
+		return opts, i18n.Errorf("多余的参数: %s", strings.Join(positional[1:], " "))
+	}
+	if opts.pidFile == "-" {
+		opts.pidFile = ""
+	}
+	if *dryRun {
+		config.ForceDryRun()
+	}
+	return opts, nil
+}
+
+// errPIDConflict 另一个实例正在运行
+var errPIDConflict = errors.New("另一个实例正在运行")
+
+// writePIDFile 写入当前进程PID；文件已存在且对应进程仍在运行时拒绝启动（残留的PID文件直接覆盖）
+func writePIDFile(path string) error {
+	if data, err := os.ReadFile(path); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid != os.Getpid() && processAlive(pid) {
+			return fmt.Errorf("%w（PID %d，PID文件 %s）", errPIDConflict, pid, path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// removePIDFile 退出时删除PID文件（仅当文件仍属于当前进程）
+func removePIDFile(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		mainLog.Warn("删除PID文件失败", "path", path, "err", err)
+	}
+}
+
+// runSelfCheck 执行启动自检并输出结果，返回建议的退出码（全部通过时为exitOK）和密钥是否属于另一个网络（测试网/主网）
+// 密钥无效属于配置问题（exitConfig），其余失败可能是暂时性的（exitUnavailable）
+func runSelfCheck(tm *manager.TraderManager) (code int, networkMismatch bool) {
+	mainLog.Info("启动自检")
+	code = exitOK
+	for _, r := range tm.SelfCheck() {
+		if r.OK {
+			level := slog.LevelInfo
+			if r.Warning {
+				level = slog.LevelWarn
+			}
+			mainLog.Log(context.Background(), level, "自检项", "trader", r.TraderID, "check", r.Check, "detail", r.Detail)
+			continue
+		}
+		mainLog.Error("自检项失败", "trader", r.TraderID, "check", r.Check, "detail", r.Detail)
+		if r.Check == trader.CheckNetwork {
+			networkMismatch = true
+		}
+		if r.Permanent {
+			code = exitConfig
+		} else if code == exitOK {
+			code = exitUnavailable
+		}
+	}
+	return code, networkMismatch
+}
+
+// sdNotify 向systemd报告状态（Type=notify；未设置NOTIFY_SOCKET时忽略）
+func sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		mainLog.Warn("通知systemd失败", "state", state, "err", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		mainLog.Warn("通知systemd失败", "state", state, "err", err)
+	}
+}
@@ -0,0 +1,93 @@
+package decision
+
+import (
+	"strings"
+)
+
+// 提示词分段的保留优先级（数值越小越优先保留，超出预算时从最低优先级开始整段删除）
+const (
+	priorityRequired   = iota // 时间、账户、风控参数、持仓概要和输出要求（始终保留）
+	priorityPositions         // 持仓币种的完整行情
+	priorityOutcomes          // 近期表现（夏普比率）
+	priorityIndicators        // 候选币种的行情指标
+	priorityNews              // 经济日历和宏观新闻
+)
+
+// MinContextTokens 提示词预算的最小值（系统提示词本身约需两千token）
+const MinContextTokens = 4000
+
+// promptSection 提示词的一段（整段保留或整段删除，不会在段落中间截断）
+type promptSection struct {
+	priority int
+	label    string // 删除时在提示词和日志中列出的名称
+	text     string
+}
+
+// EstimateTokens 粗略估算文本的token数（ASCII约4个字符一个token，中文等非ASCII字符各计一个，估算偏保守）
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < 0x80 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// assemblePrompt 按原顺序拼接各段，超出预算时删除优先级最低的段落（同一优先级先删排在后面的，如排名靠后的候选币种）
+// budget<=0表示不限制；返回拼接结果和被删除段落的名称（按原顺序）
+func assemblePrompt(sections []promptSection, budget int) (string, []string) {
+	dropped := make([]bool, len(sections))
+	if budget > 0 {
+		total := 0
+		for _, s := range sections {
+			total += EstimateTokens(s.text)
+		}
+		for total+EstimateTokens(omittedNote(sections, dropped)) > budget {
+			victim := -1
+			for i, s := range sections {
+				if dropped[i] || s.priority == priorityRequired {
+					continue
+				}
+				if victim < 0 || s.priority >= sections[victim].priority {
+					victim = i
+				}
+			}
+			if victim < 0 {
+				break // 只剩必需内容，超出预算也照常发送
+			}
+			dropped[victim] = true
+			total -= EstimateTokens(sections[victim].text)
+		}
+	}
+
+	var sb strings.Builder
+	var omitted []string
+	for i, s := range sections {
+		if dropped[i] {
+			omitted = append(omitted, s.label)
+			continue
+		}
+		if i == len(sections)-1 {
+			sb.WriteString(omittedNote(sections, dropped))
+		}
+		sb.WriteString(s.text)
+	}
+	return sb.String(), omitted
+}
+
+// omittedNote 告知AI哪些内容因预算被省略（避免把缺失的数据当作没有信号）
+func omittedNote(sections []promptSection, dropped []bool) string {
+	var labels []string
+	for i, s := range sections {
+		if dropped[i] {
+			labels = append(labels, s.label)
+		}
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	return "**上下文预算**: 为控制提示词长度已省略以下内容（缺失的数据不代表没有信号）: " + strings.Join(labels, "、") + "\n\n"
+}
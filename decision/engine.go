@@ -1,21 +1,34 @@
 package decision
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"nofx/logging"
 	"nofx/market"
 	"nofx/mcp"
+	"nofx/news"
 	"nofx/pool"
+	"nofx/risk"
 	"strings"
 	"time"
 )
 
+// decisionLog 决策引擎日志
+var decisionLog = logging.For("decision")
+
+// ErrAIUnreachable 调用AI API失败（网络错误、超时、服务端错误等，不含响应解析失败）
+var ErrAIUnreachable = errors.New("调用AI API失败")
+
 // PositionInfo 持仓信息
 type PositionInfo struct {
 	Symbol           string  `json:"symbol"`
 	Side             string  `json:"side"` // "long" or "short"
 	EntryPrice       float64 `json:"entry_price"`
+	EntryVWAP        float64 `json:"entry_vwap,omitempty"`   // 按成交滚动计算的开仓均价（多次成交/加仓的实际成本，0表示没有成交记录）
+	EntryTWAP        float64 `json:"entry_twap,omitempty"`   // 各次开仓/加仓订单均价的简单平均
+	EntryOrders      int     `json:"entry_orders,omitempty"` // 计入开仓均价的订单数（开仓加上加仓次数）
 	MarkPrice        float64 `json:"mark_price"`
 	Quantity         float64 `json:"quantity"`
 	Leverage         int     `json:"leverage"`
@@ -23,18 +36,25 @@ type PositionInfo struct {
 	UnrealizedPnLPct float64 `json:"unrealized_pnl_pct"`
 	LiquidationPrice float64 `json:"liquidation_price"`
 	MarginUsed       float64 `json:"margin_used"`
-	UpdateTime       int64   `json:"update_time"` // 持仓更新时间戳（毫秒）
+	Funding          float64 `json:"funding"`          // 持仓期间累计资金费（正数表示收入，负数表示支出）
+	StopLoss         float64 `json:"stop_loss"`        // 交易所上生效的止损触发价（0表示没有）
+	TakeProfit       float64 `json:"take_profit"`      // 交易所上生效的止盈触发价（0表示没有，分批止盈为最近一档）
+	TPLevelsFilled   int     `json:"tp_levels_filled"` // 分批止盈已成交档数
+	TPLevels         int     `json:"tp_levels"`        // 分批止盈总档数（0表示单一止盈）
+	ADLRank          int     `json:"adl_rank"`         // 自动减仓排名（1-5，越高越先被自动减仓；0表示交易所未提供）
+	UpdateTime       int64   `json:"update_time"`      // 持仓更新时间戳（毫秒）
 }
 
 // AccountInfo 账户信息
 type AccountInfo struct {
-	TotalEquity      float64 `json:"total_equity"`      // 账户净值
-	AvailableBalance float64 `json:"available_balance"` // 可用余额
-	TotalPnL         float64 `json:"total_pnl"`         // 总盈亏
-	TotalPnLPct      float64 `json:"total_pnl_pct"`     // 总盈亏百分比
-	MarginUsed       float64 `json:"margin_used"`       // 已用保证金
-	MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
-	PositionCount    int     `json:"position_count"`    // 持仓数量
+	TotalEquity       float64 `json:"total_equity"`       // 账户净值
+	AvailableBalance  float64 `json:"available_balance"`  // 可用余额
+	TotalPnL          float64 `json:"total_pnl"`          // 总盈亏
+	TotalPnLPct       float64 `json:"total_pnl_pct"`      // 总盈亏百分比
+	MarginUsed        float64 `json:"margin_used"`        // 已用保证金
+	MarginUsedPct     float64 `json:"margin_used_pct"`    // 保证金使用率
+	EffectiveLeverage float64 `json:"effective_leverage"` // 有效杠杆（持仓总名义价值 / 净值）
+	PositionCount     int     `json:"position_count"`     // 持仓数量
 }
 
 // CandidateCoin 候选币种（来自币种池）
@@ -55,17 +75,24 @@ type OITopData struct {
 
 // Context 交易上下文（传递给AI的完整信息）
 type Context struct {
-	CurrentTime     string                  `json:"current_time"`
-	RuntimeMinutes  int                     `json:"runtime_minutes"`
-	CallCount       int                     `json:"call_count"`
-	Account         AccountInfo             `json:"account"`
-	Positions       []PositionInfo          `json:"positions"`
-	CandidateCoins  []CandidateCoin         `json:"candidate_coins"`
-	MarketDataMap   map[string]*market.Data `json:"-"` // 不序列化，但内部使用
-	OITopDataMap    map[string]*OITopData   `json:"-"` // OI Top数据映射
-	Performance     interface{}             `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
-	BTCETHLeverage  int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
-	AltcoinLeverage int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	CurrentTime     string                 `json:"current_time"`
+	RuntimeMinutes  int                    `json:"runtime_minutes"`
+	CallCount       int                    `json:"call_count"`
+	Account         AccountInfo            `json:"account"`
+	Positions       []PositionInfo         `json:"positions"`
+	CandidateCoins  []CandidateCoin        `json:"candidate_coins"`
+	Snapshot        *market.MarketSnapshot `json:"-"` // 行情和账户快照（账户部分由调用方填入，行情由FetchMarketData获取）
+	OITopDataMap    map[string]*OITopData  `json:"-"` // OI Top数据映射
+	Performance     interface{}            `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
+	BTCETHLeverage  int                    `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
+	AltcoinLeverage int                    `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	AutoLeverage    bool                   `json:"-"` // 杠杆由系统按波动率计算（AI无需给出杠杆，上面两项为上限）
+	Events          []string               `json:"-"` // 即将公布的经济日历事件
+
+	RiskProfile func(symbol string) risk.RiskProfile `json:"-"` // 币种生效的风控参数（为nil时按上面的杠杆和默认敞口上限）
+	RiskRules   []string                             `json:"-"` // 按策略/币种覆盖的风控参数摘要（写入提示词）
+	PromptRules string                               `json:"-"` // 候选提示词的追加规则（追加到系统提示词末尾）
+	TokenBudget int                                  `json:"-"` // 系统和用户提示词合计的token预算（0表示不限制）
 }
 
 // Decision AI的交易决策
@@ -76,9 +103,58 @@ type Decision struct {
 	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
 	StopLoss        float64 `json:"stop_loss,omitempty"`
 	TakeProfit      float64 `json:"take_profit,omitempty"`
-	Confidence      int     `json:"confidence,omitempty"` // 信心度 (0-100)
-	RiskUSD         float64 `json:"risk_usd,omitempty"`   // 最大美元风险
-	Reasoning       string  `json:"reasoning"`
+	Confidence      int     `json:"confidence,omitempty"`  // 信心度 (0-100)
+	RiskUSD         float64 `json:"risk_usd,omitempty"`    // 最大美元风险
+	LimitPrice      float64 `json:"limit_price,omitempty"` // 开仓限价（>0时挂GTC限价单，超时未成交自动撤单）
+	Reasoning       string  `json:"reasoning"`             // 简短理由（开仓和平仓必填，随订单保存并显示在通知和仪表盘中）
+
+	// 止损止盈条件单有效期（秒，0使用配置的默认值；仅外部信号设置，AI不输出该字段）
+	TriggerExpiration int `json:"trigger_expiration,omitempty"`
+
+	// 止损止盈条件单的触发价格类型（mark/last/index，为空使用配置的默认值；仅外部信号设置，AI不输出该字段）
+	TriggerPriceType string `json:"trigger_price_type,omitempty"`
+}
+
+// 止损止盈条件单有效期范围（秒）
+const (
+	MinTriggerExpiration = 86400       // 1天（临近到期时自动续期，有效期过短会频繁重挂）
+	MaxTriggerExpiration = 365 * 86400 // 1年
+)
+
+// CheckTriggerExpiration 检查条件单有效期（0表示使用默认值）
+func CheckTriggerExpiration(seconds int) error {
+	if seconds != 0 && (seconds < MinTriggerExpiration || seconds > MaxTriggerExpiration) {
+		return fmt.Errorf("条件单有效期必须在%d-%d秒之间: %d", MinTriggerExpiration, MaxTriggerExpiration, seconds)
+	}
+	return nil
+}
+
+// MaxReasoningLen 决策理由的最大长度（字符数，超出部分截断）
+const MaxReasoningLen = 200
+
+// ShortReasoning 规范化决策理由：合并空白和换行为单个空格，超过MaxReasoningLen时截断
+func ShortReasoning(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > MaxReasoningLen {
+		text = string(runes[:MaxReasoningLen-1]) + "…"
+	}
+	return text
+}
+
+// 条件单触发价格类型
+const (
+	TriggerPriceMark  = "mark"  // 标记价格（默认）
+	TriggerPriceLast  = "last"  // 最新成交价
+	TriggerPriceIndex = "index" // 指数价格
+)
+
+// CheckTriggerPriceType 检查条件单触发价格类型（空表示使用默认值）
+func CheckTriggerPriceType(priceType string) error {
+	switch priceType {
+	case "", TriggerPriceMark, TriggerPriceLast, TriggerPriceIndex:
+		return nil
+	}
+	return fmt.Errorf("条件单触发价格类型无效: %s（可选: mark/last/index）", priceType)
 }
 
 // FullDecision AI的完整决策（包含思维链）
@@ -87,6 +163,7 @@ type FullDecision struct {
 	CoTTrace   string     `json:"cot_trace"`   // 思维链分析（AI输出）
 	Decisions  []Decision `json:"decisions"`   // 具体决策列表
 	Timestamp  time.Time  `json:"timestamp"`
+	Omitted    []string   `json:"omitted,omitempty"` // 因token预算从prompt中省略的内容
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
@@ -95,31 +172,66 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 	if err := fetchMarketDataForContext(ctx); err != nil {
 		return nil, fmt.Errorf("获取市场数据失败: %w", err)
 	}
+	return Decide(ctx, mcpClient)
+}
 
+// FetchMarketData 为上下文中的持仓和候选币种获取市场数据（GetFullDecision的第一步，单独调用时可分别计时和设置超时）
+func FetchMarketData(ctx *Context) error {
+	return fetchMarketDataForContext(ctx)
+}
+
+// Decide 按已获取市场数据的上下文构建prompt、调用AI并解析决策
+func Decide(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
+	return DecideContext(context.Background(), ctx, mcpClient)
+}
+
+// DecideContext 同Decide，callCtx取消时中止AI请求和重试等待
+func DecideContext(callCtx context.Context, ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
 	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
-	systemPrompt := buildSystemPrompt(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
-	userPrompt := buildUserPrompt(ctx)
+	systemPrompt := buildSystemPrompt(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, ctx.AutoLeverage)
+	if ctx.PromptRules != "" {
+		systemPrompt += "\n\n# 📌 附加规则\n\n" + ctx.PromptRules + "\n"
+	}
+	if mcpClient.JSONMode {
+		systemPrompt += jsonModeFormat
+	}
+	budget := 0
+	if ctx.TokenBudget > 0 {
+		budget = max(ctx.TokenBudget-EstimateTokens(systemPrompt), 1)
+	}
+	userPrompt, omitted := buildUserPrompt(ctx, budget)
+	if len(omitted) > 0 {
+		decisionLog.Info("提示词超出token预算，已省略部分内容", "budget", ctx.TokenBudget, "omitted", strings.Join(omitted, "、"))
+	}
 
 	// 3. 调用AI API（使用 system + user prompt）
-	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	aiResponse, err := mcpClient.CallWithContext(callCtx, systemPrompt, userPrompt)
 	if err != nil {
-		return nil, fmt.Errorf("调用AI API失败: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrAIUnreachable, err)
 	}
 
 	// 4. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	limits := ctx.RiskProfile
+	if limits == nil {
+		limits = defaultLimits(ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	}
+	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, limits, ctx.AutoLeverage)
 	if err != nil {
 		return nil, fmt.Errorf("解析AI响应失败: %w", err)
 	}
 
 	decision.Timestamp = time.Now()
 	decision.UserPrompt = userPrompt // 保存输入prompt
+	decision.Omitted = omitted
 	return decision, nil
 }
 
-// fetchMarketDataForContext 为上下文中的所有币种获取市场数据和OI数据
+// fetchMarketDataForContext 为上下文中的所有币种获取市场数据和OI数据，写入快照（没有快照时新建，快照时间由调用方记录）
 func fetchMarketDataForContext(ctx *Context) error {
-	ctx.MarketDataMap = make(map[string]*market.Data)
+	if ctx.Snapshot == nil {
+		ctx.Snapshot = &market.MarketSnapshot{}
+	}
+	ctx.Snapshot.Symbols = make(map[string]*market.Data)
 	ctx.OITopDataMap = make(map[string]*OITopData)
 
 	// 收集所有需要获取数据的币种
@@ -150,7 +262,7 @@ func fetchMarketDataForContext(ctx *Context) error {
 		data, err := market.Get(symbol)
 		if err != nil {
 			// 单个币种失败不影响整体，只记录错误
-			log.Printf("⚠️  获取 %s 市场数据失败: %v", symbol, err)
+			decisionLog.Warn("获取市场数据失败", "symbol", symbol, "err", err)
 			continue
 		}
 
@@ -158,6 +270,13 @@ func fetchMarketDataForContext(ctx *Context) error {
 		// 持仓价值 = 持仓量 × 当前价格
 		// 但现有持仓必须保留（需要决策是否平仓）
 		isExistingPosition := positionSymbols[symbol]
+
+		// 指标未完整预热的候选币种（K线历史不足，如新上线的合约）不做，现有持仓照常保留
+		if !isExistingPosition && !data.IndicatorsWarm {
+			decisionLog.Info("K线历史不足以完整预热指标，跳过此币种", "symbol", symbol)
+			continue
+		}
+
 		if !isExistingPosition && data.OpenInterest != nil && data.CurrentPrice > 0 {
 			// 计算持仓价值（USD）= 持仓量 × 当前价格
 			oiValue := data.OpenInterest.Latest * data.CurrentPrice
@@ -165,17 +284,17 @@ func fetchMarketDataForContext(ctx *Context) error {
 			if oiValueInMillions < 15 {
 				// 测试网可能没有持仓量数据，如果持仓量为0，跳过过滤（允许测试）
 				if data.OpenInterest.Latest == 0 {
-					log.Printf("ℹ️  %s 持仓量为0（可能是测试网数据），允许通过", symbol)
-					ctx.MarketDataMap[symbol] = data
+					decisionLog.Debug("持仓量为0（可能是测试网数据），允许通过", "symbol", symbol)
+					ctx.Snapshot.Symbols[symbol] = data
 					continue
 				}
-				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
-					symbol, oiValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
+				decisionLog.Info("持仓价值低于15M USD，跳过此币种", "symbol", symbol, "oi_value_m", oiValueInMillions,
+					"open_interest", data.OpenInterest.Latest, "price", data.CurrentPrice)
 				continue
 			}
 		}
 
-		ctx.MarketDataMap[symbol] = data
+		ctx.Snapshot.Symbols[symbol] = data
 	}
 
 	// 加载OI Top数据（不影响主流程）
@@ -206,8 +325,11 @@ func calculateMaxCandidates(ctx *Context) int {
 	return len(ctx.CandidateCoins)
 }
 
+// PromptVersion 系统提示词版本（修改buildSystemPrompt的规则时递增），写入订单文本和交易日志，用于按提示词版本统计交易表现
+const PromptVersion = "p1"
+
 // buildSystemPrompt 构建 System Prompt（固定规则，可缓存）
-func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int) string {
+func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, autoLeverage bool) string {
 	var sb strings.Builder
 
 	// === 核心使命 ===
@@ -231,7 +353,11 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	sb.WriteString("2. **最多持仓**: 3个币种（质量>数量）\n")
 	sb.WriteString(fmt.Sprintf("3. **单币仓位**: 山寨%.0f-%.0f U(%dx杠杆) | BTC/ETH %.0f-%.0f U(%dx杠杆)\n",
 		accountEquity*0.8, accountEquity*1.5, altcoinLeverage, accountEquity*5, accountEquity*10, btcEthLeverage))
-	sb.WriteString("4. **保证金**: 总使用率 ≤ 90%\n\n")
+	sb.WriteString("4. **保证金**: 总使用率 ≤ 90%\n")
+	if autoLeverage {
+		sb.WriteString(fmt.Sprintf("5. **杠杆**: 由系统按币种波动率（ATR）自动计算（山寨最高%dx，BTC/ETH最高%dx），无需给出leverage字段\n", altcoinLeverage, btcEthLeverage))
+	}
+	sb.WriteString("\n")
 
 	// === 做空激励 ===
 	sb.WriteString("# 📉 做多做空平衡\n\n")
@@ -309,7 +435,13 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	sb.WriteString("**字段说明**:\n")
 	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | hold | wait\n")
 	sb.WriteString("- `confidence`: 0-100（开仓建议≥75）\n")
-	sb.WriteString("- 开仓时必填: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n\n")
+	sb.WriteString("- `reasoning`: 一句话说明依据（50字以内），会显示在通知和仪表盘中供人工核对\n")
+	if autoLeverage {
+		sb.WriteString("- 开仓时必填: position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning（leverage由系统计算，填写也会被忽略）\n")
+	} else {
+		sb.WriteString("- 开仓时必填: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n")
+	}
+	sb.WriteString("- 平仓时必填: reasoning\n\n")
 
 	// === 关键提醒 ===
 	sb.WriteString("---\n\n")
@@ -323,27 +455,66 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 }
 
 // buildUserPrompt 构建 User Prompt（动态数据）
-func buildUserPrompt(ctx *Context) string {
+func buildUserPrompt(ctx *Context, budget int) (string, []string) {
+	var sections []promptSection
 	var sb strings.Builder
+	// section 把已写入sb的内容作为一段
+	section := func(priority int, label string) {
+		if sb.Len() > 0 {
+			sections = append(sections, promptSection{priority: priority, label: label, text: sb.String()})
+			sb.Reset()
+		}
+	}
 
 	// 系统状态
 	sb.WriteString(fmt.Sprintf("**时间**: %s | **周期**: #%d | **运行**: %d分钟\n\n",
 		ctx.CurrentTime, ctx.CallCount, ctx.RuntimeMinutes))
 
 	// BTC 市场
-	if btcData, hasBTC := ctx.MarketDataMap["BTCUSDT"]; hasBTC {
+	if btcData := ctx.Snapshot.Data("BTCUSDT"); btcData != nil {
 		sb.WriteString(fmt.Sprintf("**BTC**: %.2f (1h: %+.2f%%, 4h: %+.2f%%) | MACD: %.4f | RSI: %.2f\n\n",
 			btcData.CurrentPrice, btcData.PriceChange1h, btcData.PriceChange4h,
 			btcData.CurrentMACD, btcData.CurrentRSI7))
 	}
 
+	// 按策略/币种覆盖的风控参数（优先于系统提示中的默认上限）
+	if len(ctx.RiskRules) > 0 {
+		sb.WriteString("**风控参数**（优先于默认的杠杆和仓位上限，超出的开仓会被拒绝）:\n")
+		for _, rule := range ctx.RiskRules {
+			sb.WriteString("- " + rule + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	section(priorityRequired, "")
+
+	// 经济日历（即将公布的重要事件）
+	if len(ctx.Events) > 0 {
+		sb.WriteString("**经济日历**（即将公布，公布前后波动可能加剧）:\n")
+		for _, e := range ctx.Events {
+			sb.WriteString("- " + e + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	section(priorityNews, "经济日历")
+
+	// 宏观新闻（配置了宏观新闻源时）
+	if macro := news.Macro(); len(macro) > 0 {
+		sb.WriteString("**宏观新闻**:\n")
+		for _, h := range macro {
+			sb.WriteString(fmt.Sprintf("- [%s, %s, 情绪%+.2f] %s\n", h.Published.UTC().Format("01-02 15:04"), h.Source, h.Sentiment, h.Title))
+		}
+		sb.WriteString("\n")
+	}
+	section(priorityNews, "宏观新闻")
+
 	// 账户
-	sb.WriteString(fmt.Sprintf("**账户**: 净值%.2f | 余额%.2f (%.1f%%) | 盈亏%+.2f%% | 保证金%.1f%% | 持仓%d个\n\n",
+	sb.WriteString(fmt.Sprintf("**账户**: 净值%.2f | 余额%.2f (%.1f%%) | 盈亏%+.2f%% | 保证金%.1f%% | 有效杠杆%.2fx | 持仓%d个\n\n",
 		ctx.Account.TotalEquity,
 		ctx.Account.AvailableBalance,
 		(ctx.Account.AvailableBalance/ctx.Account.TotalEquity)*100,
 		ctx.Account.TotalPnLPct,
 		ctx.Account.MarginUsedPct,
+		ctx.Account.EffectiveLeverage,
 		ctx.Account.PositionCount))
 
 	// 持仓（完整市场数据）
@@ -364,27 +535,56 @@ func buildUserPrompt(ctx *Context) string {
 				}
 			}
 
-			sb.WriteString(fmt.Sprintf("%d. %s %s | 入场价%.4f 当前价%.4f | 盈亏%+.2f%% | 杠杆%dx | 保证金%.0f | 强平价%.4f%s\n\n",
+			// 累计资金费（持仓成本，决定是否继续持有时需计入）
+			funding := ""
+			if pos.Funding != 0 {
+				funding = fmt.Sprintf(" | 累计资金费%+.2f", pos.Funding)
+			}
+			// 交易所上生效的止损/止盈（调整时参考）
+			if pos.StopLoss > 0 {
+				funding += fmt.Sprintf(" | 止损%.4f", pos.StopLoss)
+			}
+			if pos.TakeProfit > 0 {
+				funding += fmt.Sprintf(" | 止盈%.4f", pos.TakeProfit)
+			}
+			// 分批止盈进度（已部分止盈的持仓剩余仓位较小）
+			if pos.TPLevels > 0 {
+				funding += fmt.Sprintf(" | 分批止盈已成交%d/%d档", pos.TPLevelsFilled, pos.TPLevels)
+			}
+			// 多次开仓/加仓时的成交均价（止损止盈按实际成本计算）
+			if pos.EntryOrders > 1 && pos.EntryVWAP > 0 {
+				funding += fmt.Sprintf(" | 成交均价%.4f（%d次开仓/加仓）", pos.EntryVWAP, pos.EntryOrders)
+			}
+			// 自动减仓排名（高排名的盈利持仓可能被交易所强制减仓）
+			if pos.ADLRank > 0 {
+				funding += fmt.Sprintf(" | ADL排名%d/5", pos.ADLRank)
+			}
+
+			sb.WriteString(fmt.Sprintf("%d. %s %s | 入场价%.4f 当前价%.4f | 盈亏%+.2f%% | 杠杆%dx | 保证金%.0f | 强平价%.4f%s%s\n\n",
 				i+1, pos.Symbol, strings.ToUpper(pos.Side),
 				pos.EntryPrice, pos.MarkPrice, pos.UnrealizedPnLPct,
-				pos.Leverage, pos.MarginUsed, pos.LiquidationPrice, holdingDuration))
+				pos.Leverage, pos.MarginUsed, pos.LiquidationPrice, funding, holdingDuration))
+			section(priorityRequired, "")
 
-			// 使用FormatMarketData输出完整市场数据
-			if marketData, ok := ctx.MarketDataMap[pos.Symbol]; ok {
+			// 使用FormatMarketData输出完整市场数据（超出预算时只保留上面的持仓概要）
+			if marketData := ctx.Snapshot.Data(pos.Symbol); marketData != nil {
 				sb.WriteString(market.Format(marketData))
 				sb.WriteString("\n")
+				section(priorityPositions, pos.Symbol+"持仓行情")
 			}
 		}
 	} else {
 		sb.WriteString("**当前持仓**: 无\n\n")
 	}
+	section(priorityRequired, "")
 
 	// 候选币种（完整市场数据）
-	sb.WriteString(fmt.Sprintf("## 候选币种 (%d个)\n\n", len(ctx.MarketDataMap)))
+	sb.WriteString(fmt.Sprintf("## 候选币种 (%d个)\n\n", len(ctx.Snapshot.Symbols)))
+	section(priorityRequired, "")
 	displayedCount := 0
 	for _, coin := range ctx.CandidateCoins {
-		marketData, hasData := ctx.MarketDataMap[coin.Symbol]
-		if !hasData {
+		marketData := ctx.Snapshot.Data(coin.Symbol)
+		if marketData == nil {
 			continue
 		}
 		displayedCount++
@@ -400,8 +600,10 @@ func buildUserPrompt(ctx *Context) string {
 		sb.WriteString(fmt.Sprintf("### %d. %s%s\n\n", displayedCount, coin.Symbol, sourceTags))
 		sb.WriteString(market.Format(marketData))
 		sb.WriteString("\n")
+		section(priorityIndicators, coin.Symbol+"候选行情")
 	}
 	sb.WriteString("\n")
+	section(priorityRequired, "")
 
 	// 夏普比率（直接传值，不要复杂格式化）
 	if ctx.Performance != nil {
@@ -416,20 +618,32 @@ func buildUserPrompt(ctx *Context) string {
 			}
 		}
 	}
+	section(priorityOutcomes, "夏普比率")
 
 	sb.WriteString("---\n\n")
 	sb.WriteString("现在请分析并输出决策（思维链 + JSON）\n")
+	section(priorityRequired, "")
 
-	return sb.String()
+	return assemblePrompt(sections, budget)
 }
 
-// parseFullDecisionResponse 解析AI的完整决策响应
-func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int) (*FullDecision, error) {
-	// 1. 提取思维链
-	cotTrace := extractCoTTrace(aiResponse)
+// jsonModeFormat JSON模式的输出格式（response_format=json_object时只能输出一个JSON对象，思维链放在cot_trace字段中）
+const jsonModeFormat = "\n\n# 📤 JSON模式\n\n" +
+	"上面的输出格式改为只输出一个JSON对象，不要输出对象之外的任何内容：\n" +
+	"```json\n{\"cot_trace\": \"思维链分析（纯文本）\", \"decisions\": [决策数组，字段同上]}\n```\n"
 
-	// 2. 提取JSON决策列表
-	decisions, err := extractDecisions(aiResponse)
+// parseFullDecisionResponse 解析AI的完整决策响应
+func parseFullDecisionResponse(aiResponse string, accountEquity float64, limits func(string) risk.RiskProfile, autoLeverage bool) (*FullDecision, error) {
+	// JSON模式：整个响应是 {"cot_trace", "decisions"} 对象；否则为 思维链 + JSON数组
+	cotTrace, decisions, ok := extractDecisionObject(aiResponse)
+	var err error
+	if !ok {
+		// 1. 提取思维链
+		cotTrace = extractCoTTrace(aiResponse)
+
+		// 2. 提取JSON决策列表
+		decisions, err = extractDecisions(aiResponse)
+	}
 	if err != nil {
 		return &FullDecision{
 			CoTTrace:  cotTrace,
@@ -437,8 +651,11 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 		}, fmt.Errorf("提取决策失败: %w\n\n=== AI思维链分析 ===\n%s", err, cotTrace)
 	}
 
-	// 3. 验证决策
-	if err := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+	// 3. 规范化理由并验证决策
+	for i := range decisions {
+		decisions[i].Reasoning = ShortReasoning(decisions[i].Reasoning)
+	}
+	if err := validateDecisions(decisions, accountEquity, limits, autoLeverage); err != nil {
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: decisions,
@@ -451,6 +668,22 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 	}, nil
 }
 
+// extractDecisionObject 解析JSON模式的响应（{"cot_trace": "...", "decisions": [...]}），响应不是这种对象时返回false
+func extractDecisionObject(response string) (string, []Decision, bool) {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "{") {
+		return "", nil, false
+	}
+	var object struct {
+		CoTTrace  string     `json:"cot_trace"`
+		Decisions []Decision `json:"decisions"`
+	}
+	if err := json.Unmarshal([]byte(response), &object); err != nil || object.Decisions == nil {
+		return "", nil, false
+	}
+	return strings.TrimSpace(object.CoTTrace), object.Decisions, true
+}
+
 // extractCoTTrace 提取思维链分析
 func extractCoTTrace(response string) string {
 	// 查找JSON数组的开始位置
@@ -505,10 +738,10 @@ func fixMissingQuotes(jsonStr string) string {
 	return jsonStr
 }
 
-// validateDecisions 验证所有决策（需要账户信息和杠杆配置）
-func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+// validateDecisions 验证所有决策（需要账户信息和各币种的杠杆、仓位上限）
+func validateDecisions(decisions []Decision, accountEquity float64, limits func(string) risk.RiskProfile, autoLeverage bool) error {
 	for i, decision := range decisions {
-		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+		if err := validateDecision(&decision, accountEquity, limits, autoLeverage); err != nil {
 			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
 		}
 	}
@@ -537,8 +770,19 @@ func findMatchingBracket(s string, start int) int {
 	return -1
 }
 
-// validateDecision 验证单个决策的有效性
-func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+// defaultLimits 默认的杠杆和仓位上限：杠杆按配置，BTC/ETH单币种最多10倍账户净值，山寨币最多1.5倍
+func defaultLimits(btcEthLeverage, altcoinLeverage int) func(string) risk.RiskProfile {
+	exposure := risk.DefaultExposureLimits()
+	return func(symbol string) risk.RiskProfile {
+		return risk.RiskProfile{
+			MaxLeverage:         risk.MaxLeverage(symbol, btcEthLeverage, altcoinLeverage),
+			MaxPositionMultiple: exposure.MaxPositionValue(symbol, 1),
+		}
+	}
+}
+
+// validateDecision 验证单个决策的有效性（autoLeverage时不校验AI给出的杠杆，执行时由系统计算）
+func validateDecision(d *Decision, accountEquity float64, limits func(string) risk.RiskProfile, autoLeverage bool) error {
 	// 验证action
 	validActions := map[string]bool{
 		"open_long":   true,
@@ -553,17 +797,19 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 		return fmt.Errorf("无效的action: %s", d.Action)
 	}
 
+	// 开仓和平仓必须说明理由（随订单保存，供人工核对）
+	if d.Action != "hold" && d.Action != "wait" && strings.TrimSpace(d.Reasoning) == "" {
+		return fmt.Errorf("%s %s 缺少reasoning（开仓和平仓必须填写简短理由）", d.Action, d.Symbol)
+	}
+
 	// 开仓操作必须提供完整参数
 	if d.Action == "open_long" || d.Action == "open_short" {
-		// 根据币种使用配置的杠杆上限
-		maxLeverage := altcoinLeverage          // 山寨币使用配置的杠杆
-		maxPositionValue := accountEquity * 1.5 // 山寨币最多1.5倍账户净值
-		if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
-			maxLeverage = btcEthLeverage          // BTC和ETH使用配置的杠杆
-			maxPositionValue = accountEquity * 10 // BTC/ETH最多10倍账户净值
-		}
+		// 根据币种使用配置的杠杆和仓位上限（BTC/ETH与山寨币分别配置，可按策略/币种覆盖）
+		profile := limits(d.Symbol)
+		maxLeverage := profile.MaxLeverage
+		maxPositionValue := accountEquity * profile.MaxPositionMultiple
 
-		if d.Leverage <= 0 || d.Leverage > maxLeverage {
+		if !autoLeverage && (d.Leverage <= 0 || d.Leverage > maxLeverage) {
 			return fmt.Errorf("杠杆必须在1-%d之间（%s，当前配置上限%d倍）: %d", maxLeverage, d.Symbol, maxLeverage, d.Leverage)
 		}
 		if d.PositionSizeUSD <= 0 {
@@ -572,15 +818,24 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 		// 验证仓位价值上限（加1%容差以避免浮点数精度问题）
 		tolerance := maxPositionValue * 0.01 // 1%容差
 		if d.PositionSizeUSD > maxPositionValue+tolerance {
-			if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
-				return fmt.Errorf("BTC/ETH单币种仓位价值不能超过%.0f USDT（10倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
-			} else {
-				return fmt.Errorf("山寨币单币种仓位价值不能超过%.0f USDT（1.5倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
-			}
+			return fmt.Errorf("%s单币种仓位价值不能超过%.0f USDT（%g倍账户净值），实际: %.0f",
+				d.Symbol, maxPositionValue, profile.MaxPositionMultiple, d.PositionSizeUSD)
 		}
 		if d.StopLoss <= 0 || d.TakeProfit <= 0 {
 			return fmt.Errorf("止损和止盈必须大于0")
 		}
+		if d.LimitPrice < 0 {
+			return fmt.Errorf("限价不能为负数: %.4f", d.LimitPrice)
+		}
+		if err := CheckTriggerExpiration(d.TriggerExpiration); err != nil {
+			return err
+		}
+		if err := CheckTriggerPriceType(d.TriggerPriceType); err != nil {
+			return err
+		}
+		if d.LimitPrice > 0 && (d.LimitPrice-d.StopLoss)*(d.TakeProfit-d.LimitPrice) <= 0 {
+			return fmt.Errorf("限价%.4f必须在止损%.4f和止盈%.4f之间", d.LimitPrice, d.StopLoss, d.TakeProfit)
+		}
 
 		// 验证止损止盈的合理性
 		if d.Action == "open_long" {
@@ -0,0 +1,62 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/i18n"
+	"os"
+	"strings"
+)
+
+// maxPromptVersionLen 提示词版本最大长度（写入订单文本，交易所限制订单文本长度）
+const maxPromptVersionLen = 16
+
+// PromptConfig 候选提示词：在内置系统提示词末尾追加规则，并以独立的版本号记录决策和交易（用于影子模式A/B评估）
+type PromptConfig struct {
+	Version   string `json:"version,omitempty"`    // 提示词版本（写入决策日志、订单文本和交易日志，为空时使用内置版本）
+	RulesFile string `json:"rules_file,omitempty"` // 追加规则文件（内容追加到系统提示词末尾，为空表示不追加）
+
+	// 系统和用户提示词合计的token预算（0表示不限制）；超出时按 持仓 > 近期表现 > 候选币种指标 > 新闻 的优先级整段省略
+	MaxContextTokens int `json:"max_context_tokens,omitempty"`
+}
+
+// Validate 检查版本号字符（只允许字母、数字、下划线和点）和规则文件是否可读
+func (c PromptConfig) Validate() error {
+	if len(c.Version) > maxPromptVersionLen {
+		return i18n.Errorf("prompt.version不能超过%d个字符: %s", maxPromptVersionLen, c.Version)
+	}
+	for _, r := range c.Version {
+		if r != '_' && r != '.' && (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return i18n.Errorf("prompt.version只能包含字母、数字、下划线和点: %s", c.Version)
+		}
+	}
+	if c.RulesFile != "" && c.Version == "" {
+		return i18n.Errorf("prompt.rules_file需要同时指定prompt.version（用于区分两个版本的交易表现）")
+	}
+	if c.MaxContextTokens != 0 && c.MaxContextTokens < MinContextTokens {
+		return i18n.Errorf("prompt.max_context_tokens不能小于%d（0表示不限制）: %d", MinContextTokens, c.MaxContextTokens)
+	}
+	if _, err := c.LoadRules(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// VersionOrDefault 生效的提示词版本（未指定时为内置版本）
+func (c PromptConfig) VersionOrDefault() string {
+	if c.Version == "" {
+		return PromptVersion
+	}
+	return c.Version
+}
+
+// LoadRules 读取追加规则（未配置时返回空字符串）
+func (c PromptConfig) LoadRules() (string, error) {
+	if c.RulesFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(c.RulesFile)
+	if err != nil {
+		return "", fmt.Errorf("读取提示词规则文件失败: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
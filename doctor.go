@@ -0,0 +1,202 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"nofx/config"
+	"nofx/pool"
+	"nofx/store"
+	"nofx/trader"
+	"path/filepath"
+	"time"
+)
+
+// doctorMinFreeDisk 数据目录所在磁盘的最小剩余空间（交易日志、决策日志、模拟持仓都写在本地）
+const doctorMinFreeDisk = 1 << 30
+
+// 诊断项（交易所相关的检查项见trader.Check*）
+const (
+	checkConfig    = "config"     // 配置文件能否加载和通过校验
+	checkStore     = "store"      // 交易日志数据库和迁移版本
+	checkDisk      = "disk_space" // 数据目录剩余空间
+	checkCoinCache = "coin_cache" // 币种池/OI Top缓存文件是否过旧
+)
+
+// runDoctorCommand 诊断：配置、交易日志和迁移、磁盘空间、币种池缓存，以及每个trader的交易所连通性、
+// API密钥和交易权限、时钟偏差、合约元数据、WebSocket推送，逐项输出通过/失败
+func runDoctorCommand(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configFile := fs.String("config", config.DefaultFile(), "配置文件")
+	traderID := fs.String("trader", "", "只检查该trader（默认所有启用的trader）")
+	if _, err := parseInterleaved(fs, args); err != nil {
+		return err
+	}
+
+	var results []trader.CheckResult
+	report := func(r trader.CheckResult) {
+		results = append(results, r)
+		scope := ""
+		if r.TraderID != "" {
+			scope = "[" + r.TraderID + "] "
+		}
+		mark := "✓"
+		if !r.OK {
+			mark = "❌"
+		} else if r.Warning {
+			mark = "⚠"
+		}
+		fmt.Printf("%s %s%s: %s\n", mark, scope, r.Check, r.Detail)
+	}
+	// check 输出一项非交易所检查（用法：check(name)(detail, err)）
+	check := func(name string) func(string, error) {
+		return func(detail string, err error) {
+			if err != nil {
+				detail = err.Error()
+			}
+			report(trader.CheckResult{Check: name, OK: err == nil, Detail: detail})
+		}
+	}
+
+	cfg, err := config.LoadConfig(*configFile)
+	check(checkConfig)(*configFile, err)
+	if err != nil {
+		return fmt.Errorf("配置无效，其余检查项已跳过")
+	}
+
+	dataDir := "."
+	if cfg.StorePath == "-" {
+		check(checkStore)("未启用（store_path为\"-\"）", nil)
+	} else {
+		if !store.IsPostgres(cfg.StorePath) {
+			dataDir = filepath.Dir(cfg.StorePath)
+		}
+		check(checkStore)(doctorStore(cfg.StorePath))
+	}
+	check(checkDisk)(doctorDisk(dataDir))
+	if cfg.CoinPoolAPIURL != "" {
+		check(checkCoinCache)(doctorCache(pool.CoinPoolCacheFile()))
+	}
+	if cfg.OITopAPIURL != "" {
+		check(checkCoinCache)(doctorCache(pool.OITopCacheFile()))
+	}
+
+	checked := 0
+	for _, traderCfg := range cfg.Traders {
+		if (*traderID == "" && !traderCfg.Enabled) || (*traderID != "" && traderCfg.ID != *traderID) {
+			continue
+		}
+		checked++
+		for _, r := range doctorTrader(cfg, traderCfg) {
+			report(r)
+		}
+	}
+	if checked == 0 {
+		if *traderID != "" {
+			return fmt.Errorf("trader ID '%s' 不存在", *traderID)
+		}
+		check("traders")("", fmt.Errorf("没有启用的trader"))
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d项检查未通过", failed, len(results))
+	}
+	fmt.Printf("\n全部%d项检查通过\n", len(results))
+	return nil
+}
+
+// doctorStore 打开交易日志（会执行未应用的迁移），检查迁移版本和可读写
+func doctorStore(path string) (string, error) {
+	journal, err := store.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer journal.Close()
+	path = store.Redact(path)
+	if err := journal.Ping(); err != nil {
+		return "", err
+	}
+	current, latest, err := journal.SchemaVersion()
+	if err != nil {
+		return "", err
+	}
+	if current < latest {
+		return "", fmt.Errorf("%s 迁移版本v%d落后于v%d", path, current, latest)
+	}
+	if current > latest {
+		return "", fmt.Errorf("%s 迁移版本v%d高于程序支持的v%d（数据库由更新版本的程序创建）", path, current, latest)
+	}
+	return fmt.Sprintf("%s（%s）迁移版本v%d", path, journal.Backend(), current), nil
+}
+
+// doctorDisk 数据目录所在磁盘的剩余空间
+func doctorDisk(dir string) (string, error) {
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", dir, err)
+	}
+	detail := fmt.Sprintf("%s 剩余%.1f GB", dir, float64(free)/(1<<30))
+	if free < doctorMinFreeDisk {
+		return "", fmt.Errorf("%s，低于%.0f GB", detail, float64(doctorMinFreeDisk)/(1<<30))
+	}
+	return detail, nil
+}
+
+// doctorCache 缓存文件是否过旧（API不可用时会使用缓存中的币种）；文件不存在时在首次成功请求后创建
+func doctorCache(file pool.CacheFile, err error) (string, error) {
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", file.Path, err)
+	}
+	if file.FetchedAt.IsZero() {
+		return fmt.Sprintf("%s 尚未创建", file.Path), nil
+	}
+	age := time.Since(file.FetchedAt).Round(time.Minute)
+	if age > pool.CacheMaxAge {
+		return "", fmt.Errorf("%s 已%v未更新（超过%v），检查%s接口是否可用", file.Path, age, pool.CacheMaxAge, file.Name)
+	}
+	return fmt.Sprintf("%s %v前更新", file.Path, age), nil
+}
+
+// doctorTrader 连接交易所执行自检，并探测WebSocket推送地址
+func doctorTrader(cfg *config.Config, traderCfg config.TraderConfig) []trader.CheckResult {
+	t, err := trader.NewExchangeTrader(trader.AutoTraderConfig{
+		ID:                    traderCfg.ID,
+		Name:                  traderCfg.Name,
+		Exchange:              traderCfg.Exchange,
+		BinanceAPIKey:         traderCfg.BinanceAPIKey,
+		BinanceSecretKey:      traderCfg.BinanceSecretKey,
+		HyperliquidPrivateKey: traderCfg.HyperliquidPrivateKey,
+		HyperliquidWalletAddr: traderCfg.HyperliquidWalletAddr,
+		HyperliquidTestnet:    traderCfg.HyperliquidTestnet,
+		AsterUser:             traderCfg.AsterUser,
+		AsterSigner:           traderCfg.AsterSigner,
+		AsterPrivateKey:       traderCfg.AsterPrivateKey,
+		GateAPIKey:            traderCfg.GateAPIKey,
+		GateSecretKey:         traderCfg.GateSecretKey,
+		GateTestnet:           traderCfg.GateTestnet,
+		AccountCacheTTL:       cfg.AccountCacheTTL(),
+	})
+	if err != nil {
+		return []trader.CheckResult{{TraderID: traderCfg.ID, Check: trader.CheckExchange, Detail: err.Error()}}
+	}
+
+	results := trader.CheckTrader(traderCfg.ID, t)
+	if !results[0].OK {
+		return results
+	}
+	if prober, ok := t.(trader.StreamProber); ok {
+		latency, err := prober.ProbeStream()
+		stream := trader.CheckResult{TraderID: traderCfg.ID, Check: trader.CheckStream, OK: err == nil,
+			Detail: fmt.Sprintf("握手%dms", latency.Milliseconds())}
+		if err != nil {
+			stream.Detail = err.Error()
+		}
+		results = append(results, stream)
+	}
+	return results
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"nofx/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// busLog 事件总线日志
+var busLog = logging.For("events")
+
+// Bus 事件总线：Publish不阻塞发布者
+// Handle注册的处理函数在发布者的goroutine中按注册顺序同步调用（用于更新进程内状态，必须快速返回且不能再发布事件），
+// Subscribe的订阅者通过带缓冲的通道异步接收，消费过慢时丢弃新事件
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []*handler
+	subs     map[*Subscription]struct{}
+}
+
+// handler 同步处理函数
+type handler struct {
+	filter Filter
+	fn     func(Event)
+}
+
+// Subscription 异步订阅
+type Subscription struct {
+	C <-chan Event
+
+	ch      chan Event
+	filter  Filter
+	bus     *Bus
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Publish 发布事件（总线为nil时忽略，Time为零值时使用当前时间）
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, h := range b.handlers {
+		if h.filter.Match(e) {
+			h.fn(e)
+		}
+	}
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			if sub.dropped.Add(1) == 1 {
+				busLog.Warn("事件订阅者消费过慢，丢弃事件", "type", e.Type, "trader", e.TraderID)
+			}
+		}
+	}
+}
+
+// Handle 注册同步处理函数，返回的cancel用于取消注册
+func (b *Bus) Handle(filter Filter, fn func(Event)) (cancel func()) {
+	h := &handler{filter: filter, fn: fn}
+	b.mu.Lock()
+	b.handlers = append(b.handlers, h)
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, registered := range b.handlers {
+			if registered == h {
+				b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Subscribe 异步订阅满足过滤条件的事件，buffer为通道缓冲大小，用完后需调用Close
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, bus: b}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Close 取消订阅（C不会被关闭，已缓冲的事件仍可读取）
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+	})
+}
+
+// Dropped 因消费过慢丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
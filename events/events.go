@@ -0,0 +1,106 @@
+// Package events 进程内事件总线：交易流程只发布事件（订单、持仓、风控、AI决策、通知），
+// 通知渠道、交易日志、控制台/看板和策略看守各自订阅，不再在交易方法中直接产生这些副作用
+package events
+
+import (
+	"nofx/notify"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	TypeOrder    Type = "order"    // 订单状态变化（已提交/被拒绝/已确认）
+	TypePosition Type = "position" // 持仓开仓/平仓成交
+	TypeRisk     Type = "risk"     // 风控规则拒绝或触发（节流、禁止开仓、强平距离、账户限制、回撤熔断等）
+	TypeDecision Type = "decision" // AI决策或外部信号的执行结果
+	TypeNotice   Type = "notice"   // 需要推送给用户的通知（由通知渠道订阅）
+)
+
+// Event 事件（按Type只填写对应的一个字段）
+type Event struct {
+	Type     Type      `json:"type"`
+	TraderID string    `json:"trader_id"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Time     time.Time `json:"time"`
+
+	Order    *OrderEvent    `json:"order,omitempty"`
+	Position *PositionEvent `json:"position,omitempty"`
+	Risk     *RiskEvent     `json:"risk,omitempty"`
+	Decision *DecisionEvent `json:"decision,omitempty"`
+	Notice   *notify.Event  `json:"notice,omitempty"`
+}
+
+// OrderEvent 订单状态变化
+type OrderEvent struct {
+	Action    string  `json:"action"` // open_long/open_short/close_long/close_short
+	Side      string  `json:"side"`   // long/short
+	Strategy  string  `json:"strategy,omitempty"`
+	OrderID   string  `json:"order_id,omitempty"`
+	State     string  `json:"state"` // 与交易日志的订单状态一致（submitted/filled/partially_filled/canceled/rejected等）
+	Quantity  float64 `json:"quantity"`
+	Price     float64 `json:"price"` // 下单时的参考价
+	Leverage  int     `json:"leverage,omitempty"`
+	FilledQty float64 `json:"filled_qty,omitempty"`
+	AvgPrice  float64 `json:"avg_price,omitempty"`
+	Error     string  `json:"error,omitempty"`
+
+	DecisionID string `json:"decision_id,omitempty"`
+	Reason     string `json:"reason,omitempty"`    // 原因代码（AI_DECISION、DCA_ADD、LIMIT_ORDER_EXPIRED等）
+	Reasoning  string `json:"reasoning,omitempty"` // 决策理由（AI或外部信号给出的简短说明）
+}
+
+// PositionEvent 持仓变化（开仓或平仓成交）
+type PositionEvent struct {
+	Change     string  `json:"change"` // open/close
+	Side       string  `json:"side"`   // long/short
+	Strategy   string  `json:"strategy,omitempty"`
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price"` // 成交均价
+	Leverage   int     `json:"leverage,omitempty"`
+	EntryPrice float64 `json:"entry_price,omitempty"` // 平仓时的入场均价（交易日志中有记录时）
+	GrossPnL   float64 `json:"gross_pnl,omitempty"`   // 平仓毛盈亏（交易日志中有记录时）
+	Reason     string  `json:"reason,omitempty"`      // 平仓方式：平仓动作，或交易所侧平仓时的止损触发/止盈触发/外部平仓
+}
+
+// RiskEvent 风控事件
+type RiskEvent struct {
+	Rule   string `json:"rule"`             // 规则（throttle/entry_blocked/regime/calendar/liquidation/account_limit/drawdown/external_signal/maintenance/soft_close/order_rate/risk_profile/ledger/adl/entry_confirm/downtime_queue/entry_limit/trade_loss/staleness/balance_anomaly/degradation/pause等）
+	Action string `json:"action,omitempty"` // 被拒绝的决策动作（规则不针对单个决策时为空）
+	Detail string `json:"detail"`
+	Reason string `json:"reason,omitempty"` // 原因代码（默认为 RISK_<规则>）
+}
+
+// DecisionEvent 决策执行结果
+type DecisionEvent struct {
+	ID       string  `json:"id"`
+	Source   string  `json:"source"` // ai或外部信号来源
+	Action   string  `json:"action"`
+	Leverage int     `json:"leverage,omitempty"`
+	SizeUSD  float64 `json:"size_usd,omitempty"`
+	Success  bool    `json:"success"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Filter 订阅过滤条件（TraderID为空表示所有trader，Types为空表示所有类型）
+type Filter struct {
+	TraderID string
+	Types    []Type
+}
+
+// Match 事件是否满足过滤条件
+func (f Filter) Match(e Event) bool {
+	if f.TraderID != "" && e.TraderID != f.TraderID {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"nofx/config"
+	"nofx/market"
+	"nofx/report"
+	"os"
+	"strings"
+)
+
+// backfillFundingHistory 启动时回填配置的币种的历史资金费率（失败只告警，首次查询时会重试）
+func backfillFundingHistory(history *report.FundingHistory, symbols []string) {
+	for _, symbol := range symbols {
+		n, err := history.Backfill(symbol)
+		if err != nil {
+			mainLog.Warn("回填历史资金费率失败", "symbol", symbol, "err", err)
+			continue
+		}
+		if n > 0 {
+			mainLog.Info("已回填历史资金费率", "symbol", market.Normalize(symbol), "count", n)
+		}
+	}
+}
+
+// runFundingCommand 回填币种的历史资金费率到交易日志，输出当前费率在历史分布中的位置
+func runFundingCommand(args []string) error {
+	fs := flag.NewFlagSet("funding", flag.ContinueOnError)
+	configFile := fs.String("config", config.DefaultFile(), "配置文件")
+	days := fs.Int("days", 90, "统计窗口（天）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("用法: nofx funding <SYMBOL...> [--days 90] [--config config.json]")
+	}
+	if *days < 7 || *days > 365 {
+		return fmt.Errorf("--days必须在7-365之间: %d", *days)
+	}
+
+	journal, err := openJournal(*configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	history := report.NewFundingHistory(journal, *days)
+	for _, symbol := range args {
+		symbol = market.Normalize(strings.ToUpper(symbol))
+		n, err := history.Backfill(symbol)
+		if err != nil {
+			return fmt.Errorf("回填%s历史资金费率失败: %w", symbol, err)
+		}
+		current, err := market.GetFundingRate(symbol)
+		if err != nil {
+			return fmt.Errorf("获取%s当前资金费率失败: %w", symbol, err)
+		}
+		stats, err := history.Stats(symbol, current)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			cliLog.Info("已回填历史资金费率", "symbol", symbol, "count", n)
+		}
+		if stats == nil {
+			fmt.Fprintf(os.Stdout, "%s 历史资金费率不足，无法计算分布\n", symbol)
+			continue
+		}
+		fmt.Fprint(os.Stdout, report.FormatFundingStats(symbol, stats))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package fx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nofx/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fxLog 汇率模块日志
+var fxLog = logging.For("fx")
+
+const (
+	defaultRefreshMinutes = 60
+	defaultSource         = "https://open.er-api.com/v6/latest/USD"
+)
+
+// symbols 支持的报告币种及其符号
+var symbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"CNY": "¥",
+}
+
+// Config 报告币种：盈亏汇总、通知和看板在USDT之外同时显示换算后的金额（按1 USDT = 1 USD，再按汇率换算到报告币种）
+type Config struct {
+	Currency       string  `json:"currency"`        // 报告币种: USD/EUR/CNY（为空表示只显示USDT）
+	Source         string  `json:"source"`          // 汇率接口，返回以USD为基准的 {"rates": {"EUR": 0.92, ...}}（默认open.er-api.com）
+	Rate           float64 `json:"rate"`            // 固定汇率（1 USD = rate 报告币种），设置后不请求汇率接口
+	RefreshMinutes int     `json:"refresh_minutes"` // 汇率刷新间隔（分钟，默认60）
+}
+
+// Enabled 是否配置了报告币种
+func (c Config) Enabled() bool {
+	return c.Currency != ""
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	if c.Currency != "" {
+		if _, ok := symbols[strings.ToUpper(c.Currency)]; !ok {
+			return fmt.Errorf("reporting_currency.currency必须是USD、EUR或CNY: %s", c.Currency)
+		}
+	}
+	if c.Rate < 0 || c.RefreshMinutes < 0 {
+		return fmt.Errorf("reporting_currency.rate、refresh_minutes不能为负数")
+	}
+	return nil
+}
+
+// Quote 当前汇率（1 USDT 可换算的报告币种金额）
+type Quote struct {
+	Currency  string    `json:"currency"`
+	Symbol    string    `json:"symbol"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Convert 把USDT金额换算为报告币种
+func (q *Quote) Convert(usdt float64) float64 {
+	return usdt * q.Rate
+}
+
+// Format 格式化换算后的金额（如 €12.34，signed为true时带正负号）
+func (q *Quote) Format(usdt float64, signed bool) string {
+	value := q.Convert(usdt)
+	sign := ""
+	if value < 0 {
+		sign, value = "-", -value
+	} else if signed {
+		sign = "+"
+	}
+	return fmt.Sprintf("%s%s%.2f", sign, q.Symbol, value)
+}
+
+// Service 汇率服务：后台定时刷新报告币种的汇率（刷新失败时保留上次汇率）
+type Service struct {
+	cfg Config
+
+	mu    sync.RWMutex
+	quote *Quote // 尚未取得汇率时为nil
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewService 创建汇率服务（配置应已通过Validate）；USD和固定汇率无需请求汇率接口
+func NewService(cfg Config) *Service {
+	cfg.Currency = strings.ToUpper(cfg.Currency)
+	if cfg.Source == "" {
+		cfg.Source = defaultSource
+	}
+	if cfg.RefreshMinutes == 0 {
+		cfg.RefreshMinutes = defaultRefreshMinutes
+	}
+	s := &Service{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}, now: time.Now}
+	switch {
+	case cfg.Rate > 0:
+		s.quote = &Quote{Currency: cfg.Currency, Symbol: symbols[cfg.Currency], Rate: cfg.Rate, UpdatedAt: s.now()}
+	case cfg.Currency == "USD":
+		s.quote = &Quote{Currency: cfg.Currency, Symbol: symbols[cfg.Currency], Rate: 1, UpdatedAt: s.now()}
+	}
+	return s
+}
+
+// defaultService 进程内共享的汇率服务（未启用时为nil）
+var (
+	defaultService *Service
+	defaultMu      sync.RWMutex
+)
+
+// SetDefault 设置进程内共享的汇率服务（nil表示不启用）
+func SetDefault(s *Service) {
+	defaultMu.Lock()
+	defaultService = s
+	defaultMu.Unlock()
+}
+
+// Default 进程内共享的汇率服务（未启用时为nil）
+func Default() *Service {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultService
+}
+
+// Current 共享汇率服务的当前汇率（未启用或尚未取得汇率时返回nil）
+func Current() *Quote {
+	return Default().Quote()
+}
+
+// Suffix USDT金额换算后的附注（如 "（≈ €12.34）"），未启用或尚未取得汇率时为空，用于拼接在通知和报告的金额之后
+func Suffix(usdt float64, signed bool) string {
+	quote := Current()
+	if quote == nil {
+		return ""
+	}
+	return fmt.Sprintf("（≈ %s）", quote.Format(usdt, signed))
+}
+
+// Quote 当前汇率（未取得汇率时返回nil）
+func (s *Service) Quote() *Quote {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.quote == nil {
+		return nil
+	}
+	quote := *s.quote
+	return &quote
+}
+
+// Start 立即拉取一次，之后每refresh_minutes拉取一次，直到stop关闭（USD和固定汇率不拉取）
+func (s *Service) Start(stop <-chan struct{}) {
+	if s.cfg.Rate > 0 || s.cfg.Currency == "USD" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.cfg.RefreshMinutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			if err := s.Refresh(); err != nil {
+				fxLog.Warn("刷新汇率失败，保留上次汇率", "currency", s.cfg.Currency, "err", err)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh 从汇率接口拉取报告币种的汇率
+func (s *Service) Refresh() error {
+	resp, err := s.client.Get(s.cfg.Source)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("汇率接口返回HTTP %d", resp.StatusCode)
+	}
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("解析汇率失败: %w", err)
+	}
+	rate := body.Rates[s.cfg.Currency]
+	if rate <= 0 {
+		return fmt.Errorf("汇率接口未返回%s", s.cfg.Currency)
+	}
+
+	s.mu.Lock()
+	s.quote = &Quote{Currency: s.cfg.Currency, Symbol: symbols[s.cfg.Currency], Rate: rate, UpdatedAt: s.now()}
+	s.mu.Unlock()
+	fxLog.Debug("汇率已更新", "currency", s.cfg.Currency, "rate", rate)
+	return nil
+}
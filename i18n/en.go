@@ -0,0 +1,482 @@
+package i18n
+
+// english 英文译文目录（按来源分组；新增日志或错误消息时在此补充译文）
+var english = map[string]string{
+	// i18n
+	"不支持的语言: %s（应为zh/en）": "unsupported language: %s (expected zh/en)",
+
+	// 启动与退出（main）
+	"写入PID文件失败": "Failed to write PID file",
+	"加载配置文件":    "Loading config file",
+	"加载配置失败":    "Failed to load config",
+	"初始化日志失败":   "Failed to initialize logging",
+	"初始化链路追踪失败": "Failed to initialize tracing",
+	"链路追踪已启用":   "Tracing enabled",
+	"配置加载成功":    "Config loaded",
+	"模拟交易模式（dry-run）：使用真实行情，下单、止损止盈在本地模拟": "Dry-run mode: live market data, orders and stop-loss/take-profit simulated locally",
+	"交易对符号覆盖无效":      "Invalid symbol override",
+	"已登记交易对符号覆盖":     "Symbol overrides registered",
+	"已启用默认主流币种列表":    "Default coin list enabled",
+	"已配置AI500币种池API": "AI500 coin pool API configured",
+	"已配置OI Top API":  "OI Top API configured",
+	"打开交易日志存储失败":     "Failed to open trade journal",
+	"交易日志存储":         "Trade journal",
+	"已启用历史资金费率分布":    "Funding rate history enabled",
+	"启动通知和汇总报告失败":    "Failed to start notifications and summary reports",
+	"跳过未启用的trader":   "Skipping disabled trader",
+	"初始化trader":      "Initializing trader",
+	"初始化trader失败":    "Failed to initialize trader",
+	"没有启用的trader，请在配置文件中设置至少一个trader的enabled=true": "No trader enabled, set enabled=true on at least one trader in the config file",
+	"API密钥与gate_testnet配置的网络不一致，拒绝启动实盘策略":          "API keys do not match the gate_testnet network, refusing to start live trading",
+	"启动自检":                "Running startup self-check",
+	"自检项":                 "Self-check",
+	"自检项失败":               "Self-check failed",
+	"启动自检失败":              "Startup self-check failed",
+	"启动自检未通过，继续运行":        "Startup self-check failed, continuing anyway",
+	"已启用新闻和情绪模块":          "News and sentiment enabled",
+	"报告币种":                "Reporting currency",
+	"收到退出信号，正在停止所有trader": "Received signal, stopping all traders",
+	"服务异常退出，正在停止所有trader": "Service exited unexpectedly, stopping all traders",
+	"再次收到退出信号，强制退出":       "Received second signal, forcing exit",
+	"关闭API服务器失败":          "Failed to shut down API server",
+	"导出追踪数据失败":            "Failed to export traces",
+	"删除PID文件失败":           "Failed to remove PID file",
+	"通知systemd失败":         "Failed to notify systemd",
+	"读取哨兵文件失败":            "Failed to read kill switch file",
+	"哨兵文件已删除，恢复交易":        "Kill switch file removed, resuming trading",
+	"发现哨兵文件，暂停交易":         "Kill switch file found, pausing trading",
+	"暂停时撤单失败":             "Failed to cancel orders while pausing",
+	"已撤销挂单":               "Orders cancelled",
+	"回填历史资金费率失败":          "Failed to backfill funding rate history",
+	"已回填历史资金费率":           "Funding rate history backfilled",
+	"延迟%dms":              "latency %dms",
+	"偏差%dms":              "skew %dms",
+	"本地时钟偏差%dms超过%v，请同步系统时间（NTP）": "local clock skew %dms exceeds %v, sync the system time (NTP)",
+	"🏁 竞赛参赛者:":                        "🏁 Traders:",
+	"  • %s (%s) - 初始资金: %.0f USDT\n": "  • %s (%s) - initial balance: %.0f USDT\n",
+	"🤖 AI全权决策模式:":                     "🤖 Fully AI-driven mode:",
+	"  • AI将自主决定每笔交易的杠杆倍数（山寨币最高%d倍，BTC/ETH最高%d倍）\n": "  • AI chooses the leverage of every trade (altcoins up to %dx, BTC/ETH up to %dx)\n",
+	"  • AI将自主决定每笔交易的仓位大小":                          "  • AI chooses the position size of every trade",
+	"  • AI将自主设置止损和止盈价格":                            "  • AI sets stop-loss and take-profit prices",
+	"  • AI将基于市场数据、技术指标、账户状态做出全面分析":                 "  • AI decides from market data, technical indicators and account state",
+	"⚠️  风险提示: AI自动交易有风险，建议小额资金测试！":                 "⚠️  Risk warning: automated AI trading is risky, test with small funds first!",
+	"按 Ctrl+C 停止运行":   "Press Ctrl+C to stop",
+	"API服务器错误: %w":    "API server error: %w",
+	"gRPC控制平面错误: %w":  "gRPC control plane error: %w",
+	"👋 感谢使用AI交易竞赛系统！": "👋 Thanks for using the AI trading system!",
+	"多余的参数: %s":       "unexpected arguments: %s",
+
+	// 命令行子命令（cli）
+	"命令执行失败":  "Command failed",
+	"已导出交易":   "Trades exported",
+	"已导出回放数据": "Replays exported",
+	"回放数据不完整": "Replay is incomplete",
+	"回放数据已写入": "Replay written",
+	"回填历史资金费率失败，使用已记录的历史":       "Failed to backfill funding rate history, using the recorded history",
+	"加载配置失败，无法查询合约规格，按1张=1个币计算": "Failed to load config, cannot fetch contract specs, assuming 1 contract = 1 coin",
+	"无法连接交易所查询合约规格，按1张=1个币计算":   "Cannot connect to the exchange for contract specs, assuming 1 contract = 1 coin",
+	"已写入CSV文件": "CSV file written",
+	"获取维持保证金档位失败，按默认维持保证金率计算": "Failed to fetch maintenance margin tiers, using the default maintenance margin rate",
+	"获取历史价格失败，按单位价格成交":        "Failed to fetch historical prices, filling at a unit price",
+
+	// 配置热加载（reload）
+	"Telegram机器人已启用":   "Telegram bot enabled",
+	"Discord通知已启用":     "Discord notifications enabled",
+	"Slack通知已启用":       "Slack notifications enabled",
+	"Webhook通知已启用":     "Webhook notifications enabled",
+	"汇总报告已启用":          "Summary reports enabled",
+	"热加载配置失败，继续使用当前配置": "Config reload failed, keeping the current config",
+	"配置已重新加载，没有变更":     "Config reloaded, no changes",
+	"以下配置变更需要重启才能生效":   "These config changes need a restart to take effect",
+
+	// 配置
+	"读取配置文件失败: %w":                                                       "failed to read config file: %w",
+	"解析配置文件 %s 失败: %w":                                                   "failed to parse config file %s: %w",
+	"读取密钥失败: %w":                                                         "failed to resolve secrets: %w",
+	"配置验证失败: %w":                                                         "invalid config: %w",
+	"至少需要配置一个trader":                                                     "at least one trader must be configured",
+	"trader[%d]: ID不能为空":                                                 "trader[%d]: id must not be empty",
+	"trader[%d]: ID '%s' 重复":                                             "trader[%d]: duplicate id '%s'",
+	"trader[%d]: Name不能为空":                                               "trader[%d]: name must not be empty",
+	"trader[%d]: ai_model必须是 'qwen', 'deepseek' 或 'custom'":              "trader[%d]: ai_model must be 'qwen', 'deepseek' or 'custom'",
+	"trader[%d]: exchange必须是 'binance', 'hyperliquid', 'aster' 或 'gate'": "trader[%d]: exchange must be 'binance', 'hyperliquid', 'aster' or 'gate'",
+	"trader[%d]: 使用币安时必须配置binance_api_key和binance_secret_key":            "trader[%d]: binance_api_key and binance_secret_key are required for Binance",
+	"trader[%d]: 使用Hyperliquid时必须配置hyperliquid_private_key":              "trader[%d]: hyperliquid_private_key is required for Hyperliquid",
+	"trader[%d]: 使用Aster时必须配置aster_user, aster_signer和aster_private_key": "trader[%d]: aster_user, aster_signer and aster_private_key are required for Aster",
+	"trader[%d]: 使用Gate.io时必须配置gate_api_key和gate_secret_key":             "trader[%d]: gate_api_key and gate_secret_key are required for Gate.io",
+	"trader[%d]: 使用Qwen时必须配置qwen_key":                                    "trader[%d]: qwen_key is required for Qwen",
+	"trader[%d]: 使用DeepSeek时必须配置deepseek_key":                            "trader[%d]: deepseek_key is required for DeepSeek",
+	"trader[%d]: 使用自定义API时必须配置custom_api_url":                            "trader[%d]: custom_api_url is required for a custom API",
+	"trader[%d]: 使用自定义API时必须配置custom_api_key":                            "trader[%d]: custom_api_key is required for a custom API",
+	"trader[%d]: 使用自定义API时必须配置custom_model_name":                         "trader[%d]: custom_model_name is required for a custom API",
+	"trader[%d]: initial_balance必须大于0":                                   "trader[%d]: initial_balance must be greater than 0",
+	"trader[%d]: funding_harvest需要现货模块，目前仅支持exchange='gate'":             "trader[%d]: funding_harvest needs spot trading, only exchange='gate' is supported",
+	"trader[%d]: 只读模式不支持funding_harvest":                                 "trader[%d]: funding_harvest is not supported in read-only mode",
+	"admin.token至少需要16个字符":                                               "admin.token must be at least 16 characters",
+	"admin.grpc_port无效: %d":                                              "invalid admin.grpc_port: %d",
+	"admin.grpc_port不能与api_server_port相同":                                "admin.grpc_port must differ from api_server_port",
+	"cache.account_ttl_seconds不能为负数":                                     "cache.account_ttl_seconds must not be negative",
+	"language必须是zh或en: %s":                                               "language must be zh or en: %s",
+	"⚠️  警告: BTC/ETH杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n":                   "⚠️  Warning: BTC/ETH leverage is %dx, sub-accounts may reject it (limit ≤5x)\n",
+	"⚠️  警告: 山寨币杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n":                       "⚠️  Warning: altcoin leverage is %dx, sub-accounts may reject it (limit ≤5x)\n",
+	"YAML语法错误: %w":                                                       "YAML syntax error: %w",
+	"转换YAML失败: %w":                                                       "failed to convert YAML: %w",
+	"TOML语法错误: %w":                                                       "TOML syntax error: %w",
+	"转换TOML失败: %w":                                                       "failed to convert TOML: %w",
+	"不支持的配置文件格式: %s（支持 %s）":                                              "unsupported config file format: %s (supported: %s)",
+	"字段 %s 类型错误: 期望 %s，实际为 %s":                                           "field %s has wrong type: expected %s, got %s",
+	"JSON语法错误（第%d行第%d列）: %w":                                             "JSON syntax error (line %d, column %d): %w",
+	"未知的配置项 %s（请检查拼写，或参考config.json.example）":                            "unknown config key %s (check the spelling or see config.json.example)",
+
+	// 交易错误分类（trader.Err*）
+	"保证金不足":      "insufficient margin",
+	"杠杆调整过于频繁":   "leverage changed too frequently",
+	"下单数量低于最小限制": "order size below minimum",
+	"请求频率超限":     "rate limited",
+	"持仓不存在":      "position not found",
+	"只读模式，禁止下单":  "read-only mode, orders are not allowed",
+
+	// 结构化日志
+	"DCA加仓":                "DCA add",
+	"DCA加仓被风控拒绝":           "DCA add rejected by risk control",
+	"DCA总体止损触发，全部平仓":       "DCA overall stop-loss hit, closing everything",
+	"加仓检查获取持仓失败":           "Scale-in check failed to get positions",
+	"加仓检查获取账户余额失败":         "Scale-in check failed to get balance",
+	"Gate.io交易器初始化成功":      "Gate.io trader initialized",
+	"Trader已添加":            "Trader added",
+	"Trader运行错误":           "Trader run error",
+	"WatchPositions获取持仓失败": "WatchPositions failed to get positions",
+	"gRPC控制平面已启动":          "gRPC control plane started",
+	"下单失败":                 "Order failed",
+	"事件订阅者消费过慢，丢弃事件":       "Event subscriber too slow, dropping event",
+	"交易已人工暂停":              "Trading paused",
+	"交易已恢复":                "Trading resumed",
+	"任务执行panic":            "Job panicked",
+	"使用缓存的持仓信息":            "Using cached positions",
+	"使用缓存的账户余额":            "Using cached balance",
+	"保存决策记录失败":             "Failed to save decision record",
+	"保存净值快照失败":             "Failed to save equity snapshot",
+	"保存模拟账户状态失败":           "Failed to save dry-run account state",
+	"停止所有Trader":           "Stopping all traders",
+	"写入交易日志失败":             "Failed to write journal",
+	"写入成交记录失败":             "Failed to write fill",
+	"写入持仓记录失败":             "Failed to write position",
+	"写入止损止盈记录失败":           "Failed to write stop-loss/take-profit",
+	"写入费用流水失败":             "Failed to write fee ledger",
+	"净值快照获取余额失败":           "Equity snapshot failed to get balance",
+	"净值快照获取持仓失败":           "Equity snapshot failed to get positions",
+	"发送通知失败":               "Failed to send notification",
+	"取消挂单失败":               "Failed to cancel orders",
+	"取消旧委托单失败（可能没有委托单）":    "Failed to cancel old orders (there may be none)",
+	"只读模式已拦截":              "Blocked by read-only mode",
+	"同步成交记录失败":             "Failed to sync fills",
+	"同步费用流水失败":             "Failed to sync fee ledger",
+	"启动Trader":             "Starting trader",
+	"启动对账: 比较交易所状态与交易日志":   "Startup reconcile: comparing exchange state with journal",
+	"启动所有Trader":           "Starting all traders",
+	"回复Telegram命令失败":       "Failed to reply to Telegram command",
+	"回撤熔断，暂停交易":            "Drawdown circuit breaker tripped, trading paused",
+	"回滚现货腿失败":              "Failed to roll back spot leg",
+	"外部信号执行失败":             "External signal failed",
+	"存在未成交委托":              "Open orders present",
+	"对账完成: 交易所状态与交易日志一致":   "Reconcile done: exchange state matches journal",
+	"对账完成: 发现问题":           "Reconcile done: issues found",
+	"导出汇总报告CSV失败":          "Failed to export summary CSV",
+	"已人工撤销挂单":              "Orders cancelled manually",
+	"已取消所有挂单":              "All orders cancelled",
+	"已归集手续费/资金费流水到交易记录":    "Fees and funding attributed to trades",
+	"已推送汇总报告":              "Summary report sent",
+	"币种已从配置移除，但仍持有对冲，继续跟踪至解除": "Symbol removed from config but hedge still open, tracking until unwound",
+	"平仓成功":        "Position closed",
+	"平多仓":         "Closing long",
+	"平多仓成功":       "Long closed",
+	"平空仓":         "Closing short",
+	"平空仓成功":       "Short closed",
+	"开仓成功":        "Position opened",
+	"开多仓":         "Opening long",
+	"开多仓成功":       "Long opened",
+	"开空仓":         "Opening short",
+	"开空仓成功":       "Short opened",
+	"归集手续费/资金费失败": "Failed to attribute fees and funding",
+	"当前不在交易时段内，跳过本次执行": "Outside trading sessions, skipping run",
+	"恢复DCA状态":      "Restored DCA state",
+	"恢复资金费率套利持仓":   "Restored funding harvest position",
+	"恢复资金费率套利持仓失败": "Failed to restore funding harvest position",
+	"执行决策失败":       "Failed to execute decision",
+	"拒绝白名单外的命令":    "Rejected command from non-whitelisted chat",
+	"持仓已不存在（可能已触发止损/止盈），跳过平仓": "Position no longer exists (stop-loss/take-profit may have fired), skipping close",
+	"持仓已在交易所平仓":               "Position closed on exchange",
+	"持仓数量与记录不一致":              "Position size differs from journal",
+	"收到Telegram命令":            "Telegram command received",
+	"收到外部信号":                  "External signal received",
+	"数据库迁移完成":                 "Database migrated",
+	"暂停时已撤销挂单":                "Orders cancelled on pause",
+	"暂停状态未持久化":                "Pause state not persisted",
+	"暂停状态未持久化，重启后不会保持暂停":      "Pause state not persisted, it will not survive a restart",
+	"更新订单状态失败":                "Failed to update order status",
+	"杠杆切换冷却中，3秒后重试":           "Leverage change cooling down, retrying in 3s",
+	"杠杆已切换，等待3秒冷却期":           "Leverage changed, waiting 3s cooldown",
+	"杠杆无需切换":                  "Leverage unchanged",
+	"查询持仓资金费失败":               "Failed to query position funding",
+	"查询订单状态失败":                "Failed to query order status",
+	"模拟平仓":                    "Simulated close",
+	"模拟开仓":                    "Simulated open",
+	"模拟强平":                    "Simulated liquidation",
+	"模拟条件单触发":                 "Simulated trigger order fired",
+	"止损单已设置":                  "Stop-loss set",
+	"止盈单已设置":                  "Take-profit set",
+	"渲染通知模板失败":                "Failed to render notification template",
+	"现货买入成功":                  "Spot buy filled",
+	"现货卖出成功":                  "Spot sell filled",
+	"生成汇总报告失败":                "Failed to build summary report",
+	"等待Trader退出超时":            "Timed out waiting for traders to stop",
+	"缓存过期，重新获取持仓信息":           "Cache expired, refetching positions",
+	"获取Telegram命令失败":          "Failed to fetch Telegram commands",
+	"获取合约信息失败，使用默认精度":         "Failed to get contract info, using default precision",
+	"获取合约持仓失败":                "Failed to get futures positions",
+	"获取账户余额失败":                "Failed to get balance",
+	"获取资金费率失败":                "Failed to get funding rate",
+	"订单已确认":                   "Order confirmed",
+	"订单未在超时内到达终态，等待后续对账":      "Order not final before timeout, leaving it to reconcile",
+	"订单状态已确认":                 "Order status confirmed",
+	"设置DCA总体止损失败":             "Failed to set DCA overall stop-loss",
+	"设置止损失败":                  "Failed to set stop-loss",
+	"设置止盈失败":                  "Failed to set take-profit",
+	"账户余额已刷新":                 "Balance refreshed",
+	"获取行情失败，自动杠杆使用1倍":         "Failed to get market data, auto leverage falls back to 1x",
+	"自动杠杆":                    "Auto leverage",
+	"资金费率回落，解除对冲":             "Funding rate dropped, unwinding hedge",
+	"资金费率达到阈值，建立现货多+永续空对冲":    "Funding rate above threshold, opening spot long + perp short hedge",
+	"通知队列已满，丢弃消息":             "Notification queue full, dropping message",
+	"trader[%d]: dca与pyramid不能同时启用（二者都会调整同一持仓的总体止损）": "trader[%d]: dca and pyramid cannot both be enabled (both manage the aggregate stop of the same position)",
+	"trader[%d]: stop_limit_offset_pct必须在0-20之间":     "trader[%d]: stop_limit_offset_pct must be between 0 and 20",
+	"读取持仓开仓时间失败":                                     "Failed to read position open time",
+	"顺势加仓被风控拒绝":                                      "Pyramid add rejected by risk control",
+	"顺势加仓":                                           "Pyramid add",
+	"设置顺势加仓总体止损失败":                                   "Failed to set pyramid aggregate stop",
+	"重新设置止盈失败":                                       "Failed to re-place take profit",
+	"恢复顺势加仓状态":                                       "Restored pyramid state",
+	"配置已热加载":                                         "Config reloaded",
+	"trader[%d]: take_profit_ladder不能与dca/pyramid同时使用（加仓会撤销分批止盈单）": "trader[%d]: take_profit_ladder cannot be combined with dca/pyramid (adds cancel the ladder orders)",
+	"交易器不支持分批止盈，使用单一止盈":                                            "Exchange does not support take-profit ladders, using a single take-profit",
+	"分批止盈已设置": "Take-profit ladder placed",
+	"分批止盈已成交": "Take-profit ladder level filled",
+	"trader[%d]: resize_protective_orders需要WebSocket持仓推送，目前仅支持exchange='gate'": "trader[%d]: resize_protective_orders needs a WebSocket position stream, currently only exchange='gate' is supported",
+	"交易器不支持持仓推送或单独撤销条件单，止损止盈数量不会自动调整":                                          "Exchange does not support position streaming or cancelling single trigger orders, stop-loss/take-profit sizes will not be adjusted",
+	"启动持仓推送失败，止损止盈数量不会自动调整":                                                    "Failed to start position stream, stop-loss/take-profit sizes will not be adjusted",
+	"调整止损止盈数量失败":         "Failed to resize stop-loss/take-profit orders",
+	"止损止盈数量已调整":          "Stop-loss/take-profit order resized",
+	"WebSocket推送断开，准备重连": "WebSocket stream disconnected, reconnecting",
+	"WebSocket推送已订阅":     "Subscribed to WebSocket stream",
+	"解析持仓推送失败":           "Failed to parse position push",
+	"重连后获取持仓失败":          "Failed to fetch positions after reconnect",
+	"价格超出合理范围":           "Price out of sane range",
+	"获取当前价失败，跳过价格检查":     "Failed to get current price, skipping price check",
+	"价格检查未通过，拒绝下单":       "Price check failed, order rejected",
+	"价格偏离过大，已收紧到边界价":     "Price too far from market, clamped to band edge",
+	"检测账户模式失败，按经典账户处理":   "Failed to detect account mode, assuming classic account",
+	"账户模式":               "Account mode",
+	"统一账户余额已刷新":          "Unified account balance refreshed",
+	"划转成功":               "Transfer completed",
+	"资金已划转":              "Funds transferred",
+	"交易所暂不可用":            "Exchange unavailable",
+	"交易所疑似维护，暂停下单":       "Exchange appears to be in maintenance, order placement paused",
+	"交易所已恢复，恢复下单":        "Exchange recovered, order placement resumed",
+	"只挂单未成交":             "Post-only order not filled",
+	"trader[%d]: entry_routing需要只挂单和费率查询，目前仅支持exchange='gate'": "trader[%d]: entry_routing needs post-only orders and fee queries, currently only exchange='gate' is supported",
+	"开仓执行方式":                                     "Entry route",
+	"只挂单未成交，改为市价开仓":                              "Post-only order not filled, falling back to market entry",
+	"执行成本":                                       "Execution cost",
+	"只挂单开仓成交":                                    "Post-only entry filled",
+	"获取合约费率失败，使用账户级费率":                           "Failed to fetch contract fee rates, using account-level rates",
+	"获取费率失败":                                     "Failed to fetch fee rates",
+	"获取盘口失败，使用市价开仓":                              "Failed to fetch order book, using market entry",
+	"超出策略资金分配额度":                                 "Strategy allocation budget exceeded",
+	"trader[%d]: allocation.weights缺少已启用策略%s的比例": "trader[%d]: allocation.weights is missing a weight for enabled strategy %s",
+	"资金再平衡获取账户余额失败":                              "Allocation rebalance failed to get balance",
+	"资金再平衡失败":                                    "Allocation rebalance failed",
+	"资金分配已再平衡":                                   "Strategy allocation rebalanced",
+	"超出组合敞口上限":                                   "Combined exposure limit exceeded",
+	"币种已从配置移除，但仍持有期现组合，继续跟踪至解除": "Symbol removed from config but a basis pair is still held; tracking until it unwinds",
+	"计算期现基差失败":                                                      "Failed to compute spot-perp basis",
+	"永续空头已不存在，卖出现货腿":                                                "Perp short no longer exists, selling spot leg",
+	"基差收敛，解除期现组合":                                                   "Basis converged, unwinding basis pair",
+	"永续溢价达到阈值，买入现货并做空永续":                                            "Perp premium reached threshold, buying spot and shorting perp",
+	"获取持仓失败，跳过期现组合检查":                                               "Failed to get positions, skipping basis pair leg check",
+	"恢复期现套利持仓失败":                                                    "Failed to restore basis pair",
+	"恢复期现套利持仓":                                                      "Restored basis pair",
+	"trader[%d]: basis需要现货模块，目前仅支持exchange='gate'":                  "trader[%d]: basis requires the spot module, only exchange='gate' is supported",
+	"trader[%d]: 只读模式不支持basis.trade（可关闭trade只监控基差）":                 "trader[%d]: read-only mode does not support basis.trade (turn off trade to only monitor the basis)",
+	"trader[%d]: %s 不能同时用于basis.trade和funding_harvest（两者会共用同一永续空头）": "trader[%d]: %s cannot be used by both basis.trade and funding_harvest (they would share one perp short)",
+	"查询订单记录失败":                                                      "Failed to look up order record",
+	"查询订单文本失败":                                                      "Failed to fetch order text",
+	"更新持仓归因失败":                                                      "Failed to update position attribution",
+	"已按订单文本找回接管持仓的策略":                                               "Recovered strategy of adopted position from order text",
+	"预估强平价距离过近":                                                     "Estimated liquidation price too close",
+	"获取维持保证金档位失败，使用默认维持保证金率":                                        "Failed to fetch maintenance margin tiers, using the default maintenance rate",
+	"获取行情失败，跳过强平价检查":                                                "Failed to fetch market data, skipping liquidation check",
+	"预估强平价":                                                         "Estimated liquidation price",
+	"估算所需保证金失败，跳过本地检查":                                              "Failed to estimate required margin, skipping local check",
+	"获取可用余额失败，跳过本地保证金检查":                                            "Failed to fetch available balance, skipping local margin check",
+	"追价获取盘口失败，剩余数量改为市价":                                             "Chase failed to fetch order book, sending the rest as market",
+	"追价挂单失败，剩余数量改为市价":                                               "Chase order failed, sending the rest as market",
+	"追价重挂": "Chase re-pegged",
+	"追价超时后市价开仓失败，保留已成交部分": "Market entry after chase timeout failed, keeping the filled part",
+	"追价开仓成交":               "Chase entry filled",
+	"限价单已挂出":               "Limit order placed",
+	"已撤销委托单":               "Order cancelled",
+	"限价单开始跟踪":              "Tracking limit order",
+	"查询限价单状态失败":            "Failed to query limit order status",
+	"限价单超时撤单失败":            "Failed to cancel expired limit order",
+	"限价单部分成交":              "Limit order partially filled",
+	"限价单结束":                "Limit order finished",
+	"限价单部分成交，市价补齐剩余数量":     "Limit order partially filled, completing the rest at market",
+	"市价补齐限价单失败":            "Failed to complete limit order at market",
+	"已保存止损止盈快照":            "Protective order snapshot saved",
+	"持仓已不存在，跳过恢复条件单":       "Position no longer exists, skipping trigger order restore",
+	"已恢复条件单":               "Trigger order restored",
+	"删除条件单快照失败":            "Failed to delete trigger order snapshot",
+	"解析K线推送失败":             "Failed to parse candlestick push",
+	"交易器不支持K线推送，AI决策按时间调度": "Trader does not support candlestick streaming, AI decisions run on the time schedule",
+	"启动K线推送失败，AI决策按时间调度":   "Failed to start candlestick stream, AI decisions run on the time schedule",
+	"K线收盘":                 "Bar closed",
+	"AI决策按K线收盘触发":          "AI decisions triggered on bar close",
+	"信号被节流":                "Signal throttled",
+	"下单结果不确定且无法查询原订单，不再重试": "Order result unknown and original order lookup failed, not retrying",
+	"下单结果不确定，原订单已到达交易所，不重复下单": "Order result unknown, original order reached the exchange, not resubmitting",
+	"下单结果不确定，原订单未到达交易所，重新提交":  "Order result unknown, original order did not reach the exchange, resubmitting",
+	"按客户端订单ID查询订单失败":          "Failed to look up order by client order ID",
+	"按客户端订单ID找到重启前提交的订单":      "Found order submitted before restart by client order ID",
+	"阶段超时": "Stage timed out",
+	"阶段完成": "Stage finished",
+	"超时的阶段仍在后台运行，结束后才开始下一周期": "Timed-out stage still running in background, next cycle waits for it",
+	"超时的阶段已结束":                 "Timed-out stage finished",
+	"确认阶段发现异常":                 "Confirm stage found a problem",
+	"symbol_overrides[%d]: %w": "symbol_overrides[%d]: %w",
+	"订单未成交":                    "order not filled",
+	"开仓完全未成交，重试":               "Entry order filled nothing, retrying",
+	"trader[%d]: entry_routing.zero_fill=limit需要只挂单，目前仅支持exchange='gate'": "trader[%d]: entry_routing.zero_fill=limit needs post-only orders and only supports exchange='gate'",
+	"查询风险限额档位失败，按合约参数推算":                                                  "Failed to fetch risk limit tiers, deriving them from contract settings",
+	"超出账户杠杆限制":      "account leverage limit exceeded",
+	"API密钥无效":       "invalid API key",
+	"IP不在API密钥白名单中": "IP not in the API key whitelist",
+	"API密钥权限不足":     "API key lacks permission",
+	"请求时间戳已过期":      "request timestamp expired",
+	"API密钥设置了IP白名单，本机出口IP不在其中：在交易所API管理页面添加本机公网IP":         "The API key has an IP whitelist that does not include this host: add this machine's public IP in the exchange's API management page",
+	"API密钥缺少所需权限：在交易所API管理页面为该密钥开启合约的读取和交易权限":              "The API key lacks a required permission: enable futures read and trade permissions for it in the exchange's API management page",
+	"检查api_key和secret_key是否正确、密钥是否已删除或过期，以及是否误用了测试网/主网的密钥": "Check that api_key and secret_key are correct, that the key has not been deleted or expired, and that a testnet/mainnet key is not used on the wrong network",
+	"本地时钟与交易所相差过大，签名被拒绝：同步系统时间（NTP）":                       "The local clock is too far from the exchange's and signatures are rejected: synchronize the system time (NTP)",
+	"本地时钟已恢复同步，取消时间戳校正":                                    "Local clock back in sync, timestamp correction removed",
+	"本地时钟与交易所偏差过大，请同步系统时间（NTP）":                            "Local clock differs from the exchange too much, sync the system time (NTP)",
+	"本地时钟与交易所偏差过大，已校正签名时间戳，请同步系统时间（NTP）":                   "Local clock differs from the exchange too much, signed timestamps corrected; sync the system time (NTP)",
+	"本地时钟偏差过大":                "Local clock skew too large",
+	"写入风控事件失败":                "Failed to write risk event",
+	"已安装订单钩子":                 "Order hook installed",
+	"钩子执行panic":               "Hook panicked",
+	"订单被钩子拒绝":                 "Order rejected by hook",
+	"条件单续期失败":                 "Failed to renew trigger orders",
+	"条件单即将到期，已续期":             "Trigger order near expiry renewed",
+	"1分钟K线出现缺口，重新回填":          "Gap in 1m klines, backfilling again",
+	"多周期K线回填失败，下一根1分钟K线到达时重试": "Multi-timeframe kline backfill failed, retrying on the next 1m bar",
+	"多周期K线已回填":                "Multi-timeframe klines backfilled",
+	"交易器不支持K线推送，多周期K线服务未启动":   "Trader does not support kline streaming, multi-timeframe kline service not started",
+	"启动多周期K线推送失败":             "Failed to start multi-timeframe kline stream",
+	"多周期K线服务已启动":              "Multi-timeframe kline service started",
+	"当前行情状态禁止开仓":              "Entries are blocked in the current market regime",
+	"获取行情状态失败，跳过行情状态过滤":       "Failed to get market regime, skipping regime filter",
+	"拉取新闻源失败，保留上次结果":          "Failed to fetch news source, keeping previous headlines",
+	"新闻源已更新":                  "News source refreshed",
+	"重要经济事件前后禁止开仓":            "New entries are blocked around a high-impact economic event",
+	"获取真实费率失败，使用默认费率":         "Failed to get real fee rates, using default rates",
+	"获取资金费结算周期失败，下次查询持仓时重试":   "Failed to get funding schedule, retrying on the next position query",
+	"获取资金费率失败，下次查询持仓时重试":      "Failed to get funding rate, retrying on the next position query",
+	"模拟资金费结算":                 "Simulated funding settled",
+	"获取合约规格失败，按1张=1个币计算":      "Failed to get contract spec, treating one contract as one coin",
+	"平仓建议超时未处理，自动平仓":          "Close recommendation not handled in time, closing automatically",
+	"平仓需要人工确认":                "Close requires operator confirmation",
+	"已确认平仓建议":                 "Close recommendation confirmed",
+	"已忽略平仓建议":                 "Close recommendation dismissed",
+	"平仓建议待确认":                 "Close recommendation awaiting confirmation",
+	"应答Telegram按钮失败":          "Failed to answer Telegram button",
+	"下单频率超限":                  "Order rate limit exceeded",
+	"下单频率超限，暂停交易":             "Order rate limit exceeded, trading paused",
+	"超出风控参数":                  "Risk profile limit exceeded",
+	"获取币种指数价失败，估值按0计算":        "Failed to get currency index price, valuing at 0",
+	"获取抵押折算率失败":               "Failed to get collateral discount tiers",
+	"trader[%d]: ledger_monitor需要查询账户流水，目前仅支持exchange='gate'": "trader[%d]: ledger_monitor needs to read the account ledger, currently only exchange='gate' is supported",
+	"交易器不支持资金流水监控":                                            "Trader does not support ledger monitoring",
+	"查询资金流水失败":                                                "Failed to query account ledger",
+	"查询API密钥信息失败":                                             "Failed to query API key info",
+	"资金流水监控已启动":                                               "Ledger monitor started",
+	"API密钥权限变更":                                               "API key permissions changed",
+	"检测到资金转入":                                                 "Funds transferred in",
+	"检测到资金转出":                                                 "Funds withdrawn or transferred out",
+	"API密钥没有钱包读取权限，只监控合约账户划转":                                 "API key cannot read the wallet, only futures account transfers are monitored",
+	"prompt.version不能超过%d个字符: %s":                             "prompt.version cannot exceed %d characters: %s",
+	"prompt.version只能包含字母、数字、下划线和点: %s":                       "prompt.version may only contain letters, digits, underscores and dots: %s",
+	"prompt.rules_file需要同时指定prompt.version（用于区分两个版本的交易表现）": "prompt.rules_file requires prompt.version (to tell the two versions' performance apart)",
+	"prompt.max_context_tokens不能小于%d（0表示不限制）: %d":          "prompt.max_context_tokens cannot be less than %d (0 means unlimited): %d",
+	"trader[%d]: shadow_of指向的trader不存在: %s":                "trader[%d]: shadow_of points to an unknown trader: %s",
+	"trader[%d]: shadow_of必须指向另一个非影子trader: %s":            "trader[%d]: shadow_of must point to another trader that is not itself a shadow: %s",
+	"trader[%d]: adl_guard需要持仓的ADL排名，目前仅支持exchange='gate'": "trader[%d]: adl_guard needs the position's ADL ranking, currently only exchange='gate' is supported",
+	"持仓接近自动减仓队列前列":                                         "Position near the front of the auto-deleveraging queue",
+	"ADL预警主动减仓":                                            "Position reduced on ADL warning",
+	"trader[%d]: margin（全仓模式/风险限额）目前仅支持exchange='gate'":    "trader[%d]: margin (cross mode/risk limits) currently only supports exchange='gate'",
+	"风险限额已设置":                                              "Risk limit set",
+	"下单数量低于最小下单数量，按最小数量下单":                                 "Order quantity below the minimum order size, using the minimum size",
+	"低于最小下单数量，拒绝":                                          "below the minimum order size, rejected",
+	"开仓待人工确认":                                              "entry awaiting manual confirmation",
+	"开仓建议待确认":                                              "Entry proposal awaiting confirmation",
+	"开仓需要人工确认":                                             "Entry requires manual confirmation",
+	"生成下单预览失败":                                             "Failed to build order preview",
+	"已确认开仓建议":                                              "Entry proposal confirmed",
+	"已忽略开仓建议":                                              "Entry proposal dismissed",
+	"查询历史成交失败，未估算滑点":                                       "Failed to query past fills, slippage not estimated",
+	"交易所维护中，平仓单已排队":                                        "Exchange in maintenance, close order queued",
+	"排队的平仓单已过期":                                            "Queued close order expired",
+	"排队的平仓单已过期，未执行":                                        "Queued close order expired, not executed",
+	"排队的平仓单对应持仓已不存在，跳过":                                    "Position for queued close order no longer exists, skipped",
+	"排队的平仓单执行失败":                                           "Queued close order failed",
+	"排队的平仓单已执行":                                            "Queued close order executed",
+	"trader[%d]: portfolio_settles目前仅支持exchange='gate'":    "trader[%d]: portfolio_settles currently only supports exchange='gate'",
+	"超出每周期开仓上限":                                            "Entry skipped: per-cycle entry limit reached",
+	"trader[%d]: 只读模式不支持hedge":                             "trader[%d]: hedge is not supported in read-only mode",
+	"已建立对冲":                                                "Hedge opened",
+	"已解除对冲":                                                "Hedge removed",
+	"持仓已平仓，解除对冲":                                           "Position closed, unwinding hedge",
+	"获取持仓失败，跳过对冲检查":                                        "Failed to fetch positions, skipping hedge check",
+	"恢复对冲失败":                                               "Failed to restore hedge",
+	"恢复对冲":                                                 "Restored hedge",
+	"API密钥与配置的网络（测试网/主网）不一致":                               "API key belongs to the other network (testnet/mainnet)",
+	"修改gate_testnet使其与密钥所属的网络一致，或换用对应网络的密钥（测试网密钥在测试网页面的API管理中创建）": "set gate_testnet to the network the key belongs to, or use a key for the configured network (testnet keys are created in API management on the testnet site)",
+	"主网":  "mainnet",
+	"测试网": "testnet",
+	"测试网合约账户没有资金，开仓都会因保证金不足失败：在Gate.io测试网页面领取测试资金并划转到USDT合约账户": "the testnet futures account is unfunded and every entry will fail for insufficient margin: claim test funds on the Gate.io testnet site and transfer them to the USDT futures account",
+	"决策已过期":      "decision is stale",
+	"决策已过期，拒绝执行": "Decision is stale, not executed",
+	"决策过期检查获取价格失败，只检查快照时间":                                            "Failed to fetch price for the staleness check, checking snapshot age only",
+	"下单被交易所暂时拒绝，退避后重新提交":                                              "Order temporarily refused by the exchange, resubmitting after backoff",
+	"余额核对: 获取余额失败":                                                    "Balance check: failed to fetch balance",
+	"余额核对: 汇总交易日志失败":                                                  "Balance check: failed to sum the journal",
+	"余额核对: 查询资金流水失败":                                                  "Balance check: failed to query the ledger",
+	"余额变化与交易日志不符":                                                     "Wallet balance change does not match the journal",
+	"余额变化异常":                                                          "Balance anomaly",
+	"平仓前查询最新持仓失败，按原数量下单":                                              "Failed to fetch the latest position before closing, using the requested size",
+	"平仓数量超过持仓，按持仓数量下单":                                                "Close size exceeds the position, clamped to the position size",
+	"trader[%d]: funding_harvest.entry_percentile需要启用funding_history": "trader[%d]: funding_harvest.entry_percentile requires funding_history to be enabled",
+	"funding_history需要启用交易日志存储（store_path不能为\"-\"）":                   "funding_history requires the journal store (store_path must not be \"-\")",
+	"禁止开仓时段":                                                          "New entries are blocked in this window",
+	"trader[%d]: history_import_days必须在0-%d之间":                        "trader[%d]: history_import_days must be between 0 and %d",
+	"trader[%d]: history_import_days需要查询平仓历史，目前仅支持exchange='gate'":    "trader[%d]: history_import_days needs the closed position history, which only exchange='gate' supports",
+	"持仓或开仓挂单期间禁止切换杠杆":                                                 "Leverage cannot be changed while a position or entry order is open",
+	"trader[%d]: soak只能用于模拟交易（dry_run或影子trader）":                      "trader[%d]: soak can only be used with paper trading (dry_run or a shadow trader)",
+	"已有同方向持仓或未完成的开仓单":                                                 "A position or unfinished entry already exists on this side",
+	"超出单笔亏损上限":                                                        "Per-trade loss cap exceeded",
+	"数据源故障降级中，暂停开仓":                                                   "Degraded by a data source failure, entries paused",
+	"刷新汇率失败，保留上次汇率":                                                   "Failed to refresh FX rate, keeping the previous rate",
+	"汇率已更新":                 "FX rate refreshed",
+	"订单状态无法确认":              "order status could not be confirmed",
+	"查询持仓失败，无法确认订单成交":       "Failed to fetch positions, cannot confirm the order fill",
+	"读取持仓记录失败，无法确认订单成交":     "Failed to read the position record, cannot confirm the order fill",
+	"订单状态无法确认，按交易所持仓变化确认成交": "Order status unknown, confirmed the fill from the exchange position change",
+}
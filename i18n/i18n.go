@@ -0,0 +1,101 @@
+// Package i18n 日志和错误消息的语言切换
+//
+// 源码中的中文消息本身就是消息ID：切换到其他语言后，T按目录返回译文，目录中没有的消息原样返回，
+// 因此可以逐步补充译文。格式化字符串同样以原文为ID（i18n.Errorf("读取配置文件失败: %w", err)）。
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// 支持的语言
+const (
+	Chinese = "zh" // 默认（源码中的原文）
+	English = "en"
+)
+
+// LangEnv 指定语言的环境变量（配置文件加载前生效，配置文件中的language优先）
+const LangEnv = "NOFX_LANG"
+
+// catalogs 各语言的译文目录（原文 → 译文）
+var catalogs = map[string]map[string]string{
+	English: english,
+}
+
+// active 当前语言的译文目录（nil表示中文原文）
+var active atomic.Pointer[map[string]string]
+
+// language 当前语言
+var language atomic.Value
+
+func init() {
+	language.Store(Chinese)
+	if lang := os.Getenv(LangEnv); lang != "" {
+		if err := SetLanguage(lang); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// Supported 是否支持该语言（空字符串视为默认语言）
+func Supported(lang string) bool {
+	if lang == "" || lang == Chinese {
+		return true
+	}
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// SetLanguage 切换日志和错误消息的语言（zh/en，空字符串为zh）
+func SetLanguage(lang string) error {
+	if !Supported(lang) {
+		return Errorf("不支持的语言: %s（应为zh/en）", lang)
+	}
+	if lang == "" {
+		lang = Chinese
+	}
+	if catalog, ok := catalogs[lang]; ok {
+		active.Store(&catalog)
+	} else {
+		active.Store(nil)
+	}
+	language.Store(lang)
+	return nil
+}
+
+// Language 当前语言
+func Language() string {
+	return language.Load().(string)
+}
+
+// T 按当前语言翻译消息（目录中没有时返回原文）
+func T(msg string) string {
+	catalog := active.Load()
+	if catalog == nil {
+		return msg
+	}
+	if translated, ok := (*catalog)[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Errorf 按当前语言翻译格式字符串后创建错误（支持%w）
+func Errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(T(format), args...)
+}
+
+// New 创建可翻译的错误：Error()按调用时的语言输出，适合包级的哨兵错误（errors.Is按指针比较，不受语言影响）
+func New(msg string) error {
+	return &message{text: msg}
+}
+
+type message struct {
+	text string
+}
+
+func (m *message) Error() string {
+	return T(m.text)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"nofx/config"
+	"nofx/manager"
+	"nofx/trader"
+	"os"
+	"strings"
+	"time"
+)
+
+// watchKillSwitch 监视哨兵文件（kill_switch_file）：文件存在时暂停所有trader，删除后恢复由哨兵文件暂停的trader
+// 文件内容为暂停原因；包含一行 cancel_orders 时同时撤销没有持仓的币种上的挂单
+// 文件存在期间通过其他方式恢复的trader会在下次检查时重新暂停
+func watchKillSwitch(tm *manager.TraderManager, current func() *config.Config, stop <-chan struct{}) {
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		checkKillSwitch(tm, current().KillSwitchFile)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkKillSwitch 按哨兵文件是否存在暂停或恢复trader
+func checkKillSwitch(tm *manager.TraderManager, path string) {
+	if path == "-" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		mainLog.Warn("读取哨兵文件失败", "path", path, "err", err)
+		return
+	}
+	present := err == nil
+
+	traders := tm.GetAllTraders()
+	if !present {
+		for _, t := range traders {
+			if t.IsPaused() && t.PauseState().Source == trader.PauseSourceFile {
+				mainLog.Info("哨兵文件已删除，恢复交易", "path", path, "trader", t.GetID())
+				t.Resume()
+			}
+		}
+		return
+	}
+
+	reason, cancelOrders := parseKillSwitch(string(data))
+	for _, t := range traders {
+		if t.IsPaused() {
+			continue
+		}
+		mainLog.Warn("发现哨兵文件，暂停交易", "path", path, "trader", t.GetID())
+		cancelled, err := t.Pause(trader.PauseSourceFile, reason, cancelOrders)
+		if err != nil {
+			mainLog.Error("暂停时撤单失败", "trader", t.GetID(), "err", err)
+		} else if cancelled > 0 {
+			mainLog.Info("已撤销挂单", "trader", t.GetID(), "symbols", cancelled)
+		}
+	}
+}
+
+// parseKillSwitch 解析哨兵文件内容：cancel_orders行表示撤单，其余内容为暂停原因
+func parseKillSwitch(content string) (reason string, cancelOrders bool) {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.EqualFold(line, "cancel_orders"):
+			cancelOrders = true
+		default:
+			lines = append(lines, line)
+		}
+	}
+	reason = strings.Join(lines, " ")
+	if reason == "" {
+		reason = "哨兵文件"
+	}
+	return reason, cancelOrders
+}
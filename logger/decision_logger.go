@@ -7,23 +7,39 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
 // DecisionRecord 决策记录
 type DecisionRecord struct {
-	Timestamp      time.Time          `json:"timestamp"`       // 决策时间
-	CycleNumber    int                `json:"cycle_number"`    // 周期编号
-	InputPrompt    string             `json:"input_prompt"`    // 发送给AI的输入prompt
-	CoTTrace       string             `json:"cot_trace"`       // AI思维链（输出）
-	DecisionJSON   string             `json:"decision_json"`   // 决策JSON
-	AccountState   AccountSnapshot    `json:"account_state"`   // 账户状态快照
-	Positions      []PositionSnapshot `json:"positions"`       // 持仓快照
-	CandidateCoins []string           `json:"candidate_coins"` // 候选币种列表
-	Decisions      []DecisionAction   `json:"decisions"`       // 执行的决策
-	ExecutionLog   []string           `json:"execution_log"`   // 执行日志
-	Success        bool               `json:"success"`         // 是否成功
-	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
+	Timestamp      time.Time          `json:"timestamp"`                 // 决策时间
+	CycleNumber    int                `json:"cycle_number"`              // 周期编号
+	DecisionID     string             `json:"decision_id,omitempty"`     // 决策ID（写入订单文本，用于关联交易日志）
+	PromptVersion  string             `json:"prompt_version,omitempty"`  // 系统提示词版本
+	SnapshotTime   *time.Time         `json:"snapshot_time,omitempty"`   // 决策所用行情快照的时间
+	SnapshotPrices map[string]float64 `json:"snapshot_prices,omitempty"` // 决策所用行情快照中各币种的价格
+	InputPrompt    string             `json:"input_prompt"`              // 发送给AI的输入prompt
+	Omitted        []string           `json:"omitted,omitempty"`         // 因token预算从prompt中省略的内容
+	CoTTrace       string             `json:"cot_trace"`                 // AI思维链（输出）
+	DecisionJSON   string             `json:"decision_json"`             // 决策JSON
+	AccountState   AccountSnapshot    `json:"account_state"`             // 账户状态快照
+	Positions      []PositionSnapshot `json:"positions"`                 // 持仓快照
+	CandidateCoins []string           `json:"candidate_coins"`           // 候选币种列表
+	Decisions      []DecisionAction   `json:"decisions"`                 // 执行的决策
+	ExecutionLog   []string           `json:"execution_log"`             // 执行日志
+	Stages         []StageResult      `json:"stages,omitempty"`          // 流水线各阶段的结果
+	Success        bool               `json:"success"`                   // 是否成功
+	ErrorMessage   string             `json:"error_message"`             // 错误信息（如果有）
+}
+
+// StageResult 决策周期流水线一个阶段的执行结果
+type StageResult struct {
+	Stage      string `json:"stage"`            // snapshot/decide/risk/execute/confirm
+	Symbol     string `json:"symbol,omitempty"` // 按决策执行的阶段（risk/execute）对应的币种
+	Status     string `json:"status"`           // ok/error/timeout
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
 }
 
 // AccountSnapshot 账户状态快照
@@ -41,6 +57,7 @@ type PositionSnapshot struct {
 	Side             string  `json:"side"`
 	PositionAmt      float64 `json:"position_amt"`
 	EntryPrice       float64 `json:"entry_price"`
+	EntryVWAP        float64 `json:"entry_vwap,omitempty"` // 按成交滚动计算的开仓均价（多次开仓/加仓时参考）
 	MarkPrice        float64 `json:"mark_price"`
 	UnrealizedProfit float64 `json:"unrealized_profit"`
 	Leverage         float64 `json:"leverage"`
@@ -55,6 +72,7 @@ type DecisionAction struct {
 	Leverage  int       `json:"leverage"`  // 杠杆（开仓时）
 	Price     float64   `json:"price"`     // 执行价格
 	OrderID   int64     `json:"order_id"`  // 订单ID
+	Reasoning string    `json:"reasoning"` // 决策理由
 	Timestamp time.Time `json:"timestamp"` // 执行时间
 	Success   bool      `json:"success"`   // 是否成功
 	Error     string    `json:"error"`     // 错误信息
@@ -178,6 +196,35 @@ func (l *DecisionLogger) GetRecordByDate(date time.Time) ([]*DecisionRecord, err
 	return records, nil
 }
 
+// GetRecordsSince 获取指定时间之后的所有记录（按时间正序）
+func (l *DecisionLogger) GetRecordsSince(since time.Time) ([]*DecisionRecord, error) {
+	files, err := ioutil.ReadDir(l.logDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+	}
+
+	var records []*DecisionRecord
+	for _, file := range files {
+		if file.IsDir() || file.ModTime().Before(since) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(l.logDir, file.Name()))
+		if err != nil {
+			continue
+		}
+
+		var record DecisionRecord
+		if err := json.Unmarshal(data, &record); err != nil || record.Timestamp.Before(since) {
+			continue
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+	return records, nil
+}
+
 // CleanOldRecords 清理N天前的旧记录
 func (l *DecisionLogger) CleanOldRecords(days int) error {
 	cutoffTime := time.Now().AddDate(0, 0, -days)
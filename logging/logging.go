@@ -0,0 +1,231 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"nofx/i18n"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Config 日志配置
+type Config struct {
+	Level   string            `json:"level"`   // 全局级别: debug/info/warn/error（默认info）
+	Format  string            `json:"format"`  // 输出格式: console（默认，适合终端）/text（logfmt）/json（适合日志采集）
+	Modules map[string]string `json:"modules"` // 按模块覆盖级别，如 {"gate": "debug", "scheduler": "warn"}
+}
+
+// Validate 验证日志配置
+func (c *Config) Validate() error {
+	if c.Level == "" {
+		c.Level = "info"
+	}
+	if c.Format == "" {
+		c.Format = "console"
+	}
+	if _, err := parseLevel(c.Level); err != nil {
+		return err
+	}
+	for module, level := range c.Modules {
+		if _, err := parseLevel(level); err != nil {
+			return fmt.Errorf("log.modules.%s: %w", module, err)
+		}
+	}
+	switch c.Format {
+	case "console", "text", "json":
+	default:
+		return fmt.Errorf("log.format必须是console/text/json之一: %s", c.Format)
+	}
+	return nil
+}
+
+// state 全局日志状态（Setup前使用默认值，模块logger可在包初始化时创建）
+var state = struct {
+	sync.RWMutex
+	base    slog.Handler
+	level   slog.Level
+	modules map[string]slog.Level
+}{
+	base:  newConsoleHandler(output),
+	level: slog.LevelInfo,
+}
+
+// output 日志输出：标准错误，同时保留最近的日志（见Recent）
+var output = io.MultiWriter(os.Stderr, recent)
+
+// Setup 按配置初始化日志系统，并将标准库log的输出接入slog（模块名为app）
+func Setup(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	level, _ := parseLevel(cfg.Level)
+	modules := make(map[string]slog.Level, len(cfg.Modules))
+	for module, l := range cfg.Modules {
+		modules[module], _ = parseLevel(l)
+	}
+
+	var base slog.Handler
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // 级别由moduleHandler控制
+	switch cfg.Format {
+	case "json":
+		base = slog.NewJSONHandler(output, opts)
+	case "text":
+		base = slog.NewTextHandler(output, opts)
+	default:
+		base = newConsoleHandler(output)
+	}
+
+	state.Lock()
+	state.base = base
+	state.level = level
+	state.modules = modules
+	state.Unlock()
+
+	// 未迁移的log.Printf调用经由slog输出（统一格式和级别过滤）
+	slog.SetDefault(For("app"))
+	log.SetFlags(0)
+	return nil
+}
+
+// For 获取模块logger（日志自动带上module字段，级别可按模块配置）
+func For(module string) *slog.Logger {
+	return slog.New(&moduleHandler{module: module})
+}
+
+// parseLevel 解析日志级别
+func parseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("无效的日志级别: %s（应为debug/info/warn/error）", s)
+	}
+}
+
+// moduleHandler 按模块过滤级别，并在输出时委托给当前全局handler
+type moduleHandler struct {
+	module string
+	ops    []func(slog.Handler) slog.Handler // WithAttrs/WithGroup操作（按顺序应用）
+}
+
+func (h *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	state.RLock()
+	defer state.RUnlock()
+	if l, ok := state.modules[h.module]; ok {
+		return level >= l
+	}
+	return level >= state.level
+}
+
+func (h *moduleHandler) Handle(ctx context.Context, r slog.Record) error {
+	// 结构化日志的消息是固定文本，按当前语言翻译（字段保持原样）
+	r.Message = i18n.T(r.Message)
+
+	state.RLock()
+	handler := state.base
+	state.RUnlock()
+
+	handler = handler.WithAttrs([]slog.Attr{slog.String("module", h.module)})
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(base slog.Handler) slog.Handler { return base.WithAttrs(attrs) })
+}
+
+func (h *moduleHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(base slog.Handler) slog.Handler { return base.WithGroup(name) })
+}
+
+func (h *moduleHandler) with(op func(slog.Handler) slog.Handler) *moduleHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &moduleHandler{module: h.module, ops: append(ops, op)}
+}
+
+// consoleHandler 终端友好的输出格式: "15:04:05 INFO  [gate] 消息 key=value"
+type consoleHandler struct {
+	w     io.Writer
+	mu    *sync.Mutex
+	attrs []slog.Attr
+	group string
+}
+
+func newConsoleHandler(w io.Writer) *consoleHandler {
+	return &consoleHandler{w: w, mu: &sync.Mutex{}}
+}
+
+func (h *consoleHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	sb.WriteString(r.Time.Format("2006/01/02 15:04:05"))
+
+	module := ""
+	var fields []string
+	appendAttr := func(prefix string, a slog.Attr) {
+		if a.Key == "module" {
+			module = a.Value.String()
+			return
+		}
+		fields = append(fields, fmt.Sprintf("%s%s=%v", prefix, a.Key, a.Value.Any()))
+	}
+	for _, a := range h.attrs {
+		appendAttr("", a)
+	}
+	prefix := ""
+	if h.group != "" {
+		prefix = h.group + "."
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(prefix, a)
+		return true
+	})
+
+	// 未迁移的log.Printf输出（app模块）保持原样，只加时间
+	if module != "app" {
+		sb.WriteString(fmt.Sprintf(" %-5s", r.Level.String()))
+		if module != "" {
+			sb.WriteString(" [" + module + "]")
+		}
+	}
+	sb.WriteString(" " + r.Message)
+	for _, f := range fields {
+		sb.WriteString(" " + f)
+	}
+	sb.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
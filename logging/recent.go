@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+)
+
+// recentCapacity 内存中保留的最近日志行数（供管理接口和仪表盘查看）
+const recentCapacity = 1000
+
+// recent 最近日志的环形缓冲（所有输出格式都会写入）
+var recent = &ringBuffer{lines: make([]string, recentCapacity)}
+
+// ringBuffer 按行保存最近的日志
+type ringBuffer struct {
+	lines []string
+	next  int
+	count int
+	mu    sync.Mutex
+}
+
+// Write 按行写入（handler每次写入完整的一条或多条日志）
+func (b *ringBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		b.lines[b.next] = line
+		b.next = (b.next + 1) % len(b.lines)
+		if b.count < len(b.lines) {
+			b.count++
+		}
+	}
+	return len(p), nil
+}
+
+// Recent 最近n行日志（从旧到新，n<=0或超过保留行数时返回全部）
+func Recent(n int) []string {
+	recent.mu.Lock()
+	defer recent.mu.Unlock()
+	if n <= 0 || n > recent.count {
+		n = recent.count
+	}
+	result := make([]string, 0, n)
+	start := (recent.next - n + len(recent.lines)) % len(recent.lines)
+	for i := 0; i < n; i++ {
+		result = append(result, recent.lines[(start+i)%len(recent.lines)])
+	}
+	return result
+}
@@ -1,139 +1,337 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
 	"nofx/api"
 	"nofx/config"
+	"nofx/control"
+	"nofx/fx"
+	"nofx/i18n"
+	"nofx/logging"
 	"nofx/manager"
+	"nofx/market"
+	"nofx/news"
 	"nofx/pool"
+	"nofx/report"
+	"nofx/store"
+	"nofx/symbols"
+	"nofx/tracing"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 )
 
+// mainLog 启动与退出日志
+var mainLog = logging.For("main")
+
+// shutdownTimeout 退出时等待进行中的交易周期结束的最长时间
+const shutdownTimeout = 2 * time.Minute
+
 func main() {
+	// nofx daemon：长期运行的守护进程（PID文件、严格的启动自检、供systemd使用的退出码）
+	args := os.Args[1:]
+	daemon := len(args) > 0 && args[0] == "daemon"
+	if daemon {
+		args = args[1:]
+	} else if runCLI(args) {
+		// 命令行子命令（如 nofx pnl <trader_id> 7d）
+		return
+	}
+
+	opts, err := parseRunOptions(args, daemon)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(exitOK)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(exitUsage)
+	}
+	os.Exit(run(opts))
+}
+
+// run 启动交易系统并阻塞到收到退出信号，返回进程退出码
+func run(opts runOptions) int {
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║    🏆 AI模型交易竞赛系统 - Qwen vs DeepSeek               ║")
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
 	fmt.Println()
 
-	// 加载配置文件
-	configFile := "config.json"
-	if len(os.Args) > 1 {
-		configFile = os.Args[1]
+	if opts.pidFile != "" {
+		if err := writePIDFile(opts.pidFile); err != nil {
+			mainLog.Error("写入PID文件失败", "path", opts.pidFile, "err", err)
+			if errors.Is(err, errPIDConflict) {
+				return exitPIDConflict
+			}
+			return exitFailure
+		}
+		defer removePIDFile(opts.pidFile)
 	}
 
-	log.Printf("📋 加载配置文件: %s", configFile)
+	// 加载配置文件（--dry-run 开启模拟交易）
+	configFile := opts.configFile
+	mainLog.Info("加载配置文件", "path", configFile)
 	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
-		log.Fatalf("❌ 加载配置失败: %v", err)
+		mainLog.Error("加载配置失败", "err", err)
+		return exitConfig
+	}
+	// 日志和错误消息的语言（配置加载前由NOFX_LANG环境变量决定）
+	if cfg.Language != "" {
+		i18n.SetLanguage(cfg.Language)
+	}
+
+	if err := logging.Setup(cfg.Log); err != nil {
+		mainLog.Error("初始化日志失败", "err", err)
+		return exitConfig
+	}
+
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		mainLog.Error("初始化链路追踪失败", "err", err)
+		return exitConfig
+	}
+	if cfg.Tracing.Enabled {
+		mainLog.Info("链路追踪已启用", "endpoint", cfg.Tracing.Endpoint)
 	}
 
-	log.Printf("✓ 配置加载成功，共%d个trader参赛", len(cfg.Traders))
+	mainLog.Info("配置加载成功", "traders", len(cfg.Traders))
+	if cfg.DryRun {
+		mainLog.Warn("模拟交易模式（dry-run）：使用真实行情，下单、止损止盈在本地模拟", "store", cfg.StorePath)
+	}
 	fmt.Println()
 
+	// 交易对符号覆盖（各交易所的特殊合约名）
+	for _, o := range cfg.SymbolOverrides {
+		if err := symbols.Register(o); err != nil {
+			mainLog.Error("交易对符号覆盖无效", "err", err)
+			return exitConfig
+		}
+	}
+	if len(cfg.SymbolOverrides) > 0 {
+		mainLog.Info("已登记交易对符号覆盖", "count", len(cfg.SymbolOverrides))
+	}
+
 	// 设置默认主流币种列表
 	pool.SetDefaultCoins(cfg.DefaultCoins)
 
 	// 设置是否使用默认主流币种
 	pool.SetUseDefaultCoins(cfg.UseDefaultCoins)
 	if cfg.UseDefaultCoins {
-		log.Printf("✓ 已启用默认主流币种列表（共%d个币种）: %v", len(cfg.DefaultCoins), cfg.DefaultCoins)
+		mainLog.Info("已启用默认主流币种列表", "count", len(cfg.DefaultCoins), "coins", cfg.DefaultCoins)
 	}
 
 	// 设置币种池API URL
 	if cfg.CoinPoolAPIURL != "" {
 		pool.SetCoinPoolAPI(cfg.CoinPoolAPIURL)
-		log.Printf("✓ 已配置AI500币种池API")
+		mainLog.Info("已配置AI500币种池API")
 	}
 	if cfg.OITopAPIURL != "" {
 		pool.SetOITopAPI(cfg.OITopAPIURL)
-		log.Printf("✓ 已配置OI Top API")
+		mainLog.Info("已配置OI Top API")
 	}
 
 	// 创建TraderManager
 	traderManager := manager.NewTraderManager()
 
+	// 打开交易日志存储（订单、成交、持仓生命周期、余额快照）
+	if cfg.StorePath != "-" {
+		journal, err := store.Open(cfg.StorePath)
+		if err != nil {
+			mainLog.Error("打开交易日志存储失败", "err", err)
+			return exitFailure
+		}
+		defer journal.Close()
+		traderManager.SetJournal(journal)
+		mainLog.Info("交易日志存储", "path", store.Redact(cfg.StorePath))
+
+		// 历史资金费率分布（当前费率相对近N天的百分位写入行情数据）
+		if cfg.FundingHistory.Enabled {
+			history := report.NewFundingHistory(journal, cfg.FundingHistory.Days)
+			market.SetFundingStatsSource(history.Source())
+			go backfillFundingHistory(history, cfg.FundingHistory.Symbols)
+			mainLog.Info("已启用历史资金费率分布", "days", cfg.FundingHistory.Days)
+		}
+	}
+
+	// 通知渠道（Telegram机器人同时接受命令）和每日/每周汇总报告，配置变更时热加载
+	// 事件同时推送给gRPC控制平面的WatchEvents订阅者
+	events := control.NewHub()
+	reloader := newReloader(configFile, cfg, traderManager, events)
+	if err := reloader.start(); err != nil {
+		mainLog.Error("启动通知和汇总报告失败", "err", err)
+		return exitConfig
+	}
+	defer reloader.stop()
+
 	// 添加所有启用的trader
 	enabledCount := 0
 	for i, traderCfg := range cfg.Traders {
 		// 跳过未启用的trader
 		if !traderCfg.Enabled {
-			log.Printf("⏭️  [%d/%d] 跳过未启用的 %s", i+1, len(cfg.Traders), traderCfg.Name)
+			mainLog.Info("跳过未启用的trader", "index", i+1, "total", len(cfg.Traders), "name", traderCfg.Name)
 			continue
 		}
 
 		enabledCount++
-		log.Printf("📦 [%d/%d] 初始化 %s (%s模型)...",
-			i+1, len(cfg.Traders), traderCfg.Name, strings.ToUpper(traderCfg.AIModel))
-
-		err := traderManager.AddTrader(
-			traderCfg,
-			cfg.CoinPoolAPIURL,
-			cfg.MaxDailyLoss,
-			cfg.MaxDrawdown,
-			cfg.StopTradingMinutes,
-			cfg.Leverage, // 传递杠杆配置
-		)
-		if err != nil {
-			log.Fatalf("❌ 初始化trader失败: %v", err)
+		mainLog.Info("初始化trader", "index", i+1, "total", len(cfg.Traders),
+			"name", traderCfg.Name, "model", strings.ToUpper(traderCfg.AIModel))
+
+		if err := traderManager.AddTrader(traderCfg, cfg); err != nil {
+			mainLog.Error("初始化trader失败", "trader", traderCfg.ID, "err", err)
+			return exitConfig
 		}
 	}
 
 	// 检查是否至少有一个启用的trader
 	if enabledCount == 0 {
-		log.Fatalf("❌ 没有启用的trader，请在config.json中设置至少一个trader的enabled=true")
+		mainLog.Error("没有启用的trader，请在配置文件中设置至少一个trader的enabled=true")
+		return exitConfig
+	}
+
+	// 启动自检：交易所连通性、API密钥、时钟偏差、合约元数据
+	// 守护进程模式下自检失败直接退出（由systemd按退出码决定是否重启），否则只告警；
+	// 密钥与gate_testnet配置的网络不一致时（如测试网配置误用了主网密钥）非模拟交易模式下一律拒绝启动
+	if !opts.skipSelfCheck {
+		code, networkMismatch := runSelfCheck(traderManager)
+		if networkMismatch && !cfg.DryRun {
+			mainLog.Error("API密钥与gate_testnet配置的网络不一致，拒绝启动实盘策略")
+			return exitConfig
+		}
+		if code != exitOK {
+			if opts.daemon {
+				mainLog.Error("启动自检失败", "exit_code", code)
+				return code
+			}
+			mainLog.Warn("启动自检未通过，继续运行")
+		}
 	}
 
 	fmt.Println()
-	fmt.Println("🏁 竞赛参赛者:")
+	fmt.Println(i18n.T("🏁 竞赛参赛者:"))
 	for _, traderCfg := range cfg.Traders {
 		// 只显示启用的trader
 		if !traderCfg.Enabled {
 			continue
 		}
-		fmt.Printf("  • %s (%s) - 初始资金: %.0f USDT\n",
+		fmt.Printf(i18n.T("  • %s (%s) - 初始资金: %.0f USDT\n"),
 			traderCfg.Name, strings.ToUpper(traderCfg.AIModel), traderCfg.InitialBalance)
 	}
 
 	fmt.Println()
-	fmt.Println("🤖 AI全权决策模式:")
-	fmt.Printf("  • AI将自主决定每笔交易的杠杆倍数（山寨币最高%d倍，BTC/ETH最高%d倍）\n",
+	fmt.Println(i18n.T("🤖 AI全权决策模式:"))
+	fmt.Printf(i18n.T("  • AI将自主决定每笔交易的杠杆倍数（山寨币最高%d倍，BTC/ETH最高%d倍）\n"),
 		cfg.Leverage.AltcoinLeverage, cfg.Leverage.BTCETHLeverage)
-	fmt.Println("  • AI将自主决定每笔交易的仓位大小")
-	fmt.Println("  • AI将自主设置止损和止盈价格")
-	fmt.Println("  • AI将基于市场数据、技术指标、账户状态做出全面分析")
+	fmt.Println(i18n.T("  • AI将自主决定每笔交易的仓位大小"))
+	fmt.Println(i18n.T("  • AI将自主设置止损和止盈价格"))
+	fmt.Println(i18n.T("  • AI将基于市场数据、技术指标、账户状态做出全面分析"))
 	fmt.Println()
-	fmt.Println("⚠️  风险提示: AI自动交易有风险，建议小额资金测试！")
+	fmt.Println(i18n.T("⚠️  风险提示: AI自动交易有风险，建议小额资金测试！"))
 	fmt.Println()
-	fmt.Println("按 Ctrl+C 停止运行")
+	fmt.Println(i18n.T("按 Ctrl+C 停止运行"))
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println()
 
 	// 创建并启动API服务器
 	apiServer := api.NewServer(traderManager, cfg.APIServerPort)
+	apiServer.SetConfigSource(reloader.Current)
+	apiServer.SetReloadHandler(reloader.Reload)
+	// API服务器或gRPC控制平面异常退出（如端口被占用）时停止整个进程
+	fatal := make(chan error, 2)
 	go func() {
 		if err := apiServer.Start(); err != nil {
-			log.Printf("❌ API服务器错误: %v", err)
+			fatal <- i18n.Errorf("API服务器错误: %w", err)
 		}
 	}()
 
+	// gRPC控制平面（与管理接口使用同一令牌）
+	if cfg.Admin.GRPCPort > 0 {
+		controlServer := control.NewServer(traderManager, events, reloader.Current, reloader.Reload)
+		go func() {
+			if err := controlServer.Serve(cfg.Admin.GRPCPort); err != nil {
+				fatal <- i18n.Errorf("gRPC控制平面错误: %w", err)
+			}
+		}()
+		defer controlServer.Stop()
+	}
+
 	// 设置优雅退出
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// SIGHUP或配置文件变更时热加载配置
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	stopWatch := make(chan struct{})
+	go reloader.watch(stopWatch)
+	go watchKillSwitch(traderManager, reloader.Current, stopWatch)
+
+	// 新闻和情绪模块（按币种汇总写入市场数据）
+	if cfg.News.Enabled() {
+		service := news.NewService(cfg.News)
+		news.SetDefault(service)
+		service.Start(stopWatch)
+		mainLog.Info("已启用新闻和情绪模块", "sources", len(cfg.News.Sources))
+	}
+	// 报告币种（汇总报告、盈亏通知和看板显示换算后的金额）
+	if cfg.ReportingCurrency.Enabled() {
+		service := fx.NewService(cfg.ReportingCurrency)
+		fx.SetDefault(service)
+		service.Start(stopWatch)
+		mainLog.Info("报告币种", "currency", strings.ToUpper(cfg.ReportingCurrency.Currency))
+	}
+	go func() {
+		for range hupChan {
+			reloader.reloadAndLog("SIGHUP")
+		}
+	}()
+
 	// 启动所有trader
 	traderManager.StartAll()
+	sdNotify("READY=1")
 
 	// 等待退出信号
-	<-sigChan
-	fmt.Println()
-	fmt.Println()
-	log.Println("📛 收到退出信号，正在停止所有trader...")
-	traderManager.StopAll()
+	code := exitOK
+	select {
+	case sig := <-sigChan:
+		fmt.Println()
+		fmt.Println()
+		mainLog.Info("收到退出信号，正在停止所有trader", "signal", sig)
+	case err := <-fatal:
+		mainLog.Error("服务异常退出，正在停止所有trader", "err", err)
+		code = exitFailure
+	}
+	sdNotify("STOPPING=1")
+	close(stopWatch)
+	signal.Stop(hupChan)
+
+	// 再次收到退出信号时不再等待，立即退出
+	go func() {
+		<-sigChan
+		mainLog.Warn("再次收到退出信号，强制退出")
+		os.Exit(exitFailure)
+	}()
+
+	// 等待进行中的决策周期结束（避免下单执行到一半），再关闭API服务器
+	if !traderManager.StopAll(shutdownTimeout) {
+		code = exitFailure
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := apiServer.Shutdown(ctx); err != nil {
+		mainLog.Warn("关闭API服务器失败", "err", err)
+	}
+
+	// 导出剩余的追踪数据
+	if err := shutdownTracing(ctx); err != nil {
+		mainLog.Warn("导出追踪数据失败", "err", err)
+	}
 
 	fmt.Println()
-	fmt.Println("👋 感谢使用AI交易竞赛系统！")
+	fmt.Println(i18n.T("👋 感谢使用AI交易竞赛系统！"))
+	return code
 }
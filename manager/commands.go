@@ -0,0 +1,248 @@
+package manager
+
+import (
+	"fmt"
+	"nofx/report"
+	"nofx/trader"
+	"sort"
+	"strings"
+)
+
+// Commands 远程命令（Telegram等）的执行者，作用于所有trader
+type Commands struct {
+	tm *TraderManager
+}
+
+// NewCommands 创建远程命令执行者
+func NewCommands(tm *TraderManager) *Commands {
+	return &Commands{tm: tm}
+}
+
+// Positions 所有trader的当前持仓
+func (c *Commands) Positions() string {
+	var sb strings.Builder
+	for _, id := range c.sortedIDs() {
+		t, _ := c.tm.GetTrader(id)
+		positions, err := t.GetPositions()
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("[%s] 获取持仓失败: %v\n", id, err))
+			continue
+		}
+		if len(positions) == 0 {
+			sb.WriteString(fmt.Sprintf("[%s] 无持仓\n", id))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("[%s] %d个持仓\n", id, len(positions)))
+		for _, pos := range positions {
+			sb.WriteString(fmt.Sprintf("  %s %s 数量%.4f 入场%.4f 标记%.4f 未实现%+.2f (%+.2f%%)\n",
+				pos["symbol"], strings.ToUpper(fmt.Sprint(pos["side"])), pos["quantity"], pos["entry_price"],
+				pos["mark_price"], pos["unrealized_pnl"], pos["unrealized_pnl_pct"]))
+		}
+	}
+	return c.orEmpty(sb.String())
+}
+
+// PnL 所有trader指定周期的盈亏报表（session为本次启动以来的会话统计）
+func (c *Commands) PnL(periodName string) string {
+	if strings.EqualFold(periodName, "session") {
+		return c.SessionStats()
+	}
+	period, err := report.ParsePeriod(periodName)
+	if err != nil {
+		return err.Error()
+	}
+
+	journal := c.tm.GetJournal()
+	if journal == nil {
+		return "交易日志存储未启用，无法生成盈亏报表"
+	}
+
+	var sb strings.Builder
+	for _, id := range c.sortedIDs() {
+		t, _ := c.tm.GetTrader(id)
+		unrealized := make(map[string]float64)
+		if positions, err := t.GetPositions(); err == nil {
+			for _, pos := range positions {
+				symbol, _ := pos["symbol"].(string)
+				pnl, _ := pos["unrealized_pnl"].(float64)
+				unrealized[symbol] += pnl
+			}
+		}
+
+		pnl, err := report.ReportPnL(journal, id, period, unrealized)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("[%s] 生成盈亏报表失败: %v\n", id, err))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("[%s]\n%s\n", id, pnl.String()))
+	}
+	return c.orEmpty(sb.String())
+}
+
+// SessionStats 所有trader本次启动以来的会话统计（不依赖交易日志）
+func (c *Commands) SessionStats() string {
+	var sb strings.Builder
+	for _, id := range c.sortedIDs() {
+		t, _ := c.tm.GetTrader(id)
+		sb.WriteString(fmt.Sprintf("[%s]\n%s\n", id, t.GetSessionStats().String()))
+	}
+	return c.orEmpty(sb.String())
+}
+
+// Portfolio 所有账户各结算币种账本按美元汇总的组合视图
+func (c *Commands) Portfolio() string {
+	return c.tm.Portfolio().String()
+}
+
+// Pause 暂停所有trader的AI决策和新开仓（重启后保持暂停）
+func (c *Commands) Pause(reason string, cancelOrders bool) string {
+	var sb strings.Builder
+	sb.WriteString("⏸ 已暂停所有trader的AI决策和新开仓（止损止盈照常生效），发送 /resume 恢复\n")
+	for _, id := range c.sortedIDs() {
+		t, _ := c.tm.GetTrader(id)
+		cancelled, err := t.Pause(trader.PauseSourceTelegram, reason, cancelOrders)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("[%s] ❌ 撤单失败: %v\n", id, err))
+		case cancelled > 0:
+			sb.WriteString(fmt.Sprintf("[%s] ✓ 已撤销%d个币种的挂单\n", id, cancelled))
+		}
+	}
+	return sb.String()
+}
+
+// Resume 恢复所有trader
+func (c *Commands) Resume() string {
+	for _, t := range c.tm.GetAllTraders() {
+		t.Resume()
+	}
+	return "▶️ 已恢复所有trader"
+}
+
+// Flatten 平掉所有trader在指定币种上的持仓
+func (c *Commands) Flatten(symbol string) string {
+	var sb strings.Builder
+	for _, id := range c.sortedIDs() {
+		t, _ := c.tm.GetTrader(id)
+		closed, err := t.Flatten(symbol)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("[%s] ❌ %v\n", id, err))
+		case closed > 0:
+			sb.WriteString(fmt.Sprintf("[%s] ✓ 已平掉 %s 的%d个持仓\n", id, symbol, closed))
+		}
+	}
+	if sb.Len() == 0 {
+		return fmt.Sprintf("没有 %s 的持仓", symbol)
+	}
+	return sb.String()
+}
+
+// recentTradesLimit /trades 每个trader列出的交易数
+const recentTradesLimit = 10
+
+// Trades 所有trader最近的已平仓交易（tag非空时只列出带该标签的交易）
+func (c *Commands) Trades(tag string) string {
+	journal := c.tm.GetJournal()
+	if journal == nil {
+		return "交易日志存储未启用，无法查询交易"
+	}
+
+	var sb strings.Builder
+	for _, id := range c.sortedIDs() {
+		trades, err := report.ListTrades(journal, id, report.Period{Name: "all"}, tag, recentTradesLimit)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("[%s] 查询交易失败: %v\n", id, err))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("[%s]\n%s", id, report.FormatTrades(trades)))
+	}
+	return c.orEmpty(sb.String())
+}
+
+// Annotate 修改交易的备注和标签（交易ID在所有trader之间唯一）
+func (c *Commands) Annotate(id int64, note *string, addTags, removeTags []string) string {
+	journal := c.tm.GetJournal()
+	if journal == nil {
+		return "交易日志存储未启用，无法添加备注"
+	}
+	p, err := journal.AnnotatePosition(id, note, addTags, removeTags)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	return fmt.Sprintf("✓ [%s] %s", p.TraderID, report.FormatTrade(*p))
+}
+
+// Recommendations 所有trader待确认的平仓建议和开仓建议
+func (c *Commands) Recommendations() string {
+	var sb strings.Builder
+	for _, id := range c.sortedIDs() {
+		t, _ := c.tm.GetTrader(id)
+		for _, rec := range t.CloseRecommendations() {
+			sb.WriteString(fmt.Sprintf("#%d [%s] %s %s: %s（%s）\n", rec.ID, id, rec.Symbol, strings.ToUpper(rec.Side),
+				rec.Reason, rec.CreatedAt.Local().Format("01-02 15:04")))
+		}
+		for _, p := range t.EntryProposals() {
+			sb.WriteString(fmt.Sprintf("#%d [%s] %s %s %.2f USDT %dx（%s，%s前有效）\n", p.ID, id, p.Decision.Symbol, p.Decision.Action,
+				p.Decision.PositionSizeUSD, p.Decision.Leverage, p.Source, p.ExpiresAt.Local().Format("15:04")))
+		}
+	}
+	if sb.Len() == 0 {
+		return "没有待确认的建议"
+	}
+	sb.WriteString("确认: /confirm ID，忽略: /dismiss ID")
+	return sb.String()
+}
+
+// ConfirmClose 确认平仓建议并执行平仓，或确认开仓建议并按原决策开仓（建议ID在所有trader之间唯一）
+func (c *Commands) ConfirmClose(id int64) string {
+	for _, t := range c.tm.GetAllTraders() {
+		rec, found, err := t.ConfirmClose(id)
+		if !found {
+			continue
+		}
+		if err != nil {
+			return fmt.Sprintf("❌ [%s] #%d %v", t.GetID(), id, err)
+		}
+		return fmt.Sprintf("✓ [%s] #%d 已平掉 %s %s", t.GetID(), id, rec.Symbol, strings.ToUpper(rec.Side))
+	}
+	for _, t := range c.tm.GetAllTraders() {
+		p, found, err := t.ConfirmEntry(id)
+		if !found {
+			continue
+		}
+		if err != nil {
+			return fmt.Sprintf("❌ [%s] #%d %v", t.GetID(), id, err)
+		}
+		return fmt.Sprintf("✓ [%s] #%d 已执行 %s %s", t.GetID(), id, p.Decision.Symbol, p.Decision.Action)
+	}
+	return fmt.Sprintf("没有待确认的建议 #%d（可能已处理、已超时或持仓已平掉）", id)
+}
+
+// DismissClose 忽略平仓建议（该持仓平掉前不再提示）或开仓建议（过期前同一决策不再提示）
+func (c *Commands) DismissClose(id int64) string {
+	for _, t := range c.tm.GetAllTraders() {
+		if rec, found := t.DismissClose(id); found {
+			return fmt.Sprintf("✓ [%s] #%d 已忽略，%s %s 保持持仓", t.GetID(), id, rec.Symbol, strings.ToUpper(rec.Side))
+		}
+		if p, found := t.DismissEntry(id); found {
+			return fmt.Sprintf("✓ [%s] #%d 已忽略，不执行 %s %s", t.GetID(), id, p.Decision.Symbol, p.Decision.Action)
+		}
+	}
+	return fmt.Sprintf("没有待确认的建议 #%d（可能已处理、已超时或持仓已平掉）", id)
+}
+
+// sortedIDs 按ID排序的trader列表（保证输出顺序稳定）
+func (c *Commands) sortedIDs() []string {
+	ids := c.tm.GetTraderIDs()
+	sort.Strings(ids)
+	return ids
+}
+
+// orEmpty 空输出时的提示
+func (c *Commands) orEmpty(s string) string {
+	if s == "" {
+		return "没有运行中的trader"
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"fmt"
+	"nofx/config"
+	"nofx/risk"
+	"nofx/trader"
+	"strings"
+	"time"
+)
+
+// bookAccount 组合敞口汇总中的一个交易所账户（多个trader共用同一账户时只统计一次）
+type bookAccount struct {
+	key    string // 账户唯一标识（交易所+API Key/钱包地址）
+	label  string // 显示名称（交易所:trader ID）
+	trader *trader.AutoTrader
+}
+
+// accountKey 按交易所和凭证识别账户；模拟交易每个trader是独立的模拟账户
+func accountKey(cfg config.TraderConfig, dryRun bool) string {
+	if dryRun {
+		return "dryrun:" + cfg.ID
+	}
+	switch cfg.Exchange {
+	case "binance":
+		return "binance:" + cfg.BinanceAPIKey
+	case "hyperliquid":
+		return fmt.Sprintf("hyperliquid:%t:%s", cfg.HyperliquidTestnet, strings.ToLower(cfg.HyperliquidWalletAddr))
+	case "aster":
+		return "aster:" + strings.ToLower(cfg.AsterUser)
+	case "gate":
+		return fmt.Sprintf("gate:%t:%s", cfg.GateTestnet, cfg.GateAPIKey)
+	}
+	return cfg.Exchange + ":" + cfg.ID
+}
+
+// registerBookAccount 记录trader所在的账户（需持有tm.mu；影子trader的模拟账户不计入）
+func (tm *TraderManager) registerBookAccount(cfg config.TraderConfig, global *config.Config, at *trader.AutoTrader) {
+	if cfg.ShadowOf != "" {
+		return
+	}
+	tm.bookMu.Lock()
+	defer tm.bookMu.Unlock()
+	tm.bookLimits = global.CombinedExposure
+	key := accountKey(cfg, global.DryRun)
+	for _, account := range tm.accounts {
+		if account.key == key {
+			return
+		}
+	}
+	tm.accounts = append(tm.accounts, bookAccount{key: key, label: cfg.Exchange + ":" + cfg.ID, trader: at})
+}
+
+// BookLimits 当前的组合敞口限制
+func (tm *TraderManager) BookLimits() risk.BookLimits {
+	tm.bookMu.RLock()
+	defer tm.bookMu.RUnlock()
+	return tm.bookLimits
+}
+
+// NetExposure 汇总所有账户的持仓，按标的资产计算跨交易所的净敞口
+// 获取失败的账户不计入，错误按账户名称返回
+func (tm *TraderManager) NetExposure() (*risk.Book, map[string]string) {
+	tm.bookMu.RLock()
+	accounts := append([]bookAccount(nil), tm.accounts...)
+	tm.bookMu.RUnlock()
+
+	var positions []risk.BookPosition
+	var equity float64
+	errs := make(map[string]string)
+	for _, account := range accounts {
+		accountPositions, accountEquity, err := account.trader.BookSnapshot(account.label)
+		if err != nil {
+			errs[account.label] = err.Error()
+			continue
+		}
+		positions = append(positions, accountPositions...)
+		equity += accountEquity
+	}
+	return risk.NewBook(positions, equity), errs
+}
+
+// checkBook 开仓前检查组合敞口限制（未启用时不检查；有账户获取失败时拒绝，避免漏算敞口）
+func (tm *TraderManager) checkBook(symbol, side string, addValue float64) error {
+	limits := tm.BookLimits()
+	if !limits.Enabled() {
+		return nil
+	}
+	book, errs := tm.NetExposure()
+	for label, err := range errs {
+		return fmt.Errorf("账户%s持仓获取失败，无法检查组合敞口: %s", label, err)
+	}
+	return limits.CheckAdd(book, symbol, side, addValue)
+}
+
+// Portfolio 汇总所有账户各结算币种的账本，按指数价折算为美元（获取失败的账户不计入，错误按账户名称返回）
+func (tm *TraderManager) Portfolio() *risk.Portfolio {
+	tm.bookMu.RLock()
+	accounts := append([]bookAccount(nil), tm.accounts...)
+	tm.bookMu.RUnlock()
+
+	var books []risk.SettleBook
+	errs := make(map[string]string)
+	for _, account := range accounts {
+		accountBooks, err := account.trader.PortfolioBooks(account.label)
+		if err != nil {
+			errs[account.label] = err.Error()
+			continue
+		}
+		books = append(books, accountBooks...)
+	}
+	portfolio := risk.NewPortfolio(books, time.Now())
+	if len(errs) > 0 {
+		portfolio.Errors = errs
+	}
+	return portfolio
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"fmt"
+	"nofx/config"
+	"nofx/risk"
+	"nofx/scheduler"
+	"nofx/strategy"
+	"nofx/trader"
+	"nofx/webhook"
+	"reflect"
+	"time"
+)
+
+// ApplyConfig 热加载配置，返回已应用的变更和需要重启才能生效的变更
+// 风控限制、杠杆、DCA/顺势加仓/资金费率套利参数、禁止开仓规则、经济日历、外部信号立即生效，持仓跟踪等内存状态保留；
+// 新增/删除trader，交易所、密钥、AI模型、扫描间隔、调度周期等变更需要重启
+func (tm *TraderManager) ApplyConfig(cfg *config.Config) (applied, restart []string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, traderCfg := range cfg.Traders {
+		if !traderCfg.Enabled {
+			continue
+		}
+		seen[traderCfg.ID] = true
+
+		at, exists := tm.traders[traderCfg.ID]
+		if !exists {
+			restart = append(restart, fmt.Sprintf("[%s] 新增trader", traderCfg.ID))
+			continue
+		}
+		if !reflect.DeepEqual(staticFields(tm.configs[traderCfg.ID]), staticFields(traderCfg)) {
+			restart = append(restart, fmt.Sprintf("[%s] 交易所/密钥/AI模型/扫描间隔/调度周期等配置已变更", traderCfg.ID))
+		}
+
+		changes := at.ApplyRuntimeConfig(trader.RuntimeConfig{
+			BTCETHLeverage:  cfg.Leverage.BTCETHLeverage,
+			AltcoinLeverage: cfg.Leverage.AltcoinLeverage,
+			AutoLeverage:    cfg.Leverage.Auto,
+			Liquidation:     cfg.Leverage.Liquidation,
+			AccountLimits:   cfg.Leverage.Account,
+			MaxDailyLoss:    cfg.MaxDailyLoss,
+			MaxDrawdown:     cfg.MaxDrawdown,
+			StopTradingTime: time.Duration(cfg.StopTradingMinutes) * time.Minute,
+			DCA:             traderCfg.DCA,
+			Pyramid:         traderCfg.Pyramid,
+			FundingHarvest:  traderCfg.FundingHarvest,
+			Basis:           traderCfg.Basis,
+			Hedge:           traderCfg.Hedge,
+			EntryRules:      traderCfg.Schedule.EntryRules,
+			ExitRules:       traderCfg.Schedule.ExitRules,
+			Cadence:         traderCfg.Schedule.Cadence,
+			Webhook:         traderCfg.Webhook,
+			Calendar:        cfg.EconomicCalendar,
+			SoftClose:       traderCfg.SoftClose,
+			EntryConfirm:    traderCfg.EntryConfirm,
+			Flatten:         traderCfg.Flatten,
+			DowntimeQueue:   traderCfg.DowntimeQueue,
+			EntryLimit:      traderCfg.EntryLimit,
+			TradeLossCap:    traderCfg.TradeLossCap,
+			Staleness:       traderCfg.Staleness,
+			BalanceAnomaly:  traderCfg.BalanceAnomaly,
+			OrderRateLimit:  traderCfg.OrderRateLimit,
+			RiskProfiles:    traderCfg.RiskProfiles,
+			ADLGuard:        traderCfg.ADLGuard,
+		})
+		for _, change := range changes {
+			applied = append(applied, fmt.Sprintf("[%s] %s", traderCfg.ID, change))
+		}
+	}
+	tm.bookMu.Lock()
+	if !reflect.DeepEqual(tm.bookLimits, cfg.CombinedExposure) {
+		applied = append(applied, fmt.Sprintf("combined_exposure: %+v → %+v", tm.bookLimits, cfg.CombinedExposure))
+		tm.bookLimits = cfg.CombinedExposure
+	}
+	tm.bookMu.Unlock()
+	for id := range tm.traders {
+		if !seen[id] {
+			restart = append(restart, fmt.Sprintf("[%s] trader已删除或禁用", id))
+		}
+	}
+	return applied, restart
+}
+
+// staticFields 去掉可热加载的部分，剩下的字段变更需要重启trader
+func staticFields(cfg config.TraderConfig) config.TraderConfig {
+	cfg.DCA = strategy.DCAConfig{}
+	cfg.Pyramid = strategy.PyramidConfig{}
+	cfg.FundingHarvest = strategy.FundingHarvestConfig{}
+	cfg.Basis = strategy.BasisConfig{}
+	cfg.Hedge = strategy.HedgeConfig{}
+	cfg.Schedule.EntryRules = scheduler.EntryRules{}
+	cfg.Schedule.ExitRules = scheduler.ExitRules{}
+	cfg.Schedule.Cadence = scheduler.Cadence{}
+	cfg.Webhook = webhook.Config{}
+	cfg.SoftClose = risk.SoftClose{}
+	cfg.EntryConfirm = risk.EntryConfirm{}
+	cfg.Flatten = risk.Flatten{}
+	cfg.DowntimeQueue = risk.DowntimeQueue{}
+	cfg.EntryLimit = risk.EntryLimit{}
+	cfg.TradeLossCap = risk.TradeLossCap{}
+	cfg.Staleness = risk.Staleness{}
+	cfg.BalanceAnomaly = risk.BalanceAnomaly{}
+	cfg.OrderRateLimit = risk.OrderRateLimit{}
+	cfg.RiskProfiles = risk.RiskProfiles{}
+	cfg.ADLGuard = risk.ADLGuard{}
+	return cfg
+}
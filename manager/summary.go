@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"fmt"
+	"nofx/apiusage"
+	"nofx/notify"
+	"nofx/report"
+	"nofx/scheduler"
+	"time"
+)
+
+// ScheduleSummaries 按配置创建日报/周报定时任务（返回的调度器需调用Start启动）
+// 未启用交易日志或通知渠道时返回错误
+func (tm *TraderManager) ScheduleSummaries(config report.SummaryConfig) (*scheduler.Scheduler, error) {
+	tm.mu.RLock()
+	journal, notifier := tm.journal, tm.notifier
+	tm.mu.RUnlock()
+	if journal == nil {
+		return nil, fmt.Errorf("汇总报告需要启用交易日志存储")
+	}
+	if notifier == nil {
+		return nil, fmt.Errorf("汇总报告需要至少配置一个通知渠道")
+	}
+
+	sched := scheduler.New()
+	jobs := []struct {
+		name, spec string
+		span       time.Duration
+	}{
+		{"daily", config.Daily, 24 * time.Hour},
+		{"weekly", config.Weekly, 7 * 24 * time.Hour},
+	}
+	for _, job := range jobs {
+		if job.spec == "-" {
+			continue
+		}
+		name, span := job.name, job.span
+		if err := sched.AddTimedJob("汇总报告 "+name, job.spec, func() {
+			tm.SendSummaries(report.Period{Name: name, Since: time.Now().UTC().Add(-span)}, config)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return sched, nil
+}
+
+// SendSummaries 生成所有trader指定周期的汇总报告并推送
+func (tm *TraderManager) SendSummaries(period report.Period, config report.SummaryConfig) {
+	tm.mu.RLock()
+	journal, notifier := tm.journal, tm.notifier
+	tm.mu.RUnlock()
+	if notifier == nil {
+		return
+	}
+
+	title := "每日汇总"
+	if period.Name == "weekly" {
+		title = "每周汇总"
+	}
+
+	for _, id := range tm.GetTraderIDs() {
+		summary, err := report.BuildSummary(journal, id, period, config.AIPrices)
+		if err != nil {
+			logger.Warn("生成汇总报告失败", "trader", id, "period", period.Name, "err", err)
+			continue
+		}
+
+		message := summary.String()
+		if t, err := tm.GetTrader(id); err == nil {
+			if usage, ok := t.APIUsage(period.Since); ok && len(usage) > 0 {
+				message += "\n交易所API用量（私有接口）:\n" + apiusage.Format(usage)
+			}
+		}
+		if usage := apiusage.Public.Usage(period.Since); len(usage) > 0 {
+			message += "\n交易所API用量（公共接口，所有trader共用）:\n" + apiusage.Format(usage)
+		}
+
+		event := notify.Event{
+			Kind:     notify.KindSummary,
+			TraderID: id,
+			Title:    title,
+			Message:  message,
+			Time:     time.Now(),
+		}
+		if config.AttachCSV && summary.Trades > 0 {
+			data, _, err := report.TradesCSV(journal, id, period)
+			if err != nil {
+				logger.Warn("导出汇总报告CSV失败", "trader", id, "err", err)
+			} else {
+				event.Attachment = &notify.Attachment{
+					Name: fmt.Sprintf("%s_%s_%s.csv", id, period.Name, time.Now().UTC().Format("20060102")),
+					Data: data,
+				}
+			}
+		}
+		notifier.Notify(event)
+		logger.Info("已推送汇总报告", "trader", id, "period", period.Name, "trades", summary.Trades, "net_pnl", summary.NetPnL)
+	}
+
+	// 多个账本（多账户或多结算币种）时附带按美元汇总的组合视图
+	if portfolio := tm.Portfolio(); len(portfolio.Books) > 1 {
+		notifier.Notify(notify.Event{
+			Kind:     notify.KindSummary,
+			TraderID: "portfolio",
+			Title:    title + "（组合）",
+			Message:  portfolio.String(),
+			Time:     time.Now(),
+		})
+	}
+}
@@ -2,28 +2,74 @@ package manager
 
 import (
 	"fmt"
-	"log"
 	"nofx/config"
+	"nofx/events"
+	"nofx/logging"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/store"
 	"nofx/trader"
+	"sort"
 	"sync"
 	"time"
 )
 
+// logger 管理器日志
+var logger = logging.For("manager")
+
 // TraderManager 管理多个trader实例
 type TraderManager struct {
-	traders map[string]*trader.AutoTrader // key: trader ID
-	mu      sync.RWMutex
+	traders  map[string]*trader.AutoTrader  // key: trader ID
+	configs  map[string]config.TraderConfig // 创建trader时的配置（热加载时比较变更）
+	journal  *store.Store                   // 交易日志存储（所有trader共享，按trader_id区分）
+	notifier notify.Notifier                // 通知渠道（所有trader共享）
+	events   *events.Bus                    // 事件总线（所有trader共享）
+	mu       sync.RWMutex
+	running  sync.WaitGroup // 运行中的trader主循环（StopAll等待其退出）
+
+	// 跨交易所组合敞口（单独加锁：开仓检查在trader周期内调用，不能等待tm.mu）
+	bookMu     sync.RWMutex
+	bookLimits risk.BookLimits
+	accounts   []bookAccount
 }
 
 // NewTraderManager 创建trader管理器
 func NewTraderManager() *TraderManager {
 	return &TraderManager{
 		traders: make(map[string]*trader.AutoTrader),
+		configs: make(map[string]config.TraderConfig),
+		events:  events.NewBus(),
 	}
 }
 
-// AddTrader 添加一个trader
-func (tm *TraderManager) AddTrader(cfg config.TraderConfig, coinPoolURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, leverage config.LeverageConfig) error {
+// SetJournal 设置交易日志存储（需在AddTrader之前调用）
+func (tm *TraderManager) SetJournal(journal *store.Store) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.journal = journal
+}
+
+// SetNotifier 设置通知渠道（需在AddTrader之前调用）
+func (tm *TraderManager) SetNotifier(notifier notify.Notifier) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.notifier = notifier
+}
+
+// Events 所有trader共享的事件总线（订单、持仓、风控、决策和通知事件）
+func (tm *TraderManager) Events() *events.Bus {
+	return tm.events
+}
+
+// GetJournal 获取交易日志存储（未启用时为nil）
+func (tm *TraderManager) GetJournal() *store.Store {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.journal
+}
+
+// AddTrader 添加一个trader（global提供币种池、风控限制、杠杆、缓存等全局配置）
+func (tm *TraderManager) AddTrader(cfg config.TraderConfig, global *config.Config) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -33,35 +79,89 @@ func (tm *TraderManager) AddTrader(cfg config.TraderConfig, coinPoolURL string,
 
 	// 构建AutoTraderConfig
 	traderConfig := trader.AutoTraderConfig{
-		ID:                    cfg.ID,
-		Name:                  cfg.Name,
-		AIModel:               cfg.AIModel,
-		Exchange:              cfg.Exchange,
-		BinanceAPIKey:         cfg.BinanceAPIKey,
-		BinanceSecretKey:      cfg.BinanceSecretKey,
-		HyperliquidPrivateKey: cfg.HyperliquidPrivateKey,
-		HyperliquidWalletAddr: cfg.HyperliquidWalletAddr,
-		HyperliquidTestnet:    cfg.HyperliquidTestnet,
-		AsterUser:             cfg.AsterUser,
-		AsterSigner:           cfg.AsterSigner,
-		AsterPrivateKey:       cfg.AsterPrivateKey,
-		GateAPIKey:            cfg.GateAPIKey,
-		GateSecretKey:         cfg.GateSecretKey,
-		GateTestnet:           cfg.GateTestnet,
-		CoinPoolAPIURL:        coinPoolURL,
-		UseQwen:               cfg.AIModel == "qwen",
-		DeepSeekKey:           cfg.DeepSeekKey,
-		QwenKey:               cfg.QwenKey,
-		CustomAPIURL:          cfg.CustomAPIURL,
-		CustomAPIKey:          cfg.CustomAPIKey,
-		CustomModelName:       cfg.CustomModelName,
-		ScanInterval:          cfg.GetScanInterval(),
-		InitialBalance:        cfg.InitialBalance,
-		BTCETHLeverage:        leverage.BTCETHLeverage,  // 使用配置的杠杆倍数
-		AltcoinLeverage:       leverage.AltcoinLeverage, // 使用配置的杠杆倍数
-		MaxDailyLoss:          maxDailyLoss,
-		MaxDrawdown:           maxDrawdown,
-		StopTradingTime:       time.Duration(stopTradingMinutes) * time.Minute,
+		ID:                     cfg.ID,
+		Name:                   cfg.Name,
+		AIModel:                cfg.AIModel,
+		Exchange:               cfg.Exchange,
+		BinanceAPIKey:          cfg.BinanceAPIKey,
+		BinanceSecretKey:       cfg.BinanceSecretKey,
+		HyperliquidPrivateKey:  cfg.HyperliquidPrivateKey,
+		HyperliquidWalletAddr:  cfg.HyperliquidWalletAddr,
+		HyperliquidTestnet:     cfg.HyperliquidTestnet,
+		AsterUser:              cfg.AsterUser,
+		AsterSigner:            cfg.AsterSigner,
+		AsterPrivateKey:        cfg.AsterPrivateKey,
+		GateAPIKey:             cfg.GateAPIKey,
+		GateSecretKey:          cfg.GateSecretKey,
+		GateTestnet:            cfg.GateTestnet,
+		CoinPoolAPIURL:         global.CoinPoolAPIURL,
+		UseQwen:                cfg.AIModel == "qwen",
+		DeepSeekKey:            cfg.DeepSeekKey,
+		QwenKey:                cfg.QwenKey,
+		CustomAPIURL:           cfg.CustomAPIURL,
+		CustomAPIKey:           cfg.CustomAPIKey,
+		CustomModelName:        cfg.CustomModelName,
+		LLM:                    cfg.LLM,
+		ScanInterval:           cfg.GetScanInterval(),
+		InitialBalance:         cfg.InitialBalance,
+		BTCETHLeverage:         global.Leverage.BTCETHLeverage,  // 使用配置的杠杆倍数
+		AltcoinLeverage:        global.Leverage.AltcoinLeverage, // 使用配置的杠杆倍数
+		AutoLeverage:           global.Leverage.Auto,
+		LiquidationGuard:       global.Leverage.Liquidation,
+		AccountLimits:          global.Leverage.Account,
+		MaxDailyLoss:           global.MaxDailyLoss,
+		MaxDrawdown:            global.MaxDrawdown,
+		StopTradingTime:        time.Duration(global.StopTradingMinutes) * time.Minute,
+		AccountCacheTTL:        global.AccountCacheTTL(),
+		DryRun:                 global.DryRun || cfg.ShadowOf != "",
+		ReadOnly:               cfg.ReadOnly,
+		ShadowOf:               cfg.ShadowOf,
+		Prompt:                 cfg.Prompt,
+		StopLimitOffsetPct:     cfg.StopLimitOffsetPct,
+		TriggerExpiration:      time.Duration(cfg.TriggerExpiration) * time.Second,
+		TriggerPriceType:       cfg.TriggerPriceType,
+		TakeProfitLadder:       cfg.TakeProfitLadder,
+		ResizeProtectiveOrders: cfg.ResizeProtectiveOrders,
+		LedgerMonitor:          cfg.LedgerMonitor,
+		HistoryImportDays:      cfg.HistoryImportDays,
+		PriceBand:              cfg.PriceBand,
+		EntryRouting:           cfg.EntryRouting,
+		SignalThrottle:         cfg.SignalThrottle,
+		RegimeFilter:           cfg.RegimeFilter,
+		Calendar:               global.EconomicCalendar,
+		DCA:                    cfg.DCA,
+		Pyramid:                cfg.Pyramid,
+		FundingHarvest:         cfg.FundingHarvest,
+		Basis:                  cfg.Basis,
+		Hedge:                  cfg.Hedge,
+		Schedule:               cfg.Schedule,
+		Webhook:                cfg.Webhook,
+		Bars:                   global.Bars,
+		Allocation:             cfg.Allocation,
+		SoftClose:              cfg.SoftClose,
+		EntryConfirm:           cfg.EntryConfirm,
+		Flatten:                cfg.Flatten,
+		DowntimeQueue:          cfg.DowntimeQueue,
+		EntryLimit:             cfg.EntryLimit,
+		Soak:                   cfg.Soak,
+		TradeLossCap:           cfg.TradeLossCap,
+		Staleness:              cfg.Staleness,
+		BalanceAnomaly:         cfg.BalanceAnomaly,
+		Degradation:            cfg.Degradation,
+		PortfolioSettles:       cfg.PortfolioSettles,
+		OrderRateLimit:         cfg.OrderRateLimit,
+		RiskProfiles:           cfg.RiskProfiles,
+		ADLGuard:               cfg.ADLGuard,
+		Margin:                 cfg.Margin,
+		QuantityRounding:       cfg.QuantityRounding,
+		Journal:                tm.journal,
+		Notifier:               tm.notifier,
+		Events:                 tm.events,
+		BookCheck:              tm.checkBook,
+	}
+	if cfg.ShadowOf != "" {
+		// 影子trader的模拟持仓不计入组合敞口，开仓也不受实盘组合敞口限制（独立评估候选策略）
+		traderConfig.BookCheck = nil
 	}
 
 	// 创建trader实例
@@ -71,7 +171,9 @@ func (tm *TraderManager) AddTrader(cfg config.TraderConfig, coinPoolURL string,
 	}
 
 	tm.traders[cfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s) 已添加", cfg.Name, cfg.AIModel)
+	tm.configs[cfg.ID] = cfg
+	tm.registerBookAccount(cfg, global, at)
+	logger.Info("Trader已添加", "trader", cfg.ID, "name", cfg.Name, "ai_model", cfg.AIModel)
 	return nil
 }
 
@@ -87,6 +189,30 @@ func (tm *TraderManager) GetTrader(id string) (*trader.AutoTrader, error) {
 	return t, nil
 }
 
+// SelectTraders 控制类操作的目标trader（id非空时只包含该trader，否则为全部，按ID排序）
+func (tm *TraderManager) SelectTraders(id string) ([]*trader.AutoTrader, error) {
+	if id != "" {
+		t, err := tm.GetTrader(id)
+		if err != nil {
+			return nil, err
+		}
+		return []*trader.AutoTrader{t}, nil
+	}
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	ids := make([]string, 0, len(tm.traders))
+	for traderID := range tm.traders {
+		ids = append(ids, traderID)
+	}
+	sort.Strings(ids)
+	traders := make([]*trader.AutoTrader, 0, len(ids))
+	for _, traderID := range ids {
+		traders = append(traders, tm.traders[traderID])
+	}
+	return traders, nil
+}
+
 // GetAllTraders 获取所有trader
 func (tm *TraderManager) GetAllTraders() map[string]*trader.AutoTrader {
 	tm.mu.RLock()
@@ -116,26 +242,50 @@ func (tm *TraderManager) StartAll() {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	log.Println("🚀 启动所有Trader...")
+	logger.Info("启动所有Trader", "count", len(tm.traders))
 	for id, t := range tm.traders {
+		tm.running.Add(1)
 		go func(traderID string, at *trader.AutoTrader) {
-			log.Printf("▶️  启动 %s...", at.GetName())
+			defer tm.running.Done()
+			logger.Info("启动Trader", "trader", traderID, "name", at.GetName())
 			if err := at.Run(); err != nil {
-				log.Printf("❌ %s 运行错误: %v", at.GetName(), err)
+				logger.Error("Trader运行错误", "trader", traderID, "err", err)
 			}
 		}(id, t)
 	}
 }
 
-// StopAll 停止所有trader
-func (tm *TraderManager) StopAll() {
+// StopAll 停止所有trader，等待进行中的周期结束（最多timeout），超时返回false
+func (tm *TraderManager) StopAll(timeout time.Duration) bool {
 	tm.mu.RLock()
-	defer tm.mu.RUnlock()
-
-	log.Println("⏹  停止所有Trader...")
+	logger.Info("停止所有Trader")
 	for _, t := range tm.traders {
 		t.Stop()
 	}
+	tm.mu.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		tm.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		logger.Warn("等待Trader退出超时", "timeout", timeout)
+		return false
+	}
+}
+
+// SelfCheck 对所有trader执行启动自检（按ID排序）
+func (tm *TraderManager) SelfCheck() []trader.CheckResult {
+	traders, _ := tm.SelectTraders("")
+	var results []trader.CheckResult
+	for _, t := range traders {
+		results = append(results, t.SelfCheck()...)
+	}
+	return results
 }
 
 // GetComparisonData 获取对比数据
@@ -0,0 +1,317 @@
+package market
+
+import (
+	"fmt"
+	"nofx/logging"
+	"sync"
+	"time"
+)
+
+// barLog 多周期K线日志
+var barLog = logging.For("bars")
+
+// BarTimeframes 多周期K线服务维护的周期（1分钟K线来自推送，其余周期由1分钟K线聚合）
+var BarTimeframes = []string{"1m", "15m", "1h", "4h"}
+
+const (
+	DefaultBarSize = 500  // 每个周期默认保留的K线数量
+	minBarSize     = 240  // 至少覆盖一根4小时K线的1分钟K线，回填后才能接上正在形成的高周期K线
+	maxBarSize     = 2000 // Gate.io单次K线查询上限
+)
+
+// BarConfig 多周期K线服务配置
+type BarConfig struct {
+	Symbols []string `json:"symbols"` // 维护多周期K线的币种（为空表示不启用）
+	Size    int      `json:"size"`    // 每个周期保留的K线数量（默认500）
+}
+
+// Validate 检查配置
+func (c BarConfig) Validate() error {
+	if c.Size != 0 && (c.Size < minBarSize || c.Size > maxBarSize) {
+		return fmt.Errorf("bars.size必须在%d-%d之间: %d", minBarSize, maxBarSize, c.Size)
+	}
+	return nil
+}
+
+// BarService 多周期K线服务：按币种维护1m/15m/1h/4h的滚动K线窗口，
+// 1分钟K线由交易器的K线推送喂入（Observe），高周期K线由1分钟K线聚合；启动和推送出现缺口时通过REST回填
+// 策略和行情数据直接读取，不再每个周期重新获取历史K线
+type BarService struct {
+	mu     sync.RWMutex
+	size   int
+	series map[string]*barSeries // symbol → K线
+
+	fetch func(symbol, interval string, limit int) ([]Kline, error)
+	now   func() time.Time
+}
+
+// barSeries 一个币种的多周期K线
+type barSeries struct {
+	closed      map[string][]Kline // 周期 → 已收盘K线（按时间升序）
+	forming     map[string]*Kline  // 高周期 → 正在形成的K线（由已收盘的1分钟K线聚合）
+	ready       bool               // 已完成回填
+	backfilling bool
+}
+
+// NewBarService 创建多周期K线服务（size为每个周期保留的K线数量，0表示默认值）
+func NewBarService(size int, fetch func(symbol, interval string, limit int) ([]Kline, error)) *BarService {
+	if size <= 0 {
+		size = DefaultBarSize
+	}
+	return &BarService{size: size, series: make(map[string]*barSeries), fetch: fetch, now: time.Now}
+}
+
+// defaultBars 进程内共享的多周期K线服务（多个trader跟踪同一币种时只订阅一次）
+var defaultBars = NewBarService(DefaultBarSize, getKlines)
+
+// DefaultBars 进程内共享的多周期K线服务
+func DefaultBars() *BarService {
+	return defaultBars
+}
+
+// SetSize 设置每个周期保留的K线数量（0表示默认值，之后的回填和推送生效）
+func (s *BarService) SetSize(size int) {
+	if size <= 0 {
+		size = DefaultBarSize
+	}
+	s.mu.Lock()
+	s.size = size
+	s.mu.Unlock()
+}
+
+// Track 开始跟踪币种并在后台回填历史K线，返回false表示该币种已在跟踪（由其他订阅者喂入推送）
+func (s *BarService) Track(symbol string) bool {
+	symbol = Normalize(symbol)
+	s.mu.Lock()
+	if _, ok := s.series[symbol]; ok {
+		s.mu.Unlock()
+		return false
+	}
+	s.series[symbol] = &barSeries{closed: make(map[string][]Kline), forming: make(map[string]*Kline)}
+	s.mu.Unlock()
+
+	go s.backfill(symbol)
+	return true
+}
+
+// Untrack 停止跟踪币种（推送停止后调用，之后读取回退到REST查询）
+func (s *BarService) Untrack(symbol string) {
+	s.mu.Lock()
+	delete(s.series, Normalize(symbol))
+	s.mu.Unlock()
+}
+
+// Tracked 币种是否已完成回填、可以从服务读取
+func (s *BarService) Tracked(symbol string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series, ok := s.series[Normalize(symbol)]
+	return ok && series.ready
+}
+
+// Observe 喂入一根已收盘的1分钟K线：更新1分钟窗口并聚合高周期K线
+// 重复或过期的K线忽略；与上一根之间有缺口（断线）时重新回填
+func (s *BarService) Observe(symbol string, bar Kline) {
+	symbol = Normalize(symbol)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series, ok := s.series[symbol]
+	if !ok {
+		return
+	}
+	if !series.ready {
+		// 回填中：回填结果会包含这根K线，未包含时下一根K线会发现缺口；上次回填失败时重试
+		s.startBackfill(symbol, series)
+		return
+	}
+
+	minutes := series.closed["1m"]
+	if n := len(minutes); n > 0 {
+		last := minutes[n-1]
+		if bar.OpenTime <= last.OpenTime {
+			return
+		}
+		if bar.OpenTime != last.OpenTime+time.Minute.Milliseconds() {
+			barLog.Warn("1分钟K线出现缺口，重新回填", "symbol", symbol,
+				"last", time.UnixMilli(last.OpenTime), "received", time.UnixMilli(bar.OpenTime))
+			s.startBackfill(symbol, series)
+			return
+		}
+	}
+	bar.CloseTime = bar.OpenTime + time.Minute.Milliseconds() - 1
+	series.closed["1m"] = s.trim(append(minutes, bar))
+
+	for _, interval := range BarTimeframes[1:] {
+		duration, _ := intervalDuration(interval)
+		start := bar.OpenTime - bar.OpenTime%duration
+		forming := series.forming[interval]
+		if forming == nil || forming.OpenTime != start {
+			forming = &Kline{OpenTime: start, Open: bar.Open, High: bar.High, Low: bar.Low, CloseTime: start + duration - 1}
+			series.forming[interval] = forming
+		}
+		mergeKline(forming, bar)
+		if bar.OpenTime+time.Minute.Milliseconds() == start+duration {
+			series.closed[interval] = s.trim(append(series.closed[interval], *forming))
+			delete(series.forming, interval)
+		}
+	}
+}
+
+// Closed 最近n根已收盘K线（n<=0表示全部；币种未跟踪或未完成回填时返回nil）
+func (s *BarService) Closed(symbol, interval string, n int) []Kline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series, ok := s.series[Normalize(symbol)]
+	if !ok || !series.ready {
+		return nil
+	}
+	return lastKlines(series.closed[interval], n)
+}
+
+// Recent 最近n根K线，高周期最后一根为正在形成的K线（与REST查询的返回一致；聚合到最近收盘的1分钟K线）
+func (s *BarService) Recent(symbol, interval string, n int) []Kline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series, ok := s.series[Normalize(symbol)]
+	if !ok || !series.ready {
+		return nil
+	}
+	bars := series.closed[interval]
+	if forming := series.forming[interval]; forming != nil {
+		bars = append(bars[:len(bars):len(bars)], *forming)
+	}
+	return lastKlines(bars, n)
+}
+
+// startBackfill 在后台重新回填（调用方持有锁）
+func (s *BarService) startBackfill(symbol string, series *barSeries) {
+	series.ready = false
+	if series.backfilling {
+		return
+	}
+	go s.backfill(symbol)
+}
+
+// backfill 通过REST回填各周期的已收盘K线，并用最近的1分钟K线接上正在形成的高周期K线
+func (s *BarService) backfill(symbol string) {
+	s.mu.Lock()
+	series, ok := s.series[symbol]
+	if !ok || series.backfilling {
+		s.mu.Unlock()
+		return
+	}
+	series.backfilling = true
+	size := s.size
+	s.mu.Unlock()
+
+	closed := make(map[string][]Kline, len(BarTimeframes))
+	var err error
+	for _, interval := range BarTimeframes {
+		var bars []Kline
+		if bars, err = s.fetch(symbol, interval, size+1); err != nil {
+			err = fmt.Errorf("回填%s K线失败: %w", interval, err)
+			break
+		}
+		// 去掉未收盘的最后一根
+		now := s.now().UnixMilli()
+		for len(bars) > 0 && bars[len(bars)-1].CloseTime >= now {
+			bars = bars[:len(bars)-1]
+		}
+		closed[interval] = lastKlines(bars, size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.series[symbol] != series {
+		return // 回填期间已停止跟踪
+	}
+	series.backfilling = false
+	if err != nil {
+		barLog.Warn("多周期K线回填失败，下一根1分钟K线到达时重试", "symbol", symbol, "err", err)
+		return
+	}
+	series.closed = closed
+	series.forming = formingFromMinutes(closed)
+	series.ready = true
+	barLog.Info("多周期K线已回填", "symbol", symbol, "bars", size)
+}
+
+// formingFromMinutes 用最近的1分钟K线聚合各高周期正在形成的K线（最近的1分钟K线恰好收在周期边界时没有正在形成的K线）
+func formingFromMinutes(closed map[string][]Kline) map[string]*Kline {
+	forming := make(map[string]*Kline)
+	minutes := closed["1m"]
+	if len(minutes) == 0 {
+		return forming
+	}
+	end := minutes[len(minutes)-1].OpenTime + time.Minute.Milliseconds()
+	for _, interval := range BarTimeframes[1:] {
+		duration, _ := intervalDuration(interval)
+		start := end - end%duration
+		if start == end {
+			continue
+		}
+		for _, bar := range minutes {
+			if bar.OpenTime < start {
+				continue
+			}
+			if forming[interval] == nil {
+				forming[interval] = &Kline{OpenTime: start, Open: bar.Open, High: bar.High, Low: bar.Low, CloseTime: start + duration - 1}
+			}
+			mergeKline(forming[interval], bar)
+		}
+	}
+	return forming
+}
+
+// trim 只保留最近size根K线（调用方持有锁）
+func (s *BarService) trim(bars []Kline) []Kline {
+	if len(bars) > s.size {
+		return append([]Kline(nil), bars[len(bars)-s.size:]...)
+	}
+	return bars
+}
+
+// mergeKline 把一根1分钟K线合并到高周期K线
+func mergeKline(dst *Kline, bar Kline) {
+	if bar.High > dst.High {
+		dst.High = bar.High
+	}
+	if bar.Low < dst.Low {
+		dst.Low = bar.Low
+	}
+	dst.Close = bar.Close
+	dst.Volume += bar.Volume
+}
+
+// lastKlines 最近n根K线的副本（n<=0表示全部）
+func lastKlines(bars []Kline, n int) []Kline {
+	if n > 0 && len(bars) > n {
+		bars = bars[len(bars)-n:]
+	}
+	return append([]Kline(nil), bars...)
+}
+
+// intervalDuration K线周期的毫秒数
+func intervalDuration(interval string) (int64, error) {
+	switch interval {
+	case "1m":
+		return time.Minute.Milliseconds(), nil
+	case "15m":
+		return (15 * time.Minute).Milliseconds(), nil
+	case "1h":
+		return time.Hour.Milliseconds(), nil
+	case "4h":
+		return (4 * time.Hour).Milliseconds(), nil
+	}
+	return 0, fmt.Errorf("多周期K线服务不支持周期: %s", interval)
+}
+
+// Klines 最近limit根K线（最后一根为正在形成的K线）：币种由多周期K线服务跟踪且数量足够时直接读取，
+// 否则通过REST查询（已保存的K线历史足够时只查询最近几根）
+func Klines(symbol, interval string, limit int) ([]Kline, error) {
+	symbol = Normalize(symbol)
+	if bars := defaultBars.Recent(symbol, interval, limit); len(bars) >= limit {
+		return bars, nil
+	}
+	return defaultHistory.Klines(symbol, interval, limit)
+}
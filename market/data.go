@@ -6,9 +6,13 @@ import (
 	"io/ioutil"
 	"math"
 	"net/http"
+	"nofx/apiusage"
+	"nofx/news"
+	"nofx/symbols"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // 全局变量：是否使用测试网
@@ -29,6 +33,9 @@ func SetTestnet(testnet bool) {
 	}
 }
 
+// httpClient 行情请求共用的HTTP客户端（统计公共接口的请求用量）
+var httpClient = &http.Client{Transport: apiusage.NewTransport(nil, nil)}
+
 // getBaseURL 获取API基础URL
 func getBaseURL() string {
 	testnetMutex.RLock()
@@ -50,8 +57,15 @@ type Data struct {
 	CurrentRSI7       float64
 	OpenInterest      *OIData
 	FundingRate       float64
+	FundingStats      *FundingStats // 当前资金费率相对历史分布的位置（未启用资金费率历史或历史不足时为nil）
 	IntradaySeries    *IntradayData
 	LongerTermContext *LongerTermData
+	Microstructure    *MicrostructureData // 盘口和成交特征（获取失败时为nil）
+	Regime            *RegimeData         // 行情状态（4小时K线，不足以计算时为nil）
+	Positioning       *PositioningData    // 多空比和主动买卖量（获取失败时为nil）
+	News              *news.Summary       // 近期新闻和情绪（未启用新闻模块或没有相关新闻时为nil）
+	KlineTime         time.Time           // 最新一根3分钟K线的开盘时间（判断K线数据是否过期）
+	IndicatorsWarm    bool                // K线历史足以完整预热所有指标（新上线的合约历史不足时为false）
 }
 
 // OIData Open Interest数据
@@ -97,14 +111,14 @@ func Get(symbol string) (*Data, error) {
 	// 标准化symbol
 	symbol = Normalize(symbol)
 
-	// 获取3分钟K线数据 (最近10个)
-	klines3m, err := getKlines(symbol, "3m", 40) // 多获取一些用于计算
+	// 获取3分钟K线数据（按完整预热MACD、EMA20、RSI所需的数量获取）
+	klines3m, err := Klines(symbol, "3m", WarmupBars("3m"))
 	if err != nil {
 		return nil, fmt.Errorf("获取3分钟K线失败: %v", err)
 	}
 
-	// 获取4小时K线数据 (最近10个)
-	klines4h, err := getKlines(symbol, "4h", 60) // 多获取用于计算指标
+	// 获取4小时K线数据（按完整预热EMA50所需的数量获取，多周期K线服务跟踪的币种直接读取）
+	klines4h, err := Klines(symbol, "4h", WarmupBars("4h"))
 	if err != nil {
 		return nil, fmt.Errorf("获取4小时K线失败: %v", err)
 	}
@@ -150,9 +164,21 @@ func Get(symbol string) (*Data, error) {
 	// 计算长期数据
 	longerTermData := calculateLongerTermData(klines4h)
 
+	// 盘口和成交特征失败不影响整体
+	microstructure, _ := GetMicrostructure(symbol)
+	positioning, _ := GetPositioning(symbol)
+
+	// 行情状态只使用已收盘的K线（最后一根为正在形成的K线）
+	var regime *RegimeData
+	if len(klines4h) > 0 {
+		regime = ClassifyRegime(klines4h[:len(klines4h)-1])
+	}
+
 	return &Data{
 		Symbol:            symbol,
 		CurrentPrice:      currentPrice,
+		KlineTime:         time.UnixMilli(klines3m[len(klines3m)-1].OpenTime),
+		IndicatorsWarm:    len(klines3m) >= WarmupBars("3m") && len(klines4h) >= WarmupBars("4h"),
 		PriceChange1h:     priceChange1h,
 		PriceChange4h:     priceChange4h,
 		CurrentEMA20:      currentEMA20,
@@ -160,25 +186,20 @@ func Get(symbol string) (*Data, error) {
 		CurrentRSI7:       currentRSI7,
 		OpenInterest:      oiData,
 		FundingRate:       fundingRate,
+		FundingStats:      GetFundingStats(symbol, fundingRate),
 		IntradaySeries:    intradayData,
 		LongerTermContext: longerTermData,
+		Microstructure:    microstructure,
+		Regime:            regime,
+		Positioning:       positioning,
+		News:              news.ForSymbol(symbol),
 	}, nil
 }
 
 // convertSymbolToGateContract 将标准symbol转换为Gate.io合约格式
 // 例如: "BTCUSDT" -> "BTC_USDT"
 func convertSymbolToGateContract(symbol string) string {
-	symbol = strings.ToUpper(symbol)
-	// 如果已经有下划线，直接返回
-	if strings.Contains(symbol, "_") {
-		return symbol
-	}
-	// 去掉USDT后缀，然后加上下划线
-	if strings.HasSuffix(symbol, "USDT") {
-		base := symbol[:len(symbol)-4]
-		return base + "_USDT"
-	}
-	return symbol
+	return symbols.ToVenue(symbols.Gate, symbol).Venue
 }
 
 // convertIntervalToGate 将标准interval转换为Gate.io格式
@@ -214,7 +235,12 @@ func getKlines(symbol, interval string, limit int) ([]Kline, error) {
 	url := fmt.Sprintf("%s/futures/usdt/candlesticks?contract=%s&interval=%s&limit=%d",
 		baseURL, contract, gateInterval, limit)
 
-	resp, err := http.Get(url)
+	return fetchGateKlines(url, gateInterval)
+}
+
+// fetchGateKlines 请求Gate.io K线接口并解析
+func fetchGateKlines(url, gateInterval string) ([]Kline, error) {
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -493,7 +519,7 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 	baseURL := getBaseURL()
 	url := fmt.Sprintf("%s/futures/usdt/contracts/%s", baseURL, contract)
 
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -530,7 +556,7 @@ func getFundingRate(symbol string) (float64, error) {
 	baseURL := getBaseURL()
 	url := fmt.Sprintf("%s/futures/usdt/funding_rate?contract=%s", baseURL, contract)
 
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return 0, err
 	}
@@ -551,12 +577,23 @@ func getFundingRate(symbol string) (float64, error) {
 		return 0, fmt.Errorf("未找到资金费率数据")
 	}
 
-	// 取最新的资金费率
-	latest := result[len(result)-1]
+	// 取时间最新的资金费率（不依赖返回顺序）
+	latest := result[0]
+	latestTime, _ := parseFloat(latest["t"])
+	for _, entry := range result[1:] {
+		if t, _ := parseFloat(entry["t"]); t > latestTime {
+			latest, latestTime = entry, t
+		}
+	}
 	rate, _ := parseFloat(latest["r"])
 	return rate, nil
 }
 
+// GetFundingRate 获取指定币种的最新资金费率
+func GetFundingRate(symbol string) (float64, error) {
+	return getFundingRate(Normalize(symbol))
+}
+
 // Format 格式化输出市场数据
 func Format(data *Data) string {
 	var sb strings.Builder
@@ -574,6 +611,35 @@ func Format(data *Data) string {
 
 	sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
 
+	if data.FundingStats != nil {
+		sb.WriteString(data.FundingStats.String() + "\n\n")
+	}
+
+	if data.Regime != nil {
+		sb.WriteString(fmt.Sprintf("Market regime (4‑hour): %s\n\n", data.Regime))
+	}
+
+	if !data.IndicatorsWarm {
+		sb.WriteString("Note: kline history is too short to fully warm up the indicators (e.g. a newly listed contract); EMA/MACD/RSI values are less reliable.\n\n")
+	}
+
+	if data.Positioning != nil {
+		sb.WriteString(data.Positioning.String() + "\n\n")
+	}
+
+	if data.News != nil {
+		sb.WriteString(data.News.String() + "\n")
+	}
+
+	if m := data.Microstructure; m != nil {
+		sb.WriteString(fmt.Sprintf("Order book: spread = %.2f bps, depth within 0.1%%: bids $%.0f vs. asks $%.0f, imbalance = %.3f (rolling average %.3f)\n\n",
+			m.SpreadBps, m.BidDepthUSD, m.AskDepthUSD, m.Imbalance, m.ImbalanceAvg))
+		if m.TradeCount > 0 {
+			sb.WriteString(fmt.Sprintf("Recent trades (last %d, $%.0f): taker buy/sell imbalance = %.3f\n\n",
+				m.TradeCount, m.TradeVolumeUSD, m.TradeImbalance))
+		}
+	}
+
 	if data.IntradaySeries != nil {
 		sb.WriteString("Intraday series (3‑minute intervals, oldest → latest):\n\n")
 
@@ -631,13 +697,9 @@ func formatFloatSlice(values []float64) string {
 	return "[" + strings.Join(strValues, ", ") + "]"
 }
 
-// Normalize 标准化symbol,确保是USDT交易对
+// Normalize 标准化symbol,确保是USDT交易对（1000PEPEUSDT等统一为单币符号）
 func Normalize(symbol string) string {
-	symbol = strings.ToUpper(symbol)
-	if strings.HasSuffix(symbol, "USDT") {
-		return symbol
-	}
-	return symbol + "USDT"
+	return symbols.Canonical(symbol)
 }
 
 // parseFloat 解析float值
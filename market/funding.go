@@ -0,0 +1,188 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FundingSchedule 合约的资金费结算周期
+type FundingSchedule struct {
+	Rate      float64       // 下一次结算的预测费率
+	Interval  time.Duration // 结算间隔（多数合约8小时，部分为4小时或1小时）
+	NextApply time.Time     // 下一次结算时间
+}
+
+// GetFundingSchedule 获取指定币种的资金费结算周期
+func GetFundingSchedule(symbol string) (*FundingSchedule, error) {
+	url := fmt.Sprintf("%s/futures/usdt/contracts/%s", getBaseURL(), convertSymbolToGateContract(Normalize(symbol)))
+	var contract struct {
+		FundingRate      string  `json:"funding_rate"`
+		FundingInterval  int64   `json:"funding_interval"`   // 秒
+		FundingNextApply float64 `json:"funding_next_apply"` // Unix秒
+	}
+	if err := getJSON(url, &contract); err != nil {
+		return nil, err
+	}
+	if contract.FundingInterval <= 0 || contract.FundingNextApply <= 0 {
+		return nil, fmt.Errorf("合约没有资金费结算信息")
+	}
+	rate, _ := strconv.ParseFloat(contract.FundingRate, 64)
+	return &FundingSchedule{
+		Rate:      rate,
+		Interval:  time.Duration(contract.FundingInterval) * time.Second,
+		NextApply: time.Unix(int64(contract.FundingNextApply), 0),
+	}, nil
+}
+
+// maxFundingHistory Gate.io单次查询最多返回的资金费率记录数
+const maxFundingHistory = 1000
+
+// FundingPoint 一次资金费结算的费率
+type FundingPoint struct {
+	Time time.Time
+	Rate float64
+}
+
+// FundingRatesBetween 查询指定时间范围内的历史资金费率（按时间升序，超过单次查询上限时保留最近的记录）
+func FundingRatesBetween(symbol string, from, to time.Time) ([]FundingPoint, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("资金费率时间范围无效: %s ~ %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	url := fmt.Sprintf("%s/futures/usdt/funding_rate?contract=%s&limit=%d&from=%d&to=%d", getBaseURL(),
+		convertSymbolToGateContract(Normalize(symbol)), maxFundingHistory, from.Unix(), to.Unix())
+	var records []struct {
+		T int64  `json:"t"`
+		R string `json:"r"`
+	}
+	if err := getJSON(url, &records); err != nil {
+		return nil, fmt.Errorf("获取历史资金费率失败: %w", err)
+	}
+	points := make([]FundingPoint, 0, len(records))
+	for _, r := range records {
+		rate, err := strconv.ParseFloat(r.R, 64)
+		if err != nil {
+			continue
+		}
+		points = append(points, FundingPoint{Time: time.Unix(r.T, 0), Rate: rate})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
+
+// minFundingSamples 计算资金费率分布所需的最少历史记录数
+const minFundingSamples = 30
+
+// FundingStats 当前资金费率相对历史分布的位置
+type FundingStats struct {
+	Days       int     `json:"days"`       // 统计窗口（天）
+	Samples    int     `json:"samples"`    // 历史结算次数
+	Current    float64 `json:"current"`    // 当前费率
+	Mean       float64 `json:"mean"`       // 历史均值
+	StdDev     float64 `json:"std_dev"`    // 历史标准差
+	Min        float64 `json:"min"`        // 历史最低
+	P10        float64 `json:"p10"`        // 10分位
+	Median     float64 `json:"median"`     // 中位数
+	P90        float64 `json:"p90"`        // 90分位
+	Max        float64 `json:"max"`        // 历史最高
+	Percentile float64 `json:"percentile"` // 当前费率在历史分布中的百分位（0-100）
+	ZScore     float64 `json:"z_score"`    // 当前费率偏离均值的标准差倍数
+}
+
+// ComputeFundingStats 计算当前费率在历史费率分布中的位置（历史记录不足时返回nil）
+func ComputeFundingStats(history []float64, current float64, days int) *FundingStats {
+	if len(history) < minFundingSamples {
+		return nil
+	}
+	sorted := append([]float64(nil), history...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, r := range sorted {
+		sum += r
+	}
+	mean := sum / float64(len(sorted))
+	var variance float64
+	for _, r := range sorted {
+		variance += (r - mean) * (r - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(sorted)))
+
+	stats := &FundingStats{
+		Days:       days,
+		Samples:    len(sorted),
+		Current:    current,
+		Mean:       mean,
+		StdDev:     stdDev,
+		Min:        sorted[0],
+		P10:        quantile(sorted, 0.1),
+		Median:     quantile(sorted, 0.5),
+		P90:        quantile(sorted, 0.9),
+		Max:        sorted[len(sorted)-1],
+		Percentile: percentileRank(sorted, current),
+	}
+	if stdDev > 0 {
+		stats.ZScore = (current - mean) / stdDev
+	}
+	return stats
+}
+
+// quantile 已排序数据的分位数（线性插值）
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// percentileRank 数值在已排序数据中的百分位（相等的记录计一半）
+func percentileRank(sorted []float64, v float64) float64 {
+	below := sort.SearchFloat64s(sorted, v)
+	equal := sort.Search(len(sorted), func(i int) bool { return sorted[i] > v }) - below
+	return (float64(below) + float64(equal)/2) / float64(len(sorted)) * 100
+}
+
+// String 提示词中的资金费率分布描述
+func (s *FundingStats) String() string {
+	return fmt.Sprintf("Funding rate vs. %d-day history (%d settlements): current = %.4f%%, percentile = %.0f, z-score = %.2f, mean = %.4f%%, p10/median/p90 = %.4f%%/%.4f%%/%.4f%%, range = %.4f%% ~ %.4f%%",
+		s.Days, s.Samples, s.Current*100, s.Percentile, s.ZScore, s.Mean*100, s.P10*100, s.Median*100, s.P90*100, s.Min*100, s.Max*100)
+}
+
+// FundingStatsSource 资金费率历史分布数据源（当前费率在历史分布中的位置，没有足够历史时返回nil）
+type FundingStatsSource func(symbol string, current float64) *FundingStats
+
+// fundingStatsSource 进程内共享的资金费率历史分布数据源（未启用时为nil）
+var (
+	fundingStatsSource FundingStatsSource
+	fundingStatsMu     sync.RWMutex
+)
+
+// SetFundingStatsSource 设置进程内共享的资金费率历史分布数据源（nil表示不启用）
+func SetFundingStatsSource(source FundingStatsSource) {
+	fundingStatsMu.Lock()
+	fundingStatsSource = source
+	fundingStatsMu.Unlock()
+}
+
+// GetFundingStats 当前费率在历史分布中的位置（未启用或没有足够历史时返回nil）
+func GetFundingStats(symbol string, current float64) *FundingStats {
+	fundingStatsMu.RLock()
+	source := fundingStatsSource
+	fundingStatsMu.RUnlock()
+	if source == nil {
+		return nil
+	}
+	return source(Normalize(symbol), current)
+}
+
+// FundingPercentile 当前费率在历史分布中的百分位（用于资金费率套利的开仓过滤，没有足够历史时ok为false）
+func FundingPercentile(symbol string, rate float64) (float64, bool) {
+	stats := GetFundingStats(symbol, rate)
+	if stats == nil {
+		return 0, false
+	}
+	return stats.Percentile, true
+}
@@ -0,0 +1,42 @@
+package market
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxHistoryKlines Gate.io单次查询最多返回的K线数
+const maxHistoryKlines = 2000
+
+// HistoryIntervals 历史K线支持的周期（从小到大）
+var HistoryIntervals = []string{"1m", "5m", "15m", "1h", "4h"}
+
+// historyIntervalDurations 历史K线周期的时长
+var historyIntervalDurations = map[string]time.Duration{
+	"1m": time.Minute, "5m": 5 * time.Minute, "15m": 15 * time.Minute, "1h": time.Hour, "4h": 4 * time.Hour,
+}
+
+// HistoryIntervalDuration 历史K线周期的时长（不支持的周期返回0）
+func HistoryIntervalDuration(interval string) time.Duration {
+	return historyIntervalDurations[interval]
+}
+
+// KlinesBetween 查询指定时间范围内的历史K线（REST，用于交易回放等复盘场景）
+func KlinesBetween(symbol, interval string, from, to time.Time) ([]Kline, error) {
+	step := HistoryIntervalDuration(interval)
+	if step == 0 {
+		return nil, fmt.Errorf("历史K线不支持周期: %s（可选: 1m/5m/15m/1h/4h）", interval)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("K线时间范围无效: %s ~ %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	if n := int(to.Sub(from) / step); n > maxHistoryKlines {
+		return nil, fmt.Errorf("时间范围内有%d根%sK线，超过单次查询上限%d，请使用更大的周期", n, interval, maxHistoryKlines)
+	}
+
+	contract := convertSymbolToGateContract(Normalize(symbol))
+	gateInterval := convertIntervalToGate(interval)
+	url := fmt.Sprintf("%s/futures/usdt/candlesticks?contract=%s&interval=%s&from=%d&to=%d",
+		getBaseURL(), contract, gateInterval, from.Unix(), to.Unix())
+	return fetchGateKlines(url, gateInterval)
+}
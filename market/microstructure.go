@@ -0,0 +1,190 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	depthBandPct       = 0.1 // 统计中间价上下0.1%以内的挂单深度
+	orderBookLimit     = 50  // 盘口档位数
+	tradeTapeLimit     = 100 // 统计最近的成交笔数
+	imbalanceWindowLen = 20  // 失衡滚动平均的采样数
+)
+
+// MicrostructureData 盘口和逐笔成交特征
+type MicrostructureData struct {
+	SpreadBps      float64 // 买一卖一价差（基点）
+	BidDepthUSD    float64 // 中间价下方0.1%以内的买单深度（USDT）
+	AskDepthUSD    float64 // 中间价上方0.1%以内的卖单深度（USDT）
+	Imbalance      float64 // 盘口失衡 (买深度-卖深度)/(买深度+卖深度)，-1~1，正数表示买盘更厚
+	ImbalanceAvg   float64 // 最近若干次采样的平均盘口失衡（滚动）
+	TradeImbalance float64 // 最近成交中主动买入与主动卖出的失衡，-1~1，正数表示主动买入更多
+	TradeCount     int     // 统计的成交笔数
+	TradeVolumeUSD float64 // 统计的成交额（USDT）
+}
+
+// imbalanceHistory 每个币种最近的盘口失衡采样（滚动平均）
+var (
+	imbalanceHistory = make(map[string][]float64)
+	imbalanceMutex   sync.Mutex
+)
+
+// multiplierCache 合约乘数缓存（张 → 币）
+var multiplierCache sync.Map
+
+// GetMicrostructure 获取指定币种的盘口和成交特征（每次调用采样一次盘口，计入滚动平均）
+func GetMicrostructure(symbol string) (*MicrostructureData, error) {
+	symbol = Normalize(symbol)
+	multiplier, err := getQuantoMultiplier(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取合约乘数失败: %v", err)
+	}
+
+	data := &MicrostructureData{}
+	if err := fillOrderBookFeatures(symbol, multiplier, data); err != nil {
+		return nil, fmt.Errorf("获取盘口失败: %v", err)
+	}
+	data.ImbalanceAvg = recordImbalance(symbol, data.Imbalance)
+
+	// 成交数据失败不影响盘口特征
+	_ = fillTradeFeatures(symbol, multiplier, data)
+	return data, nil
+}
+
+// fillOrderBookFeatures 计算价差、0.1%以内深度和盘口失衡
+func fillOrderBookFeatures(symbol string, multiplier float64, data *MicrostructureData) error {
+	url := fmt.Sprintf("%s/futures/usdt/order_book?contract=%s&limit=%d",
+		getBaseURL(), convertSymbolToGateContract(symbol), orderBookLimit)
+	var book struct {
+		Asks []gateBookLevel `json:"asks"`
+		Bids []gateBookLevel `json:"bids"`
+	}
+	if err := getJSON(url, &book); err != nil {
+		return err
+	}
+	if len(book.Asks) == 0 || len(book.Bids) == 0 {
+		return fmt.Errorf("盘口为空")
+	}
+
+	bestAsk, _ := strconv.ParseFloat(book.Asks[0].Price, 64)
+	bestBid, _ := strconv.ParseFloat(book.Bids[0].Price, 64)
+	if bestAsk <= 0 || bestBid <= 0 {
+		return fmt.Errorf("盘口价格无效")
+	}
+	mid := (bestAsk + bestBid) / 2
+	data.SpreadBps = (bestAsk - bestBid) / mid * 1e4
+
+	band := mid * depthBandPct / 100
+	for _, level := range book.Bids {
+		if price, _ := strconv.ParseFloat(level.Price, 64); price >= mid-band {
+			data.BidDepthUSD += price * math.Abs(level.Size) * multiplier
+		}
+	}
+	for _, level := range book.Asks {
+		if price, _ := strconv.ParseFloat(level.Price, 64); price > 0 && price <= mid+band {
+			data.AskDepthUSD += price * math.Abs(level.Size) * multiplier
+		}
+	}
+	if total := data.BidDepthUSD + data.AskDepthUSD; total > 0 {
+		data.Imbalance = (data.BidDepthUSD - data.AskDepthUSD) / total
+	}
+	return nil
+}
+
+// fillTradeFeatures 按最近成交计算主动买卖失衡（size为正表示主动买入，为负表示主动卖出）
+func fillTradeFeatures(symbol string, multiplier float64, data *MicrostructureData) error {
+	url := fmt.Sprintf("%s/futures/usdt/trades?contract=%s&limit=%d",
+		getBaseURL(), convertSymbolToGateContract(symbol), tradeTapeLimit)
+	var trades []struct {
+		Size  float64 `json:"size"`
+		Price string  `json:"price"`
+	}
+	if err := getJSON(url, &trades); err != nil {
+		return err
+	}
+
+	var buy, sell float64
+	for _, trade := range trades {
+		price, _ := strconv.ParseFloat(trade.Price, 64)
+		value := price * math.Abs(trade.Size) * multiplier
+		if trade.Size > 0 {
+			buy += value
+		} else {
+			sell += value
+		}
+	}
+	data.TradeCount = len(trades)
+	data.TradeVolumeUSD = buy + sell
+	if total := buy + sell; total > 0 {
+		data.TradeImbalance = (buy - sell) / total
+	}
+	return nil
+}
+
+// recordImbalance 记录一次盘口失衡采样，返回最近imbalanceWindowLen次采样的平均值
+func recordImbalance(symbol string, imbalance float64) float64 {
+	imbalanceMutex.Lock()
+	defer imbalanceMutex.Unlock()
+
+	samples := append(imbalanceHistory[symbol], imbalance)
+	if len(samples) > imbalanceWindowLen {
+		samples = samples[len(samples)-imbalanceWindowLen:]
+	}
+	imbalanceHistory[symbol] = samples
+
+	sum := 0.0
+	for _, v := range samples {
+		sum += v
+	}
+	return sum / float64(len(samples))
+}
+
+// getQuantoMultiplier 合约乘数（每张合约对应的币数量），盘口和成交数量以张为单位
+func getQuantoMultiplier(symbol string) (float64, error) {
+	if v, ok := multiplierCache.Load(symbol); ok {
+		return v.(float64), nil
+	}
+	var contract struct {
+		QuantoMultiplier string `json:"quanto_multiplier"`
+	}
+	url := fmt.Sprintf("%s/futures/usdt/contracts/%s", getBaseURL(), convertSymbolToGateContract(symbol))
+	if err := getJSON(url, &contract); err != nil {
+		return 0, err
+	}
+	multiplier, err := strconv.ParseFloat(contract.QuantoMultiplier, 64)
+	if err != nil || multiplier <= 0 {
+		return 0, fmt.Errorf("合约乘数无效: %q", contract.QuantoMultiplier)
+	}
+	multiplierCache.Store(symbol, multiplier)
+	return multiplier, nil
+}
+
+// gateBookLevel 盘口一档（数量为张）
+type gateBookLevel struct {
+	Price string  `json:"p"`
+	Size  float64 `json:"s"`
+}
+
+// getJSON GET请求并解析JSON响应
+func getJSON(url string, v interface{}) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,146 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	positioningInterval = "1h"            // 合约统计周期
+	positioningLimit    = 168             // 归一化使用的历史长度（7天）
+	positioningMinStats = 24              // 少于该数量时不计算z-score
+	positioningCacheTTL = 5 * time.Minute // 统计数据按小时更新，缓存避免每个决策周期重复查询
+)
+
+// PositioningData 多空人数比和主动买卖量特征（Gate.io合约统计），每项给出最新值和相对最近7天的z-score
+// 比值取对数后归一化（2倍和0.5倍对称），z-score为正表示偏多程度高于近期常态
+type PositioningData struct {
+	Interval string
+	Samples  int // 参与归一化的统计条数
+
+	TakerRatio        float64 // 主动买入量/主动卖出量
+	TakerRatioZ       float64
+	AccountRatio      float64 // 多头账户数/空头账户数
+	AccountRatioZ     float64
+	TopPositionRatio  float64 // 大户多头持仓/空头持仓
+	TopPositionRatioZ float64
+	TopAccountRatio   float64 // 大户多头账户数/空头账户数
+	TopAccountRatioZ  float64
+}
+
+// String 用于提示词
+func (p *PositioningData) String() string {
+	return fmt.Sprintf("Positioning (%s contract stats, z-score vs. last %d readings): taker buy/sell volume ratio = %.3f (z %+.2f), long/short account ratio = %.3f (z %+.2f), top traders long/short position ratio = %.3f (z %+.2f), top traders long/short account ratio = %.3f (z %+.2f)",
+		p.Interval, p.Samples, p.TakerRatio, p.TakerRatioZ, p.AccountRatio, p.AccountRatioZ,
+		p.TopPositionRatio, p.TopPositionRatioZ, p.TopAccountRatio, p.TopAccountRatioZ)
+}
+
+// contractStat Gate.io合约统计（GET /futures/usdt/contract_stats）
+type contractStat struct {
+	Time          int64   `json:"time"`
+	LsrTaker      float64 `json:"lsr_taker"`   // 主动买入/卖出量比
+	LsrAccount    float64 `json:"lsr_account"` // 多空账户数比
+	TopLsrAccount float64 `json:"top_lsr_account"`
+	TopLsrSize    float64 `json:"top_lsr_size"`
+}
+
+// positioningCache 每个币种最近一次计算的结果
+var (
+	positioningCache = make(map[string]positioningEntry)
+	positioningMutex sync.Mutex
+)
+
+type positioningEntry struct {
+	data    *PositioningData
+	fetched time.Time
+}
+
+// GetPositioning 获取指定币种的多空比和主动买卖量特征（结果缓存5分钟）
+func GetPositioning(symbol string) (*PositioningData, error) {
+	symbol = Normalize(symbol)
+	positioningMutex.Lock()
+	entry, ok := positioningCache[symbol]
+	positioningMutex.Unlock()
+	if ok && time.Since(entry.fetched) < positioningCacheTTL {
+		return entry.data, nil
+	}
+
+	url := fmt.Sprintf("%s/futures/usdt/contract_stats?contract=%s&interval=%s&limit=%d",
+		getBaseURL(), convertSymbolToGateContract(symbol), positioningInterval, positioningLimit)
+	var stats []contractStat
+	if err := getJSON(url, &stats); err != nil {
+		return nil, fmt.Errorf("获取合约统计失败: %v", err)
+	}
+	data := calculatePositioning(stats)
+	if data == nil {
+		return nil, fmt.Errorf("合约统计数据不足: %d条", len(stats))
+	}
+
+	positioningMutex.Lock()
+	positioningCache[symbol] = positioningEntry{data: data, fetched: time.Now()}
+	positioningMutex.Unlock()
+	return data, nil
+}
+
+// calculatePositioning 取时间最新的一条，各比值相对全部历史归一化（数据不足时返回nil）
+func calculatePositioning(stats []contractStat) *PositioningData {
+	if len(stats) < positioningMinStats {
+		return nil
+	}
+	latest := stats[0]
+	for _, s := range stats[1:] {
+		if s.Time > latest.Time {
+			latest = s
+		}
+	}
+
+	data := &PositioningData{Interval: positioningInterval, Samples: len(stats)}
+	series := func(get func(contractStat) float64) []float64 {
+		values := make([]float64, 0, len(stats))
+		for _, s := range stats {
+			values = append(values, get(s))
+		}
+		return values
+	}
+	data.TakerRatio = latest.LsrTaker
+	data.TakerRatioZ = logZScore(latest.LsrTaker, series(func(s contractStat) float64 { return s.LsrTaker }))
+	data.AccountRatio = latest.LsrAccount
+	data.AccountRatioZ = logZScore(latest.LsrAccount, series(func(s contractStat) float64 { return s.LsrAccount }))
+	data.TopPositionRatio = latest.TopLsrSize
+	data.TopPositionRatioZ = logZScore(latest.TopLsrSize, series(func(s contractStat) float64 { return s.TopLsrSize }))
+	data.TopAccountRatio = latest.TopLsrAccount
+	data.TopAccountRatioZ = logZScore(latest.TopLsrAccount, series(func(s contractStat) float64 { return s.TopLsrAccount }))
+	return data
+}
+
+// logZScore 比值取对数后的z-score（忽略非正值；有效数据不足或没有波动时为0）
+func logZScore(value float64, history []float64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	logs := make([]float64, 0, len(history))
+	for _, v := range history {
+		if v > 0 {
+			logs = append(logs, math.Log(v))
+		}
+	}
+	if len(logs) < positioningMinStats {
+		return 0
+	}
+	mean := 0.0
+	for _, l := range logs {
+		mean += l
+	}
+	mean /= float64(len(logs))
+	variance := 0.0
+	for _, l := range logs {
+		variance += (l - mean) * (l - mean)
+	}
+	std := math.Sqrt(variance / float64(len(logs)-1))
+	if std < 1e-9 {
+		return 0 // 没有波动（浮点误差）
+	}
+	return (math.Log(value) - mean) / std
+}
@@ -0,0 +1,152 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Regime 行情状态
+type Regime string
+
+const (
+	RegimeTrending Regime = "trending" // 趋势：ADX较高
+	RegimeRanging  Regime = "ranging"  // 震荡：ADX较低且波动率正常
+	RegimeHighVol  Regime = "high_vol" // 高波动：已实现波动率明显高于近期中位数（优先于趋势/震荡）
+)
+
+const (
+	regimePeriod        = 14   // ADX、ATR周期
+	regimeVolWindow     = 20   // 已实现波动率窗口（K线数）
+	regimeTrendADX      = 25   // ADX不低于该值视为趋势
+	regimeHighVolRatio  = 1.5  // 已实现波动率不低于近期中位数的该倍数视为高波动
+	regimeBarsPerYear4h = 2190 // 4小时K线每年根数（年化波动率）
+)
+
+// RegimeData 行情状态及其依据（基于已收盘的4小时K线）
+type RegimeData struct {
+	Regime      Regime  `json:"regime"`
+	ADX         float64 `json:"adx"`          // 14周期ADX
+	ATRPct      float64 `json:"atr_pct"`      // 14周期ATR占价格的百分比
+	RealizedVol float64 `json:"realized_vol"` // 最近20根K线对数收益率的年化波动率（%）
+	VolRatio    float64 `json:"vol_ratio"`    // 已实现波动率 / 近期各窗口已实现波动率的中位数
+}
+
+// ClassifyRegime 按已收盘K线（时间升序）判断行情状态：波动率明显放大时为高波动，否则按ADX区分趋势和震荡
+// K线不足以计算ADX时返回nil
+func ClassifyRegime(klines []Kline) *RegimeData {
+	adx := calculateADX(klines, regimePeriod)
+	if adx == 0 || len(klines) <= regimeVolWindow {
+		return nil
+	}
+	data := &RegimeData{ADX: adx}
+	if price := klines[len(klines)-1].Close; price > 0 {
+		data.ATRPct = calculateATR(klines, regimePeriod) / price * 100
+	}
+
+	// 各滚动窗口的已实现波动率，最后一个为当前值
+	var vols []float64
+	for end := regimeVolWindow + 1; end <= len(klines); end++ {
+		vols = append(vols, realizedVol(klines[end-regimeVolWindow-1:end]))
+	}
+	data.RealizedVol = vols[len(vols)-1] * math.Sqrt(regimeBarsPerYear4h) * 100
+	sorted := append([]float64(nil), vols...)
+	sort.Float64s(sorted)
+	if median := sorted[len(sorted)/2]; median > 0 {
+		data.VolRatio = vols[len(vols)-1] / median
+	}
+
+	switch {
+	case data.VolRatio >= regimeHighVolRatio:
+		data.Regime = RegimeHighVol
+	case adx >= regimeTrendADX:
+		data.Regime = RegimeTrending
+	default:
+		data.Regime = RegimeRanging
+	}
+	return data
+}
+
+// String 用于提示词
+func (r *RegimeData) String() string {
+	return fmt.Sprintf("%s (ADX %.1f, ATR %.2f%% of price, realized volatility %.0f%% annualized, %.2f× its recent median)",
+		r.Regime, r.ADX, r.ATRPct, r.RealizedVol, r.VolRatio)
+}
+
+// realizedVol 对数收益率的标准差（不年化）
+func realizedVol(klines []Kline) float64 {
+	returns := make([]float64, 0, len(klines)-1)
+	for i := 1; i < len(klines); i++ {
+		if klines[i-1].Close > 0 && klines[i].Close > 0 {
+			returns = append(returns, math.Log(klines[i].Close/klines[i-1].Close))
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// calculateADX 计算ADX（Wilder平滑，K线不足2*period+1根时返回0）
+func calculateADX(klines []Kline, period int) float64 {
+	if len(klines) < 2*period+1 {
+		return 0
+	}
+
+	var trSum, plusSum, minusSum float64
+	var dxs []float64
+	for i := 1; i < len(klines); i++ {
+		high, low, prevClose := klines[i].High, klines[i].Low, klines[i-1].Close
+		tr := math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+		up, down := high-klines[i-1].High, klines[i-1].Low-low
+		plusDM, minusDM := 0.0, 0.0
+		if up > down && up > 0 {
+			plusDM = up
+		}
+		if down > up && down > 0 {
+			minusDM = down
+		}
+
+		if i <= period {
+			trSum += tr
+			plusSum += plusDM
+			minusSum += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			trSum = trSum - trSum/float64(period) + tr
+			plusSum = plusSum - plusSum/float64(period) + plusDM
+			minusSum = minusSum - minusSum/float64(period) + minusDM
+		}
+		if trSum == 0 {
+			dxs = append(dxs, 0)
+			continue
+		}
+		plusDI, minusDI := plusSum/trSum*100, minusSum/trSum*100
+		if plusDI+minusDI == 0 {
+			dxs = append(dxs, 0)
+			continue
+		}
+		dxs = append(dxs, math.Abs(plusDI-minusDI)/(plusDI+minusDI)*100)
+	}
+
+	adx := 0.0
+	for _, dx := range dxs[:period] {
+		adx += dx
+	}
+	adx /= float64(period)
+	for _, dx := range dxs[period:] {
+		adx = (adx*float64(period-1) + dx) / float64(period)
+	}
+	return adx
+}
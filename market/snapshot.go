@@ -0,0 +1,112 @@
+package market
+
+import "time"
+
+// MarketSnapshot 决策周期的统一快照：各币种行情（价格、K线摘要、指标、资金费率、持仓量）、账户状态、持仓和挂单，
+// 由快照阶段一次构建，AI上下文、风控检查、策略看守和交易日志读取同一份数据，不再各自查询或逐个传参
+type MarketSnapshot struct {
+	Symbols    map[string]*Data // 各币种行情（获取失败或被流动性过滤的币种不在其中）
+	Account    AccountState
+	Positions  []PositionState
+	OpenOrders []OrderState // 未成交的普通委托单（交易器不支持查询时为空）
+
+	AccountAt time.Time // 账户状态的查询时间
+	TakenAt   time.Time // 行情获取完成的时间（决策过期保护和交易日志以此为准）
+}
+
+// AccountState 快照时的账户状态
+type AccountState struct {
+	WalletBalance     float64 // 钱包余额
+	UnrealizedPnL     float64 // 未实现盈亏
+	Equity            float64 // 净值（钱包余额 + 未实现盈亏）
+	Available         float64 // 可用余额
+	MarginUsed        float64 // 已占用保证金
+	MarginUsedPct     float64 // 保证金使用率（%）
+	Notional          float64 // 所有持仓总名义价值
+	EffectiveLeverage float64 // 有效杠杆（总名义价值 / 净值）
+}
+
+// PositionState 快照时的持仓
+type PositionState struct {
+	Symbol           string
+	Side             string  // long/short
+	Quantity         float64 // 持仓数量（正数，合约张数）
+	Multiplier       float64 // 合约乘数（每张对应的基础币数量，0表示数量已是基础币）
+	EntryPrice       float64
+	MarkPrice        float64
+	UnrealizedPnL    float64
+	LiquidationPrice float64
+	Leverage         int
+	Margin           float64 // 占用保证金（交易所未返回时按名义价值 / 杠杆计算）
+	StopLoss         float64 // 交易所上生效的止损触发价（0表示没有）
+	TakeProfit       float64 // 交易所上生效的止盈触发价（0表示没有）
+	ADLRank          int     // 自动减仓排名（0表示交易所未提供）
+}
+
+// Notional 持仓名义价值（按标记价格和合约乘数）
+func (p PositionState) Notional() float64 {
+	return p.ContractValue(p.Quantity)
+}
+
+// ContractValue 该合约quantity张按标记价格的名义价值
+func (p PositionState) ContractValue(quantity float64) float64 {
+	if p.Multiplier > 0 {
+		quantity *= p.Multiplier
+	}
+	return quantity * p.MarkPrice
+}
+
+// OrderState 快照时的未成交委托单
+type OrderState struct {
+	OrderID    string
+	Symbol     string
+	Quantity   float64 // 正数买入，负数卖出
+	Price      float64
+	ReduceOnly bool
+}
+
+// Data 币种的行情数据（不在快照中时返回nil）
+func (s *MarketSnapshot) Data(symbol string) *Data {
+	if s == nil {
+		return nil
+	}
+	return s.Symbols[symbol]
+}
+
+// Price 币种的快照价格：优先取行情数据的当前价，没有行情时取持仓的标记价格（都没有时返回0）
+func (s *MarketSnapshot) Price(symbol string) float64 {
+	if data := s.Data(symbol); data != nil && data.CurrentPrice > 0 {
+		return data.CurrentPrice
+	}
+	if pos := s.Position(symbol, ""); pos != nil {
+		return pos.MarkPrice
+	}
+	return 0
+}
+
+// Position 币种指定方向的持仓（side为空时返回任一方向，没有时返回nil）
+func (s *MarketSnapshot) Position(symbol, side string) *PositionState {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Positions {
+		if s.Positions[i].Symbol == symbol && (side == "" || s.Positions[i].Side == side) {
+			return &s.Positions[i]
+		}
+	}
+	return nil
+}
+
+// Prices 快照中各币种的价格（写入交易日志）
+func (s *MarketSnapshot) Prices() map[string]float64 {
+	if s == nil {
+		return nil
+	}
+	prices := make(map[string]float64, len(s.Symbols))
+	for symbol, data := range s.Symbols {
+		if data != nil && data.CurrentPrice > 0 {
+			prices[symbol] = data.CurrentPrice
+		}
+	}
+	return prices
+}
@@ -0,0 +1,117 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// warmupFactor EMA等递推指标按4倍周期取K线：SMA种子之后再递推3倍周期，初始值的权重降到0.3%以下
+	warmupFactor = 4
+	// warmupSeries 提示词中的指标序列长度（序列中每个点都需要完整预热）
+	warmupSeries = 10
+)
+
+// indicatorPeriods 各K线周期上指标的最长周期（3分钟：MACD慢线26；4小时：EMA50）
+var indicatorPeriods = map[string]int{"3m": 26, "4h": 50}
+
+// WarmupBars 完整预热该周期上所有指标需要的K线数（含最后一根正在形成的K线）
+func WarmupBars(interval string) int {
+	return indicatorPeriods[interval]*warmupFactor + warmupSeries + 1
+}
+
+// klineHistory REST查询过的K线历史（按币种和周期保存）：首次完整查询，之后只查询上次之后的几根K线并接到已保存的历史后面
+// 每个周期不必再重新获取完整的预热窗口
+type klineHistory struct {
+	mu     sync.Mutex
+	series map[string][]Kline // symbol|interval → K线（按时间升序，最后一根为查询时正在形成的K线）
+
+	fetch func(symbol, interval string, limit int) ([]Kline, error)
+	now   func() time.Time
+}
+
+// defaultHistory 进程内共享的K线历史（多个trader查询同一币种时共用）
+var defaultHistory = &klineHistory{series: make(map[string][]Kline), fetch: getKlines, now: time.Now}
+
+// Klines 最近limit根K线（最后一根为正在形成的K线）
+func (h *klineHistory) Klines(symbol, interval string, limit int) ([]Kline, error) {
+	key := symbol + "|" + interval
+	h.mu.Lock()
+	stored := h.series[key]
+	h.mu.Unlock()
+
+	// 已保存的历史足够时只查询最后一根之后的K线（至少2根，覆盖上次正在形成的K线）
+	bars, err := h.extend(stored, symbol, interval, limit)
+	if err != nil {
+		return nil, err
+	}
+	if bars == nil {
+		if bars, err = h.fetch(symbol, interval, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	h.mu.Lock()
+	h.series[key] = lastKlines(bars, min(max(limit, len(stored)), maxHistoryKlines))
+	h.mu.Unlock()
+	return lastKlines(bars, limit), nil
+}
+
+// extend 查询已保存历史之后的K线并拼接；历史不足、缺口过大或无法衔接时返回nil（改为完整查询）
+func (h *klineHistory) extend(stored []Kline, symbol, interval string, limit int) ([]Kline, error) {
+	n := len(stored)
+	if n < limit || n < 2 {
+		return nil, nil
+	}
+	last := stored[n-1]
+	step := last.OpenTime - stored[n-2].OpenTime
+	if step <= 0 {
+		return nil, nil
+	}
+	missing := int((h.now().UnixMilli()-last.OpenTime)/step) + 2
+	if missing >= limit {
+		return nil, nil
+	}
+
+	recent, err := h.fetch(symbol, interval, missing)
+	if err != nil {
+		return nil, err
+	}
+	// 新查询的第一根必须与已保存的K线重叠，否则中间有缺口
+	if len(recent) == 0 || recent[0].OpenTime > last.OpenTime {
+		return nil, nil
+	}
+	keep := n
+	for keep > 0 && stored[keep-1].OpenTime >= recent[0].OpenTime {
+		keep--
+	}
+	return append(append([]Kline(nil), stored[:keep]...), recent...), nil
+}
+
+// Warmup 预加载币种完整预热指标所需的K线历史（启动时在第一个决策周期之前调用）
+// 返回K线历史不足以完整预热的币种（如新上线的合约）；查询失败的币种合并为一个错误
+func Warmup(symbols []string) ([]string, error) {
+	var (
+		partial []string
+		errs    []error
+	)
+next:
+	for _, symbol := range symbols {
+		symbol = Normalize(symbol)
+		warm := true
+		for interval := range indicatorPeriods {
+			bars, err := Klines(symbol, interval, WarmupBars(interval))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", symbol, interval, err))
+				continue next
+			}
+			warm = warm && len(bars) >= WarmupBars(interval)
+		}
+		if !warm {
+			partial = append(partial, symbol)
+		}
+	}
+	return partial, errors.Join(errs...)
+}
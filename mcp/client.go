@@ -1,15 +1,49 @@
 package mcp
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"nofx/logging"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// logger AI客户端日志
+var logger = logging.For("mcp")
+
+const (
+	defaultMaxAttempts = 3                // 默认最多尝试次数（含首次）
+	defaultMaxBackoff  = 30 * time.Second // 默认重试等待上限
+	baseBackoff        = 2 * time.Second  // 首次重试的等待时间（之后每次翻倍）
+)
+
+// errEmptyResponse AI返回了空内容
+var errEmptyResponse = errors.New("API返回空响应")
+
+// APIError AI接口返回的非200响应
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // 服务端要求的等待时间（Retry-After响应头，未返回时为0）
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API返回错误 (status %d): %s", e.StatusCode, e.Body)
+}
+
+// Retryable 限频（429）和服务端错误（5xx）可以重试
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
 // Provider AI提供商类型
 type Provider string
 
@@ -28,6 +62,19 @@ type Client struct {
 	Model      string
 	Timeout    time.Duration
 	UseFullURL bool // 是否使用完整URL（不添加/chat/completions）
+
+	// OnUsage 每次调用成功后回调本次消耗的token数（用于统计AI成本，可为nil）
+	OnUsage func(model string, usage Usage)
+
+	// Inject 替换AI返回的内容（浸泡测试注入畸形输出，可为nil）
+	Inject func(content string) string
+
+	MaxAttempts int           // 最多尝试次数（含首次，0表示默认3次）
+	MaxBackoff  time.Duration // 重试等待上限（0表示默认30秒）
+	Stream      bool          // 流式输出（边生成边接收，记录首个token耗时）
+	JSONMode    bool          // 强制JSON输出（response_format=json_object）
+
+	metrics *metrics // 请求、延迟和成本统计
 }
 
 func New() *Client {
@@ -37,6 +84,7 @@ func New() *Client {
 		BaseURL:  "https://api.deepseek.com/v1",
 		Model:    "deepseek-chat",
 		Timeout:  120 * time.Second, // 增加到120秒，因为AI需要分析大量数据
+		metrics:  newMetrics(),
 	}
 	return &defaultClient
 }
@@ -86,46 +134,80 @@ func (cfg *Client) SetClient(Client Client) {
 
 // CallWithMessages 使用 system + user prompt 调用AI API（推荐）
 func (cfg *Client) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return cfg.CallWithContext(context.Background(), systemPrompt, userPrompt)
+}
+
+// CallWithContext 同CallWithMessages，ctx取消时中止请求和重试等待（用于决策阶段超时）
+// 网络错误、限频（429）和服务端错误（5xx）按指数退避重试，服务端返回Retry-After时按其等待
+func (cfg *Client) CallWithContext(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	if cfg.APIKey == "" {
 		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
 	}
 
-	// 重试配置
-	maxRetries := 3
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
 	var lastErr error
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if attempt > 1 {
-			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt, maxRetries)
-		}
-
-		result, err := cfg.callOnce(systemPrompt, userPrompt)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err := cfg.callOnce(ctx, systemPrompt, userPrompt)
 		if err == nil {
 			if attempt > 1 {
-				fmt.Printf("✓ AI API重试成功\n")
+				logger.Info("AI API重试成功", "attempt", attempt)
+			}
+			cfg.metrics.call(nil)
+			if cfg.Inject != nil {
+				result = cfg.Inject(result)
 			}
 			return result, nil
 		}
 
 		lastErr = err
-		// 如果不是网络错误，不重试
-		if !isRetryableError(err) {
+		// 已取消或不是临时错误，不重试
+		if ctx.Err() != nil || !isRetryableError(err) {
+			cfg.metrics.call(err)
 			return "", err
 		}
 
 		// 重试前等待
-		if attempt < maxRetries {
-			waitTime := time.Duration(attempt) * 2 * time.Second
-			fmt.Printf("⏳ 等待%v后重试...\n", waitTime)
-			time.Sleep(waitTime)
+		if attempt < maxAttempts {
+			wait := cfg.backoff(attempt, err)
+			logger.Warn("AI API调用失败，等待后重试", "attempt", attempt, "max_attempts", maxAttempts, "wait", wait, "err", err)
+			cfg.metrics.retry()
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				cfg.metrics.call(err)
+				return "", fmt.Errorf("等待重试时取消: %w", err)
+			case <-timer.C:
+			}
 		}
 	}
 
-	return "", fmt.Errorf("重试%d次后仍然失败: %w", maxRetries, lastErr)
+	err := fmt.Errorf("重试%d次后仍然失败: %w", maxAttempts, lastErr)
+	cfg.metrics.call(err)
+	return "", err
+}
+
+// backoff 第attempt次失败后的等待时间：2s起指数增长并加随机抖动，不超过MaxBackoff；服务端返回Retry-After时按其等待（同样不超过MaxBackoff）
+func (cfg *Client) backoff(attempt int, err error) time.Duration {
+	limit := cfg.MaxBackoff
+	if limit <= 0 {
+		limit = defaultMaxBackoff
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, limit)
+	}
+	wait := min(baseBackoff<<(attempt-1), limit)
+	// 等待时间在 [wait/2, wait) 之间随机，避免多个trader同时重试
+	return wait/2 + rand.N(wait/2+1)
 }
 
 // callOnce 单次调用AI API（内部使用）
-func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
+func (cfg *Client) callOnce(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// 构建 messages 数组
 	messages := []map[string]string{}
 
@@ -151,8 +233,16 @@ func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 		"max_tokens":  2000,
 	}
 
-	// 注意：response_format 参数仅 OpenAI 支持，DeepSeek/Qwen 不支持
-	// 我们通过强化 prompt 和后处理来确保 JSON 格式正确
+	// JSON模式：DeepSeek、Qwen兼容模式和OpenAI都支持 response_format=json_object（要求提示词中说明输出JSON）
+	// 未启用时通过强化 prompt 和后处理来确保 JSON 格式正确
+	if cfg.JSONMode {
+		requestBody["response_format"] = map[string]string{"type": "json_object"}
+	}
+	// 流式输出：边生成边接收，最后一个数据块返回token消耗
+	if cfg.Stream {
+		requestBody["stream"] = true
+		requestBody["stream_options"] = map[string]bool{"include_usage": true}
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
@@ -168,7 +258,7 @@ func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 		// 默认行为：添加/chat/completions
 		url = fmt.Sprintf("%s/chat/completions", cfg.BaseURL)
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -188,21 +278,51 @@ func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 	}
 
 	// 发送请求
+	start := time.Now()
 	client := &http.Client{Timeout: cfg.Timeout}
 	resp, err := client.Do(req)
 	if err != nil {
+		cfg.metrics.request(0, 0, err)
 		return "", fmt.Errorf("发送请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := &APIError{StatusCode: resp.StatusCode, Body: string(body), RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		cfg.metrics.request(time.Since(start), 0, err)
+		return "", err
+	}
+
+	var (
+		content    string
+		usage      Usage
+		firstToken time.Duration
+	)
+	if cfg.Stream {
+		content, usage, err = readStream(resp.Body, func() { firstToken = time.Since(start) })
+	} else {
+		content, usage, err = readResponse(resp.Body)
+	}
+	cfg.metrics.request(time.Since(start), firstToken, err)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		return "", err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API返回错误 (status %d): %s", resp.StatusCode, string(body))
+	cfg.metrics.usage(cfg.Model, usage)
+	if cfg.OnUsage != nil {
+		cfg.OnUsage(cfg.Model, usage)
+	}
+
+	return content, nil
+}
+
+// readResponse 解析非流式响应
+func readResponse(r io.Reader) (string, Usage, error) {
+	// 读取响应
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("读取响应失败: %w", err)
 	}
 
 	// 解析响应
@@ -212,21 +332,97 @@ func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
+		return "", Usage{}, fmt.Errorf("解析响应失败: %w", err)
 	}
 
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("API返回空响应")
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return "", result.Usage, errEmptyResponse
 	}
+	return result.Choices[0].Message.Content, result.Usage, nil
+}
+
+// readStream 解析流式响应（SSE，每行 "data: {...}"，以 "data: [DONE]" 结束），收到第一段内容时调用onFirst
+func readStream(r io.Reader, onFirst func()) (string, Usage, error) {
+	var (
+		sb    strings.Builder
+		usage Usage
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// 空行和注释（如 ": keep-alive"）跳过
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
 
-	return result.Choices[0].Message.Content, nil
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *Usage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", usage, fmt.Errorf("解析流式响应失败: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if sb.Len() == 0 {
+				onFirst()
+			}
+			sb.WriteString(choice.Delta.Content)
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", usage, fmt.Errorf("读取流式响应失败: %w", err)
+	}
+
+	if sb.Len() == 0 {
+		return "", usage, errEmptyResponse
+	}
+	return sb.String(), usage, nil
+}
+
+// parseRetryAfter 解析Retry-After响应头（秒数或HTTP日期，无法解析时返回0）
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
 }
 
 // isRetryableError 判断错误是否可重试
 func isRetryableError(err error) bool {
+	// 限频和服务端错误可以重试，其他HTTP错误（密钥无效、请求格式错误等）重试也不会成功
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	// 空响应（JSON模式下偶尔出现）可以重试
+	if errors.Is(err, errEmptyResponse) {
+		return true
+	}
 	errStr := err.Error()
 	// 网络错误、超时、EOF等可以重试
 	retryableErrors := []string{
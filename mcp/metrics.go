@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Metrics AI调用统计（进程启动以来）
+type Metrics struct {
+	Calls       int `json:"calls"`        // 调用次数（每次CallWithMessages，含其中的重试）
+	Failures    int `json:"failures"`     // 重试后仍失败的调用
+	Requests    int `json:"requests"`     // HTTP请求次数（含重试）
+	Retries     int `json:"retries"`      // 重试次数
+	RateLimited int `json:"rate_limited"` // 限频（429）响应次数
+	ServerError int `json:"server_error"` // 服务端错误（5xx）响应次数
+
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`       // 按内置模型单价估算的成本（美元）
+	CostKnown        bool    `json:"cost_known"` // 模型有内置单价时为true
+
+	LatencySeconds   float64   `json:"latency_seconds"`               // 成功请求的总耗时（秒）
+	LatencyCount     int       `json:"latency_count"`                 // 成功请求数
+	AvgLatencyMs     int64     `json:"avg_latency_ms"`                // 成功请求的平均耗时
+	LastLatencyMs    int64     `json:"last_latency_ms"`               // 最近一次成功请求的耗时
+	LastFirstTokenMs int64     `json:"last_first_token_ms,omitempty"` // 流式输出时最近一次收到首个token的耗时
+	LastError        string    `json:"last_error,omitempty"`          // 最近一次失败调用的错误
+	LastErrorAt      time.Time `json:"last_error_at,omitzero"`        // 最近一次失败调用的时间
+
+	Model    string `json:"model"`
+	Stream   bool   `json:"stream"`
+	JSONMode bool   `json:"json_mode"`
+}
+
+// metrics 调用统计计数器（nil时不记录）
+type metrics struct {
+	mu sync.Mutex
+	m  Metrics
+}
+
+// newMetrics 创建调用统计
+func newMetrics() *metrics {
+	return &metrics{m: Metrics{CostKnown: true}}
+}
+
+// request 记录一次HTTP请求（latency为0表示请求未发出；成功时计入延迟）
+func (s *metrics) request(latency, firstToken time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Requests++
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == 429 {
+			s.m.RateLimited++
+		} else if apiErr.StatusCode >= 500 {
+			s.m.ServerError++
+		}
+	}
+	if err != nil {
+		return
+	}
+	s.m.LatencySeconds += latency.Seconds()
+	s.m.LatencyCount++
+	s.m.LastLatencyMs = latency.Milliseconds()
+	if firstToken > 0 {
+		s.m.LastFirstTokenMs = firstToken.Milliseconds()
+	}
+}
+
+// usage 记录一次成功请求的token消耗和成本
+func (s *metrics) usage(model string, usage Usage) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.PromptTokens += usage.PromptTokens
+	s.m.CompletionTokens += usage.CompletionTokens
+	if price, ok := LookupPrice(model, nil); ok {
+		s.m.Cost += price.Cost(usage.PromptTokens, usage.CompletionTokens)
+	} else {
+		s.m.CostKnown = false
+	}
+}
+
+// retry 记录一次重试
+func (s *metrics) retry() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.m.Retries++
+	s.mu.Unlock()
+}
+
+// call 记录一次调用的最终结果
+func (s *metrics) call(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Calls++
+	if err != nil {
+		s.m.Failures++
+		s.m.LastError = err.Error()
+		s.m.LastErrorAt = time.Now()
+	}
+}
+
+// Metrics 调用统计的快照
+func (cfg *Client) Metrics() Metrics {
+	var m Metrics
+	if cfg.metrics != nil {
+		cfg.metrics.mu.Lock()
+		m = cfg.metrics.m
+		cfg.metrics.mu.Unlock()
+	}
+	if m.LatencyCount > 0 {
+		m.AvgLatencyMs = int64(m.LatencySeconds * 1000 / float64(m.LatencyCount))
+	}
+	m.Model, m.Stream, m.JSONMode = cfg.Model, cfg.Stream, cfg.JSONMode
+	return m
+}
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxAttemptsLimit 最多尝试次数上限（避免AI长时间不可用时一个决策周期无限拖延）
+const maxAttemptsLimit = 10
+
+// Options AI客户端选项（重试退避、超时、流式输出、JSON模式），零值表示使用默认值
+type Options struct {
+	MaxAttempts       int  `json:"max_attempts,omitempty"`        // 最多尝试次数（含首次，默认3；网络错误、429和5xx时重试，1表示不重试）
+	MaxBackoffSeconds int  `json:"max_backoff_seconds,omitempty"` // 重试等待上限（秒，默认30；等待从2秒起翻倍，服务端返回Retry-After时按其等待）
+	TimeoutSeconds    int  `json:"timeout_seconds,omitempty"`     // 单次请求超时（秒，默认120）
+	Stream            bool `json:"stream,omitempty"`              // 流式输出（边生成边接收，记录首个token耗时）
+	JSONMode          bool `json:"json_mode,omitempty"`           // 强制JSON输出（response_format=json_object，决策改为输出 {"cot_trace", "decisions"} 对象）
+}
+
+// Validate 检查配置
+func (o Options) Validate() error {
+	if o.MaxAttempts < 0 || o.MaxBackoffSeconds < 0 || o.TimeoutSeconds < 0 {
+		return fmt.Errorf("llm.max_attempts、max_backoff_seconds、timeout_seconds不能为负数")
+	}
+	if o.MaxAttempts > maxAttemptsLimit {
+		return fmt.Errorf("llm.max_attempts不能超过%d: %d", maxAttemptsLimit, o.MaxAttempts)
+	}
+	return nil
+}
+
+// Attempts 最多尝试次数（含首次，默认3）
+func (o Options) Attempts() int {
+	if o.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return o.MaxAttempts
+}
+
+// Backoff 重试等待上限（默认30秒）
+func (o Options) Backoff() time.Duration {
+	if o.MaxBackoffSeconds <= 0 {
+		return defaultMaxBackoff
+	}
+	return time.Duration(o.MaxBackoffSeconds) * time.Second
+}
+
+// Apply 把选项应用到客户端（未设置超时时保持客户端默认超时）
+func (cfg *Client) Apply(o Options) {
+	cfg.MaxAttempts = o.Attempts()
+	cfg.MaxBackoff = o.Backoff()
+	if o.TimeoutSeconds > 0 {
+		cfg.Timeout = time.Duration(o.TimeoutSeconds) * time.Second
+	}
+	cfg.Stream = o.Stream
+	cfg.JSONMode = o.JSONMode
+}
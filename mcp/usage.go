@@ -0,0 +1,41 @@
+package mcp
+
+import "strings"
+
+// Usage 单次调用的token消耗（OpenAI兼容接口返回的usage字段）
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Price 模型单价（美元/百万token）
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// DefaultPrices 内置模型单价（按官方标价，未命中缓存的价格）
+var DefaultPrices = map[string]Price{
+	"deepseek-chat":     {Input: 0.27, Output: 1.10},
+	"deepseek-reasoner": {Input: 0.55, Output: 2.19},
+	"qwen-turbo":        {Input: 0.05, Output: 0.20},
+	"qwen-plus":         {Input: 0.40, Output: 1.20},
+	"qwen-max":          {Input: 1.60, Output: 6.40},
+}
+
+// Cost 按单价估算成本（美元）
+func (p Price) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1e6
+}
+
+// LookupPrice 查找模型单价（overrides优先，模型名不区分大小写；未知模型返回false）
+func LookupPrice(model string, overrides map[string]Price) (Price, bool) {
+	model = strings.ToLower(model)
+	for name, price := range overrides {
+		if strings.ToLower(name) == model {
+			return price, true
+		}
+	}
+	price, ok := DefaultPrices[model]
+	return price, ok
+}
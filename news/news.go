@@ -0,0 +1,401 @@
+package news
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"nofx/logging"
+	"nofx/symbols"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newsLog 新闻模块日志
+var newsLog = logging.For("news")
+
+const (
+	SourceRSS         = "rss"         // RSS 2.0 / Atom 订阅
+	SourceCryptoPanic = "cryptopanic" // CryptoPanic API（带币种标签和投票）
+
+	defaultRefreshMinutes = 10
+	defaultMaxAgeHours    = 24
+	defaultMaxHeadlines   = 5
+	cryptoPanicURL        = "https://cryptopanic.com/api/v1/posts/"
+)
+
+// Config 新闻和情绪模块配置（sources为空表示不启用）
+type Config struct {
+	Sources        []Source            `json:"sources"`
+	RefreshMinutes int                 `json:"refresh_minutes"` // 拉取间隔（分钟，默认10）
+	MaxAgeHours    int                 `json:"max_age_hours"`   // 只统计该时长内的新闻（小时，默认24）
+	MaxHeadlines   int                 `json:"max_headlines"`   // 每个币种写入提示词的新闻条数（默认5）
+	Keywords       map[string][]string `json:"keywords"`        // 额外的币种关键词（如 {"PEPE": ["pepe coin"]}），补充内置别名
+}
+
+// Source 新闻源
+type Source struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`  // rss（默认）/ cryptopanic
+	URL   string `json:"url"`   // RSS地址；cryptopanic可省略
+	Token string `json:"token"` // cryptopanic的auth_token
+	Macro bool   `json:"macro"` // 宏观新闻源：未提及具体币种的新闻作为市场整体背景写入提示词
+}
+
+// Enabled 是否配置了新闻源
+func (c Config) Enabled() bool {
+	return len(c.Sources) > 0
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	if c.RefreshMinutes < 0 || c.MaxAgeHours < 0 || c.MaxHeadlines < 0 {
+		return fmt.Errorf("news.refresh_minutes、max_age_hours、max_headlines不能为负数")
+	}
+	names := make(map[string]bool)
+	for i, s := range c.Sources {
+		name := s.label(i)
+		if names[name] {
+			return fmt.Errorf("news.sources名称重复: %s", name)
+		}
+		names[name] = true
+
+		switch s.Type {
+		case "", SourceRSS:
+			if s.URL == "" {
+				return fmt.Errorf("news.sources[%s]缺少url", name)
+			}
+		case SourceCryptoPanic:
+			if s.Token == "" {
+				return fmt.Errorf("news.sources[%s]缺少token", name)
+			}
+		default:
+			return fmt.Errorf("news.sources[%s]类型无效: %s（可选rss、cryptopanic）", name, s.Type)
+		}
+		if s.URL != "" {
+			u, err := url.Parse(s.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("news.sources[%s]的url无效: %s", name, s.URL)
+			}
+		}
+	}
+	for asset, words := range c.Keywords {
+		if strings.TrimSpace(asset) == "" || len(words) == 0 {
+			return fmt.Errorf("news.keywords[%q]无效", asset)
+		}
+	}
+	return nil
+}
+
+// label 新闻源名称（未命名时使用类型和序号）
+func (s Source) label(i int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	kind := s.Type
+	if kind == "" {
+		kind = SourceRSS
+	}
+	return fmt.Sprintf("%s#%d", kind, i+1)
+}
+
+// Headline 一条新闻标题
+type Headline struct {
+	Title     string    `json:"title"`
+	Source    string    `json:"source"`
+	URL       string    `json:"url"`
+	Published time.Time `json:"published"`
+	Sentiment float64   `json:"sentiment"` // -1~1，正数偏利好
+	Assets    []string  `json:"assets"`    // 新闻源标注的币种（如CryptoPanic），与关键词匹配合并
+
+	macro bool
+	text  string // 小写、按词归一化的标题，用于别名匹配
+	codes map[string]bool
+}
+
+// Summary 某个币种近期新闻的汇总
+type Summary struct {
+	Asset     string     `json:"asset"`
+	Count     int        `json:"count"`     // 时间窗口内提及该币种的新闻数
+	Sentiment float64    `json:"sentiment"` // 平均情绪，-1~1
+	Window    int        `json:"window"`    // 时间窗口（小时）
+	Headlines []Headline `json:"headlines"` // 最新的若干条
+}
+
+// String 用于提示词
+func (s *Summary) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("News (last %dh): %d headlines mentioning %s, average sentiment %+.2f (-1 bearish … +1 bullish)\n",
+		s.Window, s.Count, s.Asset, s.Sentiment))
+	for _, h := range s.Headlines {
+		sb.WriteString(fmt.Sprintf("- [%s, %s, %+.2f] %s\n", h.Published.UTC().Format("01-02 15:04"), h.Source, h.Sentiment, h.Title))
+	}
+	return sb.String()
+}
+
+// Service 新闻服务：后台定时拉取各新闻源，按币种汇总（单个新闻源失败时保留其上次结果）
+type Service struct {
+	cfg      Config
+	keywords map[string][]string // 币种 → 小写别名
+
+	mu       sync.RWMutex
+	bySource map[string][]Headline
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewService 创建新闻服务（配置应已通过Validate）
+func NewService(cfg Config) *Service {
+	if cfg.RefreshMinutes == 0 {
+		cfg.RefreshMinutes = defaultRefreshMinutes
+	}
+	if cfg.MaxAgeHours == 0 {
+		cfg.MaxAgeHours = defaultMaxAgeHours
+	}
+	if cfg.MaxHeadlines == 0 {
+		cfg.MaxHeadlines = defaultMaxHeadlines
+	}
+	keywords := make(map[string][]string)
+	for asset, names := range assetAliases {
+		keywords[asset] = append(keywords[asset], names...)
+	}
+	for asset, words := range cfg.Keywords {
+		asset = strings.ToUpper(strings.TrimSpace(asset))
+		for _, w := range words {
+			if w = normalizeText(w); w != "" {
+				keywords[asset] = append(keywords[asset], w)
+			}
+		}
+	}
+	return &Service{
+		cfg:      cfg,
+		keywords: keywords,
+		bySource: make(map[string][]Headline),
+		client:   &http.Client{Timeout: 15 * time.Second},
+		now:      time.Now,
+	}
+}
+
+// defaultService 进程内共享的新闻服务（未启用时为nil）
+var (
+	defaultService *Service
+	defaultMu      sync.RWMutex
+)
+
+// SetDefault 设置进程内共享的新闻服务（nil表示不启用）
+func SetDefault(s *Service) {
+	defaultMu.Lock()
+	defaultService = s
+	defaultMu.Unlock()
+}
+
+// Default 进程内共享的新闻服务（未启用时为nil）
+func Default() *Service {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultService
+}
+
+// ForSymbol 共享新闻服务中某个币种的汇总（未启用或没有相关新闻时返回nil）
+func ForSymbol(symbol string) *Summary {
+	return Default().Summary(symbol)
+}
+
+// Macro 共享新闻服务中宏观新闻源的最新若干条（未启用时返回nil）
+func Macro() []Headline {
+	return Default().Macro()
+}
+
+// Start 立即拉取一次，之后每refresh_minutes拉取一次，直到stop关闭
+func (s *Service) Start(stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.cfg.RefreshMinutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			s.Refresh()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh 拉取所有新闻源
+func (s *Service) Refresh() {
+	for i, src := range s.cfg.Sources {
+		name := src.label(i)
+		headlines, err := s.fetch(src, name)
+		if err != nil {
+			newsLog.Warn("拉取新闻源失败，保留上次结果", "source", name, "err", err)
+			continue
+		}
+		for j := range headlines {
+			h := &headlines[j]
+			h.macro = src.Macro
+			h.text = " " + normalizeText(h.Title) + " "
+			h.codes = codeTokens(h.Title, h.Assets)
+			if h.Sentiment == 0 {
+				h.Sentiment = scoreHeadline(h.Title)
+			}
+		}
+		s.mu.Lock()
+		s.bySource[name] = headlines
+		s.mu.Unlock()
+		newsLog.Debug("新闻源已更新", "source", name, "headlines", len(headlines))
+	}
+}
+
+// fetch 按新闻源类型拉取
+func (s *Service) fetch(src Source, name string) ([]Headline, error) {
+	switch src.Type {
+	case SourceCryptoPanic:
+		return s.fetchCryptoPanic(src, name)
+	default:
+		return s.fetchRSS(src, name)
+	}
+}
+
+// Summary 某个币种在时间窗口内的新闻汇总（nil服务或没有相关新闻时返回nil）
+func (s *Service) Summary(symbol string) *Summary {
+	if s == nil {
+		return nil
+	}
+	asset := symbols.Base(symbol)
+	var matched []Headline
+	for _, h := range s.recent() {
+		if s.mentions(h, asset) {
+			matched = append(matched, h)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	summary := &Summary{Asset: asset, Count: len(matched), Window: s.cfg.MaxAgeHours}
+	for _, h := range matched {
+		summary.Sentiment += h.Sentiment
+	}
+	summary.Sentiment /= float64(len(matched))
+	summary.Headlines = matched
+	if len(matched) > s.cfg.MaxHeadlines {
+		summary.Headlines = matched[:s.cfg.MaxHeadlines]
+	}
+	return summary
+}
+
+// Macro 宏观新闻源中未提及已知币种的最新若干条（nil服务时返回nil）
+func (s *Service) Macro() []Headline {
+	if s == nil {
+		return nil
+	}
+	var macro []Headline
+	for _, h := range s.recent() {
+		if h.macro && !s.mentionsAny(h) {
+			macro = append(macro, h)
+			if len(macro) == s.cfg.MaxHeadlines {
+				break
+			}
+		}
+	}
+	return macro
+}
+
+// recent 时间窗口内的新闻，按发布时间从新到旧，同一标题只保留一条
+func (s *Service) recent() []Headline {
+	cutoff := s.now().Add(-time.Duration(s.cfg.MaxAgeHours) * time.Hour)
+	s.mu.RLock()
+	var all []Headline
+	for _, headlines := range s.bySource {
+		for _, h := range headlines {
+			if !h.Published.Before(cutoff) {
+				all = append(all, h)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Published.After(all[j].Published) })
+	seen := make(map[string]bool, len(all))
+	unique := all[:0]
+	for _, h := range all {
+		if key := strings.TrimSpace(h.text); !seen[key] {
+			seen[key] = true
+			unique = append(unique, h)
+		}
+	}
+	return unique
+}
+
+// mentions 新闻是否提及币种：新闻源标注、标题中的大写代码（BTC、$ETH）或别名（bitcoin）
+func (s *Service) mentions(h Headline, asset string) bool {
+	if h.codes[asset] {
+		return true
+	}
+	for _, alias := range s.keywords[asset] {
+		if strings.Contains(h.text, " "+alias+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsAny 新闻是否提及任何已知币种（内置别名或配置了关键词的币种）
+func (s *Service) mentionsAny(h Headline) bool {
+	for asset := range s.keywords {
+		if s.mentions(h, asset) {
+			return true
+		}
+	}
+	return false
+}
+
+// assetAliases 常见币种的名称别名（小写，按词匹配）
+var assetAliases = map[string][]string{
+	"BTC":  {"bitcoin", "btc"},
+	"ETH":  {"ethereum", "ether", "eth"},
+	"SOL":  {"solana"},
+	"BNB":  {"bnb", "bnb chain"},
+	"XRP":  {"xrp", "ripple"},
+	"DOGE": {"dogecoin", "doge"},
+	"ADA":  {"cardano"},
+	"AVAX": {"avalanche", "avax"},
+	"LINK": {"chainlink"},
+	"DOT":  {"polkadot"},
+	"LTC":  {"litecoin"},
+	"TRX":  {"tron"},
+	"TON":  {"toncoin"},
+	"SUI":  {"sui network"},
+	"HYPE": {"hyperliquid"},
+	"PEPE": {"pepe"},
+	"SHIB": {"shiba inu"},
+}
+
+// normalizeText 小写并按非字母数字切分后用单个空格连接
+func normalizeText(s string) string {
+	return strings.Join(words(strings.ToLower(s)), " ")
+}
+
+// codeTokens 标题中的全大写代码（长度2-10）加上新闻源标注的币种
+func codeTokens(title string, assets []string) map[string]bool {
+	codes := make(map[string]bool)
+	for _, a := range assets {
+		codes[strings.ToUpper(a)] = true
+	}
+	for _, w := range words(title) {
+		if len(w) >= 2 && len(w) <= 10 && w == strings.ToUpper(w) && strings.ToLower(w) != w {
+			codes[w] = true
+		}
+	}
+	return codes
+}
+
+// words 按非字母数字切分
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
@@ -0,0 +1,39 @@
+package news
+
+import "strings"
+
+// bullishStems、bearishStems 标题情绪词典（按词前缀匹配，如surge匹配surges/surged）
+var (
+	bullishStems = []string{
+		"surge", "soar", "rally", "bullish", "gain", "jump", "rebound", "recover", "breakout", "record",
+		"approv", "adopt", "partnership", "upgrade", "inflow", "rise", "rising", "climb", "boost", "optimis",
+		"accumulat", "buyback", "etf approv", "rate cut", "cuts rate", "dovish",
+	}
+	bearishStems = []string{
+		"plunge", "crash", "drop", "fall", "fell", "bearish", "slump", "tumble", "sink", "sell-off", "selloff",
+		"hack", "exploit", "lawsuit", "sue", "ban", "outflow", "liquidat", "fraud", "delist", "reject",
+		"fear", "warn", "crackdown", "probe", "investigat", "default", "bankrupt", "insolv", "halt",
+		"rate hike", "hikes rate", "hawkish", "recession",
+	}
+)
+
+// scoreHeadline 按词典给标题打分：(利好词数-利空词数)/(利好词数+利空词数)，-1~1，没有命中时为0
+func scoreHeadline(title string) float64 {
+	text := " " + normalizeText(strings.ReplaceAll(title, "-", "")) + " "
+	bullish, bearish := countStems(text, bullishStems), countStems(text, bearishStems)
+	if bullish+bearish == 0 {
+		return 0
+	}
+	return float64(bullish-bearish) / float64(bullish+bearish)
+}
+
+// countStems 以词开头匹配的词干个数（多词词干按相邻词匹配）
+func countStems(text string, stems []string) int {
+	n := 0
+	for _, stem := range stems {
+		if strings.Contains(text, " "+normalizeText(strings.ReplaceAll(stem, "-", ""))) {
+			n++
+		}
+	}
+	return n
+}
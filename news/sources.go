@@ -0,0 +1,202 @@
+package news
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxBodyBytes 单次响应读取上限
+const maxBodyBytes = 4 << 20
+
+// feed RSS 2.0（rss>channel>item）和Atom（feed>entry）共用的解析结构
+type feed struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+		Date    string `xml:"date"` // dc:date
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// fetchRSS 拉取RSS/Atom订阅（没有发布时间的条目按拉取时间计）
+func (s *Service) fetchRSS(src Source, name string) ([]Headline, error) {
+	body, err := s.get(src.URL)
+	if err != nil {
+		return nil, err
+	}
+	var f feed
+	if err := xml.Unmarshal(body, &f); err != nil {
+		return nil, fmt.Errorf("解析RSS失败: %w", err)
+	}
+
+	now := s.now()
+	var headlines []Headline
+	add := func(title, link, published string) {
+		title = strings.TrimSpace(html.UnescapeString(title))
+		if title == "" {
+			return
+		}
+		t, ok := parseTime(published)
+		if !ok {
+			t = now
+		}
+		headlines = append(headlines, Headline{Title: title, Source: name, URL: strings.TrimSpace(link), Published: t})
+	}
+	for _, item := range f.Items {
+		published := item.PubDate
+		if published == "" {
+			published = item.Date
+		}
+		add(item.Title, item.Link, published)
+	}
+	for _, entry := range f.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		add(entry.Title, link, published)
+	}
+	return headlines, nil
+}
+
+// cryptoPanicResponse CryptoPanic posts接口响应
+type cryptoPanicResponse struct {
+	Results []struct {
+		Title       string `json:"title"`
+		URL         string `json:"url"`
+		PublishedAt string `json:"published_at"`
+		Source      struct {
+			Title string `json:"title"`
+		} `json:"source"`
+		Currencies []struct {
+			Code string `json:"code"`
+		} `json:"currencies"`
+		Votes struct {
+			Positive int `json:"positive"`
+			Negative int `json:"negative"`
+			Liked    int `json:"liked"`
+			Disliked int `json:"disliked"`
+		} `json:"votes"`
+	} `json:"results"`
+}
+
+// fetchCryptoPanic 拉取CryptoPanic新闻：币种取自接口标注，有投票时情绪取投票结果，否则按标题词典打分
+func (s *Service) fetchCryptoPanic(src Source, name string) ([]Headline, error) {
+	endpoint := src.URL
+	if endpoint == "" {
+		endpoint = cryptoPanicURL
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("auth_token", src.Token)
+	if q.Get("public") == "" {
+		q.Set("public", "true")
+	}
+	u.RawQuery = q.Encode()
+
+	body, err := s.get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	var resp cryptoPanicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析CryptoPanic响应失败: %w", err)
+	}
+
+	now := s.now()
+	headlines := make([]Headline, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		if strings.TrimSpace(r.Title) == "" {
+			continue
+		}
+		h := Headline{Title: strings.TrimSpace(r.Title), Source: name, URL: r.URL}
+		if r.Source.Title != "" {
+			h.Source = name + "/" + r.Source.Title
+		}
+		if t, ok := parseTime(r.PublishedAt); ok {
+			h.Published = t
+		} else {
+			h.Published = now
+		}
+		for _, c := range r.Currencies {
+			h.Assets = append(h.Assets, c.Code)
+		}
+		bullish := r.Votes.Positive + r.Votes.Liked
+		bearish := r.Votes.Negative + r.Votes.Disliked
+		if bullish+bearish > 0 {
+			h.Sentiment = float64(bullish-bearish) / float64(bullish+bearish)
+		}
+		headlines = append(headlines, h)
+	}
+	return headlines, nil
+}
+
+// get 发起GET请求并读取响应（非2xx视为失败）
+func (s *Service) get(rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "nofx-news/1.0")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+}
+
+// timeLayouts RSS/Atom/API中常见的时间格式
+var timeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05",
+}
+
+// parseTime 按常见格式解析时间
+func parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,342 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"nofx/logging"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var logger = logging.For("notify")
+
+const (
+	defaultRateLimit = 20  // 默认每分钟最多发送的消息数
+	channelQueueLen  = 100 // 发送队列长度（满时丢弃）
+)
+
+// defaultTemplate 默认消息模板（与Event.Text一致）
+const defaultTemplate = `{{.Kind.Icon}} [{{.TraderID}}] {{.Title}}{{if .Message}}
+{{.Message}}{{end}}{{if .Reason}}
+#{{.Reason}}{{end}}`
+
+// templateFuncs 模板中可用的函数（json: 将任意值编码为JSON，用于在JSON载荷模板中安全地嵌入字符串）
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseTemplate 解析消息模板（带templateFuncs，后端可用于校验模板的渲染结果）
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// ChannelConfig 各通知渠道共用的配置（嵌入到Telegram/Discord/Slack配置中）
+type ChannelConfig struct {
+	Events             []string          `json:"events"`                // 推送的事件类型（为空则全部推送），如["entry","exit","stop_loss","risk","error"]
+	Templates          map[string]string `json:"templates"`             // 按事件类型自定义消息模板（Go text/template，key为事件类型或default）
+	RateLimitPerMinute int               `json:"rate_limit_per_minute"` // 每分钟最多发送的消息数（默认20，超出的消息合并计数后丢弃）
+}
+
+// Validate 验证渠道配置（模板语法、事件类型）
+func (c *ChannelConfig) Validate() error {
+	if c.RateLimitPerMinute <= 0 {
+		c.RateLimitPerMinute = defaultRateLimit
+	}
+	for _, kind := range c.Events {
+		if !validKind(Kind(kind)) {
+			return fmt.Errorf("未知的通知事件类型: %s", kind)
+		}
+	}
+	for key, text := range c.Templates {
+		if key != "default" && !validKind(Kind(key)) {
+			return fmt.Errorf("未知的模板事件类型: %s", key)
+		}
+		if _, err := ParseTemplate(key, text); err != nil {
+			return fmt.Errorf("模板 %s 语法错误: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// SendFunc 发送一条消息（attachment为nil表示无附件）
+type SendFunc func(text string, attachment *Attachment) error
+
+// EventSendFunc 发送一条消息，可读取原始事件（如附件和操作按钮）
+type EventSendFunc func(text string, e Event) error
+
+// Channel 通用通知渠道：事件过滤 → 模板渲染 → 限流 → 异步发送
+// 各后端只需提供发送函数
+type Channel struct {
+	name      string
+	events    map[Kind]bool
+	templates map[Kind]*template.Template
+	fallback  *template.Template
+	limiter   *rateLimiter
+	send      EventSendFunc
+	payload   bool // 渲染结果为结构化载荷（JSON），不能追加文字说明
+	queue     chan Event
+	stopCh    chan struct{}
+	once      sync.Once
+}
+
+// NewChannel 创建通知渠道（需调用Start启动发送）
+func NewChannel(name string, config ChannelConfig, send SendFunc) (*Channel, error) {
+	return NewEventChannel(name, config, func(text string, e Event) error {
+		return send(text, e.Attachment)
+	})
+}
+
+// NewEventChannel 创建通知渠道，发送函数可读取原始事件（支持操作按钮的渠道使用）
+func NewEventChannel(name string, config ChannelConfig, send EventSendFunc) (*Channel, error) {
+	return newChannel(name, config, defaultTemplate, false, send)
+}
+
+// NewPayloadChannel 创建发送结构化载荷的通知渠道（如通用Webhook的JSON）
+// fallback为未按事件类型配置模板时使用的默认模板；限流丢弃的数量只记录日志，不追加到载荷中
+func NewPayloadChannel(name string, config ChannelConfig, fallback string, send EventSendFunc) (*Channel, error) {
+	return newChannel(name, config, fallback, true, send)
+}
+
+func newChannel(name string, config ChannelConfig, fallback string, payload bool, send EventSendFunc) (*Channel, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	c := &Channel{
+		name:      name,
+		templates: make(map[Kind]*template.Template),
+		fallback:  template.Must(ParseTemplate("default", fallback)),
+		limiter:   newRateLimiter(config.RateLimitPerMinute),
+		send:      send,
+		payload:   payload,
+		queue:     make(chan Event, channelQueueLen),
+		stopCh:    make(chan struct{}),
+	}
+	if len(config.Events) > 0 {
+		c.events = make(map[Kind]bool)
+		for _, kind := range config.Events {
+			c.events[Kind(kind)] = true
+		}
+	}
+	for key, text := range config.Templates {
+		tmpl := template.Must(ParseTemplate(key, text))
+		if key == "default" {
+			c.fallback = tmpl
+		} else {
+			c.templates[Kind(key)] = tmpl
+		}
+	}
+	return c, nil
+}
+
+// Start 启动发送协程
+func (c *Channel) Start() {
+	go c.loop()
+}
+
+// Stop 停止发送
+func (c *Channel) Stop() {
+	c.once.Do(func() { close(c.stopCh) })
+}
+
+// Notify 推送事件（非阻塞，未订阅的事件类型忽略，队列满时丢弃）
+func (c *Channel) Notify(e Event) {
+	if c.events != nil && !c.events[e.Kind] {
+		return
+	}
+	select {
+	case c.queue <- e:
+	default:
+		logger.Warn("通知队列已满，丢弃消息", "channel", c.name, "kind", e.Kind, "title", e.Title)
+	}
+}
+
+// loop 串行发送（限流期间的消息只计数，下一条消息附带被丢弃的数量）
+func (c *Channel) loop() {
+	suppressed := 0
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case e := <-c.queue:
+			if !c.limiter.allow(time.Now()) {
+				suppressed++
+				continue
+			}
+			text := c.render(e)
+			if suppressed > 0 {
+				if c.payload {
+					logger.Warn("限流期间已丢弃通知", "channel", c.name, "count", suppressed)
+				} else {
+					text += fmt.Sprintf("\n（限流期间已丢弃%d条通知）", suppressed)
+				}
+				suppressed = 0
+			}
+			if err := c.send(text, e); err != nil {
+				logger.Warn("发送通知失败", "channel", c.name, "kind", e.Kind, "err", err)
+			}
+		}
+	}
+}
+
+// render 按事件类型渲染消息（模板执行失败时退回默认格式）
+func (c *Channel) render(e Event) string {
+	tmpl, ok := c.templates[e.Kind]
+	if !ok {
+		tmpl = c.fallback
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, e); err != nil {
+		logger.Warn("渲染通知模板失败", "channel", c.name, "kind", e.Kind, "err", err)
+		if c.payload {
+			data, _ := json.Marshal(e)
+			return string(data)
+		}
+		return e.Text()
+	}
+	return sb.String()
+}
+
+// Multi 同时推送到多个渠道
+type Multi []Notifier
+
+// Notify 推送到所有渠道
+func (m Multi) Notify(e Event) {
+	for _, n := range m {
+		n.Notify(e)
+	}
+}
+
+// Switch 可替换的通知渠道（热加载时重建渠道，已创建的trader无需重新设置）
+type Switch struct {
+	current Notifier
+	mu      sync.RWMutex
+}
+
+// Set 替换当前渠道（nil表示不推送）
+func (s *Switch) Set(n Notifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = n
+}
+
+// Notify 推送到当前渠道
+func (s *Switch) Notify(e Event) {
+	s.mu.RLock()
+	current := s.current
+	s.mu.RUnlock()
+	if current != nil {
+		current.Notify(e)
+	}
+}
+
+// rateLimiter 令牌桶限流（容量和每分钟补充量均为limit）
+type rateLimiter struct {
+	limit  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{limit: float64(perMinute), tokens: float64(perMinute)}
+}
+
+// allow 是否允许发送（只在发送协程中调用，无需加锁）
+func (r *rateLimiter) allow(now time.Time) bool {
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Minutes() * r.limit
+		if r.tokens > r.limit {
+			r.tokens = r.limit
+		}
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// httpClient Webhook类渠道共用的HTTP客户端
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// PostJSON 向Webhook地址POST JSON（非2xx视为失败）
+func PostJSON(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return PostBody(url, body, nil)
+}
+
+// PostBody 向地址POST已编码的JSON请求体，headers为附加的请求头（非2xx视为失败）
+func PostBody(url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	return nil
+}
+
+// PostMultipart 向地址POST multipart表单（fields为普通字段，附件作为fileField上传；非2xx视为失败）
+func PostMultipart(url string, fields map[string]string, fileField string, attachment *Attachment) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	part, err := w.CreateFormFile(fileField, attachment.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(attachment.Data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(url, w.FormDataContentType(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	return nil
+}
+
+// validKind 是否为已定义的事件类型
+func validKind(k Kind) bool {
+	switch k {
+	case KindEntry, KindExit, KindStopLoss, KindTakeProfit, KindRisk, KindError, KindInfo, KindSummary:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/notify"
+	"strings"
+)
+
+// maxContentLen Discord单条消息的最大长度
+const maxContentLen = 2000
+
+// Config Discord Webhook配置
+type Config struct {
+	Enabled    bool   `json:"enabled"`
+	WebhookURL string `json:"webhook_url"` // 频道设置 → 整合 → Webhook 中创建
+	Username   string `json:"username"`    // 覆盖Webhook显示名称（可选）
+	notify.ChannelConfig
+}
+
+// Validate 验证Discord配置
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if !strings.HasPrefix(c.WebhookURL, "https://") {
+		return fmt.Errorf("discord.webhook_url必须是https地址")
+	}
+	if err := c.ChannelConfig.Validate(); err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return nil
+}
+
+// New 创建Discord通知渠道（需调用Start启动发送）
+func New(config Config) (*notify.Channel, error) {
+	return notify.NewChannel("discord", config.ChannelConfig, func(text string, attachment *notify.Attachment) error {
+		if runes := []rune(text); len(runes) > maxContentLen {
+			text = string(runes[:maxContentLen-3]) + "..."
+		}
+		payload := map[string]interface{}{"content": text}
+		if config.Username != "" {
+			payload["username"] = config.Username
+		}
+		if attachment == nil {
+			return notify.PostJSON(config.WebhookURL, payload)
+		}
+
+		// 带附件时使用multipart上传，消息内容放在payload_json字段
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		return notify.PostMultipart(config.WebhookURL, map[string]string{"payload_json": string(payloadJSON)},
+			"files[0]", attachment)
+	})
+}
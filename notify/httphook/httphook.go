@@ -0,0 +1,102 @@
+package httphook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"nofx/notify"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPayload 未配置模板时发送的载荷（事件的全部字段）
+const defaultPayload = `{{json .}}`
+
+// Config 通用Webhook通知配置：按事件类型渲染JSON载荷，POST到任意地址
+type Config struct {
+	Enabled bool              `json:"enabled"`
+	Name    string            `json:"name"`    // 渠道名称（日志和热加载中区分多个Webhook）
+	URL     string            `json:"url"`     // 接收地址
+	Secret  string            `json:"secret"`  // HMAC-SHA256签名密钥（为空不签名）
+	Headers map[string]string `json:"headers"` // 附加的请求头（如Authorization）
+	notify.ChannelConfig
+}
+
+// Validate 验证Webhook配置（模板须渲染出合法的JSON）
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Name == "" {
+		return fmt.Errorf("notify_webhooks: name不能为空")
+	}
+	if !strings.HasPrefix(c.URL, "https://") && !strings.HasPrefix(c.URL, "http://") {
+		return fmt.Errorf("notify_webhooks[%s]: url必须是http或https地址", c.Name)
+	}
+	for name := range c.Headers {
+		if strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, signatureHeader) ||
+			strings.EqualFold(name, timestampHeader) || strings.EqualFold(name, eventHeader) {
+			return fmt.Errorf("notify_webhooks[%s]: 请求头%s由系统设置，不能覆盖", c.Name, name)
+		}
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("notify_webhooks[%s]: 无效的请求头名称%q", c.Name, name)
+		}
+	}
+	if err := c.ChannelConfig.Validate(); err != nil {
+		return fmt.Errorf("notify_webhooks[%s]: %w", c.Name, err)
+	}
+
+	// 用示例事件渲染每个模板，提前发现拼接出的非法JSON（如字符串未用json函数转义）
+	sample := notify.Event{Kind: notify.KindEntry, TraderID: "sample", Symbol: "BTCUSDT",
+		Title: `示例 "标题"`, Message: "第一行\n第二行", Time: time.Now(), Reason: "SAMPLE"}
+	for key, text := range c.Templates {
+		tmpl, err := notify.ParseTemplate(key, text)
+		if err != nil {
+			return fmt.Errorf("notify_webhooks[%s]: 模板 %s 语法错误: %w", c.Name, key, err)
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, sample); err != nil {
+			return fmt.Errorf("notify_webhooks[%s]: 模板 %s 执行失败: %w", c.Name, key, err)
+		}
+		if !json.Valid([]byte(sb.String())) {
+			return fmt.Errorf("notify_webhooks[%s]: 模板 %s 渲染结果不是合法的JSON（字符串字段请使用 {{json .Title}}）", c.Name, key)
+		}
+	}
+	return nil
+}
+
+const (
+	signatureHeader = "X-Signature"      // 十六进制HMAC-SHA256(secret, 时间戳 + "." + 请求体)
+	timestampHeader = "X-Nofx-Timestamp" // 签名时间（Unix秒），接收方可据此拒绝重放的旧请求
+	eventHeader     = "X-Nofx-Event"     // 事件类型（entry/exit/...），便于接收方不解析载荷直接路由
+)
+
+// Sign 计算请求签名（十六进制HMAC-SHA256，签名内容为"时间戳.请求体"）
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// New 创建通用Webhook通知渠道（需调用Start启动发送）
+// 附件和操作按钮不发送（默认载荷中包含actions字段）
+func New(config Config) (*notify.Channel, error) {
+	return notify.NewPayloadChannel("webhook:"+config.Name, config.ChannelConfig, defaultPayload, func(text string, e notify.Event) error {
+		body := []byte(text)
+		headers := map[string]string{eventHeader: string(e.Kind)}
+		for name, value := range config.Headers {
+			headers[name] = value
+		}
+		if config.Secret != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			headers[timestampHeader] = timestamp
+			headers[signatureHeader] = Sign(config.Secret, timestamp, body)
+		}
+		return notify.PostBody(config.URL, body, headers)
+	})
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind 通知事件类型
+type Kind string
+
+const (
+	KindEntry      Kind = "entry"       // 开仓成交
+	KindExit       Kind = "exit"        // 主动平仓成交
+	KindStopLoss   Kind = "stop_loss"   // 止损触发
+	KindTakeProfit Kind = "take_profit" // 止盈触发
+	KindRisk       Kind = "risk"        // 触发风控限制（回撤熔断、敞口上限等）
+	KindError      Kind = "error"       // 执行错误
+	KindInfo       Kind = "info"        // 一般信息（暂停/恢复等）
+	KindSummary    Kind = "summary"     // 每日/每周汇总报告
+)
+
+// Event 通知事件
+type Event struct {
+	Kind     Kind      `json:"kind"`
+	TraderID string    `json:"trader_id"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+	Reason   string    `json:"reason,omitempty"` // 自动操作的原因代码（如RISK_DRAWDOWN），消息末尾显示为 #RISK_DRAWDOWN 便于按原因搜索
+
+	// Attachment 附件（如汇总报告的交易CSV，不支持附件的渠道只发送文本）
+	Attachment *Attachment `json:"-"`
+
+	// Actions 操作按钮（Telegram显示为按钮，点击后执行对应命令；其他渠道忽略，消息正文中应包含等效命令）
+	Actions []Action `json:"actions,omitempty"`
+}
+
+// Action 通知中的操作按钮
+type Action struct {
+	Label   string `json:"label"`
+	Command string `json:"command"` // 点击后执行的机器人命令（如 /confirm 3）
+}
+
+// Attachment 通知附件
+type Attachment struct {
+	Name string // 文件名（如 trades_daily.csv）
+	Data []byte
+}
+
+// Notifier 通知渠道（实现需保证Notify不阻塞交易流程）
+type Notifier interface {
+	Notify(e Event)
+}
+
+// Icon 事件类型对应的图标
+func (k Kind) Icon() string {
+	switch k {
+	case KindEntry:
+		return "🟢"
+	case KindExit:
+		return "🔵"
+	case KindStopLoss:
+		return "🛑"
+	case KindTakeProfit:
+		return "🎯"
+	case KindRisk:
+		return "⚠️"
+	case KindError:
+		return "❌"
+	case KindSummary:
+		return "📊"
+	default:
+		return "ℹ️"
+	}
+}
+
+// Text 纯文本格式的消息内容
+func (e Event) Text() string {
+	text := fmt.Sprintf("%s [%s] %s", e.Kind.Icon(), e.TraderID, e.Title)
+	if e.Message != "" {
+		text += "\n" + e.Message
+	}
+	if e.Reason != "" {
+		text += "\n#" + e.Reason
+	}
+	return text
+}
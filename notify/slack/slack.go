@@ -0,0 +1,36 @@
+package slack
+
+import (
+	"fmt"
+	"nofx/notify"
+	"strings"
+)
+
+// Config Slack Incoming Webhook配置
+type Config struct {
+	Enabled    bool   `json:"enabled"`
+	WebhookURL string `json:"webhook_url"` // Slack App → Incoming Webhooks 中创建
+	notify.ChannelConfig
+}
+
+// Validate 验证Slack配置
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if !strings.HasPrefix(c.WebhookURL, "https://") {
+		return fmt.Errorf("slack.webhook_url必须是https地址")
+	}
+	if err := c.ChannelConfig.Validate(); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// New 创建Slack通知渠道（需调用Start启动发送）
+// Incoming Webhook不支持上传文件，附件会被忽略
+func New(config Config) (*notify.Channel, error) {
+	return notify.NewChannel("slack", config.ChannelConfig, func(text string, _ *notify.Attachment) error {
+		return notify.PostJSON(config.WebhookURL, map[string]string{"text": text})
+	})
+}
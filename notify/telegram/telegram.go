@@ -0,0 +1,362 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"nofx/logging"
+	"nofx/notify"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = logging.For("telegram")
+
+const (
+	apiBase     = "https://api.telegram.org"
+	pollTimeout = 30 // getUpdates长轮询超时（秒）
+)
+
+// Config Telegram机器人配置
+type Config struct {
+	Enabled  bool    `json:"enabled"`
+	BotToken string  `json:"bot_token"` // @BotFather创建机器人时获得的token
+	ChatIDs  []int64 `json:"chat_ids"`  // 接收通知并允许发送命令的聊天ID（白名单）
+	notify.ChannelConfig
+}
+
+// Validate 验证Telegram配置
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.BotToken == "" {
+		return fmt.Errorf("telegram.bot_token不能为空")
+	}
+	if len(c.ChatIDs) == 0 {
+		return fmt.Errorf("telegram.chat_ids不能为空（命令只接受白名单内的聊天）")
+	}
+	if err := c.ChannelConfig.Validate(); err != nil {
+		return fmt.Errorf("telegram: %w", err)
+	}
+	return nil
+}
+
+// Handler 机器人命令的执行者（由trader管理器实现）
+type Handler interface {
+	// Positions 当前持仓概览
+	Positions() string
+
+	// PnL 指定周期的盈亏报表（today/24h/7d/all）
+	PnL(period string) string
+
+	// Portfolio 所有账户各结算币种账本按美元汇总的组合视图
+	Portfolio() string
+
+	// Pause 暂停开仓和AI决策（已有持仓的止损止盈和策略看守照常运行，重启后保持暂停）
+	// cancelOrders为true时同时撤销没有持仓的币种上的挂单
+	Pause(reason string, cancelOrders bool) string
+
+	// Resume 恢复交易
+	Resume() string
+
+	// Flatten 市价平掉指定币种的全部持仓
+	Flatten(symbol string) string
+
+	// Trades 最近的已平仓交易（含ID，tag非空时只列出带该标签的交易）
+	Trades(tag string) string
+
+	// Annotate 修改交易的备注和标签（note为nil时不修改备注）
+	Annotate(id int64, note *string, addTags, removeTags []string) string
+
+	// Recommendations 待确认的平仓建议（软平仓）和开仓建议（开仓确认模式）
+	Recommendations() string
+
+	// ConfirmClose 确认建议：平仓建议执行平仓，开仓建议按原决策开仓
+	ConfirmClose(id int64) string
+
+	// DismissClose 忽略平仓建议或开仓建议
+	DismissClose(id int64) string
+}
+
+// Bot Telegram通知与命令机器人
+type Bot struct {
+	config  Config
+	handler Handler
+	client  *http.Client
+	channel *notify.Channel
+	stopCh  chan struct{}
+	once    sync.Once
+}
+
+// New 创建Telegram机器人（handler为nil时只推送通知，不接受命令）
+func New(config Config, handler Handler) (*Bot, error) {
+	b := &Bot{
+		config:  config,
+		handler: handler,
+		client:  &http.Client{Timeout: (pollTimeout + 10) * time.Second},
+		stopCh:  make(chan struct{}),
+	}
+	channel, err := notify.NewEventChannel("telegram", config.ChannelConfig, b.broadcast)
+	if err != nil {
+		return nil, err
+	}
+	b.channel = channel
+	return b, nil
+}
+
+// Start 启动消息发送和命令轮询
+func (b *Bot) Start() {
+	b.channel.Start()
+	if b.handler != nil {
+		go b.pollLoop()
+	}
+}
+
+// Stop 停止机器人
+func (b *Bot) Stop() {
+	b.channel.Stop()
+	b.once.Do(func() { close(b.stopCh) })
+}
+
+// Notify 推送事件（非阻塞，按配置过滤、渲染和限流）
+func (b *Bot) Notify(e notify.Event) {
+	b.channel.Notify(e)
+}
+
+// broadcast 发送到白名单内的所有聊天（操作按钮显示为消息下方的按钮，附件作为文件紧随消息发送）
+func (b *Bot) broadcast(text string, e notify.Event) error {
+	attachment := e.Attachment
+	var errs []error
+	for _, chatID := range b.config.ChatIDs {
+		if err := b.sendMessage(chatID, text, e.Actions...); err != nil {
+			errs = append(errs, fmt.Errorf("chat %d: %w", chatID, err))
+			continue
+		}
+		if attachment != nil {
+			fields := map[string]string{"chat_id": fmt.Sprint(chatID)}
+			if err := notify.PostMultipart(b.endpoint("sendDocument"), fields, "document", attachment); err != nil {
+				errs = append(errs, fmt.Errorf("chat %d 发送附件: %w", chatID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendMessage 调用sendMessage接口（纯文本，避免Markdown转义问题；actions显示为一行按钮）
+func (b *Bot) sendMessage(chatID int64, text string, actions ...notify.Action) error {
+	payload := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+	if len(actions) > 0 {
+		row := make([]map[string]string, 0, len(actions))
+		for _, a := range actions {
+			row = append(row, map[string]string{"text": a.Label, "callback_data": a.Command})
+		}
+		payload["reply_markup"] = map[string]interface{}{"inline_keyboard": [][]map[string]string{row}}
+	}
+	return b.call("sendMessage", payload)
+}
+
+// call 调用Bot API（JSON请求）
+func (b *Bot) call(method string, payload interface{}) error {
+	body, _ := json.Marshal(payload)
+	resp, err := b.client.Post(b.endpoint(method), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	return nil
+}
+
+// message Telegram消息（只解析需要的字段）
+type message struct {
+	Text string `json:"text"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// update getUpdates返回的消息或按钮点击（只解析需要的字段）
+type update struct {
+	UpdateID      int64    `json:"update_id"`
+	Message       *message `json:"message"`
+	CallbackQuery *struct {
+		ID      string   `json:"id"`
+		Data    string   `json:"data"` // 按钮对应的命令
+		Message *message `json:"message"`
+	} `json:"callback_query"`
+}
+
+// pollLoop 长轮询获取命令
+func (b *Bot) pollLoop() {
+	var offset int64
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		default:
+		}
+
+		updates, err := b.getUpdates(offset)
+		if err != nil {
+			logger.Warn("获取Telegram命令失败", "err", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			msg, text := u.Message, ""
+			if msg != nil {
+				text = msg.Text
+			}
+			if q := u.CallbackQuery; q != nil {
+				// 点击按钮：先应答（停止按钮的加载状态），再按命令执行
+				if err := b.call("answerCallbackQuery", map[string]string{"callback_query_id": q.ID}); err != nil {
+					logger.Warn("应答Telegram按钮失败", "err", err)
+				}
+				msg, text = q.Message, q.Data
+			}
+			if msg == nil || !strings.HasPrefix(text, "/") {
+				continue
+			}
+			if !b.allowed(msg.Chat.ID) {
+				logger.Warn("拒绝白名单外的命令", "chat_id", msg.Chat.ID, "text", text)
+				continue
+			}
+			reply := b.handle(text)
+			if err := b.sendMessage(msg.Chat.ID, reply); err != nil {
+				logger.Warn("回复Telegram命令失败", "chat_id", msg.Chat.ID, "err", err)
+			}
+		}
+	}
+}
+
+// getUpdates 拉取新消息
+func (b *Bot) getUpdates(offset int64) ([]update, error) {
+	params := url.Values{}
+	params.Set("offset", fmt.Sprint(offset))
+	params.Set("timeout", fmt.Sprint(pollTimeout))
+	params.Set("allowed_updates", `["message","callback_query"]`)
+
+	resp, err := b.client.Get(b.endpoint("getUpdates") + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool     `json:"ok"`
+		Description string   `json:"description"`
+		Result      []update `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("%s", result.Description)
+	}
+	return result.Result, nil
+}
+
+// allowed 聊天ID是否在白名单中
+func (b *Bot) allowed(chatID int64) bool {
+	for _, id := range b.config.ChatIDs {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// handle 解析并执行命令
+func (b *Bot) handle(text string) string {
+	fields := strings.Fields(text)
+	// 群组中的命令格式为 /cmd@BotName
+	command := strings.ToLower(strings.SplitN(fields[0], "@", 2)[0])
+	args := fields[1:]
+
+	logger.Info("收到Telegram命令", "command", command, "args", args)
+	switch command {
+	case "/positions":
+		return b.handler.Positions()
+	case "/pnl":
+		period := "today"
+		if len(args) > 0 {
+			period = args[0]
+		}
+		return b.handler.PnL(period)
+	case "/portfolio":
+		return b.handler.Portfolio()
+	case "/pause":
+		// /pause [cancel] [原因...]
+		cancelOrders := len(args) > 0 && strings.EqualFold(args[0], "cancel")
+		if cancelOrders {
+			args = args[1:]
+		}
+		return b.handler.Pause(strings.Join(args, " "), cancelOrders)
+	case "/resume":
+		return b.handler.Resume()
+	case "/flatten":
+		if len(args) == 0 {
+			return "用法: /flatten BTCUSDT"
+		}
+		return b.handler.Flatten(strings.ToUpper(args[0]))
+	case "/trades":
+		return b.handler.Trades(strings.Join(args, " "))
+	case "/recs":
+		return b.handler.Recommendations()
+	case "/confirm", "/dismiss":
+		if len(args) == 0 {
+			return fmt.Sprintf("用法: %s ID（通过 /recs 查看待确认的建议）", command)
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			return fmt.Sprintf("建议ID无效: %s", args[0])
+		}
+		if command == "/confirm" {
+			return b.handler.ConfirmClose(id)
+		}
+		return b.handler.DismissClose(id)
+	case "/note", "/tag", "/untag":
+		// /note ID 备注（- 清除备注）；/tag ID 标签1, 标签2；/untag ID 标签
+		if len(args) < 2 {
+			return fmt.Sprintf("用法: %s ID %s", command, map[string]string{"/note": "备注内容（- 清除备注）", "/tag": "标签1, 标签2", "/untag": "标签1, 标签2"}[command])
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			return fmt.Sprintf("交易ID无效: %s（通过 /trades 查看）", args[0])
+		}
+		text := strings.Join(args[1:], " ")
+		switch command {
+		case "/note":
+			if text == "-" {
+				text = ""
+			}
+			return b.handler.Annotate(id, &text, nil, nil)
+		case "/tag":
+			return b.handler.Annotate(id, nil, []string{text}, nil)
+		default:
+			return b.handler.Annotate(id, nil, nil, []string{text})
+		}
+	default:
+		return "可用命令:\n/positions - 当前持仓\n/pnl [today|24h|7d|all|session] - 盈亏报表（session为本次启动以来的统计）\n/portfolio - 组合视图（多结算币种按美元汇总）\n/pause [cancel] [原因] - 暂停开仓（cancel同时撤销无持仓币种的挂单）\n/resume - 恢复交易\n/flatten SYMBOL - 平掉该币种全部持仓\n/trades [标签] - 最近的已平仓交易\n/note ID 备注 - 为交易添加备注（- 清除）\n/tag ID 标签1, 标签2 - 为交易打标签\n/untag ID 标签 - 删除交易的标签\n/recs - 待确认的平仓/开仓建议\n/confirm ID - 确认建议\n/dismiss ID - 忽略建议"
+	}
+}
+
+// endpoint Bot API地址
+func (b *Bot) endpoint(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", apiBase, b.config.BotToken, method)
+}
@@ -0,0 +1,643 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"nofx/config"
+	"nofx/trader"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// AdminTokenEnv 管理令牌环境变量（覆盖配置中的admin.token）
+const AdminTokenEnv = "NOFX_ADMIN_TOKEN"
+
+// opsCommands 手动操作子命令
+var opsCommands = map[string]bool{
+	"positions": true,
+	"balance":   true,
+	"close":     true,
+	"flatten":   true,
+	"orders":    true,
+	"pause":     true,
+	"resume":    true,
+	"transfer":  true,
+}
+
+// opsBackend 手动操作的执行端：运行中的守护进程（管理接口）或直接连接交易所（--standalone）
+type opsBackend interface {
+	Balance() (map[string]interface{}, error)
+	Positions() ([]map[string]interface{}, error)
+	Orders(symbols []string) ([]trader.OpenOrder, []trader.TriggerOrder, error)
+	Flatten(symbol string) error                                // symbol为空时平掉所有持仓
+	CancelOrders(symbol string, scope trader.CancelScope) error // 过滤条件为空时撤销该币种的全部挂单
+	Pause(reason string, cancelOrders bool) error
+	Resume() error
+	Transfer(from, to, currency string, amount float64) error
+}
+
+// opsFlags 手动操作的公共参数
+type opsFlags struct {
+	configFile   string
+	traderID     string
+	addr         string
+	standalone   bool
+	all          bool
+	cancelOrders bool
+	cancelScope  trader.CancelScope
+}
+
+// runOpsCommand 手动干预：查询持仓/余额/挂单，平仓，撤单，暂停/恢复
+func runOpsCommand(name string, args []string) error {
+	var opts opsFlags
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.configFile, "config", config.DefaultFile(), "配置文件")
+	fs.StringVar(&opts.traderID, "trader", "", "trader ID（默认第一个trader，控制命令默认作用于所有trader）")
+	fs.StringVar(&opts.addr, "addr", "", "守护进程地址（默认 http://localhost:<api_server_port>）")
+	fs.BoolVar(&opts.standalone, "standalone", false, "不经过守护进程，直接连接交易所")
+	fs.BoolVar(&opts.all, "all", false, "flatten: 平掉所有持仓")
+	fs.BoolVar(&opts.cancelOrders, "cancel-orders", false, "pause: 同时撤销没有持仓的币种上的挂单")
+	fs.StringVar(&opts.cancelScope.Kind, "kind", "", "orders cancel: 只撤entry（开仓委托）或trigger（止损/止盈条件单）")
+	fs.StringVar(&opts.cancelScope.Strategy, "strategy", "", "orders cancel: 只撤该策略的订单（ai/webhook/dca/pyramid/...）")
+	fs.StringVar(&opts.cancelScope.Side, "side", "", "orders cancel: 只撤该方向持仓的订单（long/short）")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(opts.configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	var backend opsBackend
+	if opts.standalone {
+		backend, err = newStandaloneBackend(cfg, opts.traderID)
+	} else {
+		backend, err = newDaemonBackend(cfg, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "balance":
+		balance, err := backend.Balance()
+		if err != nil {
+			return err
+		}
+		printBalance(balance)
+	case "positions":
+		positions, err := backend.Positions()
+		if err != nil {
+			return err
+		}
+		printPositions(positions)
+	case "close":
+		if len(args) != 1 {
+			return fmt.Errorf("用法: nofx close <SYMBOL>")
+		}
+		symbol := strings.ToUpper(args[0])
+		if err := backend.Flatten(symbol); err != nil {
+			return err
+		}
+		fmt.Printf("✓ 已市价平掉 %s 的持仓\n", symbol)
+	case "flatten":
+		symbol := ""
+		if len(args) == 1 {
+			symbol = strings.ToUpper(args[0])
+		}
+		if len(args) > 1 || (symbol == "" && !opts.all) || (symbol != "" && opts.all) {
+			return fmt.Errorf("用法: nofx flatten <SYMBOL> | nofx flatten --all")
+		}
+		if err := backend.Flatten(symbol); err != nil {
+			return err
+		}
+		if symbol == "" {
+			fmt.Println("✓ 已市价平掉所有持仓")
+		} else {
+			fmt.Printf("✓ 已市价平掉 %s 的持仓\n", symbol)
+		}
+	case "orders":
+		if len(args) > 0 && args[0] == "cancel" {
+			scope := opts.cancelScope
+			if err := scope.Validate(); err != nil {
+				return err
+			}
+			symbol := ""
+			if len(args) == 2 {
+				symbol = strings.ToUpper(args[1])
+			}
+			if len(args) > 2 || (symbol == "" && scope.IsZero()) {
+				return fmt.Errorf("用法: nofx orders cancel <SYMBOL> [--kind entry|trigger] [--strategy NAME] [--side long|short]")
+			}
+			if err := backend.CancelOrders(symbol, scope); err != nil {
+				return err
+			}
+			if scope.IsZero() {
+				fmt.Printf("✓ 已撤销 %s 的全部挂单（包括止损/止盈单）\n", symbol)
+			} else {
+				fmt.Println("✓ 已撤销范围内的挂单")
+			}
+			return nil
+		}
+		symbols := make([]string, 0, len(args))
+		for _, symbol := range args {
+			symbols = append(symbols, strings.ToUpper(symbol))
+		}
+		orders, triggers, err := backend.Orders(symbols)
+		if err != nil {
+			return err
+		}
+		printOrders(orders, triggers)
+	case "pause":
+		if err := backend.Pause(strings.Join(args, " "), opts.cancelOrders); err != nil {
+			return err
+		}
+		fmt.Println("⏸ 已暂停AI决策和新开仓（止损止盈照常生效，重启后保持暂停）")
+	case "resume":
+		if err := backend.Resume(); err != nil {
+			return err
+		}
+		fmt.Println("▶ 已恢复交易")
+	case "transfer":
+		if len(args) < 3 || len(args) > 4 {
+			return fmt.Errorf("用法: nofx transfer <spot|futures> <spot|futures> <AMOUNT> [CURRENCY]")
+		}
+		amount, err := strconv.ParseFloat(args[2], 64)
+		if err != nil || amount <= 0 {
+			return fmt.Errorf("划转金额无效: %s", args[2])
+		}
+		currency := "USDT"
+		if len(args) == 4 {
+			currency = strings.ToUpper(args[3])
+		}
+		if err := backend.Transfer(args[0], args[1], currency, amount); err != nil {
+			return err
+		}
+		fmt.Printf("✓ 已划转 %g %s: %s -> %s\n", amount, currency, args[0], args[1])
+	}
+	return nil
+}
+
+// parseInterleaved 解析参数，允许参数和位置参数交替出现（如 nofx close BTCUSDT --standalone）
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// daemonBackend 通过管理接口操作运行中的守护进程
+type daemonBackend struct {
+	base     string
+	token    string
+	traderID string
+	client   *http.Client
+}
+
+func newDaemonBackend(cfg *config.Config, opts opsFlags) (*daemonBackend, error) {
+	token := os.Getenv(AdminTokenEnv)
+	if token == "" {
+		token = cfg.Admin.Token
+	}
+	if token == "" {
+		return nil, fmt.Errorf("未配置admin.token（或%s），无法连接守护进程；可使用--standalone直接连接交易所", AdminTokenEnv)
+	}
+	addr := opts.addr
+	if addr == "" {
+		addr = fmt.Sprintf("http://localhost:%d", cfg.APIServerPort)
+	}
+	return &daemonBackend{
+		base:     strings.TrimRight(addr, "/") + "/api/admin",
+		token:    token,
+		traderID: opts.traderID,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// do 调用管理接口并解析JSON响应（207表示部分trader失败，返回各trader的错误）
+func (d *daemonBackend) do(method, path string, query url.Values, out interface{}) error {
+	if d.traderID != "" {
+		query.Set("trader_id", d.traderID)
+	}
+	req, err := http.NewRequest(method, d.base+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("连接守护进程失败（未运行时可使用--standalone）: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s (HTTP %d)", apiErr.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if resp.StatusCode == http.StatusMultiStatus {
+		var result struct {
+			Traders []struct {
+				TraderID string `json:"trader_id"`
+				Error    string `json:"error"`
+			} `json:"traders"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return err
+		}
+		var failed []string
+		for _, t := range result.Traders {
+			if t.Error != "" {
+				failed = append(failed, fmt.Sprintf("[%s] %s", t.TraderID, t.Error))
+			}
+		}
+		return fmt.Errorf("部分trader操作失败:\n  %s", strings.Join(failed, "\n  "))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (d *daemonBackend) Balance() (map[string]interface{}, error) {
+	var balance map[string]interface{}
+	err := d.do(http.MethodGet, "/balance", url.Values{}, &balance)
+	return balance, err
+}
+
+func (d *daemonBackend) Positions() ([]map[string]interface{}, error) {
+	var positions []map[string]interface{}
+	err := d.do(http.MethodGet, "/positions", url.Values{}, &positions)
+	return positions, err
+}
+
+func (d *daemonBackend) Orders(symbols []string) ([]trader.OpenOrder, []trader.TriggerOrder, error) {
+	var result struct {
+		Orders   []trader.OpenOrder    `json:"orders"`
+		Triggers []trader.TriggerOrder `json:"trigger_orders"`
+	}
+	query := url.Values{}
+	if len(symbols) > 0 {
+		query.Set("symbols", strings.Join(symbols, ","))
+	}
+	err := d.do(http.MethodGet, "/orders", query, &result)
+	return result.Orders, result.Triggers, err
+}
+
+func (d *daemonBackend) Flatten(symbol string) error {
+	query := url.Values{}
+	if symbol == "" {
+		query.Set("all", "true")
+	} else {
+		query.Set("symbol", symbol)
+	}
+	return d.do(http.MethodPost, "/flatten", query, nil)
+}
+
+func (d *daemonBackend) CancelOrders(symbol string, scope trader.CancelScope) error {
+	query := url.Values{"symbol": {symbol}}
+	for key, value := range map[string]string{"kind": scope.Kind, "strategy": scope.Strategy, "side": scope.Side} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return d.do(http.MethodPost, "/orders/cancel", query, nil)
+}
+
+func (d *daemonBackend) Pause(reason string, cancelOrders bool) error {
+	query := url.Values{"reason": {reason}}
+	if cancelOrders {
+		query.Set("cancel_orders", "true")
+	}
+	return d.do(http.MethodPost, "/pause", query, nil)
+}
+
+func (d *daemonBackend) Resume() error {
+	return d.do(http.MethodPost, "/resume", url.Values{}, nil)
+}
+
+func (d *daemonBackend) Transfer(from, to, currency string, amount float64) error {
+	query := url.Values{
+		"from":     {from},
+		"to":       {to},
+		"currency": {currency},
+		"amount":   {strconv.FormatFloat(amount, 'f', -1, 64)},
+	}
+	return d.do(http.MethodPost, "/transfer", query, nil)
+}
+
+// standaloneBackend 不经过守护进程，直接用配置中的API密钥连接交易所
+// 守护进程运行时请避免使用：它不会记录交易日志，AI可能在同一周期内重新开仓
+type standaloneBackend struct {
+	trader trader.Trader
+}
+
+func newStandaloneBackend(cfg *config.Config, traderID string) (*standaloneBackend, error) {
+	var selected *config.TraderConfig
+	for i := range cfg.Traders {
+		t := &cfg.Traders[i]
+		if (traderID == "" && t.Enabled) || t.ID == traderID {
+			selected = t
+			break
+		}
+	}
+	if selected == nil {
+		if traderID == "" {
+			return nil, fmt.Errorf("配置中没有启用的trader")
+		}
+		return nil, fmt.Errorf("trader ID '%s' 不存在", traderID)
+	}
+
+	t, err := trader.NewExchangeTrader(trader.AutoTraderConfig{
+		ID:                    selected.ID,
+		Name:                  selected.Name,
+		Exchange:              selected.Exchange,
+		BinanceAPIKey:         selected.BinanceAPIKey,
+		BinanceSecretKey:      selected.BinanceSecretKey,
+		HyperliquidPrivateKey: selected.HyperliquidPrivateKey,
+		HyperliquidWalletAddr: selected.HyperliquidWalletAddr,
+		HyperliquidTestnet:    selected.HyperliquidTestnet,
+		AsterUser:             selected.AsterUser,
+		AsterSigner:           selected.AsterSigner,
+		AsterPrivateKey:       selected.AsterPrivateKey,
+		GateAPIKey:            selected.GateAPIKey,
+		GateSecretKey:         selected.GateSecretKey,
+		GateTestnet:           selected.GateTestnet,
+		AccountCacheTTL:       cfg.AccountCacheTTL(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("连接交易所失败: %w", err)
+	}
+	return &standaloneBackend{trader: t}, nil
+}
+
+func (s *standaloneBackend) Balance() (map[string]interface{}, error) {
+	balance, err := s.trader.GetBalance()
+	if err != nil {
+		return nil, err
+	}
+	wallet := floatOf(balance["totalWalletBalance"])
+	unrealized := floatOf(balance["totalUnrealizedProfit"])
+	info := map[string]interface{}{
+		"total_equity":      wallet + unrealized,
+		"wallet_balance":    wallet,
+		"unrealized_profit": unrealized,
+		"available_balance": floatOf(balance["availableBalance"]),
+	}
+	if currencies := trader.BalanceCurrencies(balance); currencies != nil {
+		info["currencies"] = currencies
+	}
+	return info, nil
+}
+
+// Positions 持仓（转换为与管理接口相同的格式）
+func (s *standaloneBackend) Positions() ([]map[string]interface{}, error) {
+	positions, err := s.trader.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]interface{}, 0, len(positions))
+	for _, pos := range positions {
+		quantity := math.Abs(floatOf(pos["positionAmt"]))
+		if quantity == 0 {
+			continue
+		}
+		side, _ := pos["side"].(string)
+		entryPrice, markPrice := floatOf(pos["entryPrice"]), floatOf(pos["markPrice"])
+		leverage := floatOf(pos["leverage"])
+		if leverage == 0 {
+			leverage = 1
+		}
+		pnlPct := 0.0
+		if entryPrice > 0 {
+			pnlPct = (markPrice - entryPrice) / entryPrice * leverage * 100
+			if side == "short" {
+				pnlPct = -pnlPct
+			}
+		}
+		result = append(result, map[string]interface{}{
+			"symbol":             pos["symbol"],
+			"side":               side,
+			"entry_price":        entryPrice,
+			"mark_price":         markPrice,
+			"quantity":           quantity,
+			"leverage":           leverage,
+			"unrealized_pnl":     floatOf(pos["unRealizedProfit"]),
+			"unrealized_pnl_pct": pnlPct,
+			"liquidation_price":  floatOf(pos["liquidationPrice"]),
+			"adl_rank":           floatOf(pos["adlRanking"]),
+		})
+	}
+	return result, nil
+}
+
+func (s *standaloneBackend) Orders(symbols []string) ([]trader.OpenOrder, []trader.TriggerOrder, error) {
+	source, ok := s.trader.(trader.OpenOrderSource)
+	if !ok {
+		return nil, nil, fmt.Errorf("该交易所不支持查询挂单")
+	}
+	if len(symbols) == 0 {
+		positions, err := s.trader.GetPositions()
+		if err != nil {
+			return nil, nil, err
+		}
+		seen := make(map[string]bool)
+		for _, pos := range positions {
+			if symbol, _ := pos["symbol"].(string); symbol != "" && !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	var orders []trader.OpenOrder
+	for _, symbol := range symbols {
+		symbolOrders, err := source.GetOpenOrders(symbol)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取 %s 挂单失败: %w", symbol, err)
+		}
+		orders = append(orders, symbolOrders...)
+	}
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取条件单失败: %w", err)
+	}
+	return orders, triggers, nil
+}
+
+// Flatten 市价平仓并撤销该币种剩余的止损/止盈单
+func (s *standaloneBackend) Flatten(symbol string) error {
+	positions, err := s.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	closed := 0
+	for _, pos := range positions {
+		posSymbol, _ := pos["symbol"].(string)
+		if (symbol != "" && posSymbol != symbol) || floatOf(pos["positionAmt"]) == 0 {
+			continue
+		}
+		side, _ := pos["side"].(string)
+		if side == "long" {
+			_, err = s.trader.CloseLong(posSymbol, 0)
+		} else {
+			_, err = s.trader.CloseShort(posSymbol, 0)
+		}
+		if err != nil {
+			return fmt.Errorf("平仓 %s %s 失败: %w", posSymbol, side, err)
+		}
+		closed++
+	}
+	if closed == 0 {
+		if symbol == "" {
+			return fmt.Errorf("没有持仓")
+		}
+		return fmt.Errorf("%s 没有持仓", symbol)
+	}
+	return nil
+}
+
+func (s *standaloneBackend) CancelOrders(symbol string, scope trader.CancelScope) error {
+	if scope.IsZero() {
+		return s.trader.CancelAllOrders(symbol)
+	}
+	_, err := trader.CancelScoped(s.trader, symbol, scope)
+	return err
+}
+
+func (s *standaloneBackend) Pause(string, bool) error {
+	return fmt.Errorf("暂停需要连接运行中的守护进程（不支持--standalone），守护进程未运行时可创建kill_switch_file哨兵文件")
+}
+
+func (s *standaloneBackend) Resume() error {
+	return fmt.Errorf("恢复需要连接运行中的守护进程（不支持--standalone）")
+}
+
+func (s *standaloneBackend) Transfer(from, to, currency string, amount float64) error {
+	wallet, ok := s.trader.(trader.WalletTransfer)
+	if !ok {
+		return fmt.Errorf("该交易所不支持账户间划转")
+	}
+	return wallet.Transfer(from, to, currency, amount)
+}
+
+// floatOf 将JSON数字或字符串转换为float64
+func floatOf(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case string:
+		var f float64
+		fmt.Sscanf(n, "%g", &f)
+		return f
+	}
+	return 0
+}
+
+func printBalance(balance map[string]interface{}) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	rows := []struct{ label, key string }{
+		{"账户净值", "total_equity"},
+		{"钱包余额", "wallet_balance"},
+		{"可用余额", "available_balance"},
+		{"未实现盈亏", "unrealized_profit"},
+		{"总盈亏", "total_pnl"},
+		{"保证金使用率(%)", "margin_used_pct"},
+	}
+	for _, row := range rows {
+		if v, ok := balance[row.key]; ok {
+			fmt.Fprintf(w, "%s\t%.2f\n", row.label, floatOf(v))
+		}
+	}
+	w.Flush()
+
+	// 币种明细（统一账户的多币种保证金）
+	currencies := trader.BalanceCurrencies(balance)
+	if len(currencies) == 0 {
+		return
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "币种\t权益\t可用\t借款\t指数价\t价值(USD)\t抵押价值(USD)")
+	for _, c := range currencies {
+		fmt.Fprintf(w, "%s\t%g\t%g\t%g\t%g\t%.2f\t%.2f\n", c.Currency, c.Equity, c.Available, c.Borrowed, c.IndexPrice,
+			c.ValueUSD, c.CollateralUSD)
+	}
+	w.Flush()
+}
+
+func printPositions(positions []map[string]interface{}) {
+	if len(positions) == 0 {
+		fmt.Println("无持仓")
+		return
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return fmt.Sprint(positions[i]["symbol"]) < fmt.Sprint(positions[j]["symbol"])
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "币种\t方向\t数量\t入场价\t标记价\t杠杆\t未实现盈亏\t盈亏%\t强平价\tADL\t")
+	for _, p := range positions {
+		// ADL排名为0表示交易所未提供
+		adl := "-"
+		if rank := floatOf(p["adl_rank"]); rank > 0 {
+			adl = fmt.Sprintf("%.0f/5", rank)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%.4f\t%.4f\t%.4f\t%.0fx\t%+.2f\t%+.2f%%\t%.4f\t%s\t\n",
+			p["symbol"], p["side"], floatOf(p["quantity"]), floatOf(p["entry_price"]), floatOf(p["mark_price"]),
+			floatOf(p["leverage"]), floatOf(p["unrealized_pnl"]), floatOf(p["unrealized_pnl_pct"]), floatOf(p["liquidation_price"]), adl)
+	}
+	w.Flush()
+}
+
+func printOrders(orders []trader.OpenOrder, triggers []trader.TriggerOrder) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(orders) == 0 {
+		fmt.Fprintln(w, "无普通挂单")
+	} else {
+		fmt.Fprintln(w, "订单ID\t币种\t数量\t价格")
+		for _, o := range orders {
+			fmt.Fprintf(w, "%s\t%s\t%g\t%g\n", o.OrderID, o.Symbol, o.Quantity, o.Price)
+		}
+	}
+	fmt.Fprintln(w)
+	if len(triggers) == 0 {
+		fmt.Fprintln(w, "无止损/止盈条件单")
+	} else {
+		fmt.Fprintln(w, "币种\t方向\t类型\t触发价")
+		for _, t := range triggers {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%g\n", t.Symbol, t.PositionSide, t.Kind, t.TriggerPrice)
+		}
+	}
+	w.Flush()
+}
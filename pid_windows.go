@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// processAlive 进程是否仍在运行（Windows上FindProcess会打开进程句柄，进程不存在时失败）
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
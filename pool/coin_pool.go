@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"nofx/logging"
+	"nofx/symbols"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+// poolLog 币种池日志
+var poolLog = logging.For("pool")
+
 // defaultMainstreamCoins 默认主流币种池（从配置文件读取）
 var defaultMainstreamCoins = []string{
 	"BTCUSDT",
@@ -39,6 +43,9 @@ var coinPoolConfig = CoinPoolConfig{
 	UseDefaultCoins: false, // 默认不使用
 }
 
+// CacheMaxAge 缓存超过该时间视为较旧（API不可用时仍会使用，但币种可能已过时）
+const CacheMaxAge = 24 * time.Hour
+
 // CoinPoolCache 币种池缓存
 type CoinPoolCache struct {
 	Coins      []CoinInfo `json:"coins"`
@@ -87,7 +94,7 @@ func SetUseDefaultCoins(useDefault bool) {
 func SetDefaultCoins(coins []string) {
 	if len(coins) > 0 {
 		defaultMainstreamCoins = coins
-		log.Printf("✓ 已设置默认币种池（共%d个币种）: %v", len(coins), coins)
+		poolLog.Info("已设置默认币种池", "count", len(coins), "coins", coins)
 	}
 }
 
@@ -95,13 +102,13 @@ func SetDefaultCoins(coins []string) {
 func GetCoinPool() ([]CoinInfo, error) {
 	// 优先检查是否启用默认币种列表
 	if coinPoolConfig.UseDefaultCoins {
-		log.Printf("✓ 已启用默认主流币种列表")
+		poolLog.Debug("使用默认主流币种列表")
 		return convertSymbolsToCoins(defaultMainstreamCoins), nil
 	}
 
 	// 检查API URL是否配置
 	if strings.TrimSpace(coinPoolConfig.APIURL) == "" {
-		log.Printf("⚠️  未配置币种池API URL，使用默认主流币种列表")
+		poolLog.Debug("未配置币种池API URL，使用默认主流币种列表")
 		return convertSymbolsToCoins(defaultMainstreamCoins), nil
 	}
 
@@ -111,42 +118,42 @@ func GetCoinPool() ([]CoinInfo, error) {
 	// 尝试从API获取
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			log.Printf("⚠️  第%d次重试获取币种池（共%d次）...", attempt, maxRetries)
+			poolLog.Warn("重试获取币种池", "attempt", attempt, "max_retries", maxRetries)
 			time.Sleep(2 * time.Second) // 重试前等待2秒
 		}
 
 		coins, err := fetchCoinPool()
 		if err == nil {
 			if attempt > 1 {
-				log.Printf("✓ 第%d次重试成功", attempt)
+				poolLog.Info("重试获取币种池成功", "attempt", attempt)
 			}
 			// 成功获取后保存到缓存
 			if err := saveCoinPoolCache(coins); err != nil {
-				log.Printf("⚠️  保存币种池缓存失败: %v", err)
+				poolLog.Warn("保存币种池缓存失败", "err", err)
 			}
 			return coins, nil
 		}
 
 		lastErr = err
-		log.Printf("❌ 第%d次请求失败: %v", attempt, err)
+		poolLog.Warn("请求币种池失败", "attempt", attempt, "err", err)
 	}
 
 	// API获取失败，尝试使用缓存
-	log.Printf("⚠️  API请求全部失败，尝试使用历史缓存数据...")
+	poolLog.Warn("币种池API请求全部失败，尝试使用历史缓存数据")
 	cachedCoins, err := loadCoinPoolCache()
 	if err == nil {
-		log.Printf("✓ 使用历史缓存数据（共%d个币种）", len(cachedCoins))
+		poolLog.Info("使用币种池历史缓存数据", "count", len(cachedCoins))
 		return cachedCoins, nil
 	}
 
 	// 缓存也失败，使用默认主流币种
-	log.Printf("⚠️  无法加载缓存数据（最后错误: %v），使用默认主流币种列表", lastErr)
+	poolLog.Warn("无法加载币种池缓存数据，使用默认主流币种列表", "last_err", lastErr)
 	return convertSymbolsToCoins(defaultMainstreamCoins), nil
 }
 
 // fetchCoinPool 实际执行币种池请求
 func fetchCoinPool() ([]CoinInfo, error) {
-	log.Printf("🔄 正在请求AI500币种池...")
+	poolLog.Debug("请求AI500币种池")
 
 	client := &http.Client{
 		Timeout: coinPoolConfig.Timeout,
@@ -187,7 +194,7 @@ func fetchCoinPool() ([]CoinInfo, error) {
 		coins[i].IsAvailable = true
 	}
 
-	log.Printf("✓ 成功获取%d个币种", len(coins))
+	poolLog.Info("获取AI500币种池成功", "count", len(coins))
 	return coins, nil
 }
 
@@ -214,7 +221,7 @@ func saveCoinPoolCache(coins []CoinInfo) error {
 		return fmt.Errorf("写入缓存文件失败: %w", err)
 	}
 
-	log.Printf("💾 已保存币种池缓存（%d个币种）", len(coins))
+	poolLog.Debug("已保存币种池缓存", "count", len(coins))
 	return nil
 }
 
@@ -239,12 +246,10 @@ func loadCoinPoolCache() ([]CoinInfo, error) {
 
 	// 检查缓存年龄
 	cacheAge := time.Since(cache.FetchedAt)
-	if cacheAge > 24*time.Hour {
-		log.Printf("⚠️  缓存数据较旧（%.1f小时前），但仍可使用", cacheAge.Hours())
+	if cacheAge > CacheMaxAge {
+		poolLog.Warn("币种池缓存数据较旧，但仍可使用", "age_h", cacheAge.Hours())
 	} else {
-		log.Printf("📂 缓存数据时间: %s（%.1f分钟前）",
-			cache.FetchedAt.Format("2006-01-02 15:04:05"),
-			cacheAge.Minutes())
+		poolLog.Info("币种池缓存数据时间", "fetched_at", cache.FetchedAt.Format("2006-01-02 15:04:05"), "age_min", cacheAge.Minutes())
 	}
 
 	return cache.Coins, nil
@@ -318,48 +323,7 @@ func GetTopRatedCoins(limit int) ([]string, error) {
 
 // normalizeSymbol 标准化币种符号
 func normalizeSymbol(symbol string) string {
-	// 移除空格
-	symbol = trimSpaces(symbol)
-
-	// 转为大写
-	symbol = toUpper(symbol)
-
-	// 确保以USDT结尾
-	if !endsWith(symbol, "USDT") {
-		symbol = symbol + "USDT"
-	}
-
-	return symbol
-}
-
-// 辅助函数
-func trimSpaces(s string) string {
-	result := ""
-	for i := 0; i < len(s); i++ {
-		if s[i] != ' ' {
-			result += string(s[i])
-		}
-	}
-	return result
-}
-
-func toUpper(s string) string {
-	result := ""
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 'a' && c <= 'z' {
-			c = c - 'a' + 'A'
-		}
-		result += string(c)
-	}
-	return result
-}
-
-func endsWith(s, suffix string) bool {
-	if len(s) < len(suffix) {
-		return false
-	}
-	return s[len(s)-len(suffix):] == suffix
+	return symbols.Canonical(symbol)
 }
 
 // convertSymbolsToCoins 将币种符号列表转换为CoinInfo列表
@@ -422,7 +386,7 @@ var oiTopConfig = struct {
 func GetOITopPositions() ([]OIPosition, error) {
 	// 检查API URL是否配置
 	if strings.TrimSpace(oiTopConfig.APIURL) == "" {
-		log.Printf("⚠️  未配置OI Top API URL，跳过OI Top数据获取")
+		poolLog.Debug("未配置OI Top API URL，跳过OI Top数据获取")
 		return []OIPosition{}, nil // 返回空列表，不是错误
 	}
 
@@ -432,42 +396,42 @@ func GetOITopPositions() ([]OIPosition, error) {
 	// 尝试从API获取
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			log.Printf("⚠️  第%d次重试获取OI Top数据（共%d次）...", attempt, maxRetries)
+			poolLog.Warn("重试获取OI Top数据", "attempt", attempt, "max_retries", maxRetries)
 			time.Sleep(2 * time.Second)
 		}
 
 		positions, err := fetchOITop()
 		if err == nil {
 			if attempt > 1 {
-				log.Printf("✓ 第%d次重试成功", attempt)
+				poolLog.Info("重试获取OI Top数据成功", "attempt", attempt)
 			}
 			// 成功获取后保存到缓存
 			if err := saveOITopCache(positions); err != nil {
-				log.Printf("⚠️  保存OI Top缓存失败: %v", err)
+				poolLog.Warn("保存OI Top缓存失败", "err", err)
 			}
 			return positions, nil
 		}
 
 		lastErr = err
-		log.Printf("❌ 第%d次请求OI Top失败: %v", attempt, err)
+		poolLog.Warn("请求OI Top失败", "attempt", attempt, "err", err)
 	}
 
 	// API获取失败，尝试使用缓存
-	log.Printf("⚠️  OI Top API请求全部失败，尝试使用历史缓存数据...")
+	poolLog.Warn("OI Top API请求全部失败，尝试使用历史缓存数据")
 	cachedPositions, err := loadOITopCache()
 	if err == nil {
-		log.Printf("✓ 使用历史OI Top缓存数据（共%d个币种）", len(cachedPositions))
+		poolLog.Info("使用OI Top历史缓存数据", "count", len(cachedPositions))
 		return cachedPositions, nil
 	}
 
 	// 缓存也失败，返回空列表（OI Top是可选的）
-	log.Printf("⚠️  无法加载OI Top缓存数据（最后错误: %v），跳过OI Top数据", lastErr)
+	poolLog.Warn("无法加载OI Top缓存数据，跳过OI Top数据", "last_err", lastErr)
 	return []OIPosition{}, nil
 }
 
 // fetchOITop 实际执行OI Top请求
 func fetchOITop() ([]OIPosition, error) {
-	log.Printf("🔄 正在请求OI Top数据...")
+	poolLog.Debug("请求OI Top数据")
 
 	client := &http.Client{
 		Timeout: oiTopConfig.Timeout,
@@ -502,8 +466,7 @@ func fetchOITop() ([]OIPosition, error) {
 		return nil, fmt.Errorf("OI Top持仓列表为空")
 	}
 
-	log.Printf("✓ 成功获取%d个OI Top币种（时间范围: %s）",
-		len(response.Data.Positions), response.Data.TimeRange)
+	poolLog.Info("获取OI Top数据成功", "count", len(response.Data.Positions), "time_range", response.Data.TimeRange)
 	return response.Data.Positions, nil
 }
 
@@ -529,7 +492,7 @@ func saveOITopCache(positions []OIPosition) error {
 		return fmt.Errorf("写入OI Top缓存文件失败: %w", err)
 	}
 
-	log.Printf("💾 已保存OI Top缓存（%d个币种）", len(positions))
+	poolLog.Debug("已保存OI Top缓存", "count", len(positions))
 	return nil
 }
 
@@ -552,12 +515,10 @@ func loadOITopCache() ([]OIPosition, error) {
 	}
 
 	cacheAge := time.Since(cache.FetchedAt)
-	if cacheAge > 24*time.Hour {
-		log.Printf("⚠️  OI Top缓存数据较旧（%.1f小时前），但仍可使用", cacheAge.Hours())
+	if cacheAge > CacheMaxAge {
+		poolLog.Warn("OI Top缓存数据较旧，但仍可使用", "age_h", cacheAge.Hours())
 	} else {
-		log.Printf("📂 OI Top缓存数据时间: %s（%.1f分钟前）",
-			cache.FetchedAt.Format("2006-01-02 15:04:05"),
-			cacheAge.Minutes())
+		poolLog.Info("OI Top缓存数据时间", "fetched_at", cache.FetchedAt.Format("2006-01-02 15:04:05"), "age_min", cacheAge.Minutes())
 	}
 
 	return cache.Positions, nil
@@ -592,14 +553,14 @@ func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
 	// 1. 获取AI500数据
 	ai500TopSymbols, err := GetTopRatedCoins(ai500Limit)
 	if err != nil {
-		log.Printf("⚠️  获取AI500数据失败: %v", err)
+		poolLog.Warn("获取AI500数据失败", "err", err)
 		ai500TopSymbols = []string{} // 失败时用空列表
 	}
 
 	// 2. 获取OI Top数据
 	oiTopSymbols, err := GetOITopSymbols()
 	if err != nil {
-		log.Printf("⚠️  获取OI Top数据失败: %v", err)
+		poolLog.Warn("获取OI Top数据失败", "err", err)
 		oiTopSymbols = []string{} // 失败时用空列表
 	}
 
@@ -638,8 +599,44 @@ func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
 		SymbolSources: symbolSources,
 	}
 
-	log.Printf("📊 币种池合并完成: AI500=%d, OI_Top=%d, 总计(去重)=%d",
-		len(ai500TopSymbols), len(oiTopSymbols), len(allSymbols))
+	poolLog.Info("币种池合并完成", "ai500", len(ai500TopSymbols), "oi_top", len(oiTopSymbols), "total", len(allSymbols))
 
 	return merged, nil
 }
+
+// CacheFile 缓存文件的路径和抓取时间
+type CacheFile struct {
+	Name      string
+	Path      string
+	FetchedAt time.Time // 文件不存在时为零值
+}
+
+// CoinPoolCacheFile 币种池缓存文件状态（诊断用）
+func CoinPoolCacheFile() (CacheFile, error) {
+	return readCacheFile("coin_pool", filepath.Join(coinPoolConfig.CacheDir, "latest.json"))
+}
+
+// OITopCacheFile OI Top缓存文件状态（诊断用）
+func OITopCacheFile() (CacheFile, error) {
+	return readCacheFile("oi_top", filepath.Join(oiTopConfig.CacheDir, "oi_top_latest.json"))
+}
+
+// readCacheFile 读取缓存文件的抓取时间（两种缓存都有fetched_at字段）
+func readCacheFile(name, path string) (CacheFile, error) {
+	file := CacheFile{Name: name, Path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return file, fmt.Errorf("读取缓存文件失败: %w", err)
+	}
+	var cache struct {
+		FetchedAt time.Time `json:"fetched_at"`
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return file, fmt.Errorf("解析缓存数据失败: %w", err)
+	}
+	file.FetchedAt = cache.FetchedAt
+	return file, nil
+}
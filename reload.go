@@ -0,0 +1,363 @@
+package main
+
+import (
+	"fmt"
+	"nofx/config"
+	"nofx/i18n"
+	"nofx/logging"
+	"nofx/manager"
+	"nofx/notify"
+	"nofx/notify/discord"
+	"nofx/notify/httphook"
+	"nofx/notify/slack"
+	"nofx/notify/telegram"
+	"nofx/pool"
+	"nofx/scheduler"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// configPollInterval 检查配置文件修改时间的间隔
+const configPollInterval = 5 * time.Second
+
+// reloadLog 配置热加载日志
+var reloadLog = logging.For("reload")
+
+// reloader 管理可热加载的全局组件（通知渠道、汇总报告、币种池、日志级别），
+// 在配置文件变更、收到SIGHUP或API请求时重新加载配置
+type reloader struct {
+	file      string
+	tm        *manager.TraderManager
+	notifier  *notify.Switch            // 所有trader共享的通知入口，重建渠道时替换其中的渠道
+	channels  map[string]*activeChannel // 渠道名 -> 运行中的渠道
+	summaries *scheduler.Scheduler      // 汇总报告任务（未启用时为nil）
+	extra     notify.Notifier           // 始终接收事件的内部订阅者（如gRPC事件流，为nil时忽略）
+	current   *config.Config
+	modTime   time.Time
+	mu        sync.Mutex
+}
+
+// activeChannel 运行中的通知渠道
+type activeChannel struct {
+	config   interface{} // 创建时的配置（未变化时不重建）
+	notifier notify.Notifier
+	stop     func()
+}
+
+// channelSpec 通知渠道的配置和创建方式
+type channelSpec struct {
+	name    string
+	enabled bool
+	config  interface{}
+	create  func() (notify.Notifier, func(), error)
+}
+
+// newReloader 创建reloader，并将通知入口设置到TraderManager（需在AddTrader之前调用）
+// extra不受通知配置影响，始终接收所有事件
+func newReloader(file string, cfg *config.Config, tm *manager.TraderManager, extra notify.Notifier) *reloader {
+	r := &reloader{
+		file:     file,
+		tm:       tm,
+		notifier: &notify.Switch{},
+		channels: make(map[string]*activeChannel),
+		current:  cfg,
+		extra:    extra,
+	}
+	if info, err := os.Stat(file); err == nil {
+		r.modTime = info.ModTime()
+	}
+	tm.SetNotifier(r.notifier)
+	return r
+}
+
+// start 按启动配置创建通知渠道和汇总报告任务
+func (r *reloader) start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.applyNotifiers(r.current); err != nil {
+		return err
+	}
+	_, err := r.applySummaries(nil, r.current)
+	return err
+}
+
+// stop 停止所有通知渠道和汇总报告任务
+func (r *reloader) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.summaries != nil {
+		r.summaries.Stop()
+	}
+	for _, ch := range r.channels {
+		ch.stop()
+	}
+}
+
+// Current 当前生效的配置
+func (r *reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload 重新读取配置文件并应用可热加载的变更，返回已应用的变更和需要重启才能生效的变更
+// 配置无效时返回错误，运行中的配置保持不变
+func (r *reloader) Reload() (applied, restart []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.LoadConfig(r.file)
+	if err != nil {
+		return nil, nil, err
+	}
+	old := r.current
+
+	// 先处理可能失败的部分（创建通知渠道、汇总任务），失败时不修改其他运行状态
+	changes, err := r.applyNotifiers(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied = append(applied, changes...)
+	changes, err = r.applySummaries(old, cfg)
+	if err != nil {
+		return applied, nil, err
+	}
+	applied = append(applied, changes...)
+
+	if !reflect.DeepEqual(old.Log, cfg.Log) {
+		if err := logging.Setup(cfg.Log); err != nil {
+			return applied, nil, err
+		}
+		applied = append(applied, fmt.Sprintf("log: level=%s format=%s", cfg.Log.Level, cfg.Log.Format))
+	}
+
+	if old.Language != cfg.Language && cfg.Language != "" {
+		i18n.SetLanguage(cfg.Language)
+		applied = append(applied, fmt.Sprintf("language: %s", cfg.Language))
+	}
+
+	// 币种池
+	if !reflect.DeepEqual(old.DefaultCoins, cfg.DefaultCoins) {
+		pool.SetDefaultCoins(cfg.DefaultCoins)
+		applied = append(applied, fmt.Sprintf("default_coins: %v → %v", old.DefaultCoins, cfg.DefaultCoins))
+	}
+	if old.UseDefaultCoins != cfg.UseDefaultCoins {
+		pool.SetUseDefaultCoins(cfg.UseDefaultCoins)
+		applied = append(applied, fmt.Sprintf("use_default_coins: %v → %v", old.UseDefaultCoins, cfg.UseDefaultCoins))
+	}
+	if old.CoinPoolAPIURL != cfg.CoinPoolAPIURL {
+		pool.SetCoinPoolAPI(cfg.CoinPoolAPIURL)
+		applied = append(applied, "coin_pool_api_url: 已更新")
+	}
+	if old.OITopAPIURL != cfg.OITopAPIURL {
+		pool.SetOITopAPI(cfg.OITopAPIURL)
+		applied = append(applied, "oi_top_api_url: 已更新")
+	}
+
+	// 各trader的风控、杠杆、策略参数
+	traderChanges, traderRestart := r.tm.ApplyConfig(cfg)
+	applied = append(applied, traderChanges...)
+	restart = append(restart, traderRestart...)
+
+	// 需要重启的全局配置
+	if old.APIServerPort != cfg.APIServerPort {
+		restart = append(restart, "api_server_port")
+	}
+	if old.Admin.GRPCPort != cfg.Admin.GRPCPort {
+		restart = append(restart, "admin.grpc_port")
+	}
+	if old.DryRun != cfg.DryRun {
+		restart = append(restart, "dry_run")
+	}
+	if old.StorePath != cfg.StorePath {
+		restart = append(restart, "store_path")
+	}
+	if !reflect.DeepEqual(old.Tracing, cfg.Tracing) {
+		restart = append(restart, "tracing")
+	}
+	if !reflect.DeepEqual(old.Cache, cfg.Cache) {
+		restart = append(restart, "cache")
+	}
+	if !reflect.DeepEqual(old.ReportingCurrency, cfg.ReportingCurrency) {
+		restart = append(restart, "reporting_currency")
+	}
+
+	r.current = cfg
+	return applied, restart, nil
+}
+
+// applyNotifiers 按配置创建、重建或停止通知渠道（配置未变化的渠道保持运行）
+// 新渠道全部创建成功后才替换，任何一个失败时保留原有渠道
+func (r *reloader) applyNotifiers(cfg *config.Config) ([]string, error) {
+	specs := []channelSpec{
+		{"telegram", cfg.Telegram.Enabled, cfg.Telegram, func() (notify.Notifier, func(), error) {
+			bot, err := telegram.New(cfg.Telegram, manager.NewCommands(r.tm))
+			if err != nil {
+				return nil, nil, fmt.Errorf("创建Telegram机器人失败: %w", err)
+			}
+			bot.Start()
+			reloadLog.Info("Telegram机器人已启用", "chats", len(cfg.Telegram.ChatIDs))
+			return bot, bot.Stop, nil
+		}},
+		{"discord", cfg.Discord.Enabled, cfg.Discord, func() (notify.Notifier, func(), error) {
+			channel, err := discord.New(cfg.Discord)
+			if err != nil {
+				return nil, nil, fmt.Errorf("创建Discord通知失败: %w", err)
+			}
+			channel.Start()
+			reloadLog.Info("Discord通知已启用")
+			return channel, channel.Stop, nil
+		}},
+		{"slack", cfg.Slack.Enabled, cfg.Slack, func() (notify.Notifier, func(), error) {
+			channel, err := slack.New(cfg.Slack)
+			if err != nil {
+				return nil, nil, fmt.Errorf("创建Slack通知失败: %w", err)
+			}
+			channel.Start()
+			reloadLog.Info("Slack通知已启用")
+			return channel, channel.Stop, nil
+		}},
+	}
+	for _, hook := range cfg.NotifyWebhooks {
+		hook := hook
+		specs = append(specs, channelSpec{"webhook:" + hook.Name, hook.Enabled, hook, func() (notify.Notifier, func(), error) {
+			channel, err := httphook.New(hook)
+			if err != nil {
+				return nil, nil, fmt.Errorf("创建Webhook通知%s失败: %w", hook.Name, err)
+			}
+			channel.Start()
+			reloadLog.Info("Webhook通知已启用", "name", hook.Name)
+			return channel, channel.Stop, nil
+		}})
+	}
+
+	var changes []string
+	next := make(map[string]*activeChannel)
+	created := make([]*activeChannel, 0, len(specs))
+	for _, spec := range specs {
+		if !spec.enabled {
+			continue
+		}
+		if current, ok := r.channels[spec.name]; ok && reflect.DeepEqual(current.config, spec.config) {
+			next[spec.name] = current
+			continue
+		}
+		notifier, stop, err := spec.create()
+		if err != nil {
+			for _, ch := range created {
+				ch.stop()
+			}
+			return nil, err
+		}
+		ch := &activeChannel{config: spec.config, notifier: notifier, stop: stop}
+		created = append(created, ch)
+		next[spec.name] = ch
+		changes = append(changes, spec.name+": 已更新")
+	}
+
+	var active notify.Multi
+	for _, spec := range specs {
+		if ch, ok := next[spec.name]; ok {
+			active = append(active, ch.notifier)
+		}
+	}
+	if r.extra != nil {
+		active = append(active, r.extra)
+	}
+	if len(active) > 0 {
+		r.notifier.Set(active)
+	} else {
+		r.notifier.Set(nil)
+	}
+
+	// 停止被替换或禁用的渠道
+	for name, ch := range r.channels {
+		if next[name] != ch {
+			ch.stop()
+			if _, ok := next[name]; !ok {
+				changes = append(changes, name+": 已禁用")
+			}
+		}
+	}
+	r.channels = next
+	return changes, nil
+}
+
+// applySummaries 按配置创建、重建或停止汇总报告任务（old为nil表示启动）
+func (r *reloader) applySummaries(old, cfg *config.Config) ([]string, error) {
+	if old != nil && reflect.DeepEqual(old.Summary, cfg.Summary) {
+		return nil, nil
+	}
+
+	var next *scheduler.Scheduler
+	if cfg.Summary.Enabled {
+		if len(r.channels) == 0 {
+			return nil, fmt.Errorf("汇总报告需要至少配置一个通知渠道")
+		}
+		summaries, err := r.tm.ScheduleSummaries(cfg.Summary)
+		if err != nil {
+			return nil, fmt.Errorf("创建汇总报告任务失败: %w", err)
+		}
+		next = summaries
+	}
+
+	if r.summaries != nil {
+		r.summaries.Stop()
+	}
+	r.summaries = next
+	if next == nil {
+		if old == nil {
+			return nil, nil
+		}
+		return []string{"summary: 已禁用"}, nil
+	}
+	next.Start()
+	reloadLog.Info("汇总报告已启用", "daily", cfg.Summary.Daily, "weekly", cfg.Summary.Weekly)
+	return []string{"summary: 已更新"}, nil
+}
+
+// reloadAndLog 重新加载配置并记录结果（source为触发来源）
+func (r *reloader) reloadAndLog(source string) {
+	applied, restart, err := r.Reload()
+	if err != nil {
+		reloadLog.Error("热加载配置失败，继续使用当前配置", "source", source, "err", err)
+		return
+	}
+	if len(applied) == 0 && len(restart) == 0 {
+		reloadLog.Info("配置已重新加载，没有变更", "source", source)
+		return
+	}
+	if len(applied) > 0 {
+		reloadLog.Info("配置已热加载", "source", source, "applied", strings.Join(applied, "; "))
+	}
+	if len(restart) > 0 {
+		reloadLog.Warn("以下配置变更需要重启才能生效", "source", source, "changes", strings.Join(restart, "; "))
+	}
+}
+
+// watch 定期检查配置文件的修改时间，变化时自动重新加载
+func (r *reloader) watch(stop <-chan struct{}) {
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.file)
+			if err != nil {
+				continue
+			}
+			r.mu.Lock()
+			changed := !info.ModTime().Equal(r.modTime)
+			r.modTime = info.ModTime()
+			r.mu.Unlock()
+			if changed {
+				r.reloadAndLog("配置文件变更")
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"fmt"
+	"nofx/risk"
+	"nofx/store"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sizeBuckets 成交额分档（USDT，左闭右开）
+var sizeBuckets = []struct {
+	Name string
+	Max  float64
+}{
+	{"<100", 100},
+	{"100-1k", 1000},
+	{"1k-10k", 10000},
+	{">=10k", 0}, // 0表示不设上限
+}
+
+// sizeBucket 成交额所在分档
+func sizeBucket(notional float64) string {
+	for _, b := range sizeBuckets {
+		if b.Max == 0 || notional < b.Max {
+			return b.Name
+		}
+	}
+	return sizeBuckets[len(sizeBuckets)-1].Name
+}
+
+// ExecutionStat 单币种单分档（或汇总）的执行质量统计，滑点均为基点，正数表示不利
+type ExecutionStat struct {
+	Symbol       string  `json:"symbol"`
+	Bucket       string  `json:"bucket"`        // 成交额分档
+	Orders       int     `json:"orders"`        // 成交订单数
+	MakerOrders  int     `json:"maker_orders"`  // 只挂单成交的订单数
+	Notional     float64 `json:"notional"`      // 成交额（USDT）
+	ArrivalBps   float64 `json:"arrival_bps"`   // 平均总滑点：成交均价相对决策时价格
+	DelayBps     float64 `json:"delay_bps"`     // 平均延迟滑点：提交价格相对决策时价格（决策到下单期间的行情变化）
+	SubmittedBps float64 `json:"submitted_bps"` // 平均成交滑点：成交均价相对提交价格
+	FeeBps       float64 `json:"fee_bps"`       // 平均手续费率（仅统计记录了费率的订单）
+	feeCount     int
+}
+
+// add 累加一笔成交（finalize前为合计值）
+func (s *ExecutionStat) add(o store.Order) {
+	notional := o.FilledQty * o.AvgPrice
+	s.Orders++
+	if o.Route == risk.RouteMaker {
+		s.MakerOrders++
+	}
+	s.Notional += notional
+	s.ArrivalBps += executionBps(o.Action, o.ArrivalPrice, o.AvgPrice)
+	s.DelayBps += executionBps(o.Action, o.ArrivalPrice, o.SubmittedPrice)
+	s.SubmittedBps += executionBps(o.Action, o.SubmittedPrice, o.AvgPrice)
+	if o.FeeRate != 0 {
+		s.FeeBps += o.FeeRate * 1e4
+		s.feeCount++
+	}
+}
+
+// merge 合并另一组统计（双方均未finalize）
+func (s *ExecutionStat) merge(o *ExecutionStat) {
+	s.Orders += o.Orders
+	s.MakerOrders += o.MakerOrders
+	s.Notional += o.Notional
+	s.ArrivalBps += o.ArrivalBps
+	s.DelayBps += o.DelayBps
+	s.SubmittedBps += o.SubmittedBps
+	s.FeeBps += o.FeeBps
+	s.feeCount += o.feeCount
+}
+
+// finalize 合计值转为平均值
+func (s *ExecutionStat) finalize() {
+	if s.Orders > 0 {
+		n := float64(s.Orders)
+		s.ArrivalBps /= n
+		s.DelayBps /= n
+		s.SubmittedBps /= n
+	}
+	if s.feeCount > 0 {
+		s.FeeBps /= float64(s.feeCount)
+	}
+}
+
+// executionBps 价格相对基准价的偏离（基点，买入高于基准价、卖出低于基准价为正）
+func executionBps(action string, base, price float64) float64 {
+	if base <= 0 || price <= 0 {
+		return 0
+	}
+	bps := (price - base) / base * 1e4
+	if action == "open_short" || action == "close_long" {
+		bps = -bps
+	}
+	return bps
+}
+
+// ExecutionReport 执行质量报表
+type ExecutionReport struct {
+	TraderID    string          `json:"trader_id"`
+	Period      Period          `json:"period"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Rows        []ExecutionStat `json:"rows"`
+	Total       ExecutionStat   `json:"total"`
+}
+
+// ReportExecution 根据交易日志生成指定周期的执行质量报表（按下单时间统计，按币种和成交额分档汇总）
+// 只统计有成交且记录了执行价格的订单
+func ReportExecution(s *store.Store, traderID string, period Period) (*ExecutionReport, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+
+	orders, err := s.ListExecutedOrders(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ symbol, bucket string }
+	rows := make(map[key]*ExecutionStat)
+	for _, o := range orders {
+		k := key{o.Symbol, sizeBucket(o.FilledQty * o.AvgPrice)}
+		if _, ok := rows[k]; !ok {
+			rows[k] = &ExecutionStat{Symbol: k.symbol, Bucket: k.bucket}
+		}
+		rows[k].add(o)
+	}
+
+	report := &ExecutionReport{
+		TraderID:    traderID,
+		Period:      period,
+		GeneratedAt: time.Now(),
+		Total:       ExecutionStat{Symbol: "TOTAL", Bucket: "all"},
+	}
+	for _, stat := range rows {
+		report.Total.merge(stat)
+		stat.finalize()
+		report.Rows = append(report.Rows, *stat)
+	}
+	report.Total.finalize()
+
+	// 按币种、分档从小到大排序
+	bucketIndex := func(name string) int {
+		for i, b := range sizeBuckets {
+			if b.Name == name {
+				return i
+			}
+		}
+		return len(sizeBuckets)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Symbol != report.Rows[j].Symbol {
+			return report.Rows[i].Symbol < report.Rows[j].Symbol
+		}
+		return bucketIndex(report.Rows[i].Bucket) < bucketIndex(report.Rows[j].Bucket)
+	})
+
+	return report, nil
+}
+
+// String 格式化为文本（用于命令行输出）
+func (r *ExecutionReport) String() string {
+	var sb strings.Builder
+
+	since := "全部"
+	if !r.Period.Since.IsZero() {
+		since = r.Period.Since.Local().Format("2006-01-02 15:04")
+	}
+	sb.WriteString(fmt.Sprintf("🎯 执行质量报表 [%s] 周期: %s（自 %s）滑点单位: 基点，正数表示不利\n", r.TraderID, r.Period.Name, since))
+	sb.WriteString(fmt.Sprintf("%-12s %-7s %6s %6s %12s %9s %9s %9s %7s\n",
+		"币种", "分档", "订单数", "挂单", "成交额USDT", "总滑点", "延迟", "成交滑点", "费率"))
+
+	writeRow := func(s ExecutionStat) {
+		sb.WriteString(fmt.Sprintf("%-12s %-7s %6d %6d %12.2f %+9.2f %+9.2f %+9.2f %+7.2f\n",
+			s.Symbol, s.Bucket, s.Orders, s.MakerOrders, s.Notional, s.ArrivalBps, s.DelayBps, s.SubmittedBps, s.FeeBps))
+	}
+	for _, s := range r.Rows {
+		writeRow(s)
+	}
+	sb.WriteString(strings.Repeat("-", 90) + "\n")
+	writeRow(r.Total)
+
+	return sb.String()
+}
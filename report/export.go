@@ -0,0 +1,199 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"nofx/store"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tradeColumns 导出的列（与tradeRow顺序一致）
+var tradeColumns = []string{
+	"id", "symbol", "side", "strategy", "opened_at", "closed_at", "entry_price", "exit_price",
+	"quantity", "leverage", "fees", "funding", "gross_pnl", "net_pnl", "tags", "note",
+}
+
+// ExportTrades 导出指定周期内已平仓的交易（format: csv 或 xlsx）
+func ExportTrades(s *store.Store, traderID, path, format string, period Period) (int, error) {
+	if s == nil {
+		return 0, fmt.Errorf("交易日志存储未启用")
+	}
+
+	positions, err := s.ListClosedPositions(traderID, period.Since)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([][]string, 0, len(positions))
+	for _, p := range positions {
+		rows = append(rows, tradeRow(p))
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		err = writeCSV(path, rows)
+	case "xlsx":
+		err = writeXLSX(path, rows)
+	default:
+		return 0, fmt.Errorf("不支持的导出格式: %s（支持 csv/xlsx）", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// tradeRow 将交易记录转换为一行导出数据
+func tradeRow(p store.Position) []string {
+	closedAt := ""
+	if p.ClosedAt != nil {
+		closedAt = p.ClosedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(p.ID, 10),
+		p.Symbol,
+		p.Side,
+		p.Strategy,
+		p.OpenedAt.UTC().Format(time.RFC3339),
+		closedAt,
+		formatNumber(p.EntryPrice),
+		formatNumber(p.ExitPrice),
+		formatNumber(p.Quantity),
+		strconv.Itoa(p.Leverage),
+		formatNumber(p.Fees),
+		formatNumber(p.Funding),
+		formatNumber(p.RealizedPnL),
+		formatNumber(p.NetPnL()),
+		strings.Join(p.Tags, ", "),
+		p.Note,
+	}
+}
+
+// formatNumber 格式化数值（去掉多余的0）
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// TradesCSV 将指定周期内已平仓的交易导出为CSV内容（用于通知附件），返回内容和交易数
+func TradesCSV(s *store.Store, traderID string, period Period) ([]byte, int, error) {
+	if s == nil {
+		return nil, 0, fmt.Errorf("交易日志存储未启用")
+	}
+
+	positions, err := s.ListClosedPositions(traderID, period.Since)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := make([][]string, 0, len(positions))
+	for _, p := range positions {
+		rows = append(rows, tradeRow(p))
+	}
+
+	var buf bytes.Buffer
+	if err := encodeCSV(&buf, rows); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), len(rows), nil
+}
+
+// writeCSV 写入CSV文件
+func writeCSV(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer f.Close()
+	return encodeCSV(f, rows)
+}
+
+// encodeCSV 写入表头和数据行
+func encodeCSV(out io.Writer, rows [][]string) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(tradeColumns); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("写入CSV失败: %w", err)
+	}
+	return nil
+}
+
+// xlsxParts 最小化的XLSX包结构（单工作表，使用内联字符串）
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="trades" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+// writeXLSX 写入XLSX文件（数值列写为数字单元格，便于表格软件直接计算）
+func writeXLSX(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, part := range xlsxParts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return err
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range append([][]string{tradeColumns}, rows...) {
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, i+1))
+		for _, cell := range row {
+			if _, err := strconv.ParseFloat(cell, 64); err == nil && i > 0 {
+				sb.WriteString(`<c><v>` + cell + `</v></c>`)
+				continue
+			}
+			sb.WriteString(`<c t="inlineStr"><is><t>`)
+			xml.EscapeText(&sb, []byte(cell))
+			sb.WriteString(`</t></is></c>`)
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(sb.String())); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("写入XLSX失败: %w", err)
+	}
+	return nil
+}
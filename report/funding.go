@@ -0,0 +1,159 @@
+package report
+
+import (
+	"fmt"
+	"nofx/logging"
+	"nofx/market"
+	"nofx/store"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fundingLog 历史资金费率日志
+var fundingLog = logging.For("funding")
+
+// fundingRefreshInterval 同一币种两次增量回填的最小间隔（资金费最快每小时结算一次）
+const fundingRefreshInterval = time.Hour
+
+// FundingHistoryConfig 历史资金费率配置
+type FundingHistoryConfig struct {
+	Enabled bool     `json:"enabled"` // 是否启用（回填历史资金费率，当前费率的历史分布写入提示词）
+	Days    int      `json:"days"`    // 统计窗口（天，默认90）
+	Symbols []string `json:"symbols"` // 启动时预先回填的币种（其他币种在首次查询时回填）
+}
+
+// Validate 验证历史资金费率配置并填充默认值
+func (c *FundingHistoryConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Days == 0 {
+		c.Days = 90
+	}
+	if c.Days < 7 || c.Days > 365 {
+		return fmt.Errorf("funding_history.days必须在7-365之间: %d", c.Days)
+	}
+	return nil
+}
+
+// FundingRatesFetcher 历史资金费率数据源（默认为market.FundingRatesBetween）
+type FundingRatesFetcher func(symbol string, from, to time.Time) ([]market.FundingPoint, error)
+
+// FundingHistory 历史资金费率：按合约回填到交易日志，计算当前费率在历史分布中的位置
+type FundingHistory struct {
+	journal *store.Store
+	days    int
+	fetch   FundingRatesFetcher
+	now     func() time.Time
+
+	mu     sync.Mutex
+	synced map[string]time.Time // symbol -> 上次回填时间
+}
+
+// NewFundingHistory 创建历史资金费率服务
+func NewFundingHistory(journal *store.Store, days int) *FundingHistory {
+	return &FundingHistory{
+		journal: journal,
+		days:    days,
+		fetch:   market.FundingRatesBetween,
+		now:     time.Now,
+		synced:  make(map[string]time.Time),
+	}
+}
+
+// window 统计窗口的起点
+func (h *FundingHistory) window() time.Time {
+	return h.now().AddDate(0, 0, -h.days)
+}
+
+// Backfill 回填币种的历史资金费率（从已记录的最近一次结算之后开始，没有记录时回填整个统计窗口），返回新写入的条数
+func (h *FundingHistory) Backfill(symbol string) (int, error) {
+	symbol = market.Normalize(symbol)
+	from := h.window()
+	latest, err := h.journal.LatestFundingRateTime(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if latest.After(from) {
+		from = latest.Add(time.Second)
+	}
+	to := h.now()
+	if !to.After(from) {
+		return 0, nil
+	}
+
+	points, err := h.fetch(symbol, from, to)
+	if err != nil {
+		return 0, err
+	}
+	rates := make([]store.FundingRate, 0, len(points))
+	for _, p := range points {
+		rates = append(rates, store.FundingRate{Symbol: symbol, Time: p.Time, Rate: p.Rate})
+	}
+	inserted, err := h.journal.RecordFundingRates(rates)
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	h.synced[symbol] = to
+	h.mu.Unlock()
+	return inserted, nil
+}
+
+// Rates 统计窗口内的历史资金费率
+func (h *FundingHistory) Rates(symbol string) ([]store.FundingRate, error) {
+	return h.journal.ListFundingRates(market.Normalize(symbol), h.window())
+}
+
+// Stats 当前费率在统计窗口内历史分布中的位置（距上次回填超过一小时时先增量回填，历史不足时返回nil）
+func (h *FundingHistory) Stats(symbol string, current float64) (*market.FundingStats, error) {
+	symbol = market.Normalize(symbol)
+	h.mu.Lock()
+	stale := h.now().Sub(h.synced[symbol]) >= fundingRefreshInterval
+	if stale {
+		// 回填失败时同样等到下一个间隔再重试
+		h.synced[symbol] = h.now()
+	}
+	h.mu.Unlock()
+	if stale {
+		if _, err := h.Backfill(symbol); err != nil {
+			// 回填失败时使用已记录的历史
+			fundingLog.Warn("回填历史资金费率失败", "symbol", symbol, "err", err)
+		}
+	}
+
+	rates, err := h.Rates(symbol)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(rates))
+	for i, r := range rates {
+		values[i] = r.Rate
+	}
+	return market.ComputeFundingStats(values, current, h.days), nil
+}
+
+// Source 作为行情数据的资金费率历史分布数据源（查询失败时视为没有历史）
+func (h *FundingHistory) Source() market.FundingStatsSource {
+	return func(symbol string, current float64) *market.FundingStats {
+		stats, err := h.Stats(symbol, current)
+		if err != nil {
+			fundingLog.Warn("计算资金费率历史分布失败", "symbol", symbol, "err", err)
+			return nil
+		}
+		return stats
+	}
+}
+
+// FormatFundingStats 格式化资金费率历史分布（命令行输出）
+func FormatFundingStats(symbol string, stats *market.FundingStats) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s 资金费率（近%d天，%d次结算）\n", symbol, stats.Days, stats.Samples))
+	sb.WriteString(fmt.Sprintf("  当前:   %+.4f%%（百分位 %.0f，Z值 %+.2f）\n", stats.Current*100, stats.Percentile, stats.ZScore))
+	sb.WriteString(fmt.Sprintf("  均值:   %+.4f%%（标准差 %.4f%%）\n", stats.Mean*100, stats.StdDev*100))
+	sb.WriteString(fmt.Sprintf("  分位:   P10 %+.4f%% / 中位数 %+.4f%% / P90 %+.4f%%\n", stats.P10*100, stats.Median*100, stats.P90*100))
+	sb.WriteString(fmt.Sprintf("  区间:   %+.4f%% ~ %+.4f%%\n", stats.Min*100, stats.Max*100))
+	return sb.String()
+}
@@ -0,0 +1,220 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"nofx/store"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period 报表统计周期
+type Period struct {
+	Name  string    `json:"name"`  // 如 "today"、"7d"、"all"
+	Since time.Time `json:"since"` // 统计起始时间
+}
+
+// ParsePeriod 解析统计周期
+// 支持: "today"（UTC当日）、"24h"/"12h"（小时）、"7d"/"30d"（天）、"all"（全部）
+func ParsePeriod(s string) (Period, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	now := time.Now().UTC()
+
+	switch {
+	case s == "" || s == "all":
+		return Period{Name: "all", Since: time.Time{}}, nil
+	case s == "today":
+		return Period{Name: s, Since: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}, nil
+	case strings.HasSuffix(s, "d"):
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days <= 0 {
+			return Period{}, fmt.Errorf("无效的统计周期: %s", s)
+		}
+		return Period{Name: s, Since: now.AddDate(0, 0, -days)}, nil
+	case strings.HasSuffix(s, "h"):
+		hours, err := strconv.Atoi(strings.TrimSuffix(s, "h"))
+		if err != nil || hours <= 0 {
+			return Period{}, fmt.Errorf("无效的统计周期: %s", s)
+		}
+		return Period{Name: s, Since: now.Add(-time.Duration(hours) * time.Hour)}, nil
+	default:
+		return Period{}, fmt.Errorf("无效的统计周期: %s（支持 today/24h/7d/30d/all）", s)
+	}
+}
+
+// SymbolPnL 单币种（或汇总）盈亏统计
+type SymbolPnL struct {
+	Symbol        string  `json:"symbol"`
+	Trades        int     `json:"trades"` // 已平仓交易数
+	Wins          int     `json:"wins"`
+	Losses        int     `json:"losses"`
+	WinRate       float64 `json:"win_rate"`       // 胜率（%）
+	RealizedPnL   float64 `json:"realized_pnl"`   // 已实现盈亏（毛）
+	Fees          float64 `json:"fees"`           // 手续费（正数表示支出）
+	Funding       float64 `json:"funding"`        // 资金费（正数表示收入）
+	NetPnL        float64 `json:"net_pnl"`        // 净盈亏 = 已实现 - 手续费 + 资金费
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 未实现盈亏（当前持仓）
+	AvgR          float64 `json:"avg_r"`          // 平均R倍数（仅统计设置了止损的交易）
+	rSum          float64
+	rCount        int
+}
+
+// PnLReport 盈亏报表
+type PnLReport struct {
+	TraderID    string      `json:"trader_id"`
+	Period      Period      `json:"period"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Symbols     []SymbolPnL `json:"symbols"`
+	Strategies  []SymbolPnL `json:"strategies"` // 按策略汇总（symbol字段为策略名）
+	Prompts     []SymbolPnL `json:"prompts"`    // AI决策按提示词版本汇总（symbol字段为版本）
+	Total       SymbolPnL   `json:"total"`
+}
+
+// ReportPnL 根据交易日志生成指定周期的盈亏报表（按平仓时间统计）
+// unrealized 为当前持仓的未实现盈亏（symbol -> USDT），可为nil
+func ReportPnL(s *store.Store, traderID string, period Period, unrealized map[string]float64) (*PnLReport, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+
+	positions, err := s.ListClosedPositions(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := make(map[string]*SymbolPnL)
+	byStrategy := make(map[string]*SymbolPnL)
+	byPrompt := make(map[string]*SymbolPnL)
+	get := func(groups map[string]*SymbolPnL, name string) *SymbolPnL {
+		if _, ok := groups[name]; !ok {
+			groups[name] = &SymbolPnL{Symbol: name}
+		}
+		return groups[name]
+	}
+
+	for _, p := range positions {
+		get(bySymbol, p.Symbol).add(p)
+
+		strategy := p.Strategy
+		if strategy == "" {
+			strategy = "unknown"
+		}
+		get(byStrategy, strategy).add(p)
+		if p.PromptVersion != "" {
+			get(byPrompt, p.PromptVersion).add(p)
+		}
+	}
+	for symbol, pnl := range unrealized {
+		get(bySymbol, symbol).UnrealizedPnL += pnl
+	}
+
+	report := &PnLReport{
+		TraderID:    traderID,
+		Period:      period,
+		GeneratedAt: time.Now(),
+		Total:       SymbolPnL{Symbol: "TOTAL"},
+	}
+	for _, stat := range bySymbol {
+		report.Total.Trades += stat.Trades
+		report.Total.Wins += stat.Wins
+		report.Total.Losses += stat.Losses
+		report.Total.RealizedPnL += stat.RealizedPnL
+		report.Total.Fees += stat.Fees
+		report.Total.Funding += stat.Funding
+		report.Total.UnrealizedPnL += stat.UnrealizedPnL
+		report.Total.rSum += stat.rSum
+		report.Total.rCount += stat.rCount
+	}
+	report.Total.finalize()
+	report.Symbols = sortedGroups(bySymbol)
+	report.Strategies = sortedGroups(byStrategy)
+	report.Prompts = sortedGroups(byPrompt)
+
+	return report, nil
+}
+
+// add 计入一笔已平仓交易
+func (s *SymbolPnL) add(p store.Position) {
+	s.Trades++
+	s.RealizedPnL += p.RealizedPnL
+	s.Fees += p.Fees
+	s.Funding += p.Funding
+
+	// 胜负和R倍数均按扣除手续费、计入资金费后的净盈亏计算
+	net := p.NetPnL()
+	if net > 0 {
+		s.Wins++
+	} else {
+		s.Losses++
+	}
+
+	// R倍数 = 净盈亏 / 初始风险（入场价到止损价的距离 × 数量）
+	if risk := math.Abs(p.EntryPrice-p.StopLoss) * p.Quantity; p.StopLoss > 0 && risk > 0 {
+		s.rSum += net / risk
+		s.rCount++
+	}
+}
+
+// sortedGroups 计算派生指标并按净盈亏从高到低排序
+func sortedGroups(groups map[string]*SymbolPnL) []SymbolPnL {
+	rows := make([]SymbolPnL, 0, len(groups))
+	for _, stat := range groups {
+		stat.finalize()
+		rows = append(rows, *stat)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].NetPnL > rows[j].NetPnL
+	})
+	return rows
+}
+
+// finalize 计算派生指标
+func (s *SymbolPnL) finalize() {
+	s.NetPnL = s.RealizedPnL - s.Fees + s.Funding
+	if s.Trades > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Trades) * 100
+	}
+	if s.rCount > 0 {
+		s.AvgR = s.rSum / float64(s.rCount)
+	}
+}
+
+// String 格式化为文本（用于命令行输出和通知推送）
+func (r *PnLReport) String() string {
+	var sb strings.Builder
+
+	since := "全部"
+	if !r.Period.Since.IsZero() {
+		since = r.Period.Since.Local().Format("2006-01-02 15:04")
+	}
+	sb.WriteString(fmt.Sprintf("📊 盈亏报表 [%s] 周期: %s（自 %s）\n", r.TraderID, r.Period.Name, since))
+	sb.WriteString(fmt.Sprintf("%-12s %6s %7s %11s %9s %9s %11s %11s %7s\n",
+		"币种", "交易数", "胜率", "已实现", "手续费", "资金费", "净盈亏", "未实现", "平均R"))
+
+	writeRow := func(s SymbolPnL) {
+		sb.WriteString(fmt.Sprintf("%-12s %6d %6.1f%% %+11.2f %9.2f %+9.2f %+11.2f %+11.2f %+7.2f\n",
+			s.Symbol, s.Trades, s.WinRate, s.RealizedPnL, s.Fees, s.Funding, s.NetPnL, s.UnrealizedPnL, s.AvgR))
+	}
+	for _, s := range r.Symbols {
+		writeRow(s)
+	}
+	sb.WriteString(strings.Repeat("-", 90) + "\n")
+	writeRow(r.Total)
+
+	// 只有一个分组时与合计相同，不重复输出
+	for _, section := range []struct {
+		title string
+		rows  []SymbolPnL
+	}{{"按策略", r.Strategies}, {"按提示词版本", r.Prompts}} {
+		if len(section.rows) < 2 {
+			continue
+		}
+		sb.WriteString("\n" + section.title + "\n")
+		for _, s := range section.rows {
+			writeRow(s)
+		}
+	}
+
+	return sb.String()
+}
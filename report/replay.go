@@ -0,0 +1,306 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/logger"
+	"nofx/market"
+	"nofx/store"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// replayTargetBars 自动选择K线周期时整个回放区间的目标K线数上限
+const replayTargetBars = 500
+
+// replayMinPaddingBars 入场前和出场后至少保留的K线数
+const replayMinPaddingBars = 30
+
+// KlineSource 历史K线数据源（默认为market.KlinesBetween）
+type KlineSource func(symbol, interval string, from, to time.Time) ([]market.Kline, error)
+
+// ReplayOptions 交易回放选项
+type ReplayOptions struct {
+	Interval    string      // K线周期（1m/5m/15m/1h/4h，为空时按持仓时长自动选择）
+	DecisionDir string      // 决策日志根目录（默认decision_logs）
+	Klines      KlineSource // 历史K线数据源（默认从Gate.io查询）
+	Now         time.Time   // 未平仓交易的回放截止时间（默认当前时间）
+}
+
+// ReplayCandle 一根K线（time为开盘时间的Unix秒，可直接用于TradingView lightweight-charts等图表库）
+type ReplayCandle struct {
+	Time   int64   `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// ReplayMarker 图表上的标记点（入场、出场、成交、止损止盈设置）
+type ReplayMarker struct {
+	Time  int64   `json:"time"` // Unix秒
+	Price float64 `json:"price"`
+	Kind  string  `json:"kind"` // entry/exit/fill/stop_loss/take_profit
+	Text  string  `json:"text"`
+}
+
+// ReplayLevel 止损/止盈价位（从设置时起生效，到下一次设置或交易结束为止）
+type ReplayLevel struct {
+	Kind   string    `json:"kind"` // stop_loss/take_profit
+	Price  float64   `json:"price"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Status string    `json:"status"` // placed/failed
+	Error  string    `json:"error,omitempty"`
+}
+
+// ReplayDecision 与该交易相关的AI决策（入场或出场）
+type ReplayDecision struct {
+	Role          string                  `json:"role"` // entry/exit
+	Timestamp     time.Time               `json:"timestamp"`
+	CycleNumber   int                     `json:"cycle_number"`
+	DecisionID    string                  `json:"decision_id,omitempty"`
+	PromptVersion string                  `json:"prompt_version,omitempty"`
+	Actions       []logger.DecisionAction `json:"actions"`       // 该币种的执行动作
+	DecisionJSON  json.RawMessage         `json:"decision_json"` // AI返回的完整决策JSON
+	CoTTrace      string                  `json:"cot_trace"`     // AI思维链
+}
+
+// TradeReplay 单笔交易的回放数据包（K线、决策、订单、成交、止损止盈），供图表工具复盘
+type TradeReplay struct {
+	Trade       store.Position          `json:"trade"`
+	Interval    string                  `json:"interval"`
+	From        time.Time               `json:"from"`
+	To          time.Time               `json:"to"`
+	Candles     []ReplayCandle          `json:"candles"`
+	Markers     []ReplayMarker          `json:"markers"`
+	Levels      []ReplayLevel           `json:"levels"`
+	Decisions   []ReplayDecision        `json:"decisions"`
+	Orders      []store.Order           `json:"orders"`
+	Fills       []store.Fill            `json:"fills"`
+	Protective  []store.ProtectiveOrder `json:"protective_orders"`
+	Warnings    []string                `json:"warnings,omitempty"` // 部分数据缺失的原因（如K线查询失败）
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// BuildTradeReplay 按交易ID生成回放数据包（K线或决策日志获取失败时记录在Warnings中，不影响其余数据）
+func BuildTradeReplay(s *store.Store, tradeID int64, opts ReplayOptions) (*TradeReplay, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+	trade, err := s.GetPosition(tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.DecisionDir == "" {
+		opts.DecisionDir = "decision_logs"
+	}
+	if opts.Klines == nil {
+		opts.Klines = market.KlinesBetween
+	}
+
+	end := opts.Now
+	if trade.ClosedAt != nil {
+		end = *trade.ClosedAt
+	}
+	interval, padding, err := replayWindow(trade.OpenedAt, end, opts.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &TradeReplay{
+		Trade:       *trade,
+		Interval:    interval,
+		From:        trade.OpenedAt.Add(-padding).UTC(),
+		To:          end.Add(padding).UTC(),
+		Candles:     []ReplayCandle{},
+		Orders:      []store.Order{},
+		Fills:       []store.Fill{},
+		GeneratedAt: opts.Now.UTC(),
+	}
+	if r.To.After(opts.Now) {
+		r.To = opts.Now.UTC()
+	}
+
+	// 交易期间（前后各留1分钟容差）的订单、成交和止损止盈
+	from, to := trade.OpenedAt.Add(-time.Minute), end.Add(time.Minute)
+	orders, err := s.ListSymbolOrders(trade.TraderID, trade.Symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sides := make(map[string]string, len(orders))
+	for _, o := range orders {
+		if o.OrderID != "" {
+			sides[o.OrderID] = o.Side
+		}
+		if o.Side == trade.Side {
+			r.Orders = append(r.Orders, o)
+		}
+	}
+	fills, err := s.ListSymbolFills(trade.TraderID, trade.Symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fills {
+		// 只保留该方向订单的成交（交易日志中找不到订单的成交无法判断方向，一并保留）
+		if side, ok := sides[f.OrderID]; ok && side != trade.Side {
+			continue
+		}
+		r.Fills = append(r.Fills, f)
+	}
+	protective, err := s.ListProtectiveOrders(trade.TraderID, trade.Symbol, trade.Side, from, to)
+	if err != nil {
+		return nil, err
+	}
+	r.Protective = append([]store.ProtectiveOrder{}, protective...)
+
+	klines, err := opts.Klines(trade.Symbol, interval, r.From, r.To)
+	if err != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("K线获取失败: %v", err))
+	}
+	for _, k := range klines {
+		r.Candles = append(r.Candles, ReplayCandle{Time: k.OpenTime / 1000, Open: k.Open, High: k.High, Low: k.Low,
+			Close: k.Close, Volume: k.Volume})
+	}
+
+	records, err := logger.NewDecisionLogger(filepath.Join(opts.DecisionDir, trade.TraderID)).
+		GetRecordsSince(trade.OpenedAt.Add(-time.Hour))
+	if err != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("决策日志读取失败: %v", err))
+	}
+	r.Decisions = replayDecisions(*trade, records, end)
+
+	r.Levels = replayLevels(r.Protective, end)
+	r.Markers = replayMarkers(*trade, r.Fills, r.Protective)
+	return r, nil
+}
+
+// replayWindow 选择K线周期和前后留白（自动选择时取整个区间不超过replayTargetBars根K线的最小周期）
+func replayWindow(openedAt, end time.Time, interval string) (string, time.Duration, error) {
+	held := end.Sub(openedAt)
+	if held < 0 {
+		held = 0
+	}
+	padding := func(step time.Duration) time.Duration {
+		if held/2 > replayMinPaddingBars*step {
+			return held / 2
+		}
+		return replayMinPaddingBars * step
+	}
+
+	if interval != "" {
+		step := market.HistoryIntervalDuration(interval)
+		if step == 0 {
+			return "", 0, fmt.Errorf("不支持的K线周期: %s（可选: %s）", interval, strings.Join(market.HistoryIntervals, "/"))
+		}
+		return interval, padding(step), nil
+	}
+	for _, iv := range market.HistoryIntervals {
+		step := market.HistoryIntervalDuration(iv)
+		if (held+2*padding(step))/step <= replayTargetBars {
+			return iv, padding(step), nil
+		}
+	}
+	iv := market.HistoryIntervals[len(market.HistoryIntervals)-1]
+	return iv, padding(market.HistoryIntervalDuration(iv)), nil
+}
+
+// replayDecisions 入场决策（按决策ID匹配，旧记录按开仓前最近一次该币种的开仓动作）和出场决策（持仓期间该币种的平仓动作）
+func replayDecisions(trade store.Position, records []*logger.DecisionRecord, end time.Time) []ReplayDecision {
+	openAction, closeAction := "open_"+trade.Side, "close_"+trade.Side
+	var entry *logger.DecisionRecord
+	decisions := []ReplayDecision{}
+	for _, rec := range records {
+		if trade.DecisionID != "" && rec.DecisionID == trade.DecisionID {
+			entry = rec
+			continue
+		}
+		if trade.DecisionID == "" && !rec.Timestamp.After(trade.OpenedAt) && rec.Timestamp.After(trade.OpenedAt.Add(-time.Hour)) &&
+			hasSymbolAction(rec, trade.Symbol, openAction) {
+			entry = rec
+			continue
+		}
+		if rec.Timestamp.After(trade.OpenedAt) && !rec.Timestamp.After(end.Add(time.Minute)) &&
+			hasSymbolAction(rec, trade.Symbol, closeAction) {
+			decisions = append(decisions, replayDecision("exit", rec, trade.Symbol))
+		}
+	}
+	if entry != nil {
+		decisions = append([]ReplayDecision{replayDecision("entry", entry, trade.Symbol)}, decisions...)
+	}
+	return decisions
+}
+
+// hasSymbolAction 决策记录中是否有该币种的指定动作
+func hasSymbolAction(rec *logger.DecisionRecord, symbol, action string) bool {
+	for _, a := range rec.Decisions {
+		if a.Symbol == symbol && a.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// replayDecision 转换决策记录（只保留该币种的执行动作）
+func replayDecision(role string, rec *logger.DecisionRecord, symbol string) ReplayDecision {
+	d := ReplayDecision{Role: role, Timestamp: rec.Timestamp, CycleNumber: rec.CycleNumber, DecisionID: rec.DecisionID,
+		PromptVersion: rec.PromptVersion, CoTTrace: rec.CoTTrace, Actions: []logger.DecisionAction{}}
+	for _, a := range rec.Decisions {
+		if a.Symbol == symbol {
+			d.Actions = append(d.Actions, a)
+		}
+	}
+	// 决策JSON不是合法JSON时（如AI返回的原始文本）按字符串输出
+	if json.Valid([]byte(rec.DecisionJSON)) {
+		d.DecisionJSON = json.RawMessage(rec.DecisionJSON)
+	} else {
+		d.DecisionJSON, _ = json.Marshal(rec.DecisionJSON)
+	}
+	return d
+}
+
+// replayLevels 止损/止盈价位区间（同类价位被下一次设置取代）
+func replayLevels(protective []store.ProtectiveOrder, end time.Time) []ReplayLevel {
+	levels := make([]ReplayLevel, 0, len(protective))
+	for i, p := range protective {
+		l := ReplayLevel{Kind: p.Kind, Price: p.TriggerPrice, From: p.CreatedAt, To: end, Status: p.Status, Error: p.Error}
+		for _, next := range protective[i+1:] {
+			if next.Kind == p.Kind && next.Status == "placed" {
+				l.To = next.CreatedAt
+				break
+			}
+		}
+		levels = append(levels, l)
+	}
+	return levels
+}
+
+// replayMarkers 入场、出场、成交和止损止盈设置的标记点（按时间排序）
+func replayMarkers(trade store.Position, fills []store.Fill, protective []store.ProtectiveOrder) []ReplayMarker {
+	markers := []ReplayMarker{{Time: trade.OpenedAt.Unix(), Price: trade.EntryPrice, Kind: "entry",
+		Text: fmt.Sprintf("%s %s %v @ %.6g", strings.ToUpper(trade.Side), trade.Symbol, trade.Quantity, trade.EntryPrice)}}
+	for _, f := range fills {
+		markers = append(markers, ReplayMarker{Time: f.Time.Unix(), Price: f.Price, Kind: "fill",
+			Text: fmt.Sprintf("%s %v @ %.6g", f.Side, f.Quantity, f.Price)})
+	}
+	for _, p := range protective {
+		if p.Status != "placed" {
+			continue
+		}
+		markers = append(markers, ReplayMarker{Time: p.CreatedAt.Unix(), Price: p.TriggerPrice, Kind: p.Kind,
+			Text: fmt.Sprintf("%s %.6g", p.Kind, p.TriggerPrice)})
+	}
+	if trade.ClosedAt != nil {
+		markers = append(markers, ReplayMarker{Time: trade.ClosedAt.Unix(), Price: trade.ExitPrice, Kind: "exit",
+			Text: fmt.Sprintf("exit @ %.6g 净盈亏%+.2f", trade.ExitPrice, trade.NetPnL())})
+	}
+	// 同一时间保持入场、成交、止损止盈、出场的顺序
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].Time < markers[j].Time })
+	return markers
+}
@@ -0,0 +1,219 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"nofx/store"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	minRuinTrades          = 10    // 少于该数量的已平仓交易时不做模拟（抽样分布没有代表性）
+	defaultRuinSimulations = 10000 // 默认模拟路径数
+	defaultRuinPct         = 50    // 未配置max_drawdown时的破产回撤阈值（%）
+)
+
+// RuinOptions 蒙特卡洛模拟参数
+type RuinOptions struct {
+	Simulations    int     // 模拟路径数（默认10000）
+	Trades         int     // 每条路径的交易数（默认与历史交易数相同）
+	RuinPct        float64 // 回撤达到该百分比视为破产（默认50）
+	SizeScale      float64 // 仓位缩放倍数：每笔交易的净值收益率按该倍数缩放（默认1，即沿用历史仓位）
+	InitialBalance float64 // 找不到开仓时的净值快照时，用该金额作为开仓时净值
+	Seed           int64   // 随机种子（0表示按当前时间）
+}
+
+// RuinReport 破产风险报表
+type RuinReport struct {
+	TraderID    string    `json:"trader_id"`
+	Period      Period    `json:"period"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// 历史交易
+	Trades        int     `json:"trades"`
+	WinRate       float64 `json:"win_rate"`        // %
+	MeanReturnPct float64 `json:"mean_return_pct"` // 每笔交易平均净值收益率（%，已按size_scale缩放）
+	WorstTradePct float64 `json:"worst_trade_pct"` // 最差一笔（%，已按size_scale缩放）
+
+	// 模拟参数
+	Simulations int     `json:"simulations"`
+	Horizon     int     `json:"horizon"` // 每条路径的交易数
+	RuinPct     float64 `json:"ruin_pct"`
+	SizeScale   float64 `json:"size_scale"`
+
+	// 模拟结果
+	RuinProbability float64            `json:"ruin_probability"` // %
+	MaxDrawdown     map[string]float64 `json:"max_drawdown"`     // 最大回撤分位数（%，p50/p75/p90/p95/p99）
+	FinalReturn     map[string]float64 `json:"final_return"`     // 期末收益分位数（%，p5/p50/p95）
+}
+
+// ruinPercentiles 报表输出的分位数
+var (
+	drawdownPercentiles = []float64{50, 75, 90, 95, 99}
+	returnPercentiles   = []float64{5, 50, 95}
+)
+
+// ReportRuin 从交易日志中的已平仓交易有放回抽样，按复利模拟净值路径，估计最大回撤分布和破产概率
+// 每笔交易的收益率 = 净盈亏 / 开仓时净值（取开仓前最近的净值快照），即假设仓位按净值比例确定
+func ReportRuin(s *store.Store, traderID string, period Period, opts RuinOptions) (*RuinReport, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+	if opts.Simulations <= 0 {
+		opts.Simulations = defaultRuinSimulations
+	}
+	if opts.RuinPct <= 0 || opts.RuinPct > 100 {
+		opts.RuinPct = defaultRuinPct
+	}
+	if opts.SizeScale <= 0 {
+		opts.SizeScale = 1
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	positions, err := s.ListClosedPositions(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	if len(positions) < minRuinTrades {
+		return nil, fmt.Errorf("已平仓交易只有%d笔，至少需要%d笔", len(positions), minRuinTrades)
+	}
+	equity, err := s.EquityHistory(traderID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	returns := make([]float64, 0, len(positions))
+	report := &RuinReport{
+		TraderID:    traderID,
+		Period:      period,
+		GeneratedAt: time.Now(),
+		Trades:      len(positions),
+		Simulations: opts.Simulations,
+		RuinPct:     opts.RuinPct,
+		SizeScale:   opts.SizeScale,
+		MaxDrawdown: make(map[string]float64),
+		FinalReturn: make(map[string]float64),
+	}
+	wins := 0
+	for _, p := range positions {
+		base := equityAt(equity, p.OpenedAt, opts.InitialBalance)
+		if base <= 0 {
+			return nil, fmt.Errorf("%s %s交易(%s)开仓时没有净值快照，请指定初始资金", p.Symbol, p.Side, p.OpenedAt.Format("2006-01-02 15:04"))
+		}
+		r := p.NetPnL() / base * opts.SizeScale
+		returns = append(returns, r)
+		if r > 0 {
+			wins++
+		}
+		report.MeanReturnPct += r * 100
+		report.WorstTradePct = math.Min(report.WorstTradePct, r*100)
+	}
+	report.WinRate = float64(wins) / float64(len(returns)) * 100
+	report.MeanReturnPct /= float64(len(returns))
+
+	report.Horizon = opts.Trades
+	if report.Horizon <= 0 {
+		report.Horizon = len(returns)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	drawdowns := make([]float64, opts.Simulations)
+	finals := make([]float64, opts.Simulations)
+	ruined := 0
+	for i := range drawdowns {
+		drawdowns[i], finals[i] = simulatePath(rng, returns, report.Horizon)
+		if drawdowns[i] >= opts.RuinPct {
+			ruined++
+		}
+	}
+	report.RuinProbability = float64(ruined) / float64(opts.Simulations) * 100
+
+	sort.Float64s(drawdowns)
+	sort.Float64s(finals)
+	for _, p := range drawdownPercentiles {
+		report.MaxDrawdown[percentileName(p)] = percentile(drawdowns, p)
+	}
+	for _, p := range returnPercentiles {
+		report.FinalReturn[percentileName(p)] = percentile(finals, p)
+	}
+	return report, nil
+}
+
+// simulatePath 模拟一条净值路径，返回最大回撤（%）和期末收益（%）；净值归零后停止
+func simulatePath(rng *rand.Rand, returns []float64, horizon int) (maxDrawdown, finalReturn float64) {
+	equity, peak := 1.0, 1.0
+	for i := 0; i < horizon; i++ {
+		equity *= 1 + returns[rng.Intn(len(returns))]
+		if equity <= 0 {
+			return 100, -100
+		}
+		if equity > peak {
+			peak = equity
+		}
+		if dd := (peak - equity) / peak * 100; dd > maxDrawdown {
+			maxDrawdown = dd
+		}
+	}
+	return maxDrawdown, (equity - 1) * 100
+}
+
+// equityAt 开仓前最近一次净值快照（没有更早的快照时取之后的第一个，没有快照时返回fallback）
+func equityAt(history []store.BalanceSnapshot, t time.Time, fallback float64) float64 {
+	i := sort.Search(len(history), func(i int) bool { return history[i].Time.After(t) })
+	if i > 0 {
+		return history[i-1].TotalEquity
+	}
+	if len(history) > 0 {
+		return history[0].TotalEquity
+	}
+	return fallback
+}
+
+// percentile 已排序数据的分位数（最近秩）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// percentileName 分位数名称（如 p95）
+func percentileName(p float64) string {
+	return fmt.Sprintf("p%g", p)
+}
+
+// String 格式化为文本（用于命令行输出）
+func (r *RuinReport) String() string {
+	var sb strings.Builder
+
+	since := "全部"
+	if !r.Period.Since.IsZero() {
+		since = r.Period.Since.Local().Format("2006-01-02 15:04")
+	}
+	sb.WriteString(fmt.Sprintf("🎲 破产风险分析 [%s] 周期: %s（自 %s）\n", r.TraderID, r.Period.Name, since))
+	sb.WriteString(fmt.Sprintf("历史交易: %d笔 | 胜率 %.1f%% | 平均每笔 %+.3f%% | 最差一笔 %+.2f%%（占开仓时净值，仓位倍数 %.2fx）\n",
+		r.Trades, r.WinRate, r.MeanReturnPct, r.WorstTradePct, r.SizeScale))
+	sb.WriteString(fmt.Sprintf("模拟: %d条路径 × %d笔交易（有放回抽样，按复利计算）\n\n", r.Simulations, r.Horizon))
+
+	sb.WriteString(fmt.Sprintf("破产概率（最大回撤 ≥ %.0f%%）: %.2f%%\n\n", r.RuinPct, r.RuinProbability))
+	sb.WriteString("最大回撤分布:\n")
+	for _, p := range drawdownPercentiles {
+		name := percentileName(p)
+		sb.WriteString(fmt.Sprintf("  %-4s %6.2f%%\n", name, r.MaxDrawdown[name]))
+	}
+	sb.WriteString("期末收益分布:\n")
+	for _, p := range returnPercentiles {
+		name := percentileName(p)
+		sb.WriteString(fmt.Sprintf("  %-4s %+8.2f%%\n", name, r.FinalReturn[name]))
+	}
+	return sb.String()
+}
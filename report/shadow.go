@@ -0,0 +1,261 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/logger"
+	"nofx/store"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 同一币种两边决策的对比结果
+const (
+	DivergenceAgree      = "agree"       // 动作相同
+	DivergenceLiveOnly   = "live_only"   // 只有实盘有动作（影子观望）
+	DivergenceShadowOnly = "shadow_only" // 只有影子有动作（实盘观望）
+	DivergenceConflict   = "conflict"    // 两边动作不同（如一边开多一边开空）
+)
+
+// shadowRecentDivergences 报表中列出的最近分歧条数
+const shadowRecentDivergences = 20
+
+// Divergence 一次分歧：配对周期中同一币种两边的动作不同
+type Divergence struct {
+	Time         time.Time `json:"time"` // 实盘决策时间
+	Symbol       string    `json:"symbol"`
+	Kind         string    `json:"kind"`          // live_only/shadow_only/conflict
+	LiveAction   string    `json:"live_action"`   // 为空表示观望
+	ShadowAction string    `json:"shadow_action"` // 为空表示观望
+}
+
+// SymbolDivergence 单币种的决策对比统计
+type SymbolDivergence struct {
+	Symbol     string `json:"symbol"`
+	Agree      int    `json:"agree"`
+	LiveOnly   int    `json:"live_only"`
+	ShadowOnly int    `json:"shadow_only"`
+	Conflict   int    `json:"conflict"`
+}
+
+// diverged 分歧次数
+func (s SymbolDivergence) diverged() int {
+	return s.LiveOnly + s.ShadowOnly + s.Conflict
+}
+
+// ShadowSide 一边（实盘或影子）的汇总
+type ShadowSide struct {
+	TraderID       string    `json:"trader_id"`
+	PromptVersions []string  `json:"prompt_versions"` // 周期内决策使用的提示词版本
+	Cycles         int       `json:"cycles"`          // 成功的决策周期数
+	Actions        int       `json:"actions"`         // 非观望的决策数
+	PnL            SymbolPnL `json:"pnl"`             // 已平仓交易盈亏（影子为模拟交易的假设盈亏）
+}
+
+// ShadowReport 影子模式对比报表：按时间配对两边的决策周期，逐币种比较动作，并对比两边的盈亏
+type ShadowReport struct {
+	Period        Period             `json:"period"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	Live          ShadowSide         `json:"live"`
+	Shadow        ShadowSide         `json:"shadow"`
+	Window        time.Duration      `json:"window"`         // 配对时间窗口
+	Paired        int                `json:"paired"`         // 配对成功的周期数
+	AgreedCycles  int                `json:"agreed_cycles"`  // 所有币种动作都相同的配对周期数
+	AgreementRate float64            `json:"agreement_rate"` // 完全一致的配对周期占比（%）
+	Symbols       []SymbolDivergence `json:"symbols"`        // 按分歧次数从多到少排序
+	Recent        []Divergence       `json:"recent"`         // 最近的分歧（从新到旧）
+}
+
+// ReportShadow 生成影子模式对比报表。live/shadow为两边的决策记录（按时间正序），
+// 每个实盘周期与window内最近的、尚未配对的影子周期配对；盈亏取自交易日志中两个trader的已平仓交易
+func ReportShadow(s *store.Store, liveID, shadowID string, live, shadow []*logger.DecisionRecord, period Period, window time.Duration) (*ShadowReport, error) {
+	report := &ShadowReport{
+		Period:      period,
+		GeneratedAt: time.Now(),
+		Live:        shadowSide(liveID, live),
+		Shadow:      shadowSide(shadowID, shadow),
+		Window:      window,
+	}
+	for _, side := range []*ShadowSide{&report.Live, &report.Shadow} {
+		pnl, err := ReportPnL(s, side.TraderID, period, nil)
+		if err != nil {
+			return nil, err
+		}
+		side.PnL = pnl.Total
+		if snapshot, err := s.LatestBalance(side.TraderID); err == nil && snapshot != nil {
+			side.PnL.UnrealizedPnL = snapshot.UnrealizedPnL
+		}
+	}
+
+	bySymbol := make(map[string]*SymbolDivergence)
+	used := make([]bool, len(shadow))
+	for _, l := range live {
+		if !l.Success {
+			continue
+		}
+		match := -1
+		for j, sh := range shadow {
+			if used[j] || !sh.Success {
+				continue
+			}
+			gap := absDuration(sh.Timestamp.Sub(l.Timestamp))
+			if gap <= window && (match < 0 || gap < absDuration(shadow[match].Timestamp.Sub(l.Timestamp))) {
+				match = j
+			}
+		}
+		if match < 0 {
+			continue
+		}
+		used[match] = true
+		report.Paired++
+
+		liveActions, shadowActions := recordActions(l), recordActions(shadow[match])
+		symbols := make(map[string]bool)
+		for symbol := range liveActions {
+			symbols[symbol] = true
+		}
+		for symbol := range shadowActions {
+			symbols[symbol] = true
+		}
+
+		agreed := true
+		for symbol := range symbols {
+			stat, ok := bySymbol[symbol]
+			if !ok {
+				stat = &SymbolDivergence{Symbol: symbol}
+				bySymbol[symbol] = stat
+			}
+			la, sa := liveActions[symbol], shadowActions[symbol]
+			kind := DivergenceConflict
+			switch {
+			case la == sa:
+				stat.Agree++
+				continue
+			case sa == "":
+				kind = DivergenceLiveOnly
+				stat.LiveOnly++
+			case la == "":
+				kind = DivergenceShadowOnly
+				stat.ShadowOnly++
+			default:
+				stat.Conflict++
+			}
+			agreed = false
+			report.Recent = append(report.Recent, Divergence{Time: l.Timestamp, Symbol: symbol, Kind: kind, LiveAction: la, ShadowAction: sa})
+		}
+		if agreed {
+			report.AgreedCycles++
+		}
+	}
+	if report.Paired > 0 {
+		report.AgreementRate = float64(report.AgreedCycles) / float64(report.Paired) * 100
+	}
+
+	for _, stat := range bySymbol {
+		report.Symbols = append(report.Symbols, *stat)
+	}
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		a, b := report.Symbols[i], report.Symbols[j]
+		if a.diverged() != b.diverged() {
+			return a.diverged() > b.diverged()
+		}
+		return a.Symbol < b.Symbol
+	})
+	sort.SliceStable(report.Recent, func(i, j int) bool { return report.Recent[i].Time.After(report.Recent[j].Time) })
+	if len(report.Recent) > shadowRecentDivergences {
+		report.Recent = report.Recent[:shadowRecentDivergences]
+	}
+	return report, nil
+}
+
+// shadowSide 统计一边的决策周期、动作数和提示词版本
+func shadowSide(traderID string, records []*logger.DecisionRecord) ShadowSide {
+	side := ShadowSide{TraderID: traderID}
+	versions := make(map[string]bool)
+	for _, r := range records {
+		if !r.Success {
+			continue
+		}
+		side.Cycles++
+		side.Actions += len(recordActions(r))
+		if r.PromptVersion != "" && !versions[r.PromptVersion] {
+			versions[r.PromptVersion] = true
+			side.PromptVersions = append(side.PromptVersions, r.PromptVersion)
+		}
+	}
+	return side
+}
+
+// recordActions 解析决策记录中AI给出的非观望动作（symbol -> action，hold/wait视为观望）
+func recordActions(r *logger.DecisionRecord) map[string]string {
+	var decisions []struct {
+		Symbol string `json:"symbol"`
+		Action string `json:"action"`
+	}
+	actions := make(map[string]string)
+	if r.DecisionJSON == "" || json.Unmarshal([]byte(r.DecisionJSON), &decisions) != nil {
+		return actions
+	}
+	for _, d := range decisions {
+		if d.Action == "" || d.Action == "hold" || d.Action == "wait" {
+			continue
+		}
+		actions[d.Symbol] = d.Action
+	}
+	return actions
+}
+
+// absDuration 时长的绝对值
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// String 格式化为文本（用于命令行输出）
+func (r *ShadowReport) String() string {
+	var sb strings.Builder
+
+	since := "全部"
+	if !r.Period.Since.IsZero() {
+		since = r.Period.Since.Local().Format("2006-01-02 15:04")
+	}
+	sb.WriteString(fmt.Sprintf("🪞 影子模式对比 [%s ↔ %s] 周期: %s（自 %s）\n", r.Live.TraderID, r.Shadow.TraderID, r.Period.Name, since))
+	sb.WriteString(fmt.Sprintf("%-8s %-16s %-12s %6s %6s %6s %7s %11s %11s\n",
+		"", "trader", "提示词版本", "周期数", "动作数", "交易数", "胜率", "净盈亏", "未实现"))
+	for _, row := range []struct {
+		label string
+		side  ShadowSide
+	}{{"实盘", r.Live}, {"影子", r.Shadow}} {
+		sb.WriteString(fmt.Sprintf("%-8s %-16s %-12s %6d %6d %6d %6.1f%% %+11.2f %+11.2f\n",
+			row.label, row.side.TraderID, strings.Join(row.side.PromptVersions, ","), row.side.Cycles, row.side.Actions,
+			row.side.PnL.Trades, row.side.PnL.WinRate, row.side.PnL.NetPnL, row.side.PnL.UnrealizedPnL))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n配对周期: %d（窗口 %s） | 完全一致: %d（%.1f%%）\n", r.Paired, r.Window, r.AgreedCycles, r.AgreementRate))
+	if len(r.Symbols) > 0 {
+		sb.WriteString(fmt.Sprintf("%-12s %6s %8s %8s %6s\n", "币种", "一致", "仅实盘", "仅影子", "相反"))
+		for _, s := range r.Symbols {
+			sb.WriteString(fmt.Sprintf("%-12s %6d %8d %8d %6d\n", s.Symbol, s.Agree, s.LiveOnly, s.ShadowOnly, s.Conflict))
+		}
+	}
+
+	if len(r.Recent) > 0 {
+		sb.WriteString("\n最近的分歧\n")
+		for _, d := range r.Recent {
+			sb.WriteString(fmt.Sprintf("%s %-12s 实盘: %-12s 影子: %s\n",
+				d.Time.Local().Format("01-02 15:04"), d.Symbol, actionOrWait(d.LiveAction), actionOrWait(d.ShadowAction)))
+		}
+	}
+	return sb.String()
+}
+
+// actionOrWait 空动作显示为观望
+func actionOrWait(action string) string {
+	if action == "" {
+		return "wait"
+	}
+	return action
+}
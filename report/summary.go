@@ -0,0 +1,189 @@
+package report
+
+import (
+	"fmt"
+	"nofx/fx"
+	"nofx/mcp"
+	"nofx/scheduler"
+	"nofx/store"
+	"strings"
+	"time"
+)
+
+// SummaryConfig 定时汇总报告配置
+type SummaryConfig struct {
+	Enabled   bool                 `json:"enabled"`
+	Daily     string               `json:"daily"`      // 日报推送时间（cron，UTC，默认 "0 0 * * *"；"-" 表示不推送）
+	Weekly    string               `json:"weekly"`     // 周报推送时间（cron，UTC，默认 "0 0 * * 1"；"-" 表示不推送）
+	AttachCSV bool                 `json:"attach_csv"` // 附带周期内交易明细CSV（Telegram/Discord支持）
+	AIPrices  map[string]mcp.Price `json:"ai_prices"`  // 覆盖内置模型单价（美元/百万token），key为模型名
+}
+
+// Validate 验证汇总报告配置并填充默认值
+func (c *SummaryConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Daily == "" {
+		c.Daily = "0 0 * * *"
+	}
+	if c.Weekly == "" {
+		c.Weekly = "0 0 * * 1"
+	}
+	for name, spec := range map[string]string{"daily": c.Daily, "weekly": c.Weekly} {
+		if spec == "-" {
+			continue
+		}
+		if _, err := scheduler.Parse(spec); err != nil {
+			return fmt.Errorf("summary.%s: %w", name, err)
+		}
+	}
+	for model, price := range c.AIPrices {
+		if price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("summary.ai_prices.%s不能为负数", model)
+		}
+	}
+	return nil
+}
+
+// TradeBrief 单笔交易摘要（最佳/最差交易）
+type TradeBrief struct {
+	Symbol string  `json:"symbol"`
+	Side   string  `json:"side"`
+	NetPnL float64 `json:"net_pnl"`
+}
+
+// Summary 周期汇总报告
+type Summary struct {
+	TraderID    string      `json:"trader_id"`
+	Period      Period      `json:"period"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Trades      int         `json:"trades"`
+	Wins        int         `json:"wins"`
+	WinRate     float64     `json:"win_rate"` // 胜率（%）
+	RealizedPnL float64     `json:"realized_pnl"`
+	Fees        float64     `json:"fees"`
+	Funding     float64     `json:"funding"`
+	NetPnL      float64     `json:"net_pnl"`
+	Best        *TradeBrief `json:"best,omitempty"`
+	Worst       *TradeBrief `json:"worst,omitempty"`
+	StartEquity float64     `json:"start_equity"`
+	EndEquity   float64     `json:"end_equity"`
+	MaxDrawdown float64     `json:"max_drawdown"` // 周期内最大回撤（%，按净值快照计算）
+	AICalls     int         `json:"ai_calls"`
+	AITokens    int         `json:"ai_tokens"`
+	AICost      float64     `json:"ai_cost"`       // 估算AI成本（美元）
+	AICostKnown bool        `json:"ai_cost_known"` // 所有模型都有单价时为true
+	AIModels    []string    `json:"ai_models,omitempty"`
+	Currency    *fx.Quote   `json:"currency,omitempty"` // 报告币种及生成报告时的汇率（未配置报告币种时为nil）
+}
+
+// BuildSummary 根据交易日志生成周期汇总（盈亏、最佳/最差交易、手续费、资金费、回撤、AI成本）
+func BuildSummary(s *store.Store, traderID string, period Period, prices map[string]mcp.Price) (*Summary, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+
+	summary := &Summary{TraderID: traderID, Period: period, GeneratedAt: time.Now(), AICostKnown: true, Currency: fx.Current()}
+
+	positions, err := s.ListClosedPositions(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range positions {
+		net := p.NetPnL()
+		summary.Trades++
+		summary.RealizedPnL += p.RealizedPnL
+		summary.Fees += p.Fees
+		summary.Funding += p.Funding
+		summary.NetPnL += net
+		if net > 0 {
+			summary.Wins++
+		}
+		if summary.Best == nil || net > summary.Best.NetPnL {
+			summary.Best = &TradeBrief{Symbol: p.Symbol, Side: p.Side, NetPnL: net}
+		}
+		if summary.Worst == nil || net < summary.Worst.NetPnL {
+			summary.Worst = &TradeBrief{Symbol: p.Symbol, Side: p.Side, NetPnL: net}
+		}
+	}
+	if summary.Trades > 0 {
+		summary.WinRate = float64(summary.Wins) / float64(summary.Trades) * 100
+	}
+
+	equity, err := s.EquityHistory(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	if len(equity) > 0 {
+		summary.StartEquity = equity[0].TotalEquity
+		summary.EndEquity = equity[len(equity)-1].TotalEquity
+		peak := 0.0
+		for _, snapshot := range equity {
+			if snapshot.TotalEquity > peak {
+				peak = snapshot.TotalEquity
+			}
+			if peak > 0 {
+				if dd := (peak - snapshot.TotalEquity) / peak * 100; dd > summary.MaxDrawdown {
+					summary.MaxDrawdown = dd
+				}
+			}
+		}
+	}
+
+	usage, err := s.SumAIUsage(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range usage {
+		summary.AICalls += u.Calls
+		summary.AITokens += u.PromptTokens + u.CompletionTokens
+		summary.AIModels = append(summary.AIModels, u.Model)
+		if price, ok := mcp.LookupPrice(u.Model, prices); ok {
+			summary.AICost += price.Cost(u.PromptTokens, u.CompletionTokens)
+		} else {
+			summary.AICostKnown = false
+		}
+	}
+
+	return summary, nil
+}
+
+// local 按报告生成时的汇率换算的附注（未配置报告币种时为空）
+func (r *Summary) local(usdt float64, signed bool) string {
+	if r.Currency == nil {
+		return ""
+	}
+	return fmt.Sprintf("（≈ %s）", r.Currency.Format(usdt, signed))
+}
+
+// String 格式化为文本（用于通知推送）
+func (r *Summary) String() string {
+	var sb strings.Builder
+
+	since := "全部"
+	if !r.Period.Since.IsZero() {
+		since = r.Period.Since.UTC().Format("2006-01-02 15:04") + " UTC"
+	}
+	sb.WriteString(fmt.Sprintf("周期: %s（自 %s）\n", r.Period.Name, since))
+	sb.WriteString(fmt.Sprintf("交易: %d笔 | 胜率: %.1f%%\n", r.Trades, r.WinRate))
+	sb.WriteString(fmt.Sprintf("净盈亏: %+.2f USDT%s（已实现 %+.2f / 手续费 %.2f / 资金费 %+.2f）\n",
+		r.NetPnL, r.local(r.NetPnL, true), r.RealizedPnL, r.Fees, r.Funding))
+	if r.Best != nil {
+		sb.WriteString(fmt.Sprintf("最佳交易: %s %s %+.2f USDT%s\n", r.Best.Symbol, strings.ToUpper(r.Best.Side), r.Best.NetPnL, r.local(r.Best.NetPnL, true)))
+		sb.WriteString(fmt.Sprintf("最差交易: %s %s %+.2f USDT%s\n", r.Worst.Symbol, strings.ToUpper(r.Worst.Side), r.Worst.NetPnL, r.local(r.Worst.NetPnL, true)))
+	}
+	if r.StartEquity > 0 {
+		sb.WriteString(fmt.Sprintf("净值: %.2f → %.2f USDT%s | 最大回撤: %.2f%%\n", r.StartEquity, r.EndEquity, r.local(r.EndEquity, false), r.MaxDrawdown))
+	}
+	if r.Currency != nil {
+		sb.WriteString(fmt.Sprintf("汇率: 1 USDT = %.4f %s（%s）\n", r.Currency.Rate, r.Currency.Currency, r.Currency.UpdatedAt.UTC().Format("2006-01-02 15:04 UTC")))
+	}
+	cost := fmt.Sprintf("$%.4f", r.AICost)
+	if !r.AICostKnown {
+		cost += "（部分模型无单价，未计入）"
+	}
+	sb.WriteString(fmt.Sprintf("AI: %d次调用 | %d tokens | 成本 %s", r.AICalls, r.AITokens, cost))
+
+	return sb.String()
+}
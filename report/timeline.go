@@ -0,0 +1,34 @@
+package report
+
+import (
+	"fmt"
+	"nofx/store"
+	"strings"
+)
+
+// timelineLabels 时间线类型的显示名称
+var timelineLabels = map[string]string{
+	store.TimelineDecision: "决策",
+	store.TimelineOrder:    "订单",
+	store.TimelineFill:     "成交",
+	store.TimelineRisk:     "风控",
+	store.TimelineConfig:   "配置",
+	store.TimelineError:    "错误",
+}
+
+// FormatTimeline 时间线文本（entries按时间倒序传入，按时间先后输出，用于命令行）
+func FormatTimeline(entries []store.TimelineEntry) string {
+	if len(entries) == 0 {
+		return "没有符合条件的事件\n"
+	}
+	var sb strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		label := timelineLabels[e.Kind]
+		if label == "" {
+			label = e.Kind
+		}
+		fmt.Fprintf(&sb, "%s [%s] %s\n", e.Time.Local().Format("01-02 15:04:05"), label, e.Summary)
+	}
+	return sb.String()
+}
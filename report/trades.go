@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"nofx/store"
+	"strings"
+)
+
+// ListTrades 指定周期内已平仓的交易（按平仓时间倒序，tag非空时只保留带该标签的交易，limit<=0表示不限）
+func ListTrades(s *store.Store, traderID string, period Period, tag string, limit int) ([]store.Position, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+	positions, err := s.ListClosedPositions(traderID, period.Since)
+	if err != nil {
+		return nil, err
+	}
+	var trades []store.Position
+	for i := len(positions) - 1; i >= 0; i-- {
+		if tag != "" && !positions[i].HasTag(tag) {
+			continue
+		}
+		trades = append(trades, positions[i])
+		if limit > 0 && len(trades) >= limit {
+			break
+		}
+	}
+	return trades, nil
+}
+
+// FormatTrade 单笔交易的一行摘要（含ID，用于备注和打标签）
+func FormatTrade(p store.Position) string {
+	closedAt := "持仓中"
+	if p.ClosedAt != nil {
+		closedAt = p.ClosedAt.Local().Format("01-02 15:04")
+	}
+	line := fmt.Sprintf("#%d %s %s %s 入场%.4f 出场%.4f 净盈亏%+.2f", p.ID, closedAt, p.Symbol,
+		strings.ToUpper(p.Side), p.EntryPrice, p.ExitPrice, p.NetPnL())
+	if len(p.Tags) > 0 {
+		line += " [" + strings.Join(p.Tags, ", ") + "]"
+	}
+	if p.Note != "" {
+		line += " 备注: " + p.Note
+	}
+	return line
+}
+
+// FormatTrades 交易列表（用于命令行和Telegram输出）
+func FormatTrades(trades []store.Position) string {
+	if len(trades) == 0 {
+		return "没有符合条件的交易\n"
+	}
+	var sb strings.Builder
+	for _, p := range trades {
+		sb.WriteString(FormatTrade(p) + "\n")
+	}
+	return sb.String()
+}
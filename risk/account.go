@@ -0,0 +1,62 @@
+package risk
+
+import "fmt"
+
+// EffectiveLeverage 账户有效杠杆：所有持仓总名义价值 / 账户净值（净值无效时为0）
+func EffectiveLeverage(notional, equity float64) float64 {
+	if equity <= 0 {
+		return 0
+	}
+	return notional / equity
+}
+
+// MarginUtilizationPct 保证金使用率（%）：已占用保证金 / 账户净值（净值无效时为0）
+func MarginUtilizationPct(marginUsed, equity float64) float64 {
+	if equity <= 0 {
+		return 0
+	}
+	return marginUsed / equity * 100
+}
+
+// AccountLimits 账户级限制：开仓后的有效杠杆和保证金使用率上限（0表示不限制）
+type AccountLimits struct {
+	MaxEffectiveLeverage    float64 `json:"max_effective_leverage"`     // 总名义价值 / 净值上限
+	MaxMarginUtilizationPct float64 `json:"max_margin_utilization_pct"` // 已占用保证金 / 净值上限（%）
+}
+
+// Enabled 是否启用账户级限制
+func (l AccountLimits) Enabled() bool {
+	return l.MaxEffectiveLeverage > 0 || l.MaxMarginUtilizationPct > 0
+}
+
+// Validate 验证配置
+func (l AccountLimits) Validate() error {
+	if l.MaxEffectiveLeverage < 0 {
+		return fmt.Errorf("leverage.account.max_effective_leverage不能为负数: %.2f", l.MaxEffectiveLeverage)
+	}
+	if l.MaxMarginUtilizationPct < 0 || l.MaxMarginUtilizationPct > 100 {
+		return fmt.Errorf("leverage.account.max_margin_utilization_pct必须在0-100之间: %.2f", l.MaxMarginUtilizationPct)
+	}
+	return nil
+}
+
+// CheckAdd 检查追加addNotional名义价值（占用addMargin保证金）后是否超出账户级限制
+func (l AccountLimits) CheckAdd(notional, marginUsed, equity, addNotional, addMargin float64) error {
+	if !l.Enabled() {
+		return nil
+	}
+	if equity <= 0 {
+		return fmt.Errorf("账户净值无效(%.2f)，拒绝增加敞口", equity)
+	}
+	if l.MaxEffectiveLeverage > 0 {
+		if leverage := EffectiveLeverage(notional+addNotional, equity); leverage > l.MaxEffectiveLeverage {
+			return fmt.Errorf("开仓后账户有效杠杆将达到%.2fx，超过上限%.2fx", leverage, l.MaxEffectiveLeverage)
+		}
+	}
+	if l.MaxMarginUtilizationPct > 0 {
+		if pct := MarginUtilizationPct(marginUsed+addMargin, equity); pct > l.MaxMarginUtilizationPct {
+			return fmt.Errorf("开仓后保证金使用率将达到%.1f%%，超过上限%.1f%%", pct, l.MaxMarginUtilizationPct)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package risk
+
+import "fmt"
+
+// MaxADLRank 自动减仓（ADL）排名最大值：交易所按盈利和杠杆把持仓分为1-5档，档位越高越先被自动减仓
+const MaxADLRank = 5
+
+// ADLGuard 自动减仓预警：持仓的ADL排名达到warn_rank时告警，达到reduce_rank时主动减仓reduce_pct%（0表示不减仓）
+// 强平单无法被保险基金承接时，交易所会按排名强制平掉对手方的盈利持仓，事先没有任何通知
+type ADLGuard struct {
+	WarnRank   int     `json:"warn_rank"`   // 排名达到该档时告警（1-5，0表示不启用）
+	ReduceRank int     `json:"reduce_rank"` // 排名达到该档时主动减仓（1-5，0表示只告警不减仓）
+	ReducePct  float64 `json:"reduce_pct"`  // 每次主动减仓的比例（%）
+}
+
+// Enabled 是否启用
+func (g ADLGuard) Enabled() bool {
+	return g.WarnRank > 0
+}
+
+// Validate 验证配置
+func (g ADLGuard) Validate() error {
+	if g.WarnRank < 0 || g.WarnRank > MaxADLRank {
+		return fmt.Errorf("adl_guard.warn_rank必须在0-%d之间: %d", MaxADLRank, g.WarnRank)
+	}
+	if g.ReduceRank < 0 || g.ReduceRank > MaxADLRank {
+		return fmt.Errorf("adl_guard.reduce_rank必须在0-%d之间: %d", MaxADLRank, g.ReduceRank)
+	}
+	if g.ReduceRank > 0 && (g.WarnRank == 0 || g.ReduceRank < g.WarnRank) {
+		return fmt.Errorf("adl_guard.reduce_rank(%d)不能低于warn_rank(%d)", g.ReduceRank, g.WarnRank)
+	}
+	if g.ReduceRank > 0 && (g.ReducePct <= 0 || g.ReducePct > 100) {
+		return fmt.Errorf("adl_guard.reduce_pct必须在0-100之间（不含0）: %.1f", g.ReducePct)
+	}
+	return nil
+}
+
+// Warn 该排名是否需要告警（排名不在1-5之间表示交易所未给出排名）
+func (g ADLGuard) Warn(rank int) bool {
+	return g.Enabled() && rank >= g.WarnRank && rank <= MaxADLRank
+}
+
+// Reduce 该排名是否需要主动减仓
+func (g ADLGuard) Reduce(rank int) bool {
+	return g.ReduceRank > 0 && rank >= g.ReduceRank && rank <= MaxADLRank
+}
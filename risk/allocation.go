@@ -0,0 +1,148 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// AllocationStrategies 可分配资金的策略（DCA/顺势加仓的加仓计入所属持仓的策略）
+var AllocationStrategies = []string{"ai", "webhook", "funding_harvest", "basis", "hedge"}
+
+// Allocation 多策略资金分配：按净值比例为每个策略分配开仓额度，定期按各策略近期收益调整比例
+// 策略额度 = 比例 × 账户净值 × max_exposure_multiple，所有策略合计不超过账户总敞口上限
+type Allocation struct {
+	Weights             map[string]float64 `json:"weights"`               // 策略→净值比例（ai/webhook/funding_harvest/basis），合计不能超过1
+	MaxExposureMultiple float64            `json:"max_exposure_multiple"` // 账户总持仓名义价值上限（净值倍数）
+	Rebalance           string             `json:"rebalance,omitempty"`   // 再平衡周期（如 "0 0 * * *"，为空表示比例固定）
+	LookbackDays        int                `json:"lookback_days"`         // 收益统计回看天数（默认7）
+	MinWeight           float64            `json:"min_weight"`            // 单策略最低比例（默认0）
+	MaxWeight           float64            `json:"max_weight"`            // 单策略最高比例（默认1）
+	MaxStep             float64            `json:"max_step"`              // 单次再平衡单策略最大调整幅度（默认0.1）
+}
+
+// Enabled 是否启用资金分配
+func (a Allocation) Enabled() bool {
+	return len(a.Weights) > 0
+}
+
+// Validate 验证资金分配配置并填充默认值
+func (a *Allocation) Validate() error {
+	if !a.Enabled() {
+		return nil
+	}
+	total := 0.0
+	for name, w := range a.Weights {
+		if !isAllocationStrategy(name) {
+			return fmt.Errorf("allocation.weights不支持策略%s（可选: %v）", name, AllocationStrategies)
+		}
+		if w < 0 || math.IsNaN(w) {
+			return fmt.Errorf("allocation.weights.%s不能为负数", name)
+		}
+		total += w
+	}
+	if total <= 0 || total > 1+1e-9 {
+		return fmt.Errorf("allocation.weights合计必须在0-1之间: %.4f", total)
+	}
+	if a.MaxExposureMultiple <= 0 {
+		return fmt.Errorf("allocation.max_exposure_multiple必须大于0")
+	}
+	if a.LookbackDays < 0 {
+		return fmt.Errorf("allocation.lookback_days不能为负数")
+	}
+	if a.LookbackDays == 0 {
+		a.LookbackDays = 7
+	}
+	if a.MaxWeight == 0 {
+		a.MaxWeight = 1
+	}
+	if a.MinWeight < 0 || a.MinWeight > a.MaxWeight || a.MaxWeight > 1 {
+		return fmt.Errorf("allocation.min_weight/max_weight必须满足0≤min≤max≤1")
+	}
+	if a.MinWeight*float64(len(a.Weights)) > total+1e-9 {
+		return fmt.Errorf("allocation.min_weight × 策略数超过比例合计%.4f", total)
+	}
+	if a.MaxStep < 0 || a.MaxStep > 1 {
+		return fmt.Errorf("allocation.max_step必须在0-1之间")
+	}
+	if a.MaxStep == 0 {
+		a.MaxStep = 0.1
+	}
+	return nil
+}
+
+// isAllocationStrategy 是否为可分配资金的策略
+func isAllocationStrategy(name string) bool {
+	for _, s := range AllocationStrategies {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Budget 策略的持仓名义价值额度（USDT）
+func (a Allocation) Budget(weight, equity float64) float64 {
+	return weight * equity * a.MaxExposureMultiple
+}
+
+// CheckAdd 检查策略追加addValue名义价值后是否超出其额度和账户总敞口上限
+// strategyValue: 该策略当前持仓名义价值，totalValue: 所有策略当前持仓名义价值
+func (a Allocation) CheckAdd(strategy string, weight, strategyValue, totalValue, addValue, equity float64) error {
+	if equity <= 0 {
+		return fmt.Errorf("账户净值无效(%.2f)，拒绝增加敞口", equity)
+	}
+	if budget := a.Budget(weight, equity); strategyValue+addValue > budget {
+		return fmt.Errorf("策略%s持仓价值将达到%.2f USDT，超过分配额度%.2f USDT（比例%.1f%%）",
+			strategy, strategyValue+addValue, budget, weight*100)
+	}
+	if maxTotal := equity * a.MaxExposureMultiple; totalValue+addValue > maxTotal {
+		return fmt.Errorf("总持仓价值将达到%.2f USDT，超过账户上限%.2f USDT", totalValue+addValue, maxTotal)
+	}
+	return nil
+}
+
+// RebalanceWeights 按各策略回看期内的收益率（净盈亏/分配资金）调整比例：
+// 收益率高于平均的策略按差值增加比例，低于平均的减少，单次调整不超过max_step，
+// 结果限制在[min_weight, max_weight]之间并保持比例合计不变（合计只会减少，不会超过原合计）
+func (a Allocation) RebalanceWeights(current, returns map[string]float64) map[string]float64 {
+	names := make([]string, 0, len(current))
+	total, mean := 0.0, 0.0
+	for name, w := range current {
+		names = append(names, name)
+		total += w
+		mean += returns[name]
+	}
+	if len(names) == 0 || total <= 0 {
+		return current
+	}
+	sort.Strings(names)
+	mean /= float64(len(names))
+
+	next := make(map[string]float64, len(names))
+	sum := 0.0
+	for _, name := range names {
+		w := current[name]
+		step := math.Max(-a.MaxStep, math.Min(a.MaxStep, w*(returns[name]-mean)))
+		next[name] = math.Max(0, w+step)
+		sum += next[name]
+	}
+
+	// 归一化到原合计后限制上下限，超出部分按比例从其他策略扣除
+	for _, name := range names {
+		if sum > 0 {
+			next[name] = next[name] / sum * total
+		}
+		next[name] = math.Max(a.MinWeight, math.Min(a.MaxWeight, next[name]))
+	}
+	sum = 0
+	for _, name := range names {
+		sum += next[name]
+	}
+	if sum > total {
+		for _, name := range names {
+			next[name] = next[name] / sum * total
+		}
+	}
+	return next
+}
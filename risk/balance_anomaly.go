@@ -0,0 +1,55 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// BalanceAnomaly 余额异常检测：按交易日志中的平仓盈亏、手续费、资金费和账户资金流水推算钱包余额的预期变化，
+// 实际余额偏离超过容差时告警并强制对账（漏记成交、未预期的费用、人工操作或程序错误）
+type BalanceAnomaly struct {
+	Enabled       bool    `json:"enabled"`
+	ToleranceUSD  float64 `json:"tolerance_usd"` // 绝对容差（USDT，默认5）
+	TolerancePct  float64 `json:"tolerance_pct"` // 相对基准钱包余额的容差（%，默认0.5），与绝对容差取较大值
+	Confirmations int     `json:"confirmations"` // 连续超出容差的检查次数达到该值才告警（默认2，成交和费用流水可能延迟同步）
+}
+
+// Validate 验证配置
+func (b BalanceAnomaly) Validate() error {
+	if b.ToleranceUSD < 0 {
+		return fmt.Errorf("balance_anomaly.tolerance_usd不能为负数")
+	}
+	if b.TolerancePct < 0 {
+		return fmt.Errorf("balance_anomaly.tolerance_pct不能为负数")
+	}
+	if b.Confirmations < 0 {
+		return fmt.Errorf("balance_anomaly.confirmations不能为负数")
+	}
+	return nil
+}
+
+// Limits 绝对容差（USDT）和相对容差（%），未配置时使用默认值
+func (b BalanceAnomaly) Limits() (usd, pct float64) {
+	usd, pct = b.ToleranceUSD, b.TolerancePct
+	if usd == 0 {
+		usd = 5
+	}
+	if pct == 0 {
+		pct = 0.5
+	}
+	return usd, pct
+}
+
+// Tolerance 基准钱包余额为wallet时允许的偏离（USDT）
+func (b BalanceAnomaly) Tolerance(wallet float64) float64 {
+	usd, pct := b.Limits()
+	return math.Max(usd, math.Abs(wallet)*pct/100)
+}
+
+// Required 告警需要的连续超出次数
+func (b BalanceAnomaly) Required() int {
+	if b.Confirmations <= 0 {
+		return 2
+	}
+	return b.Confirmations
+}
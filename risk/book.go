@@ -0,0 +1,132 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"nofx/symbols"
+	"sort"
+)
+
+// BookPosition 组合中的一个持仓（来自某个交易所账户）
+type BookPosition struct {
+	Account   string  // 账户标识（交易所:trader）
+	Symbol    string  // 交易对（BTCUSDT）
+	Side      string  // long/short
+	Quantity  float64 // 持仓数量（基础币）
+	MarkPrice float64
+}
+
+// AssetExposure 单个标的资产在所有账户上的合计敞口（名义价值单位USDT）
+type AssetExposure struct {
+	Asset       string             `json:"asset"`
+	Long        float64            `json:"long"`         // 多头名义价值
+	Short       float64            `json:"short"`        // 空头名义价值
+	Net         float64            `json:"net"`          // 净敞口 = 多头 - 空头
+	NetQuantity float64            `json:"net_quantity"` // 净数量（基础币）
+	Accounts    map[string]float64 `json:"accounts"`     // 各账户的净敞口
+}
+
+// Book 所有账户的合计持仓视图
+type Book struct {
+	Equity float64         `json:"equity"` // 所有账户净值合计
+	Gross  float64         `json:"gross"`  // 总名义价值（多头+空头）
+	Assets []AssetExposure `json:"assets"` // 按资产汇总（按净敞口绝对值从大到小）
+}
+
+// BaseAsset 交易对对应的标的资产（BTCUSDT、BTC_USDT、BTC-USDT、1000PEPEUSDT → BTC/PEPE）
+func BaseAsset(symbol string) string {
+	return symbols.Base(symbol)
+}
+
+// NewBook 汇总各账户持仓，equity为所有账户净值合计
+func NewBook(positions []BookPosition, equity float64) *Book {
+	book := &Book{Equity: equity}
+	byAsset := make(map[string]*AssetExposure)
+	for _, p := range positions {
+		asset := BaseAsset(p.Symbol)
+		exposure, ok := byAsset[asset]
+		if !ok {
+			exposure = &AssetExposure{Asset: asset, Accounts: make(map[string]float64)}
+			byAsset[asset] = exposure
+		}
+		quantity := math.Abs(p.Quantity)
+		value := quantity * p.MarkPrice
+		if p.Side == "short" {
+			exposure.Short += value
+			exposure.NetQuantity -= quantity
+			exposure.Accounts[p.Account] -= value
+		} else {
+			exposure.Long += value
+			exposure.NetQuantity += quantity
+			exposure.Accounts[p.Account] += value
+		}
+		book.Gross += value
+	}
+	for _, exposure := range byAsset {
+		exposure.Net = exposure.Long - exposure.Short
+		book.Assets = append(book.Assets, *exposure)
+	}
+	sort.Slice(book.Assets, func(i, j int) bool {
+		return math.Abs(book.Assets[i].Net) > math.Abs(book.Assets[j].Net)
+	})
+	return book
+}
+
+// Asset 指定资产的合计敞口（没有持仓时为零值）
+func (b *Book) Asset(asset string) AssetExposure {
+	for _, exposure := range b.Assets {
+		if exposure.Asset == asset {
+			return exposure
+		}
+	}
+	return AssetExposure{Asset: asset}
+}
+
+// BookLimits 组合敞口限制（以所有账户净值合计的倍数表示，0表示不限制）
+// 同一资产在不同交易所的多空相互抵消，只限制净敞口；总名义价值限制所有账户的多空合计
+type BookLimits struct {
+	MaxNetMultiple   float64 `json:"max_net_multiple"`   // 单资产净敞口上限（净值倍数）
+	MaxGrossMultiple float64 `json:"max_gross_multiple"` // 所有账户总名义价值上限（净值倍数）
+}
+
+// Enabled 是否启用组合敞口限制
+func (l BookLimits) Enabled() bool {
+	return l.MaxNetMultiple > 0 || l.MaxGrossMultiple > 0
+}
+
+// Validate 验证组合敞口限制
+func (l BookLimits) Validate() error {
+	if l.MaxNetMultiple < 0 || l.MaxGrossMultiple < 0 {
+		return fmt.Errorf("combined_exposure的倍数不能为负数")
+	}
+	return nil
+}
+
+// CheckAdd 检查在symbol上按side追加addValue名义价值后组合是否超出限制
+// 减少净敞口的开仓（如在另一交易所对冲）不受净敞口上限限制
+func (l BookLimits) CheckAdd(b *Book, symbol, side string, addValue float64) error {
+	if !l.Enabled() {
+		return nil
+	}
+	if b.Equity <= 0 {
+		return fmt.Errorf("账户净值合计无效(%.2f)，拒绝增加敞口", b.Equity)
+	}
+
+	if l.MaxNetMultiple > 0 {
+		exposure := b.Asset(BaseAsset(symbol))
+		net := exposure.Net + addValue
+		if side == "short" {
+			net = exposure.Net - addValue
+		}
+		maxNet := b.Equity * l.MaxNetMultiple
+		if math.Abs(net) > math.Abs(exposure.Net) && math.Abs(net) > maxNet {
+			return fmt.Errorf("%s 组合净敞口将达到%.2f USDT，超过上限%.2f USDT", exposure.Asset, net, maxNet)
+		}
+	}
+	if l.MaxGrossMultiple > 0 {
+		if maxGross := b.Equity * l.MaxGrossMultiple; b.Gross+addValue > maxGross {
+			return fmt.Errorf("组合总持仓价值将达到%.2f USDT，超过上限%.2f USDT", b.Gross+addValue, maxGross)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 经济事件重要程度
+var impacts = map[string]bool{"high": true, "medium": true, "low": true}
+
+// maxBlackoutMinutes 事件前后禁止开仓的最长时间
+const maxBlackoutMinutes = 24 * 60
+
+// CalendarEvent 经济日历事件（如CPI、FOMC利率决议）
+type CalendarEvent struct {
+	Name     string    `json:"name"`
+	Time     time.Time `json:"time"`     // 公布时间（RFC3339，如 "2026-11-12T13:30:00Z"）
+	Impact   string    `json:"impact"`   // high（默认）/ medium / low
+	Currency string    `json:"currency"` // 相关货币或地区（如USD），仅用于展示
+}
+
+// impact 重要程度（未填写视为high）
+func (e CalendarEvent) impact() string {
+	if e.Impact == "" {
+		return "high"
+	}
+	return e.Impact
+}
+
+// String 用于日志和提示词
+func (e CalendarEvent) String() string {
+	name := e.Name
+	if e.Currency != "" {
+		name = e.Currency + " " + name
+	}
+	return fmt.Sprintf("%s（%s，%s UTC）", name, e.impact(), e.Time.UTC().Format("2006-01-02 15:04"))
+}
+
+// EconomicCalendar 经济日历：重要事件公布前后一段时间内禁止开新仓（平仓不受影响），即将公布的事件写入提示词
+type EconomicCalendar struct {
+	Events                []CalendarEvent `json:"events"`
+	BlackoutBeforeMinutes int             `json:"blackout_before_minutes"` // 事件前N分钟禁止开仓
+	BlackoutAfterMinutes  int             `json:"blackout_after_minutes"`  // 事件后N分钟禁止开仓
+	BlackoutImpacts       []string        `json:"blackout_impacts"`        // 触发禁止开仓的重要程度（默认只有high）
+}
+
+// Enabled 是否有禁止开仓规则
+func (c EconomicCalendar) Enabled() bool {
+	return len(c.Events) > 0 && (c.BlackoutBeforeMinutes > 0 || c.BlackoutAfterMinutes > 0)
+}
+
+// Validate 验证配置
+func (c EconomicCalendar) Validate() error {
+	if c.BlackoutBeforeMinutes < 0 || c.BlackoutBeforeMinutes > maxBlackoutMinutes ||
+		c.BlackoutAfterMinutes < 0 || c.BlackoutAfterMinutes > maxBlackoutMinutes {
+		return fmt.Errorf("economic_calendar.blackout_before_minutes/blackout_after_minutes必须在0-%d之间", maxBlackoutMinutes)
+	}
+	for _, impact := range c.BlackoutImpacts {
+		if !impacts[impact] {
+			return fmt.Errorf("economic_calendar.blackout_impacts包含无效的重要程度: %s（可选: high/medium/low）", impact)
+		}
+	}
+	for i, e := range c.Events {
+		if strings.TrimSpace(e.Name) == "" {
+			return fmt.Errorf("economic_calendar.events[%d]缺少name", i)
+		}
+		if e.Time.IsZero() {
+			return fmt.Errorf("economic_calendar.events[%d](%s)缺少time", i, e.Name)
+		}
+		if !impacts[e.impact()] {
+			return fmt.Errorf("economic_calendar.events[%d](%s)的impact无效: %s（可选: high/medium/low）", i, e.Name, e.Impact)
+		}
+	}
+	return nil
+}
+
+// blocks 该重要程度是否触发禁止开仓
+func (c EconomicCalendar) blocks(impact string) bool {
+	if len(c.BlackoutImpacts) == 0 {
+		return impact == "high"
+	}
+	for _, i := range c.BlackoutImpacts {
+		if i == impact {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 检查当前是否处于重要事件的禁止开仓窗口内
+func (c EconomicCalendar) Check(now time.Time) error {
+	if !c.Enabled() {
+		return nil
+	}
+	before := time.Duration(c.BlackoutBeforeMinutes) * time.Minute
+	after := time.Duration(c.BlackoutAfterMinutes) * time.Minute
+	for _, e := range c.Events {
+		if !c.blocks(e.impact()) {
+			continue
+		}
+		if now.Before(e.Time.Add(-before)) || !now.Before(e.Time.Add(after)) {
+			continue
+		}
+		if now.Before(e.Time) {
+			return fmt.Errorf("距离%s公布还有%d分钟", e, int(math.Ceil(e.Time.Sub(now).Minutes())))
+		}
+		return fmt.Errorf("%s已公布%d分钟", e, int(now.Sub(e.Time).Minutes()))
+	}
+	return nil
+}
+
+// Upcoming within时间内即将公布的事件（按时间排序）
+func (c EconomicCalendar) Upcoming(now time.Time, within time.Duration) []CalendarEvent {
+	var upcoming []CalendarEvent
+	for _, e := range c.Events {
+		if !e.Time.Before(now) && e.Time.Sub(now) <= within {
+			upcoming = append(upcoming, e)
+		}
+	}
+	sort.Slice(upcoming, func(i, j int) bool { return upcoming[i].Time.Before(upcoming[j].Time) })
+	return upcoming
+}
@@ -0,0 +1,28 @@
+package risk
+
+// CollateralTier 抵押折算率档位：币种美元价值在[LowerUSD, UpperUSD)区间内的部分按Discount计入保证金（UpperUSD为0表示无上限）
+type CollateralTier struct {
+	LowerUSD float64
+	UpperUSD float64
+	Discount float64
+}
+
+// CollateralValue 按分档折算率计算资产可计入保证金的美元价值（各档分别折算后相加）
+// 没有档位时按全额计算；负值（借款）不打折
+func CollateralValue(valueUSD float64, tiers []CollateralTier) float64 {
+	if len(tiers) == 0 || valueUSD <= 0 {
+		return valueUSD
+	}
+	collateral := 0.0
+	for _, tier := range tiers {
+		if valueUSD <= tier.LowerUSD {
+			continue
+		}
+		upper := valueUSD
+		if tier.UpperUSD > 0 && tier.UpperUSD < upper {
+			upper = tier.UpperUSD
+		}
+		collateral += (upper - tier.LowerUSD) * tier.Discount
+	}
+	return collateral
+}
@@ -0,0 +1,94 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// 降级模式（按严重程度递增，多个数据源同时故障时取最严重的）
+const (
+	ModeNormal      = "normal"       // 正常
+	ModeRESTPolling = "rest_polling" // WebSocket推送断开：改为REST轮询持仓
+	ModeExitsOnly   = "exits_only"   // K线过期：不再开新仓，平仓照常
+	ModeRulesOnly   = "rules_only"   // AI不可用：不再开新仓，只按规则管理平仓
+)
+
+// 降级参数默认值
+const (
+	defaultDegradationPollSeconds = 15
+	defaultKlineStaleSeconds      = 600
+	defaultLLMFailures            = 3
+	defaultFallbackStopPct        = 3.0
+)
+
+// Degradation 数据源故障时的降级：WebSocket断开时改为REST轮询持仓，K线过期时只平仓不开仓，
+// AI连续不可用时只按规则管理平仓（补挂止损、浮亏超限时平仓）；模式切换写日志并告警，数据源恢复后自动回到正常模式
+type Degradation struct {
+	Enabled           bool    `json:"enabled"`
+	PollSeconds       int     `json:"poll_seconds"`        // WebSocket断开时REST轮询持仓的间隔（秒，默认15）
+	KlineStaleSeconds int     `json:"kline_stale_seconds"` // 最新K线距现在超过该值视为K线过期（秒，默认600）
+	LLMFailures       int     `json:"llm_failures"`        // AI连续调用失败（含决策阶段超时）该次数后进入规则平仓模式（默认3）
+	FallbackStopPct   float64 `json:"fallback_stop_pct"`   // 规则平仓：没有止损的持仓按入场价补挂该距离的止损，浮亏超过时市价平仓（%，默认3）
+}
+
+// Validate 验证配置
+func (d Degradation) Validate() error {
+	if d.PollSeconds < 0 || d.KlineStaleSeconds < 0 || d.LLMFailures < 0 {
+		return fmt.Errorf("degradation.poll_seconds/kline_stale_seconds/llm_failures不能为负数")
+	}
+	if d.FallbackStopPct < 0 || d.FallbackStopPct >= 100 {
+		return fmt.Errorf("degradation.fallback_stop_pct必须在0-100之间: %.2f", d.FallbackStopPct)
+	}
+	return nil
+}
+
+// PollInterval WebSocket断开时REST轮询持仓的间隔
+func (d Degradation) PollInterval() time.Duration {
+	if d.PollSeconds > 0 {
+		return time.Duration(d.PollSeconds) * time.Second
+	}
+	return defaultDegradationPollSeconds * time.Second
+}
+
+// KlineStaleAfter K线过期的阈值
+func (d Degradation) KlineStaleAfter() time.Duration {
+	if d.KlineStaleSeconds > 0 {
+		return time.Duration(d.KlineStaleSeconds) * time.Second
+	}
+	return defaultKlineStaleSeconds * time.Second
+}
+
+// LLMFailureLimit 进入规则平仓模式的AI连续失败次数
+func (d Degradation) LLMFailureLimit() int {
+	if d.LLMFailures > 0 {
+		return d.LLMFailures
+	}
+	return defaultLLMFailures
+}
+
+// StopPct 规则平仓的止损距离（%）
+func (d Degradation) StopPct() float64 {
+	if d.FallbackStopPct > 0 {
+		return d.FallbackStopPct
+	}
+	return defaultFallbackStopPct
+}
+
+// DegradationMode 按各数据源的状态得出降级模式（取最严重的）
+func DegradationMode(wsDown, klinesStale, llmDown bool) string {
+	switch {
+	case llmDown:
+		return ModeRulesOnly
+	case klinesStale:
+		return ModeExitsOnly
+	case wsDown:
+		return ModeRESTPolling
+	default:
+		return ModeNormal
+	}
+}
+
+// EntriesAllowed 该模式下是否允许开新仓
+func EntriesAllowed(mode string) bool {
+	return mode == ModeNormal || mode == ModeRESTPolling
+}
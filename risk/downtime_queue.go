@@ -0,0 +1,32 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultDowntimeQueueTTL 排队平仓单默认有效期
+const defaultDowntimeQueueTTL = 30 * time.Minute
+
+// DowntimeQueue 交易所维护期间的平仓单排队：维护中被拒绝的平仓/减仓单（从不包括开仓）进入队列，
+// 交易所恢复后按原数量重新提交，超过有效期仍未恢复的作废，避免止损等保护性平仓被静默丢弃
+type DowntimeQueue struct {
+	Enabled    bool `json:"enabled"`
+	TTLMinutes int  `json:"ttl_minutes"` // 排队超过N分钟仍未恢复时作废（0表示30分钟）
+}
+
+// Validate 验证配置
+func (q DowntimeQueue) Validate() error {
+	if q.TTLMinutes < 0 {
+		return fmt.Errorf("downtime_queue.ttl_minutes不能为负数")
+	}
+	return nil
+}
+
+// TTL 排队平仓单的有效期
+func (q DowntimeQueue) TTL() time.Duration {
+	if q.TTLMinutes <= 0 {
+		return defaultDowntimeQueueTTL
+	}
+	return time.Duration(q.TTLMinutes) * time.Minute
+}
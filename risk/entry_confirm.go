@@ -0,0 +1,32 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultEntryConfirmTimeout 开仓建议默认有效期（行情变化后预览失效）
+const defaultEntryConfirmTimeout = 15 * time.Minute
+
+// EntryConfirm 开仓确认模式：AI决策和外部信号的开仓不直接执行，而是生成附带下单预览的开仓建议，
+// 由操作员通过Telegram按钮或管理接口确认后再执行
+type EntryConfirm struct {
+	Enabled        bool `json:"enabled"`
+	TimeoutMinutes int  `json:"timeout_minutes"` // 建议超过N分钟未确认时作废（0表示15分钟）
+}
+
+// Validate 验证配置
+func (c EntryConfirm) Validate() error {
+	if c.TimeoutMinutes < 0 {
+		return fmt.Errorf("confirm_entries.timeout_minutes不能为负数")
+	}
+	return nil
+}
+
+// Timeout 开仓建议的有效期
+func (c EntryConfirm) Timeout() time.Duration {
+	if c.TimeoutMinutes <= 0 {
+		return defaultEntryConfirmTimeout
+	}
+	return time.Duration(c.TimeoutMinutes) * time.Minute
+}
@@ -0,0 +1,39 @@
+package risk
+
+import "fmt"
+
+// 超出每周期开仓上限时的处理方式
+const (
+	EntryOverflowDrop  = "drop"  // 丢弃（默认）
+	EntryOverflowQueue = "queue" // 顺延到下一周期（新决策中该币种没有动作时才执行，只顺延一次）
+)
+
+// EntryLimit 单个决策周期的新开仓数量上限：AI一次给出多个开仓时按信心度从高到低执行，
+// 成功开仓（含待确认）达到上限后其余开仓丢弃或顺延，避免一次决策把整个账户都投入进去
+type EntryLimit struct {
+	MaxPerCycle int    `json:"max_per_cycle"` // 每个周期最多新开仓数（0表示不限制）
+	Overflow    string `json:"overflow"`      // 超出上限的开仓: drop（丢弃，默认）/queue（顺延到下一周期）
+}
+
+// Validate 验证配置
+func (l EntryLimit) Validate() error {
+	if l.MaxPerCycle < 0 {
+		return fmt.Errorf("entry_limit.max_per_cycle不能为负数")
+	}
+	switch l.Overflow {
+	case "", EntryOverflowDrop, EntryOverflowQueue:
+	default:
+		return fmt.Errorf("entry_limit.overflow必须是drop或queue: %s", l.Overflow)
+	}
+	return nil
+}
+
+// Enabled 是否启用
+func (l EntryLimit) Enabled() bool {
+	return l.MaxPerCycle > 0
+}
+
+// Queue 超出上限的开仓是否顺延到下一周期
+func (l EntryLimit) Queue() bool {
+	return l.Overflow == EntryOverflowQueue
+}
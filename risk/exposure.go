@@ -0,0 +1,59 @@
+package risk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExposureLimits 敞口限制（以账户净值的倍数表示）
+// 默认值与AI决策校验保持一致：BTC/ETH单币种最多10倍净值，山寨币最多1.5倍净值
+type ExposureLimits struct {
+	BTCETHMaxMultiple  float64 `json:"btc_eth_max_multiple"` // BTC/ETH单币种仓位价值上限（净值倍数）
+	AltcoinMaxMultiple float64 `json:"altcoin_max_multiple"` // 山寨币单币种仓位价值上限（净值倍数）
+	TotalMaxMultiple   float64 `json:"total_max_multiple"`   // 所有持仓总名义价值上限（净值倍数，0表示不限制）
+}
+
+// DefaultExposureLimits 默认敞口限制
+func DefaultExposureLimits() ExposureLimits {
+	return ExposureLimits{
+		BTCETHMaxMultiple:  10,
+		AltcoinMaxMultiple: 1.5,
+		TotalMaxMultiple:   0,
+	}
+}
+
+// isBTCETH 判断是否为BTC/ETH
+func isBTCETH(symbol string) bool {
+	symbol = strings.ToUpper(symbol)
+	return symbol == "BTCUSDT" || symbol == "ETHUSDT"
+}
+
+// MaxPositionValue 单币种仓位价值上限（USDT）
+func (l ExposureLimits) MaxPositionValue(symbol string, equity float64) float64 {
+	if isBTCETH(symbol) {
+		return equity * l.BTCETHMaxMultiple
+	}
+	return equity * l.AltcoinMaxMultiple
+}
+
+// CheckAdd 检查在symbol上追加addValue名义价值后是否超出敞口限制
+// symbolValue: 该币种当前名义价值，totalValue: 所有持仓当前名义价值
+func (l ExposureLimits) CheckAdd(symbol string, symbolValue, totalValue, addValue, equity float64) error {
+	if equity <= 0 {
+		return fmt.Errorf("账户净值无效(%.2f)，拒绝增加敞口", equity)
+	}
+
+	maxSymbol := l.MaxPositionValue(symbol, equity)
+	if symbolValue+addValue > maxSymbol {
+		return fmt.Errorf("%s 仓位价值将达到%.2f USDT，超过单币种上限%.2f USDT", symbol, symbolValue+addValue, maxSymbol)
+	}
+
+	if l.TotalMaxMultiple > 0 {
+		maxTotal := equity * l.TotalMaxMultiple
+		if totalValue+addValue > maxTotal {
+			return fmt.Errorf("总持仓价值将达到%.2f USDT，超过账户上限%.2f USDT", totalValue+addValue, maxTotal)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// 全部平仓时的平仓顺序
+const (
+	FlattenByRisk     = "risk"     // 距强平价最近的先平（默认）
+	FlattenByLoss     = "loss"     // 浮亏最大的先平
+	FlattenByNotional = "notional" // 名义价值最大的先平
+)
+
+// defaultFlattenPace 相邻两笔平仓之间的默认间隔
+const defaultFlattenPace = 100 * time.Millisecond
+
+// Flatten 全部平仓（管理接口、Telegram、CLI的flatten）的平仓顺序和节奏：
+// 先平风险最大的持仓，相邻两笔平仓之间间隔pace_ms，避免一次性下单触发交易所频率限制
+type Flatten struct {
+	Order  string `json:"order"`   // risk（默认）/loss/notional
+	PaceMs int    `json:"pace_ms"` // 相邻两笔平仓的间隔（毫秒，0表示100毫秒）
+}
+
+// Validate 验证配置
+func (f Flatten) Validate() error {
+	switch f.Order {
+	case "", FlattenByRisk, FlattenByLoss, FlattenByNotional:
+	default:
+		return fmt.Errorf("flatten.order必须是risk、loss或notional: %s", f.Order)
+	}
+	if f.PaceMs < 0 || f.PaceMs > 10000 {
+		return fmt.Errorf("flatten.pace_ms必须在0-10000之间: %d", f.PaceMs)
+	}
+	return nil
+}
+
+// OrderOrDefault 生效的平仓顺序
+func (f Flatten) OrderOrDefault() string {
+	if f.Order == "" {
+		return FlattenByRisk
+	}
+	return f.Order
+}
+
+// Pace 相邻两笔平仓的间隔
+func (f Flatten) Pace() time.Duration {
+	if f.PaceMs <= 0 {
+		return defaultFlattenPace
+	}
+	return time.Duration(f.PaceMs) * time.Millisecond
+}
@@ -0,0 +1,52 @@
+package risk
+
+import "fmt"
+
+// TakeProfitStep 分批止盈档位：价格朝有利方向移动R倍初始风险（|入场价-止损价|）时，平掉Fraction比例的初始持仓
+type TakeProfitStep struct {
+	R        float64 `json:"r"`
+	Fraction float64 `json:"fraction"`
+}
+
+// TakeProfitLadder 分批止盈配置（为空表示使用AI给出的单一止盈价）
+// 例: [{"r":1,"fraction":0.5},{"r":2,"fraction":0.25}] → 1R平50%，2R平25%，剩余25%挂在AI给出的止盈价
+type TakeProfitLadder []TakeProfitStep
+
+// Validate 验证档位：R必须递增，比例之和不超过1
+func (l TakeProfitLadder) Validate() error {
+	total, lastR := 0.0, 0.0
+	for i, step := range l {
+		if step.R <= lastR {
+			return fmt.Errorf("take_profit_ladder[%d].r必须大于0且递增: %.2f", i, step.R)
+		}
+		if step.Fraction <= 0 || step.Fraction > 1 {
+			return fmt.Errorf("take_profit_ladder[%d].fraction必须在0-1之间: %.2f", i, step.Fraction)
+		}
+		total += step.Fraction
+		lastR = step.R
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("take_profit_ladder各档fraction之和不能超过1: %.2f", total)
+	}
+	return nil
+}
+
+// Prices 按入场价和初始止损计算各档止盈价（止损在入场价错误一侧时返回nil）
+func (l TakeProfitLadder) Prices(side string, entryPrice, stopLoss float64) []float64 {
+	risk := entryPrice - stopLoss
+	if side == "short" {
+		risk = -risk
+	}
+	if risk <= 0 || len(l) == 0 {
+		return nil
+	}
+	prices := make([]float64, len(l))
+	for i, step := range l {
+		if side == "short" {
+			prices[i] = entryPrice - step.R*risk
+		} else {
+			prices[i] = entryPrice + step.R*risk
+		}
+	}
+	return prices
+}
@@ -0,0 +1,63 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// AutoLeverageConfig 按波动率自动选择杠杆（启用后忽略AI/外部信号给出的杠杆）
+//
+// 杠杆 = 风险预算 / (ATR倍数 × ATR / 价格)，向下取整并限制在 [1, 该币种杠杆上限]
+// 例: BTC 4小时ATR为价格的1.2%，ATR倍数2，风险预算30% → 30% / 2.4% = 12倍 → 受BTC/ETH上限限制
+type AutoLeverageConfig struct {
+	Enabled          bool    `json:"enabled"`
+	MaxMarginLossPct float64 `json:"max_margin_loss_pct"` // 风险预算：价格逆向波动 atr_multiple×ATR 时最多亏损保证金的百分比（默认30）
+	ATRMultiple      float64 `json:"atr_multiple"`        // 逆向波动的ATR倍数（默认2，使用4小时ATR14）
+}
+
+// Validate 验证配置并填充默认值
+func (c *AutoLeverageConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.MaxMarginLossPct == 0 {
+		c.MaxMarginLossPct = 30
+	}
+	if c.ATRMultiple == 0 {
+		c.ATRMultiple = 2
+	}
+	if c.MaxMarginLossPct < 0 || c.MaxMarginLossPct > 100 {
+		return fmt.Errorf("leverage.auto.max_margin_loss_pct必须在0-100之间: %.2f", c.MaxMarginLossPct)
+	}
+	if c.ATRMultiple < 0 {
+		return fmt.Errorf("leverage.auto.atr_multiple不能为负数: %.2f", c.ATRMultiple)
+	}
+	return nil
+}
+
+// MaxLeverage 币种的杠杆上限（BTC/ETH与山寨币分别配置）
+func MaxLeverage(symbol string, btcEthLeverage, altcoinLeverage int) int {
+	if isBTCETH(symbol) {
+		return btcEthLeverage
+	}
+	return altcoinLeverage
+}
+
+// Leverage 按ATR计算杠杆（价格或ATR无效时返回1倍）
+func (c AutoLeverageConfig) Leverage(price, atr float64, maxLeverage int) int {
+	if maxLeverage < 1 {
+		maxLeverage = 1
+	}
+	if price <= 0 || atr <= 0 {
+		return 1
+	}
+	adverseMove := c.ATRMultiple * atr / price // 逆向波动占价格的比例
+	leverage := int(math.Floor(c.MaxMarginLossPct / 100 / adverseMove))
+	if leverage < 1 {
+		return 1
+	}
+	if leverage > maxLeverage {
+		return maxLeverage
+	}
+	return leverage
+}
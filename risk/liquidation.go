@@ -0,0 +1,110 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// 保证金模式
+const (
+	MarginIsolated = "isolated" // 逐仓：只有该仓位的保证金承担亏损
+	MarginCross    = "cross"    // 全仓：账户可用余额也承担亏损
+)
+
+// DefaultMaintenanceRate 交易所未提供维持保证金档位时使用的维持保证金率
+const DefaultMaintenanceRate = 0.005
+
+// MaintenanceTier 维持保证金档位（风险限额档位）：仓位价值不超过MaxValue（USDT）时使用Rate（0表示最后一档不限）
+type MaintenanceTier struct {
+	MaxValue    float64 `json:"max_value"`
+	Rate        float64 `json:"rate"`                   // 维持保证金率
+	InitialRate float64 `json:"initial_rate,omitempty"` // 起始保证金率（0表示未知）
+	MaxLeverage int     `json:"max_leverage,omitempty"` // 该档位允许的最大杠杆（0表示未知）
+}
+
+// TierFor 仓位价值所在的档位（tiers按MaxValue从小到大排列，超过所有档位时为最高档，没有档位时ok为false）
+func TierFor(tiers []MaintenanceTier, value float64) (tier MaintenanceTier, ok bool) {
+	if len(tiers) == 0 {
+		return MaintenanceTier{}, false
+	}
+	for _, tier := range tiers {
+		if tier.MaxValue == 0 || value <= tier.MaxValue {
+			return tier, true
+		}
+	}
+	return tiers[len(tiers)-1], true
+}
+
+// MaintenanceRate 仓位价值对应档位的维持保证金率，没有档位时使用默认值
+func MaintenanceRate(tiers []MaintenanceTier, value float64) float64 {
+	if tier, ok := TierFor(tiers, value); ok {
+		return tier.Rate
+	}
+	return DefaultMaintenanceRate
+}
+
+// LiquidationPrice 估算开仓后的强平价（不含手续费和资金费，返回0表示价格跌到0也不会强平）
+// 逐仓保证金 = 仓位价值 / 杠杆，全仓再加上collateral（账户可用余额）
+// 强平条件: 保证金 + 未实现盈亏 = 维持保证金率 × 强平价 × 数量
+//   - 多仓: P = (入场价 × 数量 - 保证金) / (数量 × (1 - 维持保证金率))
+//   - 空仓: P = (入场价 × 数量 + 保证金) / (数量 × (1 + 维持保证金率))
+func LiquidationPrice(side string, entry, quantity float64, leverage int, mode string, collateral float64, tiers []MaintenanceTier) float64 {
+	quantity = math.Abs(quantity)
+	if entry <= 0 || quantity <= 0 || leverage < 1 {
+		return 0
+	}
+	value := entry * quantity
+	margin := value / float64(leverage)
+	if mode == MarginCross && collateral > 0 {
+		margin += collateral
+	}
+	rate := MaintenanceRate(tiers, value)
+
+	if side == "short" {
+		return (value + margin) / (quantity * (1 + rate))
+	}
+	price := (value - margin) / (quantity * (1 - rate))
+	if price < 0 {
+		return 0
+	}
+	return price
+}
+
+// LiquidationGuard 开仓前按估算强平价检查：强平价距入场价必须超过正常波动范围（4小时ATR的倍数），否则拒绝开仓
+type LiquidationGuard struct {
+	MinATRMultiple float64 `json:"min_atr_multiple"` // 强平距离至少为ATR的倍数（0表示不检查）
+	MarginMode     string  `json:"margin_mode"`      // 估算使用的保证金模式 isolated（默认）/cross
+}
+
+// Enabled 是否启用强平距离检查
+func (g LiquidationGuard) Enabled() bool {
+	return g.MinATRMultiple > 0
+}
+
+// Validate 验证配置并填充默认值
+func (g *LiquidationGuard) Validate() error {
+	if g.MinATRMultiple < 0 {
+		return fmt.Errorf("leverage.liquidation.min_atr_multiple不能为负数: %.2f", g.MinATRMultiple)
+	}
+	switch g.MarginMode {
+	case "":
+		g.MarginMode = MarginIsolated
+	case MarginIsolated, MarginCross:
+	default:
+		return fmt.Errorf("leverage.liquidation.margin_mode无效: %s（可选: isolated/cross）", g.MarginMode)
+	}
+	return nil
+}
+
+// Check 检查强平价距入场价是否超过min_atr_multiple倍ATR（ATR无效或不会强平时通过）
+func (g LiquidationGuard) Check(entry, liquidation, atr float64) error {
+	if !g.Enabled() || atr <= 0 || liquidation <= 0 {
+		return nil
+	}
+	distance := math.Abs(entry - liquidation)
+	if minDistance := g.MinATRMultiple * atr; distance < minDistance {
+		return fmt.Errorf("预估强平价%.4f距入场价%.4f仅%.2f%%（%.1f倍ATR），小于%.1f倍ATR",
+			liquidation, entry, distance/entry*100, distance/atr, g.MinATRMultiple)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package risk
+
+import (
+	"fmt"
+	"nofx/symbols"
+)
+
+// MarginSettings 保证金模式和风险限额：全仓模式下交易所的杠杆设为0，下单杠杆作为全仓杠杆上限（计算起始保证金），
+// 风险限额决定仓位价值上限和可用的最大杠杆（未配置的币种保持交易所当前设置）
+type MarginSettings struct {
+	Mode       string             `json:"mode"`        // isolated（默认，逐仓）/cross（全仓）
+	RiskLimits map[string]float64 `json:"risk_limits"` // 币种 -> 风险限额（USDT，按交易所档位向上取整）
+}
+
+// Cross 是否使用全仓模式
+func (m MarginSettings) Cross() bool {
+	return m.Mode == MarginCross
+}
+
+// Enabled 是否需要调整交易所的默认设置（逐仓且未配置风险限额时不调整）
+func (m MarginSettings) Enabled() bool {
+	return m.Cross() || len(m.RiskLimits) > 0
+}
+
+// RiskLimit 币种配置的风险限额（0表示未配置）
+func (m MarginSettings) RiskLimit(symbol string) float64 {
+	return m.RiskLimits[symbols.Canonical(symbol)]
+}
+
+// Validate 验证配置，填充默认模式并规范化币种名
+func (m *MarginSettings) Validate() error {
+	switch m.Mode {
+	case "":
+		m.Mode = MarginIsolated
+	case MarginIsolated, MarginCross:
+	default:
+		return fmt.Errorf("margin.mode无效: %s（可选: isolated/cross）", m.Mode)
+	}
+	normalized := make(map[string]float64, len(m.RiskLimits))
+	for symbol, limit := range m.RiskLimits {
+		if limit <= 0 {
+			return fmt.Errorf("margin.risk_limits.%s必须大于0: %g", symbol, limit)
+		}
+		canonical := symbols.Canonical(symbol)
+		if _, dup := normalized[canonical]; dup {
+			return fmt.Errorf("margin.risk_limits中%s重复配置", canonical)
+		}
+		normalized[canonical] = limit
+	}
+	if m.RiskLimits != nil {
+		m.RiskLimits = normalized
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package risk
+
+import "fmt"
+
+// OrderRateLimit 下单频率上限：全局和单个币种每分钟/每小时最多提交的订单数（开仓和平仓都计数，0表示不限制）
+// 超过上限时拒绝开仓并触发熔断（暂停交易直到人工恢复），用于防止程序异常循环下单消耗手续费或被交易所封禁
+type OrderRateLimit struct {
+	MaxPerMinute       int `json:"max_per_minute"`        // 全部币种每分钟最多下单数
+	MaxPerHour         int `json:"max_per_hour"`          // 全部币种每小时最多下单数
+	SymbolMaxPerMinute int `json:"symbol_max_per_minute"` // 单个币种每分钟最多下单数
+	SymbolMaxPerHour   int `json:"symbol_max_per_hour"`   // 单个币种每小时最多下单数
+}
+
+// Enabled 是否启用
+func (l OrderRateLimit) Enabled() bool {
+	return l.MaxPerMinute > 0 || l.MaxPerHour > 0 || l.SymbolMaxPerMinute > 0 || l.SymbolMaxPerHour > 0
+}
+
+// Validate 验证配置
+func (l OrderRateLimit) Validate() error {
+	limits := []struct {
+		name  string
+		value int
+	}{
+		{"max_per_minute", l.MaxPerMinute},
+		{"max_per_hour", l.MaxPerHour},
+		{"symbol_max_per_minute", l.SymbolMaxPerMinute},
+		{"symbol_max_per_hour", l.SymbolMaxPerHour},
+	}
+	for _, limit := range limits {
+		if limit.value < 0 || limit.value > 100000 {
+			return fmt.Errorf("order_rate_limit.%s必须在0-100000之间: %d", limit.name, limit.value)
+		}
+	}
+	return nil
+}
+
+// Exceeded 最近一分钟/一小时内已提交的订单数（全局和该币种）是否已达到上限，返回达到的上限说明（未达到时为空）
+func (l OrderRateLimit) Exceeded(minute, hour, symbolMinute, symbolHour int) string {
+	switch {
+	case l.MaxPerMinute > 0 && minute >= l.MaxPerMinute:
+		return fmt.Sprintf("最近1分钟已下单%d次，达到上限%d", minute, l.MaxPerMinute)
+	case l.MaxPerHour > 0 && hour >= l.MaxPerHour:
+		return fmt.Sprintf("最近1小时已下单%d次，达到上限%d", hour, l.MaxPerHour)
+	case l.SymbolMaxPerMinute > 0 && symbolMinute >= l.SymbolMaxPerMinute:
+		return fmt.Sprintf("该币种最近1分钟已下单%d次，达到上限%d", symbolMinute, l.SymbolMaxPerMinute)
+	case l.SymbolMaxPerHour > 0 && symbolHour >= l.SymbolMaxPerHour:
+		return fmt.Sprintf("该币种最近1小时已下单%d次，达到上限%d", symbolHour, l.SymbolMaxPerHour)
+	}
+	return ""
+}
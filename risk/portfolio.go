@@ -0,0 +1,144 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"nofx/fx"
+	"sort"
+	"strings"
+	"time"
+)
+
+// portfolioSettles 支持汇总的合约结算币种（Gate USDT本位和BTC本位合约）
+var portfolioSettles = map[string]bool{"usdt": true, "btc": true}
+
+// PortfolioSettles 组合视图汇总的结算币种（为空时只统计交易使用的USDT本位合约）
+type PortfolioSettles []string
+
+// Validate 验证结算币种（统一为小写并去重）
+func (s *PortfolioSettles) Validate() error {
+	seen := make(map[string]bool, len(*s))
+	settles := make(PortfolioSettles, 0, len(*s))
+	for _, settle := range *s {
+		settle = strings.ToLower(strings.TrimSpace(settle))
+		if !portfolioSettles[settle] {
+			return fmt.Errorf("portfolio_settles包含不支持的结算币种: %q（可选: usdt/btc）", settle)
+		}
+		if !seen[settle] {
+			seen[settle] = true
+			settles = append(settles, settle)
+		}
+	}
+	*s = settles
+	return nil
+}
+
+// SettlePosition 结算账本中的一个持仓（价值和盈亏以结算币种计价，USD字段按结算币种指数价换算）
+type SettlePosition struct {
+	Contract      string  `json:"contract"` // 交易所合约名（BTC_USDT、BTC_USD）
+	Asset         string  `json:"asset"`    // 标的资产（BTC）
+	Side          string  `json:"side"`     // long/short
+	Size          float64 `json:"size"`     // 持仓张数
+	MarkPrice     float64 `json:"mark_price"`
+	Value         float64 `json:"value"`          // 持仓价值（结算币种）
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 未实现盈亏（结算币种）
+	ValueUSD      float64 `json:"value_usd"`
+	UnrealizedUSD float64 `json:"unrealized_usd"`
+}
+
+// SettleBook 一个账户在某个结算币种下的合约账本
+type SettleBook struct {
+	Account       string           `json:"account"`     // 账户标识（交易所:trader）
+	Settle        string           `json:"settle"`      // 结算币种（USDT/BTC）
+	IndexPrice    float64          `json:"index_price"` // 结算币种的美元指数价（USDT按1计）
+	Equity        float64          `json:"equity"`      // 账户权益（结算币种，含未实现盈亏）
+	UnrealizedPnL float64          `json:"unrealized_pnl"`
+	EquityUSD     float64          `json:"equity_usd"`
+	UnrealizedUSD float64          `json:"unrealized_usd"`
+	Positions     []SettlePosition `json:"positions"`
+}
+
+// PortfolioAsset 单个标的资产在所有账本上的合计敞口（美元）
+type PortfolioAsset struct {
+	Asset    string  `json:"asset"`
+	LongUSD  float64 `json:"long_usd"`
+	ShortUSD float64 `json:"short_usd"`
+	NetUSD   float64 `json:"net_usd"`
+}
+
+// Portfolio 多结算币种的合计组合视图：各账本的权益、持仓价值和未实现盈亏按结算币种指数价换算为美元后汇总
+type Portfolio struct {
+	GeneratedAt   time.Time         `json:"generated_at"`
+	EquityUSD     float64           `json:"equity_usd"`     // 所有账本权益合计
+	UnrealizedUSD float64           `json:"unrealized_usd"` // 所有账本未实现盈亏合计
+	GrossUSD      float64           `json:"gross_usd"`      // 总持仓价值（多头+空头）
+	NetUSD        float64           `json:"net_usd"`        // 净敞口（多头-空头）
+	Assets        []PortfolioAsset  `json:"assets"`         // 按资产汇总（按净敞口绝对值从大到小）
+	Books         []SettleBook      `json:"books"`
+	Errors        map[string]string `json:"errors,omitempty"` // 获取失败的账户（不计入合计）
+}
+
+// NewPortfolio 按结算币种指数价把各账本换算为美元并汇总
+func NewPortfolio(books []SettleBook, now time.Time) *Portfolio {
+	p := &Portfolio{GeneratedAt: now, Books: make([]SettleBook, 0, len(books))}
+	byAsset := make(map[string]*PortfolioAsset)
+	for _, book := range books {
+		book.EquityUSD = book.Equity * book.IndexPrice
+		book.UnrealizedUSD = book.UnrealizedPnL * book.IndexPrice
+		for i := range book.Positions {
+			pos := &book.Positions[i]
+			pos.ValueUSD = math.Abs(pos.Value) * book.IndexPrice
+			pos.UnrealizedUSD = pos.UnrealizedPnL * book.IndexPrice
+
+			asset, ok := byAsset[pos.Asset]
+			if !ok {
+				asset = &PortfolioAsset{Asset: pos.Asset}
+				byAsset[pos.Asset] = asset
+			}
+			if pos.Side == "short" {
+				asset.ShortUSD += pos.ValueUSD
+			} else {
+				asset.LongUSD += pos.ValueUSD
+			}
+			p.GrossUSD += pos.ValueUSD
+		}
+		p.EquityUSD += book.EquityUSD
+		p.UnrealizedUSD += book.UnrealizedUSD
+		p.Books = append(p.Books, book)
+	}
+	for _, asset := range byAsset {
+		asset.NetUSD = asset.LongUSD - asset.ShortUSD
+		p.NetUSD += asset.NetUSD
+		p.Assets = append(p.Assets, *asset)
+	}
+	sort.Slice(p.Assets, func(i, j int) bool {
+		if math.Abs(p.Assets[i].NetUSD) != math.Abs(p.Assets[j].NetUSD) {
+			return math.Abs(p.Assets[i].NetUSD) > math.Abs(p.Assets[j].NetUSD)
+		}
+		return p.Assets[i].Asset < p.Assets[j].Asset
+	})
+	return p
+}
+
+// String 格式化为文本（用于汇总报告和命令行）
+func (p *Portfolio) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("💼 组合（USD）权益 %.2f%s | 未实现 %+.2f%s | 总敞口 %.2f | 净敞口 %+.2f\n",
+		p.EquityUSD, fx.Suffix(p.EquityUSD, false), p.UnrealizedUSD, fx.Suffix(p.UnrealizedUSD, true), p.GrossUSD, p.NetUSD))
+	for _, book := range p.Books {
+		sb.WriteString(fmt.Sprintf("%s %s: 权益 %.6g %s（≈%.2f USD，指数价 %.6g），未实现 %+.6g，持仓%d个\n",
+			book.Account, book.Settle, book.Equity, book.Settle, book.EquityUSD, book.IndexPrice, book.UnrealizedPnL, len(book.Positions)))
+	}
+	for _, asset := range p.Assets {
+		sb.WriteString(fmt.Sprintf("  %-8s 多 %.2f / 空 %.2f / 净 %+.2f USD\n", asset.Asset, asset.LongUSD, asset.ShortUSD, asset.NetUSD))
+	}
+	labels := make([]string, 0, len(p.Errors))
+	for label := range p.Errors {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		sb.WriteString(fmt.Sprintf("⚠ %s: %s\n", label, p.Errors[label]))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,53 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// PriceBand 出站价格合理性检查（止损/止盈触发价）
+// 方向始终检查：多仓止损、空仓止盈必须低于当前价，多仓止盈、空仓止损必须高于当前价；
+// 偏离当前价超过max_deviation_pct时拒绝，或在clamp=true时收紧到边界价
+type PriceBand struct {
+	MaxDeviationPct float64 `json:"max_deviation_pct"` // 最大偏离当前价（%），0表示不限制
+	Clamp           bool    `json:"clamp"`             // 超出时收紧到边界价而不是拒绝
+}
+
+// Validate 验证配置
+func (b PriceBand) Validate() error {
+	if b.MaxDeviationPct < 0 || b.MaxDeviationPct >= 100 {
+		return fmt.Errorf("price_band.max_deviation_pct必须在0-100之间: %.2f", b.MaxDeviationPct)
+	}
+	return nil
+}
+
+// Check 按当前价检查触发价，返回实际使用的价格（kind: stop_loss/take_profit，side: long/short）
+func (b PriceBand) Check(kind, side string, mark, price float64) (float64, error) {
+	if price <= 0 {
+		return 0, fmt.Errorf("价格无效: %.4f", price)
+	}
+	if mark <= 0 {
+		return price, nil // 没有参考价，无法检查
+	}
+
+	below := (kind == "stop_loss") == (side == "long")
+	if below && price >= mark {
+		return 0, fmt.Errorf("%s %s价格%.4f应低于当前价%.4f", side, kind, price, mark)
+	}
+	if !below && price <= mark {
+		return 0, fmt.Errorf("%s %s价格%.4f应高于当前价%.4f", side, kind, price, mark)
+	}
+
+	deviation := math.Abs(price-mark) / mark * 100
+	if b.MaxDeviationPct <= 0 || deviation <= b.MaxDeviationPct {
+		return price, nil
+	}
+	if !b.Clamp {
+		return 0, fmt.Errorf("%s %s价格%.4f偏离当前价%.4f达%.2f%%，超过上限%.2f%%",
+			side, kind, price, mark, deviation, b.MaxDeviationPct)
+	}
+	if below {
+		return mark * (1 - b.MaxDeviationPct/100), nil
+	}
+	return mark * (1 + b.MaxDeviationPct/100), nil
+}
@@ -0,0 +1,171 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"nofx/symbols"
+	"sort"
+	"strings"
+)
+
+// RiskProfile 开仓风控参数（0表示沿用上一层的值）
+type RiskProfile struct {
+	RiskPct             float64 `json:"risk_pct,omitempty"`              // 单笔最大风险：仓位×止损距离占净值的百分比
+	MaxLeverage         int     `json:"max_leverage,omitempty"`          // 杠杆上限
+	MaxPositionMultiple float64 `json:"max_position_multiple,omitempty"` // 单币种仓位价值上限（净值倍数）
+	MinStopPct          float64 `json:"min_stop_pct,omitempty"`          // 止损距入场价的最小百分比（过近容易被噪音扫损）
+	MaxStopPct          float64 `json:"max_stop_pct,omitempty"`          // 止损距入场价的最大百分比
+}
+
+// Merge 用o中非0的字段覆盖p
+func (p RiskProfile) Merge(o RiskProfile) RiskProfile {
+	if o.RiskPct > 0 {
+		p.RiskPct = o.RiskPct
+	}
+	if o.MaxLeverage > 0 {
+		p.MaxLeverage = o.MaxLeverage
+	}
+	if o.MaxPositionMultiple > 0 {
+		p.MaxPositionMultiple = o.MaxPositionMultiple
+	}
+	if o.MinStopPct > 0 {
+		p.MinStopPct = o.MinStopPct
+	}
+	if o.MaxStopPct > 0 {
+		p.MaxStopPct = o.MaxStopPct
+	}
+	return p
+}
+
+// Validate 验证配置（name为配置路径，用于错误信息）
+func (p RiskProfile) Validate(name string) error {
+	if p.RiskPct < 0 || p.RiskPct > 100 {
+		return fmt.Errorf("%s.risk_pct必须在0-100之间: %.2f", name, p.RiskPct)
+	}
+	if p.MaxLeverage < 0 || p.MaxLeverage > 200 {
+		return fmt.Errorf("%s.max_leverage必须在0-200之间: %d", name, p.MaxLeverage)
+	}
+	if p.MaxPositionMultiple < 0 || p.MaxPositionMultiple > 100 {
+		return fmt.Errorf("%s.max_position_multiple必须在0-100之间: %.2f", name, p.MaxPositionMultiple)
+	}
+	if p.MinStopPct < 0 || p.MinStopPct > 100 || p.MaxStopPct < 0 || p.MaxStopPct > 100 {
+		return fmt.Errorf("%s.min_stop_pct/max_stop_pct必须在0-100之间", name)
+	}
+	if p.MinStopPct > 0 && p.MaxStopPct > 0 && p.MinStopPct > p.MaxStopPct {
+		return fmt.Errorf("%s.min_stop_pct(%.2f)不能大于max_stop_pct(%.2f)", name, p.MinStopPct, p.MaxStopPct)
+	}
+	return nil
+}
+
+// String 参数摘要（未设置的项不输出）
+func (p RiskProfile) String() string {
+	var parts []string
+	if p.MaxLeverage > 0 {
+		parts = append(parts, fmt.Sprintf("杠杆≤%dx", p.MaxLeverage))
+	}
+	if p.MaxPositionMultiple > 0 {
+		parts = append(parts, fmt.Sprintf("仓位≤%g倍净值", p.MaxPositionMultiple))
+	}
+	switch {
+	case p.MinStopPct > 0 && p.MaxStopPct > 0:
+		parts = append(parts, fmt.Sprintf("止损距离%g%%-%g%%", p.MinStopPct, p.MaxStopPct))
+	case p.MinStopPct > 0:
+		parts = append(parts, fmt.Sprintf("止损距离≥%g%%", p.MinStopPct))
+	case p.MaxStopPct > 0:
+		parts = append(parts, fmt.Sprintf("止损距离≤%g%%", p.MaxStopPct))
+	}
+	if p.RiskPct > 0 {
+		parts = append(parts, fmt.Sprintf("单笔风险≤净值%g%%", p.RiskPct))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Check 检查开仓参数：entry为预计入场价，stopLoss为止损价（0表示没有止损，不检查止损距离和单笔风险），equity为账户净值
+func (p RiskProfile) Check(leverage int, sizeUSD, entry, stopLoss, equity float64) error {
+	if p.MaxLeverage > 0 && leverage > p.MaxLeverage {
+		return fmt.Errorf("杠杆%dx超过上限%dx", leverage, p.MaxLeverage)
+	}
+	if p.MaxPositionMultiple > 0 && equity > 0 && sizeUSD > equity*p.MaxPositionMultiple*1.01 {
+		return fmt.Errorf("仓位%.2f USDT超过上限%.2f USDT（%g倍净值）", sizeUSD, equity*p.MaxPositionMultiple, p.MaxPositionMultiple)
+	}
+	if stopLoss <= 0 || entry <= 0 {
+		return nil
+	}
+	stopPct := math.Abs(entry-stopLoss) / entry * 100
+	if p.MinStopPct > 0 && stopPct < p.MinStopPct {
+		return fmt.Errorf("止损距离%.2f%%小于下限%g%%", stopPct, p.MinStopPct)
+	}
+	if p.MaxStopPct > 0 && stopPct > p.MaxStopPct {
+		return fmt.Errorf("止损距离%.2f%%超过上限%g%%", stopPct, p.MaxStopPct)
+	}
+	if p.RiskPct > 0 && equity > 0 {
+		if riskPct := sizeUSD * stopPct / equity; riskPct > p.RiskPct {
+			return fmt.Errorf("止损时亏损%.2f USDT（净值的%.2f%%），超过单笔风险上限%g%%", sizeUSD*stopPct/100, riskPct, p.RiskPct)
+		}
+	}
+	return nil
+}
+
+// RiskProfiles 按策略和币种覆盖的风控参数，逐层合并：全局配置（杠杆、敞口上限）→ default → strategies[策略] → symbols[币种]
+// 策略为下单来源（ai或外部信号的来源名），币种使用标准符号
+type RiskProfiles struct {
+	Default    RiskProfile            `json:"default,omitempty"`
+	Strategies map[string]RiskProfile `json:"strategies,omitempty"`
+	Symbols    map[string]RiskProfile `json:"symbols,omitempty"`
+}
+
+// Enabled 是否配置了任何覆盖项
+func (p RiskProfiles) Enabled() bool {
+	return p.Default != (RiskProfile{}) || len(p.Strategies) > 0 || len(p.Symbols) > 0
+}
+
+// Validate 验证配置并把币种统一为标准符号
+func (p *RiskProfiles) Validate() error {
+	if err := p.Default.Validate("risk_profiles.default"); err != nil {
+		return err
+	}
+	for name, profile := range p.Strategies {
+		if err := profile.Validate("risk_profiles.strategies." + name); err != nil {
+			return err
+		}
+	}
+	normalized := make(map[string]RiskProfile, len(p.Symbols))
+	for symbol, profile := range p.Symbols {
+		if err := profile.Validate("risk_profiles.symbols." + symbol); err != nil {
+			return err
+		}
+		canonical := symbols.Canonical(symbol)
+		if _, dup := normalized[canonical]; dup {
+			return fmt.Errorf("risk_profiles.symbols中%s重复配置", canonical)
+		}
+		normalized[canonical] = profile
+	}
+	if p.Symbols != nil {
+		p.Symbols = normalized
+	}
+	return nil
+}
+
+// Resolve 按策略和币种合并出生效的风控参数（base为全局配置）
+func (p RiskProfiles) Resolve(base RiskProfile, strategy, symbol string) RiskProfile {
+	return base.Merge(p.Default).Merge(p.Strategies[strategy]).Merge(p.Symbols[symbols.Canonical(symbol)])
+}
+
+// Overrides 策略和币种的覆盖项摘要（按名称排序，用于AI提示词）
+func (p RiskProfiles) Overrides(strategy string) []string {
+	var lines []string
+	if s := p.Default.Merge(p.Strategies[strategy]).String(); s != "" {
+		lines = append(lines, "所有币种: "+s)
+	}
+	names := make([]string, 0, len(p.Symbols))
+	for symbol := range p.Symbols {
+		names = append(names, symbol)
+	}
+	sort.Strings(names)
+	for _, symbol := range names {
+		if s := p.Symbols[symbol].String(); s != "" {
+			lines = append(lines, symbol+": "+s)
+		}
+	}
+	return lines
+}
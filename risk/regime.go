@@ -0,0 +1,41 @@
+package risk
+
+import "fmt"
+
+// 行情状态（与market.Regime一致）
+var regimes = map[string]bool{"trending": true, "ranging": true, "high_vol": true}
+
+// RegimeFilter 按行情状态过滤开仓：币种当前处于block中的状态时拒绝开仓（行情状态未知时不拦截）
+type RegimeFilter struct {
+	Block []string `json:"block"` // 禁止开仓的行情状态（trending/ranging/high_vol）
+}
+
+// Enabled 是否启用
+func (f RegimeFilter) Enabled() bool {
+	return len(f.Block) > 0
+}
+
+// Validate 验证配置
+func (f RegimeFilter) Validate() error {
+	blocked := make(map[string]bool)
+	for _, regime := range f.Block {
+		if !regimes[regime] {
+			return fmt.Errorf("regime_filter.block包含无效的行情状态: %s（可选: trending/ranging/high_vol）", regime)
+		}
+		blocked[regime] = true
+	}
+	if len(blocked) == len(regimes) {
+		return fmt.Errorf("regime_filter.block不能包含所有行情状态（将禁止一切开仓）")
+	}
+	return nil
+}
+
+// Check 检查当前行情状态是否允许开仓
+func (f RegimeFilter) Check(regime string) error {
+	for _, blocked := range f.Block {
+		if blocked == regime {
+			return fmt.Errorf("当前行情状态为%s，配置禁止在该状态下开仓", regime)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// 下单数量取整方式
+const (
+	RoundNearest = "nearest" // 四舍五入（默认）
+	RoundFloor   = "floor"   // 向下取整（不超过目标数量）
+	RoundCeil    = "ceil"    // 向上取整
+)
+
+// 取整后低于最小下单数量时的处理
+const (
+	BelowMinBump   = "bump"   // 按最小下单数量下单（默认，实际风险可能是目标的数倍）
+	BelowMinReject = "reject" // 拒绝下单
+)
+
+// QuantityRounding 下单数量取整策略：按交易所数量步长取整，取整后低于最小下单数量时提高到最小数量或拒绝
+type QuantityRounding struct {
+	Mode     string `json:"mode"`      // nearest/floor/ceil
+	BelowMin string `json:"below_min"` // bump/reject
+}
+
+// Validate 验证配置并填充默认值
+func (r *QuantityRounding) Validate() error {
+	switch r.Mode {
+	case "":
+		r.Mode = RoundNearest
+	case RoundNearest, RoundFloor, RoundCeil:
+	default:
+		return fmt.Errorf("quantity_rounding.mode无效: %s（可选: nearest/floor/ceil）", r.Mode)
+	}
+	switch r.BelowMin {
+	case "":
+		r.BelowMin = BelowMinBump
+	case BelowMinBump, BelowMinReject:
+	default:
+		return fmt.Errorf("quantity_rounding.below_min无效: %s（可选: bump/reject）", r.BelowMin)
+	}
+	return nil
+}
+
+// Customized 是否修改了默认策略（四舍五入、低于最小数量时按最小数量下单）
+func (r QuantityRounding) Customized() bool {
+	return (r.Mode != "" && r.Mode != RoundNearest) || r.BelowMin == BelowMinReject
+}
+
+// Round 按步长取整（step<=0时不取整）
+func (r QuantityRounding) Round(quantity, step float64) float64 {
+	if step <= 0 {
+		return quantity
+	}
+	units := quantity / step
+	switch r.Mode {
+	case RoundFloor:
+		// 容忍浮点误差（如0.3/0.1=2.9999999999999996）
+		units = math.Floor(units + 1e-9)
+	case RoundCeil:
+		units = math.Ceil(units - 1e-9)
+	default:
+		units = math.Round(units)
+	}
+	return units * step
+}
+
+// Reject 取整后低于最小下单数量时是否拒绝下单
+func (r QuantityRounding) Reject() bool {
+	return r.BelowMin == BelowMinReject
+}
@@ -0,0 +1,152 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// 开仓执行方式
+const (
+	RouteTaker = "taker" // IOC市价单：立即成交，支付吃单费率和半个价差
+	RouteMaker = "maker" // 只挂单（post-only）限价单：享受挂单费率，可能不成交
+	RouteChase = "chase" // 追价限价单：盘口移动时按新的买一/卖一重挂，超时后剩余部分市价成交
+	RouteLimit = "limit" // GTC限价单：按信号指定的价格挂单，超时未成交自动撤单
+)
+
+// EntryRouting 开仓执行方式：market总是市价；maker总是先挂只挂单；auto按预期手续费+滑点成本选择；
+// chase挂只挂单并在盘口移动时追价重挂（次数有限），超时后剩余部分市价成交
+// 只挂单在超时内完全未成交时撤单并改为市价开仓
+// 信号指定limit_price时不受mode影响，挂GTC限价单，limit_timeout_min后撤销未成交部分
+type EntryRouting struct {
+	Mode             string  `json:"mode"`               // market（默认）/maker/auto/chase
+	MakerTimeoutSec  int     `json:"maker_timeout_sec"`  // 只挂单等待成交的秒数（默认10，chase模式为追价总时长）
+	MakerMissBps     float64 `json:"maker_miss_bps"`     // auto模式下只挂单的额外预期成本（未成交改市价、行情走远），基点（默认3）
+	ChaseMaxReprices int     `json:"chase_max_reprices"` // chase模式最多重挂次数（默认3）
+	ChaseIntervalSec int     `json:"chase_interval_sec"` // chase模式每次挂单后等待多久再检查盘口（默认2秒）
+	LimitTimeoutMin  int     `json:"limit_timeout_min"`  // GTC限价单未成交多久后撤单（默认15分钟）
+	LimitCompletePct float64 `json:"limit_complete_pct"` // 限价单撤单时已成交比例达到该百分比则市价补齐剩余（0表示不补齐）
+	ZeroFill         string  `json:"zero_fill"`          // 市价开仓完全未成交时: abort（默认，放弃并告警）/market（重新下单）/limit（在买一/卖一挂只挂单）
+	ZeroFillRetries  int     `json:"zero_fill_retries"`  // zero_fill=market时最多重试次数（默认2）
+}
+
+// 市价开仓完全未成交时的处理方式
+const (
+	ZeroFillAbort  = "abort"
+	ZeroFillMarket = "market"
+	ZeroFillLimit  = "limit"
+)
+
+// Validate 验证配置
+func (r EntryRouting) Validate() error {
+	switch r.Mode {
+	case "", "market", "maker", "auto", "chase":
+	default:
+		return fmt.Errorf("entry_routing.mode必须是market、maker、auto或chase: %s", r.Mode)
+	}
+	if r.MakerTimeoutSec < 0 || r.MakerTimeoutSec > 300 {
+		return fmt.Errorf("entry_routing.maker_timeout_sec必须在0-300之间: %d", r.MakerTimeoutSec)
+	}
+	if r.MakerMissBps < 0 {
+		return fmt.Errorf("entry_routing.maker_miss_bps不能为负数: %.2f", r.MakerMissBps)
+	}
+	if r.ChaseMaxReprices < 0 || r.ChaseMaxReprices > 20 {
+		return fmt.Errorf("entry_routing.chase_max_reprices必须在0-20之间: %d", r.ChaseMaxReprices)
+	}
+	if r.ChaseIntervalSec < 0 || r.ChaseIntervalSec > 60 {
+		return fmt.Errorf("entry_routing.chase_interval_sec必须在0-60之间: %d", r.ChaseIntervalSec)
+	}
+	if r.LimitTimeoutMin < 0 || r.LimitTimeoutMin > 7*24*60 {
+		return fmt.Errorf("entry_routing.limit_timeout_min必须在0-10080之间: %d", r.LimitTimeoutMin)
+	}
+	if r.LimitCompletePct < 0 || r.LimitCompletePct > 100 {
+		return fmt.Errorf("entry_routing.limit_complete_pct必须在0-100之间: %.2f", r.LimitCompletePct)
+	}
+	switch r.ZeroFill {
+	case "", ZeroFillAbort, ZeroFillMarket, ZeroFillLimit:
+	default:
+		return fmt.Errorf("entry_routing.zero_fill必须是abort、market或limit: %s", r.ZeroFill)
+	}
+	if r.ZeroFillRetries < 0 || r.ZeroFillRetries > 5 {
+		return fmt.Errorf("entry_routing.zero_fill_retries必须在0-5之间: %d", r.ZeroFillRetries)
+	}
+	return nil
+}
+
+// Enabled 是否可能使用只挂单开仓
+func (r EntryRouting) Enabled() bool {
+	return r.Mode == "maker" || r.Mode == "auto" || r.Mode == "chase"
+}
+
+// ChaseReprices chase模式最多重挂次数
+func (r EntryRouting) ChaseReprices() int {
+	if r.ChaseMaxReprices == 0 {
+		return 3
+	}
+	return r.ChaseMaxReprices
+}
+
+// ChaseInterval chase模式检查盘口的间隔
+func (r EntryRouting) ChaseInterval() time.Duration {
+	if r.ChaseIntervalSec <= 0 {
+		return 2 * time.Second
+	}
+	return time.Duration(r.ChaseIntervalSec) * time.Second
+}
+
+// LimitTimeout GTC限价单等待成交的时间
+func (r EntryRouting) LimitTimeout() time.Duration {
+	if r.LimitTimeoutMin <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(r.LimitTimeoutMin) * time.Minute
+}
+
+// CompleteLimit 限价单撤单时是否市价补齐剩余数量（已成交filled，总数量quantity）
+func (r EntryRouting) CompleteLimit(filled, quantity float64) bool {
+	return r.LimitCompletePct > 0 && quantity > filled && filled/quantity*100 >= r.LimitCompletePct
+}
+
+// ZeroFillAttempts 市价开仓完全未成交后最多重试的次数（limit只重试一次，abort不重试）
+func (r EntryRouting) ZeroFillAttempts() int {
+	switch r.ZeroFill {
+	case ZeroFillMarket:
+		if r.ZeroFillRetries == 0 {
+			return 2
+		}
+		return r.ZeroFillRetries
+	case ZeroFillLimit:
+		return 1
+	}
+	return 0
+}
+
+// MakerTimeout 只挂单等待成交的时间
+func (r EntryRouting) MakerTimeout() time.Duration {
+	if r.MakerTimeoutSec <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(r.MakerTimeoutSec) * time.Second
+}
+
+// Choose 按预期成本选择执行方式（费率为小数，如0.0005；spreadBps为买一卖一价差，基点）
+// 相对中间价：吃单成本 = 吃单费率 + 半个价差；挂单成本 = 挂单费率 − 半个价差 + 未成交风险
+func (r EntryRouting) Choose(makerFee, takerFee, spreadBps float64) (route string, makerBps, takerBps float64) {
+	missBps := r.MakerMissBps
+	if missBps == 0 {
+		missBps = 3
+	}
+	takerBps = takerFee*1e4 + spreadBps/2
+	makerBps = makerFee*1e4 - spreadBps/2 + missBps
+
+	switch {
+	case r.Mode == "chase":
+		route = RouteChase
+	case r.Mode == "maker":
+		route = RouteMaker
+	case r.Mode == "auto" && makerBps < takerBps:
+		route = RouteMaker
+	default:
+		route = RouteTaker
+	}
+	return route, makerBps, takerBps
+}
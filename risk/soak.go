@@ -0,0 +1,60 @@
+package risk
+
+import (
+	"fmt"
+)
+
+// 浸泡测试注入的故障类型
+const (
+	FaultAPITimeout   = "api_timeout"   // 交易器调用超时（下单类调用随机在执行前或执行后超时，后者结果不确定）
+	FaultWSDrop       = "ws_drop"       // WebSocket推送断线（窗口内丢弃推送，连接状态报告为断开）
+	FaultMalformedLLM = "malformed_llm" // AI返回畸形输出（截断的JSON、纯文本、字段类型错误、重复的决策）
+	FaultPartialFill  = "partial_fill"  // 开仓单只成交一部分，剩余数量撤销
+)
+
+// SoakFault 一类故障的注入计划
+type SoakFault struct {
+	Kind            string  `json:"kind"`                       // api_timeout/ws_drop/malformed_llm/partial_fill
+	Schedule        string  `json:"schedule"`                   // 注入窗口的开始时间（调度表达式，如 "@every 30m"）
+	DurationSeconds int     `json:"duration_seconds,omitempty"` // 窗口长度（0表示只影响窗口开始后的第一次调用，ws_drop必须大于0）
+	Probability     float64 `json:"probability,omitempty"`      // 窗口内每次调用注入的概率（0表示1，即每次都注入）
+}
+
+// Soak 浸泡测试：模拟交易按计划注入故障，每个决策周期结束后检查不变量（持仓都有止损、同一周期内不重复开仓），
+// 作为编排层的回归测试长时间运行（只能用于模拟交易）
+type Soak struct {
+	Enabled         bool        `json:"enabled"`
+	Seed            int64       `json:"seed,omitempty"`              // 随机数种子（0表示按启动时间，相同种子可复现同一注入序列）
+	Faults          []SoakFault `json:"faults"`                      // 注入计划
+	HaltOnViolation bool        `json:"halt_on_violation,omitempty"` // 不变量被破坏时暂停交易（保留现场）
+}
+
+// Validate 验证配置（调度表达式由配置加载时检查）
+func (s Soak) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if len(s.Faults) == 0 {
+		return fmt.Errorf("soak.faults不能为空")
+	}
+	for i, f := range s.Faults {
+		switch f.Kind {
+		case FaultAPITimeout, FaultWSDrop, FaultMalformedLLM, FaultPartialFill:
+		default:
+			return fmt.Errorf("soak.faults[%d].kind必须是api_timeout、ws_drop、malformed_llm或partial_fill: %s", i, f.Kind)
+		}
+		if f.Schedule == "" {
+			return fmt.Errorf("soak.faults[%d].schedule不能为空", i)
+		}
+		if f.DurationSeconds < 0 || f.DurationSeconds > 86400 {
+			return fmt.Errorf("soak.faults[%d].duration_seconds必须在0-86400之间: %d", i, f.DurationSeconds)
+		}
+		if f.Kind == FaultWSDrop && f.DurationSeconds == 0 {
+			return fmt.Errorf("soak.faults[%d]: ws_drop需要设置duration_seconds（断线时长）", i)
+		}
+		if f.Probability < 0 || f.Probability > 1 {
+			return fmt.Errorf("soak.faults[%d].probability必须在0-1之间: %v", i, f.Probability)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package risk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SoftCloseRules 支持软平仓的规则（触发后进入待确认队列，而不是直接平仓）
+var SoftCloseRules = map[string]string{
+	"time_exit": "按时间平仓（最长持仓时间、周末前平仓）",
+	"dca_stop":  "DCA总体止损",
+}
+
+// SoftClose 软平仓：指定规则触发平仓时只生成平仓建议，由操作员通过Telegram按钮或管理接口确认后执行
+type SoftClose struct {
+	Rules          []string `json:"rules"`           // 需要人工确认的规则（见SoftCloseRules）
+	TimeoutMinutes int      `json:"timeout_minutes"` // 建议超过N分钟未处理时自动执行平仓（0表示一直等待确认）
+}
+
+// Enabled 是否有需要人工确认的规则
+func (c SoftClose) Enabled() bool {
+	return len(c.Rules) > 0
+}
+
+// Validate 验证配置
+func (c SoftClose) Validate() error {
+	for _, rule := range c.Rules {
+		if _, ok := SoftCloseRules[rule]; !ok {
+			names := make([]string, 0, len(SoftCloseRules))
+			for name := range SoftCloseRules {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("soft_close.rules包含不支持的规则: %s（可选: %s）", rule, strings.Join(names, "/"))
+		}
+	}
+	if c.TimeoutMinutes < 0 {
+		return fmt.Errorf("soft_close.timeout_minutes不能为负数")
+	}
+	return nil
+}
+
+// Applies 该规则触发的平仓是否需要人工确认
+func (c SoftClose) Applies(rule string) bool {
+	for _, r := range c.Rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// Staleness 决策过期保护：AI决策基于的行情快照距执行时间过长、或价格已明显偏离快照时拒绝执行，
+// 避免LLM响应慢时按与决策时相差很大的价格下单
+type Staleness struct {
+	MaxAgeSeconds int     `json:"max_age_seconds"` // 行情快照到执行的最长间隔（秒，0表示不检查）
+	MaxMovePct    float64 `json:"max_move_pct"`    // 执行时价格相对快照价格的最大变动（%，0表示不检查）
+	IncludeCloses bool    `json:"include_closes"`  // 平仓是否同样检查（默认只检查开仓，过期的平仓仍然执行以降低风险）
+}
+
+// Validate 验证配置
+func (s Staleness) Validate() error {
+	if s.MaxAgeSeconds < 0 {
+		return fmt.Errorf("staleness.max_age_seconds不能为负数")
+	}
+	if s.MaxMovePct < 0 {
+		return fmt.Errorf("staleness.max_move_pct不能为负数")
+	}
+	return nil
+}
+
+// Enabled 是否启用
+func (s Staleness) Enabled() bool {
+	return s.MaxAgeSeconds > 0 || s.MaxMovePct > 0
+}
+
+// MaxAge 行情快照到执行的最长间隔
+func (s Staleness) MaxAge() time.Duration {
+	return time.Duration(s.MaxAgeSeconds) * time.Second
+}
+
+// Check 检查快照时间和快照价格（快照价格或当前价格未知时只检查时间），返回过期原因（未过期时为空）
+func (s Staleness) Check(age time.Duration, snapshotPrice, price float64) string {
+	if s.MaxAgeSeconds > 0 && age > s.MaxAge() {
+		return fmt.Sprintf("距行情快照%.0f秒，超过%d秒", age.Seconds(), s.MaxAgeSeconds)
+	}
+	if s.MaxMovePct > 0 && snapshotPrice > 0 && price > 0 {
+		move := (price - snapshotPrice) / snapshotPrice * 100
+		if move > s.MaxMovePct || move < -s.MaxMovePct {
+			return fmt.Sprintf("价格较行情快照变动%+.2f%%（%.6g → %.6g），超过%.2f%%", move, snapshotPrice, price, s.MaxMovePct)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+)
+
+// SignalThrottle 信号去重和开仓节流：同一来源对同一币种给出的相同动作在dedup_window_min内只执行一次，
+// 同一币种两次开仓之间至少间隔min_entry_interval_min（防止AI在连续周期重复同一决策时叠加开仓）
+type SignalThrottle struct {
+	DedupWindowMin      int `json:"dedup_window_min"`       // 相同动作去重窗口（分钟，0表示不去重）
+	MinEntryIntervalMin int `json:"min_entry_interval_min"` // 同一币种两次开仓的最小间隔（分钟，0表示不限制，不区分方向和来源）
+}
+
+// Enabled 是否启用
+func (t SignalThrottle) Enabled() bool {
+	return t.DedupWindowMin > 0 || t.MinEntryIntervalMin > 0
+}
+
+// Validate 验证配置
+func (t SignalThrottle) Validate() error {
+	if t.DedupWindowMin < 0 || t.DedupWindowMin > 10080 {
+		return fmt.Errorf("signal_throttle.dedup_window_min必须在0-10080之间: %d", t.DedupWindowMin)
+	}
+	if t.MinEntryIntervalMin < 0 || t.MinEntryIntervalMin > 10080 {
+		return fmt.Errorf("signal_throttle.min_entry_interval_min必须在0-10080之间: %d", t.MinEntryIntervalMin)
+	}
+	return nil
+}
+
+// Check 检查动作是否允许执行：lastSame为同一来源同一币种同一动作上次执行的时间，lastEntry为该币种上次开仓的时间（零值表示没有）
+func (t SignalThrottle) Check(action string, now, lastSame, lastEntry time.Time) error {
+	if window := time.Duration(t.DedupWindowMin) * time.Minute; window > 0 && !lastSame.IsZero() && now.Sub(lastSame) < window {
+		return fmt.Errorf("%s与%s前执行的信号重复（去重窗口%d分钟）", action, now.Sub(lastSame).Round(time.Second), t.DedupWindowMin)
+	}
+	if action != "open_long" && action != "open_short" {
+		return nil
+	}
+	if interval := time.Duration(t.MinEntryIntervalMin) * time.Minute; interval > 0 && !lastEntry.IsZero() && now.Sub(lastEntry) < interval {
+		return fmt.Errorf("距上次开仓仅%s，未达到最小间隔%d分钟", now.Sub(lastEntry).Round(time.Second), t.MinEntryIntervalMin)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+)
+
+// 单笔亏损超出上限时的处理方式
+const (
+	TradeLossReject = "reject" // 拒绝开仓（默认）
+	TradeLossResize = "resize" // 按上限缩小仓位
+)
+
+// defaultTradeLossFeePct 无法查询账户费率时按吃单费率估算手续费（%）
+const defaultTradeLossFeePct = 0.05
+
+// TradeLossCap 单笔最大亏损硬上限：每笔开仓必须带止损，按仓位×止损距离加上开仓和止损平仓的手续费估算止损时的最大亏损，
+// 超过max_loss_usdt时拒绝或缩小仓位，不论AI或外部信号给出多大的仓位
+type TradeLossCap struct {
+	MaxLossUSDT float64 `json:"max_loss_usdt"` // 单笔止损时的最大亏损（USDT，含手续费，0表示不限制）
+	Action      string  `json:"action"`        // 超出上限时: reject（拒绝，默认）/resize（按上限缩小仓位）
+	FeePct      float64 `json:"fee_pct"`       // 无法查询账户费率时使用的吃单费率（%，默认0.05）
+}
+
+// Validate 验证配置
+func (c TradeLossCap) Validate() error {
+	if c.MaxLossUSDT < 0 {
+		return fmt.Errorf("max_trade_loss.max_loss_usdt不能为负数")
+	}
+	if c.FeePct < 0 || c.FeePct > 1 {
+		return fmt.Errorf("max_trade_loss.fee_pct必须在0-1之间: %.4f", c.FeePct)
+	}
+	switch c.Action {
+	case "", TradeLossReject, TradeLossResize:
+	default:
+		return fmt.Errorf("max_trade_loss.action必须是reject或resize: %s", c.Action)
+	}
+	return nil
+}
+
+// Enabled 是否启用
+func (c TradeLossCap) Enabled() bool {
+	return c.MaxLossUSDT > 0
+}
+
+// Resize 超出上限时是否缩小仓位（否则拒绝开仓）
+func (c TradeLossCap) Resize() bool {
+	return c.Action == TradeLossResize
+}
+
+// Fee 未查询到账户费率时使用的吃单费率（小数）
+func (c TradeLossCap) Fee() float64 {
+	if c.FeePct > 0 {
+		return c.FeePct / 100
+	}
+	return defaultTradeLossFeePct / 100
+}
+
+// lossPerUSD 每1 USDT仓位价值在止损时的亏损：止损距离加上开仓手续费和按止损价平仓的手续费（费率为小数）
+func lossPerUSD(entry, stopLoss, entryFee, exitFee float64) float64 {
+	return math.Abs(entry-stopLoss)/entry + entryFee + stopLoss/entry*exitFee
+}
+
+// WorstLoss 按入场价entry开仓sizeUSD、在stopLoss止损时的亏损（USDT，含手续费，entryFee/exitFee为开仓和止损平仓的小数费率）
+func WorstLoss(sizeUSD, entry, stopLoss, entryFee, exitFee float64) float64 {
+	if entry <= 0 || stopLoss <= 0 {
+		return 0
+	}
+	return sizeUSD * lossPerUSD(entry, stopLoss, entryFee, exitFee)
+}
+
+// MaxSize 止损时亏损不超过上限的最大仓位价值（USDT）
+func (c TradeLossCap) MaxSize(entry, stopLoss, entryFee, exitFee float64) float64 {
+	if entry <= 0 || stopLoss <= 0 {
+		return 0
+	}
+	return c.MaxLossUSDT / lossPerUSD(entry, stopLoss, entryFee, exitFee)
+}
+
+// Check 检查开仓的最大亏损，返回允许的仓位价值（未超出上限时为sizeUSD）；没有止损、或超出上限且不缩小仓位时返回错误
+func (c TradeLossCap) Check(sizeUSD, entry, stopLoss, entryFee, exitFee float64) (float64, error) {
+	if stopLoss <= 0 {
+		return 0, fmt.Errorf("开仓必须设置止损（单笔亏损上限%.2f USDT）", c.MaxLossUSDT)
+	}
+	if entry <= 0 {
+		return 0, fmt.Errorf("入场价未知，无法估算止损亏损")
+	}
+	loss := WorstLoss(sizeUSD, entry, stopLoss, entryFee, exitFee)
+	if loss <= c.MaxLossUSDT {
+		return sizeUSD, nil
+	}
+	if !c.Resize() {
+		return 0, fmt.Errorf("止损时亏损%.2f USDT（含手续费），超过单笔亏损上限%.2f USDT", loss, c.MaxLossUSDT)
+	}
+	return c.MaxSize(entry, stopLoss, entryFee, exitFee), nil
+}
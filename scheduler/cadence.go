@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"fmt"
+	"nofx/market"
+	"sort"
+)
+
+// SymbolTier 决策频率分层：列出的币种每every个AI决策周期评估一次
+type SymbolTier struct {
+	Symbols []string `json:"symbols"`
+	Every   int      `json:"every"` // 每N个决策周期评估一次（默认1，即每个周期）
+}
+
+// Cadence 按币种分层的决策频率：decision周期按最快的一层设置，较慢的层和币种池错开分散到各个周期，
+// 每个周期的提示词长度和行情请求数大致相同（AI调用和交易所请求不会在某个周期集中）
+type Cadence struct {
+	Tiers      []SymbolTier `json:"tiers"`       // 分层币种（总是作为候选币种，即使不在币种池中）
+	TailEvery  int          `json:"tail_every"`  // 未列入分层的候选币种（币种池）每N个周期评估一次（默认1）
+	MaxSymbols int          `json:"max_symbols"` // 每个周期最多评估的候选币种数（按分层顺序保留，币种池在后；0表示不限制）
+}
+
+// Validate 验证配置
+func (c Cadence) Validate() error {
+	seen := make(map[string]bool)
+	for i, tier := range c.Tiers {
+		if len(tier.Symbols) == 0 {
+			return fmt.Errorf("schedule.cadence.tiers[%d].symbols不能为空", i)
+		}
+		if tier.Every < 0 {
+			return fmt.Errorf("schedule.cadence.tiers[%d].every不能为负数", i)
+		}
+		for _, symbol := range tier.Symbols {
+			symbol = market.Normalize(symbol)
+			if seen[symbol] {
+				return fmt.Errorf("schedule.cadence: %s 出现在多个分层中", symbol)
+			}
+			seen[symbol] = true
+		}
+	}
+	if c.TailEvery < 0 {
+		return fmt.Errorf("schedule.cadence.tail_every不能为负数")
+	}
+	if c.MaxSymbols < 0 {
+		return fmt.Errorf("schedule.cadence.max_symbols不能为负数")
+	}
+	return nil
+}
+
+// Enabled 是否启用分层频率
+func (c Cadence) Enabled() bool {
+	return len(c.Tiers) > 0 || c.TailEvery > 1 || c.MaxSymbols > 0
+}
+
+// Select 第cycle个决策周期需要评估的候选币种：先是到期的分层币种（按配置顺序），再是到期的币种池币种。
+// 同一层的币种按位置错开，币种池按名称排序后的位置错开，使每N个周期中每个币种恰好被评估一次、
+// 各周期的币种数相差不超过1（币种池成员变化时，个别币种的间隔可能短于或长于N个周期）
+func (c Cadence) Select(cycle int, pool []string) []string {
+	selected := make([]string, 0, len(pool))
+	seen := make(map[string]bool)
+	add := func(symbol string) {
+		if !seen[symbol] && (c.MaxSymbols <= 0 || len(selected) < c.MaxSymbols) {
+			selected = append(selected, symbol)
+		}
+		seen[symbol] = true
+	}
+
+	for _, tier := range c.Tiers {
+		for i, symbol := range tier.Symbols {
+			symbol = market.Normalize(symbol)
+			if due(cycle, i, tier.Every) {
+				add(symbol)
+			} else {
+				seen[symbol] = true // 未到期的分层币种不按币种池的频率评估
+			}
+		}
+	}
+	var tail []string
+	for _, symbol := range pool {
+		if !seen[symbol] {
+			tail = append(tail, symbol)
+		}
+	}
+	sort.Strings(tail)
+	for i, symbol := range tail {
+		if due(cycle, i, c.TailEvery) {
+			add(symbol)
+		}
+	}
+	return selected
+}
+
+// due 偏移为offset的币种在第cycle个周期是否到期（every<=1时每个周期都到期）
+func due(cycle, offset, every int) bool {
+	if every <= 1 {
+		return true
+	}
+	return (cycle+offset)%every == 0
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"fmt"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BarIntervals 可用于按K线收盘触发AI决策的周期（交易所K线推送支持的周期）
+var BarIntervals = []string{"10s", "1m", "5m", "15m", "30m", "1h", "4h", "8h", "1d", "7d"}
+
+// Config 策略调度配置
+type Config struct {
+	Decision string          `json:"decision"` // AI决策周期（如 "@every 15m" 或 "*/15 * * * *"，默认按scan_interval_minutes）
+	Watchdog string          `json:"watchdog"` // 策略看守周期：DCA/资金费率套利等（如 "@every 30s"，默认与decision一致）
+	Equity   string          `json:"equity"`   // 净值快照周期（默认 "@every 5m"）
+	Sessions []SessionWindow `json:"sessions"` // AI决策的交易时段（UTC，为空表示全天）
+	// BarInterval 按K线收盘触发AI决策的周期（如 "15m"，设置后代替decision；交易器不支持K线推送时回退到decision）
+	BarInterval string `json:"bar_interval"`
+	BarSymbol   string `json:"bar_symbol"` // 用于判断收盘的币种（默认BTCUSDT，各币种同周期K线同时收盘）
+	// StageTimeouts AI决策周期各阶段（快照/决策/风控/执行/确认）的超时
+	StageTimeouts StageTimeouts `json:"stage_timeouts"`
+	// Cadence 按币种分层的决策频率（BTC/ETH等每个周期评估，长尾币种错开隔几个周期评估）
+	Cadence Cadence `json:"cadence"`
+	EntryRules
+	ExitRules
+}
+
+// Validate 验证调度配置
+func (c *Config) Validate() error {
+	if c.Decision != "" {
+		if _, err := Parse(c.Decision); err != nil {
+			return fmt.Errorf("schedule.decision: %w", err)
+		}
+	}
+	if c.Watchdog != "" {
+		if _, err := Parse(c.Watchdog); err != nil {
+			return fmt.Errorf("schedule.watchdog: %w", err)
+		}
+	}
+	if c.Equity != "" {
+		if _, err := Parse(c.Equity); err != nil {
+			return fmt.Errorf("schedule.equity: %w", err)
+		}
+	}
+	if c.BarInterval != "" && !contains(BarIntervals, c.BarInterval) {
+		return fmt.Errorf("schedule.bar_interval无效: %s（可选: %s）", c.BarInterval, strings.Join(BarIntervals, "/"))
+	}
+	if err := c.StageTimeouts.Validate(); err != nil {
+		return err
+	}
+	if err := c.Cadence.Validate(); err != nil {
+		return err
+	}
+	for _, w := range c.Sessions {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("schedule.sessions: %w", err)
+		}
+	}
+	if c.NoEntryBeforeFundingMinutes < 0 {
+		return fmt.Errorf("schedule.no_entry_before_funding_minutes不能为负数")
+	}
+	if c.FundingIntervalHours < 0 || (c.FundingIntervalHours > 0 && 24%c.FundingIntervalHours != 0) {
+		return fmt.Errorf("schedule.funding_interval_hours必须能整除24")
+	}
+	if c.NoEntryAfterFundingMinutes < 0 || c.NoEntryAfterFundingMinutes >= c.fundingInterval()*60 {
+		return fmt.Errorf("schedule.no_entry_after_funding_minutes必须在0-%d之间", c.fundingInterval()*60-1)
+	}
+	for i, w := range c.Blackouts {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("schedule.blackouts[%d]: %w", i, err)
+		}
+	}
+	if c.MaxHoldingHours < 0 {
+		return fmt.Errorf("schedule.max_holding_hours不能为负数")
+	}
+	if c.WeekendCloseLeadMinutes < 0 || c.WeekendCloseLeadMinutes > 24*60 {
+		return fmt.Errorf("schedule.weekend_close_lead_minutes必须在0-1440之间")
+	}
+	return nil
+}
+
+// BarSymbolOrDefault 用于判断K线收盘的币种
+func (c Config) BarSymbolOrDefault() string {
+	if c.BarSymbol == "" {
+		return "BTCUSDT"
+	}
+	return market.Normalize(c.BarSymbol)
+}
+
+// BarDuration K线周期的时长（如 "15m"、"1d"，无法解析时返回0）
+func BarDuration(interval string) time.Duration {
+	if days, ok := strings.CutSuffix(interval, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(n) * 24 * time.Hour
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// BarWatchdog 按K线收盘触发AI决策时的看门狗时长：超过1.5个K线周期未收到收盘事件（推送停滞）时按时间执行一次决策
+func (c Config) BarWatchdog() time.Duration {
+	return BarDuration(c.BarInterval) * 3 / 2
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
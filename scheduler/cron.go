@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 调度计划：给定当前时间，计算下一次运行时间
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// everySchedule 固定间隔调度（"@every 30s"）
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next 下一次运行时间
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// cronSchedule 5字段cron调度（分 时 日 月 周）
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+
+	domAny, dowAny bool // 日/周字段以*开头（不限制）
+}
+
+// dayMatches 日期是否匹配日和周字段：与标准cron相同，两个字段都有限制时满足其一即可，否则按有限制的字段匹配
+// （如 "0 0 1 * 1" 在每月1日和每个周一运行）
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	if !s.domAny && !s.dowAny {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// Next 下一次运行时间（按分钟粒度向后搜索，最多搜索一年）
+func (s cronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(1, 0, 0)
+	for next.Before(limit) {
+		if s.month[int(next.Month())] && s.dayMatches(next) && s.hour[next.Hour()] && s.minute[next.Minute()] {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+	return limit
+}
+
+// Parse 解析调度表达式
+// 支持: "@every 15m" / "@every 30s" / 标准5字段cron（如 "*/15 * * * *"、"0 8,16 * * 1-5"）
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("调度表达式不能为空")
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("解析间隔失败 '%s': %w", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("调度间隔不能小于1秒: %s", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron表达式必须包含5个字段（分 时 日 月 周）: '%s'", spec)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	sets := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron表达式 '%s' 第%d个字段错误: %w", spec, i+1, err)
+		}
+		sets[i] = set
+	}
+
+	return cronSchedule{minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny: strings.HasPrefix(fields[2], "*"), dowAny: strings.HasPrefix(fields[4], "*")}, nil
+}
+
+// parseField 解析单个cron字段（支持 * 、*/n 、a-b 、a-b/n 、逗号列表）
+func parseField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("无效步长: %s", part)
+			}
+			step = n
+			part = part[:idx]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("无效范围: %s", part)
+			}
+			lo, hi = a, b
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("无效值: %s", part)
+			}
+			lo, hi = v, v
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("取值超出范围[%d-%d]: %s", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
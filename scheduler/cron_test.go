@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNextDayOfMonthOrWeekday(t *testing.T) {
+	// 2026-01-01是周四
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{
+			// 日和周都有限制：每月1日或每个周一
+			spec: "0 0 1 * 1",
+			want: []time.Time{
+				time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 只限制周：每个周一
+			spec: "0 0 * * 1",
+			want: []time.Time{
+				time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 只限制日：每月15日
+			spec: "30 8 15 * *",
+			want: []time.Time{
+				time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC),
+				time.Date(2026, 2, 15, 8, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 周字段以*开头（带步长）时两个字段同时匹配：每月13日且为周日、周二、周四或周六
+			spec: "0 0 13 * */2",
+			want: []time.Time{
+				time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			next := start
+			for i, want := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("第%d次运行时间 = %s，期望 %s", i+1, next.Format(time.RFC3339), want.Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	// 2026-01-01是周四
+	start := time.Date(2026, 1, 1, 12, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec    string
+		want    time.Time // 从start起的下一次运行时间
+		wantErr bool
+	}{
+		{spec: "@every 15m", want: start.Add(15 * time.Minute)},
+		{spec: " @every 30s ", want: start.Add(30 * time.Second)},
+		{spec: "*/15 * * * *", want: time.Date(2026, 1, 1, 12, 15, 0, 0, time.UTC)},
+		{spec: "0 8,16 * * *", want: time.Date(2026, 1, 1, 16, 0, 0, 0, time.UTC)},
+		{spec: "0 8 * * 1-5", want: time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 6", want: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "30 0-6/3 * * *", want: time.Date(2026, 1, 2, 0, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1 3 *", want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 12 1,15 * *", want: time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)},
+
+		{spec: "", wantErr: true},
+		{spec: "@every", wantErr: true},
+		{spec: "@every 500ms", wantErr: true},
+		{spec: "@every soon", wantErr: true},
+		{spec: "* * * *", wantErr: true},
+		{spec: "* * * * * *", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "* * * * 7", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "5-1 * * * *", wantErr: true},
+		{spec: "a * * * *", wantErr: true},
+		{spec: "1-x * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q)应返回错误", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			if next := s.Next(start); !next.Equal(tt.want) {
+				t.Fatalf("下一次运行时间 = %s，期望 %s", next.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+			}
+		})
+	}
+}
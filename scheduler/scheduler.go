@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"fmt"
+	"nofx/clock"
+	"nofx/logging"
+	"sync"
+	"time"
+)
+
+// logger 调度器日志
+var logger = logging.For("scheduler")
+
+// job 调度任务
+type job struct {
+	name     string
+	schedule Schedule
+	sessions []SessionWindow
+	fn       func()
+	deferred bool             // 启动时不立即执行，只在计划时间运行
+	trigger  <-chan time.Time // 事件触发的任务：每收到一次执行一次（schedule为触发通道关闭后的回退计划，可为nil）
+	watchdog time.Duration    // 事件触发的任务超过该时长未收到触发时执行一次（0表示不检查）
+}
+
+// Scheduler 多任务调度器：每个任务按各自的cron/间隔独立运行，
+// 并可限定只在交易时段内运行
+type Scheduler struct {
+	jobs    []*job
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running bool
+	clock   clock.Clock
+}
+
+// New 创建调度器
+func New() *Scheduler {
+	return &Scheduler{
+		stopCh: make(chan struct{}),
+		clock:  clock.Real,
+	}
+}
+
+// SetClock 设置时钟（回测和测试使用模拟时钟，需在Start之前调用）
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock.Or(c)
+}
+
+// AddJob 添加任务（spec为 "@every 15m" 或5字段cron表达式）
+// sessions 为空表示任何时间都运行
+func (s *Scheduler) AddJob(name, spec string, sessions []SessionWindow, fn func()) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("任务 %s: %w", name, err)
+	}
+	for _, w := range sessions {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("任务 %s 交易时段配置错误: %w", name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		schedule: schedule,
+		sessions: sessions,
+		fn:       fn,
+	})
+	return nil
+}
+
+// AddTimedJob 添加只在计划时间运行的任务（启动时不立即执行，适合汇总报告等定时任务）
+func (s *Scheduler) AddTimedJob(name, spec string, fn func()) error {
+	if err := s.AddJob(name, spec, nil, fn); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[len(s.jobs)-1].deferred = true
+	return nil
+}
+
+// AddTriggeredJob 添加由外部事件触发的任务（如K线收盘）：trigger每收到一次执行一次，启动时不立即执行
+// watchdog>0时超过该时长未收到触发（事件源停滞）即执行一次；trigger关闭后按fallbackSpec的计划继续运行（为空时任务结束）
+// sessions 为空表示任何时间都运行
+func (s *Scheduler) AddTriggeredJob(name string, trigger <-chan time.Time, watchdog time.Duration, fallbackSpec string,
+	sessions []SessionWindow, fn func()) error {
+	if trigger == nil {
+		return fmt.Errorf("任务 %s: 触发通道为空", name)
+	}
+	var fallback Schedule
+	if fallbackSpec != "" {
+		var err error
+		if fallback, err = Parse(fallbackSpec); err != nil {
+			return fmt.Errorf("任务 %s: %w", name, err)
+		}
+	}
+	for _, w := range sessions {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("任务 %s 交易时段配置错误: %w", name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job{
+		name:     name,
+		schedule: fallback,
+		sessions: sessions,
+		fn:       fn,
+		deferred: true,
+		trigger:  trigger,
+		watchdog: watchdog,
+	})
+	return nil
+}
+
+// Start 启动所有任务（除AddTimedJob和AddTriggeredJob添加的任务外，每个任务先立即执行一次，之后按计划运行）
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.runJob(j)
+	}
+}
+
+// Stop 停止所有任务并等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// runJob 运行单个任务循环（同一任务串行执行，不会重叠）
+func (s *Scheduler) runJob(j *job) {
+	defer s.wg.Done()
+
+	if !j.deferred {
+		s.execute(j, s.clock.Now())
+	}
+	if j.trigger != nil && !s.runTriggered(j) {
+		return
+	}
+	for {
+		now := s.clock.Now()
+		next := j.schedule.Next(now)
+		select {
+		case <-s.stopCh:
+			return
+		case now := <-s.clock.After(next.Sub(now)):
+			s.execute(j, now)
+		}
+	}
+}
+
+// runTriggered 事件触发任务的循环：超过watchdog未收到触发时执行一次，之后重新计时
+// 触发通道关闭且有回退计划时返回true（由runJob按计划继续运行），停止或没有回退计划时返回false
+func (s *Scheduler) runTriggered(j *job) bool {
+	for {
+		var watchdog <-chan time.Time
+		if j.watchdog > 0 {
+			watchdog = s.clock.After(j.watchdog)
+		}
+		select {
+		case <-s.stopCh:
+			return false
+		case now, ok := <-j.trigger:
+			if !ok {
+				if j.schedule == nil {
+					logger.Warn("触发通道已关闭，任务停止", "job", j.name)
+					return false
+				}
+				logger.Warn("触发通道已关闭，改为按计划运行", "job", j.name)
+				return true
+			}
+			s.execute(j, now)
+		case now := <-watchdog:
+			logger.Warn("超时未收到触发，按看门狗执行一次", "job", j.name, "watchdog", j.watchdog)
+			s.execute(j, now)
+		}
+	}
+}
+
+// execute 执行一次任务（不在交易时段内则跳过）
+func (s *Scheduler) execute(j *job, now time.Time) {
+	if !InSessions(j.sessions, now) {
+		logger.Debug("当前不在交易时段内，跳过本次执行", "job", j.name)
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("任务执行panic", "job", j.name, "panic", r)
+		}
+	}()
+	j.fn()
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"nofx/clock"
+	"testing"
+	"time"
+)
+
+func TestTriggeredJobWatchdogAndFallback(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := clock.NewManual(start)
+	trigger := make(chan time.Time)
+	ran := make(chan time.Time, 4)
+
+	s := New()
+	s.SetClock(c)
+	if err := s.AddTriggeredJob("bar", trigger, 10*time.Minute, "@every 1m", nil, func() { ran <- c.Now() }); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	waitWaiters := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for c.Waiters() != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待计时器数量%d超时（当前%d）", n, c.Waiters())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	expectRun := func(stage string, want time.Time) {
+		t.Helper()
+		select {
+		case got := <-ran:
+			if !got.Equal(want) {
+				t.Fatalf("%s: 执行时间 %v，期望 %v", stage, got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: 任务未执行", stage)
+		}
+	}
+
+	// 收到触发时执行
+	waitWaiters(1)
+	trigger <- start
+	expectRun("触发", start)
+
+	// 超过watchdog未收到触发时执行一次
+	waitWaiters(2)
+	c.Advance(10 * time.Minute)
+	expectRun("看门狗", start.Add(10*time.Minute))
+
+	// 触发通道关闭后按回退计划运行
+	waitWaiters(1)
+	close(trigger)
+	waitWaiters(2)
+	c.Advance(time.Minute)
+	expectRun("回退计划", start.Add(11*time.Minute))
+}
+
+func TestTriggeredJobStopsWithoutFallback(t *testing.T) {
+	trigger := make(chan time.Time)
+	s := New()
+	s.SetClock(clock.NewManual(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if err := s.AddTriggeredJob("bar", trigger, 0, "", nil, func() { t.Error("通道关闭后不应执行") }); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	close(trigger)
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop未返回")
+	}
+}
+
+func TestBarDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"10s": 10 * time.Second,
+		"15m": 15 * time.Minute,
+		"4h":  4 * time.Hour,
+		"1d":  24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"":    0,
+		"xd":  0,
+	}
+	for interval, want := range tests {
+		if got := BarDuration(interval); got != want {
+			t.Errorf("BarDuration(%q) = %v，期望 %v", interval, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayNames 星期缩写映射
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// SessionWindow 交易时段窗口（UTC）
+// 例如 {"days": ["mon","tue"], "start": "08:00", "end": "20:00"}；end早于start表示跨零点
+type SessionWindow struct {
+	Days  []string `json:"days"`  // 生效的星期（为空表示每天）
+	Start string   `json:"start"` // 开始时间 HH:MM（UTC）
+	End   string   `json:"end"`   // 结束时间 HH:MM（UTC）
+}
+
+// Validate 验证时段配置
+func (w SessionWindow) Validate() error {
+	for _, d := range w.Days {
+		if _, ok := weekdayNames[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("无效的星期: %s（应为 mon/tue/wed/thu/fri/sat/sun）", d)
+		}
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Contains 判断时间是否落在该时段内
+func (w SessionWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	start, _ := parseClock(w.Start)
+	end, _ := parseClock(w.End)
+	minute := t.Hour()*60 + t.Minute()
+
+	// 跨零点的时段按开始日期判断星期
+	day := t.Weekday()
+	inWindow := false
+	if start <= end {
+		inWindow = minute >= start && minute < end
+	} else if minute >= start {
+		inWindow = true
+	} else if minute < end {
+		inWindow = true
+		day = (day + 6) % 7
+	}
+	if !inWindow {
+		return false
+	}
+
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdayNames[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock 解析HH:MM为当日分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间格式 '%s'（应为HH:MM）", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// InSessions 判断时间是否在任一交易时段内（未配置时段视为全天可交易）
+func InSessions(sessions []SessionWindow, t time.Time) bool {
+	if len(sessions) == 0 {
+		return true
+	}
+	for _, w := range sessions {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// BlackoutWindow 禁止开新仓的时段（UTC），如交易所每日维护、结算时间、自定义静默时段
+// 例如 {"name": "maintenance", "start": "23:55", "end": "00:10"}；平仓、止损等降低风险的操作不受影响
+type BlackoutWindow struct {
+	Name string `json:"name"` // 名称（写入拒绝原因）
+	SessionWindow
+}
+
+// label 拒绝原因中的时段名称
+func (w BlackoutWindow) label() string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return w.Start + "-" + w.End
+}
+
+// EntryRules 禁止开新仓的时间规则
+type EntryRules struct {
+	NoEntryBeforeFundingMinutes int              `json:"no_entry_before_funding_minutes"` // 资金费结算前N分钟禁止开仓（0表示不限制）
+	NoEntryAfterFundingMinutes  int              `json:"no_entry_after_funding_minutes"`  // 资金费结算后N分钟禁止开仓（0表示不限制）
+	FundingIntervalHours        int              `json:"funding_interval_hours"`          // 资金费结算间隔（小时，默认8）
+	NoEntryWeekend              bool             `json:"no_entry_weekend"`                // 周末（周五21:00 UTC至周日21:00 UTC）禁止开仓
+	Blackouts                   []BlackoutWindow `json:"blackouts"`                       // 禁止开仓时段（UTC）
+}
+
+// fundingInterval 资金费结算间隔（小时）
+func (r EntryRules) fundingInterval() int {
+	if r.FundingIntervalHours <= 0 {
+		return 8
+	}
+	return r.FundingIntervalHours
+}
+
+// EntryBlocked 判断当前是否禁止开新仓，返回原因
+func (r EntryRules) EntryBlocked(t time.Time) (bool, string) {
+	t = t.UTC()
+
+	if r.NoEntryWeekend && !weekendCutoff(t, 0).IsZero() {
+		return true, "周末禁止开新仓"
+	}
+
+	interval := r.fundingInterval()
+	// 距离上一次资金费结算的分钟数
+	minuteOfCycle := (t.Hour()%interval)*60 + t.Minute()
+	if r.NoEntryBeforeFundingMinutes > 0 {
+		untilFunding := interval*60 - minuteOfCycle
+		if untilFunding <= r.NoEntryBeforeFundingMinutes {
+			return true, fmt.Sprintf("距离资金费结算仅剩%d分钟，禁止开新仓", untilFunding)
+		}
+	}
+	if minuteOfCycle < r.NoEntryAfterFundingMinutes {
+		return true, fmt.Sprintf("资金费结算后%d分钟内禁止开新仓", r.NoEntryAfterFundingMinutes)
+	}
+
+	for _, w := range r.Blackouts {
+		if w.Contains(t) {
+			return true, fmt.Sprintf("处于禁止开仓时段%s（%s-%s UTC），禁止开新仓", w.label(), w.Start, w.End)
+		}
+	}
+
+	return false, ""
+}
+
+// weekendCutoff 周末（周五21:00 UTC至周日21:00 UTC）开始前lead时长的时间点；t不在[该时间点, 周末结束)内时返回零值
+func weekendCutoff(t time.Time, lead time.Duration) time.Time {
+	t = t.UTC()
+	daysSinceFriday := (int(t.Weekday()) - int(time.Friday) + 7) % 7
+	friday := time.Date(t.Year(), t.Month(), t.Day()-daysSinceFriday, 21, 0, 0, 0, time.UTC)
+	// 提前量可能让时间点落在下一个周末之前
+	for _, start := range []time.Time{friday, friday.AddDate(0, 0, 7)} {
+		cutoff := start.Add(-lead)
+		if !t.Before(cutoff) && t.Before(start.Add(48*time.Hour)) {
+			return cutoff
+		}
+	}
+	return time.Time{}
+}
+
+// ExitRules 按持仓时间强制平仓的规则（由策略看守周期执行，暂停时照常生效）
+type ExitRules struct {
+	MaxHoldingHours         float64 `json:"max_holding_hours"`          // 持仓超过N小时强制平仓（0表示不限制）
+	CloseBeforeWeekend      bool    `json:"close_before_weekend"`       // 周末（周五21:00 UTC）前平掉所有持仓，周末期间新开的仓不受影响
+	WeekendCloseLeadMinutes int     `json:"weekend_close_lead_minutes"` // 提前N分钟执行周末平仓（默认0）
+}
+
+// Enabled 是否配置了任一平仓规则
+func (r ExitRules) Enabled() bool {
+	return r.MaxHoldingHours > 0 || r.CloseBeforeWeekend
+}
+
+// ExitDue 判断openedAt开仓的持仓在t时是否需要平仓，返回原因
+func (r ExitRules) ExitDue(openedAt, t time.Time) (bool, string) {
+	if r.MaxHoldingHours > 0 {
+		held := t.Sub(openedAt)
+		if held >= time.Duration(r.MaxHoldingHours*float64(time.Hour)) {
+			return true, fmt.Sprintf("持仓%.1f小时，超过最长持仓时间%g小时", held.Hours(), r.MaxHoldingHours)
+		}
+	}
+
+	if r.CloseBeforeWeekend {
+		cutoff := weekendCutoff(t, time.Duration(r.WeekendCloseLeadMinutes)*time.Minute)
+		if !cutoff.IsZero() && openedAt.Before(cutoff) {
+			return true, "周末前平仓"
+		}
+	}
+
+	return false, ""
+}
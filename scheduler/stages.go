@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// AI决策周期的流水线阶段
+const (
+	StageSnapshot = "snapshot" // 账户/持仓快照和行情数据
+	StageDecide   = "decide"   // AI决策
+	StageRisk     = "risk"     // 单个决策的风控检查
+	StageExecute  = "execute"  // 单个决策的下单和成交确认
+	StageConfirm  = "confirm"  // 周期结束时确认新开持仓已挂止损
+)
+
+// defaultStageTimeouts 各阶段默认超时
+var defaultStageTimeouts = map[string]time.Duration{
+	StageSnapshot: 120 * time.Second,
+	StageDecide:   300 * time.Second,
+	StageRisk:     30 * time.Second,
+	StageExecute:  90 * time.Second,
+	StageConfirm:  30 * time.Second,
+}
+
+// StageTimeouts AI决策周期各阶段的超时（秒，0使用默认值）
+type StageTimeouts struct {
+	SnapshotSec int `json:"snapshot_sec"` // 默认120
+	DecideSec   int `json:"decide_sec"`   // 默认300
+	RiskSec     int `json:"risk_sec"`     // 默认30
+	ExecuteSec  int `json:"execute_sec"`  // 默认90
+	ConfirmSec  int `json:"confirm_sec"`  // 默认30
+}
+
+// Validate 验证阶段超时
+func (t StageTimeouts) Validate() error {
+	for stage, sec := range t.seconds() {
+		if sec < 0 {
+			return fmt.Errorf("schedule.stage_timeouts.%s_sec不能为负数", stage)
+		}
+	}
+	return nil
+}
+
+// Timeout 阶段的超时时长
+func (t StageTimeouts) Timeout(stage string) time.Duration {
+	if sec := t.seconds()[stage]; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultStageTimeouts[stage]
+}
+
+func (t StageTimeouts) seconds() map[string]int {
+	return map[string]int{
+		StageSnapshot: t.SnapshotSec,
+		StageDecide:   t.DecideSec,
+		StageRisk:     t.RiskSec,
+		StageExecute:  t.ExecuteSec,
+		StageConfirm:  t.ConfirmSec,
+	}
+}
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// awsProvider AWS Secrets Manager（GetSecretValue，SigV4签名）
+// 凭证取自 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、可选 AWS_SESSION_TOKEN，区域取自 AWS_REGION（或 AWS_DEFAULT_REGION）
+type awsProvider struct {
+	client *http.Client
+	cache  map[string]string // secret id -> SecretString
+	mu     sync.Mutex
+}
+
+func newAWSProvider() *awsProvider {
+	return &awsProvider{
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  make(map[string]string),
+	}
+}
+
+// Lookup key为 "secret-id" 或 "secret-id#field"（SecretString为JSON时取其中的字段）
+func (a *awsProvider) Lookup(key string) (string, error) {
+	id, field := splitField(key)
+	secret, err := a.getSecret(id)
+	if err != nil {
+		return "", err
+	}
+	if field == "" {
+		return secret, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("密钥内容不是JSON，无法读取字段 %s", field)
+	}
+	value, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("字段 %s 不存在", field)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// getSecret 调用GetSecretValue（同一密钥只请求一次）
+func (a *awsProvider) getSecret(id string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if secret, ok := a.cache[id]; ok {
+		return secret, nil
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if region == "" || accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("需要设置AWS_REGION、AWS_ACCESS_KEY_ID和AWS_SECRET_ACCESS_KEY")
+	}
+
+	host := fmt.Sprintf("secretsmanager.%s.amazonaws.com", region)
+	body, _ := json.Marshal(map[string]string{"SecretId": id})
+	req, err := http.NewRequest(http.MethodPost, "https://"+host+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, body, host, region, "secretsmanager", accessKey, secretKey, time.Now().UTC())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析Secrets Manager响应失败: %w", err)
+	}
+	if result.SecretString == "" {
+		return "", fmt.Errorf("只支持文本密钥（SecretString）")
+	}
+	a.cache[id] = result.SecretString
+	return result.SecretString, nil
+}
+
+// signV4 AWS Signature Version 4 签名（只签名host、x-amz-date、x-amz-target等必要头）
+func signV4(req *http.Request, body []byte, host, region, service, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	headerValues := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": req.Header.Get("X-Amz-Target"),
+	}
+	if token := req.Header.Get("X-Amz-Security-Token"); token != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+		headerValues["x-amz-security-token"] = token
+		sort.Strings(signedHeaders) // 签名头必须按字母序排列
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(headerValues[h]) + "\n")
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method, "/", "", canonicalHeaders.String(), strings.Join(signedHeaders, ";"), hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,155 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// 加密密钥格式: enc:v1:<base64(salt | nonce | AES-256-GCM密文)>，密钥由口令经scrypt派生
+const (
+	encVersion = "v1"
+	saltLen    = 16
+	scryptN    = 1 << 15
+	scryptR    = 8
+	scryptP    = 1
+)
+
+// PassphraseEnv 口令环境变量（未设置时在终端提示输入）
+const PassphraseEnv = "NOFX_PASSPHRASE"
+
+// ErrWrongPassphrase 口令错误（或密文被篡改）
+var ErrWrongPassphrase = errors.New("口令错误或密文已损坏")
+
+// PassphraseFunc 获取口令
+type PassphraseFunc func() (string, error)
+
+// encProvider 口令加密的密钥（只在内存中解密）
+type encProvider struct {
+	source     PassphraseFunc
+	passphrase string
+	mu         sync.Mutex
+}
+
+func (p *encProvider) Lookup(key string) (string, error) {
+	passphrase, err := p.getPassphrase()
+	if err != nil {
+		return "", err
+	}
+	return decrypt(key, passphrase)
+}
+
+// getPassphrase 获取口令（只询问一次，之后复用）
+func (p *encProvider) getPassphrase() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.passphrase != "" {
+		return p.passphrase, nil
+	}
+	passphrase, err := p.source()
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("口令不能为空")
+	}
+	p.passphrase = passphrase
+	return passphrase, nil
+}
+
+// defaultPassphrase 优先读取NOFX_PASSPHRASE，否则在终端提示输入（不回显）
+func defaultPassphrase() (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := Prompt("🔐 请输入密钥口令: ")
+	if err != nil {
+		return "", fmt.Errorf("配置中包含加密密钥，请设置%s或在终端中启动: %w", PassphraseEnv, err)
+	}
+	return passphrase, nil
+}
+
+// Prompt 在终端提示输入（不回显），标准输入不是终端时返回错误
+func Prompt(label string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("标准输入不是终端")
+	}
+	fmt.Fprint(os.Stderr, label)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("读取口令失败: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Encrypt 用口令加密密钥，返回可直接写入配置的引用（enc:v1:...）
+func Encrypt(plaintext, passphrase string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	blob := append(append(salt, nonce...), gcm.Seal(nil, nonce, []byte(plaintext), nil)...)
+	return "enc:" + encVersion + ":" + base64.StdEncoding.EncodeToString(blob), nil
+}
+
+// decrypt 解密 "v1:<base64>"
+func decrypt(key, passphrase string) (string, error) {
+	version, encoded, ok := strings.Cut(key, ":")
+	if !ok || version != encVersion {
+		return "", fmt.Errorf("不支持的加密格式（应为 enc:%s:...）", encVersion)
+	}
+	blob, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("密文不是有效的base64: %w", err)
+	}
+	if len(blob) < saltLen {
+		return "", ErrWrongPassphrase
+	}
+
+	gcm, err := newGCM(passphrase, blob[:saltLen])
+	if err != nil {
+		return "", err
+	}
+	rest := blob[saltLen:]
+	if len(rest) < gcm.NonceSize() {
+		return "", ErrWrongPassphrase
+	}
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(plaintext), nil
+}
+
+// newGCM 由口令和盐派生AES-256-GCM
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,106 @@
+// Package secrets 从环境变量、文件或外部密钥管理服务读取密钥，
+// 使API密钥不必明文写在配置文件中。
+//
+// 配置中的密钥字段可以写成引用（scheme:key）：
+//
+//	env:GATE_API_KEY                        环境变量
+//	file:/run/secrets/gate_api_key          文件内容（去掉首尾空白）
+//	vault:secret/data/nofx#gate_api_key     HashiCorp Vault（VAULT_ADDR/VAULT_TOKEN）
+//	aws:prod/nofx#gate_api_key              AWS Secrets Manager（AWS_REGION/AWS_ACCESS_KEY_ID等）
+//	enc:v1:...                              口令加密（nofx encrypt生成，口令取自NOFX_PASSPHRASE或启动时输入）
+//
+// 未注册scheme的值（包括普通密钥和https地址）原样返回。
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider 密钥来源
+type Provider interface {
+	// Lookup 读取密钥（key为引用中scheme之后的部分）
+	Lookup(key string) (string, error)
+}
+
+var (
+	providers = map[string]Provider{
+		"env":   envProvider{},
+		"file":  fileProvider{},
+		"vault": newVaultProvider(),
+		"aws":   newAWSProvider(),
+		"enc":   &encProvider{source: defaultPassphrase},
+	}
+	mu sync.RWMutex
+)
+
+// Register 注册（或替换）密钥来源
+func Register(scheme string, p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[scheme] = p
+}
+
+// parse 拆分引用，value不是已注册的引用时返回false
+func parse(value string) (Provider, string, string, bool) {
+	scheme, key, ok := strings.Cut(value, ":")
+	if !ok || key == "" {
+		return nil, "", "", false
+	}
+	mu.RLock()
+	p, ok := providers[scheme]
+	mu.RUnlock()
+	return p, scheme, key, ok
+}
+
+// IsRef 是否为密钥引用
+func IsRef(value string) bool {
+	_, _, _, ok := parse(value)
+	return ok
+}
+
+// Resolve 解析密钥引用（不是引用时原样返回）
+func Resolve(value string) (string, error) {
+	p, scheme, key, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := p.Lookup(key)
+	if err != nil {
+		return "", fmt.Errorf("读取密钥 %s:%s 失败: %w", scheme, key, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("密钥 %s:%s 为空", scheme, key)
+	}
+	return secret, nil
+}
+
+// splitField 拆分 "path#field"（没有#时field为空，表示整个值）
+func splitField(key string) (string, string) {
+	path, field, _ := strings.Cut(key, "#")
+	return path, field
+}
+
+// envProvider 环境变量
+type envProvider struct{}
+
+func (envProvider) Lookup(key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("环境变量未设置")
+	}
+	return value, nil
+}
+
+// fileProvider 文件（如Docker/Kubernetes挂载的secret）
+type fileProvider struct{}
+
+func (fileProvider) Lookup(key string) (string, error) {
+	data, err := os.ReadFile(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
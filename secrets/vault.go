@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// vaultProvider HashiCorp Vault KV引擎（v1/v2均可，v2路径需包含data/，如 secret/data/nofx）
+// 地址和令牌取自 VAULT_ADDR、VAULT_TOKEN（或 VAULT_TOKEN_FILE），可选 VAULT_NAMESPACE
+type vaultProvider struct {
+	client *http.Client
+	cache  map[string]map[string]interface{} // path -> 密钥数据（同一路径只请求一次）
+	mu     sync.Mutex
+}
+
+func newVaultProvider() *vaultProvider {
+	return &vaultProvider{
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  make(map[string]map[string]interface{}),
+	}
+}
+
+func (v *vaultProvider) Lookup(key string) (string, error) {
+	path, field := splitField(key)
+	if field == "" {
+		return "", fmt.Errorf("vault引用需指定字段，如 vault:secret/data/nofx#gate_api_key")
+	}
+
+	data, err := v.read(path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("字段 %s 不存在", field)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// read 读取路径下的全部字段
+func (v *vaultProvider) read(path string) (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if data, ok := v.cache[path]; ok {
+		return data, nil
+	}
+
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR未设置")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if file := os.Getenv("VAULT_TOKEN_FILE"); token == "" && file != "" {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取VAULT_TOKEN_FILE失败: %w", err)
+		}
+		token = strings.TrimSpace(string(raw))
+	}
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN未设置")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, addr+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析Vault响应失败: %w", err)
+	}
+	data := result.Data
+	// KV v2 的字段嵌套在 data.data 中
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+	v.cache[path] = data
+	return data, nil
+}
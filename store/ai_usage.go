@@ -0,0 +1,50 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// AIUsage 按模型汇总的AI调用消耗
+type AIUsage struct {
+	Model            string `json:"model"`
+	Calls            int    `json:"calls"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// RecordAIUsage 记录一次AI调用的token消耗
+func (s *Store) RecordAIUsage(traderID, model string, promptTokens, completionTokens int) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT INTO ai_usage (trader_id, model, prompt_tokens, completion_tokens, time)
+		VALUES (?, ?, ?, ?, ?)`, traderID, model, promptTokens, completionTokens, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("记录AI调用消耗失败: %w", err)
+	}
+	return nil
+}
+
+// SumAIUsage 按模型汇总指定时间之后的AI调用消耗
+func (s *Store) SumAIUsage(traderID string, since time.Time) ([]AIUsage, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT model, COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0)
+		FROM ai_usage WHERE trader_id = ? AND time >= ? GROUP BY model ORDER BY model`, traderID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询AI调用消耗失败: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []AIUsage
+	for rows.Next() {
+		var u AIUsage
+		if err := rows.Scan(&u.Model, &u.Calls, &u.PromptTokens, &u.CompletionTokens); err != nil {
+			return nil, fmt.Errorf("读取AI调用消耗失败: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// SaveAllocation 保存trader的策略资金分配比例（整体替换）
+func (s *Store) SaveAllocation(traderID string, weights map[string]float64, at time.Time) error {
+	if s == nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("保存资金分配失败: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM strategy_allocations WHERE trader_id = ?`, traderID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("保存资金分配失败: %w", err)
+	}
+	for strategy, weight := range weights {
+		if _, err := tx.Exec(`INSERT INTO strategy_allocations (trader_id, strategy, weight, updated_at) VALUES (?, ?, ?, ?)`,
+			traderID, strategy, weight, at.UTC()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("保存资金分配失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("保存资金分配失败: %w", err)
+	}
+	return nil
+}
+
+// LoadAllocation 读取trader的策略资金分配比例和最近更新时间（没有记录时返回nil）
+func (s *Store) LoadAllocation(traderID string) (map[string]float64, time.Time, error) {
+	var updatedAt time.Time
+	if s == nil {
+		return nil, updatedAt, nil
+	}
+	rows, err := s.db.Query(`SELECT strategy, weight, updated_at FROM strategy_allocations WHERE trader_id = ?`, traderID)
+	if err != nil {
+		return nil, updatedAt, fmt.Errorf("读取资金分配失败: %w", err)
+	}
+	defer rows.Close()
+
+	var weights map[string]float64
+	for rows.Next() {
+		var strategy string
+		var weight float64
+		var at time.Time
+		if err := rows.Scan(&strategy, &weight, &at); err != nil {
+			return nil, updatedAt, fmt.Errorf("读取资金分配失败: %w", err)
+		}
+		if weights == nil {
+			weights = make(map[string]float64)
+		}
+		weights[strategy] = weight
+		if at.After(updatedAt) {
+			updatedAt = at
+		}
+	}
+	return weights, updatedAt, rows.Err()
+}
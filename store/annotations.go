@@ -0,0 +1,103 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxNoteLength 备注最大长度（字符）
+const maxNoteLength = 2000
+
+// ErrPositionNotFound 交易记录不存在
+var ErrPositionNotFound = errors.New("交易记录不存在")
+
+// NormalizeTags 标签去掉首尾空白并转为小写，去除空标签和重复标签（标签不能包含逗号，逗号视为分隔符）
+func NormalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		for _, t := range strings.Split(tag, ",") {
+			t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+			if t != "" && !seen[t] {
+				seen[t] = true
+				result = append(result, t)
+			}
+		}
+	}
+	return result
+}
+
+// splitTags 解析数据库中逗号分隔的标签
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// HasTag 交易是否带有该标签
+func (p Position) HasTag(tag string) bool {
+	tags := NormalizeTags([]string{tag})
+	if len(tags) == 0 {
+		return false
+	}
+	for _, t := range p.Tags {
+		if t == tags[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPosition 按ID获取交易记录（不存在时返回ErrPositionNotFound）
+func (s *Store) GetPosition(id int64) (*Position, error) {
+	if s == nil {
+		return nil, fmt.Errorf("交易日志存储未启用")
+	}
+	positions, err := s.queryPositions(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("#%d: %w", id, ErrPositionNotFound)
+	}
+	return &positions[0], nil
+}
+
+// AnnotatePosition 修改交易的备注和标签，返回修改后的记录
+// note为nil时不修改备注（空字符串清除备注），addTags追加标签，removeTags删除标签
+func (s *Store) AnnotatePosition(id int64, note *string, addTags, removeTags []string) (*Position, error) {
+	p, err := s.GetPosition(id)
+	if err != nil {
+		return nil, err
+	}
+	if note != nil {
+		text := strings.TrimSpace(*note)
+		if len([]rune(text)) > maxNoteLength {
+			return nil, fmt.Errorf("备注不能超过%d个字符", maxNoteLength)
+		}
+		p.Note = text
+	}
+
+	removed := make(map[string]bool)
+	for _, tag := range NormalizeTags(removeTags) {
+		removed[tag] = true
+	}
+	var tags []string
+	for _, tag := range NormalizeTags(append(p.Tags, addTags...)) {
+		if !removed[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	p.Tags = tags
+
+	result, err := s.db.Exec(`UPDATE positions SET note = ?, tags = ? WHERE id = ?`, p.Note, strings.Join(tags, ","), id)
+	if err != nil {
+		return nil, fmt.Errorf("更新交易备注失败: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("#%d: %w", id, ErrPositionNotFound)
+	}
+	return p, nil
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// backend 数据库后端（SQL方言差异：连接方式、占位符、迁移中的列类型）
+// 查询和迁移统一按SQLite语法编写，由后端改写为目标数据库的语法
+type backend interface {
+	// Name 后端名称（sqlite/postgres）
+	Name() string
+	// open 打开数据库连接
+	open(dsn string) (*sql.DB, error)
+	// rebind 改写查询中的占位符
+	rebind(query string) string
+	// migration 改写迁移语句中的列类型
+	migration(stmt string) string
+	// lockMigrations 在迁移事务中加锁，避免多个实例同时迁移同一个数据库
+	lockMigrations(tx *connTx) error
+}
+
+// IsPostgres store_path是否为PostgreSQL连接串（postgres://或postgresql://）
+func IsPostgres(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// Redact 隐藏连接串中的密码（用于日志和诊断输出），SQLite路径原样返回
+func Redact(dsn string) string {
+	if !IsPostgres(dsn) {
+		return dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "postgres://***"
+	}
+	return u.Redacted()
+}
+
+// backendFor 按store_path选择后端
+func backendFor(dsn string) backend {
+	if IsPostgres(dsn) {
+		return postgresBackend{}
+	}
+	return sqliteBackend{}
+}
+
+// sqliteBackend 单机SQLite文件（默认）
+type sqliteBackend struct{}
+
+func (sqliteBackend) Name() string { return "sqlite" }
+
+func (sqliteBackend) open(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建数据目录失败: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite单写者，限制连接数避免锁冲突
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func (sqliteBackend) rebind(query string) string { return query }
+
+func (sqliteBackend) migration(stmt string) string { return stmt }
+
+func (sqliteBackend) lockMigrations(*connTx) error { return nil }
+
+// postgresBackend PostgreSQL（多个实例共享同一个数据库，看板可直接查询）
+type postgresBackend struct{}
+
+// postgresMigrationLock 迁移使用的事务级advisory lock键
+const postgresMigrationLock = 7_365_412_001
+
+// postgresTypes SQLite列类型到PostgreSQL列类型（REAL在PostgreSQL中是单精度，价格和数量需要双精度）
+var postgresTypes = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\bINTEGER PRIMARY KEY AUTOINCREMENT\b`), "BIGSERIAL PRIMARY KEY"},
+	{regexp.MustCompile(`\bINTEGER\b`), "BIGINT"},
+	{regexp.MustCompile(`\bREAL\b`), "DOUBLE PRECISION"},
+	{regexp.MustCompile(`\bTIMESTAMP\b`), "TIMESTAMPTZ"},
+}
+
+func (postgresBackend) Name() string { return "postgres" }
+
+func (postgresBackend) open(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
+
+// rebind 把?占位符改写为$1、$2...（跳过字符串字面量中的?）
+func (postgresBackend) rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var sb strings.Builder
+	sb.Grow(len(query) + 16)
+	n, quoted := 0, false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == '?' && !quoted:
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func (postgresBackend) migration(stmt string) string {
+	for _, t := range postgresTypes {
+		stmt = t.re.ReplaceAllString(stmt, t.repl)
+	}
+	return stmt
+}
+
+func (postgresBackend) lockMigrations(tx *connTx) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, postgresMigrationLock)
+	return err
+}
+
+// conn 数据库连接（查询按后端改写占位符后执行）
+type conn struct {
+	*sql.DB
+	backend backend
+}
+
+func (c *conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.Exec(c.backend.rebind(query), args...)
+}
+
+func (c *conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB.Query(c.backend.rebind(query), args...)
+}
+
+func (c *conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.DB.QueryRow(c.backend.rebind(query), args...)
+}
+
+func (c *conn) Begin() (*connTx, error) {
+	t, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &connTx{Tx: t, backend: c.backend}, nil
+}
+
+// connTx 数据库事务（查询按后端改写占位符后执行）
+type connTx struct {
+	*sql.Tx
+	backend backend
+}
+
+func (t *connTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(t.backend.rebind(query), args...)
+}
+
+func (t *connTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(t.backend.rebind(query), args...)
+}
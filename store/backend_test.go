@@ -0,0 +1,53 @@
+package store
+
+import "testing"
+
+func TestPostgresRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "没有占位符", query: `SELECT 1`, want: `SELECT 1`},
+		{name: "按顺序编号", query: `SELECT * FROM orders WHERE trader_id = ? AND symbol = ? LIMIT ?`,
+			want: `SELECT * FROM orders WHERE trader_id = $1 AND symbol = $2 LIMIT $3`},
+		{name: "相邻占位符", query: `VALUES (?,?,?)`, want: `VALUES ($1,$2,$3)`},
+		{name: "跳过字符串字面量", query: `SELECT '?' AS q, note FROM positions WHERE note <> 'why?' AND id = ?`,
+			want: `SELECT '?' AS q, note FROM positions WHERE note <> 'why?' AND id = $1`},
+		{name: "转义的单引号", query: `SELECT * FROM t WHERE a = 'it''s ?' AND b = ?`,
+			want: `SELECT * FROM t WHERE a = 'it''s ?' AND b = $1`},
+		{name: "超过9个", query: `VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			want: `VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`},
+		{name: "多行语句", query: "INSERT INTO pause_state (trader_id, paused) VALUES (?, ?)\n\t\tON CONFLICT(trader_id) DO UPDATE SET paused = excluded.paused WHERE pause_state.source <> ?",
+			want: "INSERT INTO pause_state (trader_id, paused) VALUES ($1, $2)\n\t\tON CONFLICT(trader_id) DO UPDATE SET paused = excluded.paused WHERE pause_state.source <> $3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (postgresBackend{}).rebind(tt.query); got != tt.want {
+				t.Fatalf("rebind(%q)\n得到 %q\n期望 %q", tt.query, got, tt.want)
+			}
+			if got := (sqliteBackend{}).rebind(tt.query); got != tt.query {
+				t.Fatalf("SQLite不应改写占位符: %q", got)
+			}
+		})
+	}
+}
+
+func TestPostgresMigration(t *testing.T) {
+	stmt := `CREATE TABLE IF NOT EXISTS fills (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		quantity   REAL NOT NULL DEFAULT 0,
+		paused     INTEGER NOT NULL DEFAULT 0,
+		time       TIMESTAMP NOT NULL
+	);`
+	want := `CREATE TABLE IF NOT EXISTS fills (
+		id         BIGSERIAL PRIMARY KEY,
+		quantity   DOUBLE PRECISION NOT NULL DEFAULT 0,
+		paused     BIGINT NOT NULL DEFAULT 0,
+		time       TIMESTAMPTZ NOT NULL
+	);`
+	if got := (postgresBackend{}).migration(stmt); got != want {
+		t.Fatalf("migration得到\n%s\n期望\n%s", got, want)
+	}
+}
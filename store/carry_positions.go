@@ -0,0 +1,80 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CarryPosition 资金费率套利的对冲持仓（现货多头+永续空头）
+type CarryPosition struct {
+	TraderID      string    `json:"trader_id"`
+	Symbol        string    `json:"symbol"`
+	SpotQuantity  float64   `json:"spot_quantity"`  // 现货持有数量（基础币，已扣除手续费）
+	PerpContracts float64   `json:"perp_contracts"` // 永续空头合约张数
+	EntryNotional float64   `json:"entry_notional"`
+	EntryFunding  float64   `json:"entry_funding"`
+	NetCarry      float64   `json:"net_carry"`
+	OpenTime      time.Time `json:"open_time"`
+	LastSettle    time.Time `json:"last_settle"` // 上次计入资金费的结算时间
+}
+
+// SaveCarryPosition 保存对冲持仓（同一trader和币种覆盖上一次记录）
+func (s *Store) SaveCarryPosition(p CarryPosition) error {
+	if s == nil {
+		return nil
+	}
+	var lastSettle interface{}
+	if !p.LastSettle.IsZero() {
+		lastSettle = p.LastSettle.UTC()
+	}
+	_, err := s.db.Exec(`INSERT INTO carry_positions (trader_id, symbol, spot_quantity, perp_contracts, entry_notional,
+		entry_funding, net_carry, open_time, last_settle) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(trader_id, symbol) DO UPDATE SET spot_quantity = excluded.spot_quantity,
+		perp_contracts = excluded.perp_contracts, entry_notional = excluded.entry_notional,
+		entry_funding = excluded.entry_funding, net_carry = excluded.net_carry,
+		open_time = excluded.open_time, last_settle = excluded.last_settle`,
+		p.TraderID, p.Symbol, p.SpotQuantity, p.PerpContracts, p.EntryNotional, p.EntryFunding, p.NetCarry,
+		p.OpenTime.UTC(), lastSettle)
+	if err != nil {
+		return fmt.Errorf("保存对冲持仓失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteCarryPosition 删除已解除的对冲持仓
+func (s *Store) DeleteCarryPosition(traderID, symbol string) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := s.db.Exec(`DELETE FROM carry_positions WHERE trader_id = ? AND symbol = ?`, traderID, symbol); err != nil {
+		return fmt.Errorf("删除对冲持仓失败: %w", err)
+	}
+	return nil
+}
+
+// ListCarryPositions 读取trader保存的全部对冲持仓
+func (s *Store) ListCarryPositions(traderID string) ([]CarryPosition, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT trader_id, symbol, spot_quantity, perp_contracts, entry_notional, entry_funding,
+		net_carry, open_time, last_settle FROM carry_positions WHERE trader_id = ? ORDER BY symbol`, traderID)
+	if err != nil {
+		return nil, fmt.Errorf("读取对冲持仓失败: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []CarryPosition
+	for rows.Next() {
+		var p CarryPosition
+		var lastSettle sql.NullTime
+		if err := rows.Scan(&p.TraderID, &p.Symbol, &p.SpotQuantity, &p.PerpContracts, &p.EntryNotional,
+			&p.EntryFunding, &p.NetCarry, &p.OpenTime, &lastSettle); err != nil {
+			return nil, fmt.Errorf("读取对冲持仓失败: %w", err)
+		}
+		p.LastSettle = lastSettle.Time
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// 费用类型
+const (
+	CostFee     = "fee"     // 交易手续费
+	CostFunding = "funding" // 资金费
+)
+
+// attributionTolerance 归集时间容差（开仓记录在下单返回后写入，成交时间可能略早）
+const attributionTolerance = 2 * time.Minute
+
+// Cost 手续费/资金费流水（来自交易所账户流水）
+type Cost struct {
+	TraderID string    `json:"trader_id"`
+	Kind     string    `json:"kind"` // fee/funding
+	Symbol   string    `json:"symbol"`
+	OrderID  string    `json:"order_id"` // 手续费对应的订单ID（资金费为空）
+	Amount   float64   `json:"amount"`   // 账户变动金额（手续费为负，资金费收入为正）
+	Time     time.Time `json:"time"`
+}
+
+// RecordCost 记录费用流水（重复流水自动忽略）
+func (s *Store) RecordCost(c Cost) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT INTO costs (trader_id, kind, symbol, order_id, amount, time)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`, c.TraderID, c.Kind, c.Symbol, c.OrderID, c.Amount, c.Time.UTC())
+	if err != nil {
+		return fmt.Errorf("记录费用流水失败: %w", err)
+	}
+	return nil
+}
+
+// unattributedCost 待归集的费用
+type unattributedCost struct {
+	id int64
+	Cost
+}
+
+// AttributeCosts 将未归集的费用流水归集到对应交易（同币种、发生在持仓期间），返回归集条数
+// 手续费优先按订单ID匹配持仓方向；资金费按时间窗口匹配
+func (s *Store) AttributeCosts(traderID string) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	rows, err := s.db.Query(`SELECT c.id, c.kind, c.symbol, c.order_id, c.amount, c.time, COALESCE(o.side, '')
+		FROM costs c LEFT JOIN orders o ON o.trader_id = c.trader_id AND o.order_id = c.order_id AND c.order_id != ''
+		WHERE c.trader_id = ? AND c.position_id = 0 ORDER BY c.time`, traderID)
+	if err != nil {
+		return 0, fmt.Errorf("查询待归集费用失败: %w", err)
+	}
+	var pending []unattributedCost
+	var sides []string
+	earliest := time.Now()
+	for rows.Next() {
+		var c unattributedCost
+		var side string
+		if err := rows.Scan(&c.id, &c.Kind, &c.Symbol, &c.OrderID, &c.Amount, &c.Time, &side); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("读取待归集费用失败: %w", err)
+		}
+		pending = append(pending, c)
+		sides = append(sides, side)
+		if c.Time.Before(earliest) {
+			earliest = c.Time
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	// 候选持仓：未平仓或在最早费用之后平仓的交易
+	positions, err := s.queryPositions(`WHERE trader_id = ? AND (status = 'open' OR closed_at >= ?) ORDER BY opened_at`,
+		traderID, earliest.Add(-attributionTolerance).UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开始归集事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	touched := make(map[int64]bool)
+	attributed := 0
+	for i, c := range pending {
+		pos := matchPosition(positions, c.Cost, sides[i])
+		if pos == nil {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE costs SET position_id = ? WHERE id = ?`, pos.ID, c.id); err != nil {
+			return 0, fmt.Errorf("归集费用失败: %w", err)
+		}
+		touched[pos.ID] = true
+		attributed++
+	}
+
+	// 重新汇总受影响交易的手续费和资金费
+	for id := range touched {
+		_, err := tx.Exec(`UPDATE positions SET
+			fees = (SELECT COALESCE(-SUM(amount), 0) FROM costs WHERE position_id = ? AND kind = ?),
+			funding = (SELECT COALESCE(SUM(amount), 0) FROM costs WHERE position_id = ? AND kind = ?)
+			WHERE id = ?`, id, CostFee, id, CostFunding, id)
+		if err != nil {
+			return 0, fmt.Errorf("汇总交易费用失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交归集事务失败: %w", err)
+	}
+	return attributed, nil
+}
+
+// matchPosition 为费用找到所属交易（side为手续费订单对应的持仓方向，未知时为空）
+func matchPosition(positions []Position, c Cost, side string) *Position {
+	for i := range positions {
+		p := &positions[i]
+		if p.Symbol != c.Symbol || (side != "" && p.Side != side) {
+			continue
+		}
+		if c.Time.Before(p.OpenedAt.Add(-attributionTolerance)) {
+			continue
+		}
+		if p.ClosedAt != nil && c.Time.After(p.ClosedAt.Add(attributionTolerance)) {
+			continue
+		}
+		return p
+	}
+	return nil
+}
+
+// WalletChange 交易日志记录的钱包余额变化（平仓盈亏、手续费、资金费）
+type WalletChange struct {
+	RealizedPnL float64 `json:"realized_pnl"` // 已平仓交易的毛盈亏
+	Fees        float64 `json:"fees"`         // 手续费（负数）
+	Funding     float64 `json:"funding"`      // 资金费（收入为正）
+}
+
+// Total 合计变化
+func (w WalletChange) Total() float64 {
+	return w.RealizedPnL + w.Fees + w.Funding
+}
+
+// WalletChangeSince 汇总指定时间之后平仓的盈亏和发生的费用流水（用于核对钱包余额的实际变化）
+func (s *Store) WalletChangeSince(traderID string, since time.Time) (WalletChange, error) {
+	var w WalletChange
+	if s == nil {
+		return w, nil
+	}
+	if err := s.db.QueryRow(`SELECT COALESCE(SUM(realized_pnl), 0) FROM positions
+		WHERE trader_id = ? AND status = 'closed' AND closed_at >= ?`, traderID, since.UTC()).Scan(&w.RealizedPnL); err != nil {
+		return w, fmt.Errorf("汇总平仓盈亏失败: %w", err)
+	}
+	err := s.db.QueryRow(`SELECT COALESCE(SUM(CASE WHEN kind = ? THEN amount ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN kind = ? THEN amount ELSE 0 END), 0) FROM costs WHERE trader_id = ? AND time >= ?`,
+		CostFee, CostFunding, traderID, since.UTC()).Scan(&w.Fees, &w.Funding)
+	if err != nil {
+		return w, fmt.Errorf("汇总费用流水失败: %w", err)
+	}
+	return w, nil
+}
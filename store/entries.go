@@ -0,0 +1,88 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// EntryBenchmark 持仓的开仓成本基准（按交易日志中的成交逐笔滚动计算）
+type EntryBenchmark struct {
+	VWAP     float64 `json:"vwap"`     // 成交量加权开仓均价（减仓不改变均价）
+	TWAP     float64 `json:"twap"`     // 各次开仓/加仓订单均价的简单平均（每次加仓权重相同）
+	Quantity float64 `json:"quantity"` // 按成交推算的当前持仓数量（与成交记录单位一致）
+	Fills    int     `json:"fills"`    // 计入均价的开仓成交笔数
+	Orders   int     `json:"orders"`   // 计入均价的开仓订单数
+}
+
+// EntryBenchmark 按since之后该币种的成交滚动计算side方向持仓的开仓均价：
+// 开仓方向的成交按数量加权计入成本，反方向的成交按当前均价减少数量；持仓归零后重新开始计算
+// （since之前已开始的持仓只统计since之后的部分）。没有开仓成交时返回零值
+func (s *Store) EntryBenchmark(traderID, symbol, side string, since time.Time) (EntryBenchmark, error) {
+	var b EntryBenchmark
+	if s == nil {
+		return b, nil
+	}
+	entrySide := "buy"
+	if side == "short" {
+		entrySide = "sell"
+	}
+
+	rows, err := s.db.Query(`SELECT order_id, side, price, quantity FROM fills
+		WHERE trader_id = ? AND symbol = ? AND time >= ? ORDER BY time, id`, traderID, symbol, since.UTC())
+	if err != nil {
+		return b, fmt.Errorf("查询成交记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var cost float64
+	var orderIDs []string
+	orderCost := make(map[string]float64)
+	orderQty := make(map[string]float64)
+	for rows.Next() {
+		var orderID, fillSide string
+		var price, quantity float64
+		if err := rows.Scan(&orderID, &fillSide, &price, &quantity); err != nil {
+			return b, fmt.Errorf("读取成交记录失败: %w", err)
+		}
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		if price <= 0 || quantity == 0 {
+			continue
+		}
+		if fillSide != entrySide {
+			if quantity >= b.Quantity {
+				// 持仓已平（或此前是反方向持仓），之后的开仓成交属于新的持仓
+				b, cost = EntryBenchmark{}, 0
+				orderIDs = orderIDs[:0]
+				orderCost, orderQty = make(map[string]float64), make(map[string]float64)
+				continue
+			}
+			cost -= cost / b.Quantity * quantity
+			b.Quantity -= quantity
+			continue
+		}
+		cost += price * quantity
+		b.Quantity += quantity
+		b.Fills++
+		if _, ok := orderQty[orderID]; !ok {
+			orderIDs = append(orderIDs, orderID)
+		}
+		orderCost[orderID] += price * quantity
+		orderQty[orderID] += quantity
+	}
+	if err := rows.Err(); err != nil {
+		return b, err
+	}
+	if b.Quantity <= 0 {
+		return EntryBenchmark{}, nil
+	}
+
+	b.VWAP = cost / b.Quantity
+	for _, id := range orderIDs {
+		b.TWAP += orderCost[id] / orderQty[id]
+	}
+	b.Orders = len(orderIDs)
+	b.TWAP /= float64(b.Orders)
+	return b, nil
+}
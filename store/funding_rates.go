@@ -0,0 +1,81 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FundingRate 一次资金费结算的历史费率（按合约记录，与trader无关）
+type FundingRate struct {
+	Symbol string    `json:"symbol"`
+	Time   time.Time `json:"time"`
+	Rate   float64   `json:"rate"`
+}
+
+// RecordFundingRates 批量写入历史资金费率（重复记录自动忽略），返回新写入的条数
+func (s *Store) RecordFundingRates(rates []FundingRate) (int, error) {
+	if s == nil || len(rates) == 0 {
+		return 0, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开始写入资金费率事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted := 0
+	for _, r := range rates {
+		res, err := tx.Exec(`INSERT INTO funding_rates (symbol, time, rate) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+			r.Symbol, r.Time.UTC(), r.Rate)
+		if err != nil {
+			return 0, fmt.Errorf("写入资金费率失败: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			inserted += int(n)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交资金费率失败: %w", err)
+	}
+	return inserted, nil
+}
+
+// LatestFundingRateTime 已记录的最近一次资金费结算时间（没有记录时返回零值）
+func (s *Store) LatestFundingRateTime(symbol string) (time.Time, error) {
+	var t time.Time
+	if s == nil {
+		return t, nil
+	}
+	err := s.db.QueryRow(`SELECT time FROM funding_rates WHERE symbol = ? ORDER BY time DESC LIMIT 1`, symbol).Scan(&t)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return t, fmt.Errorf("查询资金费率记录失败: %w", err)
+	}
+	return t, nil
+}
+
+// ListFundingRates 查询指定时间之后的历史资金费率（按时间升序）
+func (s *Store) ListFundingRates(symbol string, since time.Time) ([]FundingRate, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT symbol, time, rate FROM funding_rates WHERE symbol = ? AND time >= ? ORDER BY time`,
+		symbol, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询历史资金费率失败: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []FundingRate
+	for rows.Next() {
+		var r FundingRate
+		if err := rows.Scan(&r.Symbol, &r.Time, &r.Rate); err != nil {
+			return nil, fmt.Errorf("读取历史资金费率失败: %w", err)
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
@@ -0,0 +1,315 @@
+package store
+
+import (
+	"fmt"
+	"nofx/logging"
+)
+
+// logger 存储模块日志
+var logger = logging.For("store")
+
+// migrations 数据库迁移（按顺序执行，只追加不修改已发布的迁移）
+var migrations = []string{
+	// v1: 交易日志基础表
+	`CREATE TABLE IF NOT EXISTS orders (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id  TEXT NOT NULL,
+		order_id   TEXT NOT NULL DEFAULT '',
+		symbol     TEXT NOT NULL,
+		action     TEXT NOT NULL,
+		side       TEXT NOT NULL,
+		quantity   REAL NOT NULL DEFAULT 0,
+		price      REAL NOT NULL DEFAULT 0,
+		leverage   INTEGER NOT NULL DEFAULT 0,
+		status     TEXT NOT NULL,
+		strategy   TEXT NOT NULL DEFAULT '',
+		error      TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_orders_trader_time ON orders(trader_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS fills (
+		id        INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		order_id  TEXT NOT NULL DEFAULT '',
+		trade_id  TEXT NOT NULL,
+		symbol    TEXT NOT NULL,
+		side      TEXT NOT NULL,
+		price     REAL NOT NULL,
+		quantity  REAL NOT NULL,
+		fee       REAL NOT NULL DEFAULT 0,
+		role      TEXT NOT NULL DEFAULT '',
+		time      TIMESTAMP NOT NULL,
+		UNIQUE(trader_id, trade_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_fills_trader_time ON fills(trader_id, time);
+
+	CREATE TABLE IF NOT EXISTS positions (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id    TEXT NOT NULL,
+		symbol       TEXT NOT NULL,
+		side         TEXT NOT NULL,
+		quantity     REAL NOT NULL,
+		entry_price  REAL NOT NULL,
+		leverage     INTEGER NOT NULL DEFAULT 0,
+		stop_loss    REAL NOT NULL DEFAULT 0,
+		take_profit  REAL NOT NULL DEFAULT 0,
+		strategy     TEXT NOT NULL DEFAULT '',
+		status       TEXT NOT NULL,
+		opened_at    TIMESTAMP NOT NULL,
+		exit_price   REAL NOT NULL DEFAULT 0,
+		realized_pnl REAL NOT NULL DEFAULT 0,
+		closed_at    TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_positions_trader_status ON positions(trader_id, symbol, side, status);
+
+	CREATE TABLE IF NOT EXISTS protective_orders (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id     TEXT NOT NULL,
+		symbol        TEXT NOT NULL,
+		side          TEXT NOT NULL,
+		kind          TEXT NOT NULL,
+		trigger_price REAL NOT NULL,
+		quantity      REAL NOT NULL,
+		status        TEXT NOT NULL,
+		error         TEXT NOT NULL DEFAULT '',
+		created_at    TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS balance_snapshots (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id      TEXT NOT NULL,
+		total_equity   REAL NOT NULL,
+		wallet_balance REAL NOT NULL,
+		available      REAL NOT NULL,
+		unrealized_pnl REAL NOT NULL,
+		position_count INTEGER NOT NULL DEFAULT 0,
+		time           TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_balance_trader_time ON balance_snapshots(trader_id, time);`,
+
+	// v2: 手续费/资金费流水及按交易归集
+	`ALTER TABLE positions ADD COLUMN fees REAL NOT NULL DEFAULT 0;
+	ALTER TABLE positions ADD COLUMN funding REAL NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS costs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id   TEXT NOT NULL,
+		kind        TEXT NOT NULL,
+		symbol      TEXT NOT NULL,
+		order_id    TEXT NOT NULL DEFAULT '',
+		amount      REAL NOT NULL,
+		time        TIMESTAMP NOT NULL,
+		position_id INTEGER NOT NULL DEFAULT 0,
+		UNIQUE(trader_id, kind, symbol, order_id, time, amount)
+	);
+	CREATE INDEX IF NOT EXISTS idx_costs_unattributed ON costs(trader_id, position_id);`,
+
+	// v3: 订单状态机（成交数量、均价、状态变更事件）
+	`ALTER TABLE orders ADD COLUMN filled_qty REAL NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN avg_price REAL NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN updated_at TIMESTAMP;
+	UPDATE orders SET status = 'rejected' WHERE status = 'failed';
+	UPDATE orders SET filled_qty = quantity, avg_price = price WHERE status = 'filled';
+	CREATE INDEX IF NOT EXISTS idx_orders_trader_status ON orders(trader_id, status);
+
+	CREATE TABLE IF NOT EXISTS order_events (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_ref  INTEGER NOT NULL REFERENCES orders(id),
+		from_state TEXT NOT NULL,
+		to_state   TEXT NOT NULL,
+		filled_qty REAL NOT NULL DEFAULT 0,
+		avg_price  REAL NOT NULL DEFAULT 0,
+		detail     TEXT NOT NULL DEFAULT '',
+		time       TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_order_events_order ON order_events(order_ref);`,
+
+	// v4: AI调用token消耗
+	`CREATE TABLE IF NOT EXISTS ai_usage (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id         TEXT NOT NULL,
+		model             TEXT NOT NULL,
+		prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+		completion_tokens INTEGER NOT NULL DEFAULT 0,
+		time              TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_usage_trader_time ON ai_usage(trader_id, time);`,
+
+	// v5: 人工暂停状态（重启后保持暂停）
+	`CREATE TABLE IF NOT EXISTS pause_state (
+		trader_id TEXT PRIMARY KEY,
+		paused    INTEGER NOT NULL DEFAULT 0,
+		source    TEXT NOT NULL DEFAULT '',
+		reason    TEXT NOT NULL DEFAULT '',
+		since     TIMESTAMP
+	);`,
+
+	// v6: 开仓执行方式和实际执行成本（手续费率、相对参考价的滑点）
+	`ALTER TABLE orders ADD COLUMN route TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN fee_rate REAL NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN slippage_bps REAL NOT NULL DEFAULT 0;`,
+
+	// v7: 执行质量（决策时价格、提交价格）
+	`ALTER TABLE orders ADD COLUMN arrival_price REAL NOT NULL DEFAULT 0;
+	ALTER TABLE orders ADD COLUMN submitted_price REAL NOT NULL DEFAULT 0;`,
+
+	// v8: 多策略资金分配比例（再平衡后持久化，重启后沿用）
+	`CREATE TABLE IF NOT EXISTS strategy_allocations (
+		trader_id  TEXT NOT NULL,
+		strategy   TEXT NOT NULL,
+		weight     REAL NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (trader_id, strategy)
+	);`,
+
+	// v9: 策略归因（订单文本中的决策ID和提示词版本，成交按订单文本反查策略）
+	`ALTER TABLE orders ADD COLUMN decision_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE orders ADD COLUMN prompt_version TEXT NOT NULL DEFAULT '';
+	ALTER TABLE positions ADD COLUMN decision_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE positions ADD COLUMN prompt_version TEXT NOT NULL DEFAULT '';
+	ALTER TABLE fills ADD COLUMN strategy TEXT NOT NULL DEFAULT '';
+	ALTER TABLE fills ADD COLUMN decision_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE fills ADD COLUMN prompt_version TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_orders_order_id ON orders(trader_id, order_id);`,
+
+	// v10: 止损/止盈条件单快照（改杠杆、紧急撤单等操作后恢复，重启后仍可恢复）
+	`CREATE TABLE IF NOT EXISTS protective_snapshots (
+		trader_id     TEXT NOT NULL,
+		symbol        TEXT NOT NULL,
+		side          TEXT NOT NULL,
+		kind          TEXT NOT NULL,
+		trigger_price REAL NOT NULL,
+		quantity      REAL NOT NULL DEFAULT 0,
+		taken_at      TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_protective_snapshots_trader ON protective_snapshots(trader_id);`,
+
+	// v11: 客户端订单ID（下单结果不确定时按其查询原订单是否已到达交易所，避免重试重复下单）
+	`ALTER TABLE orders ADD COLUMN client_id TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_orders_client_id ON orders(trader_id, client_id);`,
+
+	// v12: 风控事件（事件总线上的风控拒绝和熔断，交易日志订阅后持久化）
+	`CREATE TABLE IF NOT EXISTS risk_events (
+		id        INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		symbol    TEXT NOT NULL DEFAULT '',
+		rule      TEXT NOT NULL,
+		action    TEXT NOT NULL DEFAULT '',
+		detail    TEXT NOT NULL DEFAULT '',
+		time      TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_risk_events_trader_time ON risk_events(trader_id, time);`,
+
+	// v13: 交易备注和标签（人工复盘）
+	`ALTER TABLE positions ADD COLUMN note TEXT NOT NULL DEFAULT '';
+	ALTER TABLE positions ADD COLUMN tags TEXT NOT NULL DEFAULT '';`,
+
+	// v14: 原因代码（自动下单、撤单、风控干预的机器可读原因）
+	`ALTER TABLE orders ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+	ALTER TABLE order_events ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+	ALTER TABLE risk_events ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_orders_reason ON orders(trader_id, reason);
+	CREATE INDEX IF NOT EXISTS idx_risk_events_reason ON risk_events(trader_id, reason);`,
+
+	// v15: 下单意图两阶段记录：发送到交易所前写入发送时间（重启对账区分未发送和在途的订单）
+	`ALTER TABLE orders ADD COLUMN sent_at TIMESTAMP;`,
+
+	// v16: 历史资金费率（按合约回填，用于资金费率分布统计）
+	`CREATE TABLE IF NOT EXISTS funding_rates (
+		id     INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		time   TIMESTAMP NOT NULL,
+		rate   REAL NOT NULL,
+		UNIQUE(symbol, time)
+	);`,
+
+	// v17: 决策理由（AI或外部信号给出的简短理由，随订单显示在通知和仪表盘中）
+	`ALTER TABLE orders ADD COLUMN reasoning TEXT NOT NULL DEFAULT '';`,
+
+	// v18: 账户事件时间线（决策、订单、成交、风控、配置变更和错误按时间统一记录，供仪表盘和 nofx timeline 查询）
+	`CREATE TABLE IF NOT EXISTS timeline (
+		id        INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		symbol    TEXT NOT NULL DEFAULT '',
+		kind      TEXT NOT NULL,
+		action    TEXT NOT NULL DEFAULT '',
+		summary   TEXT NOT NULL DEFAULT '',
+		data      TEXT NOT NULL DEFAULT '',
+		time      TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_timeline_trader_time ON timeline(trader_id, time);
+	CREATE INDEX IF NOT EXISTS idx_timeline_trader_symbol ON timeline(trader_id, symbol, time);`,
+
+	// v19: 资金费率套利的对冲持仓（现货腿数量和累计资金费，重启后恢复）
+	`CREATE TABLE IF NOT EXISTS carry_positions (
+		trader_id      TEXT NOT NULL,
+		symbol         TEXT NOT NULL,
+		spot_quantity  REAL NOT NULL DEFAULT 0,
+		perp_contracts REAL NOT NULL DEFAULT 0,
+		entry_notional REAL NOT NULL DEFAULT 0,
+		entry_funding  REAL NOT NULL DEFAULT 0,
+		net_carry      REAL NOT NULL DEFAULT 0,
+		open_time      TIMESTAMP NOT NULL,
+		last_settle    TIMESTAMP,
+		PRIMARY KEY (trader_id, symbol)
+	);`,
+
+	// v20: 持仓的合约张数（与交易所持仓直接比较，不经过基础币数量换算；0表示未记录）
+	`ALTER TABLE positions ADD COLUMN contracts REAL NOT NULL DEFAULT 0;`,
+}
+
+// SchemaVersion 数据库当前的迁移版本和程序支持的最新版本
+func (s *Store) SchemaVersion() (current, latest int, err error) {
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return 0, len(migrations), fmt.Errorf("读取迁移版本失败: %w", err)
+	}
+	return current, len(migrations), nil
+}
+
+// migrate 执行未应用的迁移
+func (s *Store) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("创建迁移表失败: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("读取迁移版本失败: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("开始迁移事务失败: %w", err)
+		}
+		// 共享数据库时其他实例可能已完成该迁移（加锁后重新检查）
+		if err := s.db.backend.lockMigrations(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("迁移加锁失败: %w", err)
+		}
+		var applied int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("读取迁移版本失败: %w", err)
+		}
+		if applied > 0 {
+			tx.Rollback()
+			continue
+		}
+		if _, err := tx.Exec(s.db.backend.migration(migrations[i])); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("执行迁移v%d失败: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("记录迁移v%d失败: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交迁移v%d失败: %w", version, err)
+		}
+		logger.Info("数据库迁移完成", "version", version)
+	}
+	return nil
+}
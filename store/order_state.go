@@ -0,0 +1,189 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 订单状态
+const (
+	OrderCreated         = "created"          // 本地已创建，尚未提交
+	OrderSubmitted       = "submitted"        // 已提交到交易所
+	OrderPartiallyFilled = "partially_filled" // 部分成交
+	OrderFilled          = "filled"           // 完全成交
+	OrderCancelled       = "cancelled"        // 已撤销（可能带部分成交）
+	OrderRejected        = "rejected"         // 提交失败或被交易所拒绝
+)
+
+// orderTransitions 合法的状态转换
+var orderTransitions = map[string][]string{
+	OrderCreated:         {OrderSubmitted, OrderRejected},
+	OrderSubmitted:       {OrderPartiallyFilled, OrderFilled, OrderCancelled, OrderRejected},
+	OrderPartiallyFilled: {OrderPartiallyFilled, OrderFilled, OrderCancelled},
+}
+
+// IsTerminalOrderState 是否为终态（不再变化）
+func IsTerminalOrderState(state string) bool {
+	return state == OrderFilled || state == OrderCancelled || state == OrderRejected
+}
+
+// canTransition 检查状态转换是否合法
+func canTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderUpdate 订单状态更新
+type OrderUpdate struct {
+	State     string  `json:"state"`
+	OrderID   string  `json:"order_id,omitempty"` // 交易所订单ID（提交后才有）
+	FilledQty float64 `json:"filled_qty"`
+	AvgPrice  float64 `json:"avg_price"`
+	Detail    string  `json:"detail,omitempty"` // 错误信息或交易所返回的结束原因
+	Reason    string  `json:"reason,omitempty"` // 系统主动撤单等状态变化的原因代码（如LIMIT_ORDER_EXPIRED）
+}
+
+// CreateOrder 创建订单记录（状态为created），返回本地记录ID
+func (s *Store) CreateOrder(o Order) (int64, error) {
+	if s == nil {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	// RETURNING而非LastInsertId（PostgreSQL驱动不支持LastInsertId）
+	var id int64
+	err := s.db.QueryRow(`INSERT INTO orders
+		(trader_id, order_id, client_id, symbol, action, side, quantity, price, leverage, status, strategy, decision_id, prompt_version, reason, reasoning, error, created_at, updated_at)
+		VALUES (?, '', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '', ?, ?) RETURNING id`,
+		o.TraderID, o.ClientID, o.Symbol, o.Action, o.Side, o.Quantity, o.Price, o.Leverage, OrderCreated, o.Strategy,
+		o.DecisionID, o.PromptVersion, o.Reason, o.Reasoning, now, now).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("创建订单记录失败: %w", err)
+	}
+	return id, nil
+}
+
+// MarkOrderSent 记录下单意图已发送（两阶段下单：created记录先写入，通过下单前检查、即将发送到交易所时再写入发送时间）
+// 重启对账时，没有发送时间的created订单一定没有到达交易所，有发送时间的订单需要按客户端订单ID确认
+func (s *Store) MarkOrderSent(id int64) error {
+	if s == nil || id == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`UPDATE orders SET sent_at = ?, updated_at = ? WHERE id = ? AND status = ?`, now, now, id, OrderCreated)
+	if err != nil {
+		return fmt.Errorf("记录下单意图失败: %w", err)
+	}
+	return nil
+}
+
+// TransitionOrder 推进订单状态（非法转换返回错误），并记录状态变更事件
+func (s *Store) TransitionOrder(id int64, u OrderUpdate) error {
+	if s == nil || id == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始订单事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var from string
+	if err := tx.QueryRow(`SELECT status FROM orders WHERE id = ?`, id).Scan(&from); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("订单记录不存在: %d", id)
+		}
+		return fmt.Errorf("读取订单状态失败: %w", err)
+	}
+	if from == u.State && u.State != OrderPartiallyFilled {
+		return nil // 状态未变化
+	}
+	if !canTransition(from, u.State) {
+		return fmt.Errorf("订单 %d 状态不能从 %s 变为 %s", id, from, u.State)
+	}
+
+	now := time.Now().UTC()
+	errText := ""
+	if u.State == OrderRejected {
+		errText = u.Detail
+	}
+	_, err = tx.Exec(`UPDATE orders SET status = ?, order_id = CASE WHEN ? != '' THEN ? ELSE order_id END,
+		filled_qty = ?, avg_price = ?, error = CASE WHEN ? != '' THEN ? ELSE error END, updated_at = ? WHERE id = ?`,
+		u.State, u.OrderID, u.OrderID, u.FilledQty, u.AvgPrice, errText, errText, now, id)
+	if err != nil {
+		return fmt.Errorf("更新订单状态失败: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO order_events (order_ref, from_state, to_state, filled_qty, avg_price, detail, reason, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, id, from, u.State, u.FilledQty, u.AvgPrice, u.Detail, u.Reason, now)
+	if err != nil {
+		return fmt.Errorf("记录订单事件失败: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ListStrategyFills 列出某策略在指定时间之后对某币种某方向的已成交订单（用于恢复策略状态）
+func (s *Store) ListStrategyFills(traderID, strategy, symbol, action string, since time.Time) ([]Order, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryOrders(`WHERE trader_id = ? AND strategy = ? AND symbol = ? AND action = ? AND created_at >= ?
+		AND filled_qty > 0 ORDER BY created_at`, traderID, strategy, symbol, action, since.UTC())
+}
+
+// ListExecutedOrders 列出指定时间之后有成交且记录了执行价格的订单（用于执行质量报表）
+func (s *Store) ListExecutedOrders(traderID string, since time.Time) ([]Order, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryOrders(`WHERE trader_id = ? AND created_at >= ? AND filled_qty > 0 AND avg_price > 0
+		AND submitted_price > 0 ORDER BY created_at`, traderID, since.UTC())
+}
+
+// ListActiveOrders 列出未到终态的订单（用于轮询确认和启动对账）
+func (s *Store) ListActiveOrders(traderID string) ([]Order, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryOrders(`WHERE trader_id = ? AND status IN (?, ?, ?) ORDER BY created_at`,
+		traderID, OrderCreated, OrderSubmitted, OrderPartiallyFilled)
+}
+
+// Execution 订单的执行情况（成交确认后写入）
+type Execution struct {
+	Route          string  // 执行方式 maker/taker（未经执行方式选择时为空）
+	FeeRate        float64 // 手续费率（未知时为0）
+	SlippageBps    float64 // 成交均价相对参考价的滑点（基点，正数表示不利）
+	ArrivalPrice   float64 // 决策时价格
+	SubmittedPrice float64 // 提交价格
+}
+
+// RecordExecution 记录订单的执行方式、价格和执行成本
+func (s *Store) RecordExecution(id int64, e Execution) error {
+	if s == nil || id == 0 {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE orders SET route = ?, fee_rate = ?, slippage_bps = ?, arrival_price = ?, submitted_price = ?
+		WHERE id = ?`, e.Route, e.FeeRate, e.SlippageBps, e.ArrivalPrice, e.SubmittedPrice, id)
+	if err != nil {
+		return fmt.Errorf("记录执行成本失败: %w", err)
+	}
+	return nil
+}
+
+// GetOrderByOrderID 按交易所订单ID查找订单记录（不存在返回nil）
+func (s *Store) GetOrderByOrderID(traderID, orderID string) (*Order, error) {
+	if s == nil || orderID == "" {
+		return nil, nil
+	}
+	orders, err := s.queryOrders(`WHERE trader_id = ? AND order_id = ? ORDER BY created_at DESC LIMIT 1`, traderID, orderID)
+	if err != nil || len(orders) == 0 {
+		return nil, err
+	}
+	return &orders[0], nil
+}
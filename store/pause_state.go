@@ -0,0 +1,53 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PauseState 人工暂停状态
+type PauseState struct {
+	Paused bool      `json:"paused"`
+	Source string    `json:"source,omitempty"` // 暂停来源: api/grpc/telegram/file/cli
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since,omitempty"`
+}
+
+// SavePauseState 保存trader的暂停状态
+func (s *Store) SavePauseState(traderID string, state PauseState) error {
+	if s == nil {
+		return nil
+	}
+	paused := 0 // paused列为整数（PostgreSQL不接受布尔值写入整数列）
+	if state.Paused {
+		paused = 1
+	}
+	_, err := s.db.Exec(`INSERT INTO pause_state (trader_id, paused, source, reason, since) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(trader_id) DO UPDATE SET paused = excluded.paused, source = excluded.source,
+		reason = excluded.reason, since = excluded.since`,
+		traderID, paused, state.Source, state.Reason, state.Since.UTC())
+	if err != nil {
+		return fmt.Errorf("保存暂停状态失败: %w", err)
+	}
+	return nil
+}
+
+// LoadPauseState 读取trader的暂停状态（没有记录时返回未暂停）
+func (s *Store) LoadPauseState(traderID string) (PauseState, error) {
+	var state PauseState
+	if s == nil {
+		return state, nil
+	}
+	var since sql.NullTime
+	err := s.db.QueryRow(`SELECT paused, source, reason, since FROM pause_state WHERE trader_id = ?`, traderID).
+		Scan(&state.Paused, &state.Source, &state.Reason, &since)
+	if err == sql.ErrNoRows {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("读取暂停状态失败: %w", err)
+	}
+	state.Since = since.Time
+	return state, nil
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// SaveProtectiveSnapshot 保存trader的止损/止盈条件单快照（整体替换上一次快照）
+func (s *Store) SaveProtectiveSnapshot(traderID string, orders []ProtectiveOrder, at time.Time) error {
+	if s == nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("保存条件单快照失败: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM protective_snapshots WHERE trader_id = ?`, traderID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("保存条件单快照失败: %w", err)
+	}
+	for _, o := range orders {
+		if _, err := tx.Exec(`INSERT INTO protective_snapshots (trader_id, symbol, side, kind, trigger_price, quantity, taken_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`, traderID, o.Symbol, o.Side, o.Kind, o.TriggerPrice, o.Quantity, at.UTC()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("保存条件单快照失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("保存条件单快照失败: %w", err)
+	}
+	return nil
+}
+
+// LoadProtectiveSnapshot 读取trader最近一次的条件单快照和快照时间（没有快照时返回nil）
+func (s *Store) LoadProtectiveSnapshot(traderID string) ([]ProtectiveOrder, time.Time, error) {
+	var takenAt time.Time
+	if s == nil {
+		return nil, takenAt, nil
+	}
+	rows, err := s.db.Query(`SELECT symbol, side, kind, trigger_price, quantity, taken_at FROM protective_snapshots
+		WHERE trader_id = ? ORDER BY symbol, side, kind`, traderID)
+	if err != nil {
+		return nil, takenAt, fmt.Errorf("读取条件单快照失败: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []ProtectiveOrder
+	for rows.Next() {
+		o := ProtectiveOrder{TraderID: traderID}
+		if err := rows.Scan(&o.Symbol, &o.Side, &o.Kind, &o.TriggerPrice, &o.Quantity, &takenAt); err != nil {
+			return nil, takenAt, fmt.Errorf("读取条件单快照失败: %w", err)
+		}
+		o.CreatedAt = takenAt
+		orders = append(orders, o)
+	}
+	return orders, takenAt, rows.Err()
+}
+
+// ClearProtectiveSnapshot 删除trader的条件单快照（全部恢复后调用）
+func (s *Store) ClearProtectiveSnapshot(traderID string) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := s.db.Exec(`DELETE FROM protective_snapshots WHERE trader_id = ?`, traderID); err != nil {
+		return fmt.Errorf("删除条件单快照失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ReasonCount 某个原因代码在订单、系统撤单和风控事件中出现的次数
+type ReasonCount struct {
+	Reason     string `json:"reason"`
+	Orders     int    `json:"orders"`      // 下单次数
+	Filled     int    `json:"filled"`      // 其中有成交的订单
+	Rejected   int    `json:"rejected"`    // 其中被拒绝的订单
+	Cancels    int    `json:"cancels"`     // 系统主动撤单次数
+	RiskEvents int    `json:"risk_events"` // 风控事件次数
+}
+
+// Total 合计次数（有成交/被拒绝是下单次数的细分，不重复计算）
+func (c ReasonCount) Total() int {
+	return c.Orders + c.Cancels + c.RiskEvents
+}
+
+// ReasonStats 按原因代码统计指定时间之后的订单、系统撤单和风控事件（按合计次数降序，未记录原因代码的旧记录不统计）
+func (s *Store) ReasonStats(traderID string, since time.Time) ([]ReasonCount, error) {
+	if s == nil {
+		return nil, nil
+	}
+	counts := make(map[string]*ReasonCount)
+	get := func(reason string) *ReasonCount {
+		if counts[reason] == nil {
+			counts[reason] = &ReasonCount{Reason: reason}
+		}
+		return counts[reason]
+	}
+
+	rows, err := s.db.Query(`SELECT reason, status, filled_qty FROM orders
+		WHERE trader_id = ? AND created_at >= ? AND reason != ''`, traderID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询订单原因代码失败: %w", err)
+	}
+	for rows.Next() {
+		var reason, status string
+		var filled float64
+		if err := rows.Scan(&reason, &status, &filled); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("读取订单原因代码失败: %w", err)
+		}
+		c := get(reason)
+		c.Orders++
+		if filled > 0 {
+			c.Filled++
+		}
+		if status == OrderRejected {
+			c.Rejected++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, q := range []struct {
+		query string
+		field func(c *ReasonCount) *int
+	}{
+		{`SELECT e.reason, COUNT(*) FROM order_events e JOIN orders o ON o.id = e.order_ref
+			WHERE o.trader_id = ? AND e.time >= ? AND e.reason != '' GROUP BY e.reason`,
+			func(c *ReasonCount) *int { return &c.Cancels }},
+		{`SELECT reason, COUNT(*) FROM risk_events WHERE trader_id = ? AND time >= ? AND reason != '' GROUP BY reason`,
+			func(c *ReasonCount) *int { return &c.RiskEvents }},
+	} {
+		rows, err := s.db.Query(q.query, traderID, since.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("统计原因代码失败: %w", err)
+		}
+		for rows.Next() {
+			var reason string
+			var n int
+			if err := rows.Scan(&reason, &n); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("读取原因代码统计失败: %w", err)
+			}
+			*q.field(get(reason)) += n
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	list := make([]ReasonCount, 0, len(counts))
+	for _, c := range counts {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Total() != list[j].Total() {
+			return list[i].Total() > list[j].Total()
+		}
+		return list[i].Reason < list[j].Reason
+	})
+	return list, nil
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ListSymbolOrders 列出某币种在时间范围内创建的订单（按时间升序，用于交易回放）
+func (s *Store) ListSymbolOrders(traderID, symbol string, from, to time.Time) ([]Order, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryOrders(`WHERE trader_id = ? AND symbol = ? AND created_at >= ? AND created_at <= ? ORDER BY created_at`,
+		traderID, symbol, from.UTC(), to.UTC())
+}
+
+// ListSymbolFills 列出某币种在时间范围内的成交（按时间升序，用于交易回放）
+func (s *Store) ListSymbolFills(traderID, symbol string, from, to time.Time) ([]Fill, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT id, trader_id, order_id, trade_id, symbol, side, price, quantity, fee, role, time,
+		strategy, decision_id, prompt_version FROM fills WHERE trader_id = ? AND symbol = ? AND time >= ? AND time <= ?
+		ORDER BY time, id`, traderID, symbol, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询成交记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var fills []Fill
+	for rows.Next() {
+		var f Fill
+		if err := rows.Scan(&f.ID, &f.TraderID, &f.OrderID, &f.TradeID, &f.Symbol, &f.Side, &f.Price, &f.Quantity,
+			&f.Fee, &f.Role, &f.Time, &f.Strategy, &f.DecisionID, &f.PromptVersion); err != nil {
+			return nil, fmt.Errorf("读取成交记录失败: %w", err)
+		}
+		fills = append(fills, f)
+	}
+	return fills, rows.Err()
+}
+
+// ListProtectiveOrders 列出某币种某方向在时间范围内设置的止损/止盈挂单（按时间升序，用于交易回放）
+func (s *Store) ListProtectiveOrders(traderID, symbol, side string, from, to time.Time) ([]ProtectiveOrder, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT id, trader_id, symbol, side, kind, trigger_price, quantity, status, error, created_at
+		FROM protective_orders WHERE trader_id = ? AND symbol = ? AND side = ? AND created_at >= ? AND created_at <= ?
+		ORDER BY created_at, id`, traderID, symbol, side, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询止损止盈记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []ProtectiveOrder
+	for rows.Next() {
+		var p ProtectiveOrder
+		if err := rows.Scan(&p.ID, &p.TraderID, &p.Symbol, &p.Side, &p.Kind, &p.TriggerPrice, &p.Quantity, &p.Status,
+			&p.Error, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("读取止损止盈记录失败: %w", err)
+		}
+		orders = append(orders, p)
+	}
+	return orders, rows.Err()
+}
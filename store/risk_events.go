@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// RiskEvent 风控事件（规则拒绝决策或触发熔断等）
+type RiskEvent struct {
+	TraderID string    `json:"trader_id"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Rule     string    `json:"rule"`
+	Action   string    `json:"action,omitempty"`
+	Detail   string    `json:"detail"`
+	Reason   string    `json:"reason,omitempty"` // 原因代码（如RISK_DRAWDOWN）
+	Time     time.Time `json:"time"`
+}
+
+// RecordRiskEvent 记录风控事件
+func (s *Store) RecordRiskEvent(e RiskEvent) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT INTO risk_events (trader_id, symbol, rule, action, detail, reason, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, e.TraderID, e.Symbol, e.Rule, e.Action, e.Detail, e.Reason, e.Time.UTC())
+	if err != nil {
+		return fmt.Errorf("记录风控事件失败: %w", err)
+	}
+	return nil
+}
+
+// ListRiskEvents 查询指定时间之后的风控事件（按时间倒序，最多limit条；reason为空时不按原因代码过滤）
+func (s *Store) ListRiskEvents(traderID, reason string, since time.Time, limit int) ([]RiskEvent, error) {
+	if s == nil {
+		return nil, nil
+	}
+	where, args := `WHERE trader_id = ? AND time >= ?`, []interface{}{traderID, since.UTC()}
+	if reason != "" {
+		where += ` AND reason = ?`
+		args = append(args, reason)
+	}
+	rows, err := s.db.Query(`SELECT trader_id, symbol, rule, action, detail, reason, time FROM risk_events
+		`+where+` ORDER BY time DESC, id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("查询风控事件失败: %w", err)
+	}
+	defer rows.Close()
+
+	var list []RiskEvent
+	for rows.Next() {
+		var e RiskEvent
+		if err := rows.Scan(&e.TraderID, &e.Symbol, &e.Rule, &e.Action, &e.Detail, &e.Reason, &e.Time); err != nil {
+			return nil, fmt.Errorf("读取风控事件失败: %w", err)
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
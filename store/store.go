@@ -0,0 +1,577 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Store 交易日志存储（SQLite文件或PostgreSQL）
+// 持久化订单、成交、持仓生命周期、止损止盈和账户余额快照，重启后可恢复历史
+// 所有写入方法对nil *Store安全（未启用存储时为空操作）
+type Store struct {
+	db *conn
+}
+
+// Open 打开（或创建）交易日志数据库并执行迁移
+// dsn为postgres://或postgresql://连接串时使用PostgreSQL（多个实例按trader_id共享），否则为SQLite文件路径
+func Open(dsn string) (*Store, error) {
+	b := backendFor(dsn)
+	db, err := b.open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+
+	s := &Store{db: &conn{DB: db, backend: b}}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Ping 检查数据库可读写（用于健康检查）
+func (s *Store) Ping() error {
+	if s == nil {
+		return nil
+	}
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&n); err != nil {
+		return fmt.Errorf("数据库不可用: %w", err)
+	}
+	return nil
+}
+
+// DB 底层数据库连接（供报表等只读查询使用，SQL按Backend()的方言编写）
+func (s *Store) DB() *sql.DB {
+	return s.db.DB
+}
+
+// Backend 数据库后端名称（sqlite/postgres）
+func (s *Store) Backend() string {
+	if s == nil {
+		return ""
+	}
+	return s.db.backend.Name()
+}
+
+// Order 订单记录
+type Order struct {
+	ID             int64      `json:"id"`
+	TraderID       string     `json:"trader_id"`
+	OrderID        string     `json:"order_id"`            // 交易所订单ID
+	ClientID       string     `json:"client_id,omitempty"` // 客户端订单ID（下单时的订单文本，用于确认结果不确定的下单）
+	Symbol         string     `json:"symbol"`
+	Action         string     `json:"action"` // open_long/open_short/close_long/close_short
+	Side           string     `json:"side"`   // long/short
+	Quantity       float64    `json:"quantity"`
+	Price          float64    `json:"price"`
+	Leverage       int        `json:"leverage"`
+	Status         string     `json:"status"`                   // 订单状态（见order_state.go）
+	Strategy       string     `json:"strategy"`                 // ai/dca/funding_harvest/webhook
+	DecisionID     string     `json:"decision_id,omitempty"`    // 所属决策（AI决策周期、外部信号或策略看守周期）
+	PromptVersion  string     `json:"prompt_version,omitempty"` // AI决策使用的提示词版本
+	Reason         string     `json:"reason,omitempty"`         // 原因代码（AI_DECISION、DCA_ADD、RISK_ADL等）
+	Reasoning      string     `json:"reasoning,omitempty"`      // 决策理由（AI或外部信号给出的简短说明）
+	Error          string     `json:"error,omitempty"`
+	FilledQty      float64    `json:"filled_qty"`                // 已成交数量
+	AvgPrice       float64    `json:"avg_price"`                 // 成交均价
+	Route          string     `json:"route,omitempty"`           // 执行方式 maker/taker（未记录时为空）
+	FeeRate        float64    `json:"fee_rate,omitempty"`        // 执行方式对应的手续费率
+	SlippageBps    float64    `json:"slippage_bps,omitempty"`    // 成交均价相对参考价的滑点（基点，正数表示不利）
+	ArrivalPrice   float64    `json:"arrival_price,omitempty"`   // 决策时价格（AI决策快照或外部信号到达时）
+	SubmittedPrice float64    `json:"submitted_price,omitempty"` // 提交价格（限价单的委托价，市价单为提交前的最新价）
+	SentAt         *time.Time `json:"sent_at,omitempty"`         // 发送到交易所的时间（为空表示尚未发送，见MarkOrderSent）
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Fill 成交记录
+type Fill struct {
+	ID       int64     `json:"id"`
+	TraderID string    `json:"trader_id"`
+	OrderID  string    `json:"order_id"`
+	TradeID  string    `json:"trade_id"`
+	Symbol   string    `json:"symbol"`
+	Side     string    `json:"side"` // buy/sell
+	Price    float64   `json:"price"`
+	Quantity float64   `json:"quantity"`
+	Fee      float64   `json:"fee"`
+	Role     string    `json:"role"` // taker/maker
+	Time     time.Time `json:"time"`
+
+	// 归因（来自交易日志中的订单或交易所返回的订单文本，无法归因时为空）
+	Strategy      string `json:"strategy,omitempty"`
+	DecisionID    string `json:"decision_id,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+// Position 持仓生命周期记录（开仓到平仓）
+type Position struct {
+	ID            int64      `json:"id"`
+	TraderID      string     `json:"trader_id"`
+	Symbol        string     `json:"symbol"`
+	Side          string     `json:"side"`
+	Quantity      float64    `json:"quantity"`            // 基础币数量（合约张数 × 合约乘数，与成交记录一致）
+	Contracts     float64    `json:"contracts,omitempty"` // 合约张数（交易所持仓单位；0表示未记录，如旧记录和导入的记录）
+	EntryPrice    float64    `json:"entry_price"`
+	Leverage      int        `json:"leverage"`
+	StopLoss      float64    `json:"stop_loss"`
+	TakeProfit    float64    `json:"take_profit"`
+	Strategy      string     `json:"strategy"`
+	DecisionID    string     `json:"decision_id,omitempty"`    // 开仓决策
+	PromptVersion string     `json:"prompt_version,omitempty"` // 开仓决策使用的提示词版本
+	Status        string     `json:"status"`                   // open/closed
+	OpenedAt      time.Time  `json:"opened_at"`
+	ExitPrice     float64    `json:"exit_price"`
+	RealizedPnL   float64    `json:"realized_pnl"` // 毛盈亏（不含手续费和资金费）
+	Fees          float64    `json:"fees"`         // 归集到该交易的手续费（正数表示支出）
+	Funding       float64    `json:"funding"`      // 归集到该交易的资金费（正数表示收入）
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	Note          string     `json:"note,omitempty"` // 人工备注
+	Tags          []string   `json:"tags,omitempty"` // 人工标签（如 news spike、bad fill）
+}
+
+// GrossPnL 按出场价计算的毛盈亏
+func (p Position) GrossPnL(exitPrice float64) float64 {
+	pnl := (exitPrice - p.EntryPrice) * p.Quantity
+	if p.Side == "short" {
+		pnl = -pnl
+	}
+	return pnl
+}
+
+// NetPnL 净盈亏 = 毛盈亏 - 手续费 + 资金费
+func (p Position) NetPnL() float64 {
+	return p.RealizedPnL - p.Fees + p.Funding
+}
+
+// ProtectiveOrder 止损/止盈挂单记录
+type ProtectiveOrder struct {
+	ID           int64     `json:"id"`
+	TraderID     string    `json:"trader_id"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"`
+	Kind         string    `json:"kind"` // stop_loss/take_profit
+	TriggerPrice float64   `json:"trigger_price"`
+	Quantity     float64   `json:"quantity"`
+	Status       string    `json:"status"` // placed/failed
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BalanceSnapshot 账户余额快照
+type BalanceSnapshot struct {
+	TraderID      string    `json:"trader_id"`
+	TotalEquity   float64   `json:"total_equity"`
+	WalletBalance float64   `json:"wallet_balance"`
+	Available     float64   `json:"available"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	PositionCount int       `json:"position_count"`
+	Time          time.Time `json:"time"`
+}
+
+// RecordFill 记录成交（按trade_id去重）
+func (s *Store) RecordFill(f Fill) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT INTO fills
+		(trader_id, order_id, trade_id, symbol, side, price, quantity, fee, role, time, strategy, decision_id, prompt_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		f.TraderID, f.OrderID, f.TradeID, f.Symbol, f.Side, f.Price, f.Quantity, f.Fee, f.Role, f.Time.UTC(),
+		f.Strategy, f.DecisionID, f.PromptVersion)
+	if err != nil {
+		return fmt.Errorf("记录成交失败: %w", err)
+	}
+	return nil
+}
+
+// OpenPosition 记录开仓（同币种同方向已有未平仓记录时视为加仓，更新数量和均价）
+func (s *Store) OpenPosition(p Position) error {
+	if s == nil {
+		return nil
+	}
+	if p.OpenedAt.IsZero() {
+		p.OpenedAt = time.Now()
+	}
+
+	existing, err := s.GetOpenPosition(p.TraderID, p.Symbol, p.Side)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		totalQty := existing.Quantity + p.Quantity
+		avgPrice := existing.EntryPrice
+		if totalQty > 0 {
+			avgPrice = (existing.EntryPrice*existing.Quantity + p.EntryPrice*p.Quantity) / totalQty
+		}
+		// 原记录没有张数（旧记录）时合计张数未知，保持为0
+		var totalContracts float64
+		if existing.Contracts > 0 && p.Contracts > 0 {
+			totalContracts = existing.Contracts + p.Contracts
+		}
+		_, err = s.db.Exec(`UPDATE positions SET quantity = ?, contracts = ?, entry_price = ? WHERE id = ?`,
+			totalQty, totalContracts, avgPrice, existing.ID)
+		if err != nil {
+			return fmt.Errorf("更新持仓失败: %w", err)
+		}
+		return nil
+	}
+
+	_, err = s.db.Exec(`INSERT INTO positions
+		(trader_id, symbol, side, quantity, contracts, entry_price, leverage, stop_loss, take_profit, strategy, decision_id, prompt_version, status, opened_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'open', ?)`,
+		p.TraderID, p.Symbol, p.Side, p.Quantity, p.Contracts, p.EntryPrice, p.Leverage, p.StopLoss, p.TakeProfit,
+		p.Strategy, p.DecisionID, p.PromptVersion, p.OpenedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("记录开仓失败: %w", err)
+	}
+	return nil
+}
+
+// ClosePosition 记录平仓（按出场价计算已实现盈亏）
+func (s *Store) ClosePosition(traderID, symbol, side string, exitPrice float64) error {
+	if s == nil {
+		return nil
+	}
+
+	existing, err := s.GetOpenPosition(traderID, symbol, side)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil // 无开仓记录（如存储启用前已持有的仓位）
+	}
+
+	_, err = s.db.Exec(`UPDATE positions SET status = 'closed', exit_price = ?, realized_pnl = ?, closed_at = ? WHERE id = ?`,
+		exitPrice, existing.GrossPnL(exitPrice), time.Now().UTC(), existing.ID)
+	if err != nil {
+		return fmt.Errorf("记录平仓失败: %w", err)
+	}
+	return nil
+}
+
+// ReducePosition 记录部分平仓：平掉的数量拆成一条已平仓记录（沿用开仓时间、均价和归因，按出场价计算已实现盈亏），
+// 未平仓记录扣减相应数量和张数（contracts为平掉的张数）；quantity<=0或不小于剩余数量时按全部平仓处理（同ClosePosition）
+// 已归集的手续费和资金费留在未平仓记录上，随最后一次平仓结算
+func (s *Store) ReducePosition(traderID, symbol, side string, quantity, contracts, exitPrice float64) error {
+	if s == nil {
+		return nil
+	}
+
+	existing, err := s.GetOpenPosition(traderID, symbol, side)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+	if quantity <= 0 || quantity >= existing.Quantity*(1-1e-6) {
+		return s.ClosePosition(traderID, symbol, side, exitPrice)
+	}
+
+	closed := *existing
+	closed.Quantity = quantity
+	closed.Contracts = 0
+	remainingContracts := 0.0
+	if existing.Contracts > 0 && contracts > 0 {
+		closed.Contracts = contracts
+		remainingContracts = math.Max(existing.Contracts-contracts, 0)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始减仓事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(`INSERT INTO positions
+		(trader_id, symbol, side, quantity, contracts, entry_price, leverage, stop_loss, take_profit, strategy, decision_id, prompt_version,
+		status, opened_at, exit_price, realized_pnl, closed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'closed', ?, ?, ?, ?)`,
+		closed.TraderID, closed.Symbol, closed.Side, closed.Quantity, closed.Contracts, closed.EntryPrice, closed.Leverage, closed.StopLoss,
+		closed.TakeProfit, closed.Strategy, closed.DecisionID, closed.PromptVersion, closed.OpenedAt.UTC(),
+		exitPrice, closed.GrossPnL(exitPrice), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("记录部分平仓失败: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE positions SET quantity = ?, contracts = ? WHERE id = ?`,
+		existing.Quantity-quantity, remainingContracts, existing.ID); err != nil {
+		return fmt.Errorf("更新持仓失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交减仓事务失败: %w", err)
+	}
+	return nil
+}
+
+// HasPositions 交易日志中是否已有该trader的持仓记录（用于判断是否首次运行）
+func (s *Store) HasPositions(traderID string) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	positions, err := s.queryPositions(`WHERE trader_id = ? LIMIT 1`, traderID)
+	return len(positions) > 0, err
+}
+
+// ImportClosedPositions 在一个事务中写入从交易所历史导入的已平仓交易（含平仓时间、盈亏和费用）
+func (s *Store) ImportClosedPositions(positions []Position) error {
+	if s == nil || len(positions) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始导入事务失败: %w", err)
+	}
+	defer tx.Rollback()
+	for _, p := range positions {
+		if p.ClosedAt == nil {
+			continue
+		}
+		_, err := tx.Exec(`INSERT INTO positions
+			(trader_id, symbol, side, quantity, entry_price, leverage, stop_loss, take_profit, strategy, decision_id, prompt_version,
+			status, opened_at, exit_price, realized_pnl, fees, funding, closed_at)
+			VALUES (?, ?, ?, ?, ?, ?, 0, 0, ?, ?, ?, 'closed', ?, ?, ?, ?, ?, ?)`,
+			p.TraderID, p.Symbol, p.Side, p.Quantity, p.EntryPrice, p.Leverage, p.Strategy, p.DecisionID, p.PromptVersion,
+			p.OpenedAt.UTC(), p.ExitPrice, p.RealizedPnL, p.Fees, p.Funding, p.ClosedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("导入历史交易失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交导入事务失败: %w", err)
+	}
+	return nil
+}
+
+// AttributePosition 更新持仓的策略归因（对账接管的持仓按成交的订单文本找回所属策略）
+func (s *Store) AttributePosition(id int64, strategy, decisionID, promptVersion string) error {
+	if s == nil || id == 0 {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE positions SET strategy = ?, decision_id = ?, prompt_version = ? WHERE id = ?`,
+		strategy, decisionID, promptVersion, id)
+	if err != nil {
+		return fmt.Errorf("更新持仓归因失败: %w", err)
+	}
+	return nil
+}
+
+// GetOpenPosition 获取未平仓的持仓记录（不存在返回nil）
+func (s *Store) GetOpenPosition(traderID, symbol, side string) (*Position, error) {
+	if s == nil {
+		return nil, nil
+	}
+	positions, err := s.queryPositions(`WHERE trader_id = ? AND symbol = ? AND side = ? AND status = 'open'
+		ORDER BY opened_at DESC LIMIT 1`, traderID, symbol, side)
+	if err != nil || len(positions) == 0 {
+		return nil, err
+	}
+	return &positions[0], nil
+}
+
+// ListOpenPositions 列出所有未平仓的持仓记录
+func (s *Store) ListOpenPositions(traderID string) ([]Position, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryPositions(`WHERE trader_id = ? AND status = 'open' ORDER BY opened_at`, traderID)
+}
+
+// ListPositions 列出指定时间之后开仓的持仓记录（按开仓时间升序）
+func (s *Store) ListPositions(traderID string, since time.Time) ([]Position, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryPositions(`WHERE trader_id = ? AND opened_at >= ? ORDER BY opened_at`, traderID, since.UTC())
+}
+
+// ListClosedPositions 列出指定时间之后平仓的持仓记录（按平仓时间升序）
+func (s *Store) ListClosedPositions(traderID string, since time.Time) ([]Position, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryPositions(`WHERE trader_id = ? AND status = 'closed' AND closed_at >= ? ORDER BY closed_at`,
+		traderID, since.UTC())
+}
+
+// queryPositions 查询持仓记录
+func (s *Store) queryPositions(where string, args ...interface{}) ([]Position, error) {
+	rows, err := s.db.Query(`SELECT id, trader_id, symbol, side, quantity, contracts, entry_price, leverage, stop_loss, take_profit,
+		strategy, decision_id, prompt_version, status, opened_at, exit_price, realized_pnl, fees, funding, closed_at, note, tags FROM positions `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询持仓记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []Position
+	for rows.Next() {
+		var p Position
+		var closedAt sql.NullTime
+		var tags string
+		if err := rows.Scan(&p.ID, &p.TraderID, &p.Symbol, &p.Side, &p.Quantity, &p.Contracts, &p.EntryPrice, &p.Leverage,
+			&p.StopLoss, &p.TakeProfit, &p.Strategy, &p.DecisionID, &p.PromptVersion, &p.Status, &p.OpenedAt, &p.ExitPrice, &p.RealizedPnL, &p.Fees, &p.Funding, &closedAt, &p.Note, &tags); err != nil {
+			return nil, fmt.Errorf("读取持仓记录失败: %w", err)
+		}
+		if closedAt.Valid {
+			t := closedAt.Time
+			p.ClosedAt = &t
+		}
+		p.Tags = splitTags(tags)
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+// RecordProtectiveOrder 记录止损/止盈挂单
+func (s *Store) RecordProtectiveOrder(p ProtectiveOrder) error {
+	if s == nil {
+		return nil
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT INTO protective_orders
+		(trader_id, symbol, side, kind, trigger_price, quantity, status, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.TraderID, p.Symbol, p.Side, p.Kind, p.TriggerPrice, p.Quantity, p.Status, p.Error, p.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("记录止损止盈失败: %w", err)
+	}
+
+	// 同步持仓的初始止损止盈（只记录首次设置的价格，用于计算R倍数）
+	if p.Status == "placed" {
+		column := "stop_loss"
+		if p.Kind == "take_profit" {
+			column = "take_profit"
+		}
+		_, err = s.db.Exec(`UPDATE positions SET `+column+` = ? WHERE trader_id = ? AND symbol = ? AND side = ?
+			AND status = 'open' AND `+column+` = 0`, p.TriggerPrice, p.TraderID, p.Symbol, p.Side)
+		if err != nil {
+			return fmt.Errorf("更新持仓止损止盈失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordBalance 记录账户余额快照
+func (s *Store) RecordBalance(b BalanceSnapshot) error {
+	if s == nil {
+		return nil
+	}
+	if b.Time.IsZero() {
+		b.Time = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT INTO balance_snapshots
+		(trader_id, total_equity, wallet_balance, available, unrealized_pnl, position_count, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		b.TraderID, b.TotalEquity, b.WalletBalance, b.Available, b.UnrealizedPnL, b.PositionCount, b.Time.UTC())
+	if err != nil {
+		return fmt.Errorf("记录余额快照失败: %w", err)
+	}
+	return nil
+}
+
+// LatestBalance 获取最近一次余额快照（不存在返回nil）
+func (s *Store) LatestBalance(traderID string) (*BalanceSnapshot, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var b BalanceSnapshot
+	err := s.db.QueryRow(`SELECT trader_id, total_equity, wallet_balance, available, unrealized_pnl, position_count, time
+		FROM balance_snapshots WHERE trader_id = ? ORDER BY time DESC LIMIT 1`, traderID).
+		Scan(&b.TraderID, &b.TotalEquity, &b.WalletBalance, &b.Available, &b.UnrealizedPnL, &b.PositionCount, &b.Time)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询余额快照失败: %w", err)
+	}
+	return &b, nil
+}
+
+// EquityHistory 获取指定时间之后的净值曲线（按时间升序）
+func (s *Store) EquityHistory(traderID string, since time.Time) ([]BalanceSnapshot, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT trader_id, total_equity, wallet_balance, available, unrealized_pnl, position_count, time
+		FROM balance_snapshots WHERE trader_id = ? AND time >= ? ORDER BY time`, traderID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("查询净值曲线失败: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []BalanceSnapshot
+	for rows.Next() {
+		var b BalanceSnapshot
+		if err := rows.Scan(&b.TraderID, &b.TotalEquity, &b.WalletBalance, &b.Available, &b.UnrealizedPnL,
+			&b.PositionCount, &b.Time); err != nil {
+			return nil, fmt.Errorf("读取净值快照失败: %w", err)
+		}
+		snapshots = append(snapshots, b)
+	}
+	return snapshots, rows.Err()
+}
+
+// EquityHighWaterMark 获取历史最高净值（无记录返回0）
+func (s *Store) EquityHighWaterMark(traderID string) (float64, error) {
+	if s == nil {
+		return 0, nil
+	}
+	var hwm float64
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(total_equity), 0) FROM balance_snapshots WHERE trader_id = ?`,
+		traderID).Scan(&hwm)
+	if err != nil {
+		return 0, fmt.Errorf("查询最高净值失败: %w", err)
+	}
+	return hwm, nil
+}
+
+// ListOrders 列出指定时间之后的订单（按时间升序）
+func (s *Store) ListOrders(traderID string, since time.Time) ([]Order, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.queryOrders(`WHERE trader_id = ? AND created_at >= ? ORDER BY created_at`, traderID, since.UTC())
+}
+
+// queryOrders 查询订单记录
+func (s *Store) queryOrders(where string, args ...interface{}) ([]Order, error) {
+	rows, err := s.db.Query(`SELECT id, trader_id, order_id, client_id, symbol, action, side, quantity, price, leverage,
+		status, strategy, decision_id, prompt_version, reason, reasoning, error, filled_qty, avg_price, route, fee_rate, slippage_bps, arrival_price, submitted_price,
+		sent_at, created_at, updated_at FROM orders `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var o Order
+		var sentAt sql.NullTime
+		var updatedAt sql.NullTime // COALESCE后驱动返回字符串，无法扫描为time.Time
+		if err := rows.Scan(&o.ID, &o.TraderID, &o.OrderID, &o.ClientID, &o.Symbol, &o.Action, &o.Side, &o.Quantity, &o.Price,
+			&o.Leverage, &o.Status, &o.Strategy, &o.DecisionID, &o.PromptVersion, &o.Reason, &o.Reasoning, &o.Error, &o.FilledQty, &o.AvgPrice, &o.Route, &o.FeeRate, &o.SlippageBps,
+			&o.ArrivalPrice, &o.SubmittedPrice, &sentAt, &o.CreatedAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("读取订单失败: %w", err)
+		}
+		if sentAt.Valid {
+			o.SentAt = &sentAt.Time
+		}
+		o.UpdatedAt = o.CreatedAt
+		if updatedAt.Valid {
+			o.UpdatedAt = updatedAt.Time
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 时间线记录类型
+const (
+	TimelineDecision = "decision" // AI决策或外部信号的执行结果
+	TimelineOrder    = "order"    // 订单提交、拒绝、撤销
+	TimelineFill     = "fill"     // 开仓/平仓成交
+	TimelineRisk     = "risk"     // 风控拒绝和触发
+	TimelineConfig   = "config"   // 配置热加载
+	TimelineError    = "error"    // 决策周期失败
+)
+
+// TimelineKinds 所有时间线记录类型（按显示顺序）
+var TimelineKinds = []string{TimelineDecision, TimelineOrder, TimelineFill, TimelineRisk, TimelineConfig, TimelineError}
+
+// TimelineEntry 账户事件时间线中的一条记录（决策、订单、成交、风控、配置变更和错误按时间统一记录）
+type TimelineEntry struct {
+	ID       int64           `json:"id"`
+	TraderID string          `json:"trader_id"`
+	Symbol   string          `json:"symbol,omitempty"`
+	Kind     string          `json:"kind"`
+	Action   string          `json:"action,omitempty"` // 决策/订单动作、成交方式或风控规则
+	Summary  string          `json:"summary"`          // 一行文本说明（CLI和仪表盘直接显示）
+	Data     json.RawMessage `json:"data,omitempty"`   // 原始事件（JSON）
+	Time     time.Time       `json:"time"`
+}
+
+// TimelineQuery 时间线查询条件（零值字段不过滤；Until为零值表示到现在）
+type TimelineQuery struct {
+	TraderID string
+	Symbol   string
+	Kinds    []string
+	Since    time.Time
+	Until    time.Time
+	Limit    int // 最多返回条数（<=0表示不限）
+}
+
+// RecordTimeline 记录一条时间线
+func (s *Store) RecordTimeline(e TimelineEntry) error {
+	if s == nil {
+		return nil
+	}
+	data := ""
+	if len(e.Data) > 0 {
+		data = string(e.Data)
+	}
+	_, err := s.db.Exec(`INSERT INTO timeline (trader_id, symbol, kind, action, summary, data, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, e.TraderID, e.Symbol, e.Kind, e.Action, e.Summary, data, e.Time.UTC())
+	if err != nil {
+		return fmt.Errorf("记录时间线失败: %w", err)
+	}
+	return nil
+}
+
+// ListTimeline 按时间范围、币种和类型查询时间线（按时间倒序）
+func (s *Store) ListTimeline(q TimelineQuery) ([]TimelineEntry, error) {
+	if s == nil {
+		return nil, nil
+	}
+	where, args := `WHERE trader_id = ? AND time >= ?`, []interface{}{q.TraderID, q.Since.UTC()}
+	if !q.Until.IsZero() {
+		where += ` AND time <= ?`
+		args = append(args, q.Until.UTC())
+	}
+	if q.Symbol != "" {
+		where += ` AND symbol = ?`
+		args = append(args, q.Symbol)
+	}
+	if len(q.Kinds) > 0 {
+		where += ` AND kind IN (?` + strings.Repeat(`, ?`, len(q.Kinds)-1) + `)`
+		for _, kind := range q.Kinds {
+			args = append(args, kind)
+		}
+	}
+	query := `SELECT id, trader_id, symbol, kind, action, summary, data, time FROM timeline ` + where + ` ORDER BY time DESC, id DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询时间线失败: %w", err)
+	}
+	defer rows.Close()
+
+	var list []TimelineEntry
+	for rows.Next() {
+		var (
+			e    TimelineEntry
+			data string
+		)
+		if err := rows.Scan(&e.ID, &e.TraderID, &e.Symbol, &e.Kind, &e.Action, &e.Summary, &data, &e.Time); err != nil {
+			return nil, fmt.Errorf("读取时间线失败: %w", err)
+		}
+		if data != "" {
+			e.Data = json.RawMessage(data)
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,345 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// basisLegGrace 新建仓后多久才检查永续腿是否仍在（交易器持仓缓存约15秒）
+const basisLegGrace = time.Minute
+
+// PriceSource 永续合约价格数据源
+type PriceSource func(symbol string) (float64, error)
+
+// BasisConfig 期现基差监控与套利配置
+type BasisConfig struct {
+	Enabled          bool     `json:"enabled"`                // 是否启用基差监控
+	Symbols          []string `json:"symbols"`                // 监控的币种
+	AlertBps         float64  `json:"alert_bps"`              // 基差绝对值≥该值时告警（基点，0表示不告警）
+	AlertCooldownMin int      `json:"alert_cooldown_minutes"` // 同一币种两次告警的最小间隔（分钟，默认60）
+	Trade            bool     `json:"trade"`                  // 是否执行期现套利（false时只监控和告警）
+	EntryBps         float64  `json:"entry_bps"`              // 永续溢价≥该值时买入现货并做空永续
+	ExitBps          float64  `json:"exit_bps"`               // 溢价收敛到≤该值时同时平掉两条腿
+	NotionalUSD      float64  `json:"notional_usd"`           // 每个币种的现货买入金额（USDT）
+	MaxPositions     int      `json:"max_positions"`          // 同时持有的最大期现组合数量
+}
+
+// Validate 验证基差配置
+func (c *BasisConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Symbols) == 0 {
+		return fmt.Errorf("basis.symbols不能为空")
+	}
+	if c.AlertBps < 0 {
+		return fmt.Errorf("basis.alert_bps不能为负数")
+	}
+	if c.AlertCooldownMin <= 0 {
+		c.AlertCooldownMin = 60
+	}
+	if !c.Trade {
+		return nil
+	}
+	if c.EntryBps <= 0 {
+		return fmt.Errorf("basis.entry_bps必须大于0")
+	}
+	if c.ExitBps >= c.EntryBps {
+		return fmt.Errorf("basis.exit_bps必须小于entry_bps")
+	}
+	if c.NotionalUSD <= 0 {
+		return fmt.Errorf("basis.notional_usd必须大于0")
+	}
+	if c.MaxPositions <= 0 {
+		c.MaxPositions = 1
+	}
+	return nil
+}
+
+// BasisQuote 最近一次计算的基差
+type BasisQuote struct {
+	Symbol    string    `json:"symbol"`
+	SpotPrice float64   `json:"spot_price"`
+	PerpPrice float64   `json:"perp_price"`
+	BasisBps  float64   `json:"basis_bps"` // (永续 - 现货) / 现货，正数表示永续溢价
+	Time      time.Time `json:"time"`
+}
+
+// BasisPosition 一组现货多头+永续空头的期现组合
+type BasisPosition struct {
+	Symbol        string    `json:"symbol"`
+	SpotQuantity  float64   `json:"spot_quantity"`  // 现货持有数量（基础币）
+	PerpContracts float64   `json:"perp_contracts"` // 永续空头合约张数
+	EntryBasisBps float64   `json:"entry_basis_bps"`
+	OpenTime      time.Time `json:"open_time"`
+}
+
+// BasisMonitor 期现基差监控：按币种计算Gate现货与永续的价差，极端时告警；
+// 启用trade时在永续溢价足够大时买入现货并做空等量永续，溢价收敛后同时平掉两条腿，
+// 任一条腿在交易所消失（如永续被强平或人工平仓）时平掉另一条腿
+type BasisMonitor struct {
+	config    BasisConfig
+	perp      Executor
+	spot      SpotExecutor
+	perpPrice PriceSource
+	positions map[string]*BasisPosition // symbol -> 期现组合
+	quotes    map[string]BasisQuote     // symbol -> 最近一次基差
+	lastAlert map[string]time.Time      // symbol -> 上次告警时间
+	mu        sync.Mutex
+}
+
+// NewBasisMonitor 创建基差监控
+func NewBasisMonitor(config BasisConfig, perp Executor, spot SpotExecutor, perpPrice PriceSource) *BasisMonitor {
+	return &BasisMonitor{
+		config:    config,
+		perp:      perp,
+		spot:      spot,
+		perpPrice: perpPrice,
+		positions: make(map[string]*BasisPosition),
+		quotes:    make(map[string]BasisQuote),
+		lastAlert: make(map[string]time.Time),
+	}
+}
+
+// Enabled 是否启用
+func (m *BasisMonitor) Enabled() bool {
+	return m != nil && m.config.Enabled
+}
+
+// SetConfig 更新基差配置（热加载），已持有的期现组合继续跟踪直到正常解除
+func (m *BasisMonitor) SetConfig(config BasisConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	symbols := append([]string(nil), config.Symbols...)
+	listed := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		listed[strings.ToUpper(symbol)] = true
+	}
+	for symbol := range m.positions {
+		if !listed[symbol] {
+			logger.Warn("币种已从配置移除，但仍持有期现组合，继续跟踪至解除", "symbol", symbol)
+			symbols = append(symbols, symbol)
+		}
+	}
+	config.Symbols = symbols
+	m.config = config
+}
+
+// Evaluate 计算各币种基差，告警并建立或解除期现组合，返回执行日志（告警以⚠开头）
+func (m *BasisMonitor) Evaluate() []string {
+	if !m.Enabled() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var logs []string
+	now := time.Now()
+	shorts := m.perpShorts()
+
+	for _, symbol := range m.config.Symbols {
+		symbol = strings.ToUpper(symbol)
+
+		quote, err := m.quote(symbol, now)
+		if err != nil {
+			logger.Warn("计算期现基差失败", "symbol", symbol, "err", err)
+			continue
+		}
+		m.quotes[symbol] = quote
+
+		if m.config.AlertBps > 0 && math.Abs(quote.BasisBps) >= m.config.AlertBps &&
+			now.Sub(m.lastAlert[symbol]) >= time.Duration(m.config.AlertCooldownMin)*time.Minute {
+			m.lastAlert[symbol] = now
+			logs = append(logs, fmt.Sprintf("⚠ 期现基差 %s %+.1fbps（现货%.4f / 永续%.4f），超过告警阈值%.0fbps",
+				symbol, quote.BasisBps, quote.SpotPrice, quote.PerpPrice, m.config.AlertBps))
+		}
+
+		if pos, exists := m.positions[symbol]; exists {
+			if shorts != nil && !shorts[symbol] && now.Sub(pos.OpenTime) >= basisLegGrace {
+				// 永续空头已不存在（被强平或人工平仓），卖出现货腿
+				logger.Warn("永续空头已不存在，卖出现货腿", "symbol", symbol, "spot_quantity", pos.SpotQuantity)
+				if err := m.spot.SpotSell(symbol, pos.SpotQuantity); err != nil {
+					logs = append(logs, fmt.Sprintf("❌ 期现套利 %s 永续腿已消失，卖出现货失败: %v", symbol, err))
+					continue
+				}
+				delete(m.positions, symbol)
+				logs = append(logs, fmt.Sprintf("⚠ 期现套利 %s 永续腿已消失，已卖出现货%.6f", symbol, pos.SpotQuantity))
+				continue
+			}
+			if m.config.Trade && quote.BasisBps <= m.config.ExitBps {
+				logger.Info("基差收敛，解除期现组合", "symbol", symbol, "basis_bps", quote.BasisBps,
+					"entry_basis_bps", pos.EntryBasisBps, "exit_bps", m.config.ExitBps)
+				if err := m.unwind(pos); err != nil {
+					logs = append(logs, fmt.Sprintf("❌ 期现套利 %s 解除失败: %v", symbol, err))
+					continue
+				}
+				delete(m.positions, symbol)
+				logs = append(logs, fmt.Sprintf("✓ 期现套利 %s 解除: 基差%+.1fbps → %+.1fbps", symbol, pos.EntryBasisBps, quote.BasisBps))
+			}
+			continue
+		}
+
+		if m.config.Trade && quote.BasisBps >= m.config.EntryBps && len(m.positions) < m.config.MaxPositions {
+			logger.Info("永续溢价达到阈值，买入现货并做空永续", "symbol", symbol, "basis_bps", quote.BasisBps,
+				"entry_bps", m.config.EntryBps)
+			pos, err := m.open(symbol, quote, now)
+			if err != nil {
+				logs = append(logs, fmt.Sprintf("❌ 期现套利 %s 建仓失败: %v", symbol, err))
+				continue
+			}
+			m.positions[symbol] = pos
+			logs = append(logs, fmt.Sprintf("✓ 期现套利 %s 建仓: 基差%+.1fbps, 现货%.6f / 永续空%.0f张",
+				symbol, quote.BasisBps, pos.SpotQuantity, pos.PerpContracts))
+		}
+	}
+
+	return logs
+}
+
+// quote 计算基差
+func (m *BasisMonitor) quote(symbol string, now time.Time) (BasisQuote, error) {
+	spot, err := m.spot.GetSpotPrice(symbol)
+	if err != nil {
+		return BasisQuote{}, err
+	}
+	perp, err := m.perpPrice(symbol)
+	if err != nil {
+		return BasisQuote{}, err
+	}
+	if spot <= 0 || perp <= 0 {
+		return BasisQuote{}, fmt.Errorf("价格无效（现货%.4f，永续%.4f）", spot, perp)
+	}
+	return BasisQuote{Symbol: symbol, SpotPrice: spot, PerpPrice: perp, BasisBps: (perp - spot) / spot * 1e4, Time: now}, nil
+}
+
+// perpShorts 交易所上当前有永续空头的币种（没有期现组合或获取失败时返回nil，不做腿检查）
+func (m *BasisMonitor) perpShorts() map[string]bool {
+	if len(m.positions) == 0 {
+		return nil
+	}
+	positions, err := m.perp.GetPositions()
+	if err != nil {
+		logger.Warn("获取持仓失败，跳过期现组合检查", "err", err)
+		return nil
+	}
+	shorts := make(map[string]bool)
+	for _, pos := range positions {
+		if side, _ := pos["side"].(string); side == "short" {
+			symbol, _ := pos["symbol"].(string)
+			shorts[strings.ToUpper(symbol)] = true
+		}
+	}
+	return shorts
+}
+
+// open 建立期现组合：先买现货，再按实际成交数量做空永续，永续失败时回滚现货
+func (m *BasisMonitor) open(symbol string, quote BasisQuote, now time.Time) (*BasisPosition, error) {
+	multiplier, err := m.spot.GetContractMultiplier(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	spotQuantity, err := m.spot.SpotBuy(symbol, m.config.NotionalUSD)
+	if err != nil {
+		return nil, err
+	}
+
+	contracts := math.Floor(spotQuantity / multiplier)
+	if contracts < 1 {
+		if sellErr := m.spot.SpotSell(symbol, spotQuantity); sellErr != nil {
+			logger.Error("回滚现货腿失败", "symbol", symbol, "quantity", spotQuantity, "err", sellErr)
+		}
+		return nil, fmt.Errorf("现货数量%.6f不足一张合约（乘数%.6f）", spotQuantity, multiplier)
+	}
+
+	order, err := m.perp.OpenShort(symbol, contracts, 1)
+	if err != nil {
+		if sellErr := m.spot.SpotSell(symbol, spotQuantity); sellErr != nil {
+			logger.Error("回滚现货腿失败", "symbol", symbol, "quantity", spotQuantity, "err", sellErr)
+		}
+		return nil, fmt.Errorf("做空永续失败: %w", err)
+	}
+	// 部分成交时按实际张数记录（平仓时只平实际持有的空头）
+	contracts, _ = actualFill(order, contracts, 0)
+
+	return &BasisPosition{
+		Symbol:        symbol,
+		SpotQuantity:  spotQuantity,
+		PerpContracts: contracts,
+		EntryBasisBps: quote.BasisBps,
+		OpenTime:      now,
+	}, nil
+}
+
+// unwind 解除期现组合：先平永续空头，再卖出现货
+func (m *BasisMonitor) unwind(pos *BasisPosition) error {
+	if _, err := m.perp.CloseShort(pos.Symbol, pos.PerpContracts); err != nil {
+		return fmt.Errorf("平永续空头失败: %w", err)
+	}
+	if err := m.spot.SpotSell(pos.Symbol, pos.SpotQuantity); err != nil {
+		return fmt.Errorf("卖出现货失败（永续已平仓，请手动处理现货）: %w", err)
+	}
+	return nil
+}
+
+// Restore 恢复重启前的期现组合（现货数量按永续张数×合约乘数估算，建仓基差未知记为0）
+func (m *BasisMonitor) Restore(symbol string, contracts float64, openTime time.Time) error {
+	if !m.Enabled() {
+		return nil
+	}
+
+	multiplier, err := m.spot.GetContractMultiplier(symbol)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.positions[symbol] = &BasisPosition{
+		Symbol:        symbol,
+		SpotQuantity:  contracts * multiplier,
+		PerpContracts: contracts,
+		OpenTime:      openTime,
+	}
+	return nil
+}
+
+// GetPositions 获取当前所有期现组合（用于API展示）
+func (m *BasisMonitor) GetPositions() []BasisPosition {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]BasisPosition, 0, len(m.positions))
+	for _, pos := range m.positions {
+		result = append(result, *pos)
+	}
+	return result
+}
+
+// GetQuotes 获取各币种最近一次计算的基差（按币种排序，用于API展示）
+func (m *BasisMonitor) GetQuotes() []BasisQuote {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]BasisQuote, 0, len(m.quotes))
+	for _, quote := range m.quotes {
+		result = append(result, quote)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result
+}
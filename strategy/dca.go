@@ -0,0 +1,254 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"nofx/market"
+	"nofx/risk"
+	"strings"
+	"sync"
+)
+
+// DCAConfig DCA/马丁加仓配置
+type DCAConfig struct {
+	Enabled           bool    `json:"enabled"`             // 是否启用DCA加仓
+	DrawdownStepPct   float64 `json:"drawdown_step_pct"`   // 每逆向波动多少%加仓一次（相对上次加仓价）
+	AddSizeMultiplier float64 `json:"add_size_multiplier"` // 加仓数量倍数（1=等额DCA，>1为马丁）
+	MaxAdds           int     `json:"max_adds"`            // 最大加仓次数（硬上限）
+	AggregateStopPct  float64 `json:"aggregate_stop_pct"`  // 总体止损：相对平均入场价亏损达到该%后全部平仓
+}
+
+// Validate 验证DCA配置
+func (c *DCAConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.DrawdownStepPct <= 0 {
+		return fmt.Errorf("dca.drawdown_step_pct必须大于0")
+	}
+	if c.AddSizeMultiplier <= 0 {
+		c.AddSizeMultiplier = 1.0 // 默认等额加仓
+	}
+	if c.MaxAdds <= 0 {
+		return fmt.Errorf("dca.max_adds必须大于0")
+	}
+	// 总体止损必须比第一档加仓更远，否则永远不会加仓
+	if c.AggregateStopPct <= c.DrawdownStepPct {
+		return fmt.Errorf("dca.aggregate_stop_pct(%.2f)必须大于dca.drawdown_step_pct(%.2f)",
+			c.AggregateStopPct, c.DrawdownStepPct)
+	}
+	return nil
+}
+
+// dcaState 单个持仓的DCA状态
+type dcaState struct {
+	baseQuantity float64 // 首次建仓数量（加仓数量以此为基数）
+	adds         int     // 已加仓次数
+	lastAddPrice float64 // 上次建仓/加仓价格
+}
+
+// DCAManager DCA加仓管理器
+// 在持仓出现逆向波动时按档位加仓，受最大加仓次数和风控敞口限制约束，
+// 并在平均入场价基础上维护一个总体止损
+type DCAManager struct {
+	config DCAConfig
+	limits ExposureLimitsFunc
+	states map[string]*dcaState // symbol_side -> 状态
+	mu     sync.Mutex
+}
+
+// ExposureLimitsFunc 币种当前生效的敞口限制（按配置解析，热加载后立即生效）
+type ExposureLimitsFunc func(symbol string) risk.ExposureLimits
+
+// NewDCAManager 创建DCA管理器（limits为nil时使用risk.DefaultExposureLimits）
+func NewDCAManager(config DCAConfig, limits ExposureLimitsFunc) *DCAManager {
+	if limits == nil {
+		limits = func(string) risk.ExposureLimits { return risk.DefaultExposureLimits() }
+	}
+	return &DCAManager{
+		config: config,
+		limits: limits,
+		states: make(map[string]*dcaState),
+	}
+}
+
+// Enabled 是否启用
+func (m *DCAManager) Enabled() bool {
+	return m != nil && m.config.Enabled
+}
+
+// SetConfig 更新DCA配置（热加载），保留各持仓已加仓次数等状态
+func (m *DCAManager) SetConfig(config DCAConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = config
+}
+
+// Restore 恢复持仓的DCA状态（重启后从交易日志恢复已加仓次数，避免重复加仓）
+func (m *DCAManager) Restore(symbol, side string, baseQuantity float64, adds int, lastAddPrice float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.states[symbol+"_"+side] = &dcaState{
+		baseQuantity: baseQuantity,
+		adds:         adds,
+		lastAddPrice: lastAddPrice,
+	}
+}
+
+// Evaluate 检查所有持仓并执行DCA加仓或总体止损，返回执行日志
+func (m *DCAManager) Evaluate(executor Executor, snapshot *market.MarketSnapshot) []string {
+	if !m.Enabled() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var logs []string
+
+	// 所有持仓总名义价值（用于总敞口检查，按合约乘数换算）
+	var totalValue float64
+	for _, pos := range snapshot.Positions {
+		totalValue += pos.Notional()
+	}
+	equity := snapshot.Account.Equity
+
+	currentKeys := make(map[string]bool)
+	for _, pos := range snapshot.Positions {
+		symbol, side := pos.Symbol, pos.Side
+		quantity, entryPrice, markPrice := pos.Quantity, pos.EntryPrice, pos.MarkPrice
+		if symbol == "" || quantity == 0 || entryPrice <= 0 || markPrice <= 0 {
+			continue
+		}
+
+		posKey := symbol + "_" + side
+		currentKeys[posKey] = true
+
+		state, exists := m.states[posKey]
+		if !exists {
+			// 新持仓，以当前数量作为DCA基数
+			state = &dcaState{
+				baseQuantity: quantity,
+				lastAddPrice: entryPrice,
+			}
+			m.states[posKey] = state
+		}
+
+		// 1. 总体止损：相对平均入场价的逆向波动
+		adverseFromEntry := adverseMovePct(side, entryPrice, markPrice)
+		if adverseFromEntry >= m.config.AggregateStopPct {
+			logger.Warn("DCA总体止损触发，全部平仓", "symbol", symbol, "side", side,
+				"adverse_pct", adverseFromEntry, "stop_pct", m.config.AggregateStopPct)
+			if err := closePosition(executor, symbol, side); errors.Is(err, ErrCloseDeferred) {
+				logs = append(logs, fmt.Sprintf("⏳ DCA总体止损 %s %s 等待人工确认", symbol, side))
+				continue
+			} else if err != nil {
+				logs = append(logs, fmt.Sprintf("❌ DCA总体止损 %s %s 失败: %v", symbol, side, err))
+				continue
+			}
+			delete(m.states, posKey)
+			logs = append(logs, fmt.Sprintf("✓ DCA总体止损 %s %s 已平仓", symbol, side))
+			continue
+		}
+
+		// 2. 加仓档位：相对上次加仓价的逆向波动
+		if state.adds >= m.config.MaxAdds {
+			continue
+		}
+		adverseFromLast := adverseMovePct(side, state.lastAddPrice, markPrice)
+		if adverseFromLast < m.config.DrawdownStepPct {
+			continue
+		}
+
+		addQuantity := state.baseQuantity * math.Pow(m.config.AddSizeMultiplier, float64(state.adds+1))
+		addValue := pos.ContractValue(addQuantity)
+		symbolValue := pos.Notional()
+		if err := m.limits(symbol).CheckAdd(symbol, symbolValue, totalValue, addValue, equity); err != nil {
+			logger.Warn("DCA加仓被风控拒绝", "symbol", symbol, "side", side, "err", err)
+			logs = append(logs, fmt.Sprintf("⚠ DCA加仓 %s %s 被风控拒绝: %v", symbol, side, err))
+			continue
+		}
+
+		leverage := pos.Leverage
+
+		logger.Info("DCA加仓", "symbol", symbol, "side", side, "add", state.adds+1, "max_adds", m.config.MaxAdds,
+			"adverse_pct", adverseFromLast, "quantity", addQuantity, "value_usdt", addValue)
+
+		var order map[string]interface{}
+		var err error
+		if side == "long" {
+			order, err = executor.OpenLong(symbol, addQuantity, leverage)
+		} else {
+			order, err = executor.OpenShort(symbol, addQuantity, leverage)
+		}
+		if err != nil {
+			logs = append(logs, fmt.Sprintf("❌ DCA加仓 %s %s 失败: %v", symbol, side, err))
+			continue
+		}
+		addQuantity, fillPrice := actualFill(order, addQuantity, markPrice)
+		if addQuantity <= 0 {
+			logs = append(logs, fmt.Sprintf("⚠ DCA加仓 %s %s 未成交", symbol, side))
+			continue
+		}
+
+		state.adds++
+		state.lastAddPrice = fillPrice
+		totalValue += addValue
+		logs = append(logs, fmt.Sprintf("✓ DCA加仓 %s %s #%d 成功", symbol, side, state.adds))
+
+		// 加仓已成交：按新的平均入场价替换总体止损（加仓不会撤销已有的止损止盈条件单），止盈按新数量调整
+		newQuantity := quantity + addQuantity
+		newEntry := (entryPrice*quantity + fillPrice*addQuantity) / newQuantity
+		stopPrice := aggregateStopPrice(side, newEntry, m.config.AggregateStopPct)
+		if replacer, ok := executor.(StopLossReplacer); ok {
+			err = replacer.ReplaceStopLoss(symbol, strings.ToUpper(side), newQuantity, stopPrice)
+		} else {
+			err = executor.SetStopLoss(symbol, strings.ToUpper(side), newQuantity, stopPrice)
+		}
+		if err != nil {
+			logger.Warn("设置DCA总体止损失败", "symbol", symbol, "side", side, "stop_price", stopPrice, "err", err)
+			logs = append(logs, fmt.Sprintf("⚠ DCA总体止损 %s %s 设置失败: %v", symbol, side, err))
+		}
+	}
+
+	// 清理已平仓的状态
+	for key := range m.states {
+		if !currentKeys[key] {
+			delete(m.states, key)
+		}
+	}
+
+	return logs
+}
+
+// adverseMovePct 计算相对参考价的逆向波动百分比（不利方向为正）
+func adverseMovePct(side string, refPrice, markPrice float64) float64 {
+	if refPrice <= 0 {
+		return 0
+	}
+	if side == "long" {
+		return (refPrice - markPrice) / refPrice * 100
+	}
+	return (markPrice - refPrice) / refPrice * 100
+}
+
+// aggregateStopPrice 根据平均入场价计算总体止损价
+func aggregateStopPrice(side string, avgEntry, stopPct float64) float64 {
+	if side == "long" {
+		return avgEntry * (1 - stopPct/100)
+	}
+	return avgEntry * (1 + stopPct/100)
+}
+
+// closePosition 全部平掉指定方向的持仓
+func closePosition(executor Executor, symbol, side string) error {
+	var err error
+	if side == "long" {
+		_, err = executor.CloseLong(symbol, 0)
+	} else {
+		_, err = executor.CloseShort(symbol, 0)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package strategy
+
+import (
+	"errors"
+	"nofx/logging"
+)
+
+// logger 策略模块日志
+var logger = logging.For("strategy")
+
+// ErrCloseDeferred 平仓需要人工确认，已生成平仓建议（策略保留状态，下个周期再次检查）
+var ErrCloseDeferred = errors.New("平仓需要人工确认")
+
+// Executor 策略执行所需的交易能力（trader.Trader 的子集）
+// 单独定义接口以避免 strategy 包反向依赖 trader 包
+type Executor interface {
+	// GetPositions 获取所有持仓
+	GetPositions() ([]map[string]interface{}, error)
+
+	// OpenLong 开多仓（返回的订单可能带实际成交数量filledQty和均价avgPrice，见actualFill）
+	OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
+
+	// OpenShort 开空仓
+	OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
+
+	// CloseLong 平多仓（quantity=0表示全部平仓）
+	CloseLong(symbol string, quantity float64) (map[string]interface{}, error)
+
+	// CloseShort 平空仓（quantity=0表示全部平仓）
+	CloseShort(symbol string, quantity float64) (map[string]interface{}, error)
+
+	// SetStopLoss 设置止损单
+	SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error
+
+	// SetTakeProfit 设置止盈单
+	SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error
+}
+
+// StopLossReplacer 可替换现有止损的执行器（可选）：撤销该持仓原有的止损条件单后按新数量和止损价重挂，
+// 止盈条件单按新数量调整。加仓不会撤销条件单，不支持时直接SetStopLoss会与旧止损并存
+type StopLossReplacer interface {
+	ReplaceStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error
+}
+
+// actualFill 开仓的实际成交数量和均价（订单未带成交信息时按下单数量和参考价，部分成交时保护单按实际数量设置）
+func actualFill(order map[string]interface{}, quantity, price float64) (float64, float64) {
+	if filled, ok := order["filledQty"].(float64); ok {
+		quantity = filled
+	}
+	if avgPrice, ok := order["avgPrice"].(float64); ok && avgPrice > 0 {
+		price = avgPrice
+	}
+	return quantity, price
+}
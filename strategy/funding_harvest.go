@@ -0,0 +1,449 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"nofx/clock"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpotExecutor 现货对冲所需的交易能力（Gate.io现货模块）
+type SpotExecutor interface {
+	// GetSpotPrice 获取现货最新价格
+	GetSpotPrice(symbol string) (float64, error)
+
+	// SpotBuy 现货市价买入（quoteAmount为USDT金额），返回成交的基础币数量
+	SpotBuy(symbol string, quoteAmount float64) (float64, error)
+
+	// SpotSell 现货市价卖出（quantity为基础币数量）
+	SpotSell(symbol string, quantity float64) error
+
+	// GetContractMultiplier 获取合约乘数（每张合约对应的基础币数量）
+	GetContractMultiplier(symbol string) (float64, error)
+}
+
+// FundingRateSource 资金费率数据源
+type FundingRateSource func(symbol string) (float64, error)
+
+// FundingPercentileSource 当前资金费率在历史分布中的百分位（0-100，没有足够历史时ok为false）
+type FundingPercentileSource func(symbol string, rate float64) (percentile float64, ok bool)
+
+// CarryStore 对冲持仓的持久化（现货腿无法从永续持仓还原，重启后按保存的记录恢复）
+type CarryStore interface {
+	SaveCarry(pos CarryPosition) error
+	DeleteCarry(symbol string) error
+	LoadCarry() ([]CarryPosition, error)
+}
+
+// FundingHarvestConfig 资金费率套利配置
+type FundingHarvestConfig struct {
+	Enabled          bool     `json:"enabled"`            // 是否启用资金费率套利
+	Symbols          []string `json:"symbols"`            // 参与套利的币种（为空则不执行）
+	EntryFundingRate float64  `json:"entry_funding_rate"` // 开仓阈值：单期资金费率≥该值时建立对冲（如0.0005=0.05%）
+	ExitFundingRate  float64  `json:"exit_funding_rate"`  // 平仓阈值：资金费率回落到≤该值时解除对冲
+	NotionalUSD      float64  `json:"notional_usd"`       // 每个币种的对冲名义价值（USDT）
+	MaxPositions     int      `json:"max_positions"`      // 同时持有的最大对冲数量
+	FundingIntervalH int      `json:"funding_interval_h"` // 资金费率结算间隔（小时，默认8）
+	EntryPercentile  float64  `json:"entry_percentile"`   // 开仓还要求费率处于历史分布的该百分位及以上（0-100，0表示不检查，需要启用funding_history）
+}
+
+// Validate 验证资金费率套利配置
+func (c *FundingHarvestConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Symbols) == 0 {
+		return fmt.Errorf("funding_harvest.symbols不能为空")
+	}
+	if c.EntryFundingRate <= 0 {
+		return fmt.Errorf("funding_harvest.entry_funding_rate必须大于0")
+	}
+	if c.ExitFundingRate >= c.EntryFundingRate {
+		return fmt.Errorf("funding_harvest.exit_funding_rate必须小于entry_funding_rate")
+	}
+	if c.NotionalUSD <= 0 {
+		return fmt.Errorf("funding_harvest.notional_usd必须大于0")
+	}
+	if c.EntryPercentile < 0 || c.EntryPercentile > 100 {
+		return fmt.Errorf("funding_harvest.entry_percentile必须在0-100之间")
+	}
+	if c.MaxPositions <= 0 {
+		c.MaxPositions = 1
+	}
+	if c.FundingIntervalH <= 0 {
+		c.FundingIntervalH = 8 // Gate.io USDT永续默认8小时结算
+	}
+	if 24%c.FundingIntervalH != 0 {
+		// 结算时间按UTC整点对齐，间隔必须能整除24小时
+		return fmt.Errorf("funding_harvest.funding_interval_h必须能整除24: %d", c.FundingIntervalH)
+	}
+	return nil
+}
+
+// CarryPosition 一组现货多头+永续空头的对冲持仓
+type CarryPosition struct {
+	Symbol        string    `json:"symbol"`
+	SpotQuantity  float64   `json:"spot_quantity"`  // 现货持有数量（基础币）
+	PerpContracts float64   `json:"perp_contracts"` // 永续空头合约张数（解除对冲时永续腿已平、现货腿待卖出时为0）
+	EntryNotional float64   `json:"entry_notional"` // 建仓名义价值（USDT）
+	EntryFunding  float64   `json:"entry_funding"`  // 建仓时资金费率
+	OpenTime      time.Time `json:"open_time"`
+	NetCarry      float64   `json:"net_carry"`   // 累计估算净资金费收入（USDT）
+	LastSettle    time.Time `json:"last_settle"` // 上次计入资金费的结算时间
+}
+
+// FundingHarvester 资金费率套利策略（Delta中性）
+// 在永续资金费率极端为正时买入现货并做空等量永续，赚取资金费；
+// 资金费率回落后同时平掉两条腿
+type FundingHarvester struct {
+	config    FundingHarvestConfig
+	perp      Executor
+	spot      SpotExecutor
+	funding   FundingRateSource
+	ranker    FundingPercentileSource   // 历史分布百分位（未设置时不检查entry_percentile）
+	positions map[string]*CarryPosition // symbol -> 对冲持仓
+	store     CarryStore                // 持久化（未设置时只保存在内存中）
+	saved     map[string]CarryPosition  // 启动时读取的持久化记录，等待Restore按交易所持仓确认
+	clock     clock.Clock
+	mu        sync.Mutex
+}
+
+// NewFundingHarvester 创建资金费率套利策略
+func NewFundingHarvester(config FundingHarvestConfig, perp Executor, spot SpotExecutor, funding FundingRateSource) *FundingHarvester {
+	return &FundingHarvester{
+		config:    config,
+		perp:      perp,
+		spot:      spot,
+		funding:   funding,
+		positions: make(map[string]*CarryPosition),
+		clock:     clock.Real,
+	}
+}
+
+// Enabled 是否启用
+func (h *FundingHarvester) Enabled() bool {
+	return h != nil && h.config.Enabled
+}
+
+// SetPercentileSource 设置资金费率历史分布百分位数据源（用于entry_percentile开仓过滤）
+func (h *FundingHarvester) SetPercentileSource(ranker FundingPercentileSource) {
+	h.mu.Lock()
+	h.ranker = ranker
+	h.mu.Unlock()
+}
+
+// SetStore 设置对冲持仓的持久化并读取已保存的记录（在Restore之前调用；读取失败时仍会保存之后的变化）
+func (h *FundingHarvester) SetStore(store CarryStore) error {
+	positions, err := store.LoadCarry()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.store = store
+	if err != nil {
+		return err
+	}
+	h.saved = make(map[string]CarryPosition, len(positions))
+	for _, pos := range positions {
+		if pos.PerpContracts <= 0 {
+			// 永续腿已平、现货腿尚未卖出：交易所没有对应的永续持仓，直接恢复以继续卖出现货
+			pos := pos
+			h.positions[pos.Symbol] = &pos
+			continue
+		}
+		h.saved[pos.Symbol] = pos
+	}
+	return nil
+}
+
+// persist 保存对冲持仓（失败只记录日志，不影响交易）
+func (h *FundingHarvester) persist(pos *CarryPosition) {
+	if h.store == nil {
+		return
+	}
+	if err := h.store.SaveCarry(*pos); err != nil {
+		logger.Warn("保存对冲持仓失败", "symbol", pos.Symbol, "err", err)
+	}
+}
+
+// forget 删除已解除的对冲持仓记录
+func (h *FundingHarvester) forget(symbol string) {
+	if h.store == nil {
+		return
+	}
+	if err := h.store.DeleteCarry(symbol); err != nil {
+		logger.Warn("删除对冲持仓记录失败", "symbol", symbol, "err", err)
+	}
+}
+
+// SetConfig 更新套利配置（热加载）
+// 已持有对冲的币种即使从symbols中移除也继续跟踪，直到资金费率回落后正常解除对冲
+func (h *FundingHarvester) SetConfig(config FundingHarvestConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	symbols := append([]string(nil), config.Symbols...)
+	listed := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		listed[strings.ToUpper(symbol)] = true
+	}
+	for symbol := range h.positions {
+		if !listed[symbol] {
+			logger.Warn("币种已从配置移除，但仍持有对冲，继续跟踪至解除", "symbol", symbol)
+			symbols = append(symbols, symbol)
+		}
+	}
+	config.Symbols = symbols
+	h.config = config
+}
+
+// SetClock 设置时钟（回测使用模拟时钟，需在Evaluate之前调用）
+func (h *FundingHarvester) SetClock(c clock.Clock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clock = clock.Or(c)
+}
+
+// Evaluate 检查各币种资金费率，建立或解除对冲，返回执行日志
+func (h *FundingHarvester) Evaluate() []string {
+	if !h.Enabled() {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var logs []string
+	now := h.clock.Now()
+
+	for _, symbol := range h.config.Symbols {
+		symbol = strings.ToUpper(symbol)
+
+		rate, err := h.funding(symbol)
+		if err != nil {
+			logger.Warn("获取资金费率失败", "symbol", symbol, "err", err)
+			continue
+		}
+
+		if pos, exists := h.positions[symbol]; exists {
+			if h.accrueCarry(pos, rate, now) {
+				h.persist(pos)
+			}
+
+			// 资金费率回落，解除对冲（永续腿已平的对冲继续卖出现货，不再看费率）
+			if rate <= h.config.ExitFundingRate || pos.PerpContracts <= 0 {
+				logger.Info("资金费率回落，解除对冲", "symbol", symbol, "funding_rate", rate,
+					"exit_rate", h.config.ExitFundingRate, "net_carry", pos.NetCarry)
+				if err := h.unwind(pos); err != nil {
+					logs = append(logs, fmt.Sprintf("❌ 资金费率套利 %s 解除对冲失败: %v", symbol, err))
+					continue
+				}
+				delete(h.positions, symbol)
+				h.forget(symbol)
+				logs = append(logs, fmt.Sprintf("✓ 资金费率套利 %s 解除对冲，净资金费%.4f USDT", symbol, pos.NetCarry))
+			}
+			continue
+		}
+
+		// 资金费率极端为正，建立对冲
+		if rate >= h.config.EntryFundingRate && len(h.positions) < h.config.MaxPositions {
+			if !h.extremeVsHistory(symbol, rate) {
+				continue
+			}
+			logger.Info("资金费率达到阈值，建立现货多+永续空对冲", "symbol", symbol, "funding_rate", rate,
+				"entry_rate", h.config.EntryFundingRate)
+			pos, err := h.open(symbol, rate, now)
+			if err != nil {
+				logs = append(logs, fmt.Sprintf("❌ 资金费率套利 %s 建立对冲失败: %v", symbol, err))
+				continue
+			}
+			h.positions[symbol] = pos
+			h.persist(pos)
+			logs = append(logs, fmt.Sprintf("✓ 资金费率套利 %s 建立对冲: 现货%.6f / 永续空%.0f张",
+				symbol, pos.SpotQuantity, pos.PerpContracts))
+		}
+	}
+
+	return logs
+}
+
+// extremeVsHistory 费率是否处于历史分布的entry_percentile及以上（未配置时不检查，没有足够历史时不开仓）
+func (h *FundingHarvester) extremeVsHistory(symbol string, rate float64) bool {
+	if h.config.EntryPercentile <= 0 {
+		return true
+	}
+	if h.ranker == nil {
+		logger.Warn("未启用资金费率历史，无法检查entry_percentile，跳过开仓", "symbol", symbol)
+		return false
+	}
+	percentile, ok := h.ranker(symbol, rate)
+	if !ok {
+		logger.Info("资金费率历史不足，跳过开仓", "symbol", symbol, "funding_rate", rate)
+		return false
+	}
+	if percentile < h.config.EntryPercentile {
+		logger.Info("资金费率未达到历史分布阈值，跳过开仓", "symbol", symbol, "funding_rate", rate,
+			"percentile", percentile, "entry_percentile", h.config.EntryPercentile)
+		return false
+	}
+	return true
+}
+
+// open 建立对冲：先买现货，再按实际成交数量做空永续
+func (h *FundingHarvester) open(symbol string, rate float64, now time.Time) (*CarryPosition, error) {
+	multiplier, err := h.spot.GetContractMultiplier(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	spotQuantity, err := h.spot.SpotBuy(symbol, h.config.NotionalUSD)
+	if err != nil {
+		return nil, err
+	}
+
+	// 永续空头张数 = 现货数量 / 合约乘数（向下取整，避免空头多于现货）
+	contracts := math.Floor(spotQuantity / multiplier)
+	if contracts < 1 {
+		// 不足一张合约，回滚现货腿
+		if sellErr := h.spot.SpotSell(symbol, spotQuantity); sellErr != nil {
+			logger.Error("回滚现货腿失败", "symbol", symbol, "quantity", spotQuantity, "err", sellErr)
+		}
+		return nil, fmt.Errorf("现货数量%.6f不足一张合约（乘数%.6f）", spotQuantity, multiplier)
+	}
+
+	order, err := h.perp.OpenShort(symbol, contracts, 1)
+	if err != nil {
+		// 永续腿失败，回滚现货腿以保持Delta中性
+		if sellErr := h.spot.SpotSell(symbol, spotQuantity); sellErr != nil {
+			logger.Error("回滚现货腿失败", "symbol", symbol, "quantity", spotQuantity, "err", sellErr)
+		}
+		return nil, fmt.Errorf("做空永续失败: %w", err)
+	}
+	// 部分成交时按实际张数记录（平仓时只平实际持有的空头）
+	contracts, _ = actualFill(order, contracts, 0)
+
+	// 卖出空头未覆盖的现货（张数向下取整的零头、永续部分成交），保持Delta中性
+	if excess := spotQuantity - contracts*multiplier; excess > spotQuantity*1e-9 {
+		if err := h.spot.SpotSell(symbol, excess); err != nil {
+			logger.Warn("卖出未对冲的现货失败，保留在对冲持仓中", "symbol", symbol, "quantity", excess, "err", err)
+		} else {
+			spotQuantity -= excess
+		}
+	}
+
+	return &CarryPosition{
+		Symbol:        symbol,
+		SpotQuantity:  spotQuantity,
+		PerpContracts: contracts,
+		EntryNotional: h.config.NotionalUSD,
+		EntryFunding:  rate,
+		OpenTime:      now,
+		LastSettle:    lastFundingSettle(now, h.config.FundingIntervalH),
+	}, nil
+}
+
+// unwind 解除对冲：先平永续空头，再卖出现货
+// 每条腿完成后更新并保存持仓，失败时下次只重试未完成的腿
+func (h *FundingHarvester) unwind(pos *CarryPosition) error {
+	if pos.PerpContracts > 0 {
+		order, err := h.perp.CloseShort(pos.Symbol, pos.PerpContracts)
+		if err != nil {
+			return fmt.Errorf("平永续空头失败: %w", err)
+		}
+		closed, _ := actualFill(order, pos.PerpContracts, 0)
+		pos.PerpContracts = math.Max(pos.PerpContracts-closed, 0)
+		h.persist(pos)
+		if pos.PerpContracts > 0 {
+			return fmt.Errorf("永续空头部分平仓，剩余%.0f张", pos.PerpContracts)
+		}
+	}
+	if pos.SpotQuantity > 0 {
+		if err := h.spot.SpotSell(pos.Symbol, pos.SpotQuantity); err != nil {
+			return fmt.Errorf("卖出现货失败（永续已平仓，下次继续卖出）: %w", err)
+		}
+		pos.SpotQuantity = 0
+	}
+	return nil
+}
+
+// accrueCarry 按经过的结算次数估算资金费收入（空头在正费率时收取资金费），返回是否有新的结算
+func (h *FundingHarvester) accrueCarry(pos *CarryPosition, rate float64, now time.Time) bool {
+	settle := lastFundingSettle(now, h.config.FundingIntervalH)
+	interval := time.Duration(h.config.FundingIntervalH) * time.Hour
+	accrued := false
+	for pos.LastSettle.Before(settle) {
+		pos.LastSettle = pos.LastSettle.Add(interval)
+		if pos.PerpContracts > 0 {
+			pos.NetCarry += pos.EntryNotional * rate
+		}
+		accrued = true
+	}
+	return accrued
+}
+
+// Restore 恢复重启前的对冲持仓
+// 有保存的记录时沿用记录中的现货数量和累计资金费（永续张数以交易所持仓为准），
+// 没有记录时现货数量按永续张数×合约乘数估算
+func (h *FundingHarvester) Restore(symbol string, contracts, entryPrice float64, openTime time.Time) error {
+	if !h.Enabled() {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if saved, ok := h.saved[symbol]; ok {
+		delete(h.saved, symbol)
+		if saved.PerpContracts != contracts {
+			logger.Warn("永续空头张数与保存的对冲记录不一致，以交易所持仓为准", "symbol", symbol,
+				"saved", saved.PerpContracts, "exchange", contracts)
+		}
+		saved.PerpContracts = contracts
+		if saved.LastSettle.IsZero() {
+			saved.LastSettle = lastFundingSettle(h.clock.Now(), h.config.FundingIntervalH)
+		}
+		h.positions[symbol] = &saved
+		h.persist(&saved)
+		return nil
+	}
+
+	multiplier, err := h.spot.GetContractMultiplier(symbol)
+	if err != nil {
+		return err
+	}
+
+	h.positions[symbol] = &CarryPosition{
+		Symbol:        symbol,
+		SpotQuantity:  contracts * multiplier,
+		PerpContracts: contracts,
+		EntryNotional: contracts * multiplier * entryPrice,
+		OpenTime:      openTime,
+		LastSettle:    lastFundingSettle(h.clock.Now(), h.config.FundingIntervalH),
+	}
+	h.persist(h.positions[symbol])
+	return nil
+}
+
+// GetPositions 获取当前所有对冲持仓（用于API展示）
+func (h *FundingHarvester) GetPositions() []CarryPosition {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]CarryPosition, 0, len(h.positions))
+	for _, pos := range h.positions {
+		result = append(result, *pos)
+	}
+	return result
+}
+
+// lastFundingSettle 计算最近一次资金费结算时间（UTC整点，按间隔对齐）
+func lastFundingSettle(now time.Time, intervalHours int) time.Time {
+	utc := now.UTC()
+	hour := utc.Hour() - utc.Hour()%intervalHours
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), hour, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,495 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hedgeLegGrace 建立对冲后多久才检查两条腿是否仍在（交易器持仓缓存约15秒）
+const hedgeLegGrace = time.Minute
+
+// hedgeMinReturns 计算beta所需的最少收益率样本数
+const hedgeMinReturns = 20
+
+// hedgeIntervals 计算beta支持的K线周期
+var hedgeIntervals = map[string]bool{"15m": true, "1h": true, "4h": true}
+
+// CloseSource 最近limit根已收盘K线的收盘价（从旧到新）
+type CloseSource func(symbol, interval string, limit int) ([]float64, error)
+
+// MultiplierSource 合约乘数数据源（每张合约对应的基础币数量）
+type MultiplierSource func(symbol string) (float64, error)
+
+// HedgeConfig 相关性对冲配置：用相关合约的反向头寸对冲持仓的方向性风险
+type HedgeConfig struct {
+	Enabled    bool    `json:"enabled"`    // 是否启用对冲
+	Instrument string  `json:"instrument"` // 对冲合约（默认ETHUSDT）
+	Interval   string  `json:"interval"`   // 计算beta的K线周期（15m/1h/4h，默认1h）
+	Lookback   int     `json:"lookback"`   // 计算beta的K线数量（默认168）
+	MaxBeta    float64 `json:"max_beta"`   // beta上限（默认3，避免低波动对冲合约被过度放大）
+	MaxRatio   float64 `json:"max_ratio"`  // 对冲比例上限（默认1.5）
+	Leverage   int     `json:"leverage"`   // 对冲腿杠杆（默认1）
+}
+
+// Validate 验证对冲配置
+func (c *HedgeConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	c.Instrument = strings.ToUpper(strings.TrimSpace(c.Instrument))
+	if c.Instrument == "" {
+		c.Instrument = "ETHUSDT"
+	}
+	if c.Interval == "" {
+		c.Interval = "1h"
+	}
+	if !hedgeIntervals[c.Interval] {
+		return fmt.Errorf("hedge.interval不支持%q（可选: 15m/1h/4h）", c.Interval)
+	}
+	if c.Lookback <= 0 {
+		c.Lookback = 168
+	}
+	if c.Lookback <= hedgeMinReturns {
+		return fmt.Errorf("hedge.lookback必须大于%d", hedgeMinReturns)
+	}
+	if c.MaxBeta < 0 || c.MaxRatio < 0 {
+		return fmt.Errorf("hedge.max_beta和hedge.max_ratio不能为负数")
+	}
+	if c.MaxBeta == 0 {
+		c.MaxBeta = 3
+	}
+	if c.MaxRatio == 0 {
+		c.MaxRatio = 1.5
+	}
+	if c.Leverage <= 0 {
+		c.Leverage = 1
+	}
+	return nil
+}
+
+// HedgePair 一组持仓+对冲腿（对冲腿张数按beta×比例×持仓名义价值计算，与持仓作为一组管理和解除）
+type HedgePair struct {
+	Symbol      string    `json:"symbol"`       // 被对冲的持仓
+	Side        string    `json:"side"`         // 被对冲持仓的方向
+	Quantity    float64   `json:"quantity"`     // 建立对冲时的持仓数量（持仓减少时按比例减少对冲腿，0表示重启后未知）
+	Instrument  string    `json:"instrument"`   // 对冲合约
+	HedgeSide   string    `json:"hedge_side"`   // 对冲腿方向（与持仓相反）
+	Contracts   float64   `json:"contracts"`    // 对冲腿张数
+	Beta        float64   `json:"beta"`         // 持仓相对对冲合约的beta（重启后恢复的为0）
+	Correlation float64   `json:"correlation"`  // 收益率相关系数
+	Ratio       float64   `json:"ratio"`        // 对冲比例（1表示完全按beta对冲）
+	NotionalUSD float64   `json:"notional_usd"` // 建立对冲时的持仓名义价值
+	HedgeUSD    float64   `json:"hedge_usd"`    // 对冲腿名义价值
+	OpenTime    time.Time `json:"open_time"`
+}
+
+// HedgeManager 相关性对冲：为指定持仓在相关合约上建立反向头寸（如多头山寨币组合对冲做空ETH），
+// 按存储的K线计算beta确定对冲数量；同一对冲合约上的多组对冲合并为一个交易所头寸，按组分别跟踪。
+// 持仓平仓时解除对应的对冲腿，持仓减少时按比例减少对冲腿，对冲腿在交易所消失时停止跟踪
+type HedgeManager struct {
+	config     HedgeConfig
+	executor   func(symbol string) Executor // 按被对冲持仓归因的执行器
+	price      PriceSource
+	closes     CloseSource
+	multiplier MultiplierSource // 为nil时按1张=1个基础币计算
+	pairs      map[string]*HedgePair
+	mu         sync.Mutex
+}
+
+// NewHedgeManager 创建对冲管理器
+func NewHedgeManager(config HedgeConfig, executor func(symbol string) Executor, price PriceSource, closes CloseSource,
+	multiplier MultiplierSource) *HedgeManager {
+	return &HedgeManager{
+		config:     config,
+		executor:   executor,
+		price:      price,
+		closes:     closes,
+		multiplier: multiplier,
+		pairs:      make(map[string]*HedgePair),
+	}
+}
+
+// Enabled 是否启用
+func (m *HedgeManager) Enabled() bool {
+	return m != nil && m.config.Enabled
+}
+
+// SetConfig 更新对冲配置（热加载），已建立的对冲继续在原对冲合约上跟踪直到解除
+func (m *HedgeManager) SetConfig(config HedgeConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = config
+}
+
+// Beta 按最近lookback根已收盘K线的对数收益率计算symbol相对对冲合约的beta和相关系数
+func (m *HedgeManager) Beta(symbol string) (beta, correlation float64, err error) {
+	m.mu.Lock()
+	config := m.config
+	m.mu.Unlock()
+	return m.beta(strings.ToUpper(symbol), config)
+}
+
+func (m *HedgeManager) beta(symbol string, config HedgeConfig) (float64, float64, error) {
+	base, err := m.closes(symbol, config.Interval, config.Lookback)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取%s K线失败: %w", symbol, err)
+	}
+	hedge, err := m.closes(config.Instrument, config.Interval, config.Lookback)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取%s K线失败: %w", config.Instrument, err)
+	}
+	// 两个序列都取最近的收盘价，按末尾对齐
+	n := min(len(base), len(hedge))
+	baseReturns, hedgeReturns := logReturns(base[len(base)-n:]), logReturns(hedge[len(hedge)-n:])
+	if len(baseReturns) < hedgeMinReturns {
+		return 0, 0, fmt.Errorf("%s K线不足（%d根收益率，至少需要%d根）", symbol, len(baseReturns), hedgeMinReturns)
+	}
+	return regressBeta(baseReturns, hedgeReturns)
+}
+
+// logReturns 相邻收盘价的对数收益率（价格无效时返回nil）
+func logReturns(closes []float64) []float64 {
+	if len(closes) < 2 {
+		return nil
+	}
+	returns := make([]float64, 0, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] <= 0 || closes[i] <= 0 {
+			return nil
+		}
+		returns = append(returns, math.Log(closes[i]/closes[i-1]))
+	}
+	return returns
+}
+
+// regressBeta beta = cov(持仓, 对冲合约) / var(对冲合约)，同时返回相关系数
+func regressBeta(base, hedge []float64) (float64, float64, error) {
+	n := float64(len(base))
+	var meanBase, meanHedge float64
+	for i := range base {
+		meanBase += base[i]
+		meanHedge += hedge[i]
+	}
+	meanBase /= n
+	meanHedge /= n
+
+	var cov, varBase, varHedge float64
+	for i := range base {
+		db, dh := base[i]-meanBase, hedge[i]-meanHedge
+		cov += db * dh
+		varBase += db * db
+		varHedge += dh * dh
+	}
+	if varHedge == 0 || varBase == 0 {
+		return 0, 0, fmt.Errorf("价格没有波动，无法计算beta")
+	}
+	return cov / varHedge, cov / math.Sqrt(varBase*varHedge), nil
+}
+
+// Hedge 为symbol的持仓在对冲合约上建立反向头寸，名义价值 = 持仓名义价值 × beta × ratio
+func (m *HedgeManager) Hedge(symbol string, ratio float64) (*HedgePair, error) {
+	if !m.Enabled() {
+		return nil, fmt.Errorf("未启用hedge")
+	}
+	symbol = strings.ToUpper(symbol)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	config := m.config
+	switch {
+	case ratio <= 0 || ratio > config.MaxRatio:
+		return nil, fmt.Errorf("对冲比例必须在(0, %.2f]之间", config.MaxRatio)
+	case symbol == config.Instrument:
+		return nil, fmt.Errorf("%s 是对冲合约本身，不能对冲", symbol)
+	case m.pairs[symbol] != nil:
+		return nil, fmt.Errorf("%s 已有对冲，请先解除", symbol)
+	}
+
+	positions, err := m.executor(symbol).GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var base map[string]interface{}
+	for _, pos := range positions {
+		if posSymbol, _ := pos["symbol"].(string); strings.EqualFold(posSymbol, symbol) {
+			base = pos
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("%s 没有持仓", symbol)
+	}
+	side, _ := base["side"].(string)
+	hedgeSide := hedgeSideFor(side)
+	for _, pair := range m.pairs {
+		if pair.Instrument == config.Instrument && pair.HedgeSide != hedgeSide {
+			return nil, fmt.Errorf("%s 上已有反向的对冲腿（%s），不能同时持有两个方向", config.Instrument, pair.HedgeSide)
+		}
+	}
+	for _, pos := range positions {
+		posSymbol, _ := pos["symbol"].(string)
+		posSide, _ := pos["side"].(string)
+		if strings.EqualFold(posSymbol, config.Instrument) && posSide != hedgeSide {
+			return nil, fmt.Errorf("%s 已有%s持仓，不能在同一合约上建立反向的对冲腿", config.Instrument, posSide)
+		}
+	}
+
+	beta, correlation, err := m.beta(symbol, config)
+	if err != nil {
+		return nil, err
+	}
+	if beta <= 0 {
+		return nil, fmt.Errorf("%s 与 %s 负相关或不相关（beta=%.2f），反向头寸无法对冲", symbol, config.Instrument, beta)
+	}
+	beta = math.Min(beta, config.MaxBeta)
+
+	quantity, _ := base["positionAmt"].(float64)
+	notional, err := m.notional(symbol, quantity, base)
+	if err != nil {
+		return nil, err
+	}
+	hedgePrice, err := m.price(config.Instrument)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s价格失败: %w", config.Instrument, err)
+	}
+	hedgeMultiplier, err := m.contractMultiplier(config.Instrument)
+	if err != nil {
+		return nil, err
+	}
+	hedgeUSD := notional * beta * ratio
+	contracts := math.Floor(hedgeUSD / (hedgePrice * hedgeMultiplier))
+	if contracts < 1 {
+		return nil, fmt.Errorf("对冲名义价值%.2f USDT不足一张%s合约", hedgeUSD, config.Instrument)
+	}
+
+	exec := m.executor(symbol)
+	var order map[string]interface{}
+	if hedgeSide == "short" {
+		order, err = exec.OpenShort(config.Instrument, contracts, config.Leverage)
+	} else {
+		order, err = exec.OpenLong(config.Instrument, contracts, config.Leverage)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("建立对冲腿失败: %w", err)
+	}
+	// 部分成交时按实际张数记录（解除时只平实际持有的部分）
+	contracts, hedgePrice = actualFill(order, contracts, hedgePrice)
+
+	pair := &HedgePair{
+		Symbol:      symbol,
+		Side:        side,
+		Quantity:    quantity,
+		Instrument:  config.Instrument,
+		HedgeSide:   hedgeSide,
+		Contracts:   contracts,
+		Beta:        beta,
+		Correlation: correlation,
+		Ratio:       ratio,
+		NotionalUSD: notional,
+		HedgeUSD:    contracts * hedgePrice * hedgeMultiplier,
+		OpenTime:    time.Now(),
+	}
+	m.pairs[symbol] = pair
+	logger.Info("已建立对冲", "symbol", symbol, "instrument", pair.Instrument, "hedge_side", hedgeSide,
+		"contracts", contracts, "beta", beta, "ratio", ratio)
+	return pair, nil
+}
+
+// Unhedge 解除symbol的对冲（平掉对应的对冲腿张数）
+func (m *HedgeManager) Unhedge(symbol string) (*HedgePair, error) {
+	if m == nil {
+		return nil, fmt.Errorf("未启用hedge")
+	}
+	symbol = strings.ToUpper(symbol)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pair, ok := m.pairs[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%s 没有对冲", symbol)
+	}
+	if err := m.reduce(pair, pair.Contracts); err != nil {
+		return nil, err
+	}
+	delete(m.pairs, symbol)
+	logger.Info("已解除对冲", "symbol", symbol, "instrument", pair.Instrument, "contracts", pair.Contracts)
+	return pair, nil
+}
+
+// Evaluate 检查各组对冲：持仓已平仓时解除对冲腿，持仓减少时按比例减少对冲腿，对冲腿消失时停止跟踪，返回执行日志
+func (m *HedgeManager) Evaluate() []string {
+	if !m.Enabled() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pairs) == 0 {
+		return nil
+	}
+	positions, err := m.executor("").GetPositions()
+	if err != nil {
+		logger.Warn("获取持仓失败，跳过对冲检查", "err", err)
+		return nil
+	}
+	held := make(map[string]float64)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity, _ := pos["positionAmt"].(float64)
+		held[strings.ToUpper(symbol)+"_"+side] = quantity
+	}
+
+	var logs []string
+	now := time.Now()
+	for _, symbol := range m.symbols() {
+		pair := m.pairs[symbol]
+		if now.Sub(pair.OpenTime) < hedgeLegGrace {
+			continue
+		}
+		if held[pair.Instrument+"_"+pair.HedgeSide] <= 0 {
+			// 对冲腿已不存在（被强平或人工平仓），持仓失去对冲
+			delete(m.pairs, symbol)
+			logs = append(logs, fmt.Sprintf("⚠ 对冲 %s 的对冲腿（%s %s）已不存在，持仓已失去对冲", symbol, pair.Instrument, pair.HedgeSide))
+			continue
+		}
+
+		quantity := held[symbol+"_"+pair.Side]
+		switch {
+		case quantity <= 0:
+			logger.Info("持仓已平仓，解除对冲", "symbol", symbol, "instrument", pair.Instrument, "contracts", pair.Contracts)
+			if err := m.reduce(pair, pair.Contracts); err != nil {
+				logs = append(logs, fmt.Sprintf("❌ 对冲 %s 持仓已平仓，解除对冲腿失败: %v", symbol, err))
+				continue
+			}
+			delete(m.pairs, symbol)
+			logs = append(logs, fmt.Sprintf("✓ 对冲 %s 持仓已平仓，已平掉对冲腿 %s %s %.0f张", symbol, pair.Instrument, pair.HedgeSide, pair.Contracts))
+		case pair.Quantity == 0:
+			// 重启后恢复的对冲不知道建立时的持仓数量，以当前数量为准
+			pair.Quantity = quantity
+		case quantity < pair.Quantity:
+			excess := math.Floor(pair.Contracts - pair.Contracts*quantity/pair.Quantity)
+			if excess < 1 {
+				continue
+			}
+			if err := m.reduce(pair, excess); err != nil {
+				logs = append(logs, fmt.Sprintf("❌ 对冲 %s 持仓减少，减少对冲腿失败: %v", symbol, err))
+				continue
+			}
+			logs = append(logs, fmt.Sprintf("✓ 对冲 %s 持仓减少 %.6g → %.6g，对冲腿减少%.0f张（剩余%.0f张）",
+				symbol, pair.Quantity, quantity, excess, pair.Contracts-excess))
+			pair.Contracts -= excess
+			pair.Quantity = quantity
+		}
+	}
+	return logs
+}
+
+// reduce 平掉对冲腿的contracts张（对冲腿只剩这些张数时全部平仓）
+func (m *HedgeManager) reduce(pair *HedgePair, contracts float64) error {
+	exec := m.executor(pair.Symbol)
+	var err error
+	if pair.HedgeSide == "short" {
+		_, err = exec.CloseShort(pair.Instrument, contracts)
+	} else {
+		_, err = exec.CloseLong(pair.Instrument, contracts)
+	}
+	if err != nil {
+		return fmt.Errorf("平对冲腿 %s %s 失败: %w", pair.Instrument, pair.HedgeSide, err)
+	}
+	return nil
+}
+
+// notional 持仓名义价值（优先使用标记价格）
+func (m *HedgeManager) notional(symbol string, quantity float64, pos map[string]interface{}) (float64, error) {
+	price, _ := pos["markPrice"].(float64)
+	if price <= 0 {
+		var err error
+		if price, err = m.price(symbol); err != nil {
+			return 0, fmt.Errorf("获取%s价格失败: %w", symbol, err)
+		}
+	}
+	multiplier, err := m.contractMultiplier(symbol)
+	if err != nil {
+		return 0, err
+	}
+	notional := quantity * multiplier * price
+	if notional <= 0 {
+		return 0, fmt.Errorf("%s 持仓名义价值无效（数量%.6g，价格%.6g）", symbol, quantity, price)
+	}
+	return notional, nil
+}
+
+// contractMultiplier 合约乘数（交易器不支持查询时按1计算）
+func (m *HedgeManager) contractMultiplier(symbol string) (float64, error) {
+	if m.multiplier == nil {
+		return 1, nil
+	}
+	multiplier, err := m.multiplier(symbol)
+	if err != nil {
+		return 0, fmt.Errorf("获取%s合约乘数失败: %w", symbol, err)
+	}
+	if multiplier <= 0 {
+		return 1, nil
+	}
+	return multiplier, nil
+}
+
+// symbols 已对冲的币种（排序后遍历，日志顺序稳定）
+func (m *HedgeManager) symbols() []string {
+	symbols := make([]string, 0, len(m.pairs))
+	for symbol := range m.pairs {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// hedgeSideFor 对冲腿方向（与持仓相反）
+func hedgeSideFor(side string) string {
+	if side == "short" {
+		return "long"
+	}
+	return "short"
+}
+
+// Restore 恢复重启前的对冲（beta和建立时的持仓数量未知记为0，持仓数量在下一次检查时按当前持仓补齐）
+func (m *HedgeManager) Restore(symbol, instrument, hedgeSide string, contracts float64, openTime time.Time) {
+	if !m.Enabled() || contracts <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pairs[symbol] = &HedgePair{
+		Symbol:     symbol,
+		Side:       hedgeSideFor(hedgeSide),
+		Instrument: instrument,
+		HedgeSide:  hedgeSide,
+		Contracts:  contracts,
+		OpenTime:   openTime,
+	}
+}
+
+// GetPairs 获取当前所有对冲（按币种排序，用于API展示）
+func (m *HedgeManager) GetPairs() []HedgePair {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]HedgePair, 0, len(m.pairs))
+	for _, symbol := range m.symbols() {
+		result = append(result, *m.pairs[symbol])
+	}
+	return result
+}
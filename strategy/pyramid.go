@@ -0,0 +1,250 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+	"nofx/risk"
+	"strings"
+	"sync"
+)
+
+// PyramidConfig 顺势加仓（金字塔）配置
+//
+// R为首仓的每单位初始风险（|入场价-初始止损|）。价格每朝有利方向移动 step_r 个R（相对上次加仓价）加仓一次，
+// 加仓后总体止损上移到最新加仓价反向 stop_r 个R处（不会放宽），且加仓数量受限于：
+// 总持仓在新止损处的亏损不超过首仓的初始风险
+type PyramidConfig struct {
+	Enabled      bool    `json:"enabled"`        // 是否启用顺势加仓
+	StepR        float64 `json:"step_r"`         // 每盈利多少个R加仓一次（默认1）
+	AddSizeRatio float64 `json:"add_size_ratio"` // 每次加仓数量占首仓的比例（默认0.5）
+	MaxAdds      int     `json:"max_adds"`       // 最大加仓次数（硬上限）
+	StopR        float64 `json:"stop_r"`         // 加仓后总体止损距最新加仓价的R倍数（默认1）
+}
+
+// Validate 验证顺势加仓配置并填充默认值
+func (c *PyramidConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.StepR == 0 {
+		c.StepR = 1
+	}
+	if c.AddSizeRatio == 0 {
+		c.AddSizeRatio = 0.5
+	}
+	if c.StopR == 0 {
+		c.StopR = 1
+	}
+	if c.StepR < 0 || c.AddSizeRatio < 0 || c.StopR < 0 {
+		return fmt.Errorf("pyramid.step_r/add_size_ratio/stop_r不能为负数")
+	}
+	if c.MaxAdds <= 0 {
+		return fmt.Errorf("pyramid.max_adds必须大于0")
+	}
+	return nil
+}
+
+// pyramidState 单个持仓的顺势加仓状态
+type pyramidState struct {
+	baseQuantity float64 // 首仓数量（加仓数量和风险预算以此为基数）
+	risk         float64 // 每单位初始风险R
+	adds         int     // 已加仓次数
+	lastAddPrice float64 // 上次建仓/加仓价格
+	stopPrice    float64 // 当前总体止损价
+	takeProfit   float64 // 止盈价（加仓会撤销委托单，需要重新挂出；0表示无）
+	seen         bool    // 是否已出现在持仓列表中（交易器持仓缓存可能尚未包含刚开的仓）
+}
+
+// PyramidManager 顺势加仓管理器
+// 只管理设置了初始止损的持仓（由Track/Restore登记），与DCA加仓互斥
+type PyramidManager struct {
+	config PyramidConfig
+	limits ExposureLimitsFunc
+	states map[string]*pyramidState // symbol_side -> 状态
+	mu     sync.Mutex
+}
+
+// NewPyramidManager 创建顺势加仓管理器（limits为nil时使用risk.DefaultExposureLimits）
+func NewPyramidManager(config PyramidConfig, limits ExposureLimitsFunc) *PyramidManager {
+	if limits == nil {
+		limits = func(string) risk.ExposureLimits { return risk.DefaultExposureLimits() }
+	}
+	return &PyramidManager{
+		config: config,
+		limits: limits,
+		states: make(map[string]*pyramidState),
+	}
+}
+
+// Enabled 是否启用
+func (m *PyramidManager) Enabled() bool {
+	return m != nil && m.config.Enabled
+}
+
+// SetConfig 更新配置（热加载），保留各持仓已加仓次数等状态
+func (m *PyramidManager) SetConfig(config PyramidConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = config
+}
+
+// Track 登记新开的持仓（初始止损无效时不登记，无法计算R）
+func (m *PyramidManager) Track(symbol, side string, quantity, entryPrice, stopLoss, takeProfit float64) {
+	m.Restore(symbol, side, quantity, entryPrice, stopLoss, takeProfit, 0, entryPrice)
+}
+
+// Restore 恢复持仓的顺势加仓状态（重启后从交易日志恢复首仓、初始止损和已加仓次数）
+func (m *PyramidManager) Restore(symbol, side string, baseQuantity, entryPrice, initialStop, takeProfit float64,
+	adds int, lastAddPrice float64) {
+
+	riskPerUnit := favorableMove(side, initialStop, entryPrice)
+	if baseQuantity <= 0 || riskPerUnit <= 0 {
+		return
+	}
+	stopPrice := initialStop
+	if adds > 0 {
+		stopPrice = m.trailStop(side, lastAddPrice, riskPerUnit, initialStop)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[symbol+"_"+side] = &pyramidState{
+		baseQuantity: baseQuantity,
+		risk:         riskPerUnit,
+		adds:         adds,
+		lastAddPrice: lastAddPrice,
+		stopPrice:    stopPrice,
+		takeProfit:   takeProfit,
+	}
+}
+
+// Evaluate 检查所有已登记的持仓并执行顺势加仓，返回执行日志
+func (m *PyramidManager) Evaluate(executor Executor, snapshot *market.MarketSnapshot) []string {
+	if !m.Enabled() {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var logs []string
+
+	// 所有持仓总名义价值（用于总敞口检查，按合约乘数换算）
+	var totalValue float64
+	for _, pos := range snapshot.Positions {
+		totalValue += pos.Notional()
+	}
+	equity := snapshot.Account.Equity
+
+	currentKeys := make(map[string]bool)
+	for _, pos := range snapshot.Positions {
+		symbol, side := pos.Symbol, pos.Side
+		quantity, entryPrice, markPrice := pos.Quantity, pos.EntryPrice, pos.MarkPrice
+		if symbol == "" || quantity == 0 || entryPrice <= 0 || markPrice <= 0 {
+			continue
+		}
+
+		posKey := symbol + "_" + side
+		currentKeys[posKey] = true
+
+		state, exists := m.states[posKey]
+		if !exists {
+			continue
+		}
+		state.seen = true
+		if state.adds >= m.config.MaxAdds {
+			continue
+		}
+
+		// 1. 加仓档位：相对上次加仓价的有利波动（R倍数）
+		favorable := favorableMove(side, state.lastAddPrice, markPrice)
+		if favorable < m.config.StepR*state.risk {
+			continue
+		}
+
+		// 2. 新的总体止损
+		stopPrice := m.trailStop(side, markPrice, state.risk, state.stopPrice)
+
+		// 3. 加仓数量：总持仓在新止损处的亏损不超过首仓初始风险（原持仓已锁定的利润可用于加仓）
+		addQuantity := state.baseQuantity * m.config.AddSizeRatio
+		budget := state.baseQuantity*state.risk - quantity*favorableMove(side, stopPrice, entryPrice)
+		if addLoss := favorableMove(side, stopPrice, markPrice); addLoss > 0 {
+			addQuantity = math.Min(addQuantity, budget/addLoss)
+		}
+		if addQuantity <= 0 {
+			logs = append(logs, fmt.Sprintf("⚠ 顺势加仓 %s %s 跳过: 风险预算已用完", symbol, side))
+			continue
+		}
+
+		addValue := pos.ContractValue(addQuantity)
+		symbolValue := pos.Notional()
+		if err := m.limits(symbol).CheckAdd(symbol, symbolValue, totalValue, addValue, equity); err != nil {
+			logger.Warn("顺势加仓被风控拒绝", "symbol", symbol, "side", side, "err", err)
+			logs = append(logs, fmt.Sprintf("⚠ 顺势加仓 %s %s 被风控拒绝: %v", symbol, side, err))
+			continue
+		}
+
+		leverage := pos.Leverage
+
+		logger.Info("顺势加仓", "symbol", symbol, "side", side, "add", state.adds+1, "max_adds", m.config.MaxAdds,
+			"favorable_r", favorable/state.risk, "quantity", addQuantity, "value_usdt", addValue, "stop_price", stopPrice)
+
+		var order map[string]interface{}
+		var err error
+		if side == "long" {
+			order, err = executor.OpenLong(symbol, addQuantity, leverage)
+		} else {
+			order, err = executor.OpenShort(symbol, addQuantity, leverage)
+		}
+		if err != nil {
+			logs = append(logs, fmt.Sprintf("❌ 顺势加仓 %s %s 失败: %v", symbol, side, err))
+			continue
+		}
+		addQuantity, fillPrice := actualFill(order, addQuantity, markPrice)
+
+		state.adds++
+		state.lastAddPrice = fillPrice
+		state.stopPrice = stopPrice
+		totalValue += addValue
+		logs = append(logs, fmt.Sprintf("✓ 顺势加仓 %s %s #%d 成功，总体止损 %.4f", symbol, side, state.adds, stopPrice))
+
+		// 开仓会撤销该币种已有的委托单，按总数量重新挂总体止损和止盈
+		newQuantity := quantity + addQuantity
+		if err := executor.SetStopLoss(symbol, strings.ToUpper(side), newQuantity, stopPrice); err != nil {
+			logger.Warn("设置顺势加仓总体止损失败", "symbol", symbol, "side", side, "stop_price", stopPrice, "err", err)
+			logs = append(logs, fmt.Sprintf("❌ 顺势加仓总体止损 %s %s 设置失败: %v", symbol, side, err))
+		}
+		if state.takeProfit > 0 {
+			if err := executor.SetTakeProfit(symbol, strings.ToUpper(side), newQuantity, state.takeProfit); err != nil {
+				logger.Warn("重新设置止盈失败", "symbol", symbol, "side", side, "take_profit", state.takeProfit, "err", err)
+				logs = append(logs, fmt.Sprintf("⚠ 顺势加仓后止盈 %s %s 设置失败: %v", symbol, side, err))
+			}
+		}
+	}
+
+	// 清理已平仓的状态
+	for key, state := range m.states {
+		if state.seen && !currentKeys[key] {
+			delete(m.states, key)
+		}
+	}
+
+	return logs
+}
+
+// trailStop 加仓后的总体止损：加仓价反向stop_r个R，不放宽当前止损
+func (m *PyramidManager) trailStop(side string, addPrice, riskPerUnit, currentStop float64) float64 {
+	if side == "long" {
+		return math.Max(addPrice-m.config.StopR*riskPerUnit, currentStop)
+	}
+	return math.Min(addPrice+m.config.StopR*riskPerUnit, currentStop)
+}
+
+// favorableMove 从from到to的有利价格变动（多仓上涨、空仓下跌为正）
+func favorableMove(side string, from, to float64) float64 {
+	if side == "long" {
+		return to - from
+	}
+	return from - to
+}
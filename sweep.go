@@ -0,0 +1,235 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"nofx/backtest"
+	"nofx/config"
+	"nofx/logging"
+	"nofx/market"
+	"nofx/report"
+	"nofx/store"
+	"nofx/strategy"
+	"nofx/trader"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSweepConfig 未指定--trader时的基础参数（网格中未扫描的维度取这些值）
+var defaultSweepConfig = strategy.FundingHarvestConfig{
+	EntryFundingRate: 0.0005,
+	ExitFundingRate:  0.0001,
+	NotionalUSD:      1000,
+	MaxPositions:     1,
+	FundingIntervalH: 8,
+}
+
+// runSweepCommand 按历史资金费率对资金费率套利做参数网格回测，输出按净盈亏排名的结果表
+// 历史资金费率先回填到交易日志（回填失败时使用已记录的历史），每组参数在独立的goroutine中回放
+func runSweepCommand(args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	configFile := fs.String("config", config.DefaultFile(), "配置文件")
+	traderID := fs.String("trader", "", "以该trader的funding_harvest配置为基础参数")
+	days := fs.Int("days", 90, "回测窗口（天）")
+	entry := fs.String("entry", "", "开仓费率，逗号分隔（如0.0003,0.0005,0.001）")
+	exit := fs.String("exit", "", "平仓费率，逗号分隔")
+	notional := fs.String("notional", "", "每个币种的名义价值（USDT），逗号分隔")
+	maxPositions := fs.String("max-positions", "", "同时持有的最大对冲数量，逗号分隔")
+	percentile := fs.String("percentile", "", "entry_percentile，逗号分隔（0表示不检查）")
+	perpFee := fs.Float64("perp-fee", 0.05, "永续吃单手续费率（%）")
+	spotFee := fs.Float64("spot-fee", 0.2, "现货吃单手续费率（%）")
+	historyDays := fs.Int("history-days", 90, "entry_percentile的历史分布窗口（天）")
+	workers := fs.Int("workers", 0, "并行回测数（默认CPU数）")
+	top := fs.Int("top", 20, "输出前N名（0输出全部）")
+	csvFile := fs.String("csv", "", "将完整排名表写入CSV文件")
+	args, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if *days < 7 || *days > 365 {
+		return fmt.Errorf("--days必须在7-365之间: %d", *days)
+	}
+
+	base := defaultSweepConfig
+	cfg, cfgErr := config.LoadConfig(*configFile)
+	if *traderID != "" {
+		if cfgErr != nil {
+			return fmt.Errorf("加载配置失败: %w", cfgErr)
+		}
+		found := false
+		for _, t := range cfg.Traders {
+			if t.ID == *traderID {
+				base, found = t.FundingHarvest, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("trader不存在: %s", *traderID)
+		}
+	}
+	if len(args) > 0 {
+		base.Symbols = args
+	}
+	if len(base.Symbols) == 0 {
+		return fmt.Errorf("用法: nofx sweep <SYMBOL...> [--trader <id>] [--days 90] [--entry 0.0003,0.0005] [--exit 0,0.0001] [--notional 1000] [--max-positions 1,2] [--percentile 0,90] [--perp-fee 0.05] [--spot-fee 0.2] [--workers N] [--top 20] [--csv file.csv] [--config config.json]")
+	}
+	for i, symbol := range base.Symbols {
+		base.Symbols[i] = market.Normalize(strings.ToUpper(symbol))
+	}
+
+	var grid backtest.FundingCarryGrid
+	if grid.EntryFundingRates, err = parseFloatList("entry", *entry); err != nil {
+		return err
+	}
+	if grid.ExitFundingRates, err = parseFloatList("exit", *exit); err != nil {
+		return err
+	}
+	if grid.NotionalUSD, err = parseFloatList("notional", *notional); err != nil {
+		return err
+	}
+	if grid.EntryPercentiles, err = parseFloatList("percentile", *percentile); err != nil {
+		return err
+	}
+	positions, err := parseFloatList("max-positions", *maxPositions)
+	if err != nil {
+		return err
+	}
+	for _, n := range positions {
+		grid.MaxPositions = append(grid.MaxPositions, int(n))
+	}
+
+	journal, err := openJournal(*configFile)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	history := report.NewFundingHistory(journal, *days)
+	rates := make(map[string][]store.FundingRate, len(base.Symbols))
+	for _, symbol := range base.Symbols {
+		if n, err := history.Backfill(symbol); err != nil {
+			cliLog.Warn("回填历史资金费率失败，使用已记录的历史", "symbol", symbol, "err", err)
+		} else if n > 0 {
+			cliLog.Info("已回填历史资金费率", "symbol", symbol, "count", n)
+		}
+		if rates[symbol], err = history.Rates(symbol); err != nil {
+			return err
+		}
+		if len(rates[symbol]) == 0 {
+			return fmt.Errorf("%s没有历史资金费率", symbol)
+		}
+	}
+
+	// 每组参数都会回放全部开平仓，策略日志只保留错误
+	if err := logging.Setup(logging.Config{Modules: map[string]string{"strategy": "error"}}); err != nil {
+		return err
+	}
+	var source trader.Trader
+	if cfgErr != nil {
+		cliLog.Warn("加载配置失败，无法查询合约规格，按1张=1个币计算", "err", cfgErr)
+	} else if backend, err := newStandaloneBackend(cfg, *traderID); err != nil {
+		cliLog.Warn("无法连接交易所查询合约规格，按1张=1个币计算", "err", err)
+	} else {
+		source = backend.trader
+	}
+	to := time.Now()
+	opts := backtest.FundingCarryOptions{
+		PerpFeeRate: *perpFee / 100,
+		SpotFeeRate: *spotFee / 100,
+		HistoryDays: *historyDays,
+		Markets:     loadSweepMarkets(source, base.Symbols, to.AddDate(0, 0, -*days), to),
+	}
+	results, skipped := backtest.SweepFundingCarry(base, grid, rates, opts, *workers)
+	if len(results) == 0 {
+		return fmt.Errorf("没有有效的参数组合（%d组无效，exit必须小于entry）", skipped)
+	}
+
+	fmt.Fprintf(os.Stdout, "资金费率套利参数扫描: %s，最近%d天，%d组参数", strings.Join(base.Symbols, ","), *days, len(results))
+	if skipped > 0 {
+		fmt.Fprintf(os.Stdout, "（跳过%d组无效参数）", skipped)
+	}
+	fmt.Fprint(os.Stdout, "\n\n", backtest.FormatSweep(results, *top))
+
+	if *csvFile != "" {
+		f, err := os.Create(*csvFile)
+		if err != nil {
+			return fmt.Errorf("创建CSV文件失败: %w", err)
+		}
+		defer f.Close()
+		if err := backtest.WriteSweepCSV(f, results); err != nil {
+			return fmt.Errorf("写入CSV文件失败: %w", err)
+		}
+		cliLog.Info("已写入CSV文件", "path", *csvFile)
+	}
+	return nil
+}
+
+// sweepPriceInterval 回测成交价使用的K线周期
+const sweepPriceInterval = "4h"
+
+// sweepPriceChunk 单次查询的K线时间跨度（Gate.io单次最多返回2000根）
+const sweepPriceChunk = 1900 * 4 * time.Hour
+
+// loadSweepMarkets 读取回测币种的合约规格、维持保证金档位和历史价格，使回测与模拟交易按相同的合约规则成交
+// 交易器不提供合约规格或查询失败时按1张=1个币计算，历史价格查询失败时该币种按单位价格成交（盈亏只来自资金费和手续费）
+func loadSweepMarkets(source trader.Trader, symbols []string, from, to time.Time) map[string]backtest.FundingCarryMarket {
+	markets := make(map[string]backtest.FundingCarryMarket, len(symbols))
+	for _, symbol := range symbols {
+		m := backtest.FundingCarryMarket{Spec: trader.ContractSpec{Multiplier: 1}}
+		if specs, ok := source.(trader.ContractSpecSource); ok {
+			if spec, err := specs.GetContractSpec(symbol); err != nil {
+				cliLog.Warn("获取合约规格失败，按1张=1个币计算", "symbol", symbol, "err", err)
+			} else {
+				m.Spec = spec
+			}
+		}
+		if tiers, ok := source.(trader.MaintenanceTierSource); ok {
+			if t, err := tiers.GetMaintenanceTiers(symbol); err != nil {
+				cliLog.Warn("获取维持保证金档位失败，按默认维持保证金率计算", "symbol", symbol, "err", err)
+			} else {
+				m.Tiers = t
+			}
+		}
+		for start := from; start.Before(to); start = start.Add(sweepPriceChunk) {
+			bars, err := market.KlinesBetween(symbol, sweepPriceInterval, start, minTime(start.Add(sweepPriceChunk), to))
+			if err != nil {
+				cliLog.Warn("获取历史价格失败，按单位价格成交", "symbol", symbol, "err", err)
+				m.Prices = nil
+				break
+			}
+			for _, bar := range bars {
+				if n := len(m.Prices); n == 0 || bar.OpenTime > m.Prices[n-1].OpenTime {
+					m.Prices = append(m.Prices, bar)
+				}
+			}
+		}
+		markets[symbol] = m
+	}
+	return markets
+}
+
+// minTime 两个时间中较早的一个
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// parseFloatList 解析逗号分隔的数值列表（空字符串表示不扫描该维度）
+func parseFloatList(name, s string) ([]float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var values []float64
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("--%s的值无效: %q", name, part)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,171 @@
+// Package symbols 交易对符号注册表：标准符号（如BTCUSDT，USDT本位永续）与各交易所合约标识之间的转换
+//
+// 普通币种按交易所的命名规则转换（Gate: BTC_USDT，Hyperliquid: BTC）；特殊命名在覆盖表中登记，
+// 例如Hyperliquid的kPEPE（1000个PEPE为一个单位）。不同写法的1000倍合约（1000PEPE、PEPE1000、kPEPE）
+// 统一为以单个币为单位的标准符号（PEPEUSDT），单位倍数记录在覆盖条目的Scale中，由交易器换算价格和数量
+package symbols
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 已登记命名规则的交易所（其他交易所直接使用标准符号）
+const (
+	Gate        = "gate"
+	Hyperliquid = "hyperliquid"
+)
+
+const quote = "USDT"
+
+// Mapping 标准符号在某个交易所上的合约
+type Mapping struct {
+	Venue string  // 交易所的合约标识（如 "BTC_USDT"、"kPEPE"）
+	Scale float64 // 一个合约单位对应的币数量（kPEPE为1000：价格是单币价格的1000倍，数量是1/1000）
+}
+
+// Override 覆盖条目（配置文件 symbol_overrides，内置条目之外的特殊命名）
+type Override struct {
+	Exchange string  `json:"exchange"`        // gate/hyperliquid
+	Symbol   string  `json:"symbol"`          // 标准符号（如 "PEPEUSDT"）
+	Venue    string  `json:"venue"`           // 交易所的合约标识（区分大小写）
+	Scale    float64 `json:"scale,omitempty"` // 单位倍数（默认1）
+}
+
+// Validate 验证覆盖条目
+func (o Override) Validate() error {
+	if _, ok := formats[o.Exchange]; !ok {
+		return fmt.Errorf("不支持的交易所: %q", o.Exchange)
+	}
+	if strings.TrimSpace(o.Symbol) == "" || strings.TrimSpace(o.Venue) == "" {
+		return fmt.Errorf("symbol和venue不能为空")
+	}
+	if o.Scale < 0 {
+		return fmt.Errorf("scale不能为负数")
+	}
+	if o.Exchange == Gate && o.Scale != 0 && o.Scale != 1 {
+		return fmt.Errorf("Gate合约的单位由合约乘数换算，scale必须为1")
+	}
+	return nil
+}
+
+// formats 各交易所的命名规则：基础币种 → 合约标识
+var formats = map[string]func(base string) string{
+	Gate:        func(base string) string { return base + "_" + quote },
+	Hyperliquid: func(base string) string { return base },
+}
+
+// scaledBases 常以1000个为单位交易的币种：1000PEPE、PEPE1000、KPEPE都视为PEPE
+// （只列出Gate上是单币合约的币种，否则Gate的1000X合约会被转换成不存在的合约）
+var scaledBases = []string{"PEPE", "SHIB", "BONK", "FLOKI", "LUNC", "DOGS", "NEIRO"}
+
+// builtin 内置覆盖条目
+var builtin = []Override{
+	{Exchange: Hyperliquid, Symbol: "PEPEUSDT", Venue: "kPEPE", Scale: 1000},
+	{Exchange: Hyperliquid, Symbol: "SHIBUSDT", Venue: "kSHIB", Scale: 1000},
+	{Exchange: Hyperliquid, Symbol: "BONKUSDT", Venue: "kBONK", Scale: 1000},
+	{Exchange: Hyperliquid, Symbol: "FLOKIUSDT", Venue: "kFLOKI", Scale: 1000},
+	{Exchange: Hyperliquid, Symbol: "LUNCUSDT", Venue: "kLUNC", Scale: 1000},
+	{Exchange: Hyperliquid, Symbol: "DOGSUSDT", Venue: "kDOGS", Scale: 1000},
+	{Exchange: Hyperliquid, Symbol: "NEIROUSDT", Venue: "kNEIRO", Scale: 1000},
+}
+
+var (
+	mu        sync.RWMutex
+	toVenue   = make(map[string]map[string]Mapping) // 交易所 → 标准符号 → 合约
+	fromVenue = make(map[string]map[string]string)  // 交易所 → 合约标识 → 标准符号
+	aliases   = make(map[string]string)             // 1000倍写法的基础币种 → 基础币种
+)
+
+func init() {
+	for _, base := range scaledBases {
+		aliases["1000"+base] = base
+		aliases[base+"1000"] = base
+		aliases["K"+base] = base
+	}
+	for _, o := range builtin {
+		register(o)
+	}
+}
+
+// Register 登记覆盖条目（覆盖同一交易所同一标准符号的已有条目）
+func Register(o Override) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	register(o)
+	return nil
+}
+
+func register(o Override) {
+	symbol := Canonical(o.Symbol)
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+	if toVenue[o.Exchange] == nil {
+		toVenue[o.Exchange] = make(map[string]Mapping)
+		fromVenue[o.Exchange] = make(map[string]string)
+	}
+	if old, ok := toVenue[o.Exchange][symbol]; ok {
+		delete(fromVenue[o.Exchange], old.Venue)
+	}
+	toVenue[o.Exchange][symbol] = Mapping{Venue: o.Venue, Scale: o.Scale}
+	fromVenue[o.Exchange][o.Venue] = symbol
+}
+
+// Canonical 标准符号：大写、去掉分隔符（BTC_USDT、btc/usdt、BTC-USDT → BTCUSDT）、补全USDT，
+// 1000倍合约的各种写法统一为单币符号（1000PEPEUSDT、PEPE1000、kPEPE → PEPEUSDT）
+func Canonical(symbol string) string {
+	s := strings.ToUpper(symbol)
+	s = strings.TrimSpace(strings.NewReplacer("/", "", "-", "", "_", "").Replace(s)) // 去掉分隔符后再去空白（如"BTC _"）
+	base := strings.TrimSuffix(s, quote)
+	if base == "" {
+		return s
+	}
+	if alias, ok := aliases[base]; ok {
+		base = alias
+	}
+	return base + quote
+}
+
+// Base 标准符号的基础币种（BTCUSDT → BTC）
+func Base(symbol string) string {
+	s := Canonical(symbol)
+	if base := strings.TrimSuffix(s, quote); base != "" {
+		return base
+	}
+	return s
+}
+
+// ToVenue 标准符号（或任意写法）在交易所上的合约；未登记命名规则的交易所返回标准符号
+func ToVenue(exchange, symbol string) Mapping {
+	symbol = Canonical(symbol)
+	mu.RLock()
+	m, ok := toVenue[exchange][symbol]
+	mu.RUnlock()
+	if ok {
+		return m
+	}
+	if format, ok := formats[exchange]; ok && symbol != "" && symbol != quote { // 空符号和单独的计价币种没有基础币种，不套用命名规则
+		return Mapping{Venue: format(Base(symbol)), Scale: 1}
+	}
+	return Mapping{Venue: symbol, Scale: 1}
+}
+
+// FromVenue 交易所合约标识对应的标准符号和单位倍数
+func FromVenue(exchange, venue string) (string, float64) {
+	mu.RLock()
+	symbol, ok := fromVenue[exchange][venue]
+	var scale float64
+	if ok {
+		scale = toVenue[exchange][symbol].Scale
+	}
+	mu.RUnlock()
+	if ok {
+		return symbol, scale
+	}
+	return Canonical(venue), 1
+}
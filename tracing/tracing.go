@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config 链路追踪配置（OTLP/HTTP导出，可对接Jaeger、Tempo、OTel Collector等）
+type Config struct {
+	Enabled     bool    `json:"enabled"`      // 是否启用（未启用时所有span为空操作，无额外开销）
+	Endpoint    string  `json:"endpoint"`     // OTLP/HTTP地址（host:port，默认localhost:4318）
+	Insecure    bool    `json:"insecure"`     // 使用HTTP而非HTTPS
+	ServiceName string  `json:"service_name"` // 服务名（默认nofx）
+	SampleRatio float64 `json:"sample_ratio"` // 采样比例0~1（默认1，全部采样）
+}
+
+// Validate 验证链路追踪配置
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "localhost:4318"
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = "nofx"
+	}
+	if c.SampleRatio == 0 {
+		c.SampleRatio = 1
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio必须在0~1之间: %.2f", c.SampleRatio)
+	}
+	return nil
+}
+
+// Setup 初始化全局TracerProvider，返回退出时调用的shutdown（刷新未导出的span）
+// 未启用时不做任何事，tracer保持为otel默认的空实现
+func Setup(cfg Config) (func(context.Context) error, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建OTLP导出器失败: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start 开始一个span（name使用"模块.操作"格式，如order.place）
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer("nofx").Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err不为nil时记录错误并标记span失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
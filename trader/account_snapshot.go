@@ -0,0 +1,202 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/risk"
+	"time"
+)
+
+// AccountSnapshot 同一时刻的账户快照：余额、持仓、挂单和条件单一起查询（不使用缓存），
+// 决策周期不必拼接不同时间缓存的数据
+type AccountSnapshot struct {
+	Balance       map[string]interface{}   // 与GetBalance字段相同
+	Positions     []map[string]interface{} // 与GetPositions字段相同
+	OpenOrders    []OpenOrder              // 所有合约未成交的普通委托单
+	TriggerOrders []TriggerOrder           // 生效中的止损/止盈条件单
+
+	Equity            float64 // 账户净值（钱包余额 + 未实现盈亏）
+	Available         float64 // 可用余额
+	MarginUsed        float64 // 已占用保证金（统一账户为全账户初始保证金）
+	MarginUsedPct     float64 // 保证金使用率（%）
+	Notional          float64 // 所有持仓总名义价值（按标记价格）
+	EffectiveLeverage float64 // 有效杠杆（总名义价值 / 净值）
+
+	FetchedAt time.Time     // 开始查询的时间
+	Latency   time.Duration // 全部查询完成的耗时
+}
+
+// AccountSnapshotSource 可一次查询账户快照的交易器
+type AccountSnapshotSource interface {
+	GetAccountSnapshot() (AccountSnapshot, error)
+}
+
+// fetchAccountSnapshot 查询账户快照；交易器不支持时依次查询余额、持仓（可能来自缓存）和条件单，挂单为空
+func fetchAccountSnapshot(t Trader) (AccountSnapshot, error) {
+	if source, ok := t.(AccountSnapshotSource); ok {
+		return source.GetAccountSnapshot()
+	}
+
+	snapshot, err := cachedAccountSnapshot(t)
+	if err != nil {
+		return AccountSnapshot{}, err
+	}
+	if source, ok := t.(OpenOrderSource); ok {
+		if snapshot.TriggerOrders, err = source.GetOpenTriggerOrders(); err != nil {
+			return AccountSnapshot{}, fmt.Errorf("获取条件单失败: %w", err)
+		}
+	}
+	snapshot.Latency = time.Since(snapshot.FetchedAt)
+	return snapshot, nil
+}
+
+// summarize 根据余额和持仓计算净值、保证金使用率和有效杠杆（与决策上下文的口径一致）
+func (s *AccountSnapshot) summarize() {
+	wallet, _ := s.Balance["totalWalletBalance"].(float64)
+	unrealized, _ := s.Balance["totalUnrealizedProfit"].(float64)
+	s.Available, _ = s.Balance["availableBalance"].(float64)
+	s.Equity = wallet + unrealized
+
+	s.MarginUsed, s.Notional = 0, 0
+	for _, pos := range s.Positions {
+		quantity, _ := pos["positionAmt"].(float64)
+		markPrice, _ := pos["markPrice"].(float64)
+		notional := math.Abs(quantity) * markPrice
+		s.Notional += notional
+
+		if margin, ok := pos["margin"].(float64); ok && margin > 0 {
+			s.MarginUsed += margin
+			continue
+		}
+		leverage, ok := pos["leverage"].(float64)
+		if !ok || leverage <= 0 {
+			leverage = 10
+		}
+		s.MarginUsed += notional / leverage
+	}
+	if initialMargin, ok := s.Balance["totalInitialMargin"].(float64); ok && initialMargin > 0 {
+		s.MarginUsed = initialMargin
+	}
+	s.MarginUsedPct = risk.MarginUtilizationPct(s.MarginUsed, s.Equity)
+	s.EffectiveLeverage = risk.EffectiveLeverage(s.Notional, s.Equity)
+}
+
+// marketSnapshot 转换为模块间共享的快照（只含账户部分，行情由决策引擎获取后写入），持仓附带交易器的合约乘数
+func (s AccountSnapshot) marketSnapshot(t Trader) *market.MarketSnapshot {
+	wallet, _ := s.Balance["totalWalletBalance"].(float64)
+	unrealized, _ := s.Balance["totalUnrealizedProfit"].(float64)
+	snapshot := &market.MarketSnapshot{
+		Account: market.AccountState{
+			WalletBalance:     wallet,
+			UnrealizedPnL:     unrealized,
+			Equity:            s.Equity,
+			Available:         s.Available,
+			MarginUsed:        s.MarginUsed,
+			MarginUsedPct:     s.MarginUsedPct,
+			Notional:          s.Notional,
+			EffectiveLeverage: s.EffectiveLeverage,
+		},
+		AccountAt: s.FetchedAt,
+	}
+
+	for _, pos := range s.Positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity := math.Abs(floatValue(pos["positionAmt"]))
+		if symbol == "" || quantity == 0 {
+			continue
+		}
+		markPrice := floatValue(pos["markPrice"])
+		leverage := 10 // 交易器未返回杠杆时的默认值
+		if lev, ok := pos["leverage"].(float64); ok && lev > 0 {
+			leverage = int(lev)
+		}
+		margin := floatValue(pos["margin"])
+		if margin <= 0 {
+			margin = quantity * markPrice / float64(leverage)
+		}
+		stopLoss, takeProfit := s.protectiveLevels(symbol, side, markPrice)
+		snapshot.Positions = append(snapshot.Positions, market.PositionState{
+			Symbol:           symbol,
+			Side:             side,
+			Quantity:         quantity,
+			Multiplier:       contractSpec(t, symbol).Multiplier,
+			EntryPrice:       floatValue(pos["entryPrice"]),
+			MarkPrice:        markPrice,
+			UnrealizedPnL:    floatValue(pos["unRealizedProfit"]),
+			LiquidationPrice: floatValue(pos["liquidationPrice"]),
+			Leverage:         leverage,
+			Margin:           margin,
+			StopLoss:         stopLoss,
+			TakeProfit:       takeProfit,
+			ADLRank:          positionADLRank(pos),
+		})
+	}
+
+	for _, o := range s.OpenOrders {
+		snapshot.OpenOrders = append(snapshot.OpenOrders, market.OrderState{
+			OrderID:    o.OrderID,
+			Symbol:     o.Symbol,
+			Quantity:   o.Quantity,
+			Price:      o.Price,
+			ReduceOnly: o.ReduceOnly,
+		})
+	}
+	return snapshot
+}
+
+// cachedAccountSnapshot 用交易器缓存的余额和持仓计算账户指标（不含订单，用于开仓前的风控检查）
+func cachedAccountSnapshot(t Trader) (AccountSnapshot, error) {
+	snapshot := AccountSnapshot{FetchedAt: time.Now()}
+	var err error
+	if snapshot.Balance, err = t.GetBalance(); err != nil {
+		return AccountSnapshot{}, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	if snapshot.Positions, err = t.GetPositions(); err != nil {
+		return AccountSnapshot{}, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	snapshot.summarize()
+	return snapshot, nil
+}
+
+// checkAccountLimits 开仓前检查账户级限制：开仓后的有效杠杆和保证金使用率（leverage.account）
+func (at *AutoTrader) checkAccountLimits(d *decision.Decision) error {
+	limits := at.config.AccountLimits
+	if !limits.Enabled() || d.PositionSizeUSD <= 0 {
+		return nil
+	}
+	snapshot, err := cachedAccountSnapshot(at.trader)
+	if err != nil {
+		return err
+	}
+	addMargin := d.PositionSizeUSD
+	if d.Leverage > 0 {
+		addMargin /= float64(d.Leverage)
+	}
+	if err := limits.CheckAdd(snapshot.Notional, snapshot.MarginUsed, snapshot.Equity, d.PositionSizeUSD, addMargin); err != nil {
+		return fmt.Errorf("%w: %v", ErrAccountLimit, err)
+	}
+	return nil
+}
+
+// protectiveLevels 快照中持仓最近的止损和止盈触发价（没有时为0；分批止盈取最近的一档）
+func (s AccountSnapshot) protectiveLevels(symbol, side string, markPrice float64) (stopLoss, takeProfit float64) {
+	for _, o := range s.TriggerOrders {
+		if o.Symbol != symbol || o.PositionSide != side {
+			continue
+		}
+		switch o.Kind {
+		case "stop_loss":
+			if stopLoss == 0 || math.Abs(o.TriggerPrice-markPrice) < math.Abs(stopLoss-markPrice) {
+				stopLoss = o.TriggerPrice
+			}
+		case "take_profit":
+			if takeProfit == 0 || math.Abs(o.TriggerPrice-markPrice) < math.Abs(takeProfit-markPrice) {
+				takeProfit = o.TriggerPrice
+			}
+		}
+	}
+	return stopLoss, takeProfit
+}
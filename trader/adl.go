@@ -0,0 +1,108 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nofx/notify"
+	"nofx/risk"
+	"strconv"
+)
+
+// adlEpisode 持仓处于ADL预警区间期间的处理状态（排名回落到告警档以下或持仓平掉时清除）
+type adlEpisode struct {
+	rank    int  // 已告警的最高排名
+	reduced bool // 本次预警期间是否已主动减仓（每次预警只减仓一次）
+}
+
+// positionADLRank 持仓的自动减仓排名（交易器不提供时为0）
+func positionADLRank(pos map[string]interface{}) int {
+	rank, _ := pos["adlRanking"].(int)
+	return rank
+}
+
+// checkADL 检查持仓的自动减仓（ADL）排名（策略看守周期调用）：排名升到告警档及以上时告警（同一次预警期间排名继续升高时再次告警），
+// 达到减仓档时按比例主动减仓一次
+func (at *AutoTrader) checkADL(ctx context.Context) []string {
+	guard := at.config.ADLGuard
+	if !guard.Enabled() {
+		return nil
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return []string{fmt.Sprintf("⚠ ADL检查获取持仓失败: %v", err)}
+	}
+
+	var logs []string
+	active := make(map[string]bool)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		rank := positionADLRank(pos)
+		if symbol == "" || !guard.Warn(rank) {
+			continue
+		}
+		key := symbol + "_" + side
+		active[key] = true
+
+		episode := at.adlEpisodes[key]
+		if rank > episode.rank {
+			detail := fmt.Sprintf("%s %s ADL排名 %d/%d", symbol, side, rank, risk.MaxADLRank)
+			at.log.Warn("持仓接近自动减仓队列前列", "symbol", symbol, "side", side, "rank", rank)
+			at.notifyReason(notify.KindRisk, riskReason("adl"), symbol, "持仓接近自动减仓队列前列", detail)
+			at.publishRisk("adl", symbol, "warn", detail)
+			episode.rank = rank
+		}
+		if guard.Reduce(rank) && !episode.reduced && !at.config.ReadOnly {
+			line, err := at.reduceForADL(ctx, symbol, side, floatValue(pos["positionAmt"]), floatValue(pos["markPrice"]), rank)
+			episode.reduced = err == nil
+			if line != "" {
+				logs = append(logs, line)
+			}
+		}
+		at.adlEpisodes[key] = episode
+	}
+	for key := range at.adlEpisodes {
+		if !active[key] {
+			delete(at.adlEpisodes, key)
+		}
+	}
+	return logs
+}
+
+// reduceForADL 按adl_guard.reduce_pct市价平掉部分持仓，返回策略看守日志
+// 订单跟踪器按成交数量拆分交易日志的持仓记录（平掉的部分单独结算盈亏，剩余部分继续持有）
+func (at *AutoTrader) reduceForADL(ctx context.Context, symbol, side string, positionAmt, price float64, rank int) (string, error) {
+	pct := at.config.ADLGuard.ReducePct
+	formatted, err := at.trader.FormatQuantity(symbol, positionAmt*pct/100)
+	if errors.Is(err, ErrOrderTooSmall) {
+		formatted, err = "0", nil // quantity_rounding.below_min=reject，按持仓太小处理
+	}
+	if err != nil {
+		return fmt.Sprintf("❌ %s %s ADL减仓数量格式化失败: %v", symbol, side, err), err
+	}
+	quantity, _ := strconv.ParseFloat(formatted, 64)
+	if quantity <= 0 {
+		// 持仓太小无法按比例减仓，视为已处理（避免每个周期重复尝试）
+		return fmt.Sprintf("⚠ %s %s ADL排名%d，持仓的%.0f%%低于最小下单数量，未减仓", symbol, side, rank, pct), nil
+	}
+
+	_, _, err = at.orders.Place(ctx, "adl", symbol, "close_"+side, quantity, price, 0,
+		func() (map[string]interface{}, error) {
+			if side == "long" {
+				return at.trader.CloseLong(symbol, quantity)
+			}
+			return at.trader.CloseShort(symbol, quantity)
+		})
+	if errors.Is(err, ErrPositionNotFound) {
+		return "", nil // 持仓在获取后已被平掉
+	}
+	if err != nil {
+		return fmt.Sprintf("❌ %s %s ADL排名%d，减仓失败: %v", symbol, side, rank, err), err
+	}
+
+	detail := fmt.Sprintf("%s %s ADL排名 %d/%d，已市价减仓%.0f%%（%s）", symbol, side, rank, risk.MaxADLRank, pct, formatted)
+	at.notifyReason(notify.KindRisk, riskReason("adl"), symbol, "ADL预警主动减仓", detail)
+	at.publishRisk("adl", symbol, "reduce", detail)
+	return "📉 " + detail, nil
+}
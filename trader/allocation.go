@@ -0,0 +1,200 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/clock"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/store"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// capitalAllocator 多策略资金分配：维护各策略的净值比例，开仓前按订单的策略标记检查额度，
+// 定期按交易日志中各策略的净盈亏再平衡（比例持久化到交易日志，重启后沿用）
+type capitalAllocator struct {
+	config       risk.Allocation
+	journal      *store.Store
+	traderID     string
+	clock        clock.Clock
+	mu           sync.Mutex
+	weights      map[string]float64
+	rebalancedAt time.Time
+}
+
+// AllocationStatus 资金分配状态（用于API）
+type AllocationStatus struct {
+	Weights             map[string]float64 `json:"weights"` // 当前比例
+	Used                map[string]float64 `json:"used"`    // 各策略当前持仓名义价值（USDT）
+	MaxExposureMultiple float64            `json:"max_exposure_multiple"`
+	RebalancedAt        time.Time          `json:"rebalanced_at,omitempty"`
+}
+
+// newCapitalAllocator 创建资金分配器（未启用时返回nil），优先恢复上次再平衡后的比例
+// 配置中的策略集合变化时丢弃保存的比例，按配置重新开始
+func newCapitalAllocator(config risk.Allocation, journal *store.Store, traderID string, clk clock.Clock) (*capitalAllocator, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	if journal == nil {
+		return nil, fmt.Errorf("资金分配需要交易日志存储（按订单策略标记统计持仓和盈亏）")
+	}
+	a := &capitalAllocator{config: config, journal: journal, traderID: traderID, clock: clock.Or(clk), weights: make(map[string]float64)}
+	for name, w := range config.Weights {
+		a.weights[name] = w
+	}
+
+	saved, at, err := journal.LoadAllocation(traderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(saved) == len(config.Weights) {
+		for name := range config.Weights {
+			if _, ok := saved[name]; !ok {
+				return a, nil
+			}
+		}
+		a.weights, a.rebalancedAt = saved, at
+	}
+	return a, nil
+}
+
+// Weights 当前比例的副本
+func (a *capitalAllocator) Weights() map[string]float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	weights := make(map[string]float64, len(a.weights))
+	for name, w := range a.weights {
+		weights[name] = w
+	}
+	return weights
+}
+
+// exposure 按交易日志中的未平仓持仓统计各策略和全部持仓的名义价值，并返回symbol/side持仓所属的策略
+func (a *capitalAllocator) exposure(symbol, side string) (byStrategy map[string]float64, total float64, owner string, err error) {
+	positions, err := a.journal.ListOpenPositions(a.traderID)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	byStrategy = make(map[string]float64)
+	for _, p := range positions {
+		value := p.Quantity * p.EntryPrice
+		byStrategy[p.Strategy] += value
+		total += value
+		if p.Symbol == symbol && p.Side == side {
+			owner = p.Strategy
+		}
+	}
+	return byStrategy, total, owner, nil
+}
+
+// checkOpen 开仓前检查策略额度和账户总敞口（加仓计入已有持仓所属的策略）
+func (a *capitalAllocator) checkOpen(t Trader, strategy, symbol, side string, addValue float64) error {
+	if a == nil {
+		return nil
+	}
+	byStrategy, total, owner, err := a.exposure(symbol, side)
+	if err != nil {
+		return fmt.Errorf("%w: 读取持仓记录失败: %v", ErrAllocationExceeded, err)
+	}
+	if owner != "" {
+		strategy = owner
+	}
+	weight, ok := a.Weights()[strategy]
+	if !ok {
+		return fmt.Errorf("%w: 策略%s未分配资金", ErrAllocationExceeded, strategy)
+	}
+
+	balance, err := t.GetBalance()
+	if err != nil {
+		return fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	if err := a.config.CheckAdd(strategy, weight, byStrategy[strategy], total, addValue, wallet+unrealized); err != nil {
+		return fmt.Errorf("%w: %v", ErrAllocationExceeded, err)
+	}
+	return nil
+}
+
+// rebalance 按回看期内各策略的收益率（已平仓净盈亏 / 分配资金）调整比例并持久化，返回调整前后的比例和收益率
+func (a *capitalAllocator) rebalance(equity float64) (before, after, returns map[string]float64, err error) {
+	if equity <= 0 {
+		return nil, nil, nil, fmt.Errorf("账户净值无效(%.2f)，跳过再平衡", equity)
+	}
+	now := a.clock.Now()
+	positions, err := a.journal.ListClosedPositions(a.traderID, now.AddDate(0, 0, -a.config.LookbackDays))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pnl := make(map[string]float64)
+	for _, p := range positions {
+		pnl[p.Strategy] += p.NetPnL()
+	}
+
+	before = a.Weights()
+	returns = make(map[string]float64, len(before))
+	for name, w := range before {
+		if capital := w * equity; capital > 0 {
+			returns[name] = pnl[name] / capital
+		}
+	}
+	after = a.config.RebalanceWeights(before, returns)
+	if err := a.journal.SaveAllocation(a.traderID, after, now); err != nil {
+		return nil, nil, nil, err
+	}
+
+	a.mu.Lock()
+	a.weights, a.rebalancedAt = after, now
+	a.mu.Unlock()
+	return before, after, returns, nil
+}
+
+// rebalanceAllocation 定时再平衡各策略的资金比例（与AI决策、策略看守串行执行）
+func (at *AutoTrader) rebalanceAllocation() {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		at.log.Warn("资金再平衡获取账户余额失败", "err", err)
+		return
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+
+	before, after, returns, err := at.allocator.rebalance(wallet + unrealized)
+	if err != nil {
+		at.log.Warn("资金再平衡失败", "err", err)
+		return
+	}
+	at.log.Info("资金分配已再平衡", "before", before, "after", after, "returns", returns)
+
+	names := make([]string, 0, len(after))
+	for name := range after {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %.1f%% → %.1f%%（收益率%+.2f%%）", name, before[name]*100, after[name]*100, returns[name]*100))
+	}
+	at.notify(notify.KindInfo, "", "资金分配已再平衡", strings.Join(lines, "\n"))
+}
+
+// Status 当前资金分配状态（未启用时返回nil）
+func (a *capitalAllocator) Status() *AllocationStatus {
+	if a == nil {
+		return nil
+	}
+	status := &AllocationStatus{Weights: a.Weights(), MaxExposureMultiple: a.config.MaxExposureMultiple}
+	a.mu.Lock()
+	status.RebalancedAt = a.rebalancedAt
+	a.mu.Unlock()
+	if used, _, _, err := a.exposure("", ""); err == nil {
+		status.Used = used
+	}
+	return status
+}
@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"nofx/apiusage"
+	"nofx/mcp"
+	"time"
+)
+
+// APIUsageSource 统计REST请求用量的交易器（按交易所频率限制分组）
+type APIUsageSource interface {
+	APIUsage(since time.Time) []apiusage.Usage
+}
+
+// APIUsage since之后各私有接口分组的请求用量
+func (t *GateTrader) APIUsage(since time.Time) []apiusage.Usage {
+	return t.usage.Usage(since)
+}
+
+// APIUsage 交易所私有接口的请求用量（交易所不支持统计时返回false）
+func (at *AutoTrader) APIUsage(since time.Time) ([]apiusage.Usage, bool) {
+	source, ok := at.trader.(APIUsageSource)
+	if !ok {
+		return nil, false
+	}
+	return source.APIUsage(since), true
+}
+
+// LLMMetrics AI客户端的请求、重试、延迟、token和成本统计（进程启动以来）
+func (at *AutoTrader) LLMMetrics() mcp.Metrics {
+	return at.mcpClient.Metrics()
+}
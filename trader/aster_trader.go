@@ -8,11 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"math"
 	"math/big"
 	"net/http"
 	"net/url"
+	"nofx/logging"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,6 +24,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// asterLog Aster交易器日志
+var asterLog = logging.For("aster")
+
 // AsterTrader Aster交易平台实现
 type AsterTrader struct {
 	ctx        context.Context
@@ -36,6 +39,8 @@ type AsterTrader struct {
 	// 缓存交易对精度信息
 	symbolPrecision map[string]SymbolPrecision
 	mu              sync.RWMutex
+
+	stopLimitOffsetPct float64 // 止损限价偏移（%），0表示触发后市价成交
 }
 
 // SymbolPrecision 交易对精度信息
@@ -524,7 +529,7 @@ func (t *AsterTrader) GetPositions() ([]map[string]interface{}, error) {
 func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+		asterLog.Warn("取消挂单失败，继续开仓", "symbol", symbol, "err", err)
 	}
 
 	// 先设置杠杆
@@ -561,8 +566,8 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	asterLog.Debug("精度处理", "symbol", symbol, "price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -591,7 +596,7 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+		asterLog.Warn("取消挂单失败，继续开仓", "symbol", symbol, "err", err)
 	}
 
 	// 先设置杠杆
@@ -628,8 +633,8 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	asterLog.Debug("精度处理", "symbol", symbol, "price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -671,9 +676,9 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的多仓: %w", symbol, ErrPositionNotFound)
 		}
-		log.Printf("  📊 获取到多仓数量: %.8f", quantity)
+		asterLog.Debug("按持仓数量平多仓", "symbol", symbol, "quantity", quantity)
 	}
 
 	price, err := t.GetMarketPrice(symbol)
@@ -703,8 +708,8 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	asterLog.Debug("精度处理", "symbol", symbol, "price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -726,11 +731,11 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		return nil, err
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, qtyStr)
+	asterLog.Info("平多仓成功", "symbol", symbol, "quantity", qtyStr)
 
 	// 平仓后取消该币种的所有挂单(止损止盈单)
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		asterLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
 
 	return result, nil
@@ -754,9 +759,9 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的空仓: %w", symbol, ErrPositionNotFound)
 		}
-		log.Printf("  📊 获取到空仓数量: %.8f", quantity)
+		asterLog.Debug("按持仓数量平空仓", "symbol", symbol, "quantity", quantity)
 	}
 
 	price, err := t.GetMarketPrice(symbol)
@@ -786,8 +791,8 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	asterLog.Debug("精度处理", "symbol", symbol, "price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -809,11 +814,11 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		return nil, err
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, qtyStr)
+	asterLog.Info("平空仓成功", "symbol", symbol, "quantity", qtyStr)
 
 	// 平仓后取消该币种的所有挂单(止损止盈单)
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		asterLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
 
 	return result, nil
@@ -894,10 +899,25 @@ func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity,
 		"timeInForce":  "GTC",
 	}
 
+	// 止损限价单：触发后按限价挂单
+	if t.stopLimitOffsetPct > 0 {
+		limitPrice, err := t.formatPrice(symbol, stopLimitPrice(positionSide, stopPrice, t.stopLimitOffsetPct))
+		if err != nil {
+			return err
+		}
+		params["type"] = "STOP"
+		params["price"] = t.formatFloatWithPrecision(limitPrice, prec.PricePrecision)
+	}
+
 	_, err = t.request("POST", "/fapi/v3/order", params)
 	return err
 }
 
+// SetStopLimitOffset 设置止损限价偏移（%），0表示触发后市价成交
+func (t *AsterTrader) SetStopLimitOffset(pct float64) {
+	t.stopLimitOffsetPct = pct
+}
+
 // SetTakeProfit 设置止盈
 func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
 	side := "SELL"
@@ -939,6 +959,11 @@ func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity
 	return err
 }
 
+// SetTakeProfitLadder 分批止盈：每档一个按数量的止盈条件单
+func (t *AsterTrader) SetTakeProfitLadder(symbol, positionSide string, quantity float64, levels []TPLevel) error {
+	return placeLadderOrders(t, symbol, positionSide, quantity, levels)
+}
+
 // CancelAllOrders 取消所有订单
 func (t *AsterTrader) CancelAllOrders(symbol string) error {
 	params := map[string]interface{}{
@@ -1,16 +1,34 @@
 package trader
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"nofx/clock"
 	"nofx/decision"
+	"nofx/events"
+	"nofx/fx"
 	"nofx/logger"
+	"nofx/logging"
 	"nofx/market"
 	"nofx/mcp"
+	"nofx/notify"
 	"nofx/pool"
+	"nofx/risk"
+	"nofx/scheduler"
+	"nofx/store"
+	"nofx/strategy"
+	"nofx/tracing"
+	"nofx/webhook"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // AutoTraderConfig 自动交易配置（简化版 - AI全权决策）
@@ -54,6 +72,9 @@ type AutoTraderConfig struct {
 	CustomAPIKey    string
 	CustomModelName string
 
+	// AI客户端选项（重试退避、超时、流式输出、JSON模式）
+	LLM mcp.Options
+
 	// 扫描配置
 	ScanInterval time.Duration // 扫描间隔（建议3分钟）
 
@@ -64,12 +85,160 @@ type AutoTraderConfig struct {
 	BTCETHLeverage  int // BTC和ETH的杠杆倍数
 	AltcoinLeverage int // 山寨币的杠杆倍数
 
+	// 按波动率自动计算杠杆（启用时忽略AI和外部信号给出的杠杆，上面两项作为上限）
+	AutoLeverage risk.AutoLeverageConfig
+
+	// 开仓前按估算强平价检查（强平价在正常波动范围内时拒绝开仓）
+	LiquidationGuard risk.LiquidationGuard
+
+	// 账户级限制（开仓后的有效杠杆和保证金使用率上限）
+	AccountLimits risk.AccountLimits
+
 	// 风险控制（仅作为提示，AI可自主决定）
 	MaxDailyLoss    float64       // 最大日亏损百分比（提示）
-	MaxDrawdown     float64       // 最大回撤百分比（提示）
+	MaxDrawdown     float64       // 最大回撤百分比（相对历史最高净值，触发后暂停交易）
 	StopTradingTime time.Duration // 触发风控后暂停时长
+
+	// 交易器余额/持仓缓存时间（0使用交易器默认值）
+	AccountCacheTTL time.Duration
+
+	// 模拟交易（dry-run）：行情读取真实交易所，下单在本地模拟
+	DryRun bool
+
+	// 只读（观察模式）：照常获取数据、运行AI和策略、记录决策，但交易器层拒绝一切下单
+	ReadOnly bool
+
+	// 影子模式：对照的实盘trader ID（影子trader始终模拟交易，为空表示不是影子trader）
+	ShadowOf string
+
+	// 候选提示词（追加规则和提示词版本，未配置时使用内置提示词）
+	Prompt decision.PromptConfig
+
+	// 止损限价偏移（%），0表示止损触发后市价成交
+	StopLimitOffsetPct float64
+
+	// 止损/止盈条件单默认有效期（0表示交易器默认值）
+	TriggerExpiration time.Duration
+
+	// 止损/止盈条件单默认触发价格类型（mark/last/index，为空表示标记价格）
+	TriggerPriceType string
+
+	// 分批止盈档位（为空时使用AI给出的单一止盈）
+	TakeProfitLadder risk.TakeProfitLadder
+
+	// 持仓数量变化时自动调整止损/止盈单数量（需要交易器支持WebSocket持仓推送）
+	ResizeProtectiveOrders bool
+
+	// 监控提现、转出和API密钥权限变化并告警（需要交易器支持查询资金流水）
+	LedgerMonitor bool
+
+	// 首次运行时从交易所导入最近N天的平仓记录和成交（0表示不导入，需要交易器支持查询平仓历史）
+	HistoryImportDays int
+
+	// 自动减仓排名预警和主动减仓（需要交易器在持仓中提供ADL排名）
+	ADLGuard risk.ADLGuard
+
+	// 保证金模式（逐仓/全仓）和各币种风险限额（需要交易器支持）
+	Margin risk.MarginSettings
+
+	// 下单数量取整策略（取整方式、低于最小下单数量时提高到最小数量或拒绝）
+	QuantityRounding risk.QuantityRounding
+
+	// 止损/止盈触发价合理性检查（方向始终检查，偏离上限为0时不限制）
+	PriceBand risk.PriceBand
+
+	// 开仓执行方式（市价/只挂单/按预期手续费+滑点成本自动选择）
+	EntryRouting risk.EntryRouting
+
+	// 信号去重和开仓节流（相同动作去重窗口、同一币种最小开仓间隔）
+	SignalThrottle risk.SignalThrottle
+
+	// 按行情状态过滤开仓（为空时不过滤）
+	RegimeFilter risk.RegimeFilter
+
+	// 经济日历（全局配置，重要事件前后禁止开仓）
+	Calendar risk.EconomicCalendar
+
+	// 策略配置
+	DCA            strategy.DCAConfig            // DCA/马丁加仓
+	Pyramid        strategy.PyramidConfig        // 顺势加仓（与DCA互斥）
+	FundingHarvest strategy.FundingHarvestConfig // 资金费率套利（需要现货模块）
+	Basis          strategy.BasisConfig          // 期现基差监控与套利（需要现货模块）
+	Hedge          strategy.HedgeConfig          // 相关性对冲（在相关合约上建立反向头寸）
+
+	// 调度配置（AI决策/策略看守独立周期、交易时段、禁止开仓规则）
+	Schedule scheduler.Config
+
+	// 外部信号（TradingView Webhook）
+	Webhook webhook.Config
+
+	// 多周期K线服务（全局配置，为空时不启用）
+	Bars market.BarConfig
+
+	// 交易日志存储（为nil时不记录）
+	Journal *store.Store
+
+	// 通知渠道（开平仓、止损止盈触发、风控、错误；为nil时不推送），订阅事件总线上的通知事件
+	Notifier notify.Notifier
+
+	// 事件总线（订单、持仓、风控、决策和通知事件，所有trader共享；为nil时使用trader自己的总线）
+	Events *events.Bus
+
+	// 时钟（为nil时使用系统时钟；回测和测试注入模拟时钟）
+	Clock clock.Clock
+
+	// 多策略资金分配（按策略标记限制各策略持仓额度，定期按收益再平衡；为空时不限制）
+	Allocation risk.Allocation
+
+	// 软平仓（指定规则触发的平仓需要人工确认）
+	SoftClose risk.SoftClose
+
+	// 开仓确认模式（开仓生成附带下单预览的建议，人工确认后执行）
+	EntryConfirm risk.EntryConfirm
+
+	// 全部平仓的平仓顺序和相邻两笔平仓的间隔
+	Flatten risk.Flatten
+
+	// 维护期间的平仓单排队（恢复后自动重新提交）
+	DowntimeQueue risk.DowntimeQueue
+
+	// 每个决策周期的新开仓数量上限（按信心度执行，超出的丢弃或顺延）
+	EntryLimit risk.EntryLimit
+
+	// 浸泡测试（模拟交易按计划注入故障并检查不变量）
+	Soak risk.Soak
+
+	// 单笔最大亏损硬上限（开仓必须带止损，止损时亏损超过上限时拒绝或缩小仓位）
+	TradeLossCap risk.TradeLossCap
+
+	// 决策过期保护（行情快照距执行时间过长或价格偏离快照过大时拒绝执行）
+	Staleness risk.Staleness
+
+	// 余额异常检测（钱包余额变化与交易日志不符时告警并强制对账）
+	BalanceAnomaly risk.BalanceAnomaly
+
+	// 数据源故障降级（WebSocket断开改为REST轮询，K线过期只平仓，AI不可用只按规则平仓）
+	Degradation risk.Degradation
+
+	// 组合视图汇总的结算币种（Gate usdt/btc，为空时只统计交易使用的结算币种）
+	PortfolioSettles risk.PortfolioSettles
+
+	// 下单频率上限（超过时拒绝开仓并暂停交易）
+	OrderRateLimit risk.OrderRateLimit
+
+	// 按策略和币种覆盖的风控参数（单笔风险、杠杆上限、仓位上限、止损距离）
+	RiskProfiles risk.RiskProfiles
+
+	// 组合敞口检查（跨交易所汇总所有账户后检查开仓，由管理器提供；为nil时不检查）
+	BookCheck func(symbol, side string, addValue float64) error
 }
 
+// vanishedPositionGrace 新开仓后多久才检查其是否已在交易所平仓（交易器持仓缓存约15秒）
+const vanishedPositionGrace = time.Minute
+
+// ai500Limit 候选币种池中AI500取前20个评分最高的币种
+const ai500Limit = 20
+
 // AutoTrader 自动交易器
 type AutoTrader struct {
 	id                    string // Trader唯一标识
@@ -87,7 +256,56 @@ type AutoTrader struct {
 	isRunning             bool
 	startTime             time.Time        // 系统启动时间
 	callCount             int              // AI调用次数
+	session               *sessionStats    // 本次启动以来的会话统计
 	positionFirstSeenTime map[string]int64 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	exposureLimits        risk.ExposureLimits
+	dcaManager            *strategy.DCAManager       // DCA加仓管理器（未启用时不执行）
+	pyramidManager        *strategy.PyramidManager   // 顺势加仓管理器（未启用时不执行）
+	tpLadders             map[string]*tpLadder       // 分批止盈进度 (symbol_side -> 档位/已成交档数)
+	tpLadderMu            sync.Mutex                 // 保护tpLadders（GetPositions在周期外读取）
+	fundingHarvester      *strategy.FundingHarvester // 资金费率套利策略（未启用时为nil）
+	sched                 *scheduler.Scheduler       // 任务调度器（Run时创建）
+	stopCh                chan struct{}              // 停止信号
+	cycleMu               sync.Mutex                 // 串行化AI决策与策略看守，避免并发下单
+	configMu              sync.RWMutex               // 保护热加载的配置（周期外的并发读取，如Webhook）
+	journal               *store.Store               // 交易日志（未启用时为nil）
+	events                *events.Bus                // 事件总线
+	orders                *orderTracker              // 订单生命周期跟踪（提交后确认成交）
+	execSource            string                     // 当前执行的决策来源（ai/webhook），用于交易日志标记
+	lastCostSync          time.Time                  // 上次同步手续费/资金费流水的时间
+	equityHighWater       float64                    // 历史最高净值（从交易日志恢复，用于回撤熔断）
+	log                   *slog.Logger               // 结构化日志（带trader字段）
+	promptRules           string                     // 候选提示词的追加规则（启动时从文件读取）
+	adlEpisodes           map[string]adlEpisode      // symbol_side -> ADL预警状态（策略看守周期中使用）
+	healthMu              sync.Mutex                 // 保护以下健康检查状态（API并发读取）
+	cycleStartedAt        time.Time                  // 当前周期开始时间（空闲时为零值）
+	lastCycleOK           time.Time                  // 最近一次成功的AI决策周期
+	lastCycleError        string                     // 最近一次AI决策周期的错误
+	paused                atomic.Bool                // 人工暂停（kill switch），停止AI决策和新开仓
+	pauseMu               sync.Mutex                 // 保护pauseState
+	pauseState            store.PauseState           // 暂停来源和原因（持久化到交易日志，重启后保持）
+	positionStreaming     atomic.Bool                // 是否已启动持仓推送（WebSocket）
+	barStreaming          atomic.Bool                // AI决策是否按K线收盘触发（WebSocket）
+	resizeMu              sync.Mutex                 // 保护pendingResize
+	pendingResize         map[string]PositionUpdate  // 待处理的持仓数量变化 (symbol_side -> 最新数量)
+	resizeCh              chan struct{}              // 通知resizeWorker有待处理的变化
+	maintenance           *exchangeStatus            // 交易所维护状态（维护中暂停下单和错误告警）
+	degradation           *degradationLadder         // 数据源故障降级状态（未启用时为nil）
+	entryLimit            *entryLimiter              // 每个决策周期的新开仓数量上限
+	balanceBaseline       *balanceBaseline           // 余额核对基准（首次检查时建立，只在持有cycleMu时替换）
+	clock                 clock.Clock                // 时钟（熔断暂停、每日重置、持仓时长、调度）
+
+	allocator    *capitalAllocator      // 多策略资金分配（未启用时为nil）
+	basisMonitor *strategy.BasisMonitor // 期现基差监控与套利（未启用时为nil）
+	hedgeManager *strategy.HedgeManager // 相关性对冲（未启用时为nil）
+	orderTags    map[string]OrderTag    // 交易所订单文本解析结果缓存（订单ID → 归因，只在成交同步中访问）
+	limitOrders  *limitOrderManager     // GTC限价单跟踪（交易器不支持限价单时为nil）
+	soak         *soakInjector          // 浸泡测试的故障注入和不变量检查（未启用时为nil）
+
+	protectiveSnapshot []TriggerOrder  // 最近一次保存的止损/止盈条件单快照（恢复后清空）
+	throttle           *signalThrottle // 最近执行的信号（去重和开仓节流）
+	softClose          softCloseQueue  // 待人工确认的平仓建议
+	entries            entryQueue      // 待人工确认的开仓建议
 }
 
 // NewAutoTrader 创建自动交易器
@@ -99,6 +317,7 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	if config.Name == "" {
 		config.Name = "Default Trader"
 	}
+	traderLog := logging.For("trader").With("trader", config.ID)
 	if config.AIModel == "" {
 		if config.UseQwen {
 			config.AIModel = "qwen"
@@ -113,15 +332,30 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	if config.AIModel == "custom" {
 		// 使用自定义API
 		mcpClient.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
-		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+		traderLog.Info("使用自定义AI API", "url", config.CustomAPIURL, "model", config.CustomModelName)
 	} else if config.UseQwen || config.AIModel == "qwen" {
 		// 使用Qwen
 		mcpClient.SetQwenAPIKey(config.QwenKey, "")
-		log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
+		traderLog.Info("使用阿里云Qwen AI")
 	} else {
 		// 默认使用DeepSeek
 		mcpClient.SetDeepSeekAPIKey(config.DeepSeekKey)
-		log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+		traderLog.Info("使用DeepSeek AI")
+	}
+	mcpClient.Apply(config.LLM)
+	if config.LLM != (mcp.Options{}) {
+		traderLog.Info("AI客户端设置", "attempts", config.LLM.Attempts(), "max_backoff", config.LLM.Backoff(),
+			"timeout", mcpClient.Timeout, "stream", config.LLM.Stream, "json_mode", config.LLM.JSONMode)
+	}
+
+	// 记录每次AI调用的token消耗（用于汇总报告中的AI成本）
+	if config.Journal != nil {
+		journal, traderID := config.Journal, config.ID
+		mcpClient.OnUsage = func(model string, usage mcp.Usage) {
+			if err := journal.RecordAIUsage(traderID, model, usage.PromptTokens, usage.CompletionTokens); err != nil {
+				traderLog.Warn("记录AI用量失败", "model", model, "err", err)
+			}
+		}
 	}
 
 	// 初始化币种池API
@@ -133,6 +367,9 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	if config.Exchange == "gate" {
 		market.SetTestnet(config.GateTestnet)
 	}
+	if len(config.Bars.Symbols) > 0 {
+		market.DefaultBars().SetSize(config.Bars.Size)
+	}
 
 	// 设置默认交易平台
 	if config.Exchange == "" {
@@ -140,33 +377,70 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	}
 
 	// 根据配置创建对应的交易器
-	var trader Trader
-	var err error
-
-	switch config.Exchange {
-	case "binance":
-		log.Printf("🏦 [%s] 使用币安合约交易", config.Name)
-		trader = NewFuturesTrader(config.BinanceAPIKey, config.BinanceSecretKey)
-	case "hyperliquid":
-		log.Printf("🏦 [%s] 使用Hyperliquid交易", config.Name)
-		trader, err = NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
+	trader, err := NewExchangeTrader(config)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Clock = clock.Or(config.Clock)
+	if config.Events == nil {
+		config.Events = events.NewBus()
+	}
+	if aware, ok := trader.(ClockAware); ok {
+		aware.SetClock(config.Clock)
+	}
+
+	if cached, ok := trader.(AccountCache); ok && config.AccountCacheTTL > 0 {
+		cached.SetCacheDuration(config.AccountCacheTTL)
+	}
+
+	// 止损限价单（模拟交易的止损仍按触发价成交）
+	if config.StopLimitOffsetPct > 0 {
+		if stopLimit, ok := trader.(StopLimitSupport); ok {
+			stopLimit.SetStopLimitOffset(config.StopLimitOffsetPct)
+			traderLog.Info("止损使用限价单", "limit_offset_pct", config.StopLimitOffsetPct)
+		} else {
+			traderLog.Warn("交易器不支持止损限价单，使用市价止损", "exchange", config.Exchange)
 		}
-	case "aster":
-		log.Printf("🏦 [%s] 使用Aster交易", config.Name)
-		trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+	}
+	if config.TriggerExpiration > 0 {
+		if expiring, ok := trader.(TriggerExpirationSupport); ok {
+			expiring.SetTriggerExpiration(config.TriggerExpiration)
+		} else {
+			traderLog.Warn("交易器不支持设置条件单有效期，忽略trigger_expiration", "exchange", config.Exchange)
 		}
-	case "gate":
-		log.Printf("🏦 [%s] 使用Gate.io交易", config.Name)
-		trader, err = NewGateTrader(config.GateAPIKey, config.GateSecretKey, config.GateTestnet)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Gate.io交易器失败: %w", err)
+	}
+	if config.TriggerPriceType != "" {
+		if priced, ok := trader.(TriggerPriceTypeSupport); ok {
+			priced.SetTriggerPriceType(config.TriggerPriceType)
+			traderLog.Info("止损止盈条件单触发价格类型", "price_type", config.TriggerPriceType)
+		} else {
+			traderLog.Warn("交易器不支持选择条件单触发价格类型，忽略trigger_price_type", "exchange", config.Exchange)
+		}
+	}
+	if rounding, ok := trader.(QuantityRoundingSupport); ok {
+		rounding.SetQuantityRounding(config.QuantityRounding)
+		if config.QuantityRounding.Customized() {
+			traderLog.Info("下单数量取整", "mode", config.QuantityRounding.Mode, "below_min", config.QuantityRounding.BelowMin)
+		}
+	} else if config.QuantityRounding.Customized() {
+		traderLog.Warn("交易器不支持设置数量取整策略，忽略quantity_rounding", "exchange", config.Exchange)
+	}
+	if len(config.PortfolioSettles) > 0 {
+		if portfolio, ok := trader.(PortfolioSettleSupport); ok {
+			portfolio.SetPortfolioSettles(config.PortfolioSettles)
+			traderLog.Info("组合视图结算币种", "settles", config.PortfolioSettles)
+		} else {
+			traderLog.Warn("交易器不支持多结算币种，忽略portfolio_settles", "exchange", config.Exchange)
+		}
+	}
+	if config.Margin.Enabled() {
+		if margin, ok := trader.(MarginModeSupport); ok {
+			margin.SetMarginSettings(config.Margin)
+			traderLog.Info("保证金设置", "mode", config.Margin.Mode, "risk_limits", config.Margin.RiskLimits)
+		} else {
+			traderLog.Warn("交易器不支持设置保证金模式和风险限额，忽略margin", "exchange", config.Exchange)
 		}
-	default:
-		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
 	}
 
 	// 验证初始金额配置
@@ -174,11 +448,178 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		return nil, fmt.Errorf("初始金额必须大于0，请在配置中设置InitialBalance")
 	}
 
+	promptRules, err := config.Prompt.LoadRules()
+	if err != nil {
+		return nil, err
+	}
+	if promptRules != "" {
+		traderLog.Info("系统提示词追加规则文件", "prompt_version", config.Prompt.Version, "rules_file", config.Prompt.RulesFile)
+	}
+
+	var soak *soakInjector
+	// 模拟交易：以初始金额作为模拟账户资金，模拟持仓保存在dryrun_state/<id>.json
+	if config.DryRun {
+		paper, err := NewPaperTrader(trader, config.InitialBalance, filepath.Join("dryrun_state", config.ID+".json"))
+		if err != nil {
+			return nil, err
+		}
+		trader = paper
+		if config.Notifier != nil {
+			config.Notifier = dryRunNotifier{config.Notifier}
+		}
+		traderLog.Info("模拟交易模式：使用真实行情，不会向交易所下单")
+		if config.Soak.Enabled {
+			soak = newSoakInjector(config.Soak, paper, config.Clock)
+			mcpClient.Inject = soak.mangle
+			traderLog.Info("浸泡测试：按计划注入故障，每个决策周期后检查不变量", "faults", len(config.Soak.Faults))
+		}
+		if config.ShadowOf != "" {
+			traderLog.Info("影子模式：与实盘trader对照", "shadow_of", config.ShadowOf, "prompt_version", config.Prompt.VersionOrDefault())
+		}
+	}
+
+	// 只读模式：最外层拦截，模拟交易也不会下单（不转发成交流水，避免把账户上其他trader的成交记入本trader）
+	if config.ReadOnly {
+		trader = readOnlyTrader{trader}
+		traderLog.Info("只读模式：只记录决策，不会下单、修改止损止盈或撤单")
+	}
+
 	// 初始化决策日志记录器（使用trader ID创建独立目录）
 	logDir := fmt.Sprintf("decision_logs/%s", config.ID)
 	decisionLogger := logger.NewDecisionLogger(logDir)
 
-	return &AutoTrader{
+	// 风控敞口限制（与AI决策校验的仓位上限一致）
+	exposureLimits := risk.DefaultExposureLimits()
+	if config.DCA.Enabled {
+		traderLog.Info("启用DCA加仓", "drawdown_step_pct", config.DCA.DrawdownStepPct, "max_adds", config.DCA.MaxAdds,
+			"size_multiplier", config.DCA.AddSizeMultiplier, "aggregate_stop_pct", config.DCA.AggregateStopPct)
+	}
+	if config.Pyramid.Enabled {
+		traderLog.Info("启用顺势加仓", "step_r", config.Pyramid.StepR, "max_adds", config.Pyramid.MaxAdds,
+			"add_size_pct", config.Pyramid.AddSizeRatio*100, "stop_r", config.Pyramid.StopR)
+	}
+
+	// 从交易日志恢复历史最高净值（重启后回撤熔断仍然有效）
+	equityHighWater, err := config.Journal.EquityHighWaterMark(config.ID)
+	if err != nil {
+		traderLog.Warn("恢复历史最高净值失败", "err", err)
+	} else if equityHighWater > 0 {
+		traderLog.Info("恢复历史最高净值", "high_water", equityHighWater)
+	}
+
+	orders := newOrderTracker(trader, config.Journal, config.ID, config.Events)
+	orders.band = config.PriceBand
+	orders.clock = config.Clock
+	maintenance := &exchangeStatus{clock: config.Clock}
+	orders.status = maintenance
+	if config.PriceBand.MaxDeviationPct > 0 {
+		action := "拒绝"
+		if config.PriceBand.Clamp {
+			action = "收紧到边界价"
+		}
+		traderLog.Info("启用止损止盈价格偏离检查", "max_deviation_pct", config.PriceBand.MaxDeviationPct, "action", action)
+	}
+	if config.EntryRouting.Mode == "chase" {
+		traderLog.Info("开仓执行方式: chase", "interval", config.EntryRouting.ChaseInterval(),
+			"max_reprices", config.EntryRouting.ChaseReprices(), "timeout", config.EntryRouting.MakerTimeout())
+	} else if config.EntryRouting.Enabled() {
+		traderLog.Info("开仓执行方式: 只挂单，完全未成交时改为市价", "mode", config.EntryRouting.Mode,
+			"timeout", config.EntryRouting.MakerTimeout())
+	}
+	if attempts := config.EntryRouting.ZeroFillAttempts(); attempts > 0 {
+		traderLog.Info("开仓完全未成交时的处理", "zero_fill", config.EntryRouting.ZeroFill, "attempts", attempts)
+	}
+
+	allocator, err := newCapitalAllocator(config.Allocation, config.Journal, config.ID, config.Clock)
+	if err != nil {
+		return nil, err
+	}
+	orders.bookCheck = config.BookCheck
+	orders.rate = newOrderRateGuard(config.OrderRateLimit, config.Clock)
+	orders.downtime = newDowntimeQueue(config.DowntimeQueue, config.Clock)
+	if config.EntryLimit.Enabled() {
+		traderLog.Info("启用每周期开仓数量上限（按信心度执行）", "max_per_cycle", config.EntryLimit.MaxPerCycle,
+			"overflow", map[bool]string{false: "丢弃", true: "顺延到下一周期"}[config.EntryLimit.Queue()])
+	}
+	if config.TradeLossCap.Enabled() {
+		traderLog.Info("启用单笔亏损上限（含手续费）", "max_loss", config.TradeLossCap.MaxLossUSDT,
+			"action", map[bool]string{false: "拒绝开仓", true: "缩小仓位"}[config.TradeLossCap.Resize()])
+	}
+	if config.Staleness.Enabled() {
+		traderLog.Info("启用决策过期保护（0表示不检查）", "max_age_s", config.Staleness.MaxAgeSeconds,
+			"max_move_pct", config.Staleness.MaxMovePct)
+	}
+	if config.BalanceAnomaly.Enabled {
+		usd, pct := config.BalanceAnomaly.Limits()
+		traderLog.Info("启用余额异常检测（容差取较大值，连续超出时告警并强制对账）", "tolerance_usd", usd,
+			"tolerance_pct", pct, "required", config.BalanceAnomaly.Required())
+	}
+	if config.Degradation.Enabled {
+		traderLog.Info("启用数据源故障降级", "poll_interval", config.Degradation.PollInterval(),
+			"kline_stale_after", config.Degradation.KlineStaleAfter(), "llm_failure_limit", config.Degradation.LLMFailureLimit(),
+			"stop_pct", config.Degradation.StopPct())
+	}
+	if config.OrderRateLimit.Enabled() {
+		traderLog.Info("启用下单频率上限（0表示不限）", "per_minute", config.OrderRateLimit.MaxPerMinute,
+			"per_hour", config.OrderRateLimit.MaxPerHour, "symbol_per_minute", config.OrderRateLimit.SymbolMaxPerMinute,
+			"symbol_per_hour", config.OrderRateLimit.SymbolMaxPerHour)
+	}
+	if allocator != nil {
+		orders.allocator = allocator
+		traderLog.Info("启用多策略资金分配", "weights", allocator.Weights(),
+			"max_exposure_multiple", config.Allocation.MaxExposureMultiple, "rebalance", config.Allocation.Rebalance)
+	}
+
+	// 资金费率套利需要交易器同时支持现货交易
+	var fundingHarvester *strategy.FundingHarvester
+	if config.FundingHarvest.Enabled {
+		spot, ok := trader.(strategy.SpotExecutor)
+		if !ok {
+			return nil, fmt.Errorf("资金费率套利需要现货模块，%s 交易器不支持", config.Exchange)
+		}
+		perp := newJournalingExecutor(context.Background(), orders, "funding_harvest")
+		fundingHarvester = strategy.NewFundingHarvester(config.FundingHarvest, perp, spot, market.GetFundingRate)
+		fundingHarvester.SetPercentileSource(market.FundingPercentile)
+		if config.Journal != nil {
+			if err := fundingHarvester.SetStore(journalCarryStore{journal: config.Journal, traderID: config.ID}); err != nil {
+				traderLog.Warn("读取资金费率套利持仓记录失败，重启前的现货数量将按永续张数估算", "err", err)
+			}
+		}
+		traderLog.Info("启用资金费率套利", "symbols", config.FundingHarvest.Symbols,
+			"entry_rate_pct", config.FundingHarvest.EntryFundingRate*100, "exit_rate_pct", config.FundingHarvest.ExitFundingRate*100,
+			"notional_usd", config.FundingHarvest.NotionalUSD)
+	}
+
+	// 期现基差监控同样需要现货模块
+	var basisMonitor *strategy.BasisMonitor
+	if config.Basis.Enabled {
+		spot, ok := trader.(strategy.SpotExecutor)
+		if !ok {
+			return nil, fmt.Errorf("期现基差监控需要现货模块，%s 交易器不支持", config.Exchange)
+		}
+		perp := newJournalingExecutor(context.Background(), orders, "basis")
+		basisMonitor = strategy.NewBasisMonitor(config.Basis, perp, spot, trader.GetMarketPrice)
+		mode := "只监控"
+		if config.Basis.Trade {
+			mode = fmt.Sprintf("溢价≥%.0fbps建仓, ≤%.0fbps解除, 每币种%.0f USDT", config.Basis.EntryBps, config.Basis.ExitBps, config.Basis.NotionalUSD)
+		}
+		traderLog.Info("启用期现基差监控", "symbols", config.Basis.Symbols, "alert_bps", config.Basis.AlertBps, "mode", mode)
+	}
+
+	var hedgeManager *strategy.HedgeManager
+	if config.Hedge.Enabled {
+		hedgeManager = newHedgeManager(config.Hedge, trader, orders)
+		traderLog.Info("启用相关性对冲", "instrument", config.Hedge.Instrument, "lookback", config.Hedge.Lookback,
+			"interval", config.Hedge.Interval, "max_ratio", config.Hedge.MaxRatio)
+	}
+
+	// 恢复人工暂停状态（kill switch重启后仍然有效）
+	pauseState, err := config.Journal.LoadPauseState(config.ID)
+	if err != nil {
+		traderLog.Warn("恢复暂停状态失败", "err", err)
+	}
+
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -188,66 +629,377 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		mcpClient:             mcpClient,
 		decisionLogger:        decisionLogger,
 		initialBalance:        config.InitialBalance,
-		lastResetTime:         time.Now(),
-		startTime:             time.Now(),
+		lastResetTime:         config.Clock.Now(),
+		startTime:             config.Clock.Now(),
+		session:               newSessionStats(config.ID, config.Clock.Now()),
 		callCount:             0,
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
-	}, nil
+		exposureLimits:        exposureLimits,
+		tpLadders:             make(map[string]*tpLadder),
+		pendingResize:         make(map[string]PositionUpdate),
+		resizeCh:              make(chan struct{}, 1),
+		fundingHarvester:      fundingHarvester,
+		stopCh:                make(chan struct{}),
+		journal:               config.Journal,
+		events:                config.Events,
+		orders:                orders,
+		maintenance:           maintenance,
+		entryLimit:            newEntryLimiter(config.EntryLimit),
+		equityHighWater:       equityHighWater,
+		log:                   traderLog,
+		pauseState:            pauseState,
+		clock:                 config.Clock,
+		allocator:             allocator,
+		basisMonitor:          basisMonitor,
+		hedgeManager:          hedgeManager,
+		limitOrders:           newLimitOrderManager(orders),
+		soak:                  soak,
+		throttle:              newSignalThrottle(),
+		softClose:             softCloseQueue{items: make(map[string]*CloseRecommendation)},
+		entries:               entryQueue{items: make(map[string]*EntryProposal)},
+		promptRules:           promptRules,
+		adlEpisodes:           make(map[string]adlEpisode),
+		degradation:           newDegradationLadder(config.Degradation, config.Clock),
+	}
+	maintenance.onEnter = at.enterMaintenance
+	if at.degradation != nil {
+		at.degradation.onChange = at.degradationChanged
+	}
+	orders.rate.onTrip = at.tripOrderRate
+	orders.blackout = at.checkEntrySchedule
+	orders.tradeLoss = at.checkOrderTradeLoss
+	at.dcaManager = strategy.NewDCAManager(config.DCA, func(symbol string) risk.ExposureLimits {
+		return at.addExposureLimits("dca", symbol)
+	})
+	at.pyramidManager = strategy.NewPyramidManager(config.Pyramid, func(symbol string) risk.ExposureLimits {
+		return at.addExposureLimits("pyramid", symbol)
+	})
+	at.subscribeEvents(config.Notifier)
+	at.subscribeSessionStats()
+	at.subscribeTimeline()
+	at.installHooks()
+	if pauseState.Paused {
+		at.paused.Store(true)
+		orders.rate.tripped = pauseState.Source == PauseSourceOrderRate
+		traderLog.Warn("保持暂停状态，恢复交易请使用resume", "since", pauseState.Since.Local().Format("2006-01-02 15:04"),
+			"source", pauseState.Source, "reason", pauseState.Reason)
+	}
+	return at, nil
 }
 
 // Run 运行自动交易主循环
 func (at *AutoTrader) Run() error {
-	at.isRunning = true
-	log.Println("🚀 AI驱动自动交易系统启动")
-	log.Printf("💰 初始余额: %.2f USDT", at.initialBalance)
-	log.Printf("⚙️  扫描间隔: %v", at.config.ScanInterval)
-	log.Println("🤖 AI将全权决定杠杆、仓位大小、止损止盈等参数")
-
-	ticker := time.NewTicker(at.config.ScanInterval)
-	defer ticker.Stop()
+	decisionSpec := at.decisionSchedule()
+	watchdogSpec := at.watchdogSchedule()
+
+	sched := scheduler.New()
+	sched.SetClock(at.clock)
+	decisionJob := func() {
+		at.soak.beginCycle()
+		err := at.runCycle()
+		at.checkSoakInvariants()
+		at.maintenance.observe(err)
+		if err != nil {
+			at.log.Error("AI决策周期执行失败", "err", err)
+			at.notify(notify.KindError, "", "AI决策周期执行失败", err.Error())
+			at.recordTimeline(store.TimelineError, "", "cycle", "AI决策周期执行失败: "+err.Error(), at.clock.Now(), nil)
+		}
+		at.cycleFinished(true, err)
+	}
+	if barClosed := at.startBarTrigger(); barClosed != nil {
+		// K线推送停滞时看门狗按时间执行决策，推送通道关闭后回退到decision计划
+		fallbackSpec := decisionSpec
+		decisionSpec = fmt.Sprintf("%s K线收盘（%s）", at.config.Schedule.BarInterval, at.config.Schedule.BarSymbolOrDefault())
+		if err := sched.AddTriggeredJob(at.name+" AI决策", barClosed, at.config.Schedule.BarWatchdog(), fallbackSpec,
+			at.config.Schedule.Sessions, decisionJob); err != nil {
+			return err
+		}
+	} else if err := sched.AddJob(at.name+" AI决策", decisionSpec, at.config.Schedule.Sessions, decisionJob); err != nil {
+		return err
+	}
+	if at.journal != nil {
+		if err := sched.AddJob(at.name+" 净值快照", at.equitySchedule(), nil, at.recordEquitySnapshot); err != nil {
+			return err
+		}
+	}
+	if at.dcaManager.Enabled() || at.pyramidManager.Enabled() || at.fundingHarvester.Enabled() || at.basisMonitor.Enabled() ||
+		at.hedgeManager.Enabled() || at.config.Schedule.ExitRules.Enabled() || at.config.ADLGuard.Enabled() {
+		// 策略看守不受交易时段限制（止损等风控需要全天运行）
+		if err := sched.AddJob(at.name+" 策略看守", watchdogSpec, nil, at.runWatchdogCycle); err != nil {
+			return err
+		}
+	}
+	if at.limitOrders != nil {
+		if err := sched.AddJob(at.name+" 限价单跟踪", limitOrderPollSpec, nil, at.pollLimitOrders); err != nil {
+			return err
+		}
+	}
+	if err := at.soak.schedule(sched, at.name); err != nil {
+		return err
+	}
+	if at.allocator != nil && at.config.Allocation.Rebalance != "" {
+		if err := sched.AddJob(at.name+" 资金再平衡", at.config.Allocation.Rebalance, nil, at.rebalanceAllocation); err != nil {
+			return err
+		}
+	}
 
-	// 首次立即执行
-	if err := at.runCycle(); err != nil {
-		log.Printf("❌ 执行失败: %v", err)
+	at.isRunning = true
+	at.log.Info("AI驱动自动交易系统启动", "initial_balance", at.initialBalance, "decision", decisionSpec, "watchdog", watchdogSpec)
+	if len(at.config.Schedule.Sessions) > 0 {
+		at.log.Info("交易时段", "sessions", fmt.Sprintf("%+v", at.config.Schedule.Sessions))
+	}
+	if cadence := at.config.Schedule.Cadence; cadence.Enabled() {
+		at.log.Info("分层决策频率（0表示不限制币种数）", "tiers", fmt.Sprintf("%+v", cadence.Tiers),
+			"tail_every", max(cadence.TailEvery, 1), "max_symbols", cadence.MaxSymbols)
 	}
 
-	for at.isRunning {
-		select {
-		case <-ticker.C:
-			if err := at.runCycle(); err != nil {
-				log.Printf("❌ 执行失败: %v", err)
-			}
-		}
+	// 启动对账：重启时恢复持仓、订单和策略状态
+	// 只读模式不接管账户上的持仓（它们属于其他trader或人工操作）
+	// 首次运行时先导入历史交易（对账接管持仓后交易日志不再为空）
+	at.importTradeHistory()
+	if !at.config.ReadOnly {
+		at.cycleMu.Lock()
+		at.reconcile()
+		at.cycleMu.Unlock()
+	}
+	if at.config.ResizeProtectiveOrders && !at.config.ReadOnly {
+		at.startPositionStream()
+	}
+	go at.monitorExchangeStatus()
+	go at.monitorClockSkew()
+	if at.degradation != nil {
+		go at.monitorDegradation()
 	}
+	at.startBarService()
+	at.warmStart()
+	if !at.config.ReadOnly {
+		go at.monitorTriggerExpiry()
+	}
+	if at.config.LedgerMonitor {
+		go at.monitorLedger()
+	}
+
+	at.sched = sched
+	sched.Start()
+	<-at.stopCh
+	sched.Stop()
 
 	return nil
 }
 
 // Stop 停止自动交易
 func (at *AutoTrader) Stop() {
+	if !at.isRunning {
+		return
+	}
 	at.isRunning = false
-	log.Println("⏹ 自动交易系统停止")
+	close(at.stopCh)
+	at.log.Info("自动交易系统停止")
+}
+
+// decisionSchedule AI决策调度表达式（未配置时使用扫描间隔）
+func (at *AutoTrader) decisionSchedule() string {
+	if at.config.Schedule.Decision != "" {
+		return at.config.Schedule.Decision
+	}
+	return fmt.Sprintf("@every %s", at.config.ScanInterval)
+}
+
+// watchdogSchedule 策略看守调度表达式（未配置时与AI决策一致）
+func (at *AutoTrader) watchdogSchedule() string {
+	if at.config.Schedule.Watchdog != "" {
+		return at.config.Schedule.Watchdog
+	}
+	return at.decisionSchedule()
+}
+
+// equitySchedule 净值快照调度表达式
+func (at *AutoTrader) equitySchedule() string {
+	if at.config.Schedule.Equity != "" {
+		return at.config.Schedule.Equity
+	}
+	return "@every 5m"
+}
+
+// recordEquitySnapshot 定时记录账户净值到交易日志，并检查回撤熔断
+func (at *AutoTrader) recordEquitySnapshot() {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		at.log.Warn("净值快照获取余额失败", "err", err)
+		return
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		at.log.Warn("净值快照获取持仓失败", "err", err)
+		return
+	}
+
+	at.closeVanishedPositions(positions, vanishedPositionGrace)
+	at.updateTakeProfitLadders(positions)
+
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	available, _ := balance["availableBalance"].(float64)
+	equity := wallet + unrealized
+
+	if err := at.journal.RecordBalance(store.BalanceSnapshot{
+		TraderID:      at.id,
+		TotalEquity:   equity,
+		WalletBalance: wallet,
+		Available:     available,
+		UnrealizedPnL: unrealized,
+		PositionCount: len(positions),
+	}); err != nil {
+		at.log.Warn("保存净值快照失败", "equity", equity, "err", err)
+	}
+
+	at.checkDrawdown(equity)
+}
+
+// checkDrawdown 回撤熔断：净值相对历史最高点回撤超过MaxDrawdown时暂停交易
+func (at *AutoTrader) checkDrawdown(equity float64) {
+	if equity > at.equityHighWater {
+		at.equityHighWater = equity
+		return
+	}
+	if at.config.MaxDrawdown <= 0 || at.equityHighWater <= 0 || at.clock.Now().Before(at.stopUntil) {
+		return
+	}
+
+	drawdown := (at.equityHighWater - equity) / at.equityHighWater * 100
+	if drawdown >= at.config.MaxDrawdown {
+		at.stopUntil = at.clock.Now().Add(at.config.StopTradingTime)
+		at.log.Error("回撤熔断，暂停交易", "equity", equity, "high_water", at.equityHighWater,
+			"drawdown_pct", drawdown, "max_drawdown_pct", at.config.MaxDrawdown, "until", at.stopUntil.Format("15:04:05"))
+		detail := fmt.Sprintf("净值%.2f较最高点%.2f回撤%.2f%% ≥ %.2f%%，暂停至 %s",
+			equity, at.equityHighWater, drawdown, at.config.MaxDrawdown, at.stopUntil.Format("15:04:05"))
+		at.publishRisk("drawdown", "", "", detail)
+		at.notifyReason(notify.KindRisk, ReasonDrawdown, "", "回撤熔断，暂停交易", detail)
+	}
 }
 
-// runCycle 运行一个交易周期（使用AI全权决策）
-func (at *AutoTrader) runCycle() error {
+// GetEquityHighWater 获取历史最高净值
+func (at *AutoTrader) GetEquityHighWater() float64 {
+	return at.equityHighWater
+}
+
+// runWatchdogCycle 运行策略看守周期（按时间平仓、DCA加仓/总体止损、顺势加仓、资金费率套利），独立于AI决策
+func (at *AutoTrader) runWatchdogCycle() {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	at.cycleStarted()
+	defer at.cycleFinished(false, nil)
+
+	traceCtx, span := tracing.Start(context.Background(), "watchdog.cycle", attribute.String("trader", at.id))
+	defer span.End()
+	traceCtx = withDecision(traceCtx, newDecisionID(at.clock.Now()), "")
+
+	// 清理持仓已平掉的平仓建议
+	if at.config.SoftClose.Enabled() {
+		if positions, err := at.trader.GetPositions(); err == nil {
+			at.pruneCloseRecommendations(positions)
+		}
+	}
+
+	// 按持仓时间平仓（最长持仓时间、周末前平仓）
+	logs := at.enforceTimeExits(traceCtx)
+
+	// 自动减仓排名预警（达到减仓档时主动减仓）
+	logs = append(logs, at.checkADL(traceCtx)...)
+
+	if at.dcaManager.Enabled() || at.pyramidManager.Enabled() {
+		if account, err := cachedAccountSnapshot(at.trader); err != nil {
+			at.log.Warn("加仓检查获取账户快照失败", "err", err)
+		} else {
+			snapshot := account.marketSnapshot(at.trader)
+			executor := newJournalingExecutor(traceCtx, at.orders, "dca")
+			logs = append(logs, at.dcaManager.Evaluate(softCloseExecutor{Trader: executor, at: at, rule: "dca_stop"}, snapshot)...)
+			executor = newJournalingExecutor(traceCtx, at.orders, "pyramid")
+			logs = append(logs, at.pyramidManager.Evaluate(executor, snapshot)...)
+		}
+	}
+
+	// 资金费率套利（独立于AI决策的Delta中性策略）
+	if at.fundingHarvester.Enabled() {
+		logs = append(logs, at.fundingHarvester.Evaluate()...)
+	}
+
+	// 期现基差监控（极端基差告警，启用trade时执行期现套利）
+	if at.basisMonitor.Enabled() {
+		logs = append(logs, at.basisMonitor.Evaluate()...)
+	}
+
+	// 相关性对冲（持仓平仓或减少时解除或减少对冲腿）
+	if at.hedgeManager.Enabled() {
+		logs = append(logs, at.hedgeManager.Evaluate()...)
+	}
+
+	for _, l := range logs {
+		at.log.Info("策略看守: " + l)
+		switch {
+		case strings.HasPrefix(l, "❌"):
+			at.notify(notify.KindError, "", "策略执行失败", l)
+		case strings.HasPrefix(l, "⚠"):
+			at.notify(notify.KindRisk, "", "策略风控提示", l)
+		}
+	}
+}
+
+// runCycle 运行一个交易周期（使用AI全权决策），按 快照 → 决策 → 风控 → 执行 → 确认 的流水线执行，各阶段的超时和失败处理见pipeline.go
+func (at *AutoTrader) runCycle() (err error) {
+	pipeline := at.lockCycle()
+	defer pipeline.release()
+
 	at.callCount++
+	at.execSource = "ai"
+	at.cycleStarted()
+
+	// 链路追踪：快照构建 → LLM决策 → 风控检查 → 下单 → 成交确认
+	traceCtx, span := tracing.Start(context.Background(), "decision.cycle",
+		attribute.String("trader", at.id), attribute.Int("cycle", at.callCount))
+	defer func() { tracing.End(span, err) }()
+	decisionID := newDecisionID(at.clock.Now())
+	traceCtx = withDecision(traceCtx, decisionID, at.config.Prompt.VersionOrDefault())
 
-	log.Printf("\n" + strings.Repeat("=", 70))
-	log.Printf("⏰ %s - AI决策周期 #%d", time.Now().Format("2006-01-02 15:04:05"), at.callCount)
-	log.Printf(strings.Repeat("=", 70))
+	at.log.Info("AI决策周期开始", "cycle", at.callCount, "decision_id", decisionID)
 
 	// 创建决策记录
 	record := &logger.DecisionRecord{
-		ExecutionLog: []string{},
-		Success:      true,
+		DecisionID:    decisionID,
+		PromptVersion: at.config.Prompt.VersionOrDefault(),
+		ExecutionLog:  []string{},
+		Success:       true,
+	}
+	pipeline.record = record
+
+	// 检查止损/止盈条件单是否已在交易所触发
+	if positions, err := at.trader.GetPositions(); err == nil {
+		at.closeVanishedPositions(positions, vanishedPositionGrace)
 	}
 
 	// 1. 检查是否需要停止交易
-	if time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
-		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
+	if at.IsPaused() {
+		at.log.Info("人工暂停中，跳过本次AI决策")
+		record.Success = false
+		record.ErrorMessage = "人工暂停中"
+		at.decisionLogger.LogDecision(record)
+		return nil
+	}
+	if at.maintenance.active() {
+		at.log.Info("交易所维护中，跳过本次AI决策")
+		record.Success = false
+		record.ErrorMessage = "交易所维护中"
+		at.decisionLogger.LogDecision(record)
+		return nil
+	}
+	if at.clock.Now().Before(at.stopUntil) {
+		remaining := at.stopUntil.Sub(at.clock.Now())
+		at.log.Info("风险控制暂停交易中，跳过本次AI决策", "remaining", remaining.Round(time.Minute))
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
 		at.decisionLogger.LogDecision(record)
@@ -255,20 +1007,47 @@ func (at *AutoTrader) runCycle() error {
 	}
 
 	// 2. 重置日盈亏（每天重置）
-	if time.Since(at.lastResetTime) > 24*time.Hour {
+	if at.clock.Now().Sub(at.lastResetTime) > 24*time.Hour {
 		at.dailyPnL = 0
-		at.lastResetTime = time.Now()
-		log.Println("📅 日盈亏已重置")
+		at.lastResetTime = at.clock.Now()
+		at.log.Info("日盈亏已重置")
 	}
 
-	// 3. 收集交易上下文
-	ctx, err := at.buildTradingContext()
+	// 同步成交与手续费/资金费流水，使交易日志中的盈亏为净值，持仓的资金费为最新
+	at.syncTradeCosts()
+
+	// 核对钱包余额变化与交易日志（依赖刚同步的成交和费用流水）
+	at.checkBalanceAnomaly()
+
+	// 3. 快照阶段：收集交易上下文和行情数据
+	ctx, err := runStage(traceCtx, pipeline, stageSnapshot, "", func(stageCtx context.Context) (*decision.Context, error) {
+		_, snapshotSpan := tracing.Start(stageCtx, "decision.snapshot")
+		ctx, err := at.buildTradingContext()
+		if err == nil {
+			if err = decision.FetchMarketData(ctx); err != nil {
+				err = fmt.Errorf("%w: %w", errMarketData, err)
+			}
+		}
+		if err == nil {
+			snapshotSpan.SetAttributes(attribute.Int("positions", len(ctx.Positions)), attribute.Int("candidates", len(ctx.CandidateCoins)))
+		}
+		tracing.End(snapshotSpan, err)
+		return ctx, err
+	})
 	if err != nil {
+		if errors.Is(err, errMarketData) {
+			at.observeKlines(nil)
+		}
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("构建交易上下文失败: %v", err)
 		at.decisionLogger.LogDecision(record)
 		return fmt.Errorf("构建交易上下文失败: %w", err)
 	}
+	snapshot := ctx.Snapshot
+	snapshot.TakenAt = at.clock.Now()
+	at.observeKlines(snapshot)
+	record.SnapshotTime = &snapshot.TakenAt
+	record.SnapshotPrices = snapshot.Prices()
 
 	// 保存账户状态快照
 	record.AccountState = logger.AccountSnapshot{
@@ -286,6 +1065,7 @@ func (at *AutoTrader) runCycle() error {
 			Side:             pos.Side,
 			PositionAmt:      pos.Quantity,
 			EntryPrice:       pos.EntryPrice,
+			EntryVWAP:        pos.EntryVWAP,
 			MarkPrice:        pos.MarkPrice,
 			UnrealizedProfit: pos.UnrealizedPnL,
 			Leverage:         float64(pos.Leverage),
@@ -298,16 +1078,30 @@ func (at *AutoTrader) runCycle() error {
 		record.CandidateCoins = append(record.CandidateCoins, coin.Symbol)
 	}
 
-	log.Printf("📊 账户净值: %.2f USDT | 可用: %.2f USDT | 持仓: %d",
-		ctx.Account.TotalEquity, ctx.Account.AvailableBalance, ctx.Account.PositionCount)
+	at.log.Info("账户状态", "equity", ctx.Account.TotalEquity, "available", ctx.Account.AvailableBalance,
+		"positions", ctx.Account.PositionCount)
+
+	// 每个决策周期同样检查回撤熔断（净值快照周期可能较长）
+	at.checkDrawdown(ctx.Account.TotalEquity)
 
-	// 4. 调用AI获取完整决策
-	log.Println("🤖 正在请求AI分析并决策...")
-	decision, err := decision.GetFullDecision(ctx, at.mcpClient)
+	// 4. 决策阶段：调用AI获取完整决策
+	at.log.Info("正在请求AI分析并决策")
+	decision, err := runStage(traceCtx, pipeline, stageDecide, "", func(stageCtx context.Context) (*decision.FullDecision, error) {
+		_, llmSpan := tracing.Start(stageCtx, "decision.llm", attribute.String("ai_model", at.aiModel))
+		full, err := decision.DecideContext(stageCtx, ctx, at.mcpClient)
+		if full != nil {
+			llmSpan.SetAttributes(attribute.Int("decisions", len(full.Decisions)))
+		}
+		tracing.End(llmSpan, err)
+		return full, err
+	})
+	// 决策阶段释放了周期锁，期间执行的外部信号会改写执行来源
+	at.execSource = "ai"
 
 	// 即使有错误，也保存思维链、决策和输入prompt（用于debug）
 	if decision != nil {
 		record.InputPrompt = decision.UserPrompt
+		record.Omitted = decision.Omitted
 		record.CoTTrace = decision.CoTTrace
 		if len(decision.Decisions) > 0 {
 			decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
@@ -321,73 +1115,135 @@ func (at *AutoTrader) runCycle() error {
 
 		// 打印AI思维链（即使有错误）
 		if decision != nil && decision.CoTTrace != "" {
-			log.Printf("\n" + strings.Repeat("-", 70))
-			log.Println("💭 AI思维链分析（错误情况）:")
-			log.Println(strings.Repeat("-", 70))
-			log.Println(decision.CoTTrace)
-			log.Printf(strings.Repeat("-", 70) + "\n")
+			at.log.Info("AI思维链分析（错误情况）", "cot", decision.CoTTrace)
+		}
+
+		// AI不可用时按规则管理平仓（补挂止损、浮亏超限时平仓）
+		at.degradation.llmResult(err)
+		if mode, _, _ := at.degradation.current(); mode == risk.ModeRulesOnly {
+			for _, l := range at.runRuleExits(traceCtx, snapshot) {
+				at.log.Warn("AI不可用，按规则管理持仓: " + l)
+				record.ExecutionLog = append(record.ExecutionLog, l)
+			}
 		}
 
 		at.decisionLogger.LogDecision(record)
 		return fmt.Errorf("获取AI决策失败: %w", err)
 	}
+	at.degradation.llmResult(nil)
 
 	// 5. 打印AI思维链
-	log.Printf("\n" + strings.Repeat("-", 70))
-	log.Println("💭 AI思维链分析:")
-	log.Println(strings.Repeat("-", 70))
-	log.Println(decision.CoTTrace)
-	log.Printf(strings.Repeat("-", 70) + "\n")
+	at.log.Info("AI思维链分析", "cot", decision.CoTTrace)
 
 	// 6. 打印AI决策
-	log.Printf("📋 AI决策列表 (%d 个):\n", len(decision.Decisions))
+	at.log.Info("AI决策列表", "count", len(decision.Decisions))
 	for i, d := range decision.Decisions {
-		log.Printf("  [%d] %s: %s - %s", i+1, d.Symbol, d.Action, d.Reasoning)
+		args := []any{"index", i + 1, "symbol", d.Symbol, "action", d.Action, "reasoning", d.Reasoning}
 		if d.Action == "open_long" || d.Action == "open_short" {
-			log.Printf("      杠杆: %dx | 仓位: %.2f USDT | 止损: %.4f | 止盈: %.4f",
-				d.Leverage, d.PositionSizeUSD, d.StopLoss, d.TakeProfit)
+			args = append(args, "leverage", d.Leverage, "size_usd", d.PositionSizeUSD, "stop_loss", d.StopLoss, "take_profit", d.TakeProfit)
 		}
+		at.log.Info("AI决策", args...)
 	}
-	log.Println()
-
-	// 7. 对决策排序：确保先平仓后开仓（防止仓位叠加超限）
-	sortedDecisions := sortDecisionsByPriority(decision.Decisions)
 
-	log.Println("🔄 执行顺序（已优化）: 先平仓→后开仓")
-	for i, d := range sortedDecisions {
-		log.Printf("  [%d] %s %s", i+1, d.Symbol, d.Action)
+	// 上一周期超出开仓上限而顺延的开仓（新决策中该币种有动作时以新决策为准）
+	decisions, carried, stale := at.entryLimit.merge(decision.Decisions)
+	for _, d := range carried {
+		at.log.Info("开仓由上一周期顺延", "symbol", d.Symbol, "action", d.Action, "confidence", d.Confidence)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("↪ %s %s 由上一周期顺延", d.Symbol, d.Action))
+	}
+	for _, d := range stale {
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏭ %s %s 顺延的开仓已作废: 本周期决策已包含该币种", d.Symbol, d.Action))
 	}
-	log.Println()
 
-	// 执行决策并记录结果
+	// 7. 对决策排序：确保先平仓后开仓（防止仓位叠加超限），启用开仓上限时开仓按信心度从高到低
+	sortedDecisions := at.entryLimit.rank(sortDecisionsByPriority(decisions))
+
+	executionOrder := make([]string, 0, len(sortedDecisions))
 	for _, d := range sortedDecisions {
+		executionOrder = append(executionOrder, d.Symbol+" "+d.Action)
+	}
+	at.log.Info("执行顺序（先平仓后开仓）", "order", executionOrder)
+
+	// 风控和执行阶段：逐个决策检查并执行，记录结果
+	var opened []logger.DecisionAction // 本周期市价开仓成功的决策（确认阶段检查止损单）
+	entries := 0                       // 本周期成功（含待确认）的开仓数，用于每周期开仓上限
+	for i, d := range sortedDecisions {
+		if pipeline.stalled() {
+			for _, rest := range sortedDecisions[i:] {
+				record.ExecutionLog = append(record.ExecutionLog,
+					fmt.Sprintf("⏭ %s %s 未执行: %s阶段超时", rest.Symbol, rest.Action, pipeline.stragglerStage))
+			}
+			break
+		}
 		actionRecord := logger.DecisionAction{
 			Action:    d.Action,
 			Symbol:    d.Symbol,
 			Quantity:  0,
 			Leverage:  d.Leverage,
 			Price:     0,
-			Timestamp: time.Now(),
+			Reasoning: d.Reasoning,
+			Timestamp: at.clock.Now(),
 			Success:   false,
 		}
+		if isOpenAction(d.Action) {
+			if full, limit := at.entryLimit.full(entries); full {
+				actionRecord.Error = at.overflowEntry(d, limit)
+				record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏭ %s %s 未执行: %s", d.Symbol, d.Action, actionRecord.Error))
+				record.Decisions = append(record.Decisions, actionRecord)
+				continue
+			}
+		}
 
-		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
-			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
+		// 决策时价格：用于执行质量报表计算成交相对决策的滑点；快照同时供风控检查决策是否过期
+		execCtx, execSpan := tracing.Start(traceCtx, "decision.execute",
+			attribute.String("symbol", d.Symbol), attribute.String("action", d.Action))
+		execCtx = withArrivalPrice(execCtx, snapshot.Price(d.Symbol))
+		execCtx = withSnapshot(execCtx, snapshot)
+		action, err := runActionStage(execCtx, pipeline, stageRisk, stageAction{decision: d, record: actionRecord}, at.checkDecision)
+		if err == nil {
+			action, err = runActionStage(execCtx, pipeline, stageExecute, action, at.executeCheckedDecision)
+		}
+		tracing.End(execSpan, err)
+		actionRecord = action.record
+		at.publishDecision(decisionID, d.Symbol, d.Action, actionRecord.Leverage, d.PositionSizeUSD, err)
+		if isOpenAction(d.Action) && (err == nil || errors.Is(err, ErrEntryPendingConfirm)) {
+			entries++
+		}
+		if errors.Is(err, ErrEntryPendingConfirm) {
+			actionRecord.Error = err.Error()
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏸ %s %s 待人工确认", d.Symbol, d.Action))
+		} else if err != nil {
+			at.log.Error("执行决策失败", "symbol", d.Symbol, "action", d.Action, "err", err)
 			actionRecord.Error = err.Error()
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
 		} else {
 			actionRecord.Success = true
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
+			if (d.Action == "open_long" || d.Action == "open_short") && d.LimitPrice <= 0 {
+				opened = append(opened, actionRecord)
+			}
 			// 成功执行后短暂延迟
-			time.Sleep(1 * time.Second)
+			at.clock.Sleep(1 * time.Second)
 		}
 
 		record.Decisions = append(record.Decisions, actionRecord)
 	}
 
-	// 8. 保存决策记录
+	// 8. 确认阶段：新开持仓已挂止损单（异常只告警）
+	if len(opened) > 0 {
+		_, err := runStage(traceCtx, pipeline, stageConfirm, "", func(context.Context) (struct{}, error) {
+			return struct{}{}, at.confirmProtection(opened)
+		})
+		if err != nil {
+			at.log.Warn("确认阶段发现异常", "err", err)
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⚠️ 确认: %v", err))
+			at.notify(notify.KindRisk, "", "开仓确认异常", err.Error())
+		}
+	}
+
+	// 9. 保存决策记录
 	if err := at.decisionLogger.LogDecision(record); err != nil {
-		log.Printf("⚠ 保存决策记录失败: %v", err)
+		at.log.Warn("保存决策记录失败", "err", err)
 	}
 
 	return nil
@@ -395,98 +1251,61 @@ func (at *AutoTrader) runCycle() error {
 
 // buildTradingContext 构建交易上下文
 func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
-	// 1. 获取账户信息
-	balance, err := at.trader.GetBalance()
-	if err != nil {
-		return nil, fmt.Errorf("获取账户余额失败: %w", err)
-	}
-
-	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
-
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
-
-	// Total Equity = 钱包余额 + 未实现盈亏
-	totalEquity := totalWalletBalance + totalUnrealizedProfit
-
-	// 2. 获取持仓信息
-	positions, err := at.trader.GetPositions()
+	// 1. 获取账户快照（余额、持仓和条件单同一时刻查询），转换为与决策引擎共享的快照（行情稍后写入）
+	accountSnapshot, err := fetchAccountSnapshot(at.trader)
 	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
+		return nil, fmt.Errorf("获取账户快照失败: %w", err)
 	}
+	snapshot := accountSnapshot.marketSnapshot(at.trader)
+	account := snapshot.Account
 
+	// 2. 持仓信息
 	var positionInfos []decision.PositionInfo
-	totalMarginUsed := 0.0
 
 	// 当前持仓的key集合（用于清理已平仓的记录）
 	currentPositionKeys := make(map[string]bool)
+	funding := at.openPositionFunding()
+	entries := at.openPositionEntries()
 
-	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity // 空仓数量为负，转为正数
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
-
-		// 计算占用保证金（优先使用API返回的保证金，如果没有则计算）
-		leverage := 10 // 默认值，实际应该从持仓信息获取
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
-		}
-		
-		// 优先使用API返回的保证金值
-		marginUsed := 0.0
-		if margin, ok := pos["margin"].(float64); ok && margin > 0 {
-			marginUsed = margin
-		} else {
-			// 如果没有API返回的保证金，使用计算公式
-			marginUsed = (quantity * markPrice) / float64(leverage)
-		}
-		totalMarginUsed += marginUsed
-
+	for _, pos := range snapshot.Positions {
 		// 计算盈亏百分比
 		pnlPct := 0.0
-		if side == "long" {
-			pnlPct = ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
+		if pos.Side == "long" {
+			pnlPct = ((pos.MarkPrice - pos.EntryPrice) / pos.EntryPrice) * float64(pos.Leverage) * 100
 		} else {
-			pnlPct = ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
+			pnlPct = ((pos.EntryPrice - pos.MarkPrice) / pos.EntryPrice) * float64(pos.Leverage) * 100
 		}
 
 		// 跟踪持仓首次出现时间
-		posKey := symbol + "_" + side
+		posKey := pos.Symbol + "_" + pos.Side
 		currentPositionKeys[posKey] = true
 		if _, exists := at.positionFirstSeenTime[posKey]; !exists {
 			// 新持仓，记录当前时间
-			at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+			at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
 		}
 		updateTime := at.positionFirstSeenTime[posKey]
+		tpFilled, tpLevels := at.takeProfitLadderProgress(pos.Symbol, pos.Side)
 
 		positionInfos = append(positionInfos, decision.PositionInfo{
-			Symbol:           symbol,
-			Side:             side,
-			EntryPrice:       entryPrice,
-			MarkPrice:        markPrice,
-			Quantity:         quantity,
-			Leverage:         leverage,
-			UnrealizedPnL:    unrealizedPnl,
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			EntryPrice:       pos.EntryPrice,
+			EntryVWAP:        entries[posKey].VWAP,
+			EntryTWAP:        entries[posKey].TWAP,
+			EntryOrders:      entries[posKey].Orders,
+			MarkPrice:        pos.MarkPrice,
+			Quantity:         pos.Quantity,
+			Leverage:         pos.Leverage,
+			UnrealizedPnL:    pos.UnrealizedPnL,
 			UnrealizedPnLPct: pnlPct,
-			LiquidationPrice: liquidationPrice,
-			MarginUsed:       marginUsed,
+			LiquidationPrice: pos.LiquidationPrice,
+			MarginUsed:       pos.Margin,
+			Funding:          funding[posKey],
+			StopLoss:         pos.StopLoss,
+			TakeProfit:       pos.TakeProfit,
+			TPLevelsFilled:   tpFilled,
+			TPLevels:         tpLevels,
+			ADLRank:          pos.ADLRank,
 			UpdateTime:       updateTime,
 		})
 	}
@@ -501,13 +1320,10 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 3. 获取合并的候选币种池（AI500 + OI Top，去重）
 	// 无论有没有持仓，都分析相同数量的币种（让AI看到所有好机会）
 	// AI会根据保证金使用率和现有持仓情况，自己决定是否要换仓
-	const ai500Limit = 20 // AI500取前20个评分最高的币种
-
 	// 获取合并后的币种池（AI500 + OI Top）
 	mergedPool, err := pool.GetMergedCoinPool(ai500Limit)
 	if err != nil {
-		log.Printf("⚠️  获取合并币种池失败: %v", err)
-		log.Printf("📋 候选币种列表为空，说明当前没有明显的强信号机会")
+		at.log.Warn("获取合并币种池失败，候选币种列表为空", "err", err)
 		// 不返回错误，继续执行（候选币种列表可以为空）
 		mergedPool = &pool.MergedCoinPool{
 			AllSymbols:    []string{},
@@ -525,28 +1341,40 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		})
 	}
 
+	// 按币种分层的决策频率：只评估本周期到期的币种（分层币种不在币种池中时也加入）
+	if cadence := at.config.Schedule.Cadence; cadence.Enabled() {
+		due := cadence.Select(at.callCount, mergedPool.AllSymbols)
+		candidateCoins = candidateCoins[:0]
+		for _, symbol := range due {
+			sources := mergedPool.SymbolSources[symbol]
+			if len(sources) == 0 {
+				sources = []string{"tier"}
+			}
+			candidateCoins = append(candidateCoins, decision.CandidateCoin{Symbol: symbol, Sources: sources})
+		}
+		at.log.Info("分层决策频率: 本周期评估的币种", "cycle", at.callCount, "due", len(due), "pool", len(mergedPool.AllSymbols))
+	}
+
 	if len(candidateCoins) == 0 {
-		log.Printf("📋 候选币种列表为空，说明当前没有明显的强信号机会")
-		log.Printf("   可能原因: 1) 使用默认币种列表但未配置coin_pool_api_url 2) API获取失败 3) 市场没有强信号")
+		at.log.Info("候选币种列表为空（未配置coin_pool_api_url、API获取失败或市场没有强信号）")
 	} else {
-		log.Printf("📋 合并币种池: AI500前%d + OI_Top20 = 总计%d个候选币种",
-			ai500Limit, len(candidateCoins))
+		at.log.Info("合并币种池（AI500 + OI_Top20）", "ai500_limit", ai500Limit, "candidates", len(candidateCoins))
 	}
 
 	// 4. 计算总盈亏
-	totalPnL := totalEquity - at.initialBalance
+	totalPnL := account.Equity - at.initialBalance
 	totalPnLPct := 0.0
 	if at.initialBalance > 0 {
 		totalPnLPct = (totalPnL / at.initialBalance) * 100
 	}
 
 	marginUsedPct := 0.0
-	if totalEquity > 0 {
-		marginUsedPct = (totalMarginUsed / totalEquity) * 100
+	if account.Equity > 0 {
+		marginUsedPct = (account.MarginUsed / account.Equity) * 100
 		// 安全检查：保证金使用率不应该超过100%（除非账户严重亏损）
 		if marginUsedPct > 100 {
-			log.Printf("⚠️  警告: 保证金使用率异常高(%.1f%%)，可能原因: 1) 账户亏损导致净值下降 2) 持仓保证金计算错误", marginUsedPct)
-			log.Printf("   详情: 总保证金=%.2f, 账户净值=%.2f, 持仓数量=%d", totalMarginUsed, totalEquity, len(positionInfos))
+			at.log.Warn("保证金使用率异常高（账户亏损导致净值下降或持仓保证金计算错误）", "margin_used_pct", marginUsedPct,
+				"margin_used", account.MarginUsed, "equity", account.Equity, "positions", len(positionInfos))
 			// 限制显示为100%，避免误导AI
 			marginUsedPct = 100.0
 		}
@@ -556,46 +1384,237 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 假设每3分钟一个周期，100个周期 = 5小时，足够覆盖大部分交易
 	performance, err := at.decisionLogger.AnalyzePerformance(100)
 	if err != nil {
-		log.Printf("⚠️  分析历史表现失败: %v", err)
+		at.log.Warn("分析历史表现失败", "err", err)
 		// 不影响主流程，继续执行（但设置performance为nil以避免传递错误数据）
 		performance = nil
 	}
 
 	// 6. 构建上下文
 	ctx := &decision.Context{
-		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
-		RuntimeMinutes:  int(time.Since(at.startTime).Minutes()),
+		CurrentTime:     at.clock.Now().Format("2006-01-02 15:04:05"),
+		RuntimeMinutes:  int(at.clock.Now().Sub(at.startTime).Minutes()),
 		CallCount:       at.callCount,
 		BTCETHLeverage:  at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
 		AltcoinLeverage: at.config.AltcoinLeverage, // 使用配置的杠杆倍数
+		AutoLeverage:    at.config.AutoLeverage.Enabled,
+		RiskProfile:     func(symbol string) risk.RiskProfile { return at.riskProfile("ai", symbol) },
+		RiskRules:       at.config.RiskProfiles.Overrides("ai"),
+		PromptRules:     at.promptRules,
+		TokenBudget:     at.config.Prompt.MaxContextTokens,
 		Account: decision.AccountInfo{
-			TotalEquity:      totalEquity,
-			AvailableBalance: availableBalance,
-			TotalPnL:         totalPnL,
-			TotalPnLPct:      totalPnLPct,
-			MarginUsed:       totalMarginUsed,
-			MarginUsedPct:    marginUsedPct,
-			PositionCount:    len(positionInfos),
+			TotalEquity:       account.Equity,
+			AvailableBalance:  account.Available,
+			TotalPnL:          totalPnL,
+			TotalPnLPct:       totalPnLPct,
+			MarginUsed:        account.MarginUsed,
+			MarginUsedPct:     marginUsedPct,
+			EffectiveLeverage: account.EffectiveLeverage,
+			PositionCount:     len(positionInfos),
 		},
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
+		Snapshot:       snapshot,
 	}
+	ctx.Events = at.upcomingEvents()
 
 	return ctx, nil
 }
 
+// ExecuteExternalDecision 执行外部信号（如TradingView Webhook），与AI决策走相同的风控和执行流程
+func (at *AutoTrader) ExecuteExternalDecision(d *decision.Decision, source string) (err error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	traceCtx, span := tracing.Start(context.Background(), "external.execute",
+		attribute.String("trader", at.id), attribute.String("source", source),
+		attribute.String("symbol", d.Symbol), attribute.String("action", d.Action))
+	defer func() { tracing.End(span, err) }()
+	decisionID := newDecisionID(at.clock.Now())
+	traceCtx = withDecision(traceCtx, decisionID, "")
+
+	at.log.Info("收到外部信号", "source", source, "symbol", d.Symbol, "action", d.Action)
+	at.execSource = source
+
+	record := &logger.DecisionRecord{
+		DecisionID:   decisionID,
+		ExecutionLog: []string{},
+		Success:      true,
+	}
+	decisionJSON, _ := json.MarshalIndent([]decision.Decision{*d}, "", "  ")
+	record.DecisionJSON = string(decisionJSON)
+	record.CoTTrace = d.Reasoning
+
+	_, riskSpan := tracing.Start(traceCtx, "risk.check")
+	err = at.checkExternalDecision(d)
+	tracing.End(riskSpan, err)
+	if err != nil {
+		at.publishRisk("external_signal", d.Symbol, d.Action, err.Error())
+		at.notifyReason(notify.KindRisk, riskReason("external_signal"), d.Symbol,
+			fmt.Sprintf("外部信号被风控拒绝: %s %s", d.Symbol, d.Action), err.Error())
+	}
+	if err == nil {
+		actionRecord := logger.DecisionAction{
+			Action:    d.Action,
+			Symbol:    d.Symbol,
+			Leverage:  d.Leverage,
+			Reasoning: d.Reasoning,
+			Timestamp: at.clock.Now(),
+		}
+		err = at.executeDecisionWithRecord(traceCtx, d, &actionRecord)
+		at.publishDecision(decisionID, d.Symbol, d.Action, actionRecord.Leverage, d.PositionSizeUSD, err)
+		if err != nil {
+			actionRecord.Error = err.Error()
+		} else {
+			actionRecord.Success = true
+		}
+		record.Decisions = append(record.Decisions, actionRecord)
+	}
+
+	if errors.Is(err, ErrEntryPendingConfirm) {
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏸ [%s] %s %s 待人工确认", source, d.Symbol, d.Action))
+	} else if err != nil {
+		at.log.Error("外部信号执行失败", "source", source, "symbol", d.Symbol, "action", d.Action, "err", err)
+		record.Success = false
+		record.ErrorMessage = err.Error()
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ [%s] %s %s 失败: %v", source, d.Symbol, d.Action, err))
+	} else {
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ [%s] %s %s 成功", source, d.Symbol, d.Action))
+	}
+
+	if logErr := at.decisionLogger.LogDecision(record); logErr != nil {
+		at.log.Warn("保存决策记录失败", "err", logErr)
+	}
+	return err
+}
+
+// checkExternalDecision 外部信号的风控检查（风控暂停、杠杆上限、敞口上限）
+func (at *AutoTrader) checkExternalDecision(d *decision.Decision) error {
+	if d.Action != "open_long" && d.Action != "open_short" {
+		return nil
+	}
+
+	if at.clock.Now().Before(at.stopUntil) {
+		return fmt.Errorf("风险控制暂停中，拒绝开仓")
+	}
+
+	profile := at.riskProfile(at.execSource, d.Symbol)
+	if maxLeverage := profile.MaxLeverage; !at.config.AutoLeverage.Enabled && d.Leverage > maxLeverage {
+		return fmt.Errorf("杠杆%dx超过配置上限%dx", d.Leverage, maxLeverage)
+	}
+
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	if maxValue := (wallet + unrealized) * profile.MaxPositionMultiple; d.PositionSizeUSD > maxValue {
+		return fmt.Errorf("仓位%.2f USDT超过敞口上限%.2f USDT", d.PositionSizeUSD, maxValue)
+	}
+	return nil
+}
+
+// GetWebhookConfig 获取外部信号配置
+func (at *AutoTrader) GetWebhookConfig() webhook.Config {
+	at.configMu.RLock()
+	defer at.configMu.RUnlock()
+	return at.config.Webhook
+}
+
 // executeDecisionWithRecord 执行AI决策并记录详细信息
-func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+func (at *AutoTrader) executeDecisionWithRecord(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) (err error) {
+	ctx, span := tracing.Start(ctx, "decision.execute",
+		attribute.String("symbol", decision.Symbol), attribute.String("action", decision.Action))
+	defer func() { tracing.End(span, err) }()
+
+	if err = at.checkDecision(ctx, decision, actionRecord); err != nil {
+		return err
+	}
+	return at.executeCheckedDecision(ctx, decision, actionRecord)
+}
+
+// checkDecision 执行前的风控检查：信号节流、禁止开仓时间、自动杠杆和强平距离（可能修改决策的杠杆）
+func (at *AutoTrader) checkDecision(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) (err error) {
+	// 信号去重和开仓节流：AI连续周期重复同一决策、外部信号重复推送时不重复执行
+	if err = at.checkSignalThrottle(decision.Symbol, decision.Action); err != nil {
+		at.log.Info("信号被节流", "symbol", decision.Symbol, "action", decision.Action, "source", at.execSource, "reason", err)
+		at.publishRisk("throttle", decision.Symbol, decision.Action, err.Error())
+		return err
+	}
+
+	// 决策过期保护：LLM响应慢或执行排队时，按已过期的行情快照做出的决策不再执行
+	if err = at.checkStaleness(ctx, decision); err != nil {
+		at.log.Warn("决策已过期，拒绝执行", "symbol", decision.Symbol, "action", decision.Action, "reason", err)
+		at.publishRisk("staleness", decision.Symbol, decision.Action, err.Error())
+		return err
+	}
+
+	// 禁止开仓时间（风控暂停/资金费结算前/周末）：只拒绝开仓，平仓不受影响
+	if decision.Action == "open_long" || decision.Action == "open_short" {
+		_, riskSpan := tracing.Start(ctx, "risk.check")
+		err = at.checkEntryAllowed()
+		tracing.End(riskSpan, err)
+		if err != nil {
+			at.publishRiskReason("entry_blocked", at.entryBlockedReason(), decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+		if err = at.checkRegime(decision); err != nil {
+			at.publishRisk("regime", decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+		if err = at.checkCalendar(); err != nil {
+			at.publishRisk("calendar", decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+		if at.config.AutoLeverage.Enabled {
+			at.applyAutoLeverage(decision)
+			actionRecord.Leverage = decision.Leverage
+		}
+		if err = at.checkTradeLoss(decision); err != nil {
+			at.publishRisk("trade_loss", decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+		if err = at.checkRiskProfile(decision); err != nil {
+			at.publishRisk("risk_profile", decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+		if err = at.checkLiquidation(decision); err != nil {
+			at.publishRisk("liquidation", decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+		if err = at.checkAccountLimits(decision); err != nil {
+			at.publishRisk("account_limit", decision.Symbol, decision.Action, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// executeCheckedDecision 执行已通过风控检查的决策，成功后记录信号（用于节流）
+func (at *AutoTrader) executeCheckedDecision(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) (err error) {
+	defer func() {
+		if err == nil {
+			at.recordSignal(decision.Symbol, decision.Action)
+		}
+	}()
+
+	ctx = withReasoning(ctx, decision.Reasoning)
+
+	// 开仓确认模式：生成开仓建议，确认后再执行
+	if (decision.Action == "open_long" || decision.Action == "open_short") && at.deferEntry(decision) {
+		return ErrEntryPendingConfirm
+	}
+
 	switch decision.Action {
 	case "open_long":
-		return at.executeOpenLongWithRecord(decision, actionRecord)
+		return at.executeOpenLongWithRecord(ctx, decision, actionRecord)
 	case "open_short":
-		return at.executeOpenShortWithRecord(decision, actionRecord)
+		return at.executeOpenShortWithRecord(ctx, decision, actionRecord)
 	case "close_long":
-		return at.executeCloseLongWithRecord(decision, actionRecord)
+		return at.executeCloseLongWithRecord(ctx, decision, actionRecord)
 	case "close_short":
-		return at.executeCloseShortWithRecord(decision, actionRecord)
+		return at.executeCloseShortWithRecord(ctx, decision, actionRecord)
 	case "hold", "wait":
 		// 无需执行，仅记录
 		return nil
@@ -604,18 +1623,52 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 	}
 }
 
+// applyAutoLeverage 按币种4小时ATR计算开仓杠杆，覆盖AI或外部信号给出的杠杆
+// 无法获取行情时使用1倍杠杆
+func (at *AutoTrader) applyAutoLeverage(d *decision.Decision) {
+	maxLeverage := at.riskProfile(at.execSource, d.Symbol).MaxLeverage
+	var price, atr float64
+	data, err := market.Get(d.Symbol)
+	if err != nil {
+		at.log.Warn("获取行情失败，自动杠杆使用1倍", "symbol", d.Symbol, "err", err)
+	} else if data.LongerTermContext != nil {
+		price, atr = data.CurrentPrice, data.LongerTermContext.ATR14
+	}
+	leverage := at.config.AutoLeverage.Leverage(price, atr, maxLeverage)
+	at.log.Info("自动杠杆", "symbol", d.Symbol, "requested", d.Leverage, "leverage", leverage, "atr", atr, "price", price, "max", maxLeverage)
+	d.Leverage = leverage
+}
+
+// checkEntryAllowed 禁止开仓检查（风控暂停、资金费结算前、周末、数据源故障降级）
+func (at *AutoTrader) checkEntryAllowed() error {
+	if at.IsPaused() {
+		return fmt.Errorf("人工暂停中，拒绝开仓")
+	}
+	if at.clock.Now().Before(at.stopUntil) {
+		return fmt.Errorf("风险控制暂停中，拒绝开仓")
+	}
+	return at.checkEntrySchedule()
+}
+
+// checkEntrySchedule 检查禁止开仓的时间规则（资金费结算前后、周末、禁止开仓时段）和数据源故障降级，平仓不受影响
+// 除AI决策和外部信号外，策略看守的加仓、套利等开仓单在下单前同样检查
+func (at *AutoTrader) checkEntrySchedule() error {
+	if blocked, reason := at.config.Schedule.EntryBlocked(at.clock.Now()); blocked {
+		return fmt.Errorf("%s", reason)
+	}
+	return at.degradation.entriesBlocked()
+}
+
 // executeOpenLongWithRecord 执行开多仓并记录详细信息
-func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📈 开多仓: %s", decision.Symbol)
+func (at *AutoTrader) executeOpenLongWithRecord(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	at.log.Info("开多仓", "symbol", decision.Symbol, "size_usd", decision.PositionSizeUSD, "leverage", decision.Leverage)
 
-	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-				return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
-			}
-		}
+	// ⚠️ 关键：检查交易所和交易日志中是否已有同币种同方向的持仓或未完成的开仓单，如果有则拒绝开仓（防止仓位叠加超限）
+	if err := at.checkDuplicateEntry(decision.Symbol, "long"); err != nil {
+		return fmt.Errorf("❌ 拒绝开仓以防止仓位叠加超限，如需换仓请先给出 close_long 决策: %w", err)
+	}
+	if decision.LimitPrice > 0 {
+		return at.placeLimitEntry(ctx, decision, "long", actionRecord)
 	}
 
 	// 获取当前价格
@@ -630,45 +1683,59 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 开仓
-	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
+	order, fill, err := at.placeEntry(ctx, decision.Symbol, "long", quantity, marketData.CurrentPrice, decision.Leverage)
 	if err != nil {
 		return err
 	}
 
+	// 按实际成交数量设置止损止盈（部分成交时避免保护单数量大于持仓）
+	quantity = fill.FilledQty
+	actionRecord.Quantity = fill.FilledQty
+	actionRecord.Price = fill.AvgPrice
+
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.log.Info("开仓成功", "symbol", decision.Symbol, "order_id", order["orderId"], "quantity", quantity, "avg_price", fill.AvgPrice)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_long"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	// 设置止损止盈（触发价先经过合理性检查，可能被收紧到边界价；信号指定有效期或触发价格类型时按指定参数挂单）
+	trigger := triggerOptions(decision)
+	stopLoss, slErr := at.orders.checkTriggerPrice(decision.Symbol, "LONG", "stop_loss", decision.StopLoss)
+	if slErr == nil {
+		slErr = at.placeStopLoss(decision.Symbol, "LONG", quantity, stopLoss, trigger)
+	} else {
+		stopLoss = decision.StopLoss
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	if slErr != nil {
+		at.log.Warn("设置止损失败", "symbol", decision.Symbol, "stop_loss", stopLoss, "err", slErr)
+	} else if at.pyramidManager.Enabled() {
+		at.pyramidManager.Track(decision.Symbol, "long", quantity, fill.AvgPrice, stopLoss, decision.TakeProfit)
 	}
+	tpErr := at.setTakeProfit(decision.Symbol, "long", quantity, fill.AvgPrice, stopLoss, decision.TakeProfit, trigger)
+	if tpErr != nil {
+		at.log.Warn("设置止盈失败", "symbol", decision.Symbol, "take_profit", decision.TakeProfit, "err", tpErr)
+	}
+	recordProtectiveOrder(at.journal, at.id, decision.Symbol, "LONG", "stop_loss", quantity, stopLoss, slErr)
 
 	return nil
 }
 
 // executeOpenShortWithRecord 执行开空仓并记录详细信息
-func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📉 开空仓: %s", decision.Symbol)
+func (at *AutoTrader) executeOpenShortWithRecord(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	at.log.Info("开空仓", "symbol", decision.Symbol, "size_usd", decision.PositionSizeUSD, "leverage", decision.Leverage)
 
-	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
-	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
-			}
-		}
+	// ⚠️ 关键：检查交易所和交易日志中是否已有同币种同方向的持仓或未完成的开仓单，如果有则拒绝开仓（防止仓位叠加超限）
+	if err := at.checkDuplicateEntry(decision.Symbol, "short"); err != nil {
+		return fmt.Errorf("❌ 拒绝开仓以防止仓位叠加超限，如需换仓请先给出 close_short 决策: %w", err)
+	}
+	if decision.LimitPrice > 0 {
+		return at.placeLimitEntry(ctx, decision, "short", actionRecord)
 	}
 
 	// 获取当前价格
@@ -683,36 +1750,52 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 开仓
-	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
+	order, fill, err := at.placeEntry(ctx, decision.Symbol, "short", quantity, marketData.CurrentPrice, decision.Leverage)
 	if err != nil {
 		return err
 	}
 
+	// 按实际成交数量设置止损止盈（部分成交时避免保护单数量大于持仓）
+	quantity = fill.FilledQty
+	actionRecord.Quantity = fill.FilledQty
+	actionRecord.Price = fill.AvgPrice
+
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.log.Info("开仓成功", "symbol", decision.Symbol, "order_id", order["orderId"], "quantity", quantity, "avg_price", fill.AvgPrice)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_short"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	// 设置止损止盈（触发价先经过合理性检查，可能被收紧到边界价；信号指定有效期或触发价格类型时按指定参数挂单）
+	trigger := triggerOptions(decision)
+	stopLoss, slErr := at.orders.checkTriggerPrice(decision.Symbol, "SHORT", "stop_loss", decision.StopLoss)
+	if slErr == nil {
+		slErr = at.placeStopLoss(decision.Symbol, "SHORT", quantity, stopLoss, trigger)
+	} else {
+		stopLoss = decision.StopLoss
+	}
+	if slErr != nil {
+		at.log.Warn("设置止损失败", "symbol", decision.Symbol, "stop_loss", stopLoss, "err", slErr)
+	} else if at.pyramidManager.Enabled() {
+		at.pyramidManager.Track(decision.Symbol, "short", quantity, fill.AvgPrice, stopLoss, decision.TakeProfit)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	tpErr := at.setTakeProfit(decision.Symbol, "short", quantity, fill.AvgPrice, stopLoss, decision.TakeProfit, trigger)
+	if tpErr != nil {
+		at.log.Warn("设置止盈失败", "symbol", decision.Symbol, "take_profit", decision.TakeProfit, "err", tpErr)
 	}
+	recordProtectiveOrder(at.journal, at.id, decision.Symbol, "SHORT", "stop_loss", quantity, stopLoss, slErr)
 
 	return nil
 }
 
 // executeCloseLongWithRecord 执行平多仓并记录详细信息
-func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  🔄 平多仓: %s", decision.Symbol)
+func (at *AutoTrader) executeCloseLongWithRecord(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	at.log.Info("平多仓", "symbol", decision.Symbol)
 
 	// 获取当前价格
 	marketData, err := market.Get(decision.Symbol)
@@ -722,7 +1805,14 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 平仓
-	order, err := at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
+	order, _, err := at.orders.Place(ctx, at.execSource, decision.Symbol, "close_long", 0, marketData.CurrentPrice, 0,
+		func() (map[string]interface{}, error) {
+			return at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
+		})
+	if errors.Is(err, ErrPositionNotFound) {
+		at.log.Warn("持仓已不存在（可能已触发止损/止盈），跳过平仓", "symbol", decision.Symbol)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -732,13 +1822,13 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 平仓成功")
+	at.log.Info("平仓成功", "symbol", decision.Symbol, "order_id", order["orderId"])
 	return nil
 }
 
 // executeCloseShortWithRecord 执行平空仓并记录详细信息
-func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  🔄 平空仓: %s", decision.Symbol)
+func (at *AutoTrader) executeCloseShortWithRecord(ctx context.Context, decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	at.log.Info("平空仓", "symbol", decision.Symbol)
 
 	// 获取当前价格
 	marketData, err := market.Get(decision.Symbol)
@@ -748,7 +1838,14 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 平仓
-	order, err := at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
+	order, _, err := at.orders.Place(ctx, at.execSource, decision.Symbol, "close_short", 0, marketData.CurrentPrice, 0,
+		func() (map[string]interface{}, error) {
+			return at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
+		})
+	if errors.Is(err, ErrPositionNotFound) {
+		at.log.Warn("持仓已不存在（可能已触发止损/止盈），跳过平仓", "symbol", decision.Symbol)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -758,7 +1855,7 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 平仓成功")
+	at.log.Info("平仓成功", "symbol", decision.Symbol, "order_id", order["orderId"])
 	return nil
 }
 
@@ -796,13 +1893,33 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		"exchange":        at.exchange,
 		"is_running":      at.isRunning,
 		"start_time":      at.startTime.Format(time.RFC3339),
-		"runtime_minutes": int(time.Since(at.startTime).Minutes()),
+		"runtime_minutes": int(at.clock.Now().Sub(at.startTime).Minutes()),
 		"call_count":      at.callCount,
 		"initial_balance": at.initialBalance,
 		"scan_interval":   at.config.ScanInterval.String(),
+		"decision_cron":   at.decisionSchedule(),
+		"watchdog_cron":   at.watchdogSchedule(),
 		"stop_until":      at.stopUntil.Format(time.RFC3339),
+		"paused":          at.IsPaused(),
+		"maintenance":     at.maintenance.active(),
+		"degradation":     at.degradationStatus(),
+		"queued_orders":   at.QueuedOrders(),
+		"queued_entries":  at.entryLimit.list(),
+		"pause":           at.PauseState(),
 		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
 		"ai_provider":     aiProvider,
+		"llm":             at.LLMMetrics(),
+		"dry_run":         at.config.DryRun,
+		"read_only":       at.config.ReadOnly,
+		"shadow_of":       at.config.ShadowOf,
+		"prompt_version":  at.config.Prompt.VersionOrDefault(),
+		"carry_positions": at.fundingHarvester.GetPositions(),
+		"allocation":      at.allocator.Status(),
+		"basis":           at.basisMonitor.GetQuotes(),
+		"basis_positions": at.basisMonitor.GetPositions(),
+		"hedges":          at.hedgeManager.GetPairs(),
+		"balance_check":   at.balanceBaseline,
+		"soak":            at.soak.Status(),
 	}
 }
 
@@ -839,6 +1956,7 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 
 	totalMarginUsed := 0.0
 	totalUnrealizedPnL := 0.0
+	totalNotional := 0.0
 	for _, pos := range positions {
 		markPrice := pos["markPrice"].(float64)
 		quantity := pos["positionAmt"].(float64)
@@ -862,6 +1980,10 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 			marginUsed = (quantity * markPrice) / float64(leverage)
 		}
 		totalMarginUsed += marginUsed
+		totalNotional += quantity * markPrice
+	}
+	if initialMargin, ok := balance["totalInitialMargin"].(float64); ok && initialMargin > 0 {
+		totalMarginUsed = initialMargin // 统一账户按交易所计算的初始保证金
 	}
 
 	totalPnL := totalEquity - at.initialBalance
@@ -875,14 +1997,14 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
 		// 安全检查：保证金使用率不应该超过100%（除非账户严重亏损）
 		if marginUsedPct > 100 {
-			log.Printf("⚠️  警告: 保证金使用率异常高(%.1f%%)，可能原因: 1) 账户亏损导致净值下降 2) 持仓保证金计算错误", marginUsedPct)
-			log.Printf("   详情: 总保证金=%.2f, 账户净值=%.2f, 持仓数量=%d", totalMarginUsed, totalEquity, len(positions))
+			at.log.Warn("保证金使用率异常高（账户亏损导致净值下降或持仓保证金计算错误）", "margin_used_pct", marginUsedPct,
+				"margin_used", totalMarginUsed, "equity", totalEquity, "positions", len(positions))
 			// 限制显示为100%，避免误导AI
 			marginUsedPct = 100.0
 		}
 	}
 
-	return map[string]interface{}{
+	info := map[string]interface{}{
 		// 核心字段
 		"total_equity":      totalEquity,           // 账户净值 = wallet + unrealized
 		"wallet_balance":    totalWalletBalance,    // 钱包余额（不含未实现盈亏）
@@ -900,7 +2022,24 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"position_count":  len(positions),  // 持仓数量
 		"margin_used":     totalMarginUsed, // 保证金占用
 		"margin_used_pct": marginUsedPct,   // 保证金使用率
-	}, nil
+
+		// 账户杠杆
+		"total_notional":         totalNotional,                                           // 持仓总名义价值
+		"effective_leverage":     risk.EffectiveLeverage(totalNotional, totalEquity),      // 有效杠杆（总名义价值 / 净值）
+		"margin_utilization_pct": risk.MarginUtilizationPct(totalMarginUsed, totalEquity), // 保证金使用率（未截断）
+	}
+
+	// 币种明细和按指数价的美元估值（交易器提供时）
+	if currencies := BalanceCurrencies(balance); currencies != nil {
+		info["currencies"] = currencies
+		info["total_asset_value"] = balance["totalAssetValue"]
+		info["total_collateral_value"] = balance["totalCollateralValue"]
+	}
+	// 报告币种的汇率（看板按此换算显示，未配置时不返回）
+	if quote := fx.Current(); quote != nil {
+		info["reporting_currency"] = quote
+	}
+	return info, nil
 }
 
 // GetPositions 获取持仓列表（用于API）
@@ -910,6 +2049,8 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
 
+	funding := at.openPositionFunding()
+	entries := at.openPositionEntries()
 	var result []map[string]interface{}
 	for _, pos := range positions {
 		symbol := pos["symbol"].(string)
@@ -936,11 +2077,15 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 		}
 
 		marginUsed := (quantity * markPrice) / float64(leverage)
+		tpFilled, tpLevels := at.takeProfitLadderProgress(symbol, side)
 
 		result = append(result, map[string]interface{}{
 			"symbol":             symbol,
 			"side":               side,
 			"entry_price":        entryPrice,
+			"entry_vwap":         entries[symbol+"_"+side].VWAP,
+			"entry_twap":         entries[symbol+"_"+side].TWAP,
+			"entry_orders":       entries[symbol+"_"+side].Orders,
 			"mark_price":         markPrice,
 			"quantity":           quantity,
 			"leverage":           leverage,
@@ -948,6 +2093,10 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 			"unrealized_pnl_pct": pnlPct,
 			"liquidation_price":  liquidationPrice,
 			"margin_used":        marginUsed,
+			"funding":            funding[symbol+"_"+side],
+			"tp_levels_filled":   tpFilled,
+			"tp_levels":          tpLevels,
+			"adl_rank":           positionADLRank(pos),
 		})
 	}
 
@@ -0,0 +1,50 @@
+package trader
+
+import "encoding/json"
+
+// CurrencyBalance 单个币种的余额和按指数价的美元估值（GetBalance的"currencies"字段）
+type CurrencyBalance struct {
+	Currency      string  `json:"currency"`
+	Equity        float64 `json:"equity"`             // 币种权益（数量，已扣除借款）
+	Available     float64 `json:"available"`          // 可用数量
+	Borrowed      float64 `json:"borrowed,omitempty"` // 借款数量
+	IndexPrice    float64 `json:"index_price"`        // 美元指数价（USDT按1计，获取不到时为0）
+	ValueUSD      float64 `json:"value_usd"`          // 权益的美元价值
+	CollateralUSD float64 `json:"collateral_usd"`     // 按折算率计入保证金的美元价值（未启用抵押时为0）
+}
+
+// BalanceCurrencies 余额中的币种明细（交易器不提供时为nil；经过JSON的管理接口结果同样支持）
+func BalanceCurrencies(balance map[string]interface{}) []CurrencyBalance {
+	switch v := balance["currencies"].(type) {
+	case []CurrencyBalance:
+		return v
+	case []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var currencies []CurrencyBalance
+		if json.Unmarshal(data, &currencies) != nil {
+			return nil
+		}
+		return currencies
+	}
+	return nil
+}
+
+// setCurrencies 把币种明细和汇总估值写入余额
+func setCurrencies(balance map[string]interface{}, currencies []CurrencyBalance) {
+	var assets, collateral float64
+	for _, c := range currencies {
+		assets += c.ValueUSD
+		collateral += c.CollateralUSD
+	}
+	balance["currencies"] = currencies
+	balance["totalAssetValue"] = assets
+	balance["totalCollateralValue"] = collateral
+}
+
+// usdtBalance 只有USDT的账户（如经典合约账户）的币种明细
+func usdtBalance(equity, available float64) CurrencyBalance {
+	return CurrencyBalance{Currency: "USDT", Equity: equity, Available: available, IndexPrice: 1, ValueUSD: equity, CollateralUSD: equity}
+}
@@ -0,0 +1,130 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/notify"
+	"strings"
+	"time"
+)
+
+// balanceBaselineWindow 核对窗口：余额在容差内时每隔该时长把基准移到当前余额（避免平仓盈亏按整笔估算的误差累积）
+const balanceBaselineWindow = 24 * time.Hour
+
+// balanceBaseline 余额核对的基准和最近一次检查结果（持有cycleMu时整体替换，状态接口只读）
+type balanceBaseline struct {
+	Wallet   float64   `json:"wallet"`   // 基准钱包余额
+	Since    time.Time `json:"since"`    // 基准时间
+	Expected float64   `json:"expected"` // 最近一次检查推算的预期变化
+	Actual   float64   `json:"actual"`   // 最近一次检查的实际变化
+	Breaches int       `json:"breaches"` // 连续超出容差的次数
+}
+
+// walletBalance 钱包余额（不含未实现盈亏）
+func walletBalance(balance map[string]interface{}) (float64, bool) {
+	wallet, ok := balance["totalWalletBalance"].(float64)
+	return wallet, ok
+}
+
+// ledgerFlow since之后合约账户的转入（正）和转出（负）合计，交易器不支持查询资金流水时为0；
+// 钱包的充值和提现记录（ID以类型为前缀）不直接改变合约账户余额，不计入
+func (at *AutoTrader) ledgerFlow(since time.Time) (float64, error) {
+	source, ok := at.trader.(LedgerSource)
+	if !ok {
+		return 0, nil
+	}
+	entries, err := source.GetLedger(since)
+	if err != nil {
+		return 0, err
+	}
+	var flow float64
+	for _, entry := range entries {
+		if entry.Time.Before(since) || !strings.EqualFold(entry.Currency, "USDT") ||
+			strings.HasPrefix(entry.ID, entry.Kind+":") {
+			continue
+		}
+		if entry.Kind == LedgerDeposit {
+			flow += entry.Amount
+		} else {
+			flow -= entry.Amount
+		}
+	}
+	return flow, nil
+}
+
+// checkBalanceAnomaly 核对钱包余额：实际变化与交易日志推算的预期变化（平仓盈亏+手续费+资金费+资金流水）
+// 连续偏离超过容差时告警并强制对账，之后以当前余额为新基准（调用方持有cycleMu，成交和费用流水已同步）
+func (at *AutoTrader) checkBalanceAnomaly() {
+	cfg := at.config.BalanceAnomaly
+	if !cfg.Enabled || at.journal == nil {
+		return
+	}
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		at.log.Warn("余额核对: 获取余额失败", "err", err)
+		return
+	}
+	wallet, ok := walletBalance(balance)
+	if !ok {
+		return
+	}
+	now := at.clock.Now()
+	if at.balanceBaseline == nil {
+		at.balanceBaseline = &balanceBaseline{Wallet: wallet, Since: now}
+		return
+	}
+
+	base := *at.balanceBaseline
+	change, err := at.journal.WalletChangeSince(at.id, base.Since)
+	if err != nil {
+		at.log.Warn("余额核对: 汇总交易日志失败", "err", err)
+		return
+	}
+	flow, err := at.ledgerFlow(base.Since)
+	if err != nil {
+		at.log.Warn("余额核对: 查询资金流水失败", "err", err)
+		return
+	}
+	base.Expected = change.Total() + flow
+	base.Actual = wallet - base.Wallet
+	diff := base.Actual - base.Expected
+	tolerance := cfg.Tolerance(base.Wallet)
+
+	if math.Abs(diff) <= tolerance {
+		base.Breaches = 0
+		if now.Sub(base.Since) >= balanceBaselineWindow {
+			base = balanceBaseline{Wallet: wallet, Since: now}
+		}
+		at.balanceBaseline = &base
+		return
+	}
+	base.Breaches++
+	at.balanceBaseline = &base
+	at.log.Warn("余额变化与交易日志不符", "actual", base.Actual, "expected", base.Expected, "diff", diff,
+		"tolerance", tolerance, "breaches", base.Breaches, "required", cfg.Required())
+	if base.Breaches < cfg.Required() {
+		return // 成交或费用流水可能尚未同步，下次检查仍超出容差再告警
+	}
+
+	detail := fmt.Sprintf("自%s以来钱包余额变化%+.2f USDT，交易日志推算%+.2f USDT（平仓盈亏%+.2f、手续费%+.2f、资金费%+.2f、资金流水%+.2f），偏差%+.2f超过容差%.2f",
+		base.Since.Local().Format("01-02 15:04"), base.Actual, base.Expected, change.RealizedPnL, change.Fees, change.Funding,
+		flow, diff, tolerance)
+	message := detail + "\n可能原因: 漏记成交、未预期的费用、人工操作或程序错误"
+	action := "reconcile"
+	if at.config.ReadOnly {
+		action = "alert" // 只读模式不接管持仓，只告警
+	} else {
+		issues := at.reconcile()
+		message += fmt.Sprintf("\n已强制对账，发现%d个问题", len(issues))
+	}
+	at.publishRisk("balance_anomaly", "", action, detail)
+	at.notifyReason(notify.KindRisk, riskReason("balance_anomaly"), "", "余额变化异常", message)
+
+	// 对账后以当前余额为新基准，避免同一偏差重复告警
+	if balance, err := at.trader.GetBalance(); err == nil {
+		if current, ok := walletBalance(balance); ok {
+			wallet = current
+		}
+	}
+	at.balanceBaseline = &balanceBaseline{Wallet: wallet, Since: at.clock.Now()}
+}
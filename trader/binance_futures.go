@@ -3,7 +3,8 @@ package trader
 import (
 	"context"
 	"fmt"
-	"log"
+	"nofx/logging"
+	"nofx/store"
 	"strconv"
 	"sync"
 	"time"
@@ -11,6 +12,9 @@ import (
 	"github.com/adshao/go-binance/v2/futures"
 )
 
+// binanceLog 币安合约交易器日志
+var binanceLog = logging.For("binance")
+
 // FuturesTrader 币安合约交易器
 type FuturesTrader struct {
 	client *futures.Client
@@ -27,6 +31,9 @@ type FuturesTrader struct {
 
 	// 缓存有效期（15秒）
 	cacheDuration time.Duration
+
+	// 止损限价偏移（%），0表示触发后市价成交
+	stopLimitOffsetPct float64
 }
 
 // NewFuturesTrader 创建合约交易器
@@ -38,6 +45,16 @@ func NewFuturesTrader(apiKey, secretKey string) *FuturesTrader {
 	}
 }
 
+// SetStopLimitOffset 设置止损限价偏移（%），0表示触发后市价成交
+func (t *FuturesTrader) SetStopLimitOffset(pct float64) {
+	t.stopLimitOffsetPct = pct
+}
+
+// SetCacheDuration 设置余额/持仓缓存有效期
+func (t *FuturesTrader) SetCacheDuration(d time.Duration) {
+	t.cacheDuration = d
+}
+
 // GetBalance 获取账户余额（带缓存）
 func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	// 先检查缓存是否有效
@@ -45,17 +62,17 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		binanceLog.Debug("使用缓存的账户余额", "cache_age_s", cacheAge.Seconds())
 		return t.cachedBalance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取账户余额...")
+	binanceLog.Debug("缓存过期，重新获取账户余额")
 	account, err := t.client.NewGetAccountService().Do(context.Background())
 	if err != nil {
-		log.Printf("❌ 币安API调用失败: %v", err)
-		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+		binanceLog.Error("获取账户信息失败", "err", err)
+		return nil, fmt.Errorf("获取账户信息失败: %w", classifyBinanceError(err))
 	}
 
 	result := make(map[string]interface{})
@@ -63,10 +80,8 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"], _ = strconv.ParseFloat(account.AvailableBalance, 64)
 	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
 
-	log.Printf("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
-		account.TotalWalletBalance,
-		account.AvailableBalance,
-		account.TotalUnrealizedProfit)
+	binanceLog.Debug("账户余额", "wallet", account.TotalWalletBalance, "available", account.AvailableBalance,
+		"unrealized_pnl", account.TotalUnrealizedProfit)
 
 	// 更新缓存
 	t.balanceCacheMutex.Lock()
@@ -84,16 +99,16 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.positionsCacheTime)
 		t.positionsCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		binanceLog.Debug("使用缓存的持仓信息", "cache_age_s", cacheAge.Seconds())
 		return t.cachedPositions, nil
 	}
 	t.positionsCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取持仓信息...")
+	binanceLog.Debug("缓存过期，重新获取持仓信息")
 	positions, err := t.client.NewGetPositionRiskService().Do(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
+		return nil, fmt.Errorf("获取持仓失败: %w", classifyBinanceError(err))
 	}
 
 	var result []map[string]interface{}
@@ -149,7 +164,7 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 
 	// 如果当前杠杆已经是目标杠杆，跳过
 	if currentLeverage == leverage && currentLeverage > 0 {
-		log.Printf("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
+		binanceLog.Debug("杠杆无需切换", "symbol", symbol, "leverage", leverage)
 		return nil
 	}
 
@@ -162,16 +177,14 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if contains(err.Error(), "No need to change") {
-			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			binanceLog.Debug("杠杆无需切换", "symbol", symbol, "leverage", leverage)
 			return nil
 		}
-		return fmt.Errorf("设置杠杆失败: %w", err)
+		return fmt.Errorf("设置杠杆失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
-
 	// 切换杠杆后等待5秒（避免冷却期错误）
-	log.Printf("  ⏱ 等待5秒冷却期...")
+	binanceLog.Info("杠杆已切换，等待5秒冷却期", "symbol", symbol, "leverage", leverage)
 	time.Sleep(5 * time.Second)
 
 	return nil
@@ -187,16 +200,14 @@ func (t *FuturesTrader) SetMarginType(symbol string, marginType futures.MarginTy
 	if err != nil {
 		// 如果已经是该模式，不算错误
 		if contains(err.Error(), "No need to change") {
-			log.Printf("  ✓ %s 保证金模式已是 %s", symbol, marginType)
+			binanceLog.Debug("保证金模式无需切换", "symbol", symbol, "margin_type", marginType)
 			return nil
 		}
-		return fmt.Errorf("设置保证金模式失败: %w", err)
+		return fmt.Errorf("设置保证金模式失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("  ✓ %s 保证金模式已切换为 %s", symbol, marginType)
-
 	// 切换保证金模式后等待3秒（避免冷却期错误）
-	log.Printf("  ⏱ 等待3秒冷却期...")
+	binanceLog.Info("保证金模式已切换，等待3秒冷却期", "symbol", symbol, "margin_type", marginType)
 	time.Sleep(3 * time.Second)
 
 	return nil
@@ -206,7 +217,7 @@ func (t *FuturesTrader) SetMarginType(symbol string, marginType futures.MarginTy
 func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		binanceLog.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
 	}
 
 	// 设置杠杆
@@ -235,11 +246,10 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("开多仓失败: %w", err)
+		return nil, fmt.Errorf("开多仓失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("✓ 开多仓成功: %s 数量: %s", symbol, quantityStr)
-	log.Printf("  订单ID: %d", order.OrderID)
+	binanceLog.Info("开多仓成功", "symbol", symbol, "quantity", quantityStr, "order_id", order.OrderID)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
@@ -252,7 +262,7 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		binanceLog.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
 	}
 
 	// 设置杠杆
@@ -281,11 +291,10 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("开空仓失败: %w", err)
+		return nil, fmt.Errorf("开空仓失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("✓ 开空仓成功: %s 数量: %s", symbol, quantityStr)
-	log.Printf("  订单ID: %d", order.OrderID)
+	binanceLog.Info("开空仓成功", "symbol", symbol, "quantity", quantityStr, "order_id", order.OrderID)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
@@ -311,7 +320,7 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的多仓: %w", symbol, ErrPositionNotFound)
 		}
 	}
 
@@ -331,14 +340,14 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("平多仓失败: %w", err)
+		return nil, fmt.Errorf("平多仓失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, quantityStr)
+	binanceLog.Info("平多仓成功", "symbol", symbol, "quantity", quantityStr)
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		binanceLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
 
 	result := make(map[string]interface{})
@@ -365,7 +374,7 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的空仓: %w", symbol, ErrPositionNotFound)
 		}
 	}
 
@@ -385,14 +394,14 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("平空仓失败: %w", err)
+		return nil, fmt.Errorf("平空仓失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, quantityStr)
+	binanceLog.Info("平空仓成功", "symbol", symbol, "quantity", quantityStr)
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		binanceLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
 
 	result := make(map[string]interface{})
@@ -409,10 +418,10 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 		Do(context.Background())
 
 	if err != nil {
-		return fmt.Errorf("取消挂单失败: %w", err)
+		return fmt.Errorf("取消挂单失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	binanceLog.Info("已取消所有挂单", "symbol", symbol)
 	return nil
 }
 
@@ -420,7 +429,7 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
 	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(context.Background())
 	if err != nil {
-		return 0, fmt.Errorf("获取价格失败: %w", err)
+		return 0, fmt.Errorf("获取价格失败: %w", classifyBinanceError(err))
 	}
 
 	if len(prices) == 0 {
@@ -435,6 +444,36 @@ func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
 	return price, nil
 }
 
+// GetOrderStatus 查询订单状态（数量单位为币数量，与下单一致）
+func (t *FuturesTrader) GetOrderStatus(symbol string, orderID string) (store.OrderUpdate, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return store.OrderUpdate{}, fmt.Errorf("订单ID无效: %s", orderID)
+	}
+	order, err := t.client.NewGetOrderService().Symbol(symbol).OrderID(id).Do(context.Background())
+	if err != nil {
+		return store.OrderUpdate{}, fmt.Errorf("查询订单失败: %w", classifyBinanceError(err))
+	}
+
+	filled, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	avgPrice, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	update := store.OrderUpdate{OrderID: orderID, FilledQty: filled, AvgPrice: avgPrice, Detail: string(order.Status)}
+	switch order.Status {
+	case futures.OrderStatusTypeNew:
+		update.State = store.OrderSubmitted
+	case futures.OrderStatusTypePartiallyFilled:
+		update.State = store.OrderPartiallyFilled
+	case futures.OrderStatusTypeFilled:
+		update.State = store.OrderFilled
+	case futures.OrderStatusTypeRejected:
+		update.State = store.OrderRejected
+	default:
+		// 市价单流动性不足时剩余部分过期（EXPIRED），或已撤销（可能带部分成交）
+		update.State = store.OrderCancelled
+	}
+	return update, nil
+}
+
 // CalculatePositionSize 计算仓位大小
 func (t *FuturesTrader) CalculatePositionSize(balance, riskPercent, price float64, leverage int) float64 {
 	riskAmount := balance * (riskPercent / 100.0)
@@ -462,22 +501,28 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		return err
 	}
 
-	_, err = t.client.NewCreateOrderService().
+	order := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
 		PositionSide(posSide).
-		Type(futures.OrderTypeStopMarket).
 		StopPrice(fmt.Sprintf("%.8f", stopPrice)).
 		Quantity(quantityStr).
-		WorkingType(futures.WorkingTypeContractPrice).
-		ClosePosition(true).
-		Do(context.Background())
+		WorkingType(futures.WorkingTypeContractPrice)
+	if t.stopLimitOffsetPct > 0 {
+		// 止损限价单：触发后按限价挂单（STOP类型不支持closePosition，按数量平仓）
+		limitPrice := stopLimitPrice(positionSide, stopPrice, t.stopLimitOffsetPct)
+		order = order.Type(futures.OrderTypeStop).
+			Price(fmt.Sprintf("%.8f", limitPrice)).
+			TimeInForce(futures.TimeInForceTypeGTC)
+	} else {
+		order = order.Type(futures.OrderTypeStopMarket).ClosePosition(true)
+	}
 
-	if err != nil {
-		return fmt.Errorf("设置止损失败: %w", err)
+	if _, err = order.Do(context.Background()); err != nil {
+		return fmt.Errorf("设置止损失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("  止损价设置: %.4f", stopPrice)
+	binanceLog.Info("止损已设置", "symbol", symbol, "side", positionSide, "stop_price", stopPrice)
 	return nil
 }
 
@@ -512,10 +557,10 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		Do(context.Background())
 
 	if err != nil {
-		return fmt.Errorf("设置止盈失败: %w", err)
+		return fmt.Errorf("设置止盈失败: %w", classifyBinanceError(err))
 	}
 
-	log.Printf("  止盈价设置: %.4f", takeProfitPrice)
+	binanceLog.Info("止盈已设置", "symbol", symbol, "side", positionSide, "take_profit", takeProfitPrice)
 	return nil
 }
 
@@ -523,7 +568,7 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
 	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
 	if err != nil {
-		return 0, fmt.Errorf("获取交易规则失败: %w", err)
+		return 0, fmt.Errorf("获取交易规则失败: %w", classifyBinanceError(err))
 	}
 
 	for _, s := range exchangeInfo.Symbols {
@@ -533,14 +578,14 @@ func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
 				if filter["filterType"] == "LOT_SIZE" {
 					stepSize := filter["stepSize"].(string)
 					precision := calculatePrecision(stepSize)
-					log.Printf("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
+					binanceLog.Debug("数量精度", "symbol", symbol, "precision", precision, "step_size", stepSize)
 					return precision, nil
 				}
 			}
 		}
 	}
 
-	log.Printf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
+	binanceLog.Warn("未找到精度信息，使用默认精度3", "symbol", symbol)
 	return 3, nil // 默认精度为3
 }
 
@@ -0,0 +1,68 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/risk"
+)
+
+// BookSnapshot 账户当前持仓和净值（用于跨交易所的组合敞口汇总），account为持仓标记的账户标识
+func (at *AutoTrader) BookSnapshot(account string) ([]risk.BookPosition, float64, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	result := make([]risk.BookPosition, 0, len(positions))
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity, _ := pos["positionAmt"].(float64)
+		markPrice, _ := pos["markPrice"].(float64)
+		result = append(result, risk.BookPosition{Account: account, Symbol: symbol, Side: side, Quantity: quantity, MarkPrice: markPrice})
+	}
+	return result, wallet + unrealized, nil
+}
+
+// PortfolioBooks 组合视图中该trader账户的结算账本（交易器不按结算币种提供时，按余额和持仓生成一个USDT账本）
+func (at *AutoTrader) PortfolioBooks(account string) ([]risk.SettleBook, error) {
+	var books []risk.SettleBook
+	if source, ok := at.trader.(SettleBookSource); ok {
+		var err error
+		if books, err = source.SettleBooks(); err != nil {
+			return nil, err
+		}
+	} else {
+		balance, err := at.trader.GetBalance()
+		if err != nil {
+			return nil, fmt.Errorf("获取账户余额失败: %w", err)
+		}
+		positions, err := at.trader.GetPositions()
+		if err != nil {
+			return nil, fmt.Errorf("获取持仓失败: %w", err)
+		}
+		wallet, _ := balance["totalWalletBalance"].(float64)
+		unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+		book := risk.SettleBook{Settle: "USDT", IndexPrice: 1, Equity: wallet + unrealized, UnrealizedPnL: unrealized,
+			Positions: make([]risk.SettlePosition, 0, len(positions))}
+		for _, pos := range positions {
+			symbol, _ := pos["symbol"].(string)
+			side, _ := pos["side"].(string)
+			quantity, _ := pos["positionAmt"].(float64)
+			markPrice, _ := pos["markPrice"].(float64)
+			pnl, _ := pos["unRealizedProfit"].(float64)
+			book.Positions = append(book.Positions, risk.SettlePosition{Contract: symbol, Asset: risk.BaseAsset(symbol), Side: side,
+				Size: quantity, MarkPrice: markPrice, Value: quantity * contractSpec(at.trader, symbol).Multiplier * markPrice, UnrealizedPnL: pnl})
+		}
+		books = []risk.SettleBook{book}
+	}
+	for i := range books {
+		books[i].Account = account
+	}
+	return books, nil
+}
@@ -0,0 +1,26 @@
+package trader
+
+import (
+	"fmt"
+	"time"
+)
+
+// calendarLookahead 写入提示词的经济日历事件范围
+const calendarLookahead = 24 * time.Hour
+
+// checkCalendar 开仓前检查是否处于重要经济事件的禁止开仓窗口内
+func (at *AutoTrader) checkCalendar() error {
+	if err := at.config.Calendar.Check(at.clock.Now()); err != nil {
+		return fmt.Errorf("%w: %v", ErrCalendarBlackout, err)
+	}
+	return nil
+}
+
+// upcomingEvents 未来24小时内即将公布的经济日历事件（写入提示词）
+func (at *AutoTrader) upcomingEvents() []string {
+	var events []string
+	for _, e := range at.config.Calendar.Upcoming(at.clock.Now(), calendarLookahead) {
+		events = append(events, e.String())
+	}
+	return events
+}
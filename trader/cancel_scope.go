@@ -0,0 +1,137 @@
+package trader
+
+import (
+	"fmt"
+)
+
+// 撤单范围的订单类型
+const (
+	CancelKindEntry   = "entry"   // 只撤开仓/加仓委托（非只减仓的普通委托）
+	CancelKindTrigger = "trigger" // 只撤止损/止盈条件单
+)
+
+// CancelScope 按范围撤单的过滤条件（同时满足所有非空条件的挂单才撤销）
+// 多策略共用一个账户时，各策略只清理自己的挂单，不会撤掉其他策略持仓的止损单
+type CancelScope struct {
+	Kind     string `json:"kind,omitempty"`     // entry/trigger，为空表示两类都撤
+	Strategy string `json:"strategy,omitempty"` // 只撤该策略下的订单（按订单文本归因，没有归因标记的订单不匹配）
+	Side     string `json:"side,omitempty"`     // long/short，只撤该方向持仓的订单
+}
+
+// OrderCanceller 可撤销单个普通委托单的交易器
+type OrderCanceller interface {
+	CancelOrder(symbol, orderID string) error
+}
+
+// IsZero 没有任何过滤条件（等同于撤销全部挂单）
+func (s CancelScope) IsZero() bool {
+	return s == CancelScope{}
+}
+
+// Validate 检查过滤条件的取值
+func (s CancelScope) Validate() error {
+	if s.Kind != "" && s.Kind != CancelKindEntry && s.Kind != CancelKindTrigger {
+		return fmt.Errorf("无效的撤单类型: %s（应为entry/trigger）", s.Kind)
+	}
+	if s.Side != "" && s.Side != "long" && s.Side != "short" {
+		return fmt.Errorf("无效的持仓方向: %s（应为long/short）", s.Side)
+	}
+	return nil
+}
+
+// matchStrategy 订单文本是否属于指定策略
+func (s CancelScope) matchStrategy(text string) bool {
+	if s.Strategy == "" {
+		return true
+	}
+	tag, ok := ParseOrderTag(text)
+	return ok && tag.Strategy == s.Strategy
+}
+
+// matchOrder 普通委托是否在范围内（只减仓委托的持仓方向与买卖方向相反）
+func (s CancelScope) matchOrder(order OpenOrder) bool {
+	if s.Kind == CancelKindTrigger || (s.Kind == CancelKindEntry && order.ReduceOnly) {
+		return false
+	}
+	side := "long"
+	if (order.Quantity < 0) != order.ReduceOnly {
+		side = "short"
+	}
+	return (s.Side == "" || s.Side == side) && s.matchStrategy(order.Text)
+}
+
+// matchTrigger 条件单是否在范围内
+func (s CancelScope) matchTrigger(order TriggerOrder) bool {
+	if s.Kind == CancelKindEntry {
+		return false
+	}
+	return (s.Side == "" || s.Side == order.PositionSide) && s.matchStrategy(order.Text)
+}
+
+// CancelScoped 逐个撤销范围内的普通委托和条件单（symbol为空表示所有币种），返回撤单数量
+// 交易器需支持查询挂单和单独撤单；中途失败时返回已撤销的数量和错误
+func CancelScoped(t Trader, symbol string, scope CancelScope) (int, error) {
+	if err := scope.Validate(); err != nil {
+		return 0, err
+	}
+	source, ok := t.(OpenOrderSource)
+	if !ok {
+		return 0, fmt.Errorf("交易器不支持查询挂单，无法按范围撤单")
+	}
+
+	cancelled := 0
+	if scope.Kind != CancelKindTrigger {
+		canceller, ok := t.(OrderCanceller)
+		if !ok {
+			return 0, fmt.Errorf("交易器不支持单独撤销委托单，无法按范围撤单")
+		}
+		orders, err := source.GetOpenOrders(symbol)
+		if err != nil {
+			return 0, err
+		}
+		for _, order := range orders {
+			if !scope.matchOrder(order) {
+				continue
+			}
+			if err := canceller.CancelOrder(order.Symbol, order.OrderID); err != nil {
+				return cancelled, fmt.Errorf("撤销 %s 委托单 %s 失败: %w", order.Symbol, order.OrderID, err)
+			}
+			cancelled++
+		}
+	}
+	if scope.Kind != CancelKindEntry {
+		canceller, ok := t.(TriggerOrderCanceller)
+		if !ok {
+			return cancelled, fmt.Errorf("交易器不支持单独撤销条件单，无法按范围撤单")
+		}
+		triggers, err := source.GetOpenTriggerOrders()
+		if err != nil {
+			return cancelled, err
+		}
+		for _, trigger := range triggers {
+			if (symbol != "" && trigger.Symbol != symbol) || !scope.matchTrigger(trigger) {
+				continue
+			}
+			if err := canceller.CancelTriggerOrder(trigger.Symbol, trigger.OrderID); err != nil {
+				return cancelled, fmt.Errorf("撤销 %s 条件单 %s 失败: %w", trigger.Symbol, trigger.OrderID, err)
+			}
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
+
+// CancelOrdersScoped 按范围撤销挂单（symbol为空表示所有币种），返回撤单数量
+func (at *AutoTrader) CancelOrdersScoped(symbol string, scope CancelScope) (int, error) {
+	if at.config.ReadOnly {
+		return 0, ErrReadOnly
+	}
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	cancelled, err := CancelScoped(at.trader, symbol, scope)
+	if cancelled > 0 {
+		at.log.Warn("已按范围撤销挂单", "symbol", symbol, "kind", scope.Kind, "strategy", scope.Strategy, "side", scope.Side, "cancelled", cancelled)
+	}
+	return cancelled, err
+}
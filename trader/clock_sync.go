@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/notify"
+	"time"
+)
+
+const (
+	clockSyncInterval  = 5 * time.Minute // 时钟偏差检查间隔
+	clockSyncThreshold = time.Second     // 偏差超过该值时告警并校正签名时间戳
+)
+
+// ClockCompensator 可校正签名时间戳的交易器（offset = 交易所时间 - 本地时间）
+type ClockCompensator interface {
+	SetClockOffset(offset time.Duration)
+}
+
+// monitorClockSkew 定时对比本地时钟与交易所服务器时间：偏差超过阈值时告警，交易器支持时用偏差校正签名时间戳，
+// 避免运行中途时钟漂移导致签名请求被拒（表现为莫名其妙的鉴权失败）；偏差恢复后取消校正
+func (at *AutoTrader) monitorClockSkew() {
+	prober, ok := at.trader.(ExchangeProber)
+	if !ok {
+		return
+	}
+	compensator, _ := at.trader.(ClockCompensator)
+
+	var offset time.Duration
+	for {
+		result, err := prober.Probe()
+		if err == nil && result.SkewKnown {
+			offset = at.syncClock(compensator, result.ClockSkew, offset)
+		}
+
+		select {
+		case <-at.stopCh:
+			return
+		case <-at.clock.After(clockSyncInterval):
+		}
+	}
+}
+
+// syncClock 根据本次测得的偏差（本地时间 - 交易所时间）更新校正量，返回新的校正量
+func (at *AutoTrader) syncClock(compensator ClockCompensator, skew, offset time.Duration) time.Duration {
+	if skew < clockSyncThreshold && skew > -clockSyncThreshold {
+		if offset != 0 {
+			if compensator != nil {
+				compensator.SetClockOffset(0)
+			}
+			at.log.Info("本地时钟已恢复同步，取消时间戳校正", "skew_ms", skew.Milliseconds())
+		}
+		return 0
+	}
+
+	// 校正量变化不足阈值时不重复告警
+	next := -skew
+	if diff := next - offset; diff < clockSyncThreshold && diff > -clockSyncThreshold {
+		return offset
+	}
+	if compensator == nil {
+		at.log.Warn("本地时钟与交易所偏差过大，请同步系统时间（NTP）", "skew_ms", skew.Milliseconds())
+	} else {
+		compensator.SetClockOffset(next)
+		at.log.Warn("本地时钟与交易所偏差过大，已校正签名时间戳，请同步系统时间（NTP）", "skew_ms", skew.Milliseconds())
+	}
+	if offset == 0 {
+		at.notify(notify.KindRisk, "", "本地时钟偏差过大",
+			fmt.Sprintf("本地时间比交易所%s %v，签名请求可能被拒绝，请同步系统时间（NTP）", skewDirection(skew), skew.Abs().Round(time.Millisecond)))
+	}
+	return next
+}
+
+// skewDirection 偏差方向（本地时间相对交易所）
+func skewDirection(skew time.Duration) string {
+	if skew > 0 {
+		return "快"
+	}
+	return "慢"
+}
@@ -0,0 +1,82 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/store"
+	"strconv"
+)
+
+// ContractSpecSource 可提供合约规格的交易器（模拟交易按真实合约的乘数和价格精度计算成交）
+type ContractSpecSource interface {
+	GetContractSpec(symbol string) (ContractSpec, error)
+}
+
+// ContractSpec 合约规格（数量单位与OpenLong/OpenShort一致，为合约张数）
+type ContractSpec struct {
+	Multiplier   float64 // 每张合约对应的基础币数量
+	TickSize     float64 // 价格最小变动单位（0表示不限）
+	OrderSizeMin float64 // 最小下单张数（0表示1张）
+}
+
+// defaultContractSpec 交易器不提供合约规格时按1张=1个币计算
+var defaultContractSpec = ContractSpec{Multiplier: 1}
+
+// RoundPrice 按价格最小变动单位取整
+func (s ContractSpec) RoundPrice(price float64) float64 {
+	if s.TickSize <= 0 || price <= 0 {
+		return price
+	}
+	return math.Round(price/s.TickSize) * s.TickSize
+}
+
+// OrderSize 按整数张向下取整（回测成交使用，不足最小下单张数时返回ErrOrderTooSmall）
+func (s ContractSpec) OrderSize(contracts float64) (float64, error) {
+	if math.IsNaN(contracts) || math.IsInf(contracts, 0) || contracts <= 0 {
+		return 0, fmt.Errorf("下单数量无效: %v", contracts)
+	}
+	size := math.Floor(contracts + 1e-9)
+	if min := math.Max(s.OrderSizeMin, 1); size < min {
+		return 0, fmt.Errorf("下单数量 %g 取整后低于最小下单数量 %.0f: %w", contracts, min, ErrOrderTooSmall)
+	}
+	return size, nil
+}
+
+// BaseQuantity 合约张数换算为基础币数量（交易日志的持仓按基础币数量记录，与成交记录和毛盈亏一致）
+func (s ContractSpec) BaseQuantity(contracts float64) float64 {
+	if s.Multiplier <= 0 {
+		return contracts
+	}
+	return contracts * s.Multiplier
+}
+
+// Contracts 基础币数量换算为合约张数（BaseQuantity的逆运算）
+func (s ContractSpec) Contracts(base float64) float64 {
+	if s.Multiplier <= 0 {
+		return base
+	}
+	return base / s.Multiplier
+}
+
+// positionContracts 持仓记录的合约张数（旧记录没有张数时按合约乘数从基础币数量换算）
+func positionContracts(t Trader, p *store.Position) float64 {
+	if p.Contracts > 0 {
+		return p.Contracts
+	}
+	return contractSpec(t, p.Symbol).Contracts(p.Quantity)
+}
+
+// GetContractSpec 合约的乘数和价格精度（随合约信息缓存）
+func (t *GateTrader) GetContractSpec(symbol string) (ContractSpec, error) {
+	contract := convertSymbolToGateContract(symbol)
+	contractInfo, err := t.getContractInfo(contract)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("获取合约信息失败: %w", classifyGateError(err))
+	}
+	multiplier, err := strconv.ParseFloat(contractInfo.QuantoMultiplier, 64)
+	if err != nil || multiplier <= 0 {
+		return ContractSpec{}, fmt.Errorf("合约 %s 乘数无效: %s", contract, contractInfo.QuantoMultiplier)
+	}
+	tick, _ := strconv.ParseFloat(contractInfo.OrderPriceRound, 64)
+	return ContractSpec{Multiplier: multiplier, TickSize: math.Max(tick, 0), OrderSizeMin: float64(contractInfo.OrderSizeMin)}, nil
+}
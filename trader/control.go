@@ -0,0 +1,337 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/store"
+	"sort"
+	"time"
+)
+
+// 暂停来源
+const (
+	PauseSourceAPI      = "api"
+	PauseSourceGRPC     = "grpc"
+	PauseSourceTelegram = "telegram"
+	PauseSourceFile     = "file" // 哨兵文件（kill_switch_file）
+)
+
+// Pause 人工暂停（kill switch）：立即停止AI决策和新开仓（止损止盈、策略看守照常运行）
+// 暂停状态写入交易日志，重启后保持暂停；已暂停时更新来源和原因
+// cancelOrders为true时同时撤销没有持仓的币种上的挂单（已有持仓的止损止盈保留），返回撤单的币种数
+func (at *AutoTrader) Pause(source, reason string, cancelOrders bool) (int, error) {
+	at.pauseMu.Lock()
+	wasPaused := at.paused.Swap(true)
+	since := at.pauseState.Since
+	if !wasPaused {
+		since = at.clock.Now()
+	}
+	at.pauseState = store.PauseState{Paused: true, Source: source, Reason: reason, Since: since}
+	if err := at.journal.SavePauseState(at.id, at.pauseState); err != nil {
+		at.log.Warn("暂停状态未持久化，重启后不会保持暂停", "err", err)
+	}
+	at.pauseMu.Unlock()
+
+	if !wasPaused {
+		at.log.Warn("交易已人工暂停", "source", source, "reason", reason)
+		message := "AI决策和新开仓已停止，已有持仓的止损止盈照常生效"
+		if reason != "" {
+			message = "原因: " + reason + "\n" + message
+		}
+		at.notify(notify.KindInfo, "", "交易已暂停", message)
+	}
+
+	if !cancelOrders {
+		return 0, nil
+	}
+	return at.cancelRestingOrders()
+}
+
+// Resume 恢复人工暂停的交易
+func (at *AutoTrader) Resume() {
+	at.pauseMu.Lock()
+	wasPaused := at.paused.Swap(false)
+	at.pauseState = store.PauseState{}
+	at.orders.rate.reset()
+	if err := at.journal.SavePauseState(at.id, at.pauseState); err != nil {
+		at.log.Warn("暂停状态未持久化", "err", err)
+	}
+	at.pauseMu.Unlock()
+
+	if wasPaused {
+		at.log.Info("交易已恢复")
+		at.notify(notify.KindInfo, "", "交易已恢复", "")
+	}
+}
+
+// IsPaused 是否处于人工暂停
+func (at *AutoTrader) IsPaused() bool {
+	return at.paused.Load()
+}
+
+// PauseState 暂停状态（来源、原因、开始时间）
+func (at *AutoTrader) PauseState() store.PauseState {
+	at.pauseMu.Lock()
+	defer at.pauseMu.Unlock()
+	return at.pauseState
+}
+
+// cancelRestingOrders 撤销没有持仓的币种上的挂单（未成交委托、已平仓遗留的条件单），返回撤单的币种数
+func (at *AutoTrader) cancelRestingOrders() (int, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	held := make(map[string]bool)
+	for _, pos := range positions {
+		if symbol, _ := pos["symbol"].(string); symbol != "" && floatValue(pos["positionAmt"]) != 0 {
+			held[symbol] = true
+		}
+	}
+
+	// 可能有挂单的币种：交易所上的条件单 + 交易日志中未到终态的订单
+	symbols := make(map[string]bool)
+	if source, ok := at.trader.(OpenOrderSource); ok {
+		triggers, err := source.GetOpenTriggerOrders()
+		if err != nil {
+			return 0, fmt.Errorf("获取条件单失败: %w", err)
+		}
+		for _, trigger := range triggers {
+			symbols[trigger.Symbol] = true
+		}
+	}
+	active, err := at.journal.ListActiveOrders(at.id)
+	if err != nil {
+		return 0, err
+	}
+	for _, order := range active {
+		symbols[order.Symbol] = true
+	}
+
+	cancelled := 0
+	for symbol := range symbols {
+		if held[symbol] {
+			continue
+		}
+		if err := at.trader.CancelAllOrders(symbol); err != nil {
+			return cancelled, fmt.Errorf("撤销 %s 挂单失败: %w", symbol, err)
+		}
+		at.publishRiskReason("pause", ReasonPauseCancel, symbol, "cancel_orders", "暂停交易，撤销无持仓币种的挂单")
+		cancelled++
+	}
+	at.log.Warn("暂停时已撤销挂单", "symbols", cancelled)
+	return cancelled, nil
+}
+
+// Flatten 市价平掉指定币种的全部持仓（symbol为空时平掉所有持仓），返回平仓数量
+// 按flatten.order排序（默认距强平价最近的先平），相邻两笔平仓间隔flatten.pace_ms
+func (at *AutoTrader) Flatten(symbol string) (int, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var targets []map[string]interface{}
+	for _, pos := range positions {
+		posSymbol, _ := pos["symbol"].(string)
+		if (symbol != "" && posSymbol != symbol) || math.Abs(floatValue(pos["positionAmt"])) == 0 {
+			continue
+		}
+		targets = append(targets, pos)
+	}
+	sortForFlatten(targets, at.config.Flatten.OrderOrDefault())
+
+	closed := 0
+	for i, pos := range targets {
+		if i > 0 {
+			at.clock.Sleep(at.config.Flatten.Pace())
+		}
+		posSymbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		price := floatValue(pos["markPrice"])
+
+		_, _, err := at.orders.Place(context.Background(), "manual", posSymbol, "close_"+side, 0, price, 0,
+			func() (map[string]interface{}, error) {
+				if side == "long" {
+					return at.trader.CloseLong(posSymbol, 0)
+				}
+				return at.trader.CloseShort(posSymbol, 0)
+			})
+		if errors.Is(err, ErrPositionNotFound) {
+			continue // 持仓在获取后已被止损/止盈平掉
+		}
+		if err != nil {
+			return closed, fmt.Errorf("平仓 %s %s 失败: %w", posSymbol, side, err)
+		}
+		closed++
+	}
+	return closed, nil
+}
+
+// sortForFlatten 按平仓顺序排列持仓（风险相同时按币种和方向排列，顺序确定）
+// risk: 距强平价的百分比距离从近到远（没有强平价的排最后）；loss: 浮动盈亏从低到高；notional: 名义价值从大到小
+func sortForFlatten(positions []map[string]interface{}, order string) {
+	key := func(pos map[string]interface{}) float64 {
+		mark := floatValue(pos["markPrice"])
+		switch order {
+		case risk.FlattenByLoss:
+			return floatValue(pos["unRealizedProfit"])
+		case risk.FlattenByNotional:
+			return -math.Abs(floatValue(pos["positionAmt"])) * mark
+		default:
+			liq := floatValue(pos["liquidationPrice"])
+			if liq <= 0 || mark <= 0 {
+				return math.Inf(1)
+			}
+			return math.Abs(mark-liq) / mark
+		}
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		ki, kj := key(positions[i]), key(positions[j])
+		if ki != kj {
+			return ki < kj
+		}
+		si, _ := positions[i]["symbol"].(string)
+		sj, _ := positions[j]["symbol"].(string)
+		if si != sj {
+			return si < sj
+		}
+		sideI, _ := positions[i]["side"].(string)
+		sideJ, _ := positions[j]["side"].(string)
+		return sideI < sideJ
+	})
+}
+
+// CancelOrders 撤销指定币种的全部挂单（包括止损/止盈单，持仓将失去保护）
+func (at *AutoTrader) CancelOrders(symbol string) error {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	if err := at.trader.CancelAllOrders(symbol); err != nil {
+		return err
+	}
+	at.log.Warn("已人工撤销挂单", "symbol", symbol)
+	return nil
+}
+
+// GetOpenOrders 获取交易所上的挂单和止损/止盈条件单
+// symbols为空时查询当前持仓涉及的币种（普通委托单需按币种查询）
+func (at *AutoTrader) GetOpenOrders(symbols []string) ([]OpenOrder, []TriggerOrder, error) {
+	source, ok := at.trader.(OpenOrderSource)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s 交易器不支持查询挂单", at.exchange)
+	}
+
+	if len(symbols) == 0 {
+		positions, err := at.trader.GetPositions()
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取持仓失败: %w", err)
+		}
+		seen := make(map[string]bool)
+		for _, pos := range positions {
+			if symbol, _ := pos["symbol"].(string); symbol != "" && !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	orders := []OpenOrder{}
+	for _, symbol := range symbols {
+		open, err := source.GetOpenOrders(symbol)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取 %s 挂单失败: %w", symbol, err)
+		}
+		orders = append(orders, open...)
+	}
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return nil, nil, err
+	}
+	return orders, triggers, nil
+}
+
+// WalletTransfer 支持账户间划转的交易器（如从现货账户补充合约保证金）
+type WalletTransfer interface {
+	// Transfer 划转资金，from/to为交易所的账户类型（Gate.io: spot/futures）
+	Transfer(from, to, currency string, amount float64) error
+}
+
+// Transfer 账户间划转资金（只读模式下拒绝）
+func (at *AutoTrader) Transfer(from, to, currency string, amount float64) error {
+	if at.config.ReadOnly {
+		return ErrReadOnly
+	}
+	wallet, ok := at.trader.(WalletTransfer)
+	if !ok {
+		return fmt.Errorf("%s 不支持账户间划转", at.exchange)
+	}
+	if err := wallet.Transfer(from, to, currency, amount); err != nil {
+		return err
+	}
+	at.log.Info("资金已划转", "from", from, "to", to, "currency", currency, "amount", amount)
+	at.notify(notify.KindInfo, "", "资金已划转", fmt.Sprintf("%s -> %s: %.2f %s", from, to, amount, currency))
+	return nil
+}
+
+// LeverageForcer 可跳过持仓检查强制切换杠杆的交易器（默认持仓或开仓挂单期间禁止切换，见ErrLeverageLocked）
+type LeverageForcer interface {
+	ForceSetLeverage(symbol string, leverage int) error
+}
+
+// ChangeLeverage 人工切换币种的杠杆（只读模式下拒绝）；force为true时即使有持仓或开仓挂单也切换（强平价随之改变）
+func (at *AutoTrader) ChangeLeverage(symbol string, leverage int, force bool) error {
+	if at.config.ReadOnly {
+		return ErrReadOnly
+	}
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	if !force {
+		if err := at.trader.SetLeverage(symbol, leverage); err != nil {
+			return err
+		}
+	} else {
+		forcer, ok := at.trader.(LeverageForcer)
+		if !ok {
+			return fmt.Errorf("%s 交易器不支持强制切换杠杆", at.exchange)
+		}
+		if err := forcer.ForceSetLeverage(symbol, leverage); err != nil {
+			return err
+		}
+	}
+	at.log.Warn("已人工切换杠杆", "symbol", symbol, "leverage", leverage, "force", force)
+	at.notify(notify.KindInfo, symbol, "杠杆已切换", fmt.Sprintf("%s -> %dx（强制: %v）", symbol, leverage, force))
+	return nil
+}
+
+// notify 发布通知事件（由订阅事件总线的通知渠道推送，未配置通知渠道时没有订阅者）
+// 交易所维护期间不推送错误告警（进入维护和恢复时各推送一次）
+func (at *AutoTrader) notify(kind notify.Kind, symbol, title, message string) {
+	at.notifyReason(kind, "", symbol, title, message)
+}
+
+// notifyReason 发布带原因代码的通知事件（自动下单、撤单和风控干预的通知）
+func (at *AutoTrader) notifyReason(kind notify.Kind, reason, symbol, title, message string) {
+	if kind == notify.KindError && at.maintenance.active() {
+		return
+	}
+	publishNotice(at.events, notify.Event{
+		Kind:     kind,
+		TraderID: at.id,
+		Symbol:   symbol,
+		Title:    title,
+		Message:  message,
+		Time:     time.Now(),
+		Reason:   reason,
+	})
+}
@@ -0,0 +1,300 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nofx/clock"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/notify"
+	"nofx/risk"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errMarketData 快照阶段获取行情数据失败（K线不可用，计入降级判断）
+var errMarketData = errors.New("获取市场数据失败")
+
+// degradationModeNames 降级模式的显示名称
+var degradationModeNames = map[string]string{
+	risk.ModeNormal:      "正常",
+	risk.ModeRESTPolling: "REST轮询持仓",
+	risk.ModeExitsOnly:   "只平仓",
+	risk.ModeRulesOnly:   "规则平仓",
+}
+
+// degradationLadder 数据源故障时的降级状态：WebSocket推送、K线和AI三个数据源各自的故障标记，
+// 降级模式取其中最严重的一级；模式变化时（在锁外）回调onChange
+type degradationLadder struct {
+	mu          sync.Mutex
+	cfg         risk.Degradation
+	wsDown      bool
+	klinesStale bool
+	llmFailures int    // AI连续调用失败次数
+	mode        string // 当前降级模式
+	since       time.Time
+	reason      string // 最近一次导致模式变化的原因
+
+	onChange func(from, to, reason string) // 模式变化时回调（在锁外调用）
+	clock    clock.Clock                   // 时钟（为nil时使用系统时钟）
+}
+
+// newDegradationLadder 创建降级状态（未启用时返回nil，所有方法对nil安全）
+func newDegradationLadder(cfg risk.Degradation, clk clock.Clock) *degradationLadder {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &degradationLadder{cfg: cfg, mode: risk.ModeNormal, since: clock.Or(clk).Now(), clock: clk}
+}
+
+// update 在持锁状态下重新计算降级模式，返回模式变化的回调（没有变化时为nil，由调用方在解锁后执行）
+func (d *degradationLadder) update(reason string) func() {
+	mode := risk.DegradationMode(d.wsDown, d.klinesStale, d.llmFailures >= d.cfg.LLMFailureLimit())
+	if mode == d.mode {
+		return nil
+	}
+	from := d.mode
+	d.mode, d.since, d.reason = mode, clock.Or(d.clock).Now(), reason
+	if d.onChange == nil {
+		return nil
+	}
+	return func() { d.onChange(from, mode, reason) }
+}
+
+// set 更新一个数据源的故障标记
+func (d *degradationLadder) set(flag *bool, down bool, reason string) {
+	d.mu.Lock()
+	if *flag == down {
+		d.mu.Unlock()
+		return
+	}
+	*flag = down
+	changed := d.update(reason)
+	d.mu.Unlock()
+	if changed != nil {
+		changed()
+	}
+}
+
+// setWSDown 记录WebSocket持仓推送的连接状态
+func (d *degradationLadder) setWSDown(down bool, reason string) {
+	if d == nil {
+		return
+	}
+	d.set(&d.wsDown, down, reason)
+}
+
+// setKlinesStale 记录K线数据是否过期
+func (d *degradationLadder) setKlinesStale(stale bool, reason string) {
+	if d == nil {
+		return
+	}
+	d.set(&d.klinesStale, stale, reason)
+}
+
+// llmResult 记录一次AI调用结果：调用失败或决策阶段超时累计连续失败次数，成功时清零（其他错误如解析失败不计入）
+func (d *degradationLadder) llmResult(err error) {
+	if d == nil || (err != nil && !errors.Is(err, decision.ErrAIUnreachable) && !errors.Is(err, errStageTimeout)) {
+		return
+	}
+	d.mu.Lock()
+	reason := "AI调用恢复"
+	if err != nil {
+		d.llmFailures++
+		reason = fmt.Sprintf("AI连续%d次不可用: %v", d.llmFailures, err)
+	} else {
+		d.llmFailures = 0
+	}
+	changed := d.update(reason)
+	d.mu.Unlock()
+	if changed != nil {
+		changed()
+	}
+}
+
+// current 当前降级模式、进入时间和原因（未启用时为正常模式）
+func (d *degradationLadder) current() (mode string, since time.Time, reason string) {
+	if d == nil {
+		return risk.ModeNormal, time.Time{}, ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.mode, d.since, d.reason
+}
+
+// entriesBlocked 当前模式禁止开仓时返回错误
+func (d *degradationLadder) entriesBlocked() error {
+	mode, since, reason := d.current()
+	if risk.EntriesAllowed(mode) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s模式（%s起，%s）", ErrDegraded, degradationModeNames[mode], since.Format("15:04:05"), reason)
+}
+
+// degradationStatus 降级状态（用于状态接口，未启用时为nil）
+func (at *AutoTrader) degradationStatus() map[string]interface{} {
+	if at.degradation == nil {
+		return nil
+	}
+	mode, since, reason := at.degradation.current()
+	return map[string]interface{}{"mode": mode, "since": since.Format(time.RFC3339), "reason": reason}
+}
+
+// degradationChanged 降级模式变化：写日志、发布风控事件并告警
+func (at *AutoTrader) degradationChanged(from, to, reason string) {
+	title := fmt.Sprintf("降级模式: %s → %s", degradationModeNames[from], degradationModeNames[to])
+	kind := notify.KindRisk
+	if to == risk.ModeNormal {
+		kind = notify.KindInfo
+		at.log.Warn("数据源已恢复，退出降级模式", "from", from, "reason", reason)
+	} else {
+		at.log.Error("数据源故障，切换降级模式", "from", from, "to", to, "reason", reason)
+	}
+	var detail string
+	switch to {
+	case risk.ModeRESTPolling:
+		detail = "持仓推送断开，改为REST轮询持仓"
+	case risk.ModeExitsOnly:
+		detail = "K线数据过期，暂停开仓，平仓照常"
+	case risk.ModeRulesOnly:
+		detail = fmt.Sprintf("AI不可用，暂停开仓，只按规则管理平仓（浮亏超过%.2f%%平仓）", at.config.Degradation.StopPct())
+	default:
+		detail = "已恢复正常交易"
+	}
+	at.publishRisk("degradation", "", to, fmt.Sprintf("%s: %s", reason, detail))
+	at.notifyReason(kind, riskReason("degradation"), "", title, fmt.Sprintf("%s\n%s", reason, detail))
+}
+
+// observeKlines 按快照中最新K线的时间判断K线是否过期（没有任何币种的K线时同样视为过期）
+func (at *AutoTrader) observeKlines(snapshot *market.MarketSnapshot) {
+	if at.degradation == nil {
+		return
+	}
+	var latest time.Time
+	if snapshot != nil {
+		for _, data := range snapshot.Symbols {
+			if data != nil && data.KlineTime.After(latest) {
+				latest = data.KlineTime
+			}
+		}
+	}
+	if latest.IsZero() {
+		at.degradation.setKlinesStale(true, "没有获取到任何币种的K线")
+		return
+	}
+	age := at.clock.Now().Sub(latest)
+	if age > at.config.Degradation.KlineStaleAfter() {
+		at.degradation.setKlinesStale(true, fmt.Sprintf("最新K线已是%s前", age.Round(time.Second)))
+		return
+	}
+	at.degradation.setKlinesStale(false, "K线数据恢复")
+}
+
+// monitorDegradation 监控持仓推送：断开时切换为REST轮询持仓，按轮询到的数量变化调整止损止盈（与推送回调一致），重连后恢复
+func (at *AutoTrader) monitorDegradation() {
+	source, ok := at.trader.(StreamStatusSource)
+	if !ok {
+		return
+	}
+	interval := at.config.Degradation.PollInterval()
+	var polled map[string]float64 // 上次轮询到的持仓数量（symbol_side → 数量）
+	for {
+		select {
+		case <-at.stopCh:
+			return
+		case <-at.clock.After(interval):
+		}
+		if !at.positionStreaming.Load() {
+			continue
+		}
+
+		connected, lastMessage := source.StreamStatus()
+		if connected {
+			at.degradation.setWSDown(false, "持仓推送已重连")
+			polled = nil
+			continue
+		}
+		since := "从未收到消息"
+		if !lastMessage.IsZero() {
+			since = fmt.Sprintf("最后一条消息在%s前", at.clock.Now().Sub(lastMessage).Round(time.Second))
+		}
+		at.degradation.setWSDown(true, "持仓推送断开，"+since)
+
+		if invalidator, ok := at.trader.(PositionCacheInvalidator); ok {
+			invalidator.InvalidatePositions()
+		}
+		positions, err := at.trader.GetPositions()
+		if err != nil {
+			at.log.Warn("REST轮询持仓失败", "err", err)
+			continue
+		}
+		current := make(map[string]float64, len(positions))
+		for _, pos := range positions {
+			symbol, _ := pos["symbol"].(string)
+			side, _ := pos["side"].(string)
+			if quantity := math.Abs(floatValue(pos["positionAmt"])); symbol != "" && quantity > 0 {
+				current[symbol+"_"+side] = quantity
+				if previous, seen := polled[symbol+"_"+side]; polled != nil && (!seen || !sameQuantity(previous, quantity)) {
+					at.onPositionUpdate(PositionUpdate{Symbol: symbol, Side: side, Quantity: quantity})
+				}
+			}
+		}
+		polled = current
+	}
+}
+
+// runRuleExits 规则平仓（AI不可用时在决策周期内执行）：浮亏超过fallback_stop_pct的持仓市价平仓，
+// 没有止损单的持仓按入场价补挂同样距离的止损（交易器不能查询条件单时不补挂，避免重复挂单）
+func (at *AutoTrader) runRuleExits(ctx context.Context, snapshot *market.MarketSnapshot) []string {
+	if snapshot == nil {
+		return nil
+	}
+	stopPct := at.config.Degradation.StopPct()
+	_, canSeeStops := at.trader.(OpenOrderSource)
+
+	var logs []string
+	for _, pos := range snapshot.Positions {
+		if pos.EntryPrice <= 0 || pos.MarkPrice <= 0 {
+			continue
+		}
+		adverse := (pos.EntryPrice - pos.MarkPrice) / pos.EntryPrice * 100
+		stopPrice := pos.EntryPrice * (1 - stopPct/100)
+		if pos.Side == "short" {
+			adverse = -adverse
+			stopPrice = pos.EntryPrice * (1 + stopPct/100)
+		}
+
+		if adverse >= stopPct {
+			symbol, side := pos.Symbol, pos.Side
+			_, _, err := at.orders.Place(ctx, "degradation", symbol, "close_"+side, 0, pos.MarkPrice, 0,
+				func() (map[string]interface{}, error) {
+					if side == "long" {
+						return at.trader.CloseLong(symbol, 0)
+					}
+					return at.trader.CloseShort(symbol, 0)
+				})
+			if errors.Is(err, ErrPositionNotFound) {
+				continue
+			}
+			if err != nil {
+				logs = append(logs, fmt.Sprintf("❌ %s %s 浮亏%.2f%%，规则平仓失败: %v", symbol, side, adverse, err))
+				continue
+			}
+			logs = append(logs, fmt.Sprintf("🧯 %s %s 浮亏%.2f%%超过%.2f%%，已按规则市价平仓", symbol, side, adverse, stopPct))
+			continue
+		}
+
+		if pos.StopLoss > 0 || !canSeeStops {
+			continue
+		}
+		if err := at.placeStopLoss(pos.Symbol, strings.ToUpper(pos.Side), pos.Quantity, stopPrice, TriggerOptions{}); err != nil {
+			logs = append(logs, fmt.Sprintf("❌ %s %s 没有止损，补挂止损%.4f失败: %v", pos.Symbol, pos.Side, stopPrice, err))
+			continue
+		}
+		logs = append(logs, fmt.Sprintf("🧯 %s %s 没有止损，已按规则补挂止损%.4f", pos.Symbol, pos.Side, stopPrice))
+	}
+	return logs
+}
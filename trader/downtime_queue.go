@@ -0,0 +1,165 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nofx/clock"
+	"nofx/events"
+	"nofx/notify"
+	"nofx/risk"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueuedOrder 交易所维护期间排队的平仓单（恢复后按原数量重新提交）
+type QueuedOrder struct {
+	Symbol    string    `json:"symbol"`
+	Action    string    `json:"action"` // close_long/close_short
+	Strategy  string    `json:"strategy"`
+	Quantity  float64   `json:"quantity"` // 0表示全部平仓
+	Price     float64   `json:"price"`    // 排队时的参考价
+	QueuedAt  time.Time `json:"queued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	ctx      context.Context                        // 原订单的归因（决策ID、提示词版本）
+	leverage int                                    // 原订单的杠杆
+	submit   func() (map[string]interface{}, error) // 原订单的下单函数
+}
+
+// downtimeQueue 维护期间的平仓单队列（symbol_action → 订单，同一币种同一动作只保留最新的一笔；只在内存中）
+type downtimeQueue struct {
+	mu     sync.Mutex
+	config risk.DowntimeQueue
+	clock  clock.Clock
+	items  map[string]*QueuedOrder
+}
+
+func newDowntimeQueue(config risk.DowntimeQueue, c clock.Clock) *downtimeQueue {
+	return &downtimeQueue{config: config, clock: c, items: make(map[string]*QueuedOrder)}
+}
+
+// setConfig 更新配置（热加载，关闭时清空队列）
+func (q *downtimeQueue) setConfig(config risk.DowntimeQueue) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.config = config
+	if !config.Enabled {
+		q.items = make(map[string]*QueuedOrder)
+	}
+}
+
+// offer 平仓单因维护被拒绝时加入队列（开仓单和未启用时返回false），added表示该币种该动作此前没有排队的订单
+func (q *downtimeQueue) offer(o *QueuedOrder) (queued, added bool) {
+	if q == nil || !strings.HasPrefix(o.Action, "close") {
+		return false, false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.config.Enabled {
+		return false, false
+	}
+	now := clock.Or(q.clock).Now()
+	o.QueuedAt, o.ExpiresAt = now, now.Add(q.config.TTL())
+	key := o.Symbol + "_" + o.Action
+	_, exists := q.items[key]
+	q.items[key] = o
+	return true, !exists
+}
+
+// drain 取出全部排队的订单，分为未过期和已过期两组（按排队时间排序）
+func (q *downtimeQueue) drain() (ready, expired []*QueuedOrder) {
+	if q == nil {
+		return nil, nil
+	}
+	q.mu.Lock()
+	now := clock.Or(q.clock).Now()
+	for _, o := range q.items {
+		if now.Before(o.ExpiresAt) {
+			ready = append(ready, o)
+		} else {
+			expired = append(expired, o)
+		}
+	}
+	q.items = make(map[string]*QueuedOrder)
+	q.mu.Unlock()
+
+	for _, list := range [][]*QueuedOrder{ready, expired} {
+		sort.Slice(list, func(i, j int) bool { return list[i].QueuedAt.Before(list[j].QueuedAt) })
+	}
+	return ready, expired
+}
+
+// list 排队中的订单（按排队时间排序，含已过期但尚未清理的）
+func (q *downtimeQueue) list() []QueuedOrder {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]QueuedOrder, 0, len(q.items))
+	for _, o := range q.items {
+		list = append(list, *o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].QueuedAt.Before(list[j].QueuedAt) })
+	return list
+}
+
+// queueForRecovery 维护期间被拒绝的平仓单加入队列，返回包装后的错误（调用方仍按失败处理，恢复后自动重新提交）
+func (t *orderTracker) queueForRecovery(ctx context.Context, strategy, symbol, action string, quantity, price float64,
+	leverage int, submit func() (map[string]interface{}, error), err error) error {
+	if !errors.Is(err, ErrExchangeUnavailable) || !t.status.active() {
+		return err
+	}
+	o := &QueuedOrder{Symbol: symbol, Action: action, Strategy: strategy, Quantity: quantity, Price: price,
+		ctx: context.WithoutCancel(ctx), leverage: leverage, submit: submit}
+	queued, added := t.downtime.offer(o)
+	if !queued {
+		return err
+	}
+	if added {
+		detail := fmt.Sprintf("%s %s 数量%v（%s），%s前恢复时自动执行", symbol, action, quantity, strategy,
+			o.ExpiresAt.Local().Format("15:04"))
+		orderLog.Warn("交易所维护中，平仓单已排队", "trader", t.traderID, "symbol", symbol, "action", action,
+			"quantity", quantity, "strategy", strategy, "expires_at", o.ExpiresAt)
+		t.events.Publish(events.Event{Type: events.TypeRisk, TraderID: t.traderID, Symbol: symbol,
+			Risk: &events.RiskEvent{Rule: "downtime_queue", Action: "queued", Detail: detail, Reason: riskReason("downtime_queue")}})
+		t.notifyReason(notify.KindRisk, riskReason("downtime_queue"), symbol, "交易所维护中，平仓单已排队", detail)
+	}
+	return fmt.Errorf("%w: %w", ErrOrderQueued, err)
+}
+
+// QueuedOrders 交易所维护期间排队的平仓单
+func (at *AutoTrader) QueuedOrders() []QueuedOrder {
+	return at.orders.downtime.list()
+}
+
+// flushDowntimeQueue 交易所恢复后重新提交排队的平仓单（已过期的作废并告警，持仓已不存在的跳过）
+func (at *AutoTrader) flushDowntimeQueue() {
+	ready, expired := at.orders.downtime.drain()
+	for _, o := range expired {
+		detail := fmt.Sprintf("%s %s 数量%v（%s），排队于%s", o.Symbol, o.Action, o.Quantity, o.Strategy,
+			o.QueuedAt.Local().Format("15:04"))
+		at.log.Warn("排队的平仓单已过期", "symbol", o.Symbol, "action", o.Action, "queued_at", o.QueuedAt)
+		at.publishRisk("downtime_queue", o.Symbol, "expired", detail)
+		at.notifyReason(notify.KindRisk, riskReason("downtime_queue"), o.Symbol, "排队的平仓单已过期，未执行", detail)
+	}
+	for _, o := range ready {
+		_, update, err := at.orders.Place(o.ctx, o.Strategy, o.Symbol, o.Action, o.Quantity, o.Price, o.leverage, o.submit)
+		switch {
+		case errors.Is(err, ErrPositionNotFound):
+			at.log.Info("排队的平仓单对应持仓已不存在，跳过", "symbol", o.Symbol, "action", o.Action)
+		case err != nil:
+			at.log.Error("排队的平仓单执行失败", "symbol", o.Symbol, "action", o.Action, "err", err)
+			at.notify(notify.KindError, o.Symbol, fmt.Sprintf("%s %s 排队的平仓单执行失败", o.Symbol, o.Action), err.Error())
+		default:
+			detail := fmt.Sprintf("%s %s 成交%v @ %.6g（%s，排队%s）", o.Symbol, o.Action, update.FilledQty, update.AvgPrice,
+				o.Strategy, at.clock.Now().Sub(o.QueuedAt).Round(time.Second))
+			at.log.Info("排队的平仓单已执行", "symbol", o.Symbol, "action", o.Action, "filled", update.FilledQty)
+			at.publishRisk("downtime_queue", o.Symbol, "executed", detail)
+			at.notify(notify.KindInfo, o.Symbol, "排队的平仓单已执行", detail)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+)
+
+// PositionCacheInvalidator 可使持仓缓存失效的交易器（开仓前按交易所最新持仓检查重复开仓）
+type PositionCacheInvalidator interface {
+	InvalidatePositions()
+}
+
+// entrySide 开仓委托对应的持仓方向（只减仓委托返回空）
+func entrySide(order OpenOrder) string {
+	if order.ReduceOnly {
+		return ""
+	}
+	if order.Quantity < 0 {
+		return "short"
+	}
+	return "long"
+}
+
+// checkDuplicateEntry 新开仓前确认该币种同方向没有持仓或未完成的开仓单，防止重启后缓存过期时叠加第二笔开仓：
+// 交易所持仓（跳过缓存）、交易所上的开仓挂单、交易日志中未到终态的开仓单，以及交易日志中刚开的持仓（交易所持仓可能尚未更新）；
+// 交易所持仓查询失败时拒绝开仓（无法确认时不冒险重复开仓）
+func (at *AutoTrader) checkDuplicateEntry(symbol, side string) error {
+	if invalidator, ok := at.trader.(PositionCacheInvalidator); ok {
+		invalidator.InvalidatePositions()
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("无法确认 %s 是否已有持仓: %w", symbol, err)
+	}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side && floatValue(pos["positionAmt"]) != 0 {
+			return fmt.Errorf("%s 已有%s仓: %w", symbol, sideName(side), ErrDuplicateEntry)
+		}
+	}
+
+	if source, ok := at.trader.(OpenOrderSource); ok {
+		orders, err := source.GetOpenOrders(symbol)
+		if err != nil {
+			return fmt.Errorf("无法确认 %s 是否有未成交的开仓单: %w", symbol, err)
+		}
+		for _, order := range orders {
+			if entrySide(order) == side {
+				return fmt.Errorf("%s 有未成交的开%s挂单 %s（数量%v）: %w", symbol, sideName(side), order.OrderID, math.Abs(order.Quantity), ErrDuplicateEntry)
+			}
+		}
+	}
+
+	active, err := at.journal.ListActiveOrders(at.id)
+	if err != nil {
+		return fmt.Errorf("读取未完成订单失败: %w", err)
+	}
+	for _, order := range active {
+		if order.Symbol == symbol && order.Action == "open_"+side {
+			return fmt.Errorf("%s 的开%s单 #%d 尚未完成（%s）: %w", symbol, sideName(side), order.ID, order.Status, ErrDuplicateEntry)
+		}
+	}
+
+	// 交易日志中已开、交易所还查不到的持仓：刚开仓时交易所持仓可能尚未更新（更早的记录由平仓同步关闭）
+	journaled, err := at.journal.GetOpenPosition(at.id, symbol, side)
+	if err != nil {
+		return fmt.Errorf("读取 %s 持仓记录失败: %w", symbol, err)
+	}
+	if journaled != nil && at.clock.Now().Sub(journaled.OpenedAt) < vanishedPositionGrace {
+		return fmt.Errorf("%s 的%s仓刚开仓（%s），交易所持仓尚未更新: %w", symbol, sideName(side),
+			journaled.OpenedAt.Local().Format("15:04:05"), ErrDuplicateEntry)
+	}
+	return nil
+}
+
+// sideName 持仓方向的中文名称
+func sideName(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
@@ -0,0 +1,176 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/notify"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EntryProposal 待人工确认的开仓建议（confirm_entries开启时AI决策和外部信号的开仓）
+type EntryProposal struct {
+	ID        int64             `json:"id"` // 与平仓建议共用编号
+	TraderID  string            `json:"trader_id"`
+	Source    string            `json:"source"` // 决策来源 ai/webhook
+	Decision  decision.Decision `json:"decision"`
+	Preview   *OrderPreview     `json:"preview,omitempty"` // 生成建议时的下单预览（预览失败时为空）
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	confirmed bool              // 已确认：执行该决策时不再生成建议
+	dismissed bool              // 已忽略：过期前同一币种同一动作不再生成建议
+}
+
+// entryQueue 开仓建议队列（symbol_action → 建议，同一币种同一动作只保留一条；只在内存中）
+type entryQueue struct {
+	mu    sync.Mutex
+	items map[string]*EntryProposal
+}
+
+// deferEntry 确认模式下开仓前检查：没有已确认的建议时生成开仓建议并返回true（已有未过期的建议时不重复生成）
+func (at *AutoTrader) deferEntry(d *decision.Decision) bool {
+	cfg := at.config.EntryConfirm
+	if !cfg.Enabled {
+		return false
+	}
+	key := d.Symbol + "_" + d.Action
+	now := at.clock.Now()
+
+	at.entries.mu.Lock()
+	if p, ok := at.entries.items[key]; ok {
+		switch {
+		case p.confirmed:
+			delete(at.entries.items, key)
+			at.entries.mu.Unlock()
+			return false
+		case now.Before(p.ExpiresAt):
+			at.entries.mu.Unlock()
+			return true
+		}
+		delete(at.entries.items, key)
+	}
+	at.entries.mu.Unlock()
+
+	side := "long"
+	if d.Action == "open_short" {
+		side = "short"
+	}
+	preview, err := at.PreviewOrder(OrderPreviewRequest{
+		Symbol:          d.Symbol,
+		Side:            side,
+		PositionSizeUSD: d.PositionSizeUSD,
+		Leverage:        d.Leverage,
+		Price:           d.LimitPrice,
+		Strategy:        at.execSource,
+	})
+	if err != nil {
+		at.log.Warn("生成下单预览失败", "symbol", d.Symbol, "action", d.Action, "err", err)
+	}
+	p := &EntryProposal{
+		ID:        recommendationSeq.Add(1),
+		TraderID:  at.id,
+		Source:    at.execSource,
+		Decision:  *d,
+		Preview:   preview,
+		CreatedAt: now,
+		ExpiresAt: now.Add(cfg.Timeout()),
+	}
+	at.entries.mu.Lock()
+	at.entries.items[key] = p
+	at.entries.mu.Unlock()
+
+	detail := fmt.Sprintf("#%d [%s] %s %s %.2f USDT %dx", p.ID, p.Source, d.Symbol, d.Action, d.PositionSizeUSD, d.Leverage)
+	at.log.Info("开仓需要人工确认", "id", p.ID, "symbol", d.Symbol, "action", d.Action, "source", p.Source)
+	at.publishRisk("entry_confirm", d.Symbol, d.Action, detail)
+
+	message := detail
+	if preview != nil {
+		message += "\n" + preview.Summary()
+	}
+	message += fmt.Sprintf("\n确认开仓: /confirm %d\n忽略: /dismiss %d\n%d分钟内未确认将作废", p.ID, p.ID, int(cfg.Timeout().Minutes()))
+	publishNotice(at.events, notify.Event{
+		Kind:     notify.KindRisk,
+		TraderID: at.id,
+		Symbol:   d.Symbol,
+		Title:    "开仓建议待确认",
+		Message:  message,
+		Reason:   riskReason("entry_confirm"),
+		Actions: []notify.Action{
+			{Label: "✅ 确认开仓", Command: fmt.Sprintf("/confirm %d", p.ID)},
+			{Label: "✖ 忽略", Command: fmt.Sprintf("/dismiss %d", p.ID)},
+		},
+	})
+	return true
+}
+
+// EntryProposals 待确认的开仓建议（按ID排序，不含已忽略和已过期的）
+func (at *AutoTrader) EntryProposals() []EntryProposal {
+	now := at.clock.Now()
+	at.entries.mu.Lock()
+	defer at.entries.mu.Unlock()
+	list := make([]EntryProposal, 0, len(at.entries.items))
+	for key, p := range at.entries.items {
+		if !now.Before(p.ExpiresAt) {
+			delete(at.entries.items, key)
+			continue
+		}
+		if !p.dismissed && !p.confirmed {
+			list = append(list, *p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// takeEntry 按ID取出未过期的待确认建议（confirm为true时标记为已确认，否则标记为已忽略）
+func (at *AutoTrader) takeEntry(id int64, confirm bool) (*EntryProposal, bool) {
+	now := at.clock.Now()
+	at.entries.mu.Lock()
+	defer at.entries.mu.Unlock()
+	for _, p := range at.entries.items {
+		if p.ID != id || p.dismissed || p.confirmed || !now.Before(p.ExpiresAt) {
+			continue
+		}
+		if confirm {
+			p.confirmed = true
+		} else {
+			p.dismissed = true
+		}
+		return p, true
+	}
+	return nil, false
+}
+
+// ConfirmEntry 确认开仓建议并按原决策执行（重新经过风控检查，found为false表示该trader没有这条建议）
+func (at *AutoTrader) ConfirmEntry(id int64) (p *EntryProposal, found bool, err error) {
+	p, found = at.takeEntry(id, true)
+	if !found {
+		return nil, false, nil
+	}
+	defer func() {
+		// 风控检查拒绝时建议仍留在队列中，删除以免之后的同一决策跳过确认
+		key := p.Decision.Symbol + "_" + p.Decision.Action
+		at.entries.mu.Lock()
+		if at.entries.items[key] == p {
+			delete(at.entries.items, key)
+		}
+		at.entries.mu.Unlock()
+	}()
+
+	d := p.Decision
+	if err = at.ExecuteExternalDecision(&d, p.Source); err != nil {
+		return p, true, fmt.Errorf("开仓 %s %s 失败: %w", d.Symbol, d.Action, err)
+	}
+	at.log.Info("已确认开仓建议", "id", id, "symbol", d.Symbol, "action", d.Action)
+	return p, true, nil
+}
+
+// DismissEntry 忽略开仓建议（过期前同一币种同一动作不再生成建议，found为false表示该trader没有这条建议）
+func (at *AutoTrader) DismissEntry(id int64) (*EntryProposal, bool) {
+	p, found := at.takeEntry(id, false)
+	if found {
+		at.log.Info("已忽略开仓建议", "id", id, "symbol", p.Decision.Symbol, "action", p.Decision.Action)
+	}
+	return p, found
+}
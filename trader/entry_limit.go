@@ -0,0 +1,126 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/risk"
+	"sort"
+	"sync"
+)
+
+// entryLimiter 每个决策周期的新开仓数量上限（开仓按信心度从高到低执行，超出上限的丢弃或顺延到下一周期）
+type entryLimiter struct {
+	mu      sync.Mutex
+	config  risk.EntryLimit
+	queued  []decision.Decision // 顺延到下一周期的开仓
+	carried map[string]bool     // 本周期由上一周期顺延来的开仓（symbol_action，再次超出上限时丢弃）
+}
+
+func newEntryLimiter(config risk.EntryLimit) *entryLimiter {
+	return &entryLimiter{config: config}
+}
+
+// setConfig 更新配置（热加载，不再顺延时清空队列）
+func (l *entryLimiter) setConfig(config risk.EntryLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+	if !config.Enabled() || !config.Queue() {
+		l.queued = nil
+	}
+}
+
+// merge 合并上一周期顺延的开仓（新决策中该币种已有动作时作废），返回合并后的决策和本周期执行的顺延开仓
+func (l *entryLimiter) merge(decisions []decision.Decision) (merged, carried, stale []decision.Decision) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	queued := l.queued
+	l.queued = nil
+	l.carried = make(map[string]bool, len(queued))
+	if len(queued) == 0 {
+		return decisions, nil, nil
+	}
+
+	mentioned := make(map[string]bool, len(decisions))
+	for _, d := range decisions {
+		mentioned[d.Symbol] = true
+	}
+	merged = append([]decision.Decision(nil), decisions...)
+	for _, d := range queued {
+		if mentioned[d.Symbol] {
+			stale = append(stale, d)
+			continue
+		}
+		mentioned[d.Symbol] = true
+		l.carried[d.Symbol+"_"+d.Action] = true
+		merged = append(merged, d)
+		carried = append(carried, d)
+	}
+	return merged, carried, stale
+}
+
+// rank 启用上限时按信心度从高到低排列开仓决策（其他决策位置不变，信心度相同时保持AI给出的顺序）
+func (l *entryLimiter) rank(decisions []decision.Decision) []decision.Decision {
+	l.mu.Lock()
+	enabled := l.config.Enabled()
+	l.mu.Unlock()
+	if !enabled {
+		return decisions
+	}
+
+	var idx []int
+	var entries []decision.Decision
+	for i, d := range decisions {
+		if isOpenAction(d.Action) {
+			idx = append(idx, i)
+			entries = append(entries, d)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Confidence > entries[j].Confidence })
+	ranked := append([]decision.Decision(nil), decisions...)
+	for k, i := range idx {
+		ranked[i] = entries[k]
+	}
+	return ranked
+}
+
+// full 本周期已开仓数是否达到上限（未启用时始终为false）
+func (l *entryLimiter) full(opened int) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config.Enabled() && opened >= l.config.MaxPerCycle, l.config.MaxPerCycle
+}
+
+// overflow 处理超出上限的开仓，返回true表示已顺延到下一周期（顺延来的开仓再次超出时丢弃）
+func (l *entryLimiter) overflow(d decision.Decision) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.config.Queue() || l.carried[d.Symbol+"_"+d.Action] {
+		return false
+	}
+	l.queued = append(l.queued, d)
+	return true
+}
+
+// list 顺延到下一周期的开仓
+func (l *entryLimiter) list() []decision.Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]decision.Decision{}, l.queued...)
+}
+
+// overflowEntry 处理超出每周期开仓上限的开仓（顺延或丢弃）并发布风控事件，返回写入执行记录的原因
+func (at *AutoTrader) overflowEntry(d decision.Decision, limit int) string {
+	result, reason := "dropped", fmt.Sprintf("超出每周期开仓上限%d（信心度%d），已丢弃", limit, d.Confidence)
+	if at.entryLimit.overflow(d) {
+		result, reason = "queued", fmt.Sprintf("超出每周期开仓上限%d（信心度%d），顺延到下一周期", limit, d.Confidence)
+	}
+	at.log.Warn("超出每周期开仓上限", "symbol", d.Symbol, "action", d.Action, "confidence", d.Confidence, "result", result)
+	at.publishRisk("entry_limit", d.Symbol, result, fmt.Sprintf("%s %s %s", d.Symbol, d.Action, reason))
+	return reason
+}
+
+// isOpenAction 是否为开仓动作
+func isOpenAction(action string) bool {
+	return action == "open_long" || action == "open_short"
+}
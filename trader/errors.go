@@ -0,0 +1,170 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"nofx/i18n"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/common"
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// 交易失败的错误分类（交易器将交易所错误映射为这些错误，调用方用errors.Is判断，无需匹配错误文本；文本随语言设置切换）
+var (
+	ErrInsufficientMargin  = i18n.New("保证金不足")
+	ErrLeverageCooldown    = i18n.New("杠杆调整过于频繁")
+	ErrOrderTooSmall       = i18n.New("下单数量低于最小限制")
+	ErrRateLimited         = i18n.New("请求频率超限")
+	ErrPositionNotFound    = i18n.New("持仓不存在")
+	ErrReadOnly            = i18n.New("只读模式，禁止下单")
+	ErrPriceOutOfBand      = i18n.New("价格超出合理范围")
+	ErrExchangeUnavailable = i18n.New("交易所暂不可用")
+	ErrMakerNotFilled      = i18n.New("只挂单未成交")
+	ErrAllocationExceeded  = i18n.New("超出策略资金分配额度")
+	ErrBookLimit           = i18n.New("超出组合敞口上限")
+	ErrLiquidationTooClose = i18n.New("预估强平价距离过近")
+	ErrOrderNotFilled      = i18n.New("订单未成交")
+	ErrOrderUnconfirmed    = i18n.New("订单状态无法确认")
+	ErrAccountLimit        = i18n.New("超出账户杠杆限制")
+	ErrInvalidAPIKey       = i18n.New("API密钥无效")
+	ErrIPNotWhitelisted    = i18n.New("IP不在API密钥白名单中")
+	ErrKeyPermission       = i18n.New("API密钥权限不足")
+	ErrRequestExpired      = i18n.New("请求时间戳已过期")
+	ErrNetworkMismatch     = i18n.New("API密钥与配置的网络（测试网/主网）不一致")
+	ErrHookRejected        = i18n.New("订单被钩子拒绝")
+	ErrRegimeBlocked       = i18n.New("当前行情状态禁止开仓")
+	ErrCalendarBlackout    = i18n.New("重要经济事件前后禁止开仓")
+	ErrEntryBlackout       = i18n.New("禁止开仓时段")
+	ErrOrderRateExceeded   = i18n.New("下单频率超限")
+	ErrRiskProfile         = i18n.New("超出风控参数")
+	ErrEntryPendingConfirm = i18n.New("开仓待人工确认")
+	ErrOrderQueued         = i18n.New("交易所维护中，平仓单已排队")
+	ErrDecisionStale       = i18n.New("决策已过期")
+	ErrLeverageLocked      = i18n.New("持仓或开仓挂单期间禁止切换杠杆")
+	ErrDuplicateEntry      = i18n.New("已有同方向持仓或未完成的开仓单")
+	ErrTradeLossCap        = i18n.New("超出单笔亏损上限")
+	ErrDegraded            = i18n.New("数据源故障降级中，暂停开仓")
+)
+
+// ExchangeError 已分类的交易所错误：errors.Is同时匹配分类（Kind）和原始错误（Err）
+type ExchangeError struct {
+	Kind    error
+	Label   string // 交易所错误标签（如Gate的INSUFFICIENT_AVAILABLE）
+	Message string
+	Policy  RetryPolicy // 重试策略（由交易器的错误标签表给出）
+	Err     error
+}
+
+func (e *ExchangeError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+// Unwrap 返回分类和原始错误
+func (e *ExchangeError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// gateErrors Gate错误标签 → 错误分类和重试策略（未指定策略时使用分类的默认策略）
+var gateErrors = ErrorTable{
+	"INSUFFICIENT_AVAILABLE":    {Kind: ErrInsufficientMargin},
+	"BALANCE_NOT_ENOUGH":        {Kind: ErrInsufficientMargin},
+	"MARGIN_BALANCE_NOT_ENOUGH": {Kind: ErrInsufficientMargin},
+	"LIQUIDATE_IMMEDIATELY":     {Kind: ErrInsufficientMargin}, // 保证金不足以支撑该仓位，下单即强平
+	"ORDER_SIZE_TOO_SMALL":      {Kind: ErrOrderTooSmall},
+	"SIZE_TOO_SMALL":            {Kind: ErrOrderTooSmall},
+	"AMOUNT_TOO_LITTLE":         {Kind: ErrOrderTooSmall}, // 现货
+	"TOO_MANY_REQUESTS":         {Kind: ErrRateLimited},
+	"TOO_FAST":                  {Kind: ErrRateLimited},
+	"POSITION_NOT_FOUND":        {Kind: ErrPositionNotFound},
+	"POSITION_EMPTY":            {Kind: ErrPositionNotFound},
+	"REDUCE_ONLY_FAIL":          {Kind: ErrPositionNotFound}, // 只减仓单方向与持仓相同（持仓已平或已反向）
+	"SERVER_ERROR":              {Kind: ErrExchangeUnavailable},
+	"TOO_BUSY":                  {Kind: ErrExchangeUnavailable},
+	"ORDER_POC":                 {Kind: ErrMakerNotFilled}, // 只挂单会立即成交，交易所拒绝
+	"INVALID_KEY":               {Kind: ErrInvalidAPIKey},
+	"INVALID_CREDENTIALS":       {Kind: ErrInvalidAPIKey},
+	"INVALID_SIGNATURE":         {Kind: ErrInvalidAPIKey},
+	"IP_FORBIDDEN":              {Kind: ErrIPNotWhitelisted},
+	"READ_ONLY":                 {Kind: ErrKeyPermission},  // 只读密钥调用交易接口
+	"FORBIDDEN":                 {Kind: ErrKeyPermission},  // 密钥未开通该业务（如合约）的权限
+	"REQUEST_EXPIRED":           {Kind: ErrRequestExpired}, // 签名时间戳与服务器时间相差过大
+}
+
+// gateMessageKind 标签未覆盖时按错误消息关键字分类（杠杆冷却没有专用标签）
+func gateMessageKind(message string) error {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "leverage") && (strings.Contains(lower, "frequent") || strings.Contains(lower, "cool")):
+		return ErrLeverageCooldown
+	case strings.Contains(lower, "insufficient"):
+		return ErrInsufficientMargin
+	case strings.Contains(lower, "too small") || strings.Contains(lower, "less than minimum"):
+		return ErrOrderTooSmall
+	case strings.Contains(lower, "rate limit") || strings.Contains(lower, "too many requests"):
+		return ErrRateLimited
+	case strings.Contains(lower, "maintenance"):
+		return ErrExchangeUnavailable
+	case strings.Contains(lower, "whitelist"):
+		return ErrIPNotWhitelisted
+	}
+	return nil
+}
+
+// classifyGateError 将Gate SDK返回的错误映射为错误分类（无法分类时原样返回）
+func classifyGateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var gateErr gateapi.GateAPIError
+	if errors.As(err, &gateErr) {
+		message := gateErr.GetMessage()
+		if classified := gateErrors.classify(gateErr.Label, message, gateMessageKind(message), err); classified != nil {
+			return classified
+		}
+		return err
+	}
+
+	// 限流时网关可能直接返回429，维护或过载时返回5xx，响应体中没有错误标签
+	var apiErr gateapi.GenericOpenAPIError
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Error(), "429") {
+		return gateErrors.classify("HTTP_429", apiErr.Error(), ErrRateLimited, err)
+	}
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Error(), "5") {
+		return gateErrors.classify("HTTP_"+apiErr.Error()[:3], apiErr.Error(), ErrExchangeUnavailable, err)
+	}
+	return err
+}
+
+// binanceErrors 币安合约错误码 → 错误分类和重试策略
+var binanceErrors = ErrorTable{
+	"-1001": {Kind: ErrExchangeUnavailable},                       // 内部连接断开
+	"-1007": {Kind: ErrExchangeUnavailable},                       // 等待后端响应超时，下单结果未知
+	"-1008": {Kind: ErrExchangeUnavailable, Policy: RetryBackoff}, // 服务器过载，请求未被处理
+	"-1003": {Kind: ErrRateLimited},
+	"-1015": {Kind: ErrRateLimited}, // 下单过多
+	"-1021": {Kind: ErrRequestExpired},
+	"-1022": {Kind: ErrInvalidAPIKey}, // 签名无效
+	"-2014": {Kind: ErrInvalidAPIKey},
+	"-2015": {Kind: ErrInvalidAPIKey}, // 密钥、IP或权限无效（币安不区分）
+	"-2018": {Kind: ErrInsufficientMargin},
+	"-2019": {Kind: ErrInsufficientMargin},
+	"-2022": {Kind: ErrPositionNotFound}, // 只减仓单被拒绝（持仓已不存在）
+	"-4003": {Kind: ErrOrderTooSmall},
+	"-4164": {Kind: ErrOrderTooSmall}, // 名义价值低于最小限制
+	"-5022": {Kind: ErrMakerNotFilled},
+}
+
+// classifyBinanceError 将币安SDK返回的错误映射为错误分类（无法分类时原样返回）
+func classifyBinanceError(err error) error {
+	var apiErr *common.APIError
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+	label := fmt.Sprintf("%d", apiErr.Code)
+	if classified := binanceErrors.classify(label, apiErr.Message, nil, err); classified != nil {
+		return classified
+	}
+	return err
+}
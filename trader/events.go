@@ -0,0 +1,65 @@
+package trader
+
+import (
+	"nofx/events"
+	"nofx/notify"
+	"nofx/store"
+	"strings"
+	"time"
+)
+
+// publishNotice 发布通知事件（由订阅事件总线的通知渠道推送）
+func publishNotice(bus *events.Bus, n notify.Event) {
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	bus.Publish(events.Event{Type: events.TypeNotice, TraderID: n.TraderID, Symbol: n.Symbol, Time: n.Time, Notice: &n})
+}
+
+// publishRisk 发布风控事件（action为被拒绝的决策动作，规则不针对单个决策时为空；原因代码为 RISK_<规则>）
+func (at *AutoTrader) publishRisk(rule, symbol, action, detail string) {
+	at.publishRiskReason(rule, riskReason(rule), symbol, action, detail)
+}
+
+// publishRiskReason 发布带指定原因代码的风控事件（同一规则有多种触发原因时使用）
+func (at *AutoTrader) publishRiskReason(rule, reason, symbol, action, detail string) {
+	at.events.Publish(events.Event{Type: events.TypeRisk, TraderID: at.id, Symbol: symbol,
+		Risk: &events.RiskEvent{Rule: rule, Action: action, Detail: detail, Reason: reason}})
+}
+
+// publishDecision 发布决策执行结果
+func (at *AutoTrader) publishDecision(decisionID, symbol, action string, leverage int, sizeUSD float64, err error) {
+	result := &events.DecisionEvent{ID: decisionID, Source: at.execSource, Action: action, Leverage: leverage,
+		SizeUSD: sizeUSD, Success: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	at.events.Publish(events.Event{Type: events.TypeDecision, TraderID: at.id, Symbol: symbol, Decision: result})
+}
+
+// subscribeEvents 订阅本trader的事件：通知渠道推送通知、交易日志持久化风控事件、策略看守跟踪持仓时间
+func (at *AutoTrader) subscribeEvents(notifier notify.Notifier) {
+	if notifier != nil {
+		at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypeNotice}}, func(e events.Event) {
+			notifier.Notify(*e.Notice)
+		})
+	}
+
+	if at.journal != nil {
+		at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypeRisk}}, func(e events.Event) {
+			err := at.journal.RecordRiskEvent(store.RiskEvent{TraderID: e.TraderID, Symbol: e.Symbol, Rule: e.Risk.Rule,
+				Action: e.Risk.Action, Detail: e.Risk.Detail, Reason: e.Risk.Reason, Time: e.Time})
+			if err != nil {
+				journalLog.Warn("写入风控事件失败", "trader", at.id, "rule", e.Risk.Rule, "err", err)
+			}
+		})
+	}
+
+	// 平仓成交后清除持仓首次出现时间，同一方向重新开仓时按时间平仓从新持仓开始计时
+	// （下单都在cycleMu内执行，处理函数在下单的goroutine中同步调用）
+	at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypePosition}}, func(e events.Event) {
+		if e.Position.Change == "close" {
+			delete(at.positionFirstSeenTime, e.Symbol+"_"+strings.ToLower(e.Position.Side))
+		}
+	})
+}
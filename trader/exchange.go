@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/logging"
+)
+
+// NewExchangeTrader 按配置创建交易所交易器（不含AI和策略，命令行独立模式也用它直接操作交易所）
+func NewExchangeTrader(config AutoTraderConfig) (Trader, error) {
+	traderLog := logging.For("trader").With("trader", config.ID)
+	switch config.Exchange {
+	case "binance", "":
+		traderLog.Info("使用币安合约交易")
+		return NewFuturesTrader(config.BinanceAPIKey, config.BinanceSecretKey), nil
+	case "hyperliquid":
+		traderLog.Info("使用Hyperliquid交易", "testnet", config.HyperliquidTestnet)
+		trader, err := NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
+		}
+		return trader, nil
+	case "aster":
+		traderLog.Info("使用Aster交易")
+		trader, err := NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+		}
+		return trader, nil
+	case "gate":
+		traderLog.Info("使用Gate.io交易", "testnet", config.GateTestnet)
+		trader, err := NewGateTrader(config.GateAPIKey, config.GateSecretKey, config.GateTestnet)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Gate.io交易器失败: %w", err)
+		}
+		return trader, nil
+	default:
+		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
+	}
+}
@@ -0,0 +1,85 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// gateClockOffset 签名时间戳的校正量（交易所时间 - 本地时间），由时间同步监控设置，REST和WebSocket共用
+type gateClockOffset struct {
+	ns atomic.Int64
+}
+
+// now 校正后的当前时间（用于签名时间戳）
+func (o *gateClockOffset) now() time.Time {
+	if o == nil {
+		return time.Now()
+	}
+	return time.Now().Add(time.Duration(o.ns.Load()))
+}
+
+// get 当前校正量
+func (o *gateClockOffset) get() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return time.Duration(o.ns.Load())
+}
+
+// gateSigningTransport 本地时钟偏差较大时用校正后的时间戳重新签名请求
+// （SDK签名固定使用本地时间，时间戳超出交易所允许的窗口时返回签名错误，看起来像密钥失效）
+type gateSigningTransport struct {
+	base   http.RoundTripper
+	secret string
+	offset *gateClockOffset
+}
+
+// RoundTrip 没有校正量或不是签名请求时原样发送，否则按SDK相同的方式重新签名: method\npath\nquery\nsha512(body)\ntimestamp
+func (t *gateSigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.offset.get() == 0 || req.Header.Get("SIGN") == "" {
+		return base.RoundTrip(req)
+	}
+
+	hash := sha512.New()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(hash, body)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	query, err := url.QueryUnescape(req.URL.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := strconv.FormatInt(t.offset.now().Unix(), 10)
+	mac := hmac.New(sha512.New, []byte(t.secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", req.Method, req.URL.Path, query, hex.EncodeToString(hash.Sum(nil)), ts)
+
+	signed := req.Clone(req.Context())
+	signed.Header.Set("SIGN", hex.EncodeToString(mac.Sum(nil)))
+	signed.Header.Set("Timestamp", ts)
+	return base.RoundTrip(signed)
+}
+
+// SetClockOffset 设置签名时间戳的校正量（交易所时间 - 本地时间），0表示使用本地时间
+func (t *GateTrader) SetClockOffset(offset time.Duration) {
+	t.clockOffset.ns.Store(int64(offset))
+}
@@ -0,0 +1,354 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/store"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antihax/optional"
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// GetFills 获取指定时间之后的成交记录（Gate.io MyTrades，不含手续费，手续费从账户流水获取）
+func (t *GateTrader) GetFills(since time.Time) ([]store.Fill, error) {
+	const pageSize = 100
+	const maxPages = 10
+
+	var fills []store.Fill
+	for page := 0; page < maxPages; page++ {
+		trades, _, err := t.client.FuturesApi.GetMyTrades(t.ctx, t.settle, &gateapi.GetMyTradesOpts{
+			Limit:  optional.NewInt32(pageSize),
+			Offset: optional.NewInt32(int32(page * pageSize)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("获取成交记录失败: %w", classifyGateError(err))
+		}
+
+		reachedSince := false
+		for _, trade := range trades {
+			tradeTime := time.Unix(0, int64(trade.CreateTime*float64(time.Second)))
+			if tradeTime.Before(since) {
+				reachedSince = true
+				continue
+			}
+
+			multiplier := 1.0
+			if contractInfo, err := t.getContractInfo(trade.Contract); err == nil {
+				if m, err := strconv.ParseFloat(contractInfo.QuantoMultiplier, 64); err == nil && m > 0 {
+					multiplier = m
+				}
+			}
+			price, _ := strconv.ParseFloat(trade.Price, 64)
+
+			side := "buy"
+			if trade.Size < 0 {
+				side = "sell"
+			}
+
+			fills = append(fills, store.Fill{
+				OrderID:  trade.OrderId,
+				TradeID:  strconv.FormatInt(trade.Id, 10),
+				Symbol:   convertGateContractToSymbol(trade.Contract),
+				Side:     side,
+				Price:    price,
+				Quantity: math.Abs(float64(trade.Size)) * multiplier,
+				Role:     trade.Role,
+				Time:     tradeTime,
+			})
+		}
+
+		// 按时间倒序返回，已到达起始时间或不足一页时结束
+		if reachedSince || len(trades) < pageSize {
+			break
+		}
+	}
+	return fills, nil
+}
+
+// GetCosts 获取指定时间之后的手续费和资金费流水（Gate.io账户流水）
+func (t *GateTrader) GetCosts(since time.Time) ([]store.Cost, error) {
+	var costs []store.Cost
+
+	// Gate.io流水类型 -> 费用类型
+	for bookType, kind := range map[string]string{"fee": store.CostFee, "fund": store.CostFunding} {
+		entries, _, err := t.client.FuturesApi.ListFuturesAccountBook(t.ctx, t.settle, &gateapi.ListFuturesAccountBookOpts{
+			Limit: optional.NewInt32(1000),
+			From:  optional.NewInt64(since.Unix()),
+			Type_: optional.NewString(bookType),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("获取账户流水(%s)失败: %w", bookType, classifyGateError(err))
+		}
+
+		for _, entry := range entries {
+			amount, err := strconv.ParseFloat(entry.Change, 64)
+			if err != nil {
+				continue
+			}
+
+			// 流水备注格式: "BTC_USDT:订单ID"（资金费只有合约名）
+			contract, orderID, _ := strings.Cut(entry.Text, ":")
+			costs = append(costs, store.Cost{
+				Kind:    kind,
+				Symbol:  convertGateContractToSymbol(contract),
+				OrderID: orderID,
+				Amount:  amount,
+				Time:    time.Unix(0, int64(entry.Time*float64(time.Second))),
+			})
+		}
+	}
+	return costs, nil
+}
+
+// GetOrderStatus 查询合约订单状态（数量单位为合约张数，与下单一致）
+func (t *GateTrader) GetOrderStatus(symbol string, orderID string) (store.OrderUpdate, error) {
+	order, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID)
+	if err != nil {
+		return store.OrderUpdate{}, fmt.Errorf("查询订单失败: %w", classifyGateError(err))
+	}
+	return gateOrderUpdate(order), nil
+}
+
+// FindOrderByClientID 在该合约最近的未成交和已结束委托中查找订单文本为clientID的订单
+// （按文本直接查询订单只在订单结束后60秒内有效，因此查委托列表）
+func (t *GateTrader) FindOrderByClientID(symbol, clientID string) (store.OrderUpdate, error) {
+	contract := convertSymbolToGateContract(symbol)
+	for _, status := range []string{"open", "finished"} {
+		orders, _, err := t.client.FuturesApi.ListFuturesOrders(t.ctx, t.settle, contract, status,
+			&gateapi.ListFuturesOrdersOpts{Limit: optional.NewInt32(100)})
+		if err != nil {
+			return store.OrderUpdate{}, fmt.Errorf("查询委托列表失败: %w", classifyGateError(err))
+		}
+		for _, order := range orders {
+			if order.Text == clientID {
+				return gateOrderUpdate(order), nil
+			}
+		}
+	}
+	return store.OrderUpdate{}, nil
+}
+
+// gateOrderUpdate 合约订单转换为订单状态（数量单位为合约张数）
+func gateOrderUpdate(order gateapi.FuturesOrder) store.OrderUpdate {
+	orderID := strconv.FormatInt(order.Id, 10)
+	size := math.Abs(float64(order.Size))
+	filled := size - math.Abs(float64(order.Left))
+	avgPrice, _ := strconv.ParseFloat(order.FillPrice, 64)
+
+	update := store.OrderUpdate{
+		OrderID:   orderID,
+		FilledQty: filled,
+		AvgPrice:  avgPrice,
+		Detail:    order.FinishAs,
+	}
+
+	switch {
+	case order.Status == "open" && filled > 0:
+		update.State = store.OrderPartiallyFilled
+	case order.Status == "open":
+		update.State = store.OrderSubmitted
+	case filled >= size && size > 0:
+		update.State = store.OrderFilled
+	default:
+		// IOC剩余部分被撤销、主动撤单、reduce_only等（可能带部分成交）
+		update.State = store.OrderCancelled
+	}
+	return update
+}
+
+// GetOrderText 查询订单下单时的自定义文本（成交记录不含text，按订单ID反查）
+func (t *GateTrader) GetOrderText(symbol string, orderID string) (string, error) {
+	order, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID)
+	if err != nil {
+		return "", fmt.Errorf("查询订单失败: %w", classifyGateError(err))
+	}
+	return order.Text, nil
+}
+
+// SetOrderText 设置该币种后续订单的自定义文本（为空表示不附带），按合约分别保存，不同币种的并发下单互不覆盖
+func (t *GateTrader) SetOrderText(symbol, text string) {
+	contract := convertSymbolToGateContract(symbol)
+	t.orderTextMutex.Lock()
+	defer t.orderTextMutex.Unlock()
+	if text == "" {
+		delete(t.orderTexts, contract)
+		return
+	}
+	if t.orderTexts == nil {
+		t.orderTexts = make(map[string]string)
+	}
+	t.orderTexts[contract] = text
+}
+
+// currentOrderText 合约当前订单的自定义文本
+func (t *GateTrader) currentOrderText(contract string) string {
+	t.orderTextMutex.Lock()
+	defer t.orderTextMutex.Unlock()
+	return t.orderTexts[contract]
+}
+
+// CancelTriggerOrder 撤销单个条件单（CancelAllOrders只撤普通委托，不影响条件单）
+func (t *GateTrader) CancelTriggerOrder(symbol, orderID string) error {
+	defer t.enterContract(symbol)()
+
+	if _, _, err := t.client.FuturesApi.CancelPriceTriggeredOrder(t.ctx, t.settle, orderID); err != nil {
+		return fmt.Errorf("撤销条件单失败: %w", classifyGateError(err))
+	}
+	gateLog.Debug("已撤销条件单", "symbol", symbol, "order_id", orderID)
+	return nil
+}
+
+// GetOpenOrders 获取指定币种未成交的普通委托单（symbol为空时获取所有合约的）
+func (t *GateTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	var orders []gateapi.FuturesOrder
+	var err error
+	if symbol == "" {
+		// SDK的contract参数必填，不指定合约时直接调用接口
+		err = t.gateGet(fmt.Sprintf("/futures/%s/orders?status=open", t.settle), &orders)
+	} else {
+		orders, _, err = t.client.FuturesApi.ListFuturesOrders(t.ctx, t.settle, convertSymbolToGateContract(symbol), "open", nil)
+		err = classifyGateError(err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取未成交委托失败: %w", err)
+	}
+
+	result := make([]OpenOrder, 0, len(orders))
+	for _, order := range orders {
+		price, _ := strconv.ParseFloat(order.Price, 64)
+		result = append(result, OpenOrder{
+			OrderID:    strconv.FormatInt(order.Id, 10),
+			Symbol:     convertGateContractToSymbol(order.Contract),
+			Quantity:   float64(order.Size),
+			Price:      price,
+			ReduceOnly: order.IsReduceOnly,
+			Text:       order.Text,
+		})
+	}
+	return result, nil
+}
+
+// GetOpenTriggerOrders 获取所有生效中的止损/止盈条件单
+func (t *GateTrader) GetOpenTriggerOrders() ([]TriggerOrder, error) {
+	orders, _, err := t.client.FuturesApi.ListPriceTriggeredOrders(t.ctx, t.settle, "open", nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取条件单失败: %w", classifyGateError(err))
+	}
+
+	result := make([]TriggerOrder, 0, len(orders))
+	for _, order := range orders {
+		price, _ := strconv.ParseFloat(order.Trigger.Price, 64)
+
+		// 平多单为卖出（size<0），平空单为买入（size>0）
+		// 多仓: ≤触发(rule=2)为止损，≥触发(rule=1)为止盈；空仓相反
+		positionSide := "long"
+		if order.Initial.Size > 0 {
+			positionSide = "short"
+		}
+		kind := "take_profit"
+		if (positionSide == "long" && order.Trigger.Rule == 2) || (positionSide == "short" && order.Trigger.Rule == 1) {
+			kind = "stop_loss"
+		}
+
+		trigger := TriggerOrder{
+			OrderID:      strconv.FormatInt(order.Id, 10),
+			Symbol:       convertGateContractToSymbol(order.Initial.Contract),
+			PositionSide: positionSide,
+			Kind:         kind,
+			TriggerPrice: price,
+			Quantity:     math.Abs(float64(order.Initial.Size)),
+			Expiration:   int64(order.Trigger.Expiration),
+			PriceType:    gatePriceTypeName(order.Trigger.PriceType),
+			Text:         order.Initial.Text,
+		}
+		if trigger.Expiration > 0 && order.CreateTime > 0 {
+			trigger.ExpiresAt = int64(order.CreateTime) + trigger.Expiration
+		}
+		result = append(result, trigger)
+	}
+	return result, nil
+}
+
+// gatePositionClose 平仓历史（SDK的PositionClose缺少开平仓均价、数量和盈亏明细，直接调用接口）
+type gatePositionClose struct {
+	Time          float64 `json:"time"`
+	Contract      string  `json:"contract"`
+	Side          string  `json:"side"`
+	Pnl           string  `json:"pnl"`
+	PnlPnl        string  `json:"pnl_pnl"`     // 仓位盈亏（不含手续费和资金费）
+	PnlFee        string  `json:"pnl_fee"`     // 手续费（负数表示支出）
+	PnlFund       string  `json:"pnl_fund"`    // 资金费（正数表示收入）
+	LongPrice     string  `json:"long_price"`  // 多仓为开仓均价，空仓为平仓均价
+	ShortPrice    string  `json:"short_price"` // 空仓为开仓均价，多仓为平仓均价
+	AccumSize     string  `json:"accum_size"`  // 累计开仓张数
+	FirstOpenTime int64   `json:"first_open_time"`
+	Text          string  `json:"text"` // 平仓订单的自定义文本
+}
+
+// GetClosedPositions 获取指定时间之后平仓的交易（Gate.io平仓历史，按平仓时间倒序分页）
+// 策略按平仓订单的文本归因，非本系统下的订单记为imported
+func (t *GateTrader) GetClosedPositions(since time.Time) ([]store.Position, error) {
+	const pageSize = 100
+	const maxPages = 50
+
+	var positions []store.Position
+	for page := 0; page < maxPages; page++ {
+		var records []gatePositionClose
+		path := fmt.Sprintf("/futures/%s/position_close?from=%d&limit=%d&offset=%d", t.settle, since.Unix(), pageSize, page*pageSize)
+		if err := t.gateGet(path, &records); err != nil {
+			return nil, fmt.Errorf("获取平仓历史失败: %w", err)
+		}
+		for _, r := range records {
+			multiplier := 1.0
+			if contractInfo, err := t.getContractInfo(r.Contract); err == nil {
+				if m, err := strconv.ParseFloat(contractInfo.QuantoMultiplier, 64); err == nil && m > 0 {
+					multiplier = m
+				}
+			}
+			size, _ := strconv.ParseFloat(r.AccumSize, 64)
+			longPrice, _ := strconv.ParseFloat(r.LongPrice, 64)
+			shortPrice, _ := strconv.ParseFloat(r.ShortPrice, 64)
+			gross, err := strconv.ParseFloat(r.PnlPnl, 64)
+			if err != nil {
+				gross, _ = strconv.ParseFloat(r.Pnl, 64) // 旧记录没有盈亏明细
+			}
+			fee, _ := strconv.ParseFloat(r.PnlFee, 64)
+			funding, _ := strconv.ParseFloat(r.PnlFund, 64)
+
+			closedAt := time.Unix(0, int64(r.Time*float64(time.Second)))
+			openedAt := closedAt
+			if r.FirstOpenTime > 0 {
+				openedAt = time.Unix(r.FirstOpenTime, 0)
+			}
+			entry, exit := longPrice, shortPrice
+			if r.Side == "short" {
+				entry, exit = shortPrice, longPrice
+			}
+			p := store.Position{
+				Symbol:      convertGateContractToSymbol(r.Contract),
+				Side:        r.Side,
+				Quantity:    math.Abs(size) * multiplier,
+				EntryPrice:  entry,
+				ExitPrice:   exit,
+				Strategy:    "imported",
+				Status:      "closed",
+				OpenedAt:    openedAt,
+				RealizedPnL: gross,
+				Fees:        -fee,
+				Funding:     funding,
+				ClosedAt:    &closedAt,
+			}
+			if tag, ok := ParseOrderTag(r.Text); ok {
+				p.Strategy, p.DecisionID, p.PromptVersion = tag.Strategy, tag.DecisionID, tag.PromptVersion
+			}
+			positions = append(positions, p)
+		}
+		if len(records) < pageSize {
+			break
+		}
+	}
+	return positions, nil
+}
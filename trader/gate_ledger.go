@@ -0,0 +1,125 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antihax/optional"
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// GetLedger 获取指定时间之后的资金变动：合约账户划转（dnw流水）和钱包提现/充值记录。
+// API密钥没有钱包读取权限时只返回合约账户划转（首次发现时记录警告）
+func (t *GateTrader) GetLedger(since time.Time) ([]LedgerEntry, error) {
+	book, _, err := t.client.FuturesApi.ListFuturesAccountBook(t.ctx, t.settle, &gateapi.ListFuturesAccountBookOpts{
+		Limit: optional.NewInt32(1000),
+		From:  optional.NewInt64(since.Unix()),
+		Type_: optional.NewString("dnw"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取账户流水(dnw)失败: %w", classifyGateError(err))
+	}
+
+	var entries []LedgerEntry
+	for _, entry := range book {
+		change, err := strconv.ParseFloat(entry.Change, 64)
+		if err != nil || change == 0 {
+			continue
+		}
+		kind := LedgerDeposit
+		if change < 0 {
+			kind = LedgerTransferOut
+		}
+		// 流水没有ID，按时间、变动和余额组合去重
+		entries = append(entries, LedgerEntry{
+			ID:       fmt.Sprintf("dnw:%.3f:%s:%s", entry.Time, entry.Change, entry.Balance),
+			Kind:     kind,
+			Currency: strings.ToUpper(t.settle),
+			Amount:   math.Abs(change),
+			Detail:   entry.Text,
+			Time:     time.Unix(0, int64(entry.Time*float64(time.Second))),
+		})
+	}
+
+	if t.walletDenied.Load() {
+		return entries, nil
+	}
+	wallet, err := t.walletLedger(since)
+	if errors.Is(err, ErrKeyPermission) {
+		t.walletDenied.Store(true)
+		gateLog.Warn("API密钥没有钱包读取权限，只监控合约账户划转", "err", err)
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, wallet...), nil
+}
+
+// walletLedger 获取钱包提现和充值记录（提现包含已取消和待审核的申请）
+func (t *GateTrader) walletLedger(since time.Time) ([]LedgerEntry, error) {
+	withdrawals, _, err := t.client.WalletApi.ListWithdrawals(t.ctx, &gateapi.ListWithdrawalsOpts{
+		From: optional.NewInt64(since.Unix()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取提现记录失败: %w", classifyGateError(err))
+	}
+	deposits, _, err := t.client.WalletApi.ListDeposits(t.ctx, &gateapi.ListDepositsOpts{
+		From: optional.NewInt64(since.Unix()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取充值记录失败: %w", classifyGateError(err))
+	}
+
+	entries := make([]LedgerEntry, 0, len(withdrawals)+len(deposits))
+	for kind, records := range map[string][]gateapi.LedgerRecord{LedgerWithdrawal: withdrawals, LedgerDeposit: deposits} {
+		for _, record := range records {
+			amount, _ := strconv.ParseFloat(record.Amount, 64)
+			ts, _ := strconv.ParseInt(record.Timestamp, 10, 64)
+			var detail []string
+			for _, field := range []string{record.Address, record.Chain, record.Status} {
+				if field != "" {
+					detail = append(detail, field)
+				}
+			}
+			entries = append(entries, LedgerEntry{
+				ID:       kind + ":" + record.Id,
+				Kind:     kind,
+				Currency: record.Currency,
+				Amount:   math.Abs(amount),
+				Detail:   strings.Join(detail, " "),
+				Time:     time.Unix(ts, 0),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// GetKeyInfo 获取API密钥可见的账户信息（/account/detail：用户ID、IP白名单、交易对白名单、密钥模式）
+func (t *GateTrader) GetKeyInfo() (KeyInfo, error) {
+	var detail struct {
+		UserID        int64    `json:"user_id"`
+		IPWhitelist   []string `json:"ip_whitelist"`
+		CurrencyPairs []string `json:"currency_pairs"`
+		Key           struct {
+			Mode int `json:"mode"`
+		} `json:"key"`
+	}
+	if err := t.gateGet("/account/detail", &detail); err != nil {
+		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+	}
+
+	sort.Strings(detail.IPWhitelist)
+	sort.Strings(detail.CurrencyPairs)
+	return KeyInfo{
+		"user_id":        strconv.FormatInt(detail.UserID, 10),
+		"ip_whitelist":   strings.Join(detail.IPWhitelist, ","),
+		"currency_pairs": strings.Join(detail.CurrencyPairs, ","),
+		"key_mode":       strconv.Itoa(detail.Key.Mode),
+	}, nil
+}
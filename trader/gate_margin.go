@@ -0,0 +1,216 @@
+package trader
+
+import (
+	"fmt"
+	"net/url"
+	"nofx/risk"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// gateMaxRiskTiers 按风险限额推算的最多档位数（step过小时避免生成过多档位）
+const gateMaxRiskTiers = 50
+
+// gateRiskLimitTier 风险限额档位（/futures/{settle}/risk_limit_tiers，数值均为字符串）
+type gateRiskLimitTier struct {
+	Tier            int    `json:"tier"`
+	RiskLimit       string `json:"risk_limit"`       // 该档位的仓位价值上限（USDT）
+	InitialRate     string `json:"initial_rate"`     // 起始保证金率
+	MaintenanceRate string `json:"maintenance_rate"` // 维持保证金率
+	LeverageMax     string `json:"leverage_max"`     // 最大杠杆
+}
+
+// GetMaintenanceTiers 合约的维持保证金（风险限额）档位，随合约信息缓存
+// 优先使用交易所公布的档位表，查询失败时按合约的风险限额参数推算
+func (t *GateTrader) GetMaintenanceTiers(symbol string) ([]risk.MaintenanceTier, error) {
+	contract := convertSymbolToGateContract(symbol)
+	t.contractCacheMutex.RLock()
+	tiers, ok := t.riskTiers[contract]
+	t.contractCacheMutex.RUnlock()
+	if ok {
+		return tiers, nil
+	}
+
+	tiers, err := t.fetchRiskLimitTiers(contract)
+	if err != nil || len(tiers) == 0 {
+		if err != nil {
+			gateLog.Debug("查询风险限额档位失败，按合约参数推算", "contract", contract, "err", err)
+		}
+		if tiers, err = t.derivedMaintenanceTiers(contract); err != nil {
+			return nil, err
+		}
+	}
+
+	t.contractCacheMutex.Lock()
+	if t.riskTiers == nil {
+		t.riskTiers = make(map[string][]risk.MaintenanceTier)
+	}
+	t.riskTiers[contract] = tiers
+	t.contractCacheMutex.Unlock()
+	return tiers, nil
+}
+
+// fetchRiskLimitTiers 查询交易所公布的风险限额档位（按仓位价值上限从小到大）
+func (t *GateTrader) fetchRiskLimitTiers(contract string) ([]risk.MaintenanceTier, error) {
+	var raw []gateRiskLimitTier
+	path := fmt.Sprintf("/futures/%s/risk_limit_tiers?contract=%s", t.settle, url.QueryEscape(contract))
+	if err := t.gateGet(path, &raw); err != nil {
+		return nil, err
+	}
+	sort.Slice(raw, func(i, j int) bool { return raw[i].Tier < raw[j].Tier })
+
+	tiers := make([]risk.MaintenanceTier, 0, len(raw))
+	for _, r := range raw {
+		var tier risk.MaintenanceTier
+		tier.MaxValue, _ = strconv.ParseFloat(r.RiskLimit, 64)
+		tier.Rate, _ = strconv.ParseFloat(r.MaintenanceRate, 64)
+		tier.InitialRate, _ = strconv.ParseFloat(r.InitialRate, 64)
+		leverage, _ := strconv.ParseFloat(r.LeverageMax, 64)
+		tier.MaxLeverage = int(leverage)
+		if tier.Rate <= 0 {
+			return nil, fmt.Errorf("%s 第%d档维持保证金率无效: %q", contract, r.Tier, r.MaintenanceRate)
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+// derivedMaintenanceTiers 按合约的风险限额参数推算维持保证金档位
+// Gate.io: 仓位价值每超过risk_limit_base一个risk_limit_step，维持保证金率增加一个基础维持保证金率，直到risk_limit_max
+func (t *GateTrader) derivedMaintenanceTiers(contract string) ([]risk.MaintenanceTier, error) {
+	contractInfo, err := t.getContractInfo(contract)
+	if err != nil {
+		return nil, fmt.Errorf("获取合约信息失败: %w", classifyGateError(err))
+	}
+	rate, _ := strconv.ParseFloat(contractInfo.MaintenanceRate, 64)
+	base, _ := strconv.ParseFloat(contractInfo.RiskLimitBase, 64)
+	step, _ := strconv.ParseFloat(contractInfo.RiskLimitStep, 64)
+	max, _ := strconv.ParseFloat(contractInfo.RiskLimitMax, 64)
+	if rate <= 0 {
+		return nil, nil
+	}
+	if base <= 0 || step <= 0 {
+		return []risk.MaintenanceTier{{Rate: rate}}, nil
+	}
+
+	var tiers []risk.MaintenanceTier
+	for n := 0; n < gateMaxRiskTiers; n++ {
+		limit := base + float64(n)*step
+		if max > 0 && limit >= max {
+			tiers = append(tiers, risk.MaintenanceTier{MaxValue: max, Rate: rate * float64(n+1)})
+			break
+		}
+		tiers = append(tiers, risk.MaintenanceTier{MaxValue: limit, Rate: rate * float64(n+1)})
+	}
+	return tiers, nil
+}
+
+// marginBufferPct 开仓前可用余额需额外覆盖的保证金比例（下单期间的价格变动、手续费率差异）
+const marginBufferPct = 5.0
+
+// RequiredMargin 按合约信息和当前价估算开仓所需保证金（USDT）：初始保证金 + 开仓手续费
+// quantity与OpenLong/OpenShort一致，按交易所下单精度取整后计算
+func (t *GateTrader) RequiredMargin(symbol string, quantity float64, leverage int) (float64, error) {
+	if leverage < 1 {
+		return 0, fmt.Errorf("%s 杠杆无效: %d", symbol, leverage)
+	}
+	contractInfo, err := t.getContractInfo(convertSymbolToGateContract(symbol))
+	if err != nil {
+		return 0, fmt.Errorf("获取合约信息失败: %w", classifyGateError(err))
+	}
+	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return 0, err
+	}
+	size, _ := strconv.ParseFloat(quantityStr, 64)
+	multiplier, _ := strconv.ParseFloat(contractInfo.QuantoMultiplier, 64)
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		return 0, err
+	}
+	takerFee, _ := strconv.ParseFloat(contractInfo.TakerFeeRate, 64)
+
+	value := size * multiplier * price
+	return value/float64(leverage) + value*takerFee, nil
+}
+
+// checkMargin 开仓前本地检查可用余额是否覆盖所需保证金（含缓冲），不足时返回ErrInsufficientMargin
+// 无法估算时不拦截，由交易所判断
+func (t *GateTrader) checkMargin(symbol string, quantity float64, leverage int) error {
+	required, err := t.RequiredMargin(symbol, quantity, leverage)
+	if err != nil {
+		gateLog.Warn("估算所需保证金失败，跳过本地检查", "symbol", symbol, "err", err)
+		return nil
+	}
+
+	// 同一周期内可能刚平过仓，使用最新余额
+	t.balanceCacheMutex.Lock()
+	t.balanceCacheTime = time.Time{}
+	t.balanceCacheMutex.Unlock()
+	balance, err := t.GetBalance()
+	if err != nil {
+		gateLog.Warn("获取可用余额失败，跳过本地保证金检查", "symbol", symbol, "err", err)
+		return nil
+	}
+	available, _ := balance["availableBalance"].(float64)
+	if withBuffer := required * (1 + marginBufferPct/100); available < withBuffer {
+		return fmt.Errorf("%w: %s 需要保证金%.2f USDT（含%.0f%%缓冲%.2f USDT），可用%.2f USDT",
+			ErrInsufficientMargin, symbol, required, marginBufferPct, withBuffer, available)
+	}
+	return nil
+}
+
+// SetMarginSettings 设置保证金模式和风险限额（下次设置杠杆时生效）
+func (t *GateTrader) SetMarginSettings(settings risk.MarginSettings) {
+	t.marginMutex.Lock()
+	defer t.marginMutex.Unlock()
+	t.margin = settings
+	t.riskLimitSet = make(map[string]float64)
+}
+
+// crossMargin 是否使用全仓模式
+func (t *GateTrader) crossMargin() bool {
+	t.marginMutex.Lock()
+	defer t.marginMutex.Unlock()
+	return t.margin.Cross()
+}
+
+// applyRiskLimit 合约配置了风险限额且尚未设置时调用交易所设置（调用方已在合约的执行队列中）
+// 配置值按档位表向上取整到所在档位的上限，档位表不可用时原样提交
+func (t *GateTrader) applyRiskLimit(symbol, contract string) error {
+	t.marginMutex.Lock()
+	limit := t.margin.RiskLimit(symbol)
+	applied := t.riskLimitSet[contract]
+	t.marginMutex.Unlock()
+	if limit <= 0 || applied == limit {
+		return nil
+	}
+
+	value := limit
+	if tiers, err := t.GetMaintenanceTiers(symbol); err == nil {
+		if tier, ok := risk.TierFor(tiers, limit); ok && tier.MaxValue > 0 {
+			value = tier.MaxValue
+		}
+	}
+	valueStr := strconv.FormatFloat(value, 'f', -1, 64)
+	if _, _, err := t.client.FuturesApi.UpdatePositionRiskLimit(t.ctx, t.settle, contract, valueStr); err != nil {
+		gateErr, ok := err.(gateapi.GateAPIError)
+		if !ok || !(strings.Contains(gateErr.Message, "No need to change") || strings.Contains(gateErr.Message, "already")) {
+			return fmt.Errorf("设置风险限额失败: %w", classifyGateError(err))
+		}
+	} else {
+		gateLog.Info("风险限额已设置", "symbol", symbol, "risk_limit", valueStr)
+	}
+
+	t.marginMutex.Lock()
+	t.riskLimitSet[contract] = limit
+	t.marginMutex.Unlock()
+	return nil
+}
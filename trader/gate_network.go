@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// gateBasePath Gate.io REST地址（测试网和主网的API密钥互不通用）
+func gateBasePath(testnet bool) string {
+	if testnet {
+		return "https://api-testnet.gateapi.io/api/v4" // Gate.io测试网API地址
+	}
+	return "https://api.gateio.ws/api/v4" // Gate.io主网API地址
+}
+
+// Testnet 是否连接测试网
+func (t *GateTrader) Testnet() bool {
+	return t.testnet
+}
+
+// CheckNetwork 确认API密钥属于配置的网络：配置的网络拒绝该密钥、另一个网络接受时返回ErrNetworkMismatch
+// （密钥在两个网络都无效时返回nil，由API密钥检查报告）
+func (t *GateTrader) CheckNetwork() error {
+	_, _, err := t.client.FuturesApi.ListFuturesAccounts(t.ctx, t.settle)
+	if !errors.Is(classifyGateError(err), ErrInvalidAPIKey) {
+		return nil
+	}
+
+	cfg := gateapi.NewConfiguration()
+	cfg.BasePath = gateBasePath(!t.testnet)
+	cfg.HTTPClient = &http.Client{Timeout: gateHTTPTimeout}
+	if _, _, err := gateapi.NewAPIClient(cfg).FuturesApi.ListFuturesAccounts(t.ctx, t.settle); err != nil {
+		return nil
+	}
+	if t.testnet {
+		return fmt.Errorf("%w: 密钥属于主网，但配置了gate_testnet=true", ErrNetworkMismatch)
+	}
+	return fmt.Errorf("%w: 密钥属于测试网，但配置了gate_testnet=false", ErrNetworkMismatch)
+}
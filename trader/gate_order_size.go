@@ -0,0 +1,218 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// gateBatchConfirmTimeout 拆分下单时等待单笔订单结束的最长时间
+const gateBatchConfirmTimeout = 10 * time.Second
+
+// splitOrderSize 按单笔上限把张数拆成多笔（maxSize<=0表示不限制；前几笔为上限，最后一笔为余数）
+func splitOrderSize(size, maxSize int64) []int64 {
+	if maxSize <= 0 || size <= maxSize {
+		return []int64{size}
+	}
+	batches := make([]int64, 0, (size+maxSize-1)/maxSize)
+	for size > 0 {
+		batch := maxSize
+		if size < batch {
+			batch = size
+		}
+		batches = append(batches, batch)
+		size -= batch
+	}
+	return batches
+}
+
+// positionRoom 当前风险限额下该方向还能增加的持仓张数（side为long/short）
+// 风险限额为仓位价值上限，超出时交易所拒绝开仓（RISK_LIMIT_EXCEEDED）；
+// 没有持仓时按配置的风险限额或合约的基础限额计算；查询失败或限额未知时ok为false，不限制
+func (t *GateTrader) positionRoom(symbol, side string) (room int64, ok bool) {
+	contract := convertSymbolToGateContract(symbol)
+	contractInfo, err := t.getContractInfo(contract)
+	if err != nil {
+		return 0, false
+	}
+	position, _, err := t.client.FuturesApi.GetPosition(t.ctx, t.settle, contract)
+	if err != nil {
+		if err = classifyGateError(err); !errors.Is(err, ErrPositionNotFound) {
+			gateLog.Warn("开仓前查询持仓失败，不检查风险限额", "symbol", symbol, "err", err)
+			return 0, false
+		}
+		position = gateapi.Position{}
+	}
+
+	riskLimit, _ := strconv.ParseFloat(position.RiskLimit, 64)
+	if riskLimit <= 0 {
+		t.marginMutex.Lock()
+		riskLimit = t.margin.RiskLimit(symbol)
+		t.marginMutex.Unlock()
+	}
+	if riskLimit <= 0 {
+		riskLimit, _ = strconv.ParseFloat(contractInfo.RiskLimitBase, 64)
+	}
+	multiplier, _ := strconv.ParseFloat(contractInfo.QuantoMultiplier, 64)
+	price, _ := strconv.ParseFloat(position.MarkPrice, 64)
+	if price <= 0 {
+		if price, err = t.GetMarketPrice(symbol); err != nil {
+			return 0, false
+		}
+	}
+	if riskLimit <= 0 || multiplier <= 0 || price <= 0 {
+		return 0, false
+	}
+
+	held := position.Size
+	if side == "short" {
+		held = -held
+	}
+	limit := int64(math.Floor(riskLimit / (price * multiplier)))
+	return max(limit-max(held, 0), 0), true
+}
+
+// openSize 开仓张数：按下单精度取整，超过风险限额允许的剩余持仓时收紧到剩余数量
+// 剩余数量不足最小下单数量时返回错误（不再把必然被拒绝的订单发到交易所）
+func (t *GateTrader) openSize(symbol, side string, quantity float64) (int64, error) {
+	size, err := t.contractSize(symbol, quantity)
+	if err != nil {
+		return 0, err
+	}
+	room, ok := t.positionRoom(symbol, side)
+	if !ok || size <= room {
+		return size, nil
+	}
+	minSize := int64(1)
+	if contractInfo, err := t.getContractInfo(convertSymbolToGateContract(symbol)); err == nil {
+		minSize = max(contractInfo.OrderSizeMin, 1)
+	}
+	if room < minSize {
+		return 0, fmt.Errorf("%s %s持仓已达到风险限额上限，无法再开仓（请求%d张）", symbol, side, size)
+	}
+	gateLog.Warn("开仓数量超过风险限额允许的持仓，按剩余数量下单", "symbol", symbol, "side", side,
+		"requested", size, "room", room)
+	return room, nil
+}
+
+// limitOrderSize 限价开仓张数（只挂单、追价和挂单等待成交的限价单不拆分）：在openSize的基础上收紧到合约单笔上限
+func (t *GateTrader) limitOrderSize(symbol, side string, quantity float64) (int64, error) {
+	size, err := t.openSize(symbol, side, quantity)
+	if err != nil {
+		return 0, err
+	}
+	return t.capOrderSize(symbol, size), nil
+}
+
+// capOrderSize 不拆分的单笔委托超出合约单笔上限时收紧到上限（交易所会直接拒绝超出上限的订单）
+func (t *GateTrader) capOrderSize(symbol string, size int64) int64 {
+	contractInfo, err := t.getContractInfo(convertSymbolToGateContract(symbol))
+	if err != nil || contractInfo.OrderSizeMax <= 0 || size <= contractInfo.OrderSizeMax {
+		return size
+	}
+	gateLog.Warn("下单数量超出合约单笔上限，按上限下单", "symbol", symbol, "requested", size,
+		"order_size_max", contractInfo.OrderSizeMax)
+	return contractInfo.OrderSizeMax
+}
+
+// placeMarketOrder 市价IOC下单（size带方向：正数买入、负数卖出），超过合约单笔上限时拆成多笔依次下单
+// 第一笔失败时返回错误；之后的某一笔失败时停止下单，返回已成交部分（未下单的张数计入剩余数量）
+// 拆分下单时结果总是附上全部批次的汇总成交（张数和加权均价），订单跟踪不必再轮询单个订单
+func (t *GateTrader) placeMarketOrder(symbol string, size int64, reduceOnly bool, action string) (map[string]interface{}, error) {
+	contract := convertSymbolToGateContract(symbol)
+	var maxSize int64
+	if contractInfo, err := t.getContractInfo(contract); err == nil {
+		maxSize = contractInfo.OrderSizeMax
+	}
+	sign := int64(1)
+	if size < 0 {
+		sign, size = -1, -size
+	}
+	batches := splitOrderSize(size, maxSize)
+	if len(batches) > 1 {
+		gateLog.Info("下单数量超过合约单笔上限，拆分下单", "symbol", symbol, "size", size,
+			"order_size_max", maxSize, "batches", len(batches))
+	}
+
+	var (
+		last          gateapi.FuturesOrder
+		filled, value float64
+		left          float64
+	)
+	for i, batch := range batches {
+		order := gateapi.FuturesOrder{
+			Contract:   contract,
+			Size:       sign * batch,
+			Price:      "0", // 市价单
+			Tif:        "ioc",
+			ReduceOnly: reduceOnly,
+			Text:       t.currentOrderText(contract),
+		}
+
+		start := time.Now()
+		orderResponse, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, order)
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("%s失败: %w", action, classifyGateError(err))
+			}
+			var unplaced int64
+			for _, rest := range batches[i:] {
+				unplaced += rest
+			}
+			gateLog.Warn("拆分下单中途失败，剩余数量未下单", "symbol", symbol, "batch", i+1,
+				"batches", len(batches), "unplaced", unplaced, "err", classifyGateError(err))
+			left += float64(unplaced)
+			break
+		}
+
+		gateLog.Info(action+"成功", "symbol", symbol, "size", batch, "order_id", orderResponse.Id,
+			"status", orderResponse.Status, "left", orderResponse.Left, "latency_ms", time.Since(start).Milliseconds())
+		if len(batches) > 1 && orderResponse.Status != "finished" {
+			orderResponse = t.awaitMarketBatch(symbol, orderResponse)
+		}
+		last = orderResponse
+		fill := gateOrderUpdate(orderResponse)
+		filled += fill.FilledQty
+		value += fill.FilledQty * fill.AvgPrice
+		left += math.Abs(float64(orderResponse.Left))
+	}
+
+	result := make(map[string]interface{})
+	result["orderId"] = last.Id
+	result["symbol"] = symbol
+	result["status"] = last.Status
+	if len(batches) == 1 {
+		return withIOCFill(result, last), nil
+	}
+	avgPrice := 0.0
+	if filled > 0 {
+		avgPrice = value / filled
+	}
+	return withFill(result, filled, avgPrice, left), nil
+}
+
+// awaitMarketBatch 拆分下单中下单响应尚未结束的一笔：轮询到订单结束（IOC通常很快结束），
+// 超时仍未结束时撤销剩余部分；查询失败时按最后一次得到的订单状态计入成交
+func (t *GateTrader) awaitMarketBatch(symbol string, order gateapi.FuturesOrder) gateapi.FuturesOrder {
+	orderID := strconv.FormatInt(order.Id, 10)
+	deadline := t.clock.Now().Add(gateBatchConfirmTimeout)
+	for order.Status == "open" && t.clock.Now().Before(deadline) {
+		t.clock.Sleep(gatePostOnlyInterval)
+		if current, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+			order = current
+		}
+	}
+	if order.Status == "open" {
+		if cancelled, _, err := t.client.FuturesApi.CancelFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+			order = cancelled
+		} else {
+			gateLog.Warn("拆分下单的一笔未能确认结束，按已知成交汇总", "symbol", symbol, "order_id", orderID,
+				"err", classifyGateError(err))
+		}
+	}
+	return order
+}
@@ -0,0 +1,65 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/risk"
+	"strconv"
+	"strings"
+)
+
+// SetPortfolioSettles 设置组合视图汇总的结算币种（如usdt和btc，BTC本位合约只统计不交易）
+func (t *GateTrader) SetPortfolioSettles(settles []string) {
+	t.portfolioSettles = settles
+}
+
+// SettleBooks 各结算币种的合约账本（权益、未实现盈亏和持仓以结算币种计价，附结算币种的美元指数价）
+func (t *GateTrader) SettleBooks() ([]risk.SettleBook, error) {
+	settles := t.portfolioSettles
+	if len(settles) == 0 {
+		settles = []string{t.settle}
+	}
+	books := make([]risk.SettleBook, 0, len(settles))
+	for _, settle := range settles {
+		book, err := t.settleBook(settle)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// settleBook 查询一个结算币种的合约账户和持仓
+func (t *GateTrader) settleBook(settle string) (risk.SettleBook, error) {
+	currency := strings.ToUpper(settle)
+	account, _, err := t.client.FuturesApi.ListFuturesAccounts(t.ctx, settle)
+	if err != nil {
+		return risk.SettleBook{}, fmt.Errorf("获取%s合约账户失败: %w", currency, classifyGateError(err))
+	}
+	positions, _, err := t.client.FuturesApi.ListPositions(t.ctx, settle)
+	if err != nil {
+		return risk.SettleBook{}, fmt.Errorf("获取%s合约持仓失败: %w", currency, classifyGateError(err))
+	}
+	price, err := t.indexPriceUSD(currency)
+	if err != nil {
+		return risk.SettleBook{}, fmt.Errorf("获取%s指数价失败: %w", currency, err)
+	}
+
+	book := risk.SettleBook{Settle: currency, IndexPrice: price, Positions: []risk.SettlePosition{}}
+	book.Equity, _ = strconv.ParseFloat(account.Total, 64)
+	book.UnrealizedPnL, _ = strconv.ParseFloat(account.UnrealisedPnl, 64)
+	for _, p := range positions {
+		if p.Size == 0 {
+			continue
+		}
+		pos := risk.SettlePosition{Contract: p.Contract, Asset: strings.SplitN(p.Contract, "_", 2)[0], Side: "long", Size: float64(p.Size)}
+		if p.Size < 0 {
+			pos.Side, pos.Size = "short", float64(-p.Size)
+		}
+		pos.MarkPrice, _ = strconv.ParseFloat(p.MarkPrice, 64)
+		pos.Value, _ = strconv.ParseFloat(p.Value, 64)
+		pos.UnrealizedPnL, _ = strconv.ParseFloat(p.UnrealisedPnl, 64)
+		book.Positions = append(book.Positions, pos)
+	}
+	return book, nil
+}
@@ -0,0 +1,40 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+)
+
+// reduceOnlySize 按交易所最新持仓校验只减仓订单（side为要平的持仓方向long/short，size为张数）：
+// 持仓不存在或方向相反时返回ErrPositionNotFound，数量超过持仓时截断为持仓数量。
+// 持仓缓存可能已过期（止损止盈已触发、部分强平、人工平仓），而Gate会拒绝超过持仓的只减仓单；
+// 查询失败时按原数量下单，由交易所校验
+func (t *GateTrader) reduceOnlySize(symbol, side string, size int64) (int64, error) {
+	contract := convertSymbolToGateContract(symbol)
+	position, _, err := t.client.FuturesApi.GetPosition(t.ctx, t.settle, contract)
+	if err != nil {
+		err = classifyGateError(err)
+		if errors.Is(err, ErrPositionNotFound) {
+			t.invalidatePositions()
+			return 0, fmt.Errorf("%s 没有%s持仓: %w", symbol, side, err)
+		}
+		gateLog.Warn("平仓前查询最新持仓失败，按原数量下单", "symbol", symbol, "err", err)
+		return size, nil
+	}
+
+	held := position.Size
+	if side == "short" {
+		held = -held
+	}
+	if held <= 0 {
+		t.invalidatePositions()
+		return 0, fmt.Errorf("%s 当前没有%s持仓（交易所持仓%d张）: %w", symbol, side, position.Size, ErrPositionNotFound)
+	}
+	if size > held {
+		gateLog.Info("平仓数量超过持仓，按持仓数量下单", "symbol", symbol, "side", side,
+			"requested", size, "held", held)
+		t.invalidatePositions()
+		return held, nil
+	}
+	return size, nil
+}
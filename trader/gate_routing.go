@@ -0,0 +1,362 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/antihax/optional"
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+const (
+	gateFeeCacheTTL      = time.Hour              // 费率缓存有效期（费率等级按日更新）
+	gatePostOnlyInterval = 500 * time.Millisecond // 只挂单成交状态轮询间隔
+)
+
+// gateFeeRate /futures/{settle}/fee返回的合约费率
+type gateFeeRate struct {
+	TakerFee string `json:"taker_fee"`
+	MakerFee string `json:"maker_fee"`
+}
+
+// GetFeeRates 账户当前的合约费率（按合约查询，未返回该合约时使用账户级合约费率）
+func (t *GateTrader) GetFeeRates(symbol string) (maker, taker float64, err error) {
+	contract := convertSymbolToGateContract(symbol)
+
+	t.feeRatesMutex.Lock()
+	defer t.feeRatesMutex.Unlock()
+	if t.feeRates == nil || t.clock.Now().Sub(t.feeRatesTime) > gateFeeCacheTTL {
+		var rates map[string]gateFeeRate
+		if err := t.gateGet(fmt.Sprintf("/futures/%s/fee", t.settle), &rates); err != nil {
+			gateLog.Warn("获取合约费率失败，使用账户级费率", "err", err)
+			rates = map[string]gateFeeRate{}
+		}
+		t.feeRates = rates
+		t.feeRatesTime = t.clock.Now()
+	}
+
+	rate, ok := t.feeRates[contract]
+	if !ok {
+		fee, _, err := t.client.WalletApi.GetTradeFee(t.ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("获取费率失败: %w", classifyGateError(err))
+		}
+		rate = gateFeeRate{TakerFee: fee.FuturesTakerFee, MakerFee: fee.FuturesMakerFee}
+	}
+	maker, _ = strconv.ParseFloat(rate.MakerFee, 64)
+	taker, _ = strconv.ParseFloat(rate.TakerFee, 64)
+	return maker, taker, nil
+}
+
+// GetBestQuote 买一/卖一价
+func (t *GateTrader) GetBestQuote(symbol string) (bid, ask float64, err error) {
+	contract := convertSymbolToGateContract(symbol)
+	book, _, err := t.client.FuturesApi.ListFuturesOrderBook(t.ctx, t.settle, contract, &gateapi.ListFuturesOrderBookOpts{
+		Limit: optional.NewInt32(1),
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取盘口失败: %w", classifyGateError(err))
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return 0, 0, fmt.Errorf("%s 盘口为空", symbol)
+	}
+	bid, _ = strconv.ParseFloat(book.Bids[0].P, 64)
+	ask, _ = strconv.ParseFloat(book.Asks[0].P, 64)
+	return bid, ask, nil
+}
+
+// OpenPostOnly 只挂单开仓：在买一（开多）/卖一（开空）挂post-only限价单，等待timeout后撤销未成交部分
+// 会立即成交而被拒绝、或超时完全未成交时返回ErrMakerNotFilled（调用方改为市价开仓）
+func (t *GateTrader) OpenPostOnly(symbol, side string, quantity float64, leverage int, timeout time.Duration) (map[string]interface{}, error) {
+	defer t.enterContract(symbol)()
+
+	if err := t.cancelAllOrders(symbol); err != nil {
+		gateLog.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
+	}
+	if err := t.setLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+	if err := t.checkMargin(symbol, quantity, leverage); err != nil {
+		return nil, err
+	}
+
+	contract := convertSymbolToGateContract(symbol)
+	// 收紧到风险限额允许的剩余持仓和合约单笔上限（限价委托不拆分）
+	size, err := t.limitOrderSize(symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	bid, ask, err := t.GetBestQuote(symbol)
+	if err != nil {
+		return nil, err
+	}
+	price := bid
+	if side == "short" {
+		price, size = ask, -size
+	}
+
+	start := time.Now()
+	order, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, gateapi.FuturesOrder{
+		Contract: contract,
+		Size:     size,
+		Price:    t.formatPrice(contract, price),
+		Tif:      "poc", // 只挂单：会立即成交时交易所直接撤单
+		Text:     t.currentOrderText(contract),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("只挂单开仓失败: %w", classifyGateError(err))
+	}
+	orderID := strconv.FormatInt(order.Id, 10)
+
+	// 等待成交，超时后撤销剩余部分
+	deadline := t.clock.Now().Add(timeout)
+	for order.Status == "open" && t.clock.Now().Before(deadline) {
+		t.clock.Sleep(gatePostOnlyInterval)
+		if current, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+			order = current
+		}
+	}
+	if order.Status == "open" {
+		if cancelled, _, err := t.client.FuturesApi.CancelFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+			order = cancelled
+		} else if current, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+			order = current // 撤单失败通常是撤单前已全部成交
+		}
+	}
+
+	filled := math.Abs(float64(order.Size)) - math.Abs(float64(order.Left))
+	if order.Status == "open" {
+		return nil, fmt.Errorf("只挂单 %s 撤单失败，请检查挂单", orderID)
+	}
+	if filled <= 0 {
+		return nil, fmt.Errorf("%w: %s %s 挂单价%s（%s）", ErrMakerNotFilled, symbol, side, order.Price, order.FinishAs)
+	}
+
+	gateLog.Info("只挂单开仓成交", "symbol", symbol, "side", side, "size", size, "filled", filled,
+		"price", order.Price, "order_id", orderID, "latency_ms", time.Since(start).Milliseconds())
+
+	result := make(map[string]interface{})
+	result["orderId"] = order.Id
+	result["symbol"] = symbol
+	result["status"] = order.Status
+	result["price"] = price
+	return result, nil
+}
+
+// OpenChase 追价限价开仓：在买一（开多）/卖一（开空）挂只挂单，每隔interval检查一次，
+// 买一/卖一移动后撤单按新价重挂剩余数量（最多maxReprices次），timeout后或重挂次数用完时剩余数量市价成交
+func (t *GateTrader) OpenChase(symbol, side string, quantity float64, leverage int, maxReprices int,
+	interval, timeout time.Duration) (map[string]interface{}, error) {
+	defer t.enterContract(symbol)()
+
+	if err := t.cancelAllOrders(symbol); err != nil {
+		gateLog.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
+	}
+	if err := t.setLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+	if err := t.checkMargin(symbol, quantity, leverage); err != nil {
+		return nil, err
+	}
+
+	contract := convertSymbolToGateContract(symbol)
+	// 收紧到风险限额允许的剩余持仓和合约单笔上限（限价委托不拆分）
+	size, err := t.limitOrderSize(symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+	sign := int64(1)
+	if side == "short" {
+		sign = -1
+	}
+
+	start := time.Now()
+	deadline := t.clock.Now().Add(timeout)
+	remaining := size
+	var filled, makerFilled, filledValue, firstPrice float64
+	var lastOrderID int64
+	reprices := 0
+
+	// 追价阶段：每轮在最新的买一/卖一挂剩余数量
+	for remaining > 0 && reprices <= maxReprices && t.clock.Now().Before(deadline) {
+		bid, ask, err := t.GetBestQuote(symbol)
+		if err != nil {
+			gateLog.Warn("追价获取盘口失败，剩余数量改为市价", "symbol", symbol, "err", err)
+			break
+		}
+		price := bid
+		if side == "short" {
+			price = ask
+		}
+		if firstPrice == 0 {
+			firstPrice = price
+		}
+
+		order, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, gateapi.FuturesOrder{
+			Contract: contract,
+			Size:     sign * remaining,
+			Price:    t.formatPrice(contract, price),
+			Tif:      "poc",
+			Text:     t.currentOrderText(contract),
+		})
+		if err = classifyGateError(err); errors.Is(err, ErrMakerNotFilled) {
+			reprices++ // 挂单前盘口已移动（会立即成交被拒绝），按新价重挂
+			continue
+		}
+		if err != nil {
+			if filled == 0 {
+				return nil, fmt.Errorf("追价挂单失败: %w", err)
+			}
+			gateLog.Warn("追价挂单失败，剩余数量改为市价", "symbol", symbol, "err", err)
+			break
+		}
+		lastOrderID = order.Id
+		orderID := strconv.FormatInt(order.Id, 10)
+
+		// 等待成交，盘口离开挂单价或超时后撤单
+		for order.Status == "open" {
+			pegDeadline := t.clock.Now().Add(interval)
+			for order.Status == "open" && t.clock.Now().Before(pegDeadline) && t.clock.Now().Before(deadline) {
+				t.clock.Sleep(gatePostOnlyInterval)
+				if current, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+					order = current
+				}
+			}
+			if order.Status != "open" || !t.clock.Now().Before(deadline) {
+				break
+			}
+			if bid, ask, err := t.GetBestQuote(symbol); err == nil && ((side == "long" && bid <= price) || (side == "short" && ask >= price)) {
+				continue // 仍在最优价，继续等待
+			}
+			break
+		}
+		if order.Status == "open" {
+			if cancelled, _, err := t.client.FuturesApi.CancelFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+				order = cancelled
+			} else if current, _, err := t.client.FuturesApi.GetFuturesOrder(t.ctx, t.settle, orderID); err == nil {
+				order = current
+			}
+			if order.Status == "open" {
+				return nil, fmt.Errorf("追价挂单 %s 撤单失败，请检查挂单", orderID)
+			}
+		}
+
+		orderFilled := math.Abs(float64(order.Size)) - math.Abs(float64(order.Left))
+		if orderFilled > 0 {
+			fillPrice, _ := strconv.ParseFloat(order.FillPrice, 64)
+			if fillPrice <= 0 {
+				fillPrice = price
+			}
+			filled += orderFilled
+			makerFilled += orderFilled
+			filledValue += orderFilled * fillPrice
+			remaining -= int64(orderFilled)
+		}
+		if remaining > 0 {
+			reprices++
+			gateLog.Debug("追价重挂", "symbol", symbol, "side", side, "price", price, "remaining", remaining, "reprices", reprices)
+		}
+	}
+
+	// 剩余数量市价成交
+	if remaining > 0 {
+		order, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, gateapi.FuturesOrder{
+			Contract: contract,
+			Size:     sign * remaining,
+			Price:    "0",
+			Tif:      "ioc",
+			Text:     t.currentOrderText(contract),
+		})
+		if err != nil {
+			if filled == 0 {
+				return nil, fmt.Errorf("追价超时后市价开仓失败: %w", classifyGateError(err))
+			}
+			gateLog.Warn("追价超时后市价开仓失败，保留已成交部分", "symbol", symbol, "filled", filled, "err", err)
+		} else {
+			lastOrderID = order.Id
+			orderFilled := math.Abs(float64(order.Size)) - math.Abs(float64(order.Left))
+			fillPrice, _ := strconv.ParseFloat(order.FillPrice, 64)
+			if orderFilled > 0 && fillPrice > 0 {
+				filled += orderFilled
+				filledValue += orderFilled * fillPrice
+				remaining -= int64(orderFilled)
+			}
+		}
+	}
+	if filled <= 0 {
+		return nil, fmt.Errorf("%s %s 追价开仓未成交", symbol, side)
+	}
+
+	avgPrice := filledValue / filled
+	gateLog.Info("追价开仓成交", "symbol", symbol, "side", side, "size", size, "filled", filled, "maker_filled", makerFilled,
+		"avg_price", avgPrice, "reprices", reprices, "latency_ms", time.Since(start).Milliseconds())
+
+	result := make(map[string]interface{})
+	result["orderId"] = lastOrderID
+	result["symbol"] = symbol
+	result["status"] = "finished"
+	result["price"] = firstPrice
+	result["filledQty"] = filled
+	result["avgPrice"] = avgPrice
+	result["makerQty"] = makerFilled
+	result["leftQty"] = float64(remaining)
+	return result, nil
+}
+
+// PlaceLimitOrder 按指定价格挂GTC限价开仓单，不等待成交（由限价单跟踪器轮询成交、超时撤单）
+// 不撤销该币种的其他委托单，同一币种可同时存在多笔限价单
+func (t *GateTrader) PlaceLimitOrder(symbol, side string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	defer t.enterContract(symbol)()
+
+	if err := t.setLeverage(symbol, leverage); err != nil {
+		return nil, err
+	}
+	if err := t.checkMargin(symbol, quantity, leverage); err != nil {
+		return nil, err
+	}
+
+	contract := convertSymbolToGateContract(symbol)
+	// 收紧到风险限额允许的剩余持仓和合约单笔上限（限价委托不拆分）
+	size, err := t.limitOrderSize(symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if side == "short" {
+		size = -size
+	}
+
+	order, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, gateapi.FuturesOrder{
+		Contract: contract,
+		Size:     size,
+		Price:    t.formatPrice(contract, price),
+		Tif:      "gtc",
+		Text:     t.currentOrderText(contract),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("限价开仓失败: %w", classifyGateError(err))
+	}
+	gateLog.Info("限价单已挂出", "symbol", symbol, "side", side, "size", size, "price", order.Price,
+		"order_id", order.Id, "status", order.Status)
+
+	result := make(map[string]interface{})
+	result["orderId"] = order.Id
+	result["symbol"] = symbol
+	result["status"] = order.Status
+	result["price"] = price
+	return result, nil
+}
+
+// CancelOrder 撤销单个普通委托单（已成交或已撤销时返回错误）
+func (t *GateTrader) CancelOrder(symbol, orderID string) error {
+	defer t.enterContract(symbol)()
+
+	if _, _, err := t.client.FuturesApi.CancelFuturesOrder(t.ctx, t.settle, orderID); err != nil {
+		return fmt.Errorf("撤单失败: %w", classifyGateError(err))
+	}
+	gateLog.Debug("已撤销委托单", "symbol", symbol, "order_id", orderID)
+	return nil
+}
@@ -0,0 +1,58 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GetAccountSnapshot 查询账户快照：余额、持仓、挂单和条件单并发查询（余额和持仓缓存失效后重新获取，同时刷新缓存），
+// 任一项失败时返回错误
+func (t *GateTrader) GetAccountSnapshot() (AccountSnapshot, error) {
+	t.balanceCacheMutex.Lock()
+	t.balanceCacheTime = time.Time{}
+	t.balanceCacheMutex.Unlock()
+	t.invalidatePositions()
+
+	snapshot := AccountSnapshot{FetchedAt: t.clock.Now()}
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	fetch := func(i int, what string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				errs[i] = fmt.Errorf("获取%s失败: %w", what, err)
+			}
+		}()
+	}
+	fetch(0, "账户余额", func() (err error) {
+		snapshot.Balance, err = t.GetBalance()
+		return err
+	})
+	fetch(1, "持仓", func() (err error) {
+		snapshot.Positions, err = t.GetPositions()
+		return err
+	})
+	fetch(2, "挂单", func() (err error) {
+		snapshot.OpenOrders, err = t.GetOpenOrders("")
+		return err
+	})
+	fetch(3, "条件单", func() (err error) {
+		snapshot.TriggerOrders, err = t.GetOpenTriggerOrders()
+		return err
+	})
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return AccountSnapshot{}, err
+		}
+	}
+
+	snapshot.Latency = time.Since(start)
+	snapshot.summarize()
+	gateLog.Debug("账户快照已获取", "positions", len(snapshot.Positions), "open_orders", len(snapshot.OpenOrders),
+		"trigger_orders", len(snapshot.TriggerOrders), "latency_ms", snapshot.Latency.Milliseconds())
+	return snapshot, nil
+}
@@ -0,0 +1,199 @@
+package trader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antihax/optional"
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// convertSymbolToGateSpotPair 将标准symbol转换为Gate.io现货交易对格式
+// 例如: "BTCUSDT" -> "BTC_USDT"（现货与永续合约命名一致）
+func convertSymbolToGateSpotPair(symbol string) string {
+	return convertSymbolToGateContract(symbol)
+}
+
+// GetSpotPrice 获取现货最新价格
+func (t *GateTrader) GetSpotPrice(symbol string) (float64, error) {
+	pair := convertSymbolToGateSpotPair(symbol)
+
+	tickers, _, err := t.client.SpotApi.ListTickers(t.ctx, &gateapi.ListTickersOpts{
+		CurrencyPair: optional.NewString(pair),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取现货价格失败: %w", classifyGateError(err))
+	}
+	if len(tickers) == 0 {
+		return 0, fmt.Errorf("未找到 %s 的现货价格", symbol)
+	}
+
+	price, err := strconv.ParseFloat(tickers[0].Last, 64)
+	if err != nil {
+		return 0, fmt.Errorf("现货价格格式错误: %w", err)
+	}
+	return price, nil
+}
+
+// GetSpotBalance 获取现货账户指定币种的可用余额
+func (t *GateTrader) GetSpotBalance(currency string) (float64, error) {
+	currency = strings.ToUpper(currency)
+
+	accounts, _, err := t.client.SpotApi.ListSpotAccounts(t.ctx, &gateapi.ListSpotAccountsOpts{
+		Currency: optional.NewString(currency),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取现货余额失败: %w", classifyGateError(err))
+	}
+
+	for _, account := range accounts {
+		if strings.ToUpper(account.Currency) == currency {
+			available, _ := strconv.ParseFloat(account.Available, 64)
+			return available, nil
+		}
+	}
+	return 0, nil
+}
+
+// SpotBuy 现货市价买入（quoteAmount为花费的USDT金额），返回实际成交的基础币数量
+func (t *GateTrader) SpotBuy(symbol string, quoteAmount float64) (float64, error) {
+	pair := convertSymbolToGateSpotPair(symbol)
+
+	// Gate.io现货市价买单的amount为计价货币（USDT）金额
+	order := gateapi.Order{
+		CurrencyPair: pair,
+		Type:         "market",
+		Side:         "buy",
+		Amount:       strconv.FormatFloat(quoteAmount, 'f', 2, 64),
+		TimeInForce:  "ioc",
+	}
+
+	orderResponse, _, err := t.client.SpotApi.CreateOrder(t.ctx, order)
+	if err != nil {
+		return 0, fmt.Errorf("现货买入失败: %w", classifyGateError(err))
+	}
+
+	filledTotal, _ := strconv.ParseFloat(orderResponse.FilledTotal, 64)
+	filledQuantity, fee, err := t.spotBuyFill(symbol, orderResponse)
+	if err != nil {
+		return 0, err
+	}
+
+	gateLog.Info("现货买入成功", "symbol", symbol, "cost_usdt", filledTotal,
+		"quantity", filledQuantity, "base_fee", fee)
+	return filledQuantity, nil
+}
+
+// spotBuyFill 现货买单实际到账的基础币数量（成交数量扣除以基础币收取的手续费）和扣除的手续费
+// 按订单的成交明细计算；查询失败时按成交总额 / 当前价估算，并扣除订单返回的基础币手续费
+// （订单的fill_price已废弃，与filled_total同为成交总额，不能用作成交均价）
+func (t *GateTrader) spotBuyFill(symbol string, order gateapi.Order) (quantity, fee float64, err error) {
+	base := strings.SplitN(order.CurrencyPair, "_", 2)[0]
+	trades, _, err := t.client.SpotApi.ListMyTrades(t.ctx, order.CurrencyPair, &gateapi.ListMyTradesOpts{
+		OrderId: optional.NewString(order.Id),
+	})
+	if err == nil && len(trades) > 0 {
+		for _, trade := range trades {
+			amount, _ := strconv.ParseFloat(trade.Amount, 64)
+			quantity += amount
+			if strings.EqualFold(trade.FeeCurrency, base) {
+				tradeFee, _ := strconv.ParseFloat(trade.Fee, 64)
+				fee += tradeFee
+			}
+		}
+		return quantity - fee, fee, nil
+	}
+	if err != nil {
+		gateLog.Warn("查询现货成交明细失败，按成交总额估算数量", "symbol", symbol, "order_id", order.Id, "err", classifyGateError(err))
+	}
+
+	filledTotal, _ := strconv.ParseFloat(order.FilledTotal, 64)
+	price, err := t.GetSpotPrice(symbol)
+	if err != nil {
+		return 0, 0, fmt.Errorf("现货已买入，但无法确认成交数量: %w", err)
+	}
+	quantity = filledTotal / price
+	if strings.EqualFold(order.FeeCurrency, base) {
+		fee, _ = strconv.ParseFloat(order.Fee, 64)
+	}
+	return quantity - fee, fee, nil
+}
+
+// SpotSell 现货市价卖出（quantity为基础币数量），可用余额不足时（如买入时扣除了手续费）按可用余额卖出
+func (t *GateTrader) SpotSell(symbol string, quantity float64) error {
+	pair := convertSymbolToGateSpotPair(symbol)
+	base := strings.SplitN(pair, "_", 2)[0]
+	if available, err := t.GetSpotBalance(base); err != nil {
+		gateLog.Warn("查询现货余额失败，按请求数量卖出", "symbol", symbol, "err", err)
+	} else if available > 0 && available < quantity {
+		gateLog.Warn("现货可用余额少于卖出数量，按可用余额卖出", "symbol", symbol, "quantity", quantity, "available", available)
+		quantity = available
+	}
+
+	order := gateapi.Order{
+		CurrencyPair: pair,
+		Type:         "market",
+		Side:         "sell",
+		Amount:       strconv.FormatFloat(quantity, 'f', -1, 64),
+		TimeInForce:  "ioc",
+	}
+
+	_, _, err := t.client.SpotApi.CreateOrder(t.ctx, order)
+	if err != nil {
+		return fmt.Errorf("现货卖出失败: %w", classifyGateError(err))
+	}
+
+	gateLog.Info("现货卖出成功", "symbol", symbol, "quantity", quantity)
+	return nil
+}
+
+// GetContractMultiplier 获取合约乘数（每张合约对应的基础币数量）
+func (t *GateTrader) GetContractMultiplier(symbol string) (float64, error) {
+	contract := convertSymbolToGateContract(symbol)
+
+	contractInfo, err := t.getContractInfo(contract)
+	if err != nil {
+		return 0, fmt.Errorf("获取合约信息失败: %w", classifyGateError(err))
+	}
+
+	multiplier, err := strconv.ParseFloat(contractInfo.QuantoMultiplier, 64)
+	if err != nil || multiplier <= 0 {
+		return 0, fmt.Errorf("合约 %s 乘数无效: %s", contract, contractInfo.QuantoMultiplier)
+	}
+	return multiplier, nil
+}
+
+// Transfer 现货账户与合约账户之间划转资金（from/to: spot/futures）
+func (t *GateTrader) Transfer(from, to, currency string, amount float64) error {
+	accounts := map[string]bool{"spot": true, "futures": true}
+	if !accounts[from] || !accounts[to] || from == to {
+		return fmt.Errorf("不支持的划转方向: %s -> %s（可选spot/futures）", from, to)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("划转金额必须大于0: %v", amount)
+	}
+
+	transfer := gateapi.Transfer{
+		Currency: strings.ToUpper(currency),
+		From:     from,
+		To:       to,
+		Amount:   strconv.FormatFloat(amount, 'f', -1, 64),
+	}
+	if from == "futures" || to == "futures" {
+		transfer.Settle = t.settle
+	}
+
+	if _, err := t.client.WalletApi.Transfer(t.ctx, transfer); err != nil {
+		return fmt.Errorf("划转失败: %w", classifyGateError(err))
+	}
+
+	// 合约账户余额已变化
+	t.balanceCacheMutex.Lock()
+	t.balanceCacheTime = time.Time{}
+	t.balanceCacheMutex.Unlock()
+
+	gateLog.Info("划转成功", "from", from, "to", to, "currency", transfer.Currency, "amount", amount)
+	return nil
+}
@@ -0,0 +1,352 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	gateWSPingInterval = 15 * time.Second // 应用层心跳间隔（futures.ping）
+	gateWSReadTimeout  = 60 * time.Second // 超过该时间未收到任何消息视为断线
+	gateWSMaxBackoff   = time.Minute      // 重连退避上限
+)
+
+// gateStream Gate.io合约WebSocket推送（私有频道需要用原始API密钥签名，一个连接对应一个频道）
+type gateStream struct {
+	apiKey    string
+	secretKey string
+	url       string
+
+	clockOffset *gateClockOffset // 私有频道签名时间戳的校正量（可为nil）
+
+	streamStatus
+}
+
+// newGateStream 创建WebSocket推送（主网/测试网地址与REST接口对应）
+func newGateStream(apiKey, secretKey string, testnet bool) *gateStream {
+	url := "wss://fx-ws.gateio.ws/v4/ws/usdt"
+	if testnet {
+		url = "wss://fx-ws-testnet.gateio.ws/v4/ws/usdt"
+	}
+	return &gateStream{apiKey: apiKey, secretKey: secretKey, url: url}
+}
+
+// gateWSRequest WebSocket请求
+type gateWSRequest struct {
+	Time    int64       `json:"time"`
+	Channel string      `json:"channel"`
+	Event   string      `json:"event,omitempty"`
+	Payload []string    `json:"payload,omitempty"`
+	Auth    *gateWSAuth `json:"auth,omitempty"`
+}
+
+// gateWSAuth 私有频道鉴权
+type gateWSAuth struct {
+	Method string `json:"method"`
+	Key    string `json:"KEY"`
+	Sign   string `json:"SIGN"`
+}
+
+// gateWSMessage WebSocket推送/响应
+type gateWSMessage struct {
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"`
+	Error   *gateWSError    `json:"error"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type gateWSError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// gateSubscription 一个频道的订阅（每个payload发送一次订阅请求）
+type gateSubscription struct {
+	channel      string
+	payloads     [][]string
+	private      bool   // 私有频道需要签名
+	name         string // 日志中的推送名称
+	onSubscribed func() // 每次订阅成功后调用（可为nil）
+	onUpdate     func(result json.RawMessage)
+}
+
+// gatePositionPush futures.positions频道推送的持仓
+type gatePositionPush struct {
+	Contract string `json:"contract"`
+	Size     int64  `json:"size"`
+	Mode     string `json:"mode"` // single/dual_long/dual_short
+}
+
+// update 转换为持仓变化（单向持仓模式下数量为0时方向为空）
+func (p gatePositionPush) update() PositionUpdate {
+	update := PositionUpdate{Symbol: convertGateContractToSymbol(p.Contract), Quantity: math.Abs(float64(p.Size))}
+	switch {
+	case p.Mode == "dual_long":
+		update.Side = "long"
+	case p.Mode == "dual_short":
+		update.Side = "short"
+	case p.Size > 0:
+		update.Side = "long"
+	case p.Size < 0:
+		update.Side = "short"
+	}
+	return update
+}
+
+// sign 私有频道签名: hex(HMAC-SHA512(secret, "channel=<channel>&event=<event>&time=<time>"))
+func (s *gateStream) sign(channel, event string, ts int64) string {
+	mac := hmac.New(sha512.New, []byte(s.secretKey))
+	fmt.Fprintf(mac, "channel=%s&event=%s&time=%d", channel, event, ts)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// run 保持连接：断线后指数退避重连，每次订阅成功后调用onSubscribed（用于补发断线期间的变化）
+func (s *gateStream) run(stop <-chan struct{}, sub gateSubscription) {
+	backoff := time.Second
+	for {
+		subscribed, err := s.serve(stop, sub)
+		s.setConnected(false)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if subscribed {
+			backoff = time.Second
+		}
+		gateLog.Warn("WebSocket推送断开，准备重连", "stream", sub.name, "err", err, "retry_in", backoff)
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > gateWSMaxBackoff {
+			backoff = gateWSMaxBackoff
+		}
+	}
+}
+
+// serve 建立一次连接并订阅频道，直到断线或stop关闭，返回是否订阅成功过
+func (s *gateStream) serve(stop <-chan struct{}, sub gateSubscription) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	channel := sub.channel
+	for _, payload := range sub.payloads {
+		now := s.clockOffset.now().Unix()
+		request := gateWSRequest{Time: now, Channel: channel, Event: "subscribe", Payload: payload}
+		if sub.private {
+			request.Auth = &gateWSAuth{Method: "api_key", Key: s.apiKey, Sign: s.sign(channel, "subscribe", now)}
+		}
+		if err := conn.WriteJSON(request); err != nil {
+			return false, fmt.Errorf("发送订阅请求失败: %w", err)
+		}
+	}
+
+	// 心跳；stop关闭时断开连接以结束读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(gateWSPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-stop:
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := conn.WriteJSON(gateWSRequest{Time: time.Now().Unix(), Channel: "futures.ping"}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	subscribed := false
+	for {
+		conn.SetReadDeadline(time.Now().Add(gateWSReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return subscribed, err
+		}
+		s.touch()
+
+		var msg gateWSMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Channel != channel {
+			continue // futures.pong等
+		}
+		if msg.Error != nil {
+			return subscribed, fmt.Errorf("订阅%s失败(%d): %s", channel, msg.Error.Code, msg.Error.Message)
+		}
+		switch msg.Event {
+		case "subscribe":
+			subscribed = true
+			s.setConnected(true)
+			gateLog.Info("WebSocket推送已订阅", "stream", sub.name)
+			if sub.onSubscribed != nil {
+				sub.onSubscribed()
+			}
+		case "update":
+			sub.onUpdate(msg.Result)
+		}
+	}
+}
+
+// StartPositionStream 订阅持仓推送（futures.positions），推送到达时同时使持仓缓存失效
+func (t *GateTrader) StartPositionStream(stop <-chan struct{}, handler func(PositionUpdate)) error {
+	// 私有频道需要用户ID，从手续费接口获取（任何已鉴权账户都可访问）
+	fee, _, err := t.client.WalletApi.GetTradeFee(t.ctx)
+	if err != nil {
+		return fmt.Errorf("获取用户ID失败: %w", classifyGateError(err))
+	}
+
+	// 订阅成功后按最新持仓补发一次，覆盖断线期间错过的变化
+	resync := func() {
+		t.invalidatePositions()
+		positions, err := t.GetPositions()
+		if err != nil {
+			gateLog.Warn("重连后获取持仓失败", "err", err)
+			return
+		}
+		for _, pos := range positions {
+			symbol, _ := pos["symbol"].(string)
+			side, _ := pos["side"].(string)
+			handler(PositionUpdate{Symbol: symbol, Side: side, Quantity: floatValue(pos["positionAmt"])})
+		}
+	}
+	go t.stream.run(stop, gateSubscription{
+		channel:      "futures.positions",
+		payloads:     [][]string{{strconv.FormatInt(fee.UserId, 10), "!all"}},
+		private:      true,
+		name:         "positions",
+		onSubscribed: resync,
+		onUpdate: func(result json.RawMessage) {
+			var positions []gatePositionPush
+			if err := json.Unmarshal(result, &positions); err != nil {
+				gateLog.Warn("解析持仓推送失败", "err", err)
+				return
+			}
+			for _, position := range positions {
+				t.invalidatePositions()
+				handler(position.update())
+			}
+		},
+	})
+	return nil
+}
+
+// StreamStatus WebSocket推送连接状态
+func (t *GateTrader) StreamStatus() (bool, time.Time) {
+	return t.stream.status()
+}
+
+// InvalidatePositions 使持仓缓存失效，下次查询读取交易所最新持仓
+func (t *GateTrader) InvalidatePositions() {
+	t.invalidatePositions()
+}
+
+// invalidatePositions 使持仓缓存失效（持仓已变化）
+func (t *GateTrader) invalidatePositions() {
+	t.positionsCacheMutex.Lock()
+	t.positionsCacheTime = time.Time{}
+	t.positionsCacheMutex.Unlock()
+}
+
+// gateCandlePush futures.candlesticks频道推送的K线（n为 "<周期>_<合约>"，w表示该K线已收盘）
+type gateCandlePush struct {
+	Time   int64   `json:"t"`
+	Volume float64 `json:"v"`
+	Close  string  `json:"c"`
+	High   string  `json:"h"`
+	Low    string  `json:"l"`
+	Open   string  `json:"o"`
+	Name   string  `json:"n"`
+	Closed bool    `json:"w"`
+}
+
+// bar 转换为K线（symbol为标准格式）
+func (p gateCandlePush) bar() (BarClose, error) {
+	interval, contract, ok := strings.Cut(p.Name, "_")
+	if !ok {
+		return BarClose{}, fmt.Errorf("无法解析K线名称: %s", p.Name)
+	}
+	duration, err := BarDuration(interval)
+	if err != nil {
+		return BarClose{}, err
+	}
+	bar := BarClose{
+		Symbol:    convertGateContractToSymbol(contract),
+		Interval:  interval,
+		OpenTime:  time.Unix(p.Time, 0),
+		CloseTime: time.Unix(p.Time, 0).Add(duration),
+		Volume:    p.Volume,
+	}
+	for _, field := range []struct {
+		raw string
+		dst *float64
+	}{{p.Open, &bar.Open}, {p.High, &bar.High}, {p.Low, &bar.Low}, {p.Close, &bar.Close}} {
+		if *field.dst, err = strconv.ParseFloat(field.raw, 64); err != nil {
+			return BarClose{}, fmt.Errorf("K线价格无效(%s): %w", p.Name, err)
+		}
+	}
+	return bar, nil
+}
+
+// StartKlineStream 订阅K线推送（futures.candlesticks，公共频道），每根K线收盘时回调
+func (t *GateTrader) StartKlineStream(stop <-chan struct{}, symbols []string, interval string, handler func(BarClose)) error {
+	if _, err := BarDuration(interval); err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("K线推送至少需要一个币种")
+	}
+	payloads := make([][]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		payloads = append(payloads, []string{interval, convertSymbolToGateContract(symbol)})
+	}
+
+	tracker := newBarTracker()
+	go t.klineStream.run(stop, gateSubscription{
+		channel:  "futures.candlesticks",
+		payloads: payloads,
+		name:     "candlesticks_" + interval,
+		onUpdate: func(result json.RawMessage) {
+			var candles []gateCandlePush
+			if err := json.Unmarshal(result, &candles); err != nil {
+				gateLog.Warn("解析K线推送失败", "err", err)
+				return
+			}
+			for _, candle := range candles {
+				bar, err := candle.bar()
+				if err != nil {
+					gateLog.Warn("解析K线推送失败", "err", err)
+					continue
+				}
+				for _, closed := range tracker.observe(candle.Name, bar, candle.Closed) {
+					handler(closed)
+				}
+			}
+		},
+	})
+	return nil
+}
+
+// KlineStreamStatus K线推送连接状态
+func (t *GateTrader) KlineStreamStatus() (bool, time.Time) {
+	return t.klineStream.status()
+}
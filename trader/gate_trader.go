@@ -2,19 +2,37 @@ package trader
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math"
+	"net/http"
+	"nofx/apiusage"
+	"nofx/clock"
+	"nofx/logging"
+	"nofx/decision"
+	"nofx/risk"
+	"nofx/symbols"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/antihax/optional"
 	gateapi "github.com/gateio/gateapi-go/v6"
 )
 
+// gateLog Gate.io交易器日志
+var gateLog = logging.For("gate")
+
+// gateHTTPTimeout REST请求超时（超时的下单按客户端订单ID确认是否已提交后再重试）
+const gateHTTPTimeout = 15 * time.Second
+
 // GateTrader Gate.io交易器
+//
+// 并发安全：多个策略可以共用同一个交易器。同一合约的下单流程（开平仓、切换杠杆、撤单、止盈止损、
+// 只挂单/追价/限价开仓）进入该合约的执行队列，按到达顺序逐个执行，开仓时的撤单 → 切换杠杆 → 下单
+// 不会与其他调用交错；不同合约的队列互不阻塞。余额/持仓缓存返回副本，调用方可以修改。Set*配置方法应在开始交易前调用
 type GateTrader struct {
 	client      *gateapi.APIClient
 	ctx         context.Context
@@ -34,6 +52,68 @@ type GateTrader struct {
 	// 合约信息缓存（用于获取精度）
 	contractCache     map[string]*gateapi.Contract
 	contractCacheMutex sync.RWMutex
+
+	// 风险限额档位缓存（contract -> 档位，与合约信息缓存共用contractCacheMutex）
+	riskTiers map[string][]risk.MaintenanceTier
+
+	// 止损限价偏移（%），0表示触发后市价成交
+	stopLimitOffsetPct float64
+	// 条件单默认有效期（0表示30天）
+	triggerExpiration time.Duration
+	// 条件单默认触发价格类型（为空表示标记价格）
+	triggerPriceType string
+	// 下单数量取整策略（零值为四舍五入、低于最小数量时按最小数量下单）
+	rounding risk.QuantityRounding
+
+	// WebSocket持仓推送（StartPositionStream启动后才连接）
+	stream *gateStream
+	// WebSocket K线推送（StartKlineStream启动后才连接，公共频道）
+	klineStream *gateStream
+
+	// 账户模式（classic或统一账户模式，首次查询余额时检测）
+	unifiedMode     string
+	accountModeOnce sync.Once
+
+	// 统一账户抵押折算率档位（币种明细估值用）
+	discounts gateDiscountCache
+
+	// API密钥无钱包读取权限时不再查询提现/充值记录（资金流水监控只看合约账户划转）
+	walletDenied atomic.Bool
+
+	// 保证金模式和风险限额（SetMarginSettings设置；riskLimitSet记录已设置的风险限额，contract -> 限额）
+	margin       risk.MarginSettings
+	riskLimitSet map[string]float64
+	marginMutex  sync.Mutex
+
+	// 组合视图汇总的结算币种（SetPortfolioSettles设置，为空时只统计交易使用的结算币种）
+	portfolioSettles []string
+
+	// 合约费率缓存（contract -> 费率，用于选择只挂单/市价开仓）
+	feeRates      map[string]gateFeeRate
+	feeRatesTime  time.Time
+	feeRatesMutex sync.Mutex
+
+	// 切换杠杆后等待交易所冷却期的时间（默认3秒）
+	leverageCooldown time.Duration
+
+	// 是否连接测试网（自检时检查密钥是否属于另一个网络）
+	testnet bool
+
+	// 时钟（缓存过期、冷却等待、只挂单轮询，回测和测试可替换为模拟时钟）
+	clock clock.Clock
+
+	// 签名时间戳校正量（本地时钟偏差较大时由时间同步监控设置）
+	clockOffset *gateClockOffset
+
+	// REST请求用量（按Gate频率限制分组，用于指标和汇总报告）
+	usage *apiusage.Tracker
+
+	// 订单自定义文本（策略和决策ID，下单前由订单跟踪器设置）
+	orderTexts     map[string]string // contract -> 文本
+	orderTextMutex sync.Mutex
+
+	// 按合约的执行队列（同一合约的下单流程按到达顺序逐个执行）
+	queue symbolQueue
 }
 
 // NewGateTrader 创建Gate交易器
@@ -53,11 +133,11 @@ func NewGateTrader(apiKey, secretKey string, testnet bool) (*GateTrader, error)
 	cfg := gateapi.NewConfiguration()
 	
 	// 根据testnet选择API地址
-	if testnet {
-		cfg.BasePath = "https://api-testnet.gateapi.io/api/v4" // Gate.io测试网API地址
-	} else {
-		cfg.BasePath = "https://api.gateio.ws/api/v4" // Gate.io主网API地址
-	}
+	cfg.BasePath = gateBasePath(testnet)
+	clockOffset := &gateClockOffset{}
+	usage := apiusage.NewTracker()
+	cfg.HTTPClient = &http.Client{Timeout: gateHTTPTimeout,
+		Transport: apiusage.NewTransport(&gateSigningTransport{secret: secretKey, offset: clockOffset}, usage)}
 	
 	client := gateapi.NewAPIClient(cfg)
 
@@ -70,14 +150,102 @@ func NewGateTrader(apiKey, secretKey string, testnet bool) (*GateTrader, error)
 		client:         client,
 		ctx:            ctx,
 		settle:         "usdt",
+		testnet:        testnet,
 		cacheDuration:  15 * time.Second,
 		contractCache:  make(map[string]*gateapi.Contract),
+		clockOffset:    clockOffset,
+		usage:          usage,
 	}
+	trader.stream = newGateStream(apiKey, secretKey, testnet)
+	trader.stream.clockOffset = clockOffset
+	trader.klineStream = newGateStream("", "", testnet)
+	trader.leverageCooldown = 3 * time.Second
+	trader.clock = clock.Real
 
-	log.Printf("✓ Gate.io交易器初始化成功 (testnet=%v, API Key前8位: %s...)", testnet, apiKey[:min(8, len(apiKey))])
+	gateLog.Info("Gate.io交易器初始化成功", "testnet", testnet, "api_key_prefix", apiKey[:min(8, len(apiKey))])
 	return trader, nil
 }
 
+// SetCacheDuration 设置余额/持仓缓存有效期
+func (t *GateTrader) SetCacheDuration(d time.Duration) {
+	t.cacheDuration = d
+}
+
+// SetStopLimitOffset 设置止损限价偏移（%），0表示触发后市价成交
+func (t *GateTrader) SetStopLimitOffset(pct float64) {
+	t.stopLimitOffsetPct = pct
+}
+
+// SetQuantityRounding 设置下单数量取整策略
+func (t *GateTrader) SetQuantityRounding(rounding risk.QuantityRounding) {
+	t.rounding = rounding
+}
+
+// roundQuantity 按取整策略把数量取整到整数张（Gate.io合约数量为整数张），
+// 取整后低于最小下单数量时按策略提高到最小数量（记录警告）或返回ErrOrderTooSmall
+func (t *GateTrader) roundQuantity(symbol string, quantity, orderSizeMin float64) (float64, error) {
+	orderSizeMin = math.Max(orderSizeMin, 1)
+	rounded := t.rounding.Round(quantity, 1)
+	if rounded >= orderSizeMin {
+		return rounded, nil
+	}
+	if t.rounding.Reject() {
+		return 0, fmt.Errorf("%s 下单数量 %g 取整后低于最小下单数量 %.0f: %w", symbol, quantity, orderSizeMin, ErrOrderTooSmall)
+	}
+	gateLog.Warn("下单数量低于最小下单数量，按最小数量下单", "symbol", symbol, "quantity", quantity, "min", orderSizeMin, "multiple", orderSizeMin/quantity)
+	return orderSizeMin, nil
+}
+
+// gateDefaultTriggerExpiration 条件单默认有效期
+const gateDefaultTriggerExpiration = 30 * 24 * time.Hour
+
+// SetTriggerExpiration 设置条件单默认有效期（0表示30天）
+func (t *GateTrader) SetTriggerExpiration(ttl time.Duration) {
+	t.triggerExpiration = ttl
+}
+
+// triggerExpirationSeconds 条件单有效期（秒），ttl为0时使用默认有效期
+func (t *GateTrader) triggerExpirationSeconds(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		ttl = t.triggerExpiration
+	}
+	if ttl <= 0 {
+		ttl = gateDefaultTriggerExpiration
+	}
+	return int32(ttl / time.Second)
+}
+
+// SetTriggerPriceType 设置条件单默认触发价格类型（mark/last/index，为空表示标记价格）
+func (t *GateTrader) SetTriggerPriceType(priceType string) {
+	t.triggerPriceType = priceType
+}
+
+// gatePriceType 条件单的price_type，priceType为空时使用默认触发价格类型
+func (t *GateTrader) gatePriceType(priceType string) int32 {
+	if priceType == "" {
+		priceType = t.triggerPriceType
+	}
+	if v, ok := gatePriceTypes[priceType]; ok {
+		return v
+	}
+	return gatePriceTypes[decision.TriggerPriceMark]
+}
+
+// SetBaseURL 设置REST接口地址（如gatetest测试服务器的地址）
+func (t *GateTrader) SetBaseURL(basePath string) {
+	t.client.GetConfig().BasePath = basePath
+}
+
+// SetLeverageCooldown 设置切换杠杆后的等待时间（默认3秒，测试时可设为0）
+func (t *GateTrader) SetLeverageCooldown(d time.Duration) {
+	t.leverageCooldown = d
+}
+
+// SetClock 设置时钟（nil表示系统时钟）
+func (t *GateTrader) SetClock(c clock.Clock) {
+	t.clock = clock.Or(c)
+}
+
 // min 辅助函数
 func min(a, b int) int {
 	if a < b {
@@ -90,28 +258,29 @@ func min(a, b int) int {
 func (t *GateTrader) GetBalance() (map[string]interface{}, error) {
 	// 先检查缓存是否有效
 	t.balanceCacheMutex.RLock()
-	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
-		cacheAge := time.Since(t.balanceCacheTime)
+	if t.cachedBalance != nil && t.clock.Now().Sub(t.balanceCacheTime) < t.cacheDuration {
+		cacheAge := t.clock.Now().Sub(t.balanceCacheTime)
+		balance := copyMap(t.cachedBalance)
 		t.balanceCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
-		return t.cachedBalance, nil
+		gateLog.Debug("使用缓存的账户余额", "cache_age_s", cacheAge.Seconds())
+		return balance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用Gate.io API获取账户余额...")
+	start := time.Now()
 	account, _, err := t.client.FuturesApi.ListFuturesAccounts(t.ctx, t.settle)
 	if err != nil {
 		// 详细错误信息
 		if gateErr, ok := err.(gateapi.GateAPIError); ok {
-			log.Printf("❌ Gate.io API调用失败: label: %s, message: %s", gateErr.Label, gateErr.Message)
+			gateLog.Error("获取账户余额失败", "label", gateErr.Label, "message", gateErr.Message)
 			if gateErr.Label == "INVALID_KEY" {
-				return nil, fmt.Errorf("Gate.io API密钥无效，请检查：1) API Key是否正确 2) Secret Key是否正确 3) API权限是否包含合约交易权限: %w", err)
+				return nil, fmt.Errorf("Gate.io API密钥无效，请检查：1) API Key是否正确 2) Secret Key是否正确 3) API权限是否包含合约交易权限: %w", classifyGateError(err))
 			}
 		} else {
-			log.Printf("❌ Gate.io API调用失败: %v", err)
+			gateLog.Error("获取账户余额失败", "err", err)
 		}
-		return nil, fmt.Errorf("获取账户信息失败: %w", err)
+		return nil, fmt.Errorf("获取账户信息失败: %w", classifyGateError(err))
 	}
 
 	result := make(map[string]interface{})
@@ -127,37 +296,47 @@ func (t *GateTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"] = availableBalance
 	result["totalUnrealizedProfit"] = unrealizedProfit
 
-	log.Printf("✓ Gate.io账户: 总净值=%.2f (钱包%.2f+未实现%.2f), 可用=%.2f",
-		totalWalletBalance, walletBalance, unrealizedProfit, availableBalance)
+	// 统一账户：改用跨币种保证金口径的余额（合约账户字段只反映USDT合约钱包）
+	if t.accountMode() != gateModeClassic {
+		if err := t.applyUnifiedBalance(result, unrealizedProfit); err != nil {
+			return nil, err
+		}
+	} else {
+		setCurrencies(result, []CurrencyBalance{usdtBalance(totalWalletBalance, availableBalance)})
+	}
+
+	gateLog.Debug("账户余额已刷新", "equity", totalWalletBalance, "wallet", walletBalance,
+		"unrealized", unrealizedProfit, "available", availableBalance, "latency_ms", time.Since(start).Milliseconds())
 
 	// 更新缓存
 	t.balanceCacheMutex.Lock()
 	t.cachedBalance = result
-	t.balanceCacheTime = time.Now()
+	t.balanceCacheTime = t.clock.Now()
 	t.balanceCacheMutex.Unlock()
 
-	return result, nil
+	return copyMap(result), nil
 }
 
 // GetPositions 获取所有持仓（带缓存）
 func (t *GateTrader) GetPositions() ([]map[string]interface{}, error) {
 	// 先检查缓存是否有效
 	t.positionsCacheMutex.RLock()
-	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
-		cacheAge := time.Since(t.positionsCacheTime)
+	if t.cachedPositions != nil && t.clock.Now().Sub(t.positionsCacheTime) < t.cacheDuration {
+		cacheAge := t.clock.Now().Sub(t.positionsCacheTime)
+		positions := copyPositions(t.cachedPositions)
 		t.positionsCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
-		return t.cachedPositions, nil
+		gateLog.Debug("使用缓存的持仓信息", "cache_age_s", cacheAge.Seconds())
+		return positions, nil
 	}
 	t.positionsCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用Gate.io API获取持仓信息...")
+	gateLog.Debug("缓存过期，重新获取持仓信息")
 
 	// Gate.io需要先获取所有合约列表，然后查询每个合约的持仓
 	contracts, _, err := t.client.FuturesApi.ListFuturesContracts(t.ctx, t.settle)
 	if err != nil {
-		return nil, fmt.Errorf("获取合约列表失败: %w", err)
+		return nil, fmt.Errorf("获取合约列表失败: %w", classifyGateError(err))
 	}
 
 	var result []map[string]interface{}
@@ -166,13 +345,11 @@ func (t *GateTrader) GetPositions() ([]map[string]interface{}, error) {
 		position, _, err := t.client.FuturesApi.GetPosition(t.ctx, t.settle, contract.Name)
 		if err != nil {
 			// 如果返回POSITION_NOT_FOUND错误，说明没有持仓，跳过
-			if gateErr, ok := err.(gateapi.GateAPIError); ok {
-				if gateErr.Label == "POSITION_NOT_FOUND" {
-					continue
-				}
+			if errors.Is(classifyGateError(err), ErrPositionNotFound) {
+				continue
 			}
 			// 其他错误记录但继续处理其他合约
-			log.Printf("⚠ 获取合约 %s 持仓失败: %v", contract.Name, err)
+			gateLog.Warn("获取合约持仓失败", "contract", contract.Name, "err", err)
 			continue
 		}
 
@@ -214,6 +391,13 @@ func (t *GateTrader) GetPositions() ([]map[string]interface{}, error) {
 				leverage = lev
 			}
 		}
+		if leverage <= 0 {
+			// 全仓模式杠杆为0，使用全仓杠杆上限
+			leverage = 10.0
+			if lev, err := strconv.ParseFloat(position.CrossLeverageLimit, 64); err == nil && lev > 0 {
+				leverage = lev
+			}
+		}
 
 		posMap["entryPrice"] = entryPrice
 		posMap["markPrice"] = markPrice
@@ -221,6 +405,7 @@ func (t *GateTrader) GetPositions() ([]map[string]interface{}, error) {
 		posMap["leverage"] = leverage
 		posMap["liquidationPrice"] = liquidationPrice
 		posMap["margin"] = positionMargin // 添加API返回的保证金字段
+		posMap["adlRanking"] = int(position.AdlRanking)
 
 		result = append(result, posMap)
 
@@ -233,139 +418,217 @@ func (t *GateTrader) GetPositions() ([]map[string]interface{}, error) {
 	// 更新缓存
 	t.positionsCacheMutex.Lock()
 	t.cachedPositions = result
-	t.positionsCacheTime = time.Now()
+	t.positionsCacheTime = t.clock.Now()
 	t.positionsCacheMutex.Unlock()
 
-	return result, nil
+	return copyPositions(result), nil
+}
+
+// copyMap 余额/持仓map的浅拷贝（值都是数字和字符串）
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// copyPositions 持仓缓存的副本（每个持仓的map也复制）
+func copyPositions(positions []map[string]interface{}) []map[string]interface{} {
+	if positions == nil {
+		return nil
+	}
+	copied := make([]map[string]interface{}, len(positions))
+	for i, pos := range positions {
+		copied[i] = copyMap(pos)
+	}
+	return copied
+}
+
+// enterContract 在合约的执行队列中排队直到轮到本次操作，返回结束函数（用法：defer t.enterContract(symbol)()）
+func (t *GateTrader) enterContract(symbol string) func() {
+	contract := convertSymbolToGateContract(symbol)
+	if ahead := t.queue.depth(contract); ahead > 0 {
+		gateLog.Debug("同一合约的操作正在执行，排队等待", "symbol", symbol, "ahead", ahead)
+	}
+	return t.queue.enter(contract)
 }
 
 // SetLeverage 设置杠杆
 func (t *GateTrader) SetLeverage(symbol string, leverage int) error {
+	defer t.enterContract(symbol)()
+	return t.setLeverage(symbol, leverage)
+}
+
+// ForceSetLeverage 设置杠杆，跳过持仓和开仓挂单的检查（人工确认后调用）
+func (t *GateTrader) ForceSetLeverage(symbol string, leverage int) error {
+	defer t.enterContract(symbol)()
+	return t.changeLeverage(symbol, leverage)
+}
+
+// setLeverage 设置杠杆（调用方已在合约的执行队列中）
+// 合约上有持仓或开仓挂单时，杠杆或保证金模式与目标不同则拒绝（强平价会随之改变，策略的止损和仓位计算不会考虑这一点）
+func (t *GateTrader) setLeverage(symbol string, leverage int) error {
+	if err := t.checkLeverageInterlock(symbol, leverage); err != nil {
+		return err
+	}
+	return t.changeLeverage(symbol, leverage)
+}
+
+// checkLeverageInterlock 杠杆和保证金模式不变时放行；需要切换时，合约上不能有持仓或非只减仓的挂单
+func (t *GateTrader) checkLeverageInterlock(symbol string, leverage int) error {
+	contract := convertSymbolToGateContract(symbol)
+	position, _, err := t.client.FuturesApi.GetPosition(t.ctx, t.settle, contract)
+	if err != nil {
+		if errors.Is(classifyGateError(err), ErrPositionNotFound) {
+			return nil
+		}
+		return fmt.Errorf("查询持仓失败，无法确认能否切换杠杆: %w", classifyGateError(err))
+	}
+
+	// 杠杆为0表示全仓，全仓时的杠杆上限为cross_leverage_limit
+	current, _ := strconv.ParseFloat(position.Leverage, 64)
+	crossLimit, _ := strconv.ParseFloat(position.CrossLeverageLimit, 64)
+	if t.crossMargin() {
+		if current == 0 && int(crossLimit) == leverage {
+			return nil
+		}
+	} else if current != 0 && int(current) == leverage {
+		return nil
+	}
+
+	if position.Size != 0 {
+		return fmt.Errorf("%w: %s 持有%d张，当前杠杆%s（全仓上限%s），目标%dx", ErrLeverageLocked, symbol, position.Size,
+			position.Leverage, position.CrossLeverageLimit, leverage)
+	}
+	orders, _, err := t.client.FuturesApi.ListFuturesOrders(t.ctx, t.settle, contract, "open", nil)
+	if err != nil {
+		return fmt.Errorf("查询挂单失败，无法确认能否切换杠杆: %w", classifyGateError(err))
+	}
+	for _, order := range orders {
+		if !order.IsReduceOnly {
+			return fmt.Errorf("%w: %s 有未成交的开仓委托 %d，当前杠杆%s，目标%dx", ErrLeverageLocked, symbol, order.Id,
+				position.Leverage, leverage)
+		}
+	}
+	return nil
+}
+
+// changeLeverage 切换杠杆和保证金模式（调用方已在合约的执行队列中）
+func (t *GateTrader) changeLeverage(symbol string, leverage int) error {
 	contract := convertSymbolToGateContract(symbol)
+	if err := t.applyRiskLimit(symbol, contract); err != nil {
+		return err
+	}
+
 	leverageStr := strconv.Itoa(leverage)
+	var opts *gateapi.UpdatePositionLeverageOpts
+	if t.crossMargin() {
+		// 全仓模式：杠杆设为0，下单杠杆作为全仓杠杆上限
+		opts = &gateapi.UpdatePositionLeverageOpts{CrossLeverageLimit: optional.NewString(leverageStr)}
+		leverageStr = "0"
+	}
 
-	_, _, err := t.client.FuturesApi.UpdatePositionLeverage(t.ctx, t.settle, contract, leverageStr, nil)
+	_, _, err := t.client.FuturesApi.UpdatePositionLeverage(t.ctx, t.settle, contract, leverageStr, opts)
+	if errors.Is(classifyGateError(err), ErrLeverageCooldown) {
+		// 仍在上次切换的冷却期内，等待后重试一次
+		gateLog.Warn("杠杆切换冷却中，3秒后重试", "symbol", symbol, "leverage", leverage, "wait", t.leverageCooldown)
+		t.clock.Sleep(t.leverageCooldown)
+		_, _, err = t.client.FuturesApi.UpdatePositionLeverage(t.ctx, t.settle, contract, leverageStr, opts)
+	}
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if gateErr, ok := err.(gateapi.GateAPIError); ok {
 			if strings.Contains(gateErr.Message, "No need to change") || strings.Contains(gateErr.Message, "already") {
-				log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+				gateLog.Debug("杠杆无需切换", "symbol", symbol, "leverage", leverage)
 				return nil
 			}
 		}
-		return fmt.Errorf("设置杠杆失败: %w", err)
+		return fmt.Errorf("设置杠杆失败: %w", classifyGateError(err))
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	gateLog.Info("杠杆已切换，等待3秒冷却期", "symbol", symbol, "leverage", leverage, "wait", t.leverageCooldown)
 
-	// 切换杠杆后等待3秒（避免冷却期错误）
-	log.Printf("  ⏱ 等待3秒冷却期...")
-	time.Sleep(3 * time.Second)
+	// 切换杠杆后等待冷却期（避免冷却期错误）
+	t.clock.Sleep(t.leverageCooldown)
 
 	return nil
 }
 
 // OpenLong 开多仓
 func (t *GateTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
-	// 先取消该币种的所有委托单
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
-	}
+	defer t.enterContract(symbol)()
 
 	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if err := t.setLeverage(symbol, leverage); err != nil {
 		return nil, err
 	}
 
-	contract := convertSymbolToGateContract(symbol)
-
-	// 格式化数量到正确精度
-	quantityStr, err := t.FormatQuantity(symbol, quantity)
-	if err != nil {
+	// 本地检查保证金，避免交易所返回BALANCE_NOT_ENOUGH
+	if err := t.checkMargin(symbol, quantity, leverage); err != nil {
 		return nil, err
 	}
 
-	// 转换为整数（Gate.io要求数量为整数）
-	quantityInt, err := strconv.ParseInt(quantityStr, 10, 64)
+	// 按下单精度取整，并收紧到风险限额允许的剩余持仓
+	size, err := t.openSize(symbol, "long", quantity)
 	if err != nil {
-		// 如果无法转换为整数，尝试四舍五入
-		quantityInt = int64(quantity + 0.5)
-	}
-
-	// 创建市价买入订单（IOC类型，价格为0表示市价）
-	order := gateapi.FuturesOrder{
-		Contract: contract,
-		Size:     quantityInt, // 正数表示买入（开多）
-		Price:    "0",         // 0表示市价单
-		Tif:      "ioc",       // Immediate or Cancel
+		return nil, err
 	}
 
-	orderResponse, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, order)
-	if err != nil {
-		return nil, fmt.Errorf("开多仓失败: %w", err)
+	// 检查通过后再取消该币种的普通委托单（不含止损止盈条件单），开仓被拒时保留原有挂单
+	if err := t.cancelAllOrders(symbol); err != nil {
+		gateLog.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
 	}
 
-	log.Printf("✓ 开多仓成功: %s 数量: %d", symbol, quantityInt)
-	log.Printf("  订单ID: %d", orderResponse.Id)
-
-	result := make(map[string]interface{})
-	result["orderId"] = orderResponse.Id
-	result["symbol"] = symbol
-	result["status"] = orderResponse.Status
-	return result, nil
+	// 市价IOC下单（正数买入开多），超过合约单笔上限时拆分成多笔
+	return t.placeMarketOrder(symbol, size, false, "开多仓")
 }
 
 // OpenShort 开空仓
 func (t *GateTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
-	// 先取消该币种的所有委托单
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
-	}
+	defer t.enterContract(symbol)()
 
 	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if err := t.setLeverage(symbol, leverage); err != nil {
 		return nil, err
 	}
 
-	contract := convertSymbolToGateContract(symbol)
-
-	// 格式化数量到正确精度
-	quantityStr, err := t.FormatQuantity(symbol, quantity)
-	if err != nil {
+	// 本地检查保证金，避免交易所返回BALANCE_NOT_ENOUGH
+	if err := t.checkMargin(symbol, quantity, leverage); err != nil {
 		return nil, err
 	}
 
-	// 转换为整数（Gate.io要求数量为整数）
-	quantityInt, err := strconv.ParseInt(quantityStr, 10, 64)
+	// 按下单精度取整，并收紧到风险限额允许的剩余持仓
+	size, err := t.openSize(symbol, "short", quantity)
 	if err != nil {
-		quantityInt = int64(quantity + 0.5)
-	}
-
-	// 创建市价卖出订单（负数表示卖出开空）
-	order := gateapi.FuturesOrder{
-		Contract: contract,
-		Size:     -quantityInt, // 负数表示卖出（开空）
-		Price:    "0",           // 0表示市价单
-		Tif:      "ioc",         // Immediate or Cancel
+		return nil, err
 	}
 
-	orderResponse, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, order)
-	if err != nil {
-		return nil, fmt.Errorf("开空仓失败: %w", err)
+	// 检查通过后再取消该币种的普通委托单（不含止损止盈条件单），开仓被拒时保留原有挂单
+	if err := t.cancelAllOrders(symbol); err != nil {
+		gateLog.Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "err", err)
 	}
 
-	log.Printf("✓ 开空仓成功: %s 数量: %d", symbol, quantityInt)
-	log.Printf("  订单ID: %d", orderResponse.Id)
+	// 市价IOC下单（负数卖出开空），超过合约单笔上限时拆分成多笔
+	return t.placeMarketOrder(symbol, -size, false, "开空仓")
+}
 
-	result := make(map[string]interface{})
-	result["orderId"] = orderResponse.Id
-	result["symbol"] = symbol
-	result["status"] = orderResponse.Status
-	return result, nil
+// withIOCFill IOC订单在下单响应中已结束时附上实际成交（张数和均价），订单跟踪不必再轮询订单
+func withIOCFill(result map[string]interface{}, order gateapi.FuturesOrder) map[string]interface{} {
+	if order.Status != "finished" {
+		return result
+	}
+	fill := gateOrderUpdate(order)
+	return withFill(result, fill.FilledQty, fill.AvgPrice, math.Abs(float64(order.Left)))
 }
 
 // CloseLong 平多仓
 func (t *GateTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	defer t.enterContract(symbol)()
+
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -381,53 +644,38 @@ func (t *GateTrader) CloseLong(symbol string, quantity float64) (map[string]inte
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的多仓: %w", symbol, ErrPositionNotFound)
 		}
 	}
 
-	contract := convertSymbolToGateContract(symbol)
-
-	// 格式化数量
-	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	// 按下单精度取整（超出合约单笔上限时拆分下单）
+	size, err := t.contractSize(symbol, quantity)
 	if err != nil {
 		return nil, err
 	}
 
-	quantityInt, err := strconv.ParseInt(quantityStr, 10, 64)
-	if err != nil {
-		quantityInt = int64(quantity + 0.5)
-	}
-
-	// 创建市价卖出订单（平多）
-	order := gateapi.FuturesOrder{
-		Contract:   contract,
-		Size:       -quantityInt, // 负数表示卖出（平多）
-		Price:       "0",          // 市价单
-		Tif:        "ioc",
-		ReduceOnly: true, // 只平仓，不开新仓
+	// 按最新持仓校验方向并截断数量（超过持仓的只减仓单会被拒绝）
+	if size, err = t.reduceOnlySize(symbol, "long", size); err != nil {
+		return nil, err
 	}
 
-	orderResponse, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, order)
+	// 市价卖出平仓（只减仓），超过合约单笔上限时拆分成多笔
+	result, err := t.placeMarketOrder(symbol, -size, true, "平多仓")
 	if err != nil {
-		return nil, fmt.Errorf("平多仓失败: %w", err)
+		return nil, err
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %d", symbol, quantityInt)
-
 	// 平仓后取消该币种的所有挂单
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	if err := t.cancelAllOrders(symbol); err != nil {
+		gateLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
-
-	result := make(map[string]interface{})
-	result["orderId"] = orderResponse.Id
-	result["symbol"] = symbol
-	result["status"] = orderResponse.Status
 	return result, nil
 }
 
 // CloseShort 平空仓
 func (t *GateTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	defer t.enterContract(symbol)()
+
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -443,53 +691,42 @@ func (t *GateTrader) CloseShort(symbol string, quantity float64) (map[string]int
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的空仓: %w", symbol, ErrPositionNotFound)
 		}
 	}
 
-	contract := convertSymbolToGateContract(symbol)
-
-	// 格式化数量
-	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	// 按下单精度取整（超出合约单笔上限时拆分下单）
+	size, err := t.contractSize(symbol, quantity)
 	if err != nil {
 		return nil, err
 	}
 
-	quantityInt, err := strconv.ParseInt(quantityStr, 10, 64)
-	if err != nil {
-		quantityInt = int64(quantity + 0.5)
-	}
-
-	// 创建市价买入订单（平空）
-	order := gateapi.FuturesOrder{
-		Contract:   contract,
-		Size:       quantityInt, // 正数表示买入（平空）
-		Price:      "0",         // 市价单
-		Tif:        "ioc",
-		ReduceOnly: true, // 只平仓，不开新仓
+	// 按最新持仓校验方向并截断数量（超过持仓的只减仓单会被拒绝）
+	if size, err = t.reduceOnlySize(symbol, "short", size); err != nil {
+		return nil, err
 	}
 
-	orderResponse, _, err := t.client.FuturesApi.CreateFuturesOrder(t.ctx, t.settle, order)
+	// 市价买入平仓（只减仓），超过合约单笔上限时拆分成多笔
+	result, err := t.placeMarketOrder(symbol, size, true, "平空仓")
 	if err != nil {
-		return nil, fmt.Errorf("平空仓失败: %w", err)
+		return nil, err
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %d", symbol, quantityInt)
-
 	// 平仓后取消该币种的所有挂单
-	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	if err := t.cancelAllOrders(symbol); err != nil {
+		gateLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
-
-	result := make(map[string]interface{})
-	result["orderId"] = orderResponse.Id
-	result["symbol"] = symbol
-	result["status"] = orderResponse.Status
 	return result, nil
 }
 
 // CancelAllOrders 取消该币种的所有挂单
 func (t *GateTrader) CancelAllOrders(symbol string) error {
+	defer t.enterContract(symbol)()
+	return t.cancelAllOrders(symbol)
+}
+
+// cancelAllOrders 取消该币种的所有挂单（调用方已在合约的执行队列中）
+func (t *GateTrader) cancelAllOrders(symbol string) error {
 	contract := convertSymbolToGateContract(symbol)
 
 	_, _, err := t.client.FuturesApi.CancelFuturesOrders(t.ctx, t.settle, contract, nil)
@@ -500,10 +737,10 @@ func (t *GateTrader) CancelAllOrders(symbol string) error {
 				return nil
 			}
 		}
-		return fmt.Errorf("取消挂单失败: %w", err)
+		return fmt.Errorf("取消挂单失败: %w", classifyGateError(err))
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	gateLog.Debug("已取消所有挂单", "symbol", symbol)
 	return nil
 }
 
@@ -516,7 +753,7 @@ func (t *GateTrader) GetMarketPrice(symbol string) (float64, error) {
 		Contract: optional.NewString(contract),
 	})
 	if err != nil {
-		return 0, fmt.Errorf("获取价格失败: %w", err)
+		return 0, fmt.Errorf("获取价格失败: %w", classifyGateError(err))
 	}
 
 	if len(tickers) == 0 {
@@ -531,20 +768,28 @@ func (t *GateTrader) GetMarketPrice(symbol string) (float64, error) {
 	return lastPrice, nil
 }
 
-// SetStopLoss 设置止损单
+// SetStopLoss 设置止损单（使用默认有效期）
 func (t *GateTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return t.SetStopLossWithExpiration(symbol, positionSide, quantity, stopPrice, 0)
+}
+
+// SetStopLossWithExpiration 设置指定有效期的止损单（ttl为0时使用默认有效期）
+func (t *GateTrader) SetStopLossWithExpiration(symbol string, positionSide string, quantity, stopPrice float64, ttl time.Duration) error {
+	return t.SetStopLossWithOptions(symbol, positionSide, quantity, stopPrice, TriggerOptions{Expiration: ttl})
+}
+
+// SetStopLossWithOptions 按指定有效期和触发价格类型设置止损单（零值参数使用默认值）
+func (t *GateTrader) SetStopLossWithOptions(symbol string, positionSide string, quantity, stopPrice float64, opts TriggerOptions) error {
+	defer t.enterContract(symbol)()
+
 	contract := convertSymbolToGateContract(symbol)
 
-	// 格式化数量
-	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	// 按下单精度取整；条件单不拆分，超出合约单笔上限时收紧到上限
+	quantityInt, err := t.contractSize(symbol, quantity)
 	if err != nil {
 		return err
 	}
-
-	quantityInt, err := strconv.ParseInt(quantityStr, 10, 64)
-	if err != nil {
-		quantityInt = int64(quantity + 0.5)
-	}
+	quantityInt = t.capOrderSize(symbol, quantityInt)
 
 	// 格式化止损价格
 	stopPriceStr := fmt.Sprintf("%.8f", stopPrice)
@@ -560,47 +805,62 @@ func (t *GateTrader) SetStopLoss(symbol string, positionSide string, quantity, s
 		rule = 1            // 价格>=触发价时触发（空仓止损）
 	}
 
+	// 触发后的委托：默认市价单；启用止损限价时挂GTC限价单
+	initial := gateapi.FuturesInitialOrder{
+		Contract:   contract,
+		Size:       size,
+		Price:      "0", // 市价单
+		Tif:        "ioc",
+		ReduceOnly: true,
+	}
+	if t.stopLimitOffsetPct > 0 {
+		initial.Price = t.formatPrice(contract, stopLimitPrice(positionSide, stopPrice, t.stopLimitOffsetPct))
+		initial.Tif = "gtc"
+	}
+
 	// Gate.io使用价格触发订单来实现止损
 	triggerOrder := gateapi.FuturesPriceTriggeredOrder{
-		Initial: gateapi.FuturesInitialOrder{
-			Contract:   contract,
-			Size:       size,
-			Price:      "0", // 市价单
-			Tif:        "ioc",
-			ReduceOnly: true,
-		},
+		Initial: initial,
 		Trigger: gateapi.FuturesPriceTrigger{
 			StrategyType: 0,        // 0: 按价格触发
-			PriceType:    1,        // 1: 标记价格
+			PriceType:    t.gatePriceType(opts.PriceType), // 0: 最新成交价，1: 标记价格，2: 指数价格
 			Price:        stopPriceStr,
 			Rule:         rule,     // 触发规则
-			Expiration:   2592000,  // 30天过期
+			Expiration:   t.triggerExpirationSeconds(opts.Expiration), // 到期后由交易所撤销
 		},
 	}
 
 	_, _, err = t.client.FuturesApi.CreatePriceTriggeredOrder(t.ctx, t.settle, triggerOrder)
 	if err != nil {
-		return fmt.Errorf("设置止损失败: %w", err)
+		return fmt.Errorf("设置止损失败: %w", classifyGateError(err))
 	}
 
-	log.Printf("  止损价设置: %.4f", stopPrice)
+	gateLog.Info("止损单已设置", "symbol", symbol, "stop_price", stopPrice, "limit_price", initial.Price)
 	return nil
 }
 
-// SetTakeProfit 设置止盈单
+// SetTakeProfit 设置止盈单（使用默认有效期）
 func (t *GateTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return t.SetTakeProfitWithExpiration(symbol, positionSide, quantity, takeProfitPrice, 0)
+}
+
+// SetTakeProfitWithExpiration 设置指定有效期的止盈单（ttl为0时使用默认有效期）
+func (t *GateTrader) SetTakeProfitWithExpiration(symbol string, positionSide string, quantity, takeProfitPrice float64, ttl time.Duration) error {
+	return t.SetTakeProfitWithOptions(symbol, positionSide, quantity, takeProfitPrice, TriggerOptions{Expiration: ttl})
+}
+
+// SetTakeProfitWithOptions 按指定有效期和触发价格类型设置止盈单（零值参数使用默认值）
+func (t *GateTrader) SetTakeProfitWithOptions(symbol string, positionSide string, quantity, takeProfitPrice float64, opts TriggerOptions) error {
+	defer t.enterContract(symbol)()
+
 	contract := convertSymbolToGateContract(symbol)
 
-	// 格式化数量
-	quantityStr, err := t.FormatQuantity(symbol, quantity)
+	// 按下单精度取整；条件单不拆分，超出合约单笔上限时收紧到上限
+	quantityInt, err := t.contractSize(symbol, quantity)
 	if err != nil {
 		return err
 	}
-
-	quantityInt, err := strconv.ParseInt(quantityStr, 10, 64)
-	if err != nil {
-		quantityInt = int64(quantity + 0.5)
-	}
+	quantityInt = t.capOrderSize(symbol, quantityInt)
 
 	// 格式化止盈价格
 	takeProfitPriceStr := fmt.Sprintf("%.8f", takeProfitPrice)
@@ -627,51 +887,87 @@ func (t *GateTrader) SetTakeProfit(symbol string, positionSide string, quantity,
 		},
 		Trigger: gateapi.FuturesPriceTrigger{
 			StrategyType: 0,        // 0: 按价格触发
-			PriceType:    1,        // 1: 标记价格
+			PriceType:    t.gatePriceType(opts.PriceType), // 0: 最新成交价，1: 标记价格，2: 指数价格
 			Price:        takeProfitPriceStr,
 			Rule:         rule,     // 触发规则
-			Expiration:   2592000,  // 30天过期
+			Expiration:   t.triggerExpirationSeconds(opts.Expiration), // 到期后由交易所撤销
 		},
 	}
 
 	_, _, err = t.client.FuturesApi.CreatePriceTriggeredOrder(t.ctx, t.settle, triggerOrder)
 	if err != nil {
-		return fmt.Errorf("设置止盈失败: %w", err)
+		return fmt.Errorf("设置止盈失败: %w", classifyGateError(err))
 	}
 
-	log.Printf("  止盈价设置: %.4f", takeProfitPrice)
+	gateLog.Info("止盈单已设置", "symbol", symbol, "take_profit_price", takeProfitPrice)
 	return nil
 }
 
+// SetTakeProfitLadder 分批止盈：每档一个只减仓的止盈条件单
+func (t *GateTrader) SetTakeProfitLadder(symbol, positionSide string, quantity float64, levels []TPLevel) error {
+	return placeLadderOrders(t, symbol, positionSide, quantity, levels)
+}
+
+// gateMaxOrderSize 下单张数的合理上限（超出时float64转int64可能溢出）
+const gateMaxOrderSize = 1e12
+
 // FormatQuantity 格式化数量到正确的精度
+// 不按合约单笔上限（order_size_max）收紧：市价开平仓超出上限时拆分成多笔下单，限价单和条件单下单时收紧到上限
 func (t *GateTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	size, err := t.contractSize(symbol, quantity)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(size, 10), nil
+}
+
+// contractSize 按下单精度把数量取整为张数（Gate.io按整数张下单）
+func (t *GateTrader) contractSize(symbol string, quantity float64) (int64, error) {
 	contract := convertSymbolToGateContract(symbol)
 
+	// 拒绝无效数量（NaN、无穷大、非正数、超出上限），避免按最小数量或溢出后的数量下单
+	if math.IsNaN(quantity) || math.IsInf(quantity, 0) || quantity <= 0 {
+		return 0, fmt.Errorf("%s 下单数量无效: %v", symbol, quantity)
+	}
+	if quantity > gateMaxOrderSize {
+		return 0, fmt.Errorf("%s 下单数量 %.0f 超出上限 %.0f", symbol, quantity, gateMaxOrderSize)
+	}
+
 	// 获取合约信息（带缓存）
 	contractInfo, err := t.getContractInfo(contract)
 	if err != nil {
-		// 如果获取失败，使用默认精度
-		log.Printf("  ⚠ 获取合约 %s 信息失败，使用默认精度: %v", contract, err)
-		return fmt.Sprintf("%.0f", quantity), nil
+		// 如果获取失败，使用默认精度（按整数张取整，最少1张）
+		gateLog.Warn("获取合约信息失败，使用默认精度", "contract", contract, "err", err)
+		rounded, err := t.roundQuantity(symbol, quantity, 1)
+		if err != nil {
+			return 0, err
+		}
+		return int64(rounded), nil
 	}
 
-	// Gate.io使用OrderSizeMin
-	// 数量必须不小于OrderSizeMin
-	orderSizeMin := float64(contractInfo.OrderSizeMin)
-
-	// 确保不小于最小数量
-	if quantity < orderSizeMin {
-		quantity = orderSizeMin
+	quantity, err = t.roundQuantity(symbol, quantity, float64(contractInfo.OrderSizeMin))
+	if err != nil {
+		return 0, err
 	}
+	return int64(quantity), nil
+}
 
-	// Gate.io合约通常使用整数数量，所以直接四舍五入到整数
-	quantity = math.Round(quantity)
-
-	// 计算精度（Gate.io通常使用整数，所以精度为0）
-	precision := 0
-
-	format := fmt.Sprintf("%%.%df", precision)
-	return fmt.Sprintf(format, quantity), nil
+// formatPrice 按合约的价格精度（order_price_round）格式化委托价格
+func (t *GateTrader) formatPrice(contract string, price float64) string {
+	contractInfo, err := t.getContractInfo(contract)
+	if err != nil {
+		gateLog.Warn("获取合约信息失败，使用默认精度", "contract", contract, "err", err)
+		return strconv.FormatFloat(price, 'f', 8, 64)
+	}
+	tick, err := strconv.ParseFloat(contractInfo.OrderPriceRound, 64)
+	if err != nil || tick <= 0 {
+		return strconv.FormatFloat(price, 'f', 8, 64)
+	}
+	decimals := 0
+	if i := strings.IndexByte(contractInfo.OrderPriceRound, '.'); i >= 0 {
+		decimals = len(strings.TrimRight(contractInfo.OrderPriceRound[i+1:], "0"))
+	}
+	return strconv.FormatFloat(math.Round(price/tick)*tick, 'f', decimals, 64)
 }
 
 // getContractInfo 获取合约信息（带缓存）
@@ -698,28 +994,17 @@ func (t *GateTrader) getContractInfo(contract string) (*gateapi.Contract, error)
 	return &contractInfo, nil
 }
 
-// convertSymbolToGateContract 将标准symbol转换为Gate.io合约格式
-// 例如: "BTCUSDT" -> "BTC_USDT"
+// convertSymbolToGateContract 将标准symbol转换为Gate.io合约格式（按符号注册表）
+// 例如: "BTCUSDT" -> "BTC_USDT"，"btc/usdt"、"BTC-USDT" -> "BTC_USDT"，"1000PEPEUSDT" -> "PEPE_USDT"
 func convertSymbolToGateContract(symbol string) string {
-	symbol = strings.ToUpper(symbol)
-	// 如果已经有下划线，直接返回
-	if strings.Contains(symbol, "_") {
-		return symbol
-	}
-	// 去掉USDT后缀，然后加上下划线
-	if strings.HasSuffix(symbol, "USDT") {
-		base := symbol[:len(symbol)-4]
-		return base + "_USDT"
-	}
-	return symbol
+	return symbols.ToVenue(symbols.Gate, symbol).Venue
 }
 
 // convertGateContractToSymbol 将Gate.io合约格式转换为标准symbol
 // 例如: "BTC_USDT" -> "BTCUSDT"
 func convertGateContractToSymbol(contract string) string {
-	contract = strings.ToUpper(contract)
-	// 替换下划线
-	return strings.Replace(contract, "_", "", -1)
+	symbol, _ := symbols.FromVenue(symbols.Gate, contract)
+	return symbol
 }
 
 // calculatePrecisionFromStep 根据step计算精度
@@ -734,3 +1019,41 @@ func calculatePrecisionFromStep(step float64) int {
 	}
 	return 0
 }
+
+// Probe 探测Gate.io连通性，并根据响应头中的服务器时间估算本地时钟偏差
+func (t *GateTrader) Probe() (ProbeResult, error) {
+	start := time.Now()
+	_, resp, err := t.client.FuturesApi.ListFuturesTickers(t.ctx, t.settle, &gateapi.ListFuturesTickersOpts{
+		Contract: optional.NewString("BTC_USDT"),
+	})
+	end := time.Now()
+	result := ProbeResult{Latency: end.Sub(start)}
+	if err != nil {
+		return result, classifyGateError(err)
+	}
+
+	// X-Out-Time为服务器响应时间（微秒），缺失时退回到秒级精度的Date头
+	local := start.Add(result.Latency / 2)
+	if out, parseErr := strconv.ParseInt(resp.Header.Get("X-Out-Time"), 10, 64); parseErr == nil && out > 0 {
+		result.ClockSkew = local.Sub(time.UnixMicro(out))
+		result.SkewKnown = true
+	} else if date, parseErr := http.ParseTime(resp.Header.Get("Date")); parseErr == nil {
+		result.ClockSkew = local.Sub(date).Truncate(time.Second)
+		result.SkewKnown = true
+	}
+	return result, nil
+}
+
+// CheckTradePermission 确认API密钥有合约交易权限：撤销一个不存在的订单，交易所返回订单不存在说明有权限
+// （不影响任何订单；只读密钥、未开通合约权限或IP不在白名单时返回对应的错误）
+func (t *GateTrader) CheckTradePermission() error {
+	_, _, err := t.client.FuturesApi.CancelFuturesOrder(t.ctx, t.settle, "1")
+	if err == nil {
+		return nil
+	}
+	var gateErr gateapi.GateAPIError
+	if errors.As(err, &gateErr) && (gateErr.Label == "ORDER_NOT_FOUND" || gateErr.Label == "ORDER_FINISHED") {
+		return nil
+	}
+	return classifyGateError(err)
+}
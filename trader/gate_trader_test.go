@@ -0,0 +1,311 @@
+package trader
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"nofx/risk"
+	"nofx/trader/gatetest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gateio/gateapi-go/v6"
+)
+
+func FuzzConvertSymbolToGateContract(f *testing.F) {
+	for _, seed := range []string{
+		"BTCUSDT", "btcusdt", "BTC_USDT", "btc_usdt", "BTC-USDT", "btc/usdt", " ETHUSDT\n",
+		"1000PEPEUSDT", "BTCUSD", "BTCUSDC", "ETHBTC", "USDT", "usdt", "", "_", "__USDT", "USDTUSDT",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, symbol string) {
+		contract := convertSymbolToGateContract(symbol)
+
+		// 合约名再换回标准符号后应得到同一个合约
+		if back := convertSymbolToGateContract(convertGateContractToSymbol(contract)); back != contract {
+			t.Fatalf("%q → %q → %q", symbol, contract, back)
+		}
+
+		// 只对ASCII输入检查大小写和分隔符无关（Unicode大小写转换不一定可逆）
+		for _, r := range symbol {
+			if r > 127 {
+				return
+			}
+		}
+		for _, variant := range []string{
+			strings.ToLower(symbol),
+			strings.ToUpper(symbol),
+			strings.NewReplacer("_", "", "-", "", "/", "").Replace(symbol),
+		} {
+			if got := convertSymbolToGateContract(variant); got != contract {
+				t.Fatalf("%q → %q，但 %q → %q", symbol, contract, variant, got)
+			}
+		}
+		if contract != "" && contract != "USDT" && !strings.HasSuffix(contract, "_USDT") {
+			t.Fatalf("%q → %q，不是USDT合约", symbol, contract)
+		}
+		if strings.HasPrefix(contract, "_") {
+			t.Fatalf("%q → %q，基础币种为空", symbol, contract)
+		}
+	})
+}
+
+func FuzzGateContractSize(f *testing.F) {
+	for _, q := range []float64{1, 0.4, 0.5, 1.5, 2.5, 7.9999999999, 1e-9, 0, -1,
+		math.NaN(), math.Inf(1), math.Inf(-1), 1e12, 1e12 + 1, 1e18, math.MaxFloat64} {
+		f.Add(q, uint8(1), uint8(0), false)
+	}
+	f.Add(3.2, uint8(5), uint8(0), false)
+	f.Add(3.2, uint8(5), uint8(1), true)
+	f.Add(4.9999999999, uint8(5), uint8(2), true)
+	f.Add(0.6, uint8(0), uint8(1), true)
+
+	modes := []string{risk.RoundNearest, risk.RoundFloor, risk.RoundCeil}
+	f.Fuzz(func(t *testing.T, quantity float64, orderSizeMin, mode uint8, reject bool) {
+		rounding := risk.QuantityRounding{Mode: modes[int(mode)%len(modes)], BelowMin: risk.BelowMinBump}
+		if reject {
+			rounding.BelowMin = risk.BelowMinReject
+		}
+		gt := &GateTrader{
+			rounding: rounding,
+			contractCache: map[string]*gateapi.Contract{
+				"BTC_USDT": {Name: "BTC_USDT", QuantoMultiplier: "0.0001", OrderSizeMin: int64(orderSizeMin)},
+			},
+		}
+		minSize := math.Max(float64(orderSizeMin), 1)
+
+		size, err := gt.contractSize("BTCUSDT", quantity)
+		if math.IsNaN(quantity) || math.IsInf(quantity, 0) || quantity <= 0 || quantity > gateMaxOrderSize {
+			if err == nil {
+				t.Fatalf("无效数量 %v 应被拒绝，得到 %d 张", quantity, size)
+			}
+			return
+		}
+
+		rounded := rounding.Round(quantity, 1)
+		if rounded < minSize {
+			// 取整后低于最小下单数量：reject拒绝，bump按最小数量下单
+			if reject {
+				if !errors.Is(err, ErrOrderTooSmall) {
+					t.Fatalf("数量 %v（取整%v，最小%v）应返回ErrOrderTooSmall，得到 %d, %v", quantity, rounded, minSize, size, err)
+				}
+				return
+			}
+			if err != nil || float64(size) != minSize {
+				t.Fatalf("数量 %v（取整%v）应提高到最小数量%v，得到 %d, %v", quantity, rounded, minSize, size, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("数量 %v: %v", quantity, err)
+		}
+		if float64(size) != rounded {
+			t.Fatalf("数量 %v 按%s取整应为 %v，得到 %d", quantity, rounding.Mode, rounded, size)
+		}
+		if math.Abs(float64(size)-quantity) >= 1+1e-6 {
+			t.Fatalf("数量 %v 取整为 %d，偏差超过1张", quantity, size)
+		}
+
+		formatted, err := gt.FormatQuantity("BTCUSDT", quantity)
+		if err != nil || formatted != strings.TrimSpace(formatted) || strings.ContainsAny(formatted, ".eE+-") {
+			t.Fatalf("FormatQuantity(%v) = %q, %v，应为整数张", quantity, formatted, err)
+		}
+	})
+}
+
+// newTestGateTrader 连接gatetest测试服务器的GateTrader（切换杠杆后不等待冷却）
+func newTestGateTrader(t *testing.T) (*GateTrader, *gatetest.Server) {
+	t.Helper()
+	srv := gatetest.NewServer()
+	t.Cleanup(srv.Close)
+	gt, err := NewGateTrader("key", "secret", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gt.SetBaseURL(srv.BasePath())
+	gt.SetLeverageCooldown(0)
+	return gt, srv
+}
+
+// countRequests 测试服务器收到的指定请求数量
+func countRequests(srv *gatetest.Server, request string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r == request {
+			n++
+		}
+	}
+	return n
+}
+
+func TestGatePositionNotFound(t *testing.T) {
+	gt, srv := newTestGateTrader(t)
+
+	// 从未交易过的合约返回POSITION_NOT_FOUND，视为没有持仓
+	positions, err := gt.GetPositions()
+	if err != nil || len(positions) != 0 {
+		t.Fatalf("GetPositions = %v, %v，期望没有持仓", positions, err)
+	}
+	if _, err := gt.CloseLong("BTCUSDT", 0); !errors.Is(err, ErrPositionNotFound) {
+		t.Fatalf("没有持仓时平仓应返回ErrPositionNotFound，得到 %v", err)
+	}
+	// 切换杠杆前的持仓检查同样按没有持仓放行
+	if err := gt.SetLeverage("BTCUSDT", 5); err != nil {
+		t.Fatalf("没有持仓时设置杠杆: %v", err)
+	}
+
+	// 其他合约仍返回POSITION_NOT_FOUND时不影响已有持仓
+	srv.SetPosition("BTC_USDT", 3, 100000, 5)
+	gt.SetCacheDuration(0)
+	positions, err = gt.GetPositions()
+	if err != nil || len(positions) != 1 {
+		t.Fatalf("GetPositions = %v, %v，期望1个持仓", positions, err)
+	}
+	if p := positions[0]; p["symbol"] != "BTCUSDT" || p["side"] != "long" || p["positionAmt"] != 3.0 {
+		t.Fatalf("持仓 %v，期望BTCUSDT多仓3张", p)
+	}
+}
+
+func TestGateLeverageCooldown(t *testing.T) {
+	gt, srv := newTestGateTrader(t)
+	const leveragePath = "POST /futures/usdt/positions/BTC_USDT/leverage"
+	srv.SetLeverageCooldown(200 * time.Millisecond)
+
+	if err := gt.SetLeverage("BTCUSDT", 5); err != nil {
+		t.Fatal(err)
+	}
+	// 冷却期内再次切换：等待交易器的冷却时间后重试一次
+	gt.SetLeverageCooldown(250 * time.Millisecond)
+	if err := gt.SetLeverage("BTCUSDT", 10); err != nil {
+		t.Fatalf("冷却后重试应成功: %v", err)
+	}
+	if pos, _ := srv.Position("BTC_USDT"); pos.Leverage != "10" {
+		t.Fatalf("杠杆 %s，期望10", pos.Leverage)
+	}
+	if n := countRequests(srv, leveragePath); n != 3 {
+		t.Fatalf("杠杆请求 %d 次，期望3次（5x一次，10x冷却重试两次）", n)
+	}
+
+	// 重试时仍在冷却期内返回ErrLeverageCooldown
+	srv.SetLeverageCooldown(time.Hour)
+	gt.SetLeverageCooldown(0)
+	if err := gt.SetLeverage("BTCUSDT", 20); !errors.Is(err, ErrLeverageCooldown) {
+		t.Fatalf("冷却期内切换杠杆应返回ErrLeverageCooldown，得到 %v", err)
+	}
+	if n := countRequests(srv, leveragePath); n != 5 {
+		t.Fatalf("杠杆请求 %d 次，期望5次（冷却错误只重试一次）", n)
+	}
+}
+
+func TestGateSplitMarketOrder(t *testing.T) {
+	gt, srv := newTestGateTrader(t)
+	srv.SetOrderSizeMax("BTC_USDT", 10)
+
+	// 25张超过单笔上限10张：拆成10+10+5依次下单，结果汇总全部批次的成交
+	result, err := gt.OpenLong("BTCUSDT", 25, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int64
+	for _, o := range srv.Orders() {
+		if o.Tif != "ioc" || o.Price != "0" || o.IsReduceOnly {
+			t.Fatalf("拆分的委托 %+v，期望市价IOC开仓单", o)
+		}
+		sizes = append(sizes, o.Size)
+	}
+	if len(sizes) != 3 || sizes[0] != 10 || sizes[1] != 10 || sizes[2] != 5 {
+		t.Fatalf("拆分下单 %v，期望[10 10 5]", sizes)
+	}
+	if result["filledQty"] != 25.0 || result["avgPrice"] != 100000.0 || result["leftQty"] != 0.0 {
+		t.Fatalf("下单结果 %v，期望成交25张、均价100000", result)
+	}
+	if pos, _ := srv.Position("BTC_USDT"); pos.Size != 25 {
+		t.Fatalf("持仓 %d 张，期望25张", pos.Size)
+	}
+
+	// 平仓同样拆分（只减仓）
+	result, err = gt.CloseLong("BTCUSDT", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["filledQty"] != 25.0 {
+		t.Fatalf("平仓结果 %v，期望成交25张", result)
+	}
+	if pos, _ := srv.Position("BTC_USDT"); pos.Size != 0 {
+		t.Fatalf("平仓后持仓 %d 张，期望0", pos.Size)
+	}
+	if orders := srv.Orders(); len(orders) != 6 || !orders[5].IsReduceOnly || orders[5].Size != -5 {
+		t.Fatalf("平仓委托 %+v，期望拆成-10/-10/-5的只减仓单", orders[3:])
+	}
+}
+
+func TestGateSplitMarketOrderFirstBatchFails(t *testing.T) {
+	gt, srv := newTestGateTrader(t)
+	srv.SetOrderSizeMax("BTC_USDT", 10)
+	srv.FailNext(http.MethodPost, "/futures/usdt/orders", http.StatusServiceUnavailable, "SERVER_ERROR", "server error")
+
+	// 第一笔失败时返回错误，不再下后续批次
+	if _, err := gt.OpenLong("BTCUSDT", 25, 5); !errors.Is(err, ErrExchangeUnavailable) {
+		t.Fatalf("第一笔下单失败应返回ErrExchangeUnavailable，得到 %v", err)
+	}
+	if orders := srv.Orders(); len(orders) != 0 {
+		t.Fatalf("第一笔失败后仍下了 %d 笔委托", len(orders))
+	}
+}
+
+func TestGatePostOnlyFilled(t *testing.T) {
+	gt, srv := newTestGateTrader(t)
+
+	// 挂单出现后以委托价全部成交
+	go func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			for _, o := range srv.Orders() {
+				if o.Status == "open" {
+					srv.FillOrder(o.Id, 0)
+					return
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	result, err := gt.OpenPostOnly("BTCUSDT", "long", 4, 5, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := srv.Orders()
+	if len(orders) != 1 || orders[0].Tif != "poc" || orders[0].Size != 4 || orders[0].Price != "99999.9" {
+		t.Fatalf("委托 %+v，期望在买一99999.9挂4张只挂单", orders)
+	}
+	if result["orderId"] != orders[0].Id || result["price"] != 99999.9 {
+		t.Fatalf("下单结果 %v", result)
+	}
+	if pos, _ := srv.Position("BTC_USDT"); pos.Size != 4 {
+		t.Fatalf("持仓 %d 张，期望4张", pos.Size)
+	}
+}
+
+func TestGatePostOnlyNotFilled(t *testing.T) {
+	gt, srv := newTestGateTrader(t)
+
+	// 超时未成交：撤单后返回ErrMakerNotFilled（调用方改为市价开仓）
+	if _, err := gt.OpenPostOnly("ETHUSDT", "short", 2, 5, 0); !errors.Is(err, ErrMakerNotFilled) {
+		t.Fatalf("未成交应返回ErrMakerNotFilled，得到 %v", err)
+	}
+	orders := srv.Orders()
+	if len(orders) != 1 || orders[0].Size != -2 || orders[0].Price != "3000.01" || orders[0].Status != "finished" {
+		t.Fatalf("委托 %+v，期望在卖一3000.01挂2张空单并已撤销", orders)
+	}
+	if pos, _ := srv.Position("ETH_USDT"); pos.Size != 0 {
+		t.Fatalf("持仓 %d 张，期望没有持仓", pos.Size)
+	}
+
+	// 只挂单会立即成交被交易所拒绝时同样返回ErrMakerNotFilled
+	srv.FailNext(http.MethodPost, "/futures/usdt/orders", http.StatusBadRequest, "ORDER_POC", "order would match immediately as taker")
+	if _, err := gt.OpenPostOnly("ETHUSDT", "short", 2, 5, time.Second); !errors.Is(err, ErrMakerNotFilled) {
+		t.Fatalf("只挂单被拒绝应返回ErrMakerNotFilled，得到 %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"nofx/risk"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/antihax/optional"
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+// gateModeClassic 经典账户（合约账户独立，按USDT计算保证金）
+// 统一账户的模式为single_currency（单币种保证金）、multi_currency（跨币种保证金）或portfolio（组合保证金）
+const gateModeClassic = "classic"
+
+// gateUnifiedAccount 统一账户汇总（/unified/accounts，金额均以USD计）
+type gateUnifiedAccount struct {
+	TotalMarginBalance     string `json:"total_margin_balance"`   // 保证金余额（已计入未实现盈亏和折算率）
+	TotalAvailableMargin   string `json:"total_available_margin"` // 可用保证金
+	TotalInitialMargin     string `json:"total_initial_margin"`   // 已占用初始保证金
+	TotalMaintenanceMargin string `json:"total_maintenance_margin"`
+	UnifiedTotalEquity     string `json:"unified_account_total_equity"` // 账户总权益（单币种模式下保证金余额可能为空）
+
+	Balances map[string]gateUnifiedBalance `json:"balances"` // 币种 → 余额
+}
+
+// gateUnifiedBalance 统一账户单个币种的余额（数量以该币种计）
+type gateUnifiedBalance struct {
+	Available         string `json:"available"`
+	Borrowed          string `json:"borrowed"`
+	Equity            string `json:"equity"`
+	EnabledCollateral *bool  `json:"enabled_collateral"` // 是否作为保证金（接口未返回时按启用处理）
+}
+
+// gateDiscountCacheTTL 抵押折算率档位的缓存时间
+const gateDiscountCacheTTL = time.Hour
+
+// gateDiscountCache 统一账户抵押折算率档位缓存（币种 → 档位，公共数据，零值可用）
+type gateDiscountCache struct {
+	mu      sync.Mutex
+	tiers   map[string][]risk.CollateralTier
+	fetched time.Time
+}
+
+// gateGet 调用SDK未包含的GET接口，查询参数写在path中（签名方式与SDK一致: method\npath\nquery\nsha512(body)\ntimestamp）
+func (t *GateTrader) gateGet(path string, out interface{}) error {
+	auth, _ := t.ctx.Value(gateapi.ContextGateAPIV4).(gateapi.GateAPIV4)
+	cfg := t.client.GetConfig()
+	u, err := url.Parse(cfg.BasePath + path)
+	if err != nil {
+		return err
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	emptyBody := sha512.Sum512(nil)
+	mac := hmac.New(sha512.New, []byte(auth.Secret))
+	fmt.Fprintf(mac, "GET\n%s\n%s\n%s\n%s", u.Path, u.RawQuery, hex.EncodeToString(emptyBody[:]), ts)
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("KEY", auth.Key)
+	req.Header.Set("SIGN", hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("Timestamp", ts)
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr gateapi.GateAPIError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Label != "" {
+			return classifyGateError(apiErr)
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// accountMode 账户模式（首次调用时检测并缓存；检测失败按经典账户处理）
+func (t *GateTrader) accountMode() string {
+	t.accountModeOnce.Do(func() {
+		t.unifiedMode = gateModeClassic
+		var resp struct {
+			Mode string `json:"mode"`
+		}
+		if err := t.gateGet("/unified/unified_mode", &resp); err != nil {
+			gateLog.Warn("检测账户模式失败，按经典账户处理", "err", err)
+			return
+		}
+		if resp.Mode != "" {
+			t.unifiedMode = resp.Mode
+		}
+		gateLog.Info("账户模式", "mode", t.unifiedMode)
+	})
+	return t.unifiedMode
+}
+
+// applyUnifiedBalance 统一账户余额：合约账户的total/available只反映USDT合约钱包，
+// 跨币种/组合保证金模式下其他币种资产也可作保证金，改用统一账户的保证金余额和可用保证金
+// unrealizedProfit为合约账户的未实现盈亏（统一账户汇总不单独返回），用于拆分出钱包余额
+func (t *GateTrader) applyUnifiedBalance(result map[string]interface{}, unrealizedProfit float64) error {
+	var account gateUnifiedAccount
+	if err := t.gateGet("/unified/accounts", &account); err != nil {
+		return fmt.Errorf("获取统一账户余额失败: %w", err)
+	}
+
+	setCurrencies(result, t.unifiedCurrencies(account.Balances))
+
+	equity, _ := strconv.ParseFloat(account.TotalMarginBalance, 64)
+	if equity == 0 {
+		equity, _ = strconv.ParseFloat(account.UnifiedTotalEquity, 64)
+	}
+	if equity == 0 {
+		equity = result["totalCollateralValue"].(float64) // 汇总字段为空时按各币种折算后的价值计算
+	}
+	available, _ := strconv.ParseFloat(account.TotalAvailableMargin, 64)
+	initialMargin, _ := strconv.ParseFloat(account.TotalInitialMargin, 64)
+	maintenanceMargin, _ := strconv.ParseFloat(account.TotalMaintenanceMargin, 64)
+
+	result["totalWalletBalance"] = equity - unrealizedProfit
+	result["availableBalance"] = available
+	result["totalInitialMargin"] = initialMargin
+	result["totalMaintenanceMargin"] = maintenanceMargin
+
+	gateLog.Debug("统一账户余额已刷新", "mode", t.unifiedMode, "margin_balance", equity, "available", available,
+		"initial_margin", initialMargin, "maintenance_margin", maintenanceMargin)
+	return nil
+}
+
+// unifiedCurrencies 统一账户的币种明细：按指数价估算美元价值，启用抵押的币种按分档折算率计算可计入保证金的价值
+// 获取不到价格的币种估值为0（记录警告，不影响余额查询）
+func (t *GateTrader) unifiedCurrencies(balances map[string]gateUnifiedBalance) []CurrencyBalance {
+	tiers := t.collateralTiers()
+	currencies := make([]CurrencyBalance, 0, len(balances))
+	for currency, b := range balances {
+		c := CurrencyBalance{Currency: currency}
+		c.Equity, _ = strconv.ParseFloat(b.Equity, 64)
+		c.Available, _ = strconv.ParseFloat(b.Available, 64)
+		c.Borrowed, _ = strconv.ParseFloat(b.Borrowed, 64)
+		if c.Equity == 0 && c.Available == 0 && c.Borrowed == 0 {
+			continue
+		}
+		price, err := t.indexPriceUSD(currency)
+		if err != nil {
+			gateLog.Warn("获取币种指数价失败，估值按0计算", "currency", currency, "err", err)
+		}
+		c.IndexPrice = price
+		c.ValueUSD = c.Equity * price
+		if b.EnabledCollateral == nil || *b.EnabledCollateral {
+			c.CollateralUSD = risk.CollateralValue(c.ValueUSD, tiers[currency])
+		}
+		currencies = append(currencies, c)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		if currencies[i].ValueUSD != currencies[j].ValueUSD {
+			return currencies[i].ValueUSD > currencies[j].ValueUSD
+		}
+		return currencies[i].Currency < currencies[j].Currency
+	})
+	return currencies
+}
+
+// indexPriceUSD 币种的美元价格：USDT按1计，优先取USDT永续合约的指数价，没有合约时取现货最新价
+func (t *GateTrader) indexPriceUSD(currency string) (float64, error) {
+	if currency == "USDT" {
+		return 1, nil
+	}
+	tickers, _, err := t.client.FuturesApi.ListFuturesTickers(t.ctx, t.settle, &gateapi.ListFuturesTickersOpts{
+		Contract: optional.NewString(currency + "_USDT"),
+	})
+	if err == nil && len(tickers) > 0 {
+		if price, _ := strconv.ParseFloat(tickers[0].IndexPrice, 64); price > 0 {
+			return price, nil
+		}
+	}
+	return t.GetSpotPrice(currency + "USDT")
+}
+
+// collateralTiers 各币种的抵押折算率档位（每小时刷新，获取失败时沿用上次的数据，从未获取成功时按全额计算）
+func (t *GateTrader) collateralTiers() map[string][]risk.CollateralTier {
+	c := &t.discounts
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tiers != nil && t.clock.Now().Sub(c.fetched) < gateDiscountCacheTTL {
+		return c.tiers
+	}
+
+	var resp []struct {
+		Currency      string `json:"currency"`
+		DiscountTiers []struct {
+			Discount   string `json:"discount"`
+			LowerLimit string `json:"lower_limit"`
+			UpperLimit string `json:"upper_limit"` // 最高档为"+"
+		} `json:"discount_tiers"`
+	}
+	if err := t.gateGet("/unified/currency_discount_tiers", &resp); err != nil {
+		gateLog.Warn("获取抵押折算率失败", "err", err)
+		return c.tiers
+	}
+	tiers := make(map[string][]risk.CollateralTier, len(resp))
+	for _, currency := range resp {
+		for _, tier := range currency.DiscountTiers {
+			var parsed risk.CollateralTier
+			parsed.Discount, _ = strconv.ParseFloat(tier.Discount, 64)
+			parsed.LowerUSD, _ = strconv.ParseFloat(tier.LowerLimit, 64)
+			parsed.UpperUSD, _ = strconv.ParseFloat(tier.UpperLimit, 64)
+			tiers[currency.Currency] = append(tiers[currency.Currency], parsed)
+		}
+	}
+	c.tiers, c.fetched = tiers, t.clock.Now()
+	return tiers
+}
@@ -0,0 +1,954 @@
+// Package gatetest 模拟Gate.io USDT合约REST接口的测试服务器（基于net/http/httptest），
+// 覆盖GateTrader用到的账户、合约、风险限额档位、持仓、杠杆（含全仓）、风险限额、下单、条件单、成交和流水接口，
+// 其他结算币种（如BTC本位）只提供SetSettleBook设置的账户和持仓，
+// 可注入POSITION_NOT_FOUND、杠杆冷却、5xx等错误，无需真实API密钥即可测试交易器行为
+//
+//	srv := gatetest.NewServer()
+//	defer srv.Close()
+//	t, _ := trader.NewGateTrader("key", "secret", false)
+//	t.SetBaseURL(srv.BasePath())
+//	t.SetLeverageCooldown(0)
+package gatetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gateapi "github.com/gateio/gateapi-go/v6"
+)
+
+const (
+	basePath       = "/api/v4"
+	settlePrefix   = "/futures/usdt"
+	defaultBalance = 10000.0
+	defaultMaker   = 0.0002
+	defaultTaker   = 0.0005
+)
+
+// failure 注入的错误（按方法和路径匹配一次）
+type failure struct {
+	method, path string
+	status       int
+	label        string
+	message      string
+	processed    bool // 先正常处理请求再返回错误（模拟已受理但响应丢失）
+}
+
+// Server 模拟的Gate.io合约服务器，所有状态都在内存中，方法可并发调用
+type Server struct {
+	*httptest.Server
+
+	mu               sync.Mutex
+	balance          float64 // 钱包余额（已扣除手续费、计入已实现盈亏）
+	makerFee         float64
+	takerFee         float64
+	contracts        map[string]*gateapi.Contract
+	riskTiers        map[string][]RiskLimitTier
+	settleBooks      map[string]settleBook // 其他结算币种的账户和持仓（只读）
+	prices           map[string]float64
+	positions        map[string]*gateapi.Position // 交易过或设置过杠杆的合约（size可以为0）
+	orders           map[int64]*gateapi.FuturesOrder
+	triggers         map[int64]*gateapi.FuturesPriceTriggeredOrder
+	trades           []gateapi.MyFuturesTrade
+	book             []gateapi.FuturesAccountBook
+	nextID           int64
+	leverageCooldown time.Duration
+	leverageChanged  map[string]time.Time
+	failures         []failure
+	requests         []string
+}
+
+// NewServer 启动测试服务器：BTC_USDT（100000，乘数0.0001）和ETH_USDT（3000，乘数0.01），钱包余额10000 USDT
+func NewServer() *Server {
+	s := &Server{
+		balance:         defaultBalance,
+		makerFee:        defaultMaker,
+		takerFee:        defaultTaker,
+		contracts:       make(map[string]*gateapi.Contract),
+		riskTiers:       make(map[string][]RiskLimitTier),
+		settleBooks:     make(map[string]settleBook),
+		prices:          make(map[string]float64),
+		positions:       make(map[string]*gateapi.Position),
+		orders:          make(map[int64]*gateapi.FuturesOrder),
+		triggers:        make(map[int64]*gateapi.FuturesPriceTriggeredOrder),
+		leverageChanged: make(map[string]time.Time),
+		nextID:          1000,
+	}
+	s.AddContract("BTC_USDT", 100000, 0.0001, "0.1")
+	s.AddContract("ETH_USDT", 3000, 0.01, "0.01")
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BasePath REST接口地址（传给GateTrader.SetBaseURL）
+func (s *Server) BasePath() string {
+	return s.URL + basePath
+}
+
+// AddContract 添加合约（price为最新价，multiplier为每张合约的币数量，tick为价格精度）
+func (s *Server) AddContract(name string, price, multiplier float64, tick string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contracts[name] = &gateapi.Contract{
+		Name:             name,
+		QuantoMultiplier: formatFloat(multiplier),
+		LeverageMin:      "1",
+		LeverageMax:      "100",
+		OrderPriceRound:  tick,
+		OrderSizeMin:     1,
+	}
+	s.prices[name] = price
+}
+
+// SetOrderSizeMax 设置合约的单笔最大下单张数（超出时拒绝下单）
+func (s *Server) SetOrderSizeMax(contract string, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.contracts[contract]; ok {
+		c.OrderSizeMax = size
+	}
+}
+
+// SetBalance 设置钱包余额
+func (s *Server) SetBalance(balance float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balance = balance
+}
+
+// RiskLimitTier 风险限额档位（仓位价值上限、起始/维持保证金率、最大杠杆）
+type RiskLimitTier struct {
+	RiskLimit       float64
+	InitialRate     float64
+	MaintenanceRate float64
+	LeverageMax     int
+}
+
+// SetRiskLimitTiers 设置合约的风险限额档位（按档位顺序；未设置的合约返回空列表）
+func (s *Server) SetRiskLimitTiers(contract string, tiers ...RiskLimitTier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.riskTiers[contract] = tiers
+}
+
+// settleBook 其他结算币种的合约账户和持仓
+type settleBook struct {
+	account   gateapi.FuturesAccount
+	positions []gateapi.Position
+}
+
+// SetSettleBook 设置其他结算币种（如"btc"）的合约账户和持仓，只支持查询
+func (s *Server) SetSettleBook(settle string, account gateapi.FuturesAccount, positions ...gateapi.Position) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settleBooks[settle] = settleBook{account: account, positions: positions}
+}
+
+// SetFeeRates 设置挂单/吃单费率（小数，默认0.0002/0.0005）
+func (s *Server) SetFeeRates(maker, taker float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.makerFee, s.takerFee = maker, taker
+}
+
+// SetPrice 设置最新价（同时作为标记价），达到触发价的条件单立即执行
+func (s *Server) SetPrice(contract string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[contract] = price
+	s.checkTriggers(contract)
+}
+
+// SetPosition 直接设置持仓（size为合约张数，负数为空仓），用于准备测试场景
+func (s *Server) SetPosition(contract string, size int64, entryPrice float64, leverage int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos := s.position(contract)
+	pos.Size = size
+	pos.EntryPrice = formatFloat(entryPrice)
+	pos.Leverage = strconv.Itoa(leverage)
+}
+
+// Position 合约当前持仓（从未交易过的合约ok为false）
+func (s *Server) Position(contract string) (gateapi.Position, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pos, ok := s.positions[contract]
+	if !ok {
+		return gateapi.Position{}, false
+	}
+	return s.positionView(pos), true
+}
+
+// Orders 所有普通委托（含已完成），按创建顺序
+func (s *Server) Orders() []gateapi.FuturesOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]gateapi.FuturesOrder, 0, len(s.orders))
+	for _, o := range s.orders {
+		result = append(result, *o)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// TriggerOrders 生效中的条件单，按创建顺序
+func (s *Server) TriggerOrders() []gateapi.FuturesPriceTriggeredOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.openTriggers()
+}
+
+// FillOrder 以委托价成交一笔挂单（模拟只挂单被动成交），qty为成交张数（0表示全部）
+func (s *Server) FillOrder(id int64, qty int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orders[id]
+	if !ok || o.Status != "open" {
+		return fmt.Errorf("订单 %d 不存在或已完成", id)
+	}
+	left := abs(o.Left)
+	if qty <= 0 || qty > left {
+		qty = left
+	}
+	price, _ := strconv.ParseFloat(o.Price, 64)
+	if o.Size < 0 {
+		qty = -qty
+	}
+	s.fill(o, qty, price, "maker")
+	return nil
+}
+
+// SetLeverageCooldown 设置杠杆切换冷却期：冷却期内再次切换同一合约的杠杆返回冷却错误（默认0，不限制）
+func (s *Server) SetLeverageCooldown(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leverageCooldown = d
+}
+
+// FailNext 下一次匹配的请求返回错误（path不含/api/v4前缀和查询参数，如"/futures/usdt/orders"）
+// label为空时返回无错误标签的纯文本响应（如网关返回的502）
+func (s *Server) FailNext(method, path string, status int, label, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{method: method, path: path, status: status, label: label, message: message})
+}
+
+// FailNextAfterProcessing 下一次匹配的请求照常处理（如订单已创建）但返回错误，模拟交易所已受理、响应在网关丢失
+func (s *Server) FailNextAfterProcessing(method, path string, status int, label, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{method: method, path: path, status: status, label: label, message: message, processed: true})
+}
+
+// Requests 已收到的请求（"METHOD /path"，不含/api/v4前缀和查询参数）
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// serve 路由请求
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, basePath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+path)
+
+	if !publicPath(path) && (r.Header.Get("KEY") == "" || r.Header.Get("SIGN") == "") {
+		writeError(w, http.StatusUnauthorized, "INVALID_KEY", "Invalid key provided")
+		return
+	}
+	for i, f := range s.failures {
+		if f.method == r.Method && f.path == path {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			if f.processed {
+				s.route(httptest.NewRecorder(), r, path)
+			}
+			writeError(w, f.status, f.label, f.message)
+			return
+		}
+	}
+	s.route(w, r, path)
+}
+
+// route 按路径处理请求（调用方持有mu）
+func (s *Server) route(w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, settlePrefix), "/"), "/")
+	futures := strings.HasPrefix(path, settlePrefix+"/")
+
+	switch {
+	case path == "/unified/unified_mode" && r.Method == http.MethodGet:
+		writeJSON(w, map[string]string{"mode": "classic"})
+	case path == "/wallet/fee" && r.Method == http.MethodGet:
+		writeJSON(w, gateapi.TradeFee{FuturesMakerFee: formatFloat(s.makerFee), FuturesTakerFee: formatFloat(s.takerFee)})
+	case !futures && strings.HasPrefix(path, "/futures/") && r.Method == http.MethodGet:
+		s.serveSettleBook(w, path)
+	case !futures:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unsupported path "+path)
+
+	case parts[0] == "accounts" && r.Method == http.MethodGet:
+		writeJSON(w, s.account())
+	case parts[0] == "fee" && r.Method == http.MethodGet:
+		fees := make(map[string]map[string]string)
+		for name := range s.contracts {
+			fees[name] = map[string]string{"maker_fee": formatFloat(s.makerFee), "taker_fee": formatFloat(s.takerFee)}
+		}
+		writeJSON(w, fees)
+	case parts[0] == "contracts" && len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, s.contractList())
+	case parts[0] == "contracts" && len(parts) == 2 && r.Method == http.MethodGet:
+		c, ok := s.contract(parts[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, "CONTRACT_NOT_FOUND", "contract not found")
+			return
+		}
+		writeJSON(w, c)
+	case parts[0] == "risk_limit_tiers" && r.Method == http.MethodGet:
+		contract := query.Get("contract")
+		tiers := make([]map[string]interface{}, 0, len(s.riskTiers[contract]))
+		for i, tier := range s.riskTiers[contract] {
+			tiers = append(tiers, map[string]interface{}{
+				"tier":             i + 1,
+				"contract":         contract,
+				"risk_limit":       formatFloat(tier.RiskLimit),
+				"initial_rate":     formatFloat(tier.InitialRate),
+				"maintenance_rate": formatFloat(tier.MaintenanceRate),
+				"leverage_max":     strconv.Itoa(tier.LeverageMax),
+			})
+		}
+		writeJSON(w, tiers)
+	case parts[0] == "tickers" && r.Method == http.MethodGet:
+		writeJSON(w, s.tickers(query.Get("contract")))
+	case parts[0] == "order_book" && r.Method == http.MethodGet:
+		s.serveOrderBook(w, query.Get("contract"))
+
+	case parts[0] == "positions" && len(parts) == 1 && r.Method == http.MethodGet:
+		positions := make([]gateapi.Position, 0, len(s.positions))
+		for _, pos := range s.positions {
+			if pos.Size != 0 {
+				positions = append(positions, s.positionView(pos))
+			}
+		}
+		sort.Slice(positions, func(i, j int) bool { return positions[i].Contract < positions[j].Contract })
+		writeJSON(w, positions)
+	case parts[0] == "positions" && len(parts) == 2 && r.Method == http.MethodGet:
+		pos, ok := s.positions[parts[1]]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "POSITION_NOT_FOUND", "position not found")
+			return
+		}
+		writeJSON(w, s.positionView(pos))
+	case parts[0] == "positions" && len(parts) == 3 && parts[2] == "leverage" && r.Method == http.MethodPost:
+		s.serveLeverage(w, parts[1], query.Get("leverage"), query.Get("cross_leverage_limit"))
+	case parts[0] == "positions" && len(parts) == 3 && parts[2] == "risk_limit" && r.Method == http.MethodPost:
+		s.serveRiskLimit(w, parts[1], query.Get("risk_limit"))
+
+	case parts[0] == "orders" && len(parts) == 1 && r.Method == http.MethodPost:
+		var order gateapi.FuturesOrder
+		if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+			return
+		}
+		s.serveCreateOrder(w, order)
+	case parts[0] == "orders" && len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, s.listOrders(query.Get("contract"), query.Get("status")))
+	case parts[0] == "orders" && len(parts) == 1 && r.Method == http.MethodDelete:
+		var cancelled []gateapi.FuturesOrder
+		for _, o := range s.sortedOrders() {
+			if o.Contract == query.Get("contract") && o.Status == "open" {
+				s.cancel(o)
+				cancelled = append(cancelled, *o)
+			}
+		}
+		writeJSON(w, nonNil(cancelled))
+	case parts[0] == "orders" && len(parts) == 2:
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+		o, ok := s.orders[id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			if o.Status != "open" {
+				writeError(w, http.StatusBadRequest, "ORDER_FINISHED", "order finished")
+				return
+			}
+			s.cancel(o)
+		}
+		writeJSON(w, o)
+
+	case parts[0] == "price_orders" && len(parts) == 1 && r.Method == http.MethodPost:
+		var trigger gateapi.FuturesPriceTriggeredOrder
+		if err := json.NewDecoder(r.Body).Decode(&trigger); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_REQUEST_BODY", err.Error())
+			return
+		}
+		s.serveCreateTrigger(w, trigger)
+	case parts[0] == "price_orders" && len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, s.openTriggers())
+	case parts[0] == "price_orders" && len(parts) == 2 && r.Method == http.MethodDelete:
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+		trigger, ok := s.triggers[id]
+		if !ok || trigger.Status != "open" {
+			writeError(w, http.StatusNotFound, "ORDER_NOT_FOUND", "order not found")
+			return
+		}
+		trigger.Status, trigger.FinishAs = "finished", "cancelled"
+		trigger.FinishTime = now()
+		writeJSON(w, trigger)
+
+	case parts[0] == "my_trades" && r.Method == http.MethodGet:
+		writeJSON(w, s.listTrades(query))
+	case parts[0] == "account_book" && r.Method == http.MethodGet:
+		writeJSON(w, s.listBook(query))
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unsupported path "+path)
+	}
+}
+
+// serveLeverage 切换杠杆（leverage为0表示全仓，此时cross_leverage_limit为全仓杠杆上限；冷却期内返回与Gate一致的冷却错误消息）
+func (s *Server) serveLeverage(w http.ResponseWriter, contract, leverage, crossLimit string) {
+	if _, ok := s.contracts[contract]; !ok {
+		writeError(w, http.StatusNotFound, "CONTRACT_NOT_FOUND", "contract not found")
+		return
+	}
+	lev, err := strconv.Atoi(leverage)
+	if err != nil || lev < 0 || lev > 100 {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "invalid leverage "+leverage)
+		return
+	}
+	if lev == 0 {
+		if limit, err := strconv.Atoi(crossLimit); err != nil || limit < 1 || limit > 100 {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "invalid cross_leverage_limit "+crossLimit)
+			return
+		}
+	} else {
+		crossLimit = ""
+	}
+	pos := s.position(contract)
+	if pos.Leverage == leverage && pos.CrossLeverageLimit == crossLimit {
+		writeJSON(w, s.positionView(pos))
+		return
+	}
+	if changed, ok := s.leverageChanged[contract]; ok && time.Since(changed) < s.leverageCooldown {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "leverage change too frequent, please wait for cool down")
+		return
+	}
+	pos.Leverage = leverage
+	pos.CrossLeverageLimit = crossLimit
+	s.leverageChanged[contract] = time.Now()
+	writeJSON(w, s.positionView(pos))
+}
+
+// serveRiskLimit 设置风险限额（设置了档位时只接受档位上限值）
+func (s *Server) serveRiskLimit(w http.ResponseWriter, contract, riskLimit string) {
+	if _, ok := s.contracts[contract]; !ok {
+		writeError(w, http.StatusNotFound, "CONTRACT_NOT_FOUND", "contract not found")
+		return
+	}
+	limit, err := strconv.ParseFloat(riskLimit, 64)
+	if err != nil || limit <= 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "invalid risk_limit "+riskLimit)
+		return
+	}
+	if tiers := s.riskTiers[contract]; len(tiers) > 0 {
+		valid := false
+		for _, tier := range tiers {
+			valid = valid || tier.RiskLimit == limit
+		}
+		if !valid {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "risk_limit not match any tier "+riskLimit)
+			return
+		}
+	}
+	pos := s.position(contract)
+	pos.RiskLimit = formatFloat(limit)
+	writeJSON(w, s.positionView(pos))
+}
+
+// serveCreateOrder 下单：市价单（price为0）按最新价全部成交；只挂单（poc）会立即成交时拒绝；限价单挂单等待FillOrder
+func (s *Server) serveCreateOrder(w http.ResponseWriter, order gateapi.FuturesOrder) {
+	last, ok := s.prices[order.Contract]
+	if !ok {
+		writeError(w, http.StatusNotFound, "CONTRACT_NOT_FOUND", "contract not found")
+		return
+	}
+	if order.Size == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "size must not be zero")
+		return
+	}
+	if c := s.contracts[order.Contract]; c != nil && c.OrderSizeMax > 0 && abs(order.Size) > c.OrderSizeMax {
+		writeError(w, http.StatusBadRequest, "ORDER_SIZE_TOO_LARGE", "order size exceeds order_size_max")
+		return
+	}
+
+	pos := s.positions[order.Contract]
+	reduce := order.ReduceOnly || order.IsReduceOnly
+	if reduce {
+		// 只减仓：方向必须与持仓相反，数量不超过持仓
+		if pos == nil || pos.Size == 0 || (pos.Size > 0) == (order.Size > 0) {
+			writeError(w, http.StatusBadRequest, "REDUCE_ONLY_FAIL", "reduce-only order would increase position")
+			return
+		}
+		if abs(order.Size) > abs(pos.Size) {
+			writeError(w, http.StatusBadRequest, "REDUCE_EXCEEDED", "reduce-only order size exceeds position size")
+			return
+		}
+	} else if required := s.margin(order.Contract, abs(order.Size), last, pos); required > s.available() {
+		writeError(w, http.StatusBadRequest, "INSUFFICIENT_AVAILABLE", "balance not enough")
+		return
+	}
+
+	price, _ := strconv.ParseFloat(order.Price, 64)
+	market := price == 0
+	crosses := !market && ((order.Size > 0 && price >= last) || (order.Size < 0 && price <= last))
+	if order.Tif == "poc" && crosses {
+		writeError(w, http.StatusBadRequest, "ORDER_POC", "order would match immediately as taker")
+		return
+	}
+
+	s.nextID++
+	o := order
+	o.Id = s.nextID
+	o.CreateTime = now()
+	o.Status = "open"
+	o.Left = o.Size
+	o.FillPrice = "0"
+	o.IsReduceOnly = reduce
+	if o.Tif == "" {
+		o.Tif = "gtc"
+	}
+	s.orders[o.Id] = &o
+
+	switch {
+	case market || crosses:
+		s.fill(&o, o.Size, last, "taker")
+	case o.Tif == "ioc":
+		s.cancel(&o) // 限价IOC未成交
+	}
+	writeJSON(w, o)
+}
+
+// serveCreateTrigger 创建价格条件单
+func (s *Server) serveCreateTrigger(w http.ResponseWriter, trigger gateapi.FuturesPriceTriggeredOrder) {
+	if _, ok := s.contracts[trigger.Initial.Contract]; !ok {
+		writeError(w, http.StatusNotFound, "CONTRACT_NOT_FOUND", "contract not found")
+		return
+	}
+	if price, err := strconv.ParseFloat(trigger.Trigger.Price, 64); err != nil || price <= 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "invalid trigger price")
+		return
+	}
+	if trigger.Trigger.Rule != 1 && trigger.Trigger.Rule != 2 {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAM_VALUE", "invalid trigger rule")
+		return
+	}
+	s.nextID++
+	t := trigger
+	t.Id = s.nextID
+	t.CreateTime = now()
+	t.Status = "open"
+	s.triggers[t.Id] = &t
+	writeJSON(w, gateapi.TriggerOrderResponse{Id: t.Id})
+}
+
+// serveOrderBook 一档盘口：买一/卖一为最新价上下各一个价格精度
+func (s *Server) serveOrderBook(w http.ResponseWriter, contract string) {
+	last, ok := s.prices[contract]
+	if !ok {
+		writeError(w, http.StatusNotFound, "CONTRACT_NOT_FOUND", "contract not found")
+		return
+	}
+	tick, _ := strconv.ParseFloat(s.contracts[contract].OrderPriceRound, 64)
+	writeJSON(w, gateapi.FuturesOrderBook{
+		Asks: []gateapi.FuturesOrderBookItem{{P: formatFloat(last + tick), S: 1000}},
+		Bids: []gateapi.FuturesOrderBookItem{{P: formatFloat(last - tick), S: 1000}},
+	})
+}
+
+// fill 成交size张（带方向）：更新委托、持仓、钱包余额，记录成交和手续费流水
+func (s *Server) fill(o *gateapi.FuturesOrder, size int64, price float64, role string) {
+	contract := o.Contract
+	multiplier := s.multiplier(contract)
+	pos := s.position(contract)
+	entry, _ := strconv.ParseFloat(pos.EntryPrice, 64)
+
+	switch {
+	case pos.Size == 0 || (pos.Size > 0) == (size > 0):
+		// 开仓/加仓：按数量加权计算开仓均价
+		total := abs(pos.Size) + abs(size)
+		entry = (entry*float64(abs(pos.Size)) + price*float64(abs(size))) / float64(total)
+		pos.Size += size
+	default:
+		// 减仓/平仓（超出部分反向开仓）
+		closed := min(abs(size), abs(pos.Size))
+		direction := 1.0
+		if pos.Size < 0 {
+			direction = -1
+		}
+		s.balance += (price - entry) * float64(closed) * multiplier * direction
+		pos.Size += size
+		if pos.Size == 0 {
+			entry = 0
+		} else if (pos.Size > 0) == (size > 0) {
+			entry = price
+		}
+	}
+	pos.EntryPrice = formatFloat(entry)
+
+	feeRate := s.takerFee
+	if role == "maker" {
+		feeRate = s.makerFee
+	}
+	fee := float64(abs(size)) * multiplier * price * feeRate
+	s.balance -= fee
+
+	filledBefore := abs(o.Size) - abs(o.Left)
+	avg, _ := strconv.ParseFloat(o.FillPrice, 64)
+	filled := filledBefore + abs(size)
+	o.FillPrice = formatFloat((avg*float64(filledBefore) + price*float64(abs(size))) / float64(filled))
+	o.Left -= size
+	if o.Left == 0 {
+		o.Status, o.FinishAs = "finished", "filled"
+		o.FinishTime = now()
+	}
+
+	s.nextID++
+	orderID := strconv.FormatInt(o.Id, 10)
+	s.trades = append(s.trades, gateapi.MyFuturesTrade{
+		Id: s.nextID, CreateTime: now(), Contract: contract, OrderId: orderID,
+		Size: size, Price: formatFloat(price), Role: role,
+	})
+	s.book = append(s.book, gateapi.FuturesAccountBook{
+		Time: now(), Change: formatFloat(-fee), Balance: formatFloat(s.balance),
+		Type: "fee", Text: contract + ":" + orderID,
+	})
+}
+
+// cancel 撤销委托的未成交部分
+func (s *Server) cancel(o *gateapi.FuturesOrder) {
+	o.Status = "finished"
+	o.FinishAs = "cancelled"
+	if o.Tif == "ioc" {
+		o.FinishAs = "ioc"
+	}
+	o.FinishTime = now()
+}
+
+// checkTriggers 最新价达到触发价时执行条件单（触发后按市价只减仓成交，无持仓时失败）
+func (s *Server) checkTriggers(contract string) {
+	price := s.prices[contract]
+	for _, t := range s.openTriggers() {
+		if t.Initial.Contract != contract {
+			continue
+		}
+		trigger, _ := strconv.ParseFloat(t.Trigger.Price, 64)
+		if (t.Trigger.Rule == 1 && price < trigger) || (t.Trigger.Rule == 2 && price > trigger) {
+			continue
+		}
+
+		stored := s.triggers[t.Id]
+		stored.Status, stored.FinishTime = "finished", now()
+		pos := s.positions[contract]
+		if pos == nil || pos.Size == 0 || (pos.Size > 0) == (t.Initial.Size > 0) {
+			stored.FinishAs, stored.Reason = "failed", "position not found"
+			continue
+		}
+		size := t.Initial.Size
+		if abs(size) > abs(pos.Size) {
+			size = -pos.Size
+		}
+		s.nextID++
+		o := &gateapi.FuturesOrder{Id: s.nextID, CreateTime: now(), Status: "open", Contract: contract,
+			Size: size, Left: size, Price: "0", FillPrice: "0", Tif: "ioc", IsReduceOnly: true, Text: "auto-trigger"}
+		s.orders[o.Id] = o
+		s.fill(o, size, price, "taker")
+		stored.FinishAs, stored.TradeId = "succeeded", s.nextID
+	}
+}
+
+// account 合约账户（total为钱包余额，available扣除持仓保证金和未实现亏损）
+func (s *Server) account() gateapi.FuturesAccount {
+	var positionMargin, unrealised float64
+	for contract, pos := range s.positions {
+		positionMargin += s.margin(contract, abs(pos.Size), s.prices[contract], nil)
+		unrealised += s.unrealised(contract, pos)
+	}
+	return gateapi.FuturesAccount{
+		Total:          formatFloat(s.balance),
+		UnrealisedPnl:  formatFloat(unrealised),
+		PositionMargin: formatFloat(positionMargin),
+		OrderMargin:    "0",
+		Available:      formatFloat(s.available()),
+		Currency:       "USDT",
+	}
+}
+
+// available 可用余额
+func (s *Server) available() float64 {
+	available := s.balance
+	for contract, pos := range s.positions {
+		available -= s.margin(contract, abs(pos.Size), s.prices[contract], nil)
+		if pnl := s.unrealised(contract, pos); pnl < 0 {
+			available += pnl
+		}
+	}
+	return available
+}
+
+// margin 指定张数需要的保证金（pos非nil时使用其杠杆，否则按合约已有持仓的杠杆）
+func (s *Server) margin(contract string, size int64, price float64, pos *gateapi.Position) float64 {
+	if pos == nil {
+		pos = s.positions[contract]
+	}
+	leverage := 10.0
+	if pos != nil {
+		if lev, err := strconv.ParseFloat(pos.Leverage, 64); err == nil && lev > 0 {
+			leverage = lev
+		} else if lev, err := strconv.ParseFloat(pos.CrossLeverageLimit, 64); err == nil && lev > 0 {
+			leverage = lev // 全仓按全仓杠杆上限计算
+		}
+	}
+	return float64(size) * s.multiplier(contract) * price / leverage
+}
+
+// unrealised 持仓的未实现盈亏（按最新价）
+func (s *Server) unrealised(contract string, pos *gateapi.Position) float64 {
+	entry, _ := strconv.ParseFloat(pos.EntryPrice, 64)
+	return (s.prices[contract] - entry) * float64(pos.Size) * s.multiplier(contract)
+}
+
+// position 合约持仓记录（不存在时创建空持仓，默认10倍杠杆）
+func (s *Server) position(contract string) *gateapi.Position {
+	pos, ok := s.positions[contract]
+	if !ok {
+		pos = &gateapi.Position{Contract: contract, Leverage: "10", EntryPrice: "0", Mode: "single"}
+		s.positions[contract] = pos
+	}
+	return pos
+}
+
+// positionView 返回给客户端的持仓（补充标记价、未实现盈亏、保证金和强平价）
+func (s *Server) positionView(pos *gateapi.Position) gateapi.Position {
+	view := *pos
+	price := s.prices[pos.Contract]
+	margin := s.margin(pos.Contract, abs(pos.Size), price, pos)
+	view.MarkPrice = formatFloat(price)
+	view.UnrealisedPnl = formatFloat(s.unrealised(pos.Contract, pos))
+	view.Margin = formatFloat(margin)
+	view.Value = formatFloat(float64(abs(pos.Size)) * s.multiplier(pos.Contract) * price)
+	view.LiqPrice = "0"
+	if entry, _ := strconv.ParseFloat(pos.EntryPrice, 64); pos.Size != 0 && entry > 0 {
+		lev, _ := strconv.ParseFloat(pos.Leverage, 64)
+		if pos.Size > 0 {
+			view.LiqPrice = formatFloat(entry * (1 - 1/lev))
+		} else {
+			view.LiqPrice = formatFloat(entry * (1 + 1/lev))
+		}
+	}
+	return view
+}
+
+// contract 合约信息（含最新价和标记价）
+func (s *Server) contract(name string) (gateapi.Contract, bool) {
+	c, ok := s.contracts[name]
+	if !ok {
+		return gateapi.Contract{}, false
+	}
+	view := *c
+	view.LastPrice = formatFloat(s.prices[name])
+	view.MarkPrice = view.LastPrice
+	return view, true
+}
+
+// contractList 所有合约，按名称排序
+func (s *Server) contractList() []gateapi.Contract {
+	names := make([]string, 0, len(s.contracts))
+	for name := range s.contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]gateapi.Contract, 0, len(names))
+	for _, name := range names {
+		c, _ := s.contract(name)
+		result = append(result, c)
+	}
+	return result
+}
+
+// tickers 行情（contract为空时返回所有合约）
+func (s *Server) tickers(contract string) []gateapi.FuturesTicker {
+	result := []gateapi.FuturesTicker{}
+	for _, c := range s.contractList() {
+		if contract == "" || c.Name == contract {
+			result = append(result, gateapi.FuturesTicker{Contract: c.Name, Last: c.LastPrice, MarkPrice: c.MarkPrice,
+				IndexPrice: c.LastPrice, FundingRate: "0.0001"})
+		}
+	}
+	return result
+}
+
+// sortedOrders 所有委托，按创建顺序
+func (s *Server) sortedOrders() []*gateapi.FuturesOrder {
+	result := make([]*gateapi.FuturesOrder, 0, len(s.orders))
+	for _, o := range s.orders {
+		result = append(result, o)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// listOrders 按合约和状态（open/finished）筛选委托
+func (s *Server) listOrders(contract, status string) []gateapi.FuturesOrder {
+	result := []gateapi.FuturesOrder{}
+	for _, o := range s.sortedOrders() {
+		if (contract == "" || o.Contract == contract) && (status == "" || o.Status == status) {
+			result = append(result, *o)
+		}
+	}
+	return result
+}
+
+// openTriggers 生效中的条件单，按创建顺序
+func (s *Server) openTriggers() []gateapi.FuturesPriceTriggeredOrder {
+	result := []gateapi.FuturesPriceTriggeredOrder{}
+	for _, t := range s.triggers {
+		if t.Status == "open" {
+			result = append(result, *t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// listTrades 成交记录（按时间倒序，支持limit/offset分页）
+func (s *Server) listTrades(query map[string][]string) []gateapi.MyFuturesTrade {
+	result := []gateapi.MyFuturesTrade{}
+	for i := len(s.trades) - 1; i >= 0; i-- {
+		result = append(result, s.trades[i])
+	}
+	return paginate(result, query)
+}
+
+// listBook 账户流水（按类型和起始时间筛选，时间倒序）
+func (s *Server) listBook(query map[string][]string) []gateapi.FuturesAccountBook {
+	from, _ := strconv.ParseFloat(first(query, "from"), 64)
+	kind := first(query, "type")
+	result := []gateapi.FuturesAccountBook{}
+	for i := len(s.book) - 1; i >= 0; i-- {
+		entry := s.book[i]
+		if (kind == "" || entry.Type == kind) && entry.Time >= from {
+			result = append(result, entry)
+		}
+	}
+	return paginate(result, query)
+}
+
+// paginate 按limit（默认100）和offset分页
+func paginate[T any](items []T, query map[string][]string) []T {
+	offset, _ := strconv.Atoi(first(query, "offset"))
+	limit, err := strconv.Atoi(first(query, "limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if offset >= len(items) {
+		return items[:0]
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
+
+// multiplier 每张合约的币数量
+func (s *Server) multiplier(contract string) float64 {
+	if c, ok := s.contracts[contract]; ok {
+		if m, err := strconv.ParseFloat(c.QuantoMultiplier, 64); err == nil && m > 0 {
+			return m
+		}
+	}
+	return 1
+}
+
+// publicPath 无需签名的公共行情接口
+func publicPath(path string) bool {
+	for _, prefix := range []string{"/contracts", "/tickers", "/order_book"} {
+		if strings.HasPrefix(path, settlePrefix+prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON 返回JSON响应
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Out-Time", strconv.FormatInt(time.Now().UnixMicro(), 10))
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 返回Gate格式的错误（label为空时返回纯文本）
+func writeError(w http.ResponseWriter, status int, label, message string) {
+	if label == "" {
+		http.Error(w, message, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"label": label, "message": message})
+}
+
+// nonNil nil切片返回空切片（序列化为[]而不是null）
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func first(query map[string][]string, key string) string {
+	if values := query[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func abs(v int64) int64 {
+	return int64(math.Abs(float64(v)))
+}
+
+func now() float64 {
+	return float64(time.Now().UnixMicro()) / 1e6
+}
+
+// serveSettleBook 其他结算币种的账户和持仓查询（/futures/{settle}/accounts、/futures/{settle}/positions，调用方持有mu）
+func (s *Server) serveSettleBook(w http.ResponseWriter, path string) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/futures/"), "/"), "/")
+	book, ok := s.settleBooks[parts[0]]
+	switch {
+	case !ok || len(parts) != 2:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unsupported path "+path)
+	case parts[1] == "accounts":
+		writeJSON(w, book.account)
+	case parts[1] == "positions":
+		positions := book.positions
+		if positions == nil {
+			positions = []gateapi.Position{}
+		}
+		writeJSON(w, positions)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unsupported path "+path)
+	}
+}
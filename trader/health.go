@@ -0,0 +1,165 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/risk"
+	"time"
+)
+
+const (
+	maxClockSkew     = 5 * time.Second  // 超过该时钟偏差视为未就绪（交易所签名对时间戳敏感）
+	maxCycleDuration = 10 * time.Minute // 单个周期运行超过该时长视为卡死（存活检查失败）
+)
+
+// ProbeResult 交易所连通性探测结果
+type ProbeResult struct {
+	Latency   time.Duration // 请求往返耗时
+	ClockSkew time.Duration // 本地时间 - 交易所时间
+	SkewKnown bool          // 交易所是否返回了服务器时间
+}
+
+// ExchangeProber 可探测交易所连通性和时钟偏差的交易器
+type ExchangeProber interface {
+	Probe() (ProbeResult, error)
+}
+
+// StreamStatusSource 使用WebSocket推送的交易器（报告连接状态）
+type StreamStatusSource interface {
+	StreamStatus() (connected bool, lastMessage time.Time)
+}
+
+// TraderHealth 单个trader的健康状态
+type TraderHealth struct {
+	TraderID          string     `json:"trader_id"`
+	Running           bool       `json:"running"`
+	Live              bool       `json:"live"`
+	Ready             bool       `json:"ready"`
+	ExchangeOK        bool       `json:"exchange_ok"`
+	ExchangeLatencyMs int64      `json:"exchange_latency_ms,omitempty"`
+	ClockSkewMs       *int64     `json:"clock_skew_ms,omitempty"`
+	Stream            string     `json:"stream"`                // connected/disconnected/not_used
+	BarStream         string     `json:"bar_stream"`            // K线推送（按K线收盘触发决策时）：connected/disconnected/not_used
+	Maintenance       bool       `json:"maintenance,omitempty"` // 交易所维护中（暂停下单）
+	Degradation       string     `json:"degradation,omitempty"` // 数据源故障降级模式（rest_polling/exits_only/rules_only，正常或未启用时为空）
+	LastCycleAt       *time.Time `json:"last_cycle_at,omitempty"`
+	LastCycleError    string     `json:"last_cycle_error,omitempty"`
+	CycleRunningSec   float64    `json:"cycle_running_sec,omitempty"`
+	Problems          []string   `json:"problems,omitempty"`
+}
+
+// cycleStarted 记录周期开始时间（用于检测卡死的周期）
+func (at *AutoTrader) cycleStarted() {
+	at.healthMu.Lock()
+	at.cycleStartedAt = time.Now()
+	at.healthMu.Unlock()
+}
+
+// cycleFinished 记录周期结束，AI决策周期同时记录最近一次成功时间或错误
+func (at *AutoTrader) cycleFinished(decision bool, err error) {
+	at.healthMu.Lock()
+	defer at.healthMu.Unlock()
+	at.cycleStartedAt = time.Time{}
+	if !decision {
+		return
+	}
+	at.session.cycle(err)
+	if err != nil {
+		at.lastCycleError = err.Error()
+		return
+	}
+	at.lastCycleOK = time.Now()
+	at.lastCycleError = ""
+}
+
+// Liveness 存活检查（不访问交易所，只检查周期是否卡死）
+func (at *AutoTrader) Liveness() TraderHealth {
+	h := TraderHealth{TraderID: at.id, Running: at.isRunning, Live: true, Stream: "not_used", BarStream: "not_used"}
+
+	at.healthMu.Lock()
+	if !at.lastCycleOK.IsZero() {
+		last := at.lastCycleOK
+		h.LastCycleAt = &last
+	}
+	h.LastCycleError = at.lastCycleError
+	if !at.cycleStartedAt.IsZero() {
+		running := time.Since(at.cycleStartedAt)
+		h.CycleRunningSec = running.Seconds()
+		if running > maxCycleDuration {
+			h.Live = false
+			h.Problems = append(h.Problems, fmt.Sprintf("周期已运行%.0f秒未结束", running.Seconds()))
+		}
+	}
+	at.healthMu.Unlock()
+
+	if source, ok := at.trader.(StreamStatusSource); ok && at.positionStreaming.Load() {
+		connected, _ := source.StreamStatus()
+		h.Stream = "disconnected"
+		if connected {
+			h.Stream = "connected"
+		}
+	}
+	if mode, _, reason := at.degradation.current(); mode != risk.ModeNormal {
+		h.Degradation = mode
+		h.Problems = append(h.Problems, fmt.Sprintf("降级模式%s: %s", mode, reason))
+	}
+	if stream, ok := at.trader.(KlineStream); ok && at.barStreaming.Load() {
+		connected, _ := stream.KlineStreamStatus()
+		h.BarStream = "disconnected"
+		if connected {
+			h.BarStream = "connected"
+		}
+	}
+	return h
+}
+
+// Readiness 就绪检查：在存活检查基础上探测交易所连通性和时钟偏差
+func (at *AutoTrader) Readiness() TraderHealth {
+	h := at.Liveness()
+	h.Ready = h.Live && h.Running
+	if !h.Running {
+		h.Problems = append(h.Problems, "trader未运行")
+	}
+
+	var result ProbeResult
+	var err error
+	if prober, ok := at.trader.(ExchangeProber); ok {
+		result, err = prober.Probe()
+	} else {
+		start := time.Now()
+		_, err = at.trader.GetMarketPrice("BTCUSDT")
+		result.Latency = time.Since(start)
+	}
+
+	if err != nil {
+		h.Ready = false
+		h.Problems = append(h.Problems, fmt.Sprintf("交易所不可达: %v", err))
+	} else {
+		h.ExchangeOK = true
+		h.ExchangeLatencyMs = result.Latency.Milliseconds()
+	}
+
+	if result.SkewKnown {
+		skew := result.ClockSkew.Milliseconds()
+		h.ClockSkewMs = &skew
+		if result.ClockSkew > maxClockSkew || result.ClockSkew < -maxClockSkew {
+			h.Ready = false
+			h.Problems = append(h.Problems, fmt.Sprintf("本地时钟偏差%dms超过%v", skew, maxClockSkew))
+		}
+	}
+
+	if h.Stream == "disconnected" {
+		h.Ready = false
+		h.Problems = append(h.Problems, "WebSocket推送已断开")
+	}
+	if h.BarStream == "disconnected" {
+		h.Ready = false
+		h.Problems = append(h.Problems, "K线推送已断开，AI决策暂停")
+	}
+
+	if at.maintenance.active() {
+		h.Maintenance = true
+		h.Ready = false
+		h.Problems = append(h.Problems, "交易所维护中，暂停下单")
+	}
+	return h
+}
@@ -0,0 +1,110 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"nofx/market"
+	"nofx/notify"
+	"nofx/store"
+	"nofx/strategy"
+)
+
+// newHedgeManager 创建相关性对冲管理器（对冲腿的订单按被对冲的币种记录决策ID，重启后据此恢复每组对冲）
+func newHedgeManager(config strategy.HedgeConfig, trader Trader, orders *orderTracker) *strategy.HedgeManager {
+	var multiplier strategy.MultiplierSource
+	if spot, ok := trader.(strategy.SpotExecutor); ok {
+		multiplier = spot.GetContractMultiplier
+	}
+	executor := func(symbol string) strategy.Executor {
+		return newJournalingExecutor(withDecision(context.Background(), symbol, ""), orders, "hedge")
+	}
+	return strategy.NewHedgeManager(config, executor, trader.GetMarketPrice, hedgeCloses, multiplier)
+}
+
+// hedgeCloses 最近limit根已收盘K线的收盘价（多周期K线服务已跟踪的币种直接读取，否则通过REST查询）
+func hedgeCloses(symbol, interval string, limit int) ([]float64, error) {
+	klines, err := market.Klines(symbol, interval, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) > 0 {
+		klines = klines[:len(klines)-1] // 去掉正在形成的K线
+	}
+	closes := make([]float64, len(klines))
+	for i, k := range klines {
+		closes[i] = k.Close
+	}
+	return closes, nil
+}
+
+// Hedge 为symbol的持仓在配置的相关合约上建立反向对冲（数量按beta×ratio计算）
+func (at *AutoTrader) Hedge(symbol string, ratio float64) (*strategy.HedgePair, error) {
+	if at.config.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if !at.hedgeManager.Enabled() {
+		return nil, fmt.Errorf("[%s] 未启用hedge", at.name)
+	}
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	pair, err := at.hedgeManager.Hedge(symbol, ratio)
+	if err != nil {
+		return nil, err
+	}
+	at.notify(notify.KindInfo, pair.Symbol, "已建立对冲",
+		fmt.Sprintf("%s %s 对冲: %s %s %.0f张（beta %.2f，相关系数%.2f，比例%.2f，约%.2f USDT）", pair.Symbol, pair.Side,
+			pair.Instrument, pair.HedgeSide, pair.Contracts, pair.Beta, pair.Correlation, pair.Ratio, pair.HedgeUSD))
+	return pair, nil
+}
+
+// Unhedge 解除symbol的对冲（只平掉该组对冲对应的张数，持仓不变）
+func (at *AutoTrader) Unhedge(symbol string) (*strategy.HedgePair, error) {
+	if at.config.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	pair, err := at.hedgeManager.Unhedge(symbol)
+	if err != nil {
+		return nil, err
+	}
+	at.notify(notify.KindInfo, pair.Symbol, "已解除对冲",
+		fmt.Sprintf("%s 对冲腿 %s %s %.0f张已平仓", pair.Symbol, pair.Instrument, pair.HedgeSide, pair.Contracts))
+	return pair, nil
+}
+
+// restoreHedges 从交易日志恢复对冲合约持仓上的各组对冲（按决策ID即被对冲的币种汇总建仓和平仓成交）
+func (at *AutoTrader) restoreHedges(position *store.Position) {
+	opens, err := at.journal.ListStrategyFills(at.id, "hedge", position.Symbol, "open_"+position.Side, position.OpenedAt)
+	if err != nil {
+		reconcileLog.Warn("恢复对冲失败", "trader", at.id, "symbol", position.Symbol, "err", err)
+		return
+	}
+	closes, err := at.journal.ListStrategyFills(at.id, "hedge", position.Symbol, "close_"+position.Side, position.OpenedAt)
+	if err != nil {
+		reconcileLog.Warn("恢复对冲失败", "trader", at.id, "symbol", position.Symbol, "err", err)
+		return
+	}
+
+	contracts := make(map[string]float64)
+	var symbols []string
+	for _, o := range opens {
+		if _, seen := contracts[o.DecisionID]; !seen {
+			symbols = append(symbols, o.DecisionID)
+		}
+		contracts[o.DecisionID] += o.FilledQty
+	}
+	for _, o := range closes {
+		contracts[o.DecisionID] -= o.FilledQty
+	}
+	for _, symbol := range symbols {
+		if symbol == "" || contracts[symbol] <= 0 {
+			continue
+		}
+		at.hedgeManager.Restore(symbol, position.Symbol, position.Side, contracts[symbol], position.OpenedAt)
+		reconcileLog.Info("恢复对冲", "trader", at.id, "symbol", symbol, "instrument", position.Symbol,
+			"hedge_side", position.Side, "contracts", contracts[symbol])
+	}
+}
@@ -0,0 +1,200 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nofx/events"
+	"nofx/logging"
+	"strings"
+	"sync"
+)
+
+// hookLog 订单生命周期钩子日志
+var hookLog = logging.For("hook")
+
+// OrderIntent 即将提交的订单（下单前钩子的参数）
+type OrderIntent struct {
+	TraderID   string
+	Symbol     string
+	Action     string  // open_long/open_short/close_long/close_short
+	Side       string  // long/short
+	Quantity   float64 // 平仓时为0表示全部平仓
+	Price      float64 // 下单时的参考价
+	Leverage   int
+	Strategy   string // 下单来源（ai、webhook来源、dca、pyramid、manual等）
+	DecisionID string
+}
+
+// OrderFill 订单成交（成交后钩子的参数，部分成交时FilledQty小于Quantity）
+type OrderFill struct {
+	OrderIntent
+	OrderID   string
+	State     string
+	FilledQty float64
+	AvgPrice  float64
+}
+
+// PositionClose 持仓已平仓（平仓钩子的参数）
+type PositionClose struct {
+	TraderID   string
+	Symbol     string
+	Side       string
+	Strategy   string
+	Quantity   float64
+	EntryPrice float64 // 交易日志中没有开仓记录时为0
+	ExitPrice  float64
+	GrossPnL   float64
+	Reason     string // 主动平仓时为平仓动作（close_long/close_short），交易所侧平仓时为止损触发/止盈触发/外部平仓
+}
+
+// PreOrderHook 下单前钩子：返回错误时拒绝该订单（ErrHookRejected）
+// 在下单流程中同步调用（持有交易周期锁），耗时操作应放到独立的goroutine
+type PreOrderHook interface {
+	BeforeOrder(ctx context.Context, order OrderIntent) error
+}
+
+// PostFillHook 成交后钩子（如对冲、外部报表），在下单流程中同步调用
+type PostFillHook interface {
+	AfterFill(fill OrderFill)
+}
+
+// PositionClosedHook 平仓钩子（主动平仓和止损/止盈条件单在交易所触发都会调用），在下单或对账流程中同步调用
+type PositionClosedHook interface {
+	PositionClosed(position PositionClose)
+}
+
+// namedHook 已注册的钩子
+type namedHook struct {
+	name string
+	hook interface{}
+}
+
+// registeredHooks 全局注册的钩子（对所有trader生效，创建trader时读取）
+var registeredHooks struct {
+	mu    sync.Mutex
+	hooks []namedHook
+}
+
+// RegisterHook 注册对所有trader生效的订单生命周期钩子（通常在init中调用，需在创建trader之前）
+// hook需实现PreOrderHook、PostFillHook、PositionClosedHook中的至少一个；名称重复或未实现任何钩子时返回错误
+func RegisterHook(name string, hook interface{}) error {
+	if err := checkHook(name, hook); err != nil {
+		return err
+	}
+	registeredHooks.mu.Lock()
+	defer registeredHooks.mu.Unlock()
+	for _, h := range registeredHooks.hooks {
+		if h.name == name {
+			return fmt.Errorf("钩子%q重复注册", name)
+		}
+	}
+	registeredHooks.hooks = append(registeredHooks.hooks, namedHook{name: name, hook: hook})
+	return nil
+}
+
+// checkHook 检查钩子至少实现了一个钩子接口
+func checkHook(name string, hook interface{}) error {
+	_, pre := hook.(PreOrderHook)
+	_, fill := hook.(PostFillHook)
+	_, closed := hook.(PositionClosedHook)
+	if name == "" || !(pre || fill || closed) {
+		return fmt.Errorf("钩子%q未实现PreOrderHook、PostFillHook或PositionClosedHook", name)
+	}
+	return nil
+}
+
+// AddHook 为当前trader添加订单生命周期钩子（需在Run之前调用）
+func (at *AutoTrader) AddHook(name string, hook interface{}) error {
+	if err := checkHook(name, hook); err != nil {
+		return err
+	}
+	at.installHook(namedHook{name: name, hook: hook})
+	return nil
+}
+
+// installHooks 安装全局注册的钩子
+func (at *AutoTrader) installHooks() {
+	registeredHooks.mu.Lock()
+	hooks := append([]namedHook(nil), registeredHooks.hooks...)
+	registeredHooks.mu.Unlock()
+	for _, h := range hooks {
+		at.installHook(h)
+	}
+}
+
+// installHook 下单前钩子由订单跟踪器直接调用（需要能拒绝订单），成交和平仓钩子订阅事件总线
+func (at *AutoTrader) installHook(h namedHook) {
+	if hook, ok := h.hook.(PreOrderHook); ok {
+		at.orders.preOrder = append(at.orders.preOrder, namedPreOrderHook{name: h.name, hook: hook})
+	}
+	if hook, ok := h.hook.(PostFillHook); ok {
+		at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypeOrder}}, func(e events.Event) {
+			if e.Order.FilledQty <= 0 {
+				return // 已提交、被拒绝或未成交
+			}
+			fill := OrderFill{
+				OrderIntent: OrderIntent{TraderID: e.TraderID, Symbol: e.Symbol, Action: e.Order.Action, Side: e.Order.Side,
+					Quantity: e.Order.Quantity, Price: e.Order.Price, Leverage: e.Order.Leverage, Strategy: e.Order.Strategy,
+					DecisionID: e.Order.DecisionID},
+				OrderID: e.Order.OrderID, State: e.Order.State, FilledQty: e.Order.FilledQty, AvgPrice: e.Order.AvgPrice,
+			}
+			runHook(h.name, "after_fill", func() error { hook.AfterFill(fill); return nil })
+		})
+	}
+	if hook, ok := h.hook.(PositionClosedHook); ok {
+		at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypePosition}}, func(e events.Event) {
+			if e.Position.Change != "close" {
+				return
+			}
+			closed := PositionClose{TraderID: e.TraderID, Symbol: e.Symbol, Side: e.Position.Side, Strategy: e.Position.Strategy,
+				Quantity: e.Position.Quantity, EntryPrice: e.Position.EntryPrice, ExitPrice: e.Position.Price,
+				GrossPnL: e.Position.GrossPnL, Reason: e.Position.Reason}
+			runHook(h.name, "position_closed", func() error { hook.PositionClosed(closed); return nil })
+		})
+	}
+	hookLog.Info("已安装订单钩子", "trader", at.id, "hook", h.name)
+}
+
+// namedPreOrderHook 下单前钩子
+type namedPreOrderHook struct {
+	name string
+	hook PreOrderHook
+}
+
+// hookPanic 钩子panic（作为错误返回）
+type hookPanic struct {
+	value interface{}
+}
+
+func (p hookPanic) Error() string {
+	return fmt.Sprintf("panic: %v", p.value)
+}
+
+// runHook 调用钩子，panic时记录日志并作为hookPanic返回（钩子的故障不影响交易流程）
+func runHook(name, stage string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			hookLog.Error("钩子执行panic", "hook", name, "stage", stage, "panic", r)
+			err = hookPanic{value: r}
+		}
+	}()
+	return fn()
+}
+
+// beforeOrder 依次调用下单前钩子，任一钩子返回错误时拒绝订单
+// 钩子panic时拒绝开仓，平仓照常提交（避免钩子故障导致无法平仓）
+func (t *orderTracker) beforeOrder(ctx context.Context, order OrderIntent) error {
+	for _, h := range t.preOrder {
+		err := runHook(h.name, "before_order", func() error { return h.hook.BeforeOrder(ctx, order) })
+		if err == nil {
+			continue
+		}
+		var panicked hookPanic
+		if errors.As(err, &panicked) && strings.HasPrefix(order.Action, "close") {
+			continue
+		}
+		return fmt.Errorf("%w: %s: %v", ErrHookRejected, h.name, err)
+	}
+	return nil
+}
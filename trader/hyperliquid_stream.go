@@ -0,0 +1,241 @@
+package trader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"nofx/logging"
+	"nofx/symbols"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var hyperliquidLog = logging.For("hyperliquid")
+
+const (
+	hyperliquidWSPingInterval = 30 * time.Second // 应用层心跳间隔（服务端60秒未收到消息会断开）
+	hyperliquidWSReadTimeout  = 90 * time.Second // 超过该时间未收到任何消息视为断线
+	hyperliquidWSMaxBackoff   = time.Minute      // 重连退避上限
+)
+
+// hyperliquidIntervals 标准K线周期 → Hyperliquid K线周期（不支持10s）
+var hyperliquidIntervals = map[string]string{
+	"1m": "1m", "5m": "5m", "15m": "15m", "30m": "30m", "1h": "1h", "4h": "4h", "8h": "8h", "1d": "1d", "7d": "1w",
+}
+
+// hyperliquidStream Hyperliquid WebSocket推送（公共频道，不需要签名）
+type hyperliquidStream struct {
+	url string
+
+	streamStatus
+}
+
+// newHyperliquidStream 创建WebSocket推送（主网/测试网地址与REST接口对应）
+func newHyperliquidStream(testnet bool) *hyperliquidStream {
+	url := "wss://api.hyperliquid.xyz/ws"
+	if testnet {
+		url = "wss://api.hyperliquid-testnet.xyz/ws"
+	}
+	return &hyperliquidStream{url: url}
+}
+
+// hyperliquidWSRequest 订阅/心跳请求
+type hyperliquidWSRequest struct {
+	Method       string      `json:"method"` // subscribe/ping
+	Subscription interface{} `json:"subscription,omitempty"`
+}
+
+// hyperliquidWSMessage WebSocket推送（channel为subscriptionResponse/pong/error或订阅的频道）
+type hyperliquidWSMessage struct {
+	Channel string          `json:"channel"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// run 保持连接：断线后指数退避重连并重新订阅
+func (s *hyperliquidStream) run(stop <-chan struct{}, name string, subscriptions []interface{}, onMessage func(hyperliquidWSMessage)) {
+	backoff := time.Second
+	for {
+		subscribed, err := s.serve(stop, name, subscriptions, onMessage)
+		s.setConnected(false)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if subscribed {
+			backoff = time.Second
+		}
+		hyperliquidLog.Warn("WebSocket推送断开，准备重连", "stream", name, "err", err, "retry_in", backoff)
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > hyperliquidWSMaxBackoff {
+			backoff = hyperliquidWSMaxBackoff
+		}
+	}
+}
+
+// serve 建立一次连接并订阅，直到断线或stop关闭，返回是否全部订阅成功过
+func (s *hyperliquidStream) serve(stop <-chan struct{}, name string, subscriptions []interface{}, onMessage func(hyperliquidWSMessage)) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(s.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	for _, subscription := range subscriptions {
+		if err := conn.WriteJSON(hyperliquidWSRequest{Method: "subscribe", Subscription: subscription}); err != nil {
+			return false, fmt.Errorf("发送订阅请求失败: %w", err)
+		}
+	}
+
+	// 心跳；stop关闭时断开连接以结束读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(hyperliquidWSPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-stop:
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := conn.WriteJSON(hyperliquidWSRequest{Method: "ping"}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	confirmed := 0
+	for {
+		conn.SetReadDeadline(time.Now().Add(hyperliquidWSReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return confirmed >= len(subscriptions), err
+		}
+		s.touch()
+
+		var msg hyperliquidWSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Channel {
+		case "pong":
+		case "error":
+			return confirmed >= len(subscriptions), fmt.Errorf("订阅失败: %s", string(msg.Data))
+		case "subscriptionResponse":
+			if confirmed++; confirmed == len(subscriptions) {
+				s.setConnected(true)
+				hyperliquidLog.Info("WebSocket推送已订阅", "stream", name)
+			}
+		default:
+			onMessage(msg)
+		}
+	}
+}
+
+// hyperliquidCandlePush candle频道推送的当前K线（t/T为开盘/收盘毫秒时间戳，s为币种，价格和成交量为字符串）
+// T必须单独声明：JSON字段名匹配不区分大小写，否则T会覆盖t
+type hyperliquidCandlePush struct {
+	OpenTime  int64  `json:"t"`
+	CloseTime int64  `json:"T"`
+	Coin      string `json:"s"`
+	Open      string `json:"o"`
+	High      string `json:"h"`
+	Low       string `json:"l"`
+	Close     string `json:"c"`
+	Volume    string `json:"v"`
+}
+
+// bar 转换为K线（symbol为标准格式，interval为标准周期，kPEPE等按单币换算价格和成交量）
+func (p hyperliquidCandlePush) bar(interval string) (BarClose, error) {
+	duration, err := BarDuration(interval)
+	if err != nil {
+		return BarClose{}, err
+	}
+	open := time.UnixMilli(p.OpenTime)
+	symbol, scale := symbols.FromVenue(symbols.Hyperliquid, p.Coin)
+	bar := BarClose{Symbol: symbol, Interval: interval, OpenTime: open, CloseTime: open.Add(duration)}
+	for _, field := range []struct {
+		raw string
+		dst *float64
+	}{{p.Open, &bar.Open}, {p.High, &bar.High}, {p.Low, &bar.Low}, {p.Close, &bar.Close}, {p.Volume, &bar.Volume}} {
+		if *field.dst, err = strconv.ParseFloat(field.raw, 64); err != nil {
+			return BarClose{}, fmt.Errorf("K线数据无效(%s): %w", p.Coin, err)
+		}
+	}
+	bar.Open, bar.High, bar.Low, bar.Close = bar.Open/scale, bar.High/scale, bar.Low/scale, bar.Close/scale
+	bar.Volume *= scale
+	return bar, nil
+}
+
+// parseHyperliquidCandles 解析candle推送（单根K线对象或K线数组）
+func parseHyperliquidCandles(data json.RawMessage) ([]hyperliquidCandlePush, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var candles []hyperliquidCandlePush
+		err := json.Unmarshal(data, &candles)
+		return candles, err
+	}
+	var candle hyperliquidCandlePush
+	if err := json.Unmarshal(data, &candle); err != nil {
+		return nil, err
+	}
+	return []hyperliquidCandlePush{candle}, nil
+}
+
+// StartKlineStream 订阅K线推送（candle，公共频道），每根K线收盘时回调
+// Hyperliquid不推送收盘标记，下一根K线的第一条推送到达时上一根视为收盘
+func (t *HyperliquidTrader) StartKlineStream(stop <-chan struct{}, symbols []string, interval string, handler func(BarClose)) error {
+	hlInterval, ok := hyperliquidIntervals[interval]
+	if !ok {
+		return fmt.Errorf("Hyperliquid不支持%s K线推送", interval)
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("K线推送至少需要一个币种")
+	}
+	subscriptions := make([]interface{}, 0, len(symbols))
+	for _, symbol := range symbols {
+		coin := convertSymbolToHyperliquid(symbol)
+		if _, err := t.assetInfo(coin); err != nil {
+			return err
+		}
+		subscriptions = append(subscriptions, map[string]string{"type": "candle", "coin": coin, "interval": hlInterval})
+	}
+
+	tracker := newBarTracker()
+	go t.klineStream.run(stop, "candle_"+interval, subscriptions, func(msg hyperliquidWSMessage) {
+		if msg.Channel != "candle" {
+			return
+		}
+		candles, err := parseHyperliquidCandles(msg.Data)
+		if err != nil {
+			hyperliquidLog.Warn("解析K线推送失败", "err", err)
+			return
+		}
+		for _, candle := range candles {
+			bar, err := candle.bar(interval)
+			if err != nil {
+				hyperliquidLog.Warn("解析K线推送失败", "err", err)
+				continue
+			}
+			for _, closed := range tracker.observe(candle.Coin, bar, false) {
+				handler(closed)
+			}
+		}
+	})
+	return nil
+}
+
+// KlineStreamStatus K线推送连接状态
+func (t *HyperliquidTrader) KlineStreamStatus() (bool, time.Time) {
+	return t.klineStream.status()
+}
@@ -3,9 +3,11 @@ package trader
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"nofx/symbols"
 	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/sonirico/go-hyperliquid"
@@ -17,6 +19,11 @@ type HyperliquidTrader struct {
 	ctx        context.Context
 	walletAddr string
 	meta       *hyperliquid.Meta // 缓存meta信息（包含精度等）
+	testnet    bool
+
+	klineStream *hyperliquidStream // K线推送（公共频道）
+
+	stopLimitOffsetPct float64 // 止损限价偏移（%），0表示触发后市价成交
 }
 
 // NewHyperliquidTrader 创建Hyperliquid交易器
@@ -54,7 +61,7 @@ func NewHyperliquidTrader(privateKeyHex string, walletAddr string, testnet bool)
 		nil,        // SpotMeta will be fetched automatically
 	)
 
-	log.Printf("✓ Hyperliquid交易器初始化成功 (testnet=%v, wallet=%s)", testnet, walletAddr)
+	hyperliquidLog.Info("Hyperliquid交易器初始化成功", "testnet", testnet, "wallet", walletAddr)
 
 	// 获取meta信息（包含精度等配置）
 	meta, err := exchange.Info().Meta(ctx)
@@ -63,21 +70,23 @@ func NewHyperliquidTrader(privateKeyHex string, walletAddr string, testnet bool)
 	}
 
 	return &HyperliquidTrader{
-		exchange:   exchange,
-		ctx:        ctx,
-		walletAddr: walletAddr,
-		meta:       meta,
+		exchange:    exchange,
+		ctx:         ctx,
+		walletAddr:  walletAddr,
+		meta:        meta,
+		testnet:     testnet,
+		klineStream: newHyperliquidStream(testnet),
 	}, nil
 }
 
 // GetBalance 获取账户余额
 func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
-	log.Printf("🔄 正在调用Hyperliquid API获取账户余额...")
+	hyperliquidLog.Debug("调用Hyperliquid API获取账户余额")
 
 	// 获取账户状态
 	accountState, err := t.exchange.Info().UserState(t.ctx, t.walletAddr)
 	if err != nil {
-		log.Printf("❌ Hyperliquid API调用失败: %v", err)
+		hyperliquidLog.Error("获取账户信息失败", "err", err)
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
 	}
 
@@ -86,8 +95,7 @@ func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
 
 	// 🔍 调试：打印API返回的完整CrossMarginSummary结构
 	summaryJSON, _ := json.MarshalIndent(accountState.MarginSummary, "  ", "  ")
-	log.Printf("🔍 [DEBUG] Hyperliquid API CrossMarginSummary完整数据:")
-	log.Printf("%s", string(summaryJSON))
+	hyperliquidLog.Debug("CrossMarginSummary完整数据", "summary", string(summaryJSON))
 
 	accountValue, _ := strconv.ParseFloat(accountState.MarginSummary.AccountValue, 64)
 	totalMarginUsed, _ := strconv.ParseFloat(accountState.MarginSummary.TotalMarginUsed, 64)
@@ -111,12 +119,8 @@ func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"] = accountValue - totalMarginUsed   // 可用余额（总净值 - 占用保证金）
 	result["totalUnrealizedProfit"] = totalUnrealizedPnl          // 未实现盈亏
 
-	log.Printf("✓ Hyperliquid 账户: 总净值=%.2f (钱包%.2f+未实现%.2f), 可用=%.2f, 保证金占用=%.2f",
-		accountValue,
-		walletBalanceWithoutUnrealized,
-		totalUnrealizedPnl,
-		result["availableBalance"],
-		totalMarginUsed)
+	hyperliquidLog.Debug("账户余额", "equity", accountValue, "wallet", walletBalanceWithoutUnrealized,
+		"unrealized_pnl", totalUnrealizedPnl, "available", result["availableBalance"], "margin_used", totalMarginUsed)
 
 	return result, nil
 }
@@ -144,9 +148,10 @@ func (t *HyperliquidTrader) GetPositions() ([]map[string]interface{}, error) {
 
 		posMap := make(map[string]interface{})
 
-		// 标准化symbol格式（Hyperliquid使用如"BTC"，我们转换为"BTCUSDT"）
-		symbol := position.Coin + "USDT"
+		// 标准化symbol格式（Hyperliquid使用如"BTC"，我们转换为"BTCUSDT"；kPEPE等按单币换算数量和价格）
+		symbol, scale := symbols.FromVenue(symbols.Hyperliquid, position.Coin)
 		posMap["symbol"] = symbol
+		posAmt *= scale
 
 		// 持仓数量和方向
 		if posAmt > 0 {
@@ -161,9 +166,11 @@ func (t *HyperliquidTrader) GetPositions() ([]map[string]interface{}, error) {
 		var entryPrice, liquidationPx float64
 		if position.EntryPx != nil {
 			entryPrice, _ = strconv.ParseFloat(*position.EntryPx, 64)
+			entryPrice /= scale
 		}
 		if position.LiquidationPx != nil {
 			liquidationPx, _ = strconv.ParseFloat(*position.LiquidationPx, 64)
+			liquidationPx /= scale
 		}
 
 		positionValue, _ := strconv.ParseFloat(position.PositionValue, 64)
@@ -191,14 +198,24 @@ func (t *HyperliquidTrader) GetPositions() ([]map[string]interface{}, error) {
 func (t *HyperliquidTrader) SetLeverage(symbol string, leverage int) error {
 	// Hyperliquid symbol格式（去掉USDT后缀）
 	coin := convertSymbolToHyperliquid(symbol)
+	asset, err := t.assetInfo(coin)
+	if err != nil {
+		return err
+	}
+
+	// 不超过该币种允许的最大杠杆（超过时交易所拒绝）
+	if asset.MaxLeverage > 0 && leverage > asset.MaxLeverage {
+		hyperliquidLog.Warn("杠杆超过币种最大杠杆，按最大杠杆设置", "symbol", symbol, "requested", leverage, "max_leverage", asset.MaxLeverage)
+		leverage = asset.MaxLeverage
+	}
 
 	// 调用UpdateLeverage (leverage int, name string, isCross bool)
-	_, err := t.exchange.UpdateLeverage(t.ctx, leverage, coin, false) // false = 逐仓模式
+	_, err = t.exchange.UpdateLeverage(t.ctx, leverage, coin, false) // false = 逐仓模式
 	if err != nil {
 		return fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	hyperliquidLog.Info("杠杆已切换", "symbol", symbol, "leverage", leverage)
 	return nil
 }
 
@@ -206,7 +223,7 @@ func (t *HyperliquidTrader) SetLeverage(symbol string, leverage int) error {
 func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败: %v", err)
+		hyperliquidLog.Warn("取消旧委托单失败", "symbol", symbol, "err", err)
 	}
 
 	// 设置杠杆
@@ -214,22 +231,24 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 		return nil, err
 	}
 
-	// Hyperliquid symbol格式
-	coin := convertSymbolToHyperliquid(symbol)
+	// Hyperliquid symbol格式（kPEPE等以1000个为单位的币种按合约单位换算数量和价格）
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	coin := m.Venue
+	quantity /= m.Scale
 
 	// 获取当前价格（用于市价单）
-	price, err := t.GetMarketPrice(symbol)
+	price, err := t.getMidPrice(coin)
 	if err != nil {
 		return nil, err
 	}
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	hyperliquidLog.Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 1.01)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*1.01, aggressivePrice)
+	hyperliquidLog.Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*1.01, "rounded", aggressivePrice)
 
 	// 创建市价买入订单（使用IOC limit order with aggressive price）
 	order := hyperliquid.CreateOrderRequest{
@@ -245,18 +264,16 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 		ReduceOnly: false,
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	status, err := t.exchange.Order(t.ctx, order, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开多仓失败: %w", err)
+	}
+	result, err := hyperliquidIOCFill(symbol, status, roundedQuantity, m.Scale)
 	if err != nil {
 		return nil, fmt.Errorf("开多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
-
-	result := make(map[string]interface{})
-	result["orderId"] = 0 // Hyperliquid没有返回order ID
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
-
+	hyperliquidLog.Info("开多仓成功", "symbol", symbol, "quantity", roundedQuantity*m.Scale, "filled", result["filledQty"])
 	return result, nil
 }
 
@@ -264,7 +281,7 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败: %v", err)
+		hyperliquidLog.Warn("取消旧委托单失败", "symbol", symbol, "err", err)
 	}
 
 	// 设置杠杆
@@ -272,22 +289,24 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 		return nil, err
 	}
 
-	// Hyperliquid symbol格式
-	coin := convertSymbolToHyperliquid(symbol)
+	// Hyperliquid symbol格式（kPEPE等以1000个为单位的币种按合约单位换算数量和价格）
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	coin := m.Venue
+	quantity /= m.Scale
 
 	// 获取当前价格
-	price, err := t.GetMarketPrice(symbol)
+	price, err := t.getMidPrice(coin)
 	if err != nil {
 		return nil, err
 	}
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	hyperliquidLog.Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 0.99)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*0.99, aggressivePrice)
+	hyperliquidLog.Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*0.99, "rounded", aggressivePrice)
 
 	// 创建市价卖出订单
 	order := hyperliquid.CreateOrderRequest{
@@ -303,18 +322,16 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 		ReduceOnly: false,
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	status, err := t.exchange.Order(t.ctx, order, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开空仓失败: %w", err)
+	}
+	result, err := hyperliquidIOCFill(symbol, status, roundedQuantity, m.Scale)
 	if err != nil {
 		return nil, fmt.Errorf("开空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
-
-	result := make(map[string]interface{})
-	result["orderId"] = 0
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
-
+	hyperliquidLog.Info("开空仓成功", "symbol", symbol, "quantity", roundedQuantity*m.Scale, "filled", result["filledQty"])
 	return result, nil
 }
 
@@ -335,26 +352,28 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的多仓: %w", symbol, ErrPositionNotFound)
 		}
 	}
 
-	// Hyperliquid symbol格式
-	coin := convertSymbolToHyperliquid(symbol)
+	// Hyperliquid symbol格式（kPEPE等以1000个为单位的币种按合约单位换算数量和价格）
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	coin := m.Venue
+	quantity /= m.Scale
 
 	// 获取当前价格
-	price, err := t.GetMarketPrice(symbol)
+	price, err := t.getMidPrice(coin)
 	if err != nil {
 		return nil, err
 	}
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	hyperliquidLog.Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 0.99)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*0.99, aggressivePrice)
+	hyperliquidLog.Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*0.99, "rounded", aggressivePrice)
 
 	// 创建平仓订单（卖出 + ReduceOnly）
 	order := hyperliquid.CreateOrderRequest{
@@ -370,23 +389,21 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 		ReduceOnly: true, // 只平仓，不开新仓
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	status, err := t.exchange.Order(t.ctx, order, nil)
+	if err != nil {
+		return nil, fmt.Errorf("平多仓失败: %w", err)
+	}
+	result, err := hyperliquidIOCFill(symbol, status, roundedQuantity, m.Scale)
 	if err != nil {
 		return nil, fmt.Errorf("平多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
+	hyperliquidLog.Info("平多仓成功", "symbol", symbol, "quantity", roundedQuantity*m.Scale, "filled", result["filledQty"])
 
 	// 平仓后取消该币种的所有挂单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		hyperliquidLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
-
-	result := make(map[string]interface{})
-	result["orderId"] = 0
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
-
 	return result, nil
 }
 
@@ -407,26 +424,28 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 		}
 
 		if quantity == 0 {
-			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+			return nil, fmt.Errorf("没有找到 %s 的空仓: %w", symbol, ErrPositionNotFound)
 		}
 	}
 
-	// Hyperliquid symbol格式
-	coin := convertSymbolToHyperliquid(symbol)
+	// Hyperliquid symbol格式（kPEPE等以1000个为单位的币种按合约单位换算数量和价格）
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	coin := m.Venue
+	quantity /= m.Scale
 
 	// 获取当前价格
-	price, err := t.GetMarketPrice(symbol)
+	price, err := t.getMidPrice(coin)
 	if err != nil {
 		return nil, err
 	}
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	hyperliquidLog.Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 1.01)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*1.01, aggressivePrice)
+	hyperliquidLog.Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*1.01, "rounded", aggressivePrice)
 
 	// 创建平仓订单（买入 + ReduceOnly）
 	order := hyperliquid.CreateOrderRequest{
@@ -442,23 +461,21 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 		ReduceOnly: true,
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	status, err := t.exchange.Order(t.ctx, order, nil)
+	if err != nil {
+		return nil, fmt.Errorf("平空仓失败: %w", err)
+	}
+	result, err := hyperliquidIOCFill(symbol, status, roundedQuantity, m.Scale)
 	if err != nil {
 		return nil, fmt.Errorf("平空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
+	hyperliquidLog.Info("平空仓成功", "symbol", symbol, "quantity", roundedQuantity*m.Scale, "filled", result["filledQty"])
 
 	// 平仓后取消该币种的所有挂单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		hyperliquidLog.Warn("取消挂单失败", "symbol", symbol, "err", err)
 	}
-
-	result := make(map[string]interface{})
-	result["orderId"] = 0
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
-
 	return result, nil
 }
 
@@ -477,19 +494,93 @@ func (t *HyperliquidTrader) CancelAllOrders(symbol string) error {
 		if order.Coin == coin {
 			_, err := t.exchange.Cancel(t.ctx, coin, order.Oid)
 			if err != nil {
-				log.Printf("  ⚠ 取消订单失败 (oid=%d): %v", order.Oid, err)
+				hyperliquidLog.Warn("取消订单失败", "symbol", symbol, "oid", order.Oid, "err", err)
 			}
 		}
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	hyperliquidLog.Info("已取消所有挂单", "symbol", symbol)
 	return nil
 }
 
-// GetMarketPrice 获取市场价格
-func (t *HyperliquidTrader) GetMarketPrice(symbol string) (float64, error) {
+// GetOpenOrders 获取指定币种未成交的普通委托单（数量单位为币）
+func (t *HyperliquidTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
 	coin := convertSymbolToHyperliquid(symbol)
+	orders, err := t.exchange.Info().FrontendOpenOrders(t.ctx, t.walletAddr)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
 
+	var result []OpenOrder
+	for _, order := range orders {
+		if order.Coin != coin || order.IsTrigger {
+			continue
+		}
+		symbol, scale := symbols.FromVenue(symbols.Hyperliquid, order.Coin)
+		quantity := order.Sz * scale
+		if order.Side == hyperliquid.OrderSideAsk {
+			quantity = -quantity
+		}
+		result = append(result, OpenOrder{
+			OrderID:  strconv.FormatInt(order.Oid, 10),
+			Symbol:   symbol,
+			Quantity: quantity,
+			Price:    order.LimitPx / scale,
+		})
+	}
+	return result, nil
+}
+
+// GetOpenTriggerOrders 获取所有生效中的止损/止盈条件单
+func (t *HyperliquidTrader) GetOpenTriggerOrders() ([]TriggerOrder, error) {
+	orders, err := t.exchange.Info().FrontendOpenOrders(t.ctx, t.walletAddr)
+	if err != nil {
+		return nil, fmt.Errorf("获取条件单失败: %w", err)
+	}
+
+	var result []TriggerOrder
+	for _, order := range orders {
+		if !order.IsTrigger {
+			continue
+		}
+		// 平多单为卖出，平空单为买入；订单类型为 "Stop Market"/"Stop Limit"/"Take Profit Market"/"Take Profit Limit"
+		positionSide := "long"
+		if order.Side == hyperliquid.OrderSideBid {
+			positionSide = "short"
+		}
+		kind := "take_profit"
+		if strings.HasPrefix(order.OrderType, "Stop") {
+			kind = "stop_loss"
+		}
+		symbol, scale := symbols.FromVenue(symbols.Hyperliquid, order.Coin)
+		quantity := order.Sz * scale
+		if order.IsPositionTpSl {
+			quantity = 0 // 跟随整个持仓
+		}
+		result = append(result, TriggerOrder{
+			OrderID:      strconv.FormatInt(order.Oid, 10),
+			Symbol:       symbol,
+			PositionSide: positionSide,
+			Kind:         kind,
+			TriggerPrice: order.TriggerPx / scale,
+			Quantity:     quantity,
+		})
+	}
+	return result, nil
+}
+
+// GetMarketPrice 获取市场价格（单币价格）
+func (t *HyperliquidTrader) GetMarketPrice(symbol string) (float64, error) {
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	price, err := t.getMidPrice(m.Venue)
+	if err != nil {
+		return 0, err
+	}
+	return price / m.Scale, nil
+}
+
+// getMidPrice 获取币种的中间价（合约单位）
+func (t *HyperliquidTrader) getMidPrice(coin string) (float64, error) {
 	// 获取所有市场价格
 	allMids, err := t.exchange.Info().AllMids(t.ctx)
 	if err != nil {
@@ -505,12 +596,17 @@ func (t *HyperliquidTrader) GetMarketPrice(symbol string) (float64, error) {
 		return 0, fmt.Errorf("价格格式错误: %v", err)
 	}
 
-	return 0, fmt.Errorf("未找到 %s 的价格", symbol)
+	return 0, fmt.Errorf("未找到 %s 的价格", coin)
 }
 
 // SetStopLoss 设置止损单
 func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
-	coin := convertSymbolToHyperliquid(symbol)
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	coin := m.Venue
+	if _, err := t.assetInfo(coin); err != nil {
+		return err
+	}
+	quantity, stopPrice = quantity/m.Scale, stopPrice*m.Scale
 
 	isBuy := positionSide == "SHORT" // 空仓止损=买入，多仓止损=卖出
 
@@ -520,16 +616,22 @@ func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quan
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	roundedStopPrice := t.roundPriceToSigfigs(stopPrice)
 
+	// 止损限价单：触发后按限价挂单
+	limitPrice := roundedStopPrice
+	if t.stopLimitOffsetPct > 0 {
+		limitPrice = t.roundPriceToSigfigs(stopLimitPrice(positionSide, stopPrice, t.stopLimitOffsetPct))
+	}
+
 	// 创建止损单（Trigger Order）
 	order := hyperliquid.CreateOrderRequest{
 		Coin:  coin,
 		IsBuy: isBuy,
-		Size:  roundedQuantity,  // 使用四舍五入后的数量
-		Price: roundedStopPrice, // 使用处理后的价格
+		Size:  roundedQuantity, // 使用四舍五入后的数量
+		Price: limitPrice,      // 使用处理后的价格
 		OrderType: hyperliquid.OrderType{
 			Trigger: &hyperliquid.TriggerOrderType{
 				TriggerPx: roundedStopPrice,
-				IsMarket:  true,
+				IsMarket:  t.stopLimitOffsetPct <= 0,
 				Tpsl:      "sl", // stop loss
 			},
 		},
@@ -541,13 +643,18 @@ func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quan
 		return fmt.Errorf("设置止损失败: %w", err)
 	}
 
-	log.Printf("  止损价设置: %.4f", roundedStopPrice)
+	hyperliquidLog.Info("止损已设置", "symbol", symbol, "side", positionSide, "stop_price", roundedStopPrice)
 	return nil
 }
 
 // SetTakeProfit 设置止盈单
 func (t *HyperliquidTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
-	coin := convertSymbolToHyperliquid(symbol)
+	m := symbols.ToVenue(symbols.Hyperliquid, symbol)
+	coin := m.Venue
+	if _, err := t.assetInfo(coin); err != nil {
+		return err
+	}
+	quantity, takeProfitPrice = quantity/m.Scale, takeProfitPrice*m.Scale
 
 	isBuy := positionSide == "SHORT" // 空仓止盈=买入，多仓止盈=卖出
 
@@ -578,10 +685,15 @@ func (t *HyperliquidTrader) SetTakeProfit(symbol string, positionSide string, qu
 		return fmt.Errorf("设置止盈失败: %w", err)
 	}
 
-	log.Printf("  止盈价设置: %.4f", roundedTakeProfitPrice)
+	hyperliquidLog.Info("止盈已设置", "symbol", symbol, "side", positionSide, "take_profit", roundedTakeProfitPrice)
 	return nil
 }
 
+// SetTakeProfitLadder 分批止盈：每档一个只减仓的止盈触发单
+func (t *HyperliquidTrader) SetTakeProfitLadder(symbol, positionSide string, quantity float64, levels []TPLevel) error {
+	return placeLadderOrders(t, symbol, positionSide, quantity, levels)
+}
+
 // FormatQuantity 格式化数量到正确的精度
 func (t *HyperliquidTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
 	coin := convertSymbolToHyperliquid(symbol)
@@ -592,10 +704,29 @@ func (t *HyperliquidTrader) FormatQuantity(symbol string, quantity float64) (str
 	return fmt.Sprintf(formatStr, quantity), nil
 }
 
+// assetInfo 查找币种的永续合约信息
+// 下单、撤单、改杠杆的请求按资产序号（meta.universe中的位置）标识币种，SDK查不到币种时序号为0（即BTC），
+// 所以下单前必须确认币种存在，否则会误下到BTC上
+func (t *HyperliquidTrader) assetInfo(coin string) (hyperliquid.AssetInfo, error) {
+	if t.meta == nil {
+		return hyperliquid.AssetInfo{}, fmt.Errorf("meta信息为空，无法确认 %s 的资产序号", coin)
+	}
+	for _, asset := range t.meta.Universe {
+		if asset.Name != coin {
+			continue
+		}
+		if asset.IsDelisted {
+			return asset, fmt.Errorf("Hyperliquid %s 永续合约已下架", coin)
+		}
+		return asset, nil
+	}
+	return hyperliquid.AssetInfo{}, fmt.Errorf("Hyperliquid没有 %s 永续合约", coin)
+}
+
 // getSzDecimals 获取币种的数量精度
 func (t *HyperliquidTrader) getSzDecimals(coin string) int {
 	if t.meta == nil {
-		log.Printf("⚠️  meta信息为空，使用默认精度4")
+		hyperliquidLog.Warn("meta信息为空，使用默认精度4", "coin", coin)
 		return 4 // 默认精度
 	}
 
@@ -606,7 +737,7 @@ func (t *HyperliquidTrader) getSzDecimals(coin string) int {
 		}
 	}
 
-	log.Printf("⚠️  未找到 %s 的精度信息，使用默认精度4", coin)
+	hyperliquidLog.Warn("未找到精度信息，使用默认精度4", "coin", coin)
 	return 4 // 默认精度
 }
 
@@ -662,14 +793,36 @@ func (t *HyperliquidTrader) roundPriceToSigfigs(price float64) float64 {
 	return rounded
 }
 
-// convertSymbolToHyperliquid 将标准symbol转换为Hyperliquid格式
-// 例如: "BTCUSDT" -> "BTC"
-func convertSymbolToHyperliquid(symbol string) string {
-	// 去掉USDT后缀
-	if len(symbol) > 4 && symbol[len(symbol)-4:] == "USDT" {
-		return symbol[:len(symbol)-4]
+// hyperliquidIOCFill 解析IOC订单的下单响应（IOC订单在响应时已是终态），返回单币单位的成交汇总（quantity为合约单位）
+// 完全没有成交时交易所返回"could not immediately match"错误，按成交0返回，由订单跟踪判定未成交
+func hyperliquidIOCFill(symbol string, status hyperliquid.OrderStatus, quantity, scale float64) (map[string]interface{}, error) {
+	result := map[string]interface{}{"symbol": symbol, "status": "FILLED"}
+	switch {
+	case status.Filled != nil:
+		filled, _ := strconv.ParseFloat(status.Filled.TotalSz, 64)
+		avgPrice, _ := strconv.ParseFloat(status.Filled.AvgPx, 64)
+		result["orderId"] = int64(status.Filled.Oid)
+		left := quantity - filled
+		if left <= quantity*1e-9 {
+			left = 0
+		} else {
+			result["status"] = "PARTIALLY_FILLED"
+		}
+		return withFill(result, filled*scale, avgPrice/scale, left*scale), nil
+	case status.Error != nil && strings.Contains(*status.Error, "could not immediately match"):
+		result["status"] = "CANCELLED"
+		return withFill(result, 0, 0, quantity*scale), nil
+	case status.Error != nil:
+		return nil, errors.New(*status.Error)
+	default:
+		return nil, fmt.Errorf("IOC订单未立即结束: %s", status.String())
 	}
-	return symbol
+}
+
+// convertSymbolToHyperliquid 将标准symbol转换为Hyperliquid币种名（按符号注册表）
+// 例如: "BTCUSDT" -> "BTC"，"PEPEUSDT" -> "kPEPE"
+func convertSymbolToHyperliquid(symbol string) string {
+	return symbols.ToVenue(symbols.Hyperliquid, symbol).Venue
 }
 
 // absFloat 返回浮点数的绝对值
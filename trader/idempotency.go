@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"nofx/store"
+	"time"
+)
+
+// ClientOrderLookup 可按客户端订单ID（下单时的订单文本）查询订单的交易器
+type ClientOrderLookup interface {
+	// FindOrderByClientID 查询订单文本为clientID的订单（未找到时返回的OrderID为空），数量单位与下单时一致
+	FindOrderByClientID(symbol, clientID string) (store.OrderUpdate, error)
+}
+
+const (
+	orderSubmitRetries = 2               // 下单失败后最多重新提交的次数（只重试结果不确定和退避类错误）
+	orderRetryBackoff  = 2 * time.Second // 重新提交前的基础等待时间（按重试策略递增）
+)
+
+// submitIdempotent 下单，失败时按错误的重试策略处理：退避类错误（限流等，交易所已拒绝）等待后重新提交；
+// 结果不确定时先按客户端订单ID查询原订单是否已到达交易所，找到则沿用原订单，确认未到达才重新提交，
+// 交易器不支持按客户端订单ID查询或查询失败时不再重试（宁可少下一单也不重复开仓）
+func (t *orderTracker) submitIdempotent(tag OrderTag, symbol, action string, submit func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	order, err := t.submitTagged(tag, symbol, submit)
+	lookup, ok := t.trader.(ClientOrderLookup)
+	clientID := ""
+	if ok && tag.ClientID != "" {
+		clientID = tag.Text()
+	}
+
+	for attempt := 1; err != nil && attempt <= orderSubmitRetries; attempt++ {
+		policy := retryPolicyOf(err)
+		wait, retry := policy.delay(attempt, orderRetryBackoff)
+		if !retry || (policy == RetryNow && clientID == "") {
+			return order, err
+		}
+		t.clock.Sleep(wait)
+		if policy == RetryBackoff {
+			orderLog.Warn("下单被交易所暂时拒绝，退避后重新提交", "trader", t.traderID, "symbol", symbol, "action", action,
+				"attempt", attempt, "wait", wait, "err", err)
+			order, err = t.submitTagged(tag, symbol, submit)
+			continue
+		}
+
+		found, lookupErr := lookup.FindOrderByClientID(symbol, clientID)
+		if lookupErr != nil {
+			orderLog.Warn("下单结果不确定且无法查询原订单，不再重试", "trader", t.traderID, "symbol", symbol, "action", action,
+				"client_id", clientID, "err", err, "lookup_err", lookupErr)
+			return order, err
+		}
+		if found.OrderID != "" {
+			orderLog.Warn("下单结果不确定，原订单已到达交易所，不重复下单", "trader", t.traderID, "symbol", symbol, "action", action,
+				"client_id", clientID, "order_id", found.OrderID, "state", found.State, "err", err)
+			return map[string]interface{}{"orderId": found.OrderID, "symbol": symbol, "status": found.State}, nil
+		}
+		orderLog.Warn("下单结果不确定，原订单未到达交易所，重新提交", "trader", t.traderID, "symbol", symbol, "action", action,
+			"client_id", clientID, "attempt", attempt, "err", err)
+		order, err = t.submitTagged(tag, symbol, submit)
+	}
+	return order, err
+}
+
+// resolveClientOrder 重启对账：没有交易所订单ID的订单按客户端订单ID查询是否已提交（找到时补记订单ID）；
+// checked表示已向交易所确认（此时订单ID仍为空说明订单没有到达交易所）
+func (at *AutoTrader) resolveClientOrder(order *store.Order) (checked bool) {
+	lookup, ok := at.trader.(ClientOrderLookup)
+	if !ok || order.OrderID != "" || order.ClientID == "" {
+		return false
+	}
+	found, err := lookup.FindOrderByClientID(order.Symbol, order.ClientID)
+	if err != nil {
+		at.log.Warn("按客户端订单ID查询订单失败", "ref", order.ID, "client_id", order.ClientID, "err", err)
+		return false
+	}
+	if found.OrderID == "" {
+		return true
+	}
+	at.log.Info("按客户端订单ID找到重启前提交的订单", "ref", order.ID, "client_id", order.ClientID, "order_id", found.OrderID)
+	if order.Status == store.OrderCreated {
+		at.orders.transition(order.ID, store.OrderUpdate{State: store.OrderSubmitted, OrderID: found.OrderID})
+		order.Status = store.OrderSubmitted
+	}
+	order.OrderID = found.OrderID
+	return true
+}
@@ -1,5 +1,11 @@
 package trader
 
+import (
+	"nofx/clock"
+	"nofx/risk"
+	"time"
+)
+
 // Trader 交易器统一接口
 // 支持多个交易平台（币安、Hyperliquid等）
 type Trader interface {
@@ -39,3 +45,53 @@ type Trader interface {
 	// FormatQuantity 格式化数量到正确的精度
 	FormatQuantity(symbol string, quantity float64) (string, error)
 }
+
+// AccountCache 带余额/持仓缓存的交易器（可通过配置调整缓存时间）
+type AccountCache interface {
+	// SetCacheDuration 设置余额/持仓缓存有效期
+	SetCacheDuration(d time.Duration)
+}
+
+// ClockAware 使用可替换时钟的交易器（缓存过期、杠杆冷却等待等，回测和测试时注入模拟时钟）
+type ClockAware interface {
+	SetClock(c clock.Clock)
+}
+
+// StopLimitSupport 支持止损限价单的交易器
+// 触发后挂限价单而不是市价成交，避免流动性差的合约在插针时以极端价格成交
+type StopLimitSupport interface {
+	// SetStopLimitOffset 设置止损限价相对触发价的偏移百分比（0表示触发后市价成交）
+	SetStopLimitOffset(pct float64)
+}
+
+// QuantityRoundingSupport 支持配置下单数量取整策略的交易器（按交易所最小下单数量取整的交易器）
+type QuantityRoundingSupport interface {
+	// SetQuantityRounding 设置取整方式和低于最小下单数量时的处理
+	SetQuantityRounding(rounding risk.QuantityRounding)
+}
+
+// MarginModeSupport 支持全仓模式和风险限额设置的交易器
+type MarginModeSupport interface {
+	// SetMarginSettings 设置保证金模式和各币种风险限额（设置杠杆时一并应用）
+	SetMarginSettings(settings risk.MarginSettings)
+}
+
+// SettleBookSource 可按结算币种汇总账户和持仓的交易器（组合视图；不提供时按USDT账本统计）
+type SettleBookSource interface {
+	// SettleBooks 各结算币种的合约账本（价值以结算币种计价，附美元指数价）
+	SettleBooks() ([]risk.SettleBook, error)
+}
+
+// PortfolioSettleSupport 支持汇总多个结算币种账本的交易器
+type PortfolioSettleSupport interface {
+	// SetPortfolioSettles 设置组合视图汇总的结算币种
+	SetPortfolioSettles(settles []string)
+}
+
+// stopLimitPrice 止损限价：多仓止损（卖出）为触发价下方offset%，空仓止损（买入）为触发价上方offset%
+func stopLimitPrice(positionSide string, stopPrice, offsetPct float64) float64 {
+	if positionSide == "LONG" {
+		return stopPrice * (1 - offsetPct/100)
+	}
+	return stopPrice * (1 + offsetPct/100)
+}
@@ -0,0 +1,318 @@
+package trader
+
+import (
+	"context"
+	"nofx/logging"
+	"nofx/store"
+	"nofx/strategy"
+	"strings"
+	"time"
+)
+
+// journalLog 交易日志同步日志
+var journalLog = logging.For("journal")
+
+// TradeHistorySource 可提供成交和费用流水的交易器（用于将手续费/资金费归集到每笔交易）
+type TradeHistorySource interface {
+	// GetFills 获取指定时间之后的成交记录
+	GetFills(since time.Time) ([]store.Fill, error)
+
+	// GetCosts 获取指定时间之后的手续费和资金费流水
+	GetCosts(since time.Time) ([]store.Cost, error)
+}
+
+// costSyncOverlap 费用同步的回溯重叠时间（流水按唯一键去重）
+const costSyncOverlap = 10 * time.Minute
+
+// syncTradeCosts 同步成交和费用流水到交易日志，并归集到对应交易
+func (at *AutoTrader) syncTradeCosts() {
+	source, ok := at.trader.(TradeHistorySource)
+	if !ok || at.journal == nil {
+		return
+	}
+
+	since := at.lastCostSync
+	if since.IsZero() {
+		since = at.startTime.Add(-24 * time.Hour)
+	}
+	syncStart := time.Now()
+
+	fills, err := source.GetFills(since)
+	if err != nil {
+		journalLog.Warn("同步成交记录失败", "trader", at.id, "err", err)
+		return
+	}
+	for _, fill := range fills {
+		fill.TraderID = at.id
+		if tag, fromExchange := at.attributeFill(fill); tag.Strategy != "" {
+			fill.Strategy, fill.DecisionID, fill.PromptVersion = tag.Strategy, tag.DecisionID, tag.PromptVersion
+			if fromExchange {
+				at.attributeAdoptedPosition(fill, tag)
+			}
+		}
+		if err := at.journal.RecordFill(fill); err != nil {
+			journalLog.Warn("写入成交记录失败", "trader", at.id, "symbol", fill.Symbol, "err", err)
+		}
+	}
+
+	costs, err := source.GetCosts(since)
+	if err != nil {
+		journalLog.Warn("同步费用流水失败", "trader", at.id, "err", err)
+		return
+	}
+	for _, cost := range costs {
+		cost.TraderID = at.id
+		if err := at.journal.RecordCost(cost); err != nil {
+			journalLog.Warn("写入费用流水失败", "trader", at.id, "symbol", cost.Symbol, "err", err)
+		}
+	}
+
+	attributed, err := at.journal.AttributeCosts(at.id)
+	if err != nil {
+		journalLog.Warn("归集手续费/资金费失败", "trader", at.id, "err", err)
+		return
+	}
+	if attributed > 0 {
+		journalLog.Info("已归集手续费/资金费流水到交易记录", "trader", at.id, "count", attributed,
+			"latency_ms", time.Since(syncStart).Milliseconds())
+	}
+
+	at.lastCostSync = syncStart.Add(-costSyncOverlap)
+}
+
+// orderTagCacheSize 订单文本缓存上限（超过后清空重新查询）
+const orderTagCacheSize = 1000
+
+// attributeFill 成交所属的策略和决策：优先使用交易日志中的订单记录，
+// 没有记录时（如交易日志丢失、其他实例下的单）按订单ID向交易所查询下单时的订单文本
+func (at *AutoTrader) attributeFill(fill store.Fill) (tag OrderTag, fromExchange bool) {
+	order, err := at.journal.GetOrderByOrderID(at.id, fill.OrderID)
+	if err != nil {
+		journalLog.Warn("查询订单记录失败", "trader", at.id, "order_id", fill.OrderID, "err", err)
+	}
+	if order != nil {
+		return OrderTag{Strategy: order.Strategy, DecisionID: order.DecisionID, PromptVersion: order.PromptVersion}, false
+	}
+
+	source, ok := at.trader.(OrderTextSource)
+	if !ok || fill.OrderID == "" {
+		return OrderTag{}, false
+	}
+	if tag, ok := at.orderTags[fill.OrderID]; ok {
+		return tag, true
+	}
+	text, err := source.GetOrderText(fill.Symbol, fill.OrderID)
+	if err != nil {
+		journalLog.Debug("查询订单文本失败", "trader", at.id, "order_id", fill.OrderID, "err", err)
+		return OrderTag{}, false
+	}
+	tag, _ = ParseOrderTag(text) // 非本系统下的订单（网页、其他程序）无法归因
+	if at.orderTags == nil || len(at.orderTags) >= orderTagCacheSize {
+		at.orderTags = make(map[string]OrderTag)
+	}
+	at.orderTags[fill.OrderID] = tag
+	return tag, true
+}
+
+// attributeAdoptedPosition 对账接管的持仓（策略为adopted）按接管前的开仓成交找回所属策略
+func (at *AutoTrader) attributeAdoptedPosition(fill store.Fill, tag OrderTag) {
+	side := "long"
+	if fill.Side == "sell" {
+		side = "short"
+	}
+	position, err := at.journal.GetOpenPosition(at.id, fill.Symbol, side)
+	if err != nil || position == nil || position.Strategy != "adopted" || fill.Time.After(position.OpenedAt) {
+		return
+	}
+	if err := at.journal.AttributePosition(position.ID, tag.Strategy, tag.DecisionID, tag.PromptVersion); err != nil {
+		journalLog.Warn("更新持仓归因失败", "trader", at.id, "symbol", fill.Symbol, "err", err)
+		return
+	}
+	journalLog.Info("已按订单文本找回接管持仓的策略", "trader", at.id, "symbol", fill.Symbol, "side", side,
+		"strategy", tag.Strategy, "decision_id", tag.DecisionID)
+}
+
+// openPositionFunding 未平仓交易已归集的资金费（key: symbol_side，正数表示收入）
+// 随每次费用同步更新；交易器不提供账户流水或未启用交易日志时为空
+func (at *AutoTrader) openPositionFunding() map[string]float64 {
+	positions, err := at.journal.ListOpenPositions(at.id)
+	if err != nil {
+		journalLog.Warn("查询持仓资金费失败", "trader", at.id, "err", err)
+		return nil
+	}
+	funding := make(map[string]float64, len(positions))
+	for _, p := range positions {
+		funding[p.Symbol+"_"+p.Side] += p.Funding
+	}
+	return funding
+}
+
+// entryFillLead 开仓成交早于交易日志开仓时间的容差（开仓记录在确认成交后写入）
+const entryFillLead = 2 * time.Minute
+
+// openPositionEntries 未平仓交易按成交滚动计算的开仓均价（key: symbol_side），
+// 多次成交和加仓时比交易所的开仓价更能反映实际成本；没有成交记录的持仓不在结果中
+func (at *AutoTrader) openPositionEntries() map[string]store.EntryBenchmark {
+	positions, err := at.journal.ListOpenPositions(at.id)
+	if err != nil {
+		journalLog.Warn("查询持仓开仓均价失败", "trader", at.id, "err", err)
+		return nil
+	}
+	entries := make(map[string]store.EntryBenchmark, len(positions))
+	for _, p := range positions {
+		b, err := at.journal.EntryBenchmark(at.id, p.Symbol, p.Side, p.OpenedAt.Add(-entryFillLead))
+		if err != nil {
+			journalLog.Warn("计算开仓均价失败", "trader", at.id, "symbol", p.Symbol, "err", err)
+			continue
+		}
+		if b.Fills > 0 {
+			entries[p.Symbol+"_"+p.Side] = b
+		}
+	}
+	return entries
+}
+
+// journalingExecutor 包装Trader，将策略模块（DCA、资金费率套利等）下的订单写入交易日志
+type journalingExecutor struct {
+	Trader
+	ctx      context.Context // 追踪上下文（订单span挂在策略看守周期下）
+	orders   *orderTracker
+	strategy string
+}
+
+// newJournalingExecutor 创建带交易日志和成交确认的执行器（共用trader的订单跟踪器）
+func newJournalingExecutor(ctx context.Context, orders *orderTracker, strategy string) Trader {
+	return &journalingExecutor{Trader: orders.trader, ctx: ctx, orders: orders, strategy: strategy}
+}
+
+// OpenLong 开多仓并记录（返回的订单附带实际成交数量和均价，见withFill）
+func (j *journalingExecutor) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	price, _ := j.Trader.GetMarketPrice(symbol)
+	order, fill, err := j.orders.Place(j.ctx, j.strategy, symbol, "open_long", quantity, price, leverage, func() (map[string]interface{}, error) {
+		return j.Trader.OpenLong(symbol, quantity, leverage)
+	})
+	if err != nil {
+		return order, err
+	}
+	return withFill(order, fill.FilledQty, fill.AvgPrice, quantity-fill.FilledQty), nil
+}
+
+// OpenShort 开空仓并记录
+func (j *journalingExecutor) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	price, _ := j.Trader.GetMarketPrice(symbol)
+	order, fill, err := j.orders.Place(j.ctx, j.strategy, symbol, "open_short", quantity, price, leverage, func() (map[string]interface{}, error) {
+		return j.Trader.OpenShort(symbol, quantity, leverage)
+	})
+	if err != nil {
+		return order, err
+	}
+	return withFill(order, fill.FilledQty, fill.AvgPrice, quantity-fill.FilledQty), nil
+}
+
+// CloseLong 平多仓并记录
+func (j *journalingExecutor) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	price, _ := j.Trader.GetMarketPrice(symbol)
+	order, _, err := j.orders.Place(j.ctx, j.strategy, symbol, "close_long", quantity, price, 0, func() (map[string]interface{}, error) {
+		return j.Trader.CloseLong(symbol, quantity)
+	})
+	return order, err
+}
+
+// CloseShort 平空仓并记录
+func (j *journalingExecutor) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	price, _ := j.Trader.GetMarketPrice(symbol)
+	order, _, err := j.orders.Place(j.ctx, j.strategy, symbol, "close_short", quantity, price, 0, func() (map[string]interface{}, error) {
+		return j.Trader.CloseShort(symbol, quantity)
+	})
+	return order, err
+}
+
+// SetStopLoss 检查触发价后设置止损并记录
+func (j *journalingExecutor) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	price, err := j.orders.checkTriggerPrice(symbol, positionSide, "stop_loss", stopPrice)
+	if err == nil {
+		err = j.Trader.SetStopLoss(symbol, positionSide, quantity, price)
+	} else {
+		price = stopPrice
+	}
+	recordProtectiveOrder(j.orders.journal, j.orders.traderID, symbol, positionSide, "stop_loss", quantity, price, err)
+	return err
+}
+
+// SetTakeProfit 检查触发价后设置止盈
+func (j *journalingExecutor) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	price, err := j.orders.checkTriggerPrice(symbol, positionSide, "take_profit", takeProfitPrice)
+	if err != nil {
+		return err
+	}
+	return j.Trader.SetTakeProfit(symbol, positionSide, quantity, price)
+}
+
+// recordProtectiveOrder 记录止损/止盈挂单
+func recordProtectiveOrder(journal *store.Store, traderID, symbol, positionSide, kind string, quantity, triggerPrice float64, err error) {
+	if journal == nil {
+		return
+	}
+
+	p := store.ProtectiveOrder{
+		TraderID:     traderID,
+		Symbol:       symbol,
+		Side:         strings.ToLower(positionSide),
+		Kind:         kind,
+		TriggerPrice: triggerPrice,
+		Quantity:     quantity,
+		Status:       "placed",
+	}
+	if err != nil {
+		p.Status = "failed"
+		p.Error = err.Error()
+	}
+	if jErr := journal.RecordProtectiveOrder(p); jErr != nil {
+		journalLog.Warn("写入止损止盈记录失败", "symbol", symbol, "kind", kind, "err", jErr)
+	}
+}
+
+// journalCarryStore 资金费率套利对冲持仓的交易日志持久化
+type journalCarryStore struct {
+	journal  *store.Store
+	traderID string
+}
+
+func (c journalCarryStore) SaveCarry(pos strategy.CarryPosition) error {
+	return c.journal.SaveCarryPosition(store.CarryPosition{
+		TraderID:      c.traderID,
+		Symbol:        pos.Symbol,
+		SpotQuantity:  pos.SpotQuantity,
+		PerpContracts: pos.PerpContracts,
+		EntryNotional: pos.EntryNotional,
+		EntryFunding:  pos.EntryFunding,
+		NetCarry:      pos.NetCarry,
+		OpenTime:      pos.OpenTime,
+		LastSettle:    pos.LastSettle,
+	})
+}
+
+func (c journalCarryStore) DeleteCarry(symbol string) error {
+	return c.journal.DeleteCarryPosition(c.traderID, symbol)
+}
+
+func (c journalCarryStore) LoadCarry() ([]strategy.CarryPosition, error) {
+	saved, err := c.journal.ListCarryPositions(c.traderID)
+	if err != nil {
+		return nil, err
+	}
+	positions := make([]strategy.CarryPosition, 0, len(saved))
+	for _, p := range saved {
+		positions = append(positions, strategy.CarryPosition{
+			Symbol:        p.Symbol,
+			SpotQuantity:  p.SpotQuantity,
+			PerpContracts: p.PerpContracts,
+			EntryNotional: p.EntryNotional,
+			EntryFunding:  p.EntryFunding,
+			OpenTime:      p.OpenTime,
+			NetCarry:      p.NetCarry,
+			LastSettle:    p.LastSettle,
+		})
+	}
+	return positions, nil
+}
@@ -0,0 +1,162 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BarClose 已收盘的K线
+type BarClose struct {
+	Symbol    string
+	Interval  string // 如 "1m"、"15m"、"4h"
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// KlineStream 可推送K线收盘事件的交易器（WebSocket）
+type KlineStream interface {
+	// StartKlineStream 订阅symbols在interval周期上的K线，每根K线收盘时回调一次，断线后自动重连，直到stop关闭
+	StartKlineStream(stop <-chan struct{}, symbols []string, interval string, handler func(BarClose)) error
+	// KlineStreamStatus K线推送连接状态
+	KlineStreamStatus() (connected bool, lastMessage time.Time)
+}
+
+// BarDuration K线周期的时长（支持s/m/h/d/w后缀，如 "10s"、"15m"、"4h"、"1d"、"7d"）
+func BarDuration(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("K线周期无效: %q", interval)
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("K线周期无效: %q", interval)
+	}
+	unit := map[string]time.Duration{
+		"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+	}[strings.ToLower(interval[len(interval)-1:])]
+	if unit == 0 {
+		return 0, fmt.Errorf("K线周期无效: %q", interval)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// startBarTrigger 订阅schedule.bar_symbol的K线推送，每根K线收盘时向返回的通道发送收盘时间（用于触发AI决策）
+// 交易器不支持K线推送或订阅失败时返回nil，调用方回退到按时间调度
+func (at *AutoTrader) startBarTrigger() <-chan time.Time {
+	interval := at.config.Schedule.BarInterval
+	if interval == "" {
+		return nil
+	}
+	stream, ok := at.trader.(KlineStream)
+	if !ok {
+		at.log.Warn("交易器不支持K线推送，AI决策按时间调度", "exchange", at.exchange, "bar_interval", interval)
+		return nil
+	}
+
+	// 缓冲1：决策周期未结束时最多保留一次收盘事件，之后的合并（不会在落后时连续补跑）
+	trigger := make(chan time.Time, 1)
+	symbol := at.config.Schedule.BarSymbolOrDefault()
+	err := stream.StartKlineStream(at.stopCh, []string{symbol}, interval, func(bar BarClose) {
+		at.log.Debug("K线收盘", "symbol", bar.Symbol, "interval", bar.Interval, "open_time", bar.OpenTime, "close", bar.Close)
+		select {
+		case trigger <- bar.CloseTime:
+		default:
+		}
+	})
+	if err != nil {
+		at.log.Warn("启动K线推送失败，AI决策按时间调度", "err", err)
+		return nil
+	}
+	at.barStreaming.Store(true)
+	at.log.Info("AI决策按K线收盘触发", "symbol", symbol, "interval", interval)
+	return trigger
+}
+
+// barTracker 根据K线推送判断收盘：推送带有已收盘标记，或同一K线序列出现更新的开盘时间时，
+// 上一根K线视为收盘（交易所不一定推送收盘标记）；每根K线只回调一次
+type barTracker struct {
+	mu     sync.Mutex
+	last   map[string]BarClose  // K线名称 → 最近推送的未收盘K线
+	closed map[string]time.Time // K线名称 → 最近回调的收盘K线开盘时间
+}
+
+func newBarTracker() *barTracker {
+	return &barTracker{last: make(map[string]BarClose), closed: make(map[string]time.Time)}
+}
+
+// observe 处理一次推送，返回其中收盘的K线
+func (b *barTracker) observe(name string, bar BarClose, final bool) []BarClose {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var closed []BarClose
+	emit := func(c BarClose) {
+		if done, ok := b.closed[name]; ok && !c.OpenTime.After(done) {
+			return
+		}
+		b.closed[name] = c.OpenTime
+		closed = append(closed, c)
+	}
+	if prev, ok := b.last[name]; ok && bar.OpenTime.After(prev.OpenTime) {
+		emit(prev)
+	}
+	if final {
+		emit(bar)
+		delete(b.last, name)
+	} else if prev, ok := b.last[name]; !ok || !bar.OpenTime.Before(prev.OpenTime) {
+		b.last[name] = bar
+	}
+	return closed
+}
+
+// startBarService 订阅bars.symbols的1分钟K线推送并喂入多周期K线服务
+// 服务在进程内共享，其他trader已在跟踪的币种不重复订阅；trader停止时停止跟踪，之后读取回退到REST查询
+func (at *AutoTrader) startBarService() {
+	if len(at.config.Bars.Symbols) == 0 {
+		return
+	}
+	stream, ok := at.trader.(KlineStream)
+	if !ok {
+		at.log.Warn("交易器不支持K线推送，多周期K线服务未启动", "exchange", at.exchange)
+		return
+	}
+
+	bars := market.DefaultBars()
+	var tracked []string
+	for _, symbol := range at.config.Bars.Symbols {
+		if symbol = market.Normalize(symbol); bars.Track(symbol) {
+			tracked = append(tracked, symbol)
+		}
+	}
+	if len(tracked) == 0 {
+		return
+	}
+	untrack := func() {
+		for _, symbol := range tracked {
+			bars.Untrack(symbol)
+		}
+	}
+
+	err := stream.StartKlineStream(at.stopCh, tracked, "1m", func(bar BarClose) {
+		bars.Observe(bar.Symbol, market.Kline{OpenTime: bar.OpenTime.UnixMilli(), Open: bar.Open, High: bar.High,
+			Low: bar.Low, Close: bar.Close, Volume: bar.Volume})
+	})
+	if err != nil {
+		untrack()
+		at.log.Warn("启动多周期K线推送失败", "err", err)
+		return
+	}
+	go func() {
+		<-at.stopCh
+		untrack()
+	}()
+	at.log.Info("多周期K线服务已启动", "symbols", tracked, "timeframes", market.BarTimeframes)
+}
@@ -0,0 +1,152 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/notify"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ledgerPollInterval = time.Minute      // 资金流水和API密钥信息检查间隔
+	ledgerLookback     = 10 * time.Minute // 每次查询向前重叠的时长（交易所记录可能延迟出现，按ID去重）
+)
+
+// 资金流水类型
+const (
+	LedgerWithdrawal  = "withdrawal"   // 提现
+	LedgerTransferOut = "transfer_out" // 从交易账户转出
+	LedgerDeposit     = "deposit"      // 充值或转入
+)
+
+// LedgerEntry 账户资金变动记录
+type LedgerEntry struct {
+	ID       string    // 记录ID（用于去重）
+	Kind     string    // withdrawal/transfer_out/deposit
+	Currency string    // 币种
+	Amount   float64   // 数量（绝对值）
+	Detail   string    // 地址、链、状态等补充信息
+	Time     time.Time // 发生时间
+}
+
+// KeyInfo API密钥可见的账户权限信息（IP白名单、密钥模式等，字段名 -> 值）
+type KeyInfo map[string]string
+
+// LedgerSource 可查询资金流水和API密钥信息的交易器（密钥泄露的入侵告警）
+type LedgerSource interface {
+	// GetLedger 获取指定时间之后的提现、转出和充值记录
+	GetLedger(since time.Time) ([]LedgerEntry, error)
+	// GetKeyInfo 获取当前API密钥可见的权限信息
+	GetKeyInfo() (KeyInfo, error)
+}
+
+// diffKeyInfo 列出两次密钥信息中变化的字段（"字段: 旧值 -> 新值"，按字段名排序）
+func diffKeyInfo(before, after KeyInfo) []string {
+	var changes []string
+	for name, value := range after {
+		if old, ok := before[name]; !ok || old != value {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, before[name], value))
+		}
+	}
+	for name, old := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, fmt.Sprintf("%s: %s -> ", name, old))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// ledgerWatch 资金流水监控状态（只在monitorLedger的goroutine中使用）
+type ledgerWatch struct {
+	start time.Time            // 监控启动时间（之前发生的记录不告警）
+	since time.Time            // 已检查到的时间
+	seen  map[string]time.Time // 已处理的记录ID -> 记录时间
+	key   KeyInfo              // 最近一次的密钥信息（首次查询结果为基线）
+}
+
+// monitorLedger 定时检查账户流水和API密钥信息：出现提现或转出、密钥权限变化时立即告警。
+// 启动前已存在的记录和启动时的密钥信息作为基线，不告警
+func (at *AutoTrader) monitorLedger() {
+	source, ok := at.trader.(LedgerSource)
+	if !ok {
+		at.log.Warn("交易器不支持资金流水监控")
+		return
+	}
+
+	now := at.clock.Now()
+	watch := &ledgerWatch{start: now, since: now, seen: make(map[string]time.Time)}
+	for {
+		if !at.maintenance.active() {
+			at.checkLedger(source, watch)
+		}
+
+		select {
+		case <-at.stopCh:
+			return
+		case <-at.clock.After(ledgerPollInterval):
+		}
+	}
+}
+
+// checkLedger 执行一次流水和密钥信息检查
+func (at *AutoTrader) checkLedger(source LedgerSource, watch *ledgerWatch) {
+	now := at.clock.Now()
+	from := watch.since.Add(-ledgerLookback)
+	if entries, err := source.GetLedger(from); err != nil {
+		at.log.Warn("查询资金流水失败", "err", err)
+	} else {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+		for _, entry := range entries {
+			if _, ok := watch.seen[entry.ID]; ok {
+				continue
+			}
+			watch.seen[entry.ID] = entry.Time
+			if !entry.Time.Before(watch.start) {
+				at.alertLedger(entry)
+			}
+		}
+		// 只保留仍可能在重叠窗口内再次出现的记录
+		for id, t := range watch.seen {
+			if t.Before(from) {
+				delete(watch.seen, id)
+			}
+		}
+		watch.since = now
+	}
+
+	key, err := source.GetKeyInfo()
+	if err != nil {
+		at.log.Warn("查询API密钥信息失败", "err", err)
+		return
+	}
+	if watch.key == nil {
+		watch.key = key
+		at.log.Info("资金流水监控已启动", "key", key)
+		return
+	}
+	if changes := diffKeyInfo(watch.key, key); len(changes) > 0 {
+		detail := strings.Join(changes, "; ")
+		at.log.Warn("API密钥权限变更", "changes", detail)
+		at.notifyReason(notify.KindRisk, riskReason("ledger"), "", "API密钥权限变更", detail)
+		at.publishRisk("ledger", "", "key_changed", detail)
+		watch.key = key
+	}
+}
+
+// alertLedger 对一条资金变动告警：提现和转出按风控告警，转入按一般信息
+func (at *AutoTrader) alertLedger(entry LedgerEntry) {
+	detail := fmt.Sprintf("%s %g %s", entry.Kind, entry.Amount, entry.Currency)
+	if entry.Detail != "" {
+		detail += " (" + entry.Detail + ")"
+	}
+	if entry.Kind == LedgerDeposit {
+		at.log.Info("检测到资金转入", "detail", detail)
+		at.notify(notify.KindInfo, "", "检测到资金转入", detail)
+		return
+	}
+	at.log.Warn("检测到资金转出", "detail", detail)
+	at.notifyReason(notify.KindRisk, riskReason("ledger"), "", "检测到资金转出", detail)
+	at.publishRisk("ledger", "", entry.Kind, detail)
+}
@@ -0,0 +1,309 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/store"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LimitOrderSupport 支持GTC限价开仓单的交易器（挂单后不等待成交，需同时支持OrderStatusSource查询成交）
+type LimitOrderSupport interface {
+	// PlaceLimitOrder 按指定价格挂GTC限价开仓单，返回结果包含orderId
+	PlaceLimitOrder(symbol, side string, quantity, price float64, leverage int) (map[string]interface{}, error)
+	// CancelOrder 撤销单个普通委托单
+	CancelOrder(symbol, orderID string) error
+}
+
+// limitOrderPollSpec 限价单状态轮询周期
+const limitOrderPollSpec = "@every 15s"
+
+// limitOrder 挂单中的GTC限价开仓单
+type limitOrder struct {
+	placedOrder
+	symbol     string
+	strategy   string
+	quantity   float64
+	price      float64
+	leverage   int
+	stopLoss   float64        // 成交后设置的止损
+	takeProfit float64        // 成交后设置的止盈
+	trigger    TriggerOptions // 止损止盈条件单参数（零值表示默认有效期和触发价格类型）
+	protected  bool           // 部分成交时是否已按已成交数量挂出止损止盈
+	placedStop float64        // 已挂出的止损触发价（经过合理性检查，可能被收紧到边界价；设置失败时为0）
+	expiresAt  time.Time
+	expired    bool              // 是否因超时被撤单
+	update     store.OrderUpdate // 最近一次查询到的订单状态
+}
+
+// remaining 未成交数量（全部成交时为0，交易器可能对下单数量取整）
+func (o *limitOrder) remaining() float64 {
+	if o.update.State == store.OrderFilled {
+		return 0
+	}
+	if left := o.quantity - o.update.FilledQty; left > 0 {
+		return left
+	}
+	return 0
+}
+
+// limitOrderManager GTC限价单跟踪：挂单后定期查询成交，新增部分成交时推送，超时撤销未成交部分，
+// 到达终态时同步持仓并交给调用方决定是否市价补齐剩余数量
+type limitOrderManager struct {
+	orders  *orderTracker
+	support LimitOrderSupport
+	source  OrderStatusSource
+
+	mu      sync.Mutex
+	pending map[string]*limitOrder // 交易所订单ID → 限价单
+}
+
+// newLimitOrderManager 创建限价单跟踪器（交易器不支持限价单或订单查询时返回nil）
+func newLimitOrderManager(orders *orderTracker) *limitOrderManager {
+	support, ok := orders.trader.(LimitOrderSupport)
+	source, isSource := orders.trader.(OrderStatusSource)
+	if !ok || !isSource {
+		return nil
+	}
+	return &limitOrderManager{orders: orders, support: support, source: source, pending: make(map[string]*limitOrder)}
+}
+
+// Place 挂GTC限价开仓单并开始跟踪，ttl后未成交部分自动撤销
+func (m *limitOrderManager) Place(ctx context.Context, strategy, symbol, side string, quantity, price float64, leverage int,
+	ttl time.Duration, stopLoss, takeProfit float64, trigger TriggerOptions) (*limitOrder, error) {
+	if m == nil {
+		return nil, fmt.Errorf("交易器不支持限价单")
+	}
+	placed, _, err := m.orders.submit(ctx, strategy, symbol, "open_"+side, quantity, price, leverage,
+		func() (map[string]interface{}, error) {
+			return m.support.PlaceLimitOrder(symbol, side, quantity, price, leverage)
+		})
+	if err != nil {
+		return nil, err
+	}
+	if placed.orderID == "" {
+		return nil, fmt.Errorf("%s 限价单未返回订单ID，无法跟踪", symbol)
+	}
+
+	order := &limitOrder{
+		placedOrder: placed,
+		symbol:      symbol,
+		strategy:    strategy,
+		quantity:    quantity,
+		price:       price,
+		leverage:    leverage,
+		stopLoss:    stopLoss,
+		takeProfit:  takeProfit,
+		trigger:     trigger,
+		expiresAt:   m.orders.clock.Now().Add(ttl),
+		update:      store.OrderUpdate{State: store.OrderSubmitted},
+	}
+	m.mu.Lock()
+	m.pending[placed.orderID] = order
+	m.mu.Unlock()
+
+	orderLog.Info("限价单开始跟踪", "trader", m.orders.traderID, "symbol", symbol, "side", side, "order_id", placed.orderID,
+		"quantity", quantity, "price", price, "expires_at", order.expiresAt)
+	return order, nil
+}
+
+// Pending 跟踪中的限价单数量
+func (m *limitOrderManager) Pending() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// Poll 查询所有跟踪中的限价单：新增部分成交时推送，超时未到达终态时撤单，
+// 到达终态（全部成交、超时撤单、被其他操作撤销）时按已成交数量同步持仓，
+// 返回本次新增部分成交但仍在挂单的限价单和本次结束的限价单
+func (m *limitOrderManager) Poll() (partial, finished []*limitOrder) {
+	if m == nil {
+		return nil, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for orderID, o := range m.pending {
+		latest, err := m.source.GetOrderStatus(o.symbol, orderID)
+		if err != nil {
+			orderLog.Warn("查询限价单状态失败", "trader", m.orders.traderID, "symbol", o.symbol, "order_id", orderID, "err", err)
+			continue
+		}
+		if !store.IsTerminalOrderState(latest.State) && !m.orders.clock.Now().Before(o.expiresAt) {
+			if err := m.support.CancelOrder(o.symbol, orderID); err != nil {
+				orderLog.Warn("限价单超时撤单失败", "trader", m.orders.traderID, "symbol", o.symbol, "order_id", orderID, "err", err)
+			}
+			o.expired = true
+			if current, err := m.source.GetOrderStatus(o.symbol, orderID); err == nil {
+				latest = current
+			}
+			if latest.State == store.OrderCancelled {
+				latest.Reason = ReasonLimitExpired
+			}
+		}
+
+		terminal := store.IsTerminalOrderState(latest.State)
+		if latest.State != o.update.State || latest.FilledQty != o.update.FilledQty {
+			m.orders.transition(o.ref, latest)
+			if !terminal && latest.FilledQty > o.update.FilledQty {
+				orderLog.Info("限价单部分成交", "trader", m.orders.traderID, "symbol", o.symbol, "order_id", orderID,
+					"filled", latest.FilledQty, "quantity", o.quantity)
+				m.orders.notify(notify.KindInfo, o.symbol, fmt.Sprintf("%s 限价单部分成交", o.symbol),
+					fmt.Sprintf("已成交: %.4f / %.4f | 挂单价: %.4f", latest.FilledQty, o.quantity, o.price))
+				partial = append(partial, o)
+			}
+		}
+		o.update = latest
+		if terminal {
+			delete(m.pending, orderID)
+			m.finish(o)
+			finished = append(finished, o)
+		}
+	}
+	return partial, finished
+}
+
+// finish 限价单到达终态：记录执行成本并按已成交数量同步持仓
+func (m *limitOrderManager) finish(o *limitOrder) {
+	orderLog.Info("限价单结束", "trader", m.orders.traderID, "symbol", o.symbol, "order_id", o.orderID,
+		"state", o.update.State, "filled", o.update.FilledQty, "quantity", o.quantity, "expired", o.expired)
+	if o.update.FilledQty <= 0 {
+		return
+	}
+	if o.update.AvgPrice <= 0 {
+		o.update.AvgPrice = o.price
+	}
+
+	order := map[string]interface{}{"route": risk.RouteLimit, "price": o.price}
+	if source, ok := m.orders.trader.(FeeRateSource); ok {
+		if maker, _, err := source.GetFeeRates(o.symbol); err == nil {
+			order["feeRate"] = maker
+		}
+	}
+	action := "open_" + o.side
+	m.orders.recordExecution(context.Background(), o.ref, o.symbol, action, order, o.price, o.update)
+	m.orders.syncPosition(o.placedOrder, o.symbol, action, o.leverage, o.update)
+}
+
+// placeLimitEntry 按决策的limit_price挂GTC限价开仓单，成交后由pollLimitOrders设置止损止盈
+func (at *AutoTrader) placeLimitEntry(ctx context.Context, d *decision.Decision, side string, actionRecord *logger.DecisionAction) error {
+	if at.limitOrders == nil {
+		return fmt.Errorf("%s 交易器不支持限价单", at.exchange)
+	}
+	quantity := d.PositionSizeUSD / d.LimitPrice
+	actionRecord.Quantity = quantity
+	actionRecord.Price = d.LimitPrice
+
+	ttl := at.config.EntryRouting.LimitTimeout()
+	order, err := at.limitOrders.Place(ctx, at.execSource, d.Symbol, side, quantity, d.LimitPrice, d.Leverage,
+		ttl, d.StopLoss, d.TakeProfit, triggerOptions(d))
+	if err != nil {
+		return err
+	}
+	if orderID, err := strconv.ParseInt(order.orderID, 10, 64); err == nil {
+		actionRecord.OrderID = orderID
+	}
+	at.log.Info("限价单已挂出", "symbol", d.Symbol, "side", side, "order_id", order.orderID, "quantity", quantity,
+		"price", d.LimitPrice, "timeout", ttl)
+	return nil
+}
+
+// pollLimitOrders 限价单跟踪周期：每次新增部分成交时按已成交数量设置或调整止损止盈，
+// 撤单时已成交比例达到limit_complete_pct则市价补齐剩余数量
+func (at *AutoTrader) pollLimitOrders() {
+	if at.limitOrders.Pending() == 0 {
+		return
+	}
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	partial, finished := at.limitOrders.Poll()
+	for _, o := range partial {
+		at.protectFilled(o, o.update.FilledQty, o.update.AvgPrice)
+	}
+	for _, o := range finished {
+		at.completeLimitEntry(o)
+	}
+}
+
+// completeLimitEntry 处理结束的限价单
+func (at *AutoTrader) completeLimitEntry(o *limitOrder) {
+	filled, avgPrice := o.update.FilledQty, o.update.AvgPrice
+	if remaining := o.remaining(); remaining > 0 {
+		reason, code := "已被撤销", ""
+		if o.expired {
+			reason, code = "超时撤单", ReasonLimitExpired
+		}
+		if at.config.EntryRouting.CompleteLimit(filled, o.quantity) {
+			at.log.Info("限价单部分成交，市价补齐剩余数量", "symbol", o.symbol, "side", o.side, "filled", filled, "remaining", remaining)
+			ctx := withReason(withDecision(context.Background(), o.tag.DecisionID, o.tag.PromptVersion), o.reason)
+			_, fill, err := at.orders.Place(ctx, o.strategy, o.symbol, "open_"+o.side, remaining, o.price, o.leverage,
+				at.openSubmit(o.symbol, o.side, remaining, o.leverage))
+			if err != nil {
+				at.log.Warn("市价补齐限价单失败", "symbol", o.symbol, "side", o.side, "remaining", remaining, "err", err)
+			} else if filled+fill.FilledQty > 0 {
+				avgPrice = (filled*avgPrice + fill.FilledQty*fill.AvgPrice) / (filled + fill.FilledQty)
+				filled += fill.FilledQty
+			}
+		} else {
+			at.notifyReason(notify.KindInfo, code, o.symbol, fmt.Sprintf("%s 限价单%s", o.symbol, reason),
+				fmt.Sprintf("已成交: %.4f / %.4f | 挂单价: %.4f | 剩余%.4f不再开仓", filled, o.quantity, o.price, remaining))
+		}
+	}
+	if filled <= 0 {
+		return
+	}
+
+	at.protectFilled(o, filled, avgPrice)
+	if o.placedStop > 0 && at.pyramidManager.Enabled() {
+		at.pyramidManager.Track(o.symbol, o.side, filled, avgPrice, o.placedStop, o.takeProfit)
+	}
+}
+
+// protectFilled 按限价单已成交数量设置止损止盈：首次成交时挂出，之后每次成交按新的成交数量调整已挂出的条件单
+func (at *AutoTrader) protectFilled(o *limitOrder, filled, avgPrice float64) {
+	if filled <= 0 {
+		return
+	}
+	if o.protected {
+		if err := at.resizeProtectiveOrders(o.symbol, o.side, filled, true); err != nil {
+			at.log.Warn("按成交数量调整止损止盈失败", "symbol", o.symbol, "side", o.side, "filled", filled, "err", err)
+			at.notify(notify.KindError, o.symbol, "止损止盈数量调整失败", err.Error())
+		}
+		return
+	}
+
+	positionSide := "LONG"
+	if o.side == "short" {
+		positionSide = "SHORT"
+	}
+	at.positionFirstSeenTime[o.symbol+"_"+o.side] = at.clock.Now().UnixMilli()
+
+	// 按实际成交数量设置止损止盈
+	stopLoss, slErr := at.orders.checkTriggerPrice(o.symbol, positionSide, "stop_loss", o.stopLoss)
+	if slErr == nil {
+		slErr = at.placeStopLoss(o.symbol, positionSide, filled, stopLoss, o.trigger)
+	} else {
+		stopLoss = o.stopLoss
+	}
+	if slErr != nil {
+		at.log.Warn("设置止损失败", "symbol", o.symbol, "stop_loss", stopLoss, "filled", filled, "err", slErr)
+	} else {
+		o.placedStop = stopLoss
+	}
+	if err := at.setTakeProfit(o.symbol, o.side, filled, avgPrice, stopLoss, o.takeProfit, o.trigger); err != nil {
+		at.log.Warn("设置止盈失败", "symbol", o.symbol, "take_profit", o.takeProfit, "err", err)
+	}
+	recordProtectiveOrder(at.journal, at.id, o.symbol, positionSide, "stop_loss", filled, stopLoss, slErr)
+	o.protected = true
+}
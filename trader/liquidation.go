@@ -0,0 +1,66 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/risk"
+	"strings"
+)
+
+// MaintenanceTierSource 可提供合约维持保证金档位的交易器（用于本地估算强平价，不支持时使用默认维持保证金率）
+type MaintenanceTierSource interface {
+	GetMaintenanceTiers(symbol string) ([]risk.MaintenanceTier, error)
+}
+
+// estimateLiquidation 按当前价、杠杆和维持保证金档位估算开仓后的强平价
+// 全仓模式把账户可用余额计入保证金
+func (at *AutoTrader) estimateLiquidation(symbol, side string, price, quantity float64, leverage int, mode string) (float64, error) {
+	var tiers []risk.MaintenanceTier
+	if source, ok := at.trader.(MaintenanceTierSource); ok {
+		var err error
+		if tiers, err = source.GetMaintenanceTiers(symbol); err != nil {
+			at.log.Warn("获取维持保证金档位失败，使用默认维持保证金率", "symbol", symbol, "err", err)
+		}
+	}
+
+	collateral := 0.0
+	if mode == risk.MarginCross {
+		balance, err := at.trader.GetBalance()
+		if err != nil {
+			return 0, fmt.Errorf("获取账户余额失败: %w", err)
+		}
+		collateral, _ = balance["availableBalance"].(float64)
+		collateral -= price * quantity / float64(leverage) // 开仓占用的保证金已计入仓位保证金
+	}
+	return risk.LiquidationPrice(side, price, quantity, leverage, mode, collateral, tiers), nil
+}
+
+// checkLiquidation 开仓前检查估算强平价与入场价的距离（小于配置的ATR倍数时拒绝，获取不到行情时不检查）
+func (at *AutoTrader) checkLiquidation(d *decision.Decision) error {
+	guard := at.config.LiquidationGuard
+	if !guard.Enabled() || d.Leverage < 1 {
+		return nil
+	}
+	data, err := market.Get(d.Symbol)
+	if err != nil || data.LongerTermContext == nil {
+		at.log.Warn("获取行情失败，跳过强平价检查", "symbol", d.Symbol, "err", err)
+		return nil
+	}
+	price, atr := data.CurrentPrice, data.LongerTermContext.ATR14
+	if price <= 0 {
+		return nil
+	}
+
+	side := strings.TrimPrefix(d.Action, "open_")
+	liquidation, err := at.estimateLiquidation(d.Symbol, side, price, d.PositionSizeUSD/price, d.Leverage, guard.MarginMode)
+	if err != nil {
+		return fmt.Errorf("估算强平价失败: %w", err)
+	}
+	at.log.Info("预估强平价", "symbol", d.Symbol, "side", side, "leverage", d.Leverage, "price", price,
+		"liquidation", liquidation, "atr", atr)
+	if err := guard.Check(price, liquidation, atr); err != nil {
+		return fmt.Errorf("%w: %v", ErrLiquidationTooClose, err)
+	}
+	return nil
+}
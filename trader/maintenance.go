@@ -0,0 +1,159 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"nofx/clock"
+	"nofx/notify"
+	"sync"
+	"time"
+)
+
+const (
+	maintenanceErrorBurst     = 3                // 窗口内出现该数量的交易所不可用错误视为进入维护
+	maintenanceErrorWindow    = 2 * time.Minute  // 错误计数窗口
+	maintenancePollInterval   = 30 * time.Second // 交易所状态探测间隔
+	maintenanceRecoveryProbes = 2                // 维护期间连续探测成功该次数视为恢复
+)
+
+// exchangeStatus 交易所维护状态：短时间内连续出现不可用错误（SERVER_ERROR、5xx、维护公告）时进入维护，
+// 维护期间暂停下单、不推送错误告警，探测连续成功后恢复
+type exchangeStatus struct {
+	mu       sync.Mutex
+	failures []time.Time // 窗口内不可用错误的时间
+	since    time.Time   // 进入维护的时间（正常时为零值）
+	lastErr  error       // 触发维护的错误
+	healthy  int         // 维护期间连续探测成功的次数
+
+	onEnter func(err error) // 进入维护时回调（在锁外调用）
+	clock   clock.Clock     // 时钟（为nil时使用系统时钟）
+}
+
+// observe 记录交易所错误，返回本次是否触发进入维护（只统计ErrExchangeUnavailable）
+func (s *exchangeStatus) observe(err error) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	now := clock.Or(s.clock).Now()
+	s.healthy = 0
+	if !errors.Is(err, ErrExchangeUnavailable) || !s.since.IsZero() {
+		s.mu.Unlock()
+		return false
+	}
+	recent := s.failures[:0]
+	for _, t := range s.failures {
+		if now.Sub(t) < maintenanceErrorWindow {
+			recent = append(recent, t)
+		}
+	}
+	s.failures = append(recent, now)
+	entered := len(s.failures) >= maintenanceErrorBurst
+	if entered {
+		s.since = now
+		s.lastErr = err
+		s.failures = nil
+	}
+	s.mu.Unlock()
+
+	if entered && s.onEnter != nil {
+		s.onEnter(err)
+	}
+	return entered
+}
+
+// probeSucceeded 记录一次成功的探测，返回本次是否恢复及维护持续的时长
+func (s *exchangeStatus) probeSucceeded() (bool, time.Duration) {
+	if s == nil {
+		return false, 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = nil
+	if s.since.IsZero() {
+		return false, 0
+	}
+	s.healthy++
+	if s.healthy < maintenanceRecoveryProbes {
+		return false, 0
+	}
+	duration := clock.Or(s.clock).Now().Sub(s.since)
+	s.since = time.Time{}
+	s.lastErr = nil
+	s.healthy = 0
+	return true, duration
+}
+
+// active 是否处于维护中
+func (s *exchangeStatus) active() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.since.IsZero()
+}
+
+// unavailable 维护中时返回下单拒绝错误，否则返回nil
+func (s *exchangeStatus) unavailable() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since.IsZero() {
+		return nil
+	}
+	return &ExchangeError{Kind: ErrExchangeUnavailable, Label: "MAINTENANCE",
+		Message: "交易所维护中，暂停下单", Err: fmt.Errorf("维护开始于 %s: %v", s.since.Format("15:04:05"), s.lastErr)}
+}
+
+// enterMaintenance 进入维护：暂停下单（订单跟踪器直接拒绝），错误告警在恢复前不推送
+func (at *AutoTrader) enterMaintenance(err error) {
+	at.log.Error("交易所疑似维护，暂停下单", "err", err)
+	at.publishRisk("maintenance", "", "", err.Error())
+	at.notifyReason(notify.KindRisk, riskReason("maintenance"), "", "交易所维护中，已暂停下单",
+		fmt.Sprintf("短时间内连续%d次交易所不可用: %v\n恢复前不再推送错误告警，恢复后自动重新对账", maintenanceErrorBurst, err))
+}
+
+// monitorExchangeStatus 定时探测交易所状态：正常时发现不可用错误累计进入维护，维护中探测连续成功后恢复并重新对账
+func (at *AutoTrader) monitorExchangeStatus() {
+	for {
+		select {
+		case <-at.stopCh:
+			return
+		case <-at.clock.After(maintenancePollInterval):
+		}
+
+		var err error
+		if prober, ok := at.trader.(ExchangeProber); ok {
+			_, err = prober.Probe()
+		} else {
+			_, err = at.trader.GetMarketPrice("BTCUSDT")
+		}
+		if err != nil {
+			at.maintenance.observe(err)
+			continue
+		}
+
+		recovered, duration := at.maintenance.probeSucceeded()
+		if !recovered {
+			continue
+		}
+		at.log.Warn("交易所已恢复，恢复下单", "duration", duration.Round(time.Second))
+		if !at.config.ReadOnly {
+			at.cycleMu.Lock()
+			at.reconcile()
+			at.cycleMu.Unlock()
+		}
+		at.notify(notify.KindInfo, "", "交易所已恢复",
+			fmt.Sprintf("维护持续%s，已恢复下单并重新对账持仓和订单", duration.Round(time.Second)))
+		if !at.config.ReadOnly {
+			// 对账后再提交排队的平仓单（持仓已在维护期间被平掉的会被跳过）
+			at.cycleMu.Lock()
+			at.flushDowntimeQueue()
+			at.cycleMu.Unlock()
+		}
+	}
+}
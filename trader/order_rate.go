@@ -0,0 +1,116 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/clock"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/symbols"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PauseSourceOrderRate 下单频率超限触发的暂停
+const PauseSourceOrderRate = "order_rate"
+
+// orderRateGuard 下单频率限制：统计最近一小时提交的订单（进程内存），超过上限时拒绝开仓并触发熔断
+// 熔断后拒绝所有开仓（包括DCA等策略），直到人工恢复交易；平仓和止损止盈不受限制
+type orderRateGuard struct {
+	mu       sync.Mutex
+	limits   risk.OrderRateLimit
+	clock    clock.Clock
+	global   []time.Time            // 最近一小时的下单时间
+	bySymbol map[string][]time.Time // 币种 → 最近一小时的下单时间
+	tripped  bool                   // 已熔断（人工恢复交易时清除）
+
+	onTrip func(symbol, detail string) // 熔断时调用（不持有锁）
+}
+
+func newOrderRateGuard(limits risk.OrderRateLimit, c clock.Clock) *orderRateGuard {
+	return &orderRateGuard{limits: limits, clock: c, bySymbol: make(map[string][]time.Time)}
+}
+
+// setLimits 更新上限（热加载）
+func (g *orderRateGuard) setLimits(limits risk.OrderRateLimit) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limits = limits
+}
+
+// reset 清除熔断状态（人工恢复交易时）
+func (g *orderRateGuard) reset() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tripped = false
+}
+
+// allow 提交订单前检查并计数：熔断中或达到上限时拒绝开仓，平仓照常提交（达到上限时同样触发熔断）
+func (g *orderRateGuard) allow(symbol, action string) error {
+	if g == nil {
+		return nil
+	}
+	symbol = symbols.Canonical(symbol)
+	opening := strings.HasPrefix(action, "open")
+
+	g.mu.Lock()
+	if !g.limits.Enabled() {
+		g.mu.Unlock()
+		return nil
+	}
+	now := g.clock.Now()
+	g.global = recentOrders(g.global, now.Add(-time.Hour))
+	g.bySymbol[symbol] = recentOrders(g.bySymbol[symbol], now.Add(-time.Hour))
+	minuteAgo := now.Add(-time.Minute)
+	reason := g.limits.Exceeded(countSince(g.global, minuteAgo), len(g.global),
+		countSince(g.bySymbol[symbol], minuteAgo), len(g.bySymbol[symbol]))
+
+	trip := reason != "" && !g.tripped
+	if trip {
+		g.tripped = true
+	}
+	wasTripped := g.tripped
+	if !opening || !wasTripped {
+		g.global = append(g.global, now)
+		g.bySymbol[symbol] = append(g.bySymbol[symbol], now)
+	}
+	onTrip := g.onTrip
+	g.mu.Unlock()
+
+	if trip && onTrip != nil {
+		onTrip(symbol, reason)
+	}
+	if !opening || !wasTripped {
+		return nil
+	}
+	if reason == "" {
+		reason = "下单频率熔断中，恢复交易后才能开仓"
+	}
+	return fmt.Errorf("%w: %s", ErrOrderRateExceeded, reason)
+}
+
+// recentOrders 去掉since之前的下单时间（按时间升序）
+func recentOrders(times []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(since) {
+		i++
+	}
+	return times[i:]
+}
+
+// countSince since之后的下单数（按时间升序）
+func countSince(times []time.Time, since time.Time) int {
+	return len(recentOrders(times, since))
+}
+
+// tripOrderRate 下单频率超限熔断：暂停交易并推送风控告警
+func (at *AutoTrader) tripOrderRate(symbol, detail string) {
+	at.log.Error("下单频率超限，暂停交易", "symbol", symbol, "detail", detail)
+	message := fmt.Sprintf("%s: %s\n已拒绝所有开仓，检查后使用resume恢复交易", symbol, detail)
+	at.publishRisk("order_rate", symbol, "", detail)
+	at.notifyReason(notify.KindRisk, riskReason("order_rate"), symbol, "下单频率超限，暂停交易", message)
+	at.Pause(PauseSourceOrderRate, detail, false) // 不撤单：已有持仓的止损止盈保留
+}
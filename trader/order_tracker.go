@@ -0,0 +1,512 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"nofx/clock"
+	"nofx/events"
+	"nofx/fx"
+	"nofx/logging"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/store"
+	"nofx/symbols"
+	"nofx/tracing"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// orderLog 订单生命周期日志
+var orderLog = logging.For("order")
+
+// OrderStatusSource 可查询订单成交状态的交易器（用于确认市价单是否真正成交）
+type OrderStatusSource interface {
+	// GetOrderStatus 查询订单状态，数量单位与下单时一致
+	GetOrderStatus(symbol string, orderID string) (store.OrderUpdate, error)
+}
+
+const (
+	orderConfirmTimeout  = 10 * time.Second       // 等待订单到达终态的最长时间
+	orderConfirmInterval = 500 * time.Millisecond // 轮询间隔
+)
+
+// orderTracker 订单生命周期跟踪：创建→提交→轮询确认成交，每次状态变化都持久化到交易日志
+type orderTracker struct {
+	trader   Trader
+	journal  *store.Store
+	traderID string
+	events   *events.Bus     // 事件总线（订单状态、持仓变化和通知）
+	band     risk.PriceBand  // 止损/止盈触发价合理性检查
+	status   *exchangeStatus // 交易所维护状态（维护中直接拒绝下单）
+	clock    clock.Clock     // 时钟（成交确认的轮询等待）
+
+	allocator *capitalAllocator                                                  // 多策略资金分配（开仓前检查策略额度，未启用时为nil）
+	bookCheck func(symbol, side string, addValue float64) error                  // 跨交易所组合敞口检查（为nil时不检查）
+	blackout  func() error                                                       // 禁止开仓时段检查（为nil时不检查）
+	tradeLoss func(strategy, symbol, side string, quantity, price float64) error // 加仓单的单笔亏损上限检查（为nil时不检查）
+
+	textQueue symbolQueue // 同一币种的订单文本设置到下单完成之间不被其他策略的下单覆盖
+
+	preOrder []namedPreOrderHook // 下单前钩子（创建trader时安装）
+	rate     *orderRateGuard     // 下单频率限制（为nil时不限制）
+	downtime *downtimeQueue      // 维护期间的平仓单队列（为nil时不排队）
+}
+
+// newOrderTracker 创建订单跟踪器（journal为nil时只做成交确认，不持久化；bus为nil时不发布事件）
+func newOrderTracker(t Trader, journal *store.Store, traderID string, bus *events.Bus) *orderTracker {
+	return &orderTracker{trader: t, journal: journal, traderID: traderID, events: bus, clock: clock.Real}
+}
+
+// Place 提交订单并确认成交，成交后同步持仓生命周期
+// price为下单时的参考价（交易所未返回成交均价时使用），submit执行实际下单
+func (t *orderTracker) Place(ctx context.Context, strategy, symbol, action string, quantity, price float64, leverage int,
+	submit func() (map[string]interface{}, error)) (order map[string]interface{}, update store.OrderUpdate, err error) {
+
+	ctx, span := tracing.Start(ctx, "order.place",
+		attribute.String("trader", t.traderID),
+		attribute.String("symbol", symbol),
+		attribute.String("action", action),
+		attribute.String("strategy", strategy),
+		attribute.Float64("quantity", quantity))
+	defer func() {
+		span.SetAttributes(attribute.String("order.state", update.State), attribute.Float64("order.filled", update.FilledQty))
+		tracing.End(span, err)
+	}()
+
+	start := time.Now()
+	placed, order, err := t.submit(ctx, strategy, symbol, action, quantity, price, leverage, submit)
+	if err != nil {
+		err = t.queueForRecovery(ctx, strategy, symbol, action, quantity, price, leverage, submit, err)
+		return order, store.OrderUpdate{State: store.OrderRejected, Detail: err.Error()}, err
+	}
+	span.SetAttributes(attribute.String("order.id", placed.orderID))
+
+	confirmed := t.canConfirm(placed.orderID)
+	_, confirmSpan := tracing.Start(ctx, "order.confirm", attribute.String("order.id", placed.orderID))
+	if aggregate, ok := aggregatedFill(order); ok {
+		// 由多笔订单组成的下单（如追价重挂），交易器已汇总成交，不再只按最后一笔订单确认
+		confirmed = true
+		update = aggregate
+		t.transition(placed.ref, update)
+	} else {
+		update = t.confirm(placed.ref, symbol, placed.orderID, quantity, price)
+	}
+	confirmSpan.End()
+	orderLog.Info("订单已确认", "trader", t.traderID, "symbol", symbol, "action", action, "order_id", placed.orderID,
+		"state", update.State, "filled", update.FilledQty, "avg_price", update.AvgPrice,
+		"latency_ms", time.Since(start).Milliseconds())
+	if confirmed && update.FilledQty <= 0 && !store.IsTerminalOrderState(update.State) {
+		// 状态未知（查询持续失败或超时）不等于未成交：按交易所持仓变化确认实际成交，
+		// 无法确认时返回ErrOrderUnconfirmed（不按未成交重试，避免重复开仓）
+		filled, avgPrice, ok := t.positionFill(symbol, placed.side, action)
+		if !ok {
+			t.publishOrder(placed, symbol, action, quantity, price, leverage, update)
+			err = fmt.Errorf("%w: %s（状态: %s）", ErrOrderUnconfirmed, placed.orderID, update.State)
+			t.notifyReason(notify.KindError, placed.reason, symbol, fmt.Sprintf("%s %s 状态未知", symbol, action), err.Error())
+			return order, update, err
+		}
+		orderLog.Warn("订单状态无法确认，按交易所持仓变化确认成交", "trader", t.traderID, "symbol", symbol, "action", action,
+			"order_id", placed.orderID, "filled", filled, "avg_price", avgPrice)
+		update.FilledQty = filled
+		if avgPrice > 0 {
+			update.AvgPrice = avgPrice
+		}
+	}
+	t.publishOrder(placed, symbol, action, quantity, price, leverage, update)
+	if confirmed && update.FilledQty <= 0 {
+		err = fmt.Errorf("%w: %s（状态: %s %s）", ErrOrderNotFilled, placed.orderID, update.State, update.Detail)
+		t.notifyReason(notify.KindError, placed.reason, symbol, fmt.Sprintf("%s %s 未成交", symbol, action), err.Error())
+		return order, update, err
+	}
+
+	t.recordExecution(ctx, placed.ref, symbol, action, order, price, update)
+
+	// 无法确认时（如平仓数量为0表示全部平仓）按已成交处理
+	t.syncPosition(placed, symbol, action, leverage, update)
+	return order, update, nil
+}
+
+// placedOrder 已提交到交易所的订单
+type placedOrder struct {
+	ref       int64    // 交易日志中的订单编号
+	tag       OrderTag // 归因标记
+	reason    string   // 原因代码
+	reasoning string   // 决策理由（AI或外部信号给出，策略和风控下单为空）
+	side      string   // long/short
+	orderID   string   // 交易所订单ID
+	quantity  float64  // 下单数量（平仓为0表示全部平仓）
+}
+
+// submit 写入交易日志并下单（维护状态、资金分配额度、组合敞口、下单频率检查不通过时拒绝），下单失败时记录并推送
+func (t *orderTracker) submit(ctx context.Context, strategy, symbol, action string, quantity, price float64, leverage int,
+	submit func() (map[string]interface{}, error)) (placed placedOrder, order map[string]interface{}, err error) {
+
+	placed.side, placed.quantity = "long", quantity
+	if strings.HasSuffix(action, "short") {
+		placed.side = "short"
+	}
+	placed.tag = decisionTag(ctx, strategy)
+	placed.reason = orderReason(ctx, strategy, action)
+	placed.reasoning = decisionReasoning(ctx)
+	var clientID string
+	if _, ok := t.trader.(ClientOrderLookup); ok {
+		if _, tagged := t.trader.(OrderTagger); tagged {
+			placed.tag.ClientID = newClientOrderID(t.clock.Now())
+			clientID = placed.tag.Text()
+		}
+	}
+
+	placed.ref, err = t.journal.CreateOrder(store.Order{
+		TraderID: t.traderID,
+		ClientID: clientID,
+		Symbol:   symbol,
+		Action:   action,
+		Side:     placed.side,
+		Quantity: quantity,
+		Price:    price,
+		Leverage: leverage,
+		Strategy: strategy,
+
+		DecisionID:    placed.tag.DecisionID,
+		PromptVersion: placed.tag.PromptVersion,
+		Reason:        placed.reason,
+		Reasoning:     placed.reasoning,
+	})
+	if err != nil {
+		orderLog.Warn("写入交易日志失败", "trader", t.traderID, "symbol", symbol, "err", err)
+	}
+
+	start := time.Now()
+	_, submitSpan := tracing.Start(ctx, "order.submit")
+	err = t.status.unavailable()
+	if err == nil {
+		err = t.beforeOrder(ctx, OrderIntent{TraderID: t.traderID, Symbol: symbol, Action: action, Side: placed.side,
+			Quantity: quantity, Price: price, Leverage: leverage, Strategy: strategy, DecisionID: placed.tag.DecisionID})
+	}
+	if err == nil && strings.HasPrefix(action, "open") {
+		// 禁止开仓时段对所有策略的开仓单生效（对冲腿降低组合风险，不受限制）
+		if t.blackout != nil && strategy != "hedge" {
+			if blackoutErr := t.blackout(); blackoutErr != nil {
+				err = fmt.Errorf("%w: %v", ErrEntryBlackout, blackoutErr)
+			}
+		}
+		if err == nil && t.tradeLoss != nil {
+			err = t.tradeLoss(strategy, symbol, placed.side, quantity, price)
+		}
+		if err == nil {
+			err = t.allocator.checkOpen(t.trader, strategy, symbol, placed.side, quantity*price)
+		}
+		if err == nil && t.bookCheck != nil {
+			if bookErr := t.bookCheck(symbol, placed.side, quantity*price); bookErr != nil {
+				err = fmt.Errorf("%w: %v", ErrBookLimit, bookErr)
+			}
+		}
+	}
+	if err == nil {
+		err = t.rate.allow(symbol, action)
+	}
+	if err == nil {
+		// 两阶段下单：发送前记录意图，重启时据此判断订单是否可能已到达交易所
+		if markErr := t.journal.MarkOrderSent(placed.ref); markErr != nil {
+			orderLog.Warn("记录下单意图失败", "trader", t.traderID, "symbol", symbol, "ref", placed.ref, "err", markErr)
+		}
+		order, err = t.submitIdempotent(placed.tag, symbol, action, submit)
+		t.status.observe(err)
+	}
+	tracing.End(submitSpan, err)
+	if err != nil {
+		orderLog.Error("下单失败", "trader", t.traderID, "symbol", symbol, "action", action, "quantity", quantity,
+			"latency_ms", time.Since(start).Milliseconds(), "err", err)
+		rejected := store.OrderUpdate{State: store.OrderRejected, Detail: err.Error()}
+		t.transition(placed.ref, rejected)
+		t.publishOrder(placed, symbol, action, quantity, price, leverage, rejected)
+		t.notifyRejected(symbol, action, placed.reason, err)
+		return placed, order, err
+	}
+
+	if order != nil && order["orderId"] != nil {
+		placed.orderID = fmt.Sprintf("%v", order["orderId"])
+	}
+	submitted := store.OrderUpdate{State: store.OrderSubmitted, OrderID: placed.orderID}
+	t.transition(placed.ref, submitted)
+	t.publishOrder(placed, symbol, action, quantity, price, leverage, submitted)
+	return placed, order, nil
+}
+
+// submitTagged 下单时在订单文本中附带策略和决策ID（交易器不支持时直接下单），成交同步时据此归因
+func (t *orderTracker) submitTagged(tag OrderTag, symbol string, submit func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	tagger, ok := t.trader.(OrderTagger)
+	if !ok {
+		return submit()
+	}
+	defer t.textQueue.enter(symbols.Canonical(symbol))()
+	tagger.SetOrderText(symbol, tag.Text())
+	defer tagger.SetOrderText(symbol, "")
+	return submit()
+}
+
+// aggregatedFill 交易器返回的多笔订单成交汇总（filledQty/avgPrice/leftQty，未返回时ok为false）
+func aggregatedFill(order map[string]interface{}) (update store.OrderUpdate, ok bool) {
+	filled, ok := order["filledQty"].(float64)
+	if !ok {
+		return update, false
+	}
+	update = store.OrderUpdate{State: store.OrderFilled, FilledQty: filled}
+	update.AvgPrice, _ = order["avgPrice"].(float64)
+	if left, _ := order["leftQty"].(float64); left > 0 {
+		update.State = store.OrderCancelled
+		update.Detail = fmt.Sprintf("剩余%v未成交", left)
+	}
+	return update, true
+}
+
+// withFill 在下单结果中附上成交汇总（filledQty/avgPrice/leftQty，格式见aggregatedFill），
+// 交易所下单响应已包含最终成交时使用，不必再轮询订单
+func withFill(order map[string]interface{}, filled, avgPrice, left float64) map[string]interface{} {
+	if order == nil {
+		order = make(map[string]interface{})
+	}
+	order["filledQty"] = filled
+	order["avgPrice"] = avgPrice
+	order["leftQty"] = math.Max(left, 0)
+	return order
+}
+
+// checkTriggerPrice 下单前按当前价检查止损/止盈触发价，返回实际使用的价格
+// 方向错误或偏离过大时返回ErrPriceOutOfBand（配置clamp时偏离过大收紧到边界价），获取不到当前价时不检查
+func (t *orderTracker) checkTriggerPrice(symbol, positionSide, kind string, price float64) (float64, error) {
+	side := strings.ToLower(positionSide)
+	mark, err := t.trader.GetMarketPrice(symbol)
+	if err != nil {
+		orderLog.Warn("获取当前价失败，跳过价格检查", "trader", t.traderID, "symbol", symbol, "err", err)
+		mark = 0
+	}
+	checked, err := t.band.Check(kind, side, mark, price)
+	if err != nil {
+		orderLog.Warn("价格检查未通过，拒绝下单", "trader", t.traderID, "symbol", symbol, "side", side, "kind", kind, "err", err)
+		return 0, fmt.Errorf("%w: %v", ErrPriceOutOfBand, err)
+	}
+	if checked != price {
+		orderLog.Warn("价格偏离过大，已收紧到边界价", "trader", t.traderID, "symbol", symbol, "side", side, "kind", kind,
+			"price", price, "clamped", checked, "mark", mark)
+	}
+	return checked, nil
+}
+
+// canConfirm 是否能向交易所确认订单状态
+func (t *orderTracker) canConfirm(orderID string) bool {
+	_, ok := t.trader.(OrderStatusSource)
+	return ok && orderID != ""
+}
+
+// confirm 轮询订单直到终态或超时
+// 交易器不支持查询时，按市价IOC单全部成交处理（与原有行为一致）
+func (t *orderTracker) confirm(ref int64, symbol, orderID string, quantity, price float64) store.OrderUpdate {
+	if !t.canConfirm(orderID) {
+		update := store.OrderUpdate{State: store.OrderFilled, FilledQty: quantity, AvgPrice: price}
+		t.transition(ref, update)
+		return update
+	}
+
+	source := t.trader.(OrderStatusSource)
+	update := store.OrderUpdate{State: store.OrderSubmitted}
+	deadline := t.clock.Now().Add(orderConfirmTimeout)
+	for {
+		latest, err := source.GetOrderStatus(symbol, orderID)
+		if err != nil {
+			orderLog.Warn("查询订单状态失败", "symbol", symbol, "order_id", orderID, "err", err)
+		} else {
+			if latest.State != update.State || latest.FilledQty != update.FilledQty {
+				t.transition(ref, latest)
+			}
+			update = latest
+		}
+
+		if store.IsTerminalOrderState(update.State) || t.clock.Now().After(deadline) {
+			break
+		}
+		t.clock.Sleep(orderConfirmInterval)
+	}
+
+	if update.AvgPrice <= 0 {
+		update.AvgPrice = price
+	}
+	if !store.IsTerminalOrderState(update.State) {
+		orderLog.Warn("订单未在超时内到达终态，等待后续对账", "symbol", symbol, "order_id", orderID,
+			"timeout", orderConfirmTimeout, "state", update.State)
+	}
+	return update
+}
+
+// positionFill 订单状态无法确认时按交易所持仓（跳过缓存）推算成交数量（张）：开仓为交易所持仓超出交易日志持仓的部分，
+// 平仓为交易日志持仓减少的部分；原来没有持仓的开仓以交易所持仓均价作为成交均价（否则avgPrice为0）
+// 查询失败或持仓没有变化时ok为false
+func (t *orderTracker) positionFill(symbol, side, action string) (filled, avgPrice float64, ok bool) {
+	if invalidator, ok := t.trader.(PositionCacheInvalidator); ok {
+		invalidator.InvalidatePositions()
+	}
+	positions, err := t.trader.GetPositions()
+	if err != nil {
+		orderLog.Warn("查询持仓失败，无法确认订单成交", "trader", t.traderID, "symbol", symbol, "err", err)
+		return 0, 0, false
+	}
+	var held, entryPrice float64
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			held = math.Abs(floatValue(pos["positionAmt"]))
+			entryPrice = floatValue(pos["entryPrice"])
+		}
+	}
+	record, err := t.journal.GetOpenPosition(t.traderID, symbol, side)
+	if err != nil {
+		orderLog.Warn("读取持仓记录失败，无法确认订单成交", "trader", t.traderID, "symbol", symbol, "err", err)
+		return 0, 0, false
+	}
+	// 持仓记录带有张数时与交易所持仓直接比较；旧记录只有基础币数量，按合约乘数换算（换算的浮点误差不算成交）
+	var journaled float64
+	tolerance := 1e-9
+	if record != nil {
+		journaled = positionContracts(t.trader, record)
+		if record.Contracts <= 0 {
+			tolerance = 1e-6
+		}
+	}
+
+	filled = journaled - held
+	if strings.HasPrefix(action, "open") {
+		filled = held - journaled
+		if journaled == 0 {
+			avgPrice = entryPrice
+		}
+	}
+	if filled <= math.Max(held, journaled)*tolerance {
+		return 0, 0, false
+	}
+	return filled, avgPrice, true
+}
+
+// transition 持久化状态变化（失败只记录日志，不影响交易）
+func (t *orderTracker) transition(ref int64, update store.OrderUpdate) {
+	if err := t.journal.TransitionOrder(ref, update); err != nil {
+		orderLog.Warn("更新订单状态失败", "ref", ref, "state", update.State, "err", err)
+	}
+}
+
+// syncPosition 按实际成交更新持仓生命周期，并推送开平仓通知
+func (t *orderTracker) syncPosition(placed placedOrder, symbol, action string, leverage int, update store.OrderUpdate) {
+	tag, reason, side := placed.tag, placed.reason, placed.side
+	strategy := tag.Strategy
+	change := events.PositionEvent{Change: "open", Side: side, Strategy: strategy, Quantity: update.FilledQty,
+		Price: update.AvgPrice, Leverage: leverage}
+	if strings.HasPrefix(action, "open") {
+		t.notifyReason(notify.KindEntry, reason, symbol, fmt.Sprintf("开仓 %s %s", symbol, strings.ToUpper(side)),
+			withReasoningLine(fmt.Sprintf("数量: %.4f | 均价: %.4f | 杠杆: %dx | 策略: %s", update.FilledQty, update.AvgPrice, leverage, strategy),
+				placed.reasoning))
+	} else {
+		message := fmt.Sprintf("均价: %.4f | 策略: %s", update.AvgPrice, strategy)
+		change.Change, change.Reason = "close", action
+		if position, _ := t.journal.GetOpenPosition(t.traderID, symbol, side); position != nil {
+			if placed.quantity > 0 {
+				// 部分平仓只计算平掉部分的盈亏
+				position.Quantity = math.Min(position.Quantity, contractSpec(t.trader, symbol).BaseQuantity(update.FilledQty))
+			}
+			change.EntryPrice, change.GrossPnL = position.EntryPrice, position.GrossPnL(update.AvgPrice)
+			message = fmt.Sprintf("入场: %.4f → 出场: %.4f | 毛盈亏: %+.2f USDT%s | 策略: %s",
+				position.EntryPrice, update.AvgPrice, change.GrossPnL, fx.Suffix(change.GrossPnL, true), strategy)
+		}
+		t.notifyReason(notify.KindExit, reason, symbol, fmt.Sprintf("平仓 %s %s", symbol, strings.ToUpper(side)),
+			withReasoningLine(message, placed.reasoning))
+	}
+	t.events.Publish(events.Event{Type: events.TypePosition, TraderID: t.traderID, Symbol: symbol, Position: &change})
+
+	if t.journal == nil {
+		return
+	}
+
+	var err error
+	if strings.HasPrefix(action, "open") {
+		err = t.journal.OpenPosition(store.Position{
+			TraderID:   t.traderID,
+			Symbol:     symbol,
+			Side:       side,
+			Quantity:   contractSpec(t.trader, symbol).BaseQuantity(update.FilledQty), // 成交张数换算为基础币数量
+			Contracts:  update.FilledQty,
+			EntryPrice: update.AvgPrice,
+			Leverage:   leverage,
+			Strategy:   strategy,
+
+			DecisionID:    tag.DecisionID,
+			PromptVersion: tag.PromptVersion,
+		})
+	} else if placed.quantity > 0 {
+		// 指定数量的平仓（如ADL减仓）按成交数量拆分持仓记录，平完剩余数量时关闭记录
+		err = t.journal.ReducePosition(t.traderID, symbol, side, contractSpec(t.trader, symbol).BaseQuantity(update.FilledQty),
+			update.FilledQty, update.AvgPrice)
+	} else {
+		err = t.journal.ClosePosition(t.traderID, symbol, side, update.AvgPrice)
+	}
+	if err != nil {
+		orderLog.Warn("写入持仓记录失败", "trader", t.traderID, "symbol", symbol, "side", side, "err", err)
+	}
+}
+
+// notifyRejected 按错误分类推送下单失败：保证金不足/数量过小/超出资金分配额度/组合敞口属于风控类，平仓时持仓已不存在不推送
+func (t *orderTracker) notifyRejected(symbol, action, reason string, err error) {
+	kind := notify.KindError
+	switch {
+	case errors.Is(err, ErrPositionNotFound):
+		return
+	case errors.Is(err, ErrExchangeUnavailable) && t.status.active():
+		return // 维护期间不逐单告警（进入维护时已推送）
+	case errors.Is(err, ErrOrderRateExceeded):
+		return // 熔断时已推送
+	case errors.Is(err, ErrReadOnly):
+		t.notifyReason(notify.KindInfo, reason, symbol, fmt.Sprintf("%s %s 已拦截（只读模式）", symbol, action), "")
+		return
+	case errors.Is(err, ErrInsufficientMargin), errors.Is(err, ErrOrderTooSmall), errors.Is(err, ErrAllocationExceeded),
+		errors.Is(err, ErrBookLimit), errors.Is(err, ErrEntryBlackout), errors.Is(err, ErrLeverageLocked):
+		kind = notify.KindRisk
+	}
+	t.notifyReason(kind, reason, symbol, fmt.Sprintf("%s %s 下单失败", symbol, action), err.Error())
+}
+
+// publishOrder 发布订单状态变化事件
+func (t *orderTracker) publishOrder(placed placedOrder, symbol, action string, quantity, price float64, leverage int,
+	update store.OrderUpdate) {
+	order := &events.OrderEvent{Action: action, Side: placed.side, Strategy: placed.tag.Strategy, OrderID: placed.orderID,
+		State: update.State, Quantity: quantity, Price: price, Leverage: leverage, FilledQty: update.FilledQty,
+		AvgPrice: update.AvgPrice, DecisionID: placed.tag.DecisionID, Reason: placed.reason, Reasoning: placed.reasoning}
+	if update.Reason != "" {
+		order.Reason = update.Reason // 系统主动撤单等状态变化带有自己的原因代码
+	}
+	if update.State == store.OrderRejected {
+		order.Error = update.Detail
+	}
+	t.events.Publish(events.Event{Type: events.TypeOrder, TraderID: t.traderID, Symbol: symbol, Order: order})
+}
+
+// withReasoningLine 在通知正文后附上决策理由（没有理由时原样返回）
+func withReasoningLine(message, reasoning string) string {
+	if reasoning == "" {
+		return message
+	}
+	return message + "\n理由: " + reasoning
+}
+
+// notify 发布通知事件（维护期间不发布错误通知，由订阅事件总线的通知渠道推送）
+func (t *orderTracker) notify(kind notify.Kind, symbol, title, message string) {
+	t.notifyReason(kind, "", symbol, title, message)
+}
+
+// notifyReason 发布带原因代码的通知事件
+func (t *orderTracker) notifyReason(kind notify.Kind, reason, symbol, title, message string) {
+	if kind == notify.KindError && t.status.active() {
+		return
+	}
+	publishNotice(t.events, notify.Event{Kind: kind, TraderID: t.traderID, Symbol: symbol, Title: title, Message: message,
+		Reason: reason})
+}
@@ -0,0 +1,103 @@
+package trader
+
+import (
+	"math"
+	"nofx/store"
+	"path/filepath"
+	"testing"
+)
+
+// contractTrader 只提供持仓和合约规格的交易器（其他方法未实现）
+type contractTrader struct {
+	Trader
+	spec      ContractSpec
+	contracts float64 // 交易所多仓张数
+}
+
+func (c *contractTrader) GetPositions() ([]map[string]interface{}, error) {
+	if c.contracts == 0 {
+		return nil, nil
+	}
+	return []map[string]interface{}{{"symbol": "BTCUSDT", "side": "long", "positionAmt": c.contracts, "entryPrice": 60000.0}}, nil
+}
+
+func (c *contractTrader) GetContractSpec(string) (ContractSpec, error) {
+	return c.spec, nil
+}
+
+func TestPositionFillWithQuantoMultiplier(t *testing.T) {
+	journal, err := store.Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	// 0.0001 BTC/张：3张记为0.0003 BTC，按乘数换算回张数有浮点误差（2.9999999999999996）
+	exchange := &contractTrader{spec: ContractSpec{Multiplier: 0.0001, OrderSizeMin: 1}}
+	tracker := newOrderTracker(exchange, journal, "t1", nil)
+	long := placedOrder{side: "long"}
+
+	expectFill := func(stage, action string, wantFilled float64, wantOK bool) {
+		t.Helper()
+		filled, _, ok := tracker.positionFill("BTCUSDT", "long", action)
+		if ok != wantOK || filled != wantFilled {
+			t.Fatalf("%s: positionFill = %v, %v，期望 %v, %v", stage, filled, ok, wantFilled, wantOK)
+		}
+	}
+
+	exchange.contracts = 3
+	expectFill("首次开仓", "open_long", 3, true)
+	tracker.syncPosition(long, "BTCUSDT", "open_long", 10, store.OrderUpdate{FilledQty: 3, AvgPrice: 60000})
+
+	record, err := journal.GetOpenPosition("t1", "BTCUSDT", "long")
+	if err != nil || record == nil {
+		t.Fatalf("读取持仓记录: %v, %v", record, err)
+	}
+	if record.Contracts != 3 || math.Abs(record.Quantity-0.0003) > 1e-12 {
+		t.Fatalf("持仓记录 %.10f BTC / %v张，期望0.0003 BTC / 3张", record.Quantity, record.Contracts)
+	}
+	expectFill("持仓与记录一致", "open_long", 0, false)
+
+	// 加仓2张：按张数直接比较
+	exchange.contracts = 5
+	expectFill("加仓", "open_long", 2, true)
+	tracker.syncPosition(long, "BTCUSDT", "open_long", 10, store.OrderUpdate{FilledQty: 2, AvgPrice: 61000})
+
+	// 部分平仓1张后记录剩4张
+	exchange.contracts = 4
+	expectFill("部分平仓", "close_long", 1, true)
+	tracker.syncPosition(placedOrder{side: "long", quantity: 1}, "BTCUSDT", "close_long", 0,
+		store.OrderUpdate{FilledQty: 1, AvgPrice: 62000})
+	if record, _ = journal.GetOpenPosition("t1", "BTCUSDT", "long"); record == nil || record.Contracts != 4 {
+		t.Fatalf("部分平仓后持仓记录 %+v，期望4张", record)
+	}
+	expectFill("部分平仓后一致", "close_long", 0, false)
+
+	// 交易所持仓全部平掉
+	exchange.contracts = 0
+	expectFill("全部平仓", "close_long", 4, true)
+}
+
+func TestPositionFillLegacyRecord(t *testing.T) {
+	journal, err := store.Open(filepath.Join(t.TempDir(), "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	// 旧记录没有张数，只有基础币数量
+	if err := journal.OpenPosition(store.Position{TraderID: "t1", Symbol: "BTCUSDT", Side: "long", Quantity: 0.0007, EntryPrice: 60000}); err != nil {
+		t.Fatal(err)
+	}
+	exchange := &contractTrader{spec: ContractSpec{Multiplier: 0.0001}, contracts: 7}
+	tracker := newOrderTracker(exchange, journal, "t1", nil)
+
+	if filled, _, ok := tracker.positionFill("BTCUSDT", "long", "open_long"); ok {
+		t.Fatalf("持仓未变化时不应确认成交: %v", filled)
+	}
+	exchange.contracts = 9
+	filled, _, ok := tracker.positionFill("BTCUSDT", "long", "open_long")
+	if !ok || math.Abs(filled-2) > 1e-9 {
+		t.Fatalf("positionFill = %v, %v，期望约2张", filled, ok)
+	}
+}
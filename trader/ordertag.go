@@ -0,0 +1,179 @@
+package trader
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// OrderTagger 支持在订单上附带自定义文本的交易器（Gate的text字段），用于从成交反查订单所属的策略和决策
+type OrderTagger interface {
+	// SetOrderText 设置该币种后续订单的自定义文本（为空表示不附带）
+	SetOrderText(symbol, text string)
+}
+
+// OrderTextSource 可按订单ID查询下单时自定义文本的交易器（交易日志中没有该订单时用于归因）
+type OrderTextSource interface {
+	GetOrderText(symbol string, orderID string) (string, error)
+}
+
+// OrderTag 订单归因标记：策略、决策ID和提示词版本
+// 编码为订单文本 "t-<策略代码>-<决策ID>[-<提示词版本>]"，交易所限制 "t-" 之后最多28个字符；
+// 带客户端订单ID时固定为4段 "t-<策略代码>-<决策ID>-<提示词版本>-<客户端订单ID>"（空段保留）
+type OrderTag struct {
+	Strategy      string `json:"strategy"`
+	DecisionID    string `json:"decision_id,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+	ClientID      string `json:"client_id,omitempty"` // 每次下单唯一，使订单文本可作为客户端订单ID查询
+}
+
+// orderTextMaxLen 订单文本 "t-" 前缀之后的最大长度
+const orderTextMaxLen = 28
+
+// strategyCodes 策略 → 订单文本中的短代码（未列出的策略使用清理后的策略名）
+var strategyCodes = map[string]string{
+	"ai":              "ai",
+	"webhook":         "wh",
+	"dca":             "dca",
+	"pyramid":         "pyr",
+	"funding_harvest": "fh",
+	"basis":           "bs",
+	"hedge":           "hg",
+	"time_exit":       "tx",
+	"manual":          "man",
+}
+
+// Text 编码为订单文本（策略为空时返回空字符串）
+func (tag OrderTag) Text() string {
+	code := strategyCodes[tag.Strategy]
+	if code == "" {
+		code = sanitizeTagPart(tag.Strategy)
+	}
+	if code == "" {
+		return ""
+	}
+	if client := sanitizeTagPart(tag.ClientID); client != "" {
+		// 超长时依次截短提示词版本、决策ID、策略代码，客户端订单ID保持完整
+		decision, version := sanitizeTagPart(tag.DecisionID), ""
+		if decision != "" {
+			version = sanitizeTagPart(tag.PromptVersion)
+		}
+		budget := orderTextMaxLen - len(client) - 3
+		version = truncateTagPart(version, budget-len(code)-len(decision))
+		decision = truncateTagPart(decision, budget-len(code)-len(version))
+		code = truncateTagPart(code, budget-len(decision)-len(version))
+		return "t-" + strings.Join([]string{code, decision, version, client}, "-")
+	}
+	parts := []string{code}
+	if id := sanitizeTagPart(tag.DecisionID); id != "" {
+		parts = append(parts, id)
+		if version := sanitizeTagPart(tag.PromptVersion); version != "" {
+			parts = append(parts, version)
+		}
+	}
+	text := strings.Join(parts, "-")
+	if len(text) > orderTextMaxLen {
+		text = text[:orderTextMaxLen]
+	}
+	return "t-" + text
+}
+
+// ParseOrderTag 解析订单文本（不是本系统下的订单时返回false）
+func ParseOrderTag(text string) (OrderTag, bool) {
+	if !strings.HasPrefix(text, "t-") {
+		return OrderTag{}, false
+	}
+	parts := strings.Split(strings.TrimPrefix(text, "t-"), "-")
+	if parts[0] == "" {
+		return OrderTag{}, false
+	}
+	tag := OrderTag{Strategy: parts[0]}
+	for strategy, code := range strategyCodes {
+		if code == parts[0] {
+			tag.Strategy = strategy
+			break
+		}
+	}
+	if len(parts) > 1 {
+		tag.DecisionID = parts[1]
+	}
+	if len(parts) > 2 {
+		tag.PromptVersion = parts[2]
+	}
+	if len(parts) > 3 {
+		tag.ClientID = parts[3]
+	}
+	return tag, true
+}
+
+// sanitizeTagPart 只保留交易所允许的字符（字母、数字、下划线和点），"-" 作为分隔符被移除
+func sanitizeTagPart(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '_' || r == '.' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// truncateTagPart 截断到n个字符（n<=0时返回空字符串）
+func truncateTagPart(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// lastClientOrderID 最近分配的客户端订单ID（微秒时间戳，单调递增保证进程内唯一）
+var lastClientOrderID atomic.Int64
+
+// newClientOrderID 客户端订单ID（36进制微秒时间戳，同一时刻多次下单时顺延）
+func newClientOrderID(now time.Time) string {
+	for {
+		last := lastClientOrderID.Load()
+		next := max(now.UnixMicro(), last+1)
+		if lastClientOrderID.CompareAndSwap(last, next) {
+			return strconv.FormatInt(next, 36)
+		}
+	}
+}
+
+// newDecisionID 决策ID（决策时间的36进制秒数，同一trader内唯一，写入订单文本和决策日志）
+func newDecisionID(at time.Time) string {
+	return strconv.FormatInt(at.Unix(), 36)
+}
+
+// decisionKey 决策ID和提示词版本在context中的键
+type decisionKey struct{}
+
+// withDecision 记录本次下单所属的决策（AI决策周期、外部信号、策略看守周期）
+func withDecision(ctx context.Context, decisionID, promptVersion string) context.Context {
+	return context.WithValue(ctx, decisionKey{}, OrderTag{DecisionID: decisionID, PromptVersion: promptVersion})
+}
+
+// reasoningKey 决策理由在context中的键
+type reasoningKey struct{}
+
+// withReasoning 记录本次下单所属决策的理由（随订单保存，显示在开平仓通知和仪表盘中）
+func withReasoning(ctx context.Context, reasoning string) context.Context {
+	return context.WithValue(ctx, reasoningKey{}, reasoning)
+}
+
+// decisionReasoning 订单所属决策的理由（context中没有时为空，如策略看守和风控下单）
+func decisionReasoning(ctx context.Context) string {
+	reasoning, _ := ctx.Value(reasoningKey{}).(string)
+	return reasoning
+}
+
+// decisionTag 订单的归因标记（context中没有决策时只包含策略）
+func decisionTag(ctx context.Context, strategy string) OrderTag {
+	tag, _ := ctx.Value(decisionKey{}).(OrderTag)
+	tag.Strategy = strategy
+	return tag
+}
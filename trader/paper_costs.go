@@ -0,0 +1,188 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/market"
+	"nofx/store"
+	"time"
+)
+
+const (
+	paperDefaultMakerFee = 0.0002    // 交易器不提供费率时使用的挂单费率
+	paperDefaultTakerFee = 0.0005    // 交易器不提供费率时使用的吃单费率
+	paperFeeCacheTTL     = time.Hour // 费率缓存有效期（包括查询失败时的默认费率，避免每笔成交重试）
+	paperSpecCacheTTL    = time.Hour // 合约规格缓存有效期（同上）
+	paperLedgerHistory   = 1000      // 保留最近多少条成交/费用流水
+)
+
+// paperFeeRates 缓存的费率
+type paperFeeRates struct {
+	maker, taker float64
+	fetched      time.Time
+}
+
+// paperSpec 缓存的合约规格
+type paperSpec struct {
+	spec    ContractSpec
+	fetched time.Time
+}
+
+// paperFundingAt 缓存的资金费结算周期（到下一次结算前有效）
+type paperFundingAt struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// paperLedger 模拟成交和费用流水，通过TradeHistorySource同步到交易日志，与实盘一样归集到每笔交易
+type paperLedger struct {
+	Fills []store.Fill `json:"fills"`
+	Costs []store.Cost `json:"costs"`
+}
+
+// feeRates 账户在真实交易所的费率（交易器不提供或查询失败时使用默认费率，调用方持有锁）
+func (t *PaperTrader) feeRates(symbol string) (maker, taker float64) {
+	if cached, ok := t.fees[symbol]; ok && time.Since(cached.fetched) < paperFeeCacheTTL {
+		return cached.maker, cached.taker
+	}
+	maker, taker = paperDefaultMakerFee, paperDefaultTakerFee
+	if source, ok := t.market.(FeeRateSource); ok {
+		if m, tk, err := source.GetFeeRates(symbol); err != nil {
+			paperLog.Warn("获取真实费率失败，使用默认费率", "symbol", symbol, "maker", maker, "taker", taker, "err", err)
+		} else {
+			maker, taker = m, tk
+		}
+	}
+	t.fees[symbol] = paperFeeRates{maker: maker, taker: taker, fetched: time.Now()}
+	return maker, taker
+}
+
+// spec 真实交易所的合约规格（交易器不提供或查询失败时按1张=1个币计算，调用方持有锁）
+func (t *PaperTrader) spec(symbol string) ContractSpec {
+	if cached, ok := t.specs[symbol]; ok && time.Since(cached.fetched) < paperSpecCacheTTL {
+		return cached.spec
+	}
+	spec := defaultContractSpec
+	if source, ok := t.market.(ContractSpecSource); ok {
+		if s, err := source.GetContractSpec(symbol); err != nil {
+			paperLog.Warn("获取合约规格失败，按1张=1个币计算", "symbol", symbol, "err", err)
+		} else {
+			spec = s
+		}
+	}
+	t.specs[symbol] = paperSpec{spec: spec, fetched: time.Now()}
+	return spec
+}
+
+// GetContractSpec 模拟成交使用的合约规格（真实交易所的合约规格）
+func (t *PaperTrader) GetContractSpec(symbol string) (ContractSpec, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.spec(symbol), nil
+}
+
+// value 合约张数对应的仓位价值（USDT，调用方持有锁）
+func (t *PaperTrader) value(symbol string, quantity, price float64) float64 {
+	return quantity * t.spec(symbol).Multiplier * price
+}
+
+// GetFeeRates 模拟成交使用的费率（真实交易所的账户费率）
+func (t *PaperTrader) GetFeeRates(symbol string) (maker, taker float64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	maker, taker = t.feeRates(symbol)
+	return maker, taker, nil
+}
+
+// chargeTaker 按吃单费率计算一笔模拟成交的手续费并记入流水，返回手续费（调用方持有锁并从余额中扣除）
+// 流水中的成交数量与实盘成交记录一致，换算为基础币数量
+func (t *PaperTrader) chargeTaker(symbol, side string, quantity, price float64, orderID string) float64 {
+	_, rate := t.feeRates(symbol)
+	fee := t.value(symbol, quantity, price) * rate
+	now := time.Now()
+	t.state.Ledger.Fills = append(t.state.Ledger.Fills, store.Fill{
+		OrderID: orderID, TradeID: orderID, Symbol: symbol, Side: side,
+		Price: price, Quantity: quantity * t.spec(symbol).Multiplier, Fee: fee, Role: "taker", Time: now,
+	})
+	t.addCost(store.Cost{Kind: store.CostFee, Symbol: symbol, OrderID: orderID, Amount: -fee, Time: now})
+	if n := len(t.state.Ledger.Fills); n > paperLedgerHistory {
+		t.state.Ledger.Fills = append([]store.Fill(nil), t.state.Ledger.Fills[n-paperLedgerHistory:]...)
+	}
+	return fee
+}
+
+// addCost 记入费用流水（调用方持有锁）
+func (t *PaperTrader) addCost(c store.Cost) {
+	t.state.Ledger.Costs = append(t.state.Ledger.Costs, c)
+	if n := len(t.state.Ledger.Costs); n > paperLedgerHistory {
+		t.state.Ledger.Costs = append([]store.Cost(nil), t.state.Ledger.Costs[n-paperLedgerHistory:]...)
+	}
+}
+
+// accrueFunding 结算持仓自上次结算以来经过的资金费（按合约的结算周期，费率为最近一次实际结算费率，
+// 费率为正时多头支付、空头收取；停机期间错过的多次结算按同一费率补记，调用方持有锁）
+func (t *PaperTrader) accrueFunding(pos *paperPosition, price float64) {
+	now := time.Now()
+	if pos.FundingAt == 0 {
+		// 旧版本保存的持仓没有结算时间，从现在开始计算
+		pos.FundingAt = now.Unix()
+		return
+	}
+
+	schedule, ok := t.funding[pos.Symbol]
+	if !ok || !now.Before(schedule.next) {
+		s, err := market.GetFundingSchedule(pos.Symbol)
+		if err != nil {
+			paperLog.Warn("获取资金费结算周期失败，下次查询持仓时重试", "symbol", pos.Symbol, "err", err)
+			return
+		}
+		schedule = paperFundingAt{interval: s.Interval, next: s.NextApply}
+		t.funding[pos.Symbol] = schedule
+	}
+	last := schedule.next.Add(-schedule.interval) // 最近一次结算时间
+	if last.Unix() <= pos.FundingAt || last.After(now) {
+		return
+	}
+	settlements := int(last.Sub(time.Unix(pos.FundingAt, 0))/schedule.interval) + 1
+
+	rate, err := market.GetFundingRate(pos.Symbol)
+	if err != nil {
+		paperLog.Warn("获取资金费率失败，下次查询持仓时重试", "symbol", pos.Symbol, "err", err)
+		return
+	}
+	payment := float64(settlements) * t.value(pos.Symbol, pos.Quantity, price) * rate
+	if pos.Side == "long" {
+		payment = -payment
+	}
+	t.state.WalletBalance += payment
+	pos.FundingAt = last.Unix()
+	t.addCost(store.Cost{Kind: store.CostFunding, Symbol: pos.Symbol, Amount: payment, Time: last})
+	t.save()
+	paperLog.Info("模拟资金费结算", "symbol", pos.Symbol, "side", pos.Side, "rate", rate,
+		"settlements", settlements, "amount", fmt.Sprintf("%+.4f", payment))
+}
+
+// GetFills 指定时间之后的模拟成交
+func (t *PaperTrader) GetFills(since time.Time) ([]store.Fill, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var fills []store.Fill
+	for _, f := range t.state.Ledger.Fills {
+		if !f.Time.Before(since) {
+			fills = append(fills, f)
+		}
+	}
+	return fills, nil
+}
+
+// GetCosts 指定时间之后的模拟手续费和资金费流水
+func (t *PaperTrader) GetCosts(since time.Time) ([]store.Cost, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var costs []store.Cost
+	for _, c := range t.state.Ledger.Costs {
+		if !c.Time.Before(since) {
+			costs = append(costs, c)
+		}
+	}
+	return costs, nil
+}
@@ -0,0 +1,631 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"nofx/logging"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/store"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	paperFillHistory = 200 // 保留最近多少笔模拟成交（供订单跟踪和对账查询）
+)
+
+// paperLog 模拟交易日志
+var paperLog = logging.For("paper")
+
+// PaperTrader 模拟交易器（dry-run）：行情、精度读取真实交易所，下单/止损止盈/保证金在内存中模拟
+// 下单数量按真实交易所的精度和最小张数取整，仓位价值按合约乘数计算，条件单价格按价格精度取整
+// 手续费按账户在真实交易所的费率扣除，资金费按合约的结算周期和实际结算费率收付
+// 状态保存在statePath（为空时不保存），重启后模拟持仓不会丢失
+type PaperTrader struct {
+	market    Trader // 真实交易所（只调用GetMarketPrice/FormatQuantity/GetFeeRates/GetContractSpec等只读接口）
+	statePath string
+
+	mu      sync.Mutex
+	state   paperState
+	fees    map[string]paperFeeRates  // symbol → 费率缓存
+	funding map[string]paperFundingAt // symbol → 结算周期缓存
+	specs   map[string]paperSpec      // symbol → 合约规格缓存
+	texts   map[string]string         // symbol → 后续订单的自定义文本（订单跟踪下单前设置）
+
+	faults *soakInjector // 浸泡测试的故障注入（未启用时为nil）
+}
+
+// paperState 模拟账户状态
+type paperState struct {
+	WalletBalance float64                      `json:"wallet_balance"`
+	Positions     map[string]*paperPosition    `json:"positions"` // symbol_side -> 持仓
+	Triggers      []TriggerOrder               `json:"triggers"`
+	Fills         map[string]store.OrderUpdate `json:"fills"` // 订单ID -> 成交（供订单跟踪确认成交价）
+	NextOrderID   int64                        `json:"next_order_id"`
+	ClientOrders  map[string]string            `json:"client_orders,omitempty"` // 订单文本 -> 订单ID（按客户端订单ID确认结果不确定的下单）
+	Ledger        paperLedger                  `json:"ledger"`                  // 成交和费用流水（同步到交易日志）
+}
+
+// paperPosition 模拟持仓
+type paperPosition struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`     // long/short
+	Quantity   float64 `json:"quantity"` // 合约张数
+	EntryPrice float64 `json:"entry_price"`
+	Leverage   int     `json:"leverage"`
+	FundingAt  int64   `json:"funding_at"` // 已结算资金费的最后时间（Unix秒，开仓时为开仓时间）
+}
+
+// NewPaperTrader 创建模拟交易器，initialBalance为模拟账户初始资金
+func NewPaperTrader(market Trader, initialBalance float64, statePath string) (*PaperTrader, error) {
+	t := &PaperTrader{
+		market:    market,
+		statePath: statePath,
+		fees:      make(map[string]paperFeeRates),
+		funding:   make(map[string]paperFundingAt),
+		specs:     make(map[string]paperSpec),
+		texts:     make(map[string]string),
+		state: paperState{
+			WalletBalance: initialBalance,
+			Positions:     make(map[string]*paperPosition),
+			Fills:         make(map[string]store.OrderUpdate),
+			ClientOrders:  make(map[string]string),
+		},
+	}
+	if statePath == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取模拟账户状态失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.state); err != nil {
+		return nil, fmt.Errorf("解析模拟账户状态 %s 失败: %w", statePath, err)
+	}
+	if t.state.Positions == nil {
+		t.state.Positions = make(map[string]*paperPosition)
+	}
+	if t.state.Fills == nil {
+		t.state.Fills = make(map[string]store.OrderUpdate)
+	}
+	if t.state.ClientOrders == nil {
+		t.state.ClientOrders = make(map[string]string)
+	}
+	return t, nil
+}
+
+// save 保存状态（调用方持有锁，失败只记录日志）
+func (t *PaperTrader) save() {
+	if t.statePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(t.state, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(t.statePath), 0755); err == nil {
+			tmp := t.statePath + ".tmp"
+			if err = os.WriteFile(tmp, data, 0644); err == nil {
+				err = os.Rename(tmp, t.statePath)
+			}
+		}
+	}
+	if err != nil {
+		paperLog.Warn("保存模拟账户状态失败", "path", t.statePath, "err", err)
+	}
+}
+
+// GetBalance 模拟账户余额（未实现盈亏按最新价格计算）
+func (t *PaperTrader) GetBalance() (map[string]interface{}, error) {
+	if err := t.faults.apiFault("get_balance"); err != nil {
+		return nil, err
+	}
+	return t.balance()
+}
+
+// balance 模拟账户余额（内部调用不注入故障）
+func (t *PaperTrader) balance() (map[string]interface{}, error) {
+	positions, err := t.positions()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	unrealized, marginUsed := 0.0, 0.0
+	for _, pos := range positions {
+		unrealized += pos["unRealizedProfit"].(float64)
+		marginUsed += t.value(pos["symbol"].(string), math.Abs(pos["positionAmt"].(float64)), pos["entryPrice"].(float64)) / pos["leverage"].(float64)
+	}
+	return map[string]interface{}{
+		"totalWalletBalance":    t.state.WalletBalance,
+		"availableBalance":      t.state.WalletBalance + math.Min(unrealized, 0) - marginUsed,
+		"totalUnrealizedProfit": unrealized,
+	}, nil
+}
+
+// GetPositions 模拟持仓（先结算到期的资金费，再按最新价格检查止损/止盈/强平是否触发）
+func (t *PaperTrader) GetPositions() ([]map[string]interface{}, error) {
+	if err := t.faults.apiFault("get_positions"); err != nil {
+		return nil, err
+	}
+	return t.positions()
+}
+
+// positions 模拟持仓（内部调用不注入故障）
+func (t *PaperTrader) positions() ([]map[string]interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]map[string]interface{}, 0, len(t.state.Positions))
+	for key, pos := range t.state.Positions {
+		price, err := t.market.GetMarketPrice(pos.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 价格失败: %w", pos.Symbol, err)
+		}
+		t.accrueFunding(pos, price)
+		if t.checkTriggers(key, pos, price) {
+			continue
+		}
+
+		amount := pos.Quantity
+		if pos.Side == "short" {
+			amount = -amount
+		}
+		result = append(result, map[string]interface{}{
+			"symbol":           pos.Symbol,
+			"side":             pos.Side,
+			"positionAmt":      amount,
+			"entryPrice":       pos.EntryPrice,
+			"markPrice":        price,
+			"unRealizedProfit": t.value(pos.Symbol, amount, price-pos.EntryPrice),
+			"leverage":         float64(pos.Leverage),
+			"liquidationPrice": t.liquidationPrice(pos),
+		})
+	}
+	return result, nil
+}
+
+// liquidationPrice 逐仓强平价（维持保证金率按真实交易所的档位，交易所不提供时使用默认值）
+func (t *PaperTrader) liquidationPrice(pos *paperPosition) float64 {
+	tiers, _ := t.GetMaintenanceTiers(pos.Symbol)
+	coins := pos.Quantity * t.spec(pos.Symbol).Multiplier
+	return risk.LiquidationPrice(pos.Side, pos.EntryPrice, coins, pos.Leverage, risk.MarginIsolated, 0, tiers)
+}
+
+// GetMaintenanceTiers 真实交易所的维持保证金档位（交易所不提供时返回空）
+func (t *PaperTrader) GetMaintenanceTiers(symbol string) ([]risk.MaintenanceTier, error) {
+	if source, ok := t.market.(MaintenanceTierSource); ok {
+		return source.GetMaintenanceTiers(symbol)
+	}
+	return nil, nil
+}
+
+// checkTriggers 按价格检查止损/止盈/强平，触发时按触发价平仓，返回持仓是否已平掉（调用方持有锁）
+func (t *PaperTrader) checkTriggers(key string, pos *paperPosition, price float64) bool {
+	hit := func(trigger float64, above bool) bool {
+		return trigger > 0 && ((above && price >= trigger) || (!above && price <= trigger))
+	}
+	long := pos.Side == "long"
+
+	if liq := t.liquidationPrice(pos); hit(liq, !long) {
+		paperLog.Warn("模拟强平", "symbol", pos.Symbol, "side", pos.Side, "price", liq)
+		t.fill(key, pos, pos.Quantity, liq, t.nextOrderID())
+		return true
+	}
+	for i := 0; i < len(t.state.Triggers); i++ {
+		trigger := t.state.Triggers[i]
+		if trigger.Symbol != pos.Symbol || trigger.PositionSide != pos.Side {
+			continue
+		}
+		// 多仓止损在下方、止盈在上方，空仓相反
+		above := (trigger.Kind == "take_profit") == long
+		if !hit(trigger.TriggerPrice, above) {
+			continue
+		}
+		paperLog.Info("模拟条件单触发", "symbol", pos.Symbol, "side", pos.Side, "kind", trigger.Kind, "price", trigger.TriggerPrice)
+		t.state.Triggers = append(t.state.Triggers[:i], t.state.Triggers[i+1:]...)
+		i--
+		// 分批止盈单只平掉对应数量，继续检查同一价格下的其他条件单
+		if trigger.Quantity > 0 && trigger.Quantity < pos.Quantity*(1-1e-9) {
+			t.fill(key, pos, trigger.Quantity, trigger.TriggerPrice, t.nextOrderID())
+			continue
+		}
+		t.fill(key, pos, pos.Quantity, trigger.TriggerPrice, t.nextOrderID())
+		return true
+	}
+	return false
+}
+
+// fill 按价格平掉quantity数量的持仓并结算盈亏和吃单手续费（调用方持有锁）
+func (t *PaperTrader) fill(key string, pos *paperPosition, quantity, price float64, orderID string) {
+	pnl := t.value(pos.Symbol, quantity, price-pos.EntryPrice)
+	if pos.Side == "short" {
+		pnl = -pnl
+	}
+	side := map[string]string{"long": "sell", "short": "buy"}[pos.Side]
+	t.state.WalletBalance += pnl - t.chargeTaker(pos.Symbol, side, quantity, price, orderID)
+	pos.Quantity -= quantity
+	if pos.Quantity <= 1e-12 {
+		delete(t.state.Positions, key)
+		t.removeTriggers(pos.Symbol, pos.Side)
+	}
+	t.save()
+}
+
+// removeTriggers 删除持仓对应的条件单（调用方持有锁）
+func (t *PaperTrader) removeTriggers(symbol, side string) {
+	kept := t.state.Triggers[:0]
+	for _, trigger := range t.state.Triggers {
+		if trigger.Symbol != symbol || (side != "" && trigger.PositionSide != side) {
+			kept = append(kept, trigger)
+		}
+	}
+	t.state.Triggers = kept
+}
+
+// nextOrderID 分配模拟订单ID（调用方持有锁）
+func (t *PaperTrader) nextOrderID() string {
+	t.state.NextOrderID++
+	return fmt.Sprintf("paper-%d", t.state.NextOrderID)
+}
+
+// record 记录模拟成交并返回订单结果，left为未成交撤销的数量（调用方持有锁）
+func (t *PaperTrader) record(orderID, symbol string, quantity, left, price float64) map[string]interface{} {
+	update := store.OrderUpdate{State: store.OrderFilled, OrderID: orderID, FilledQty: quantity, AvgPrice: price}
+	if left > 0 {
+		update.State = store.OrderCancelled
+		update.Detail = fmt.Sprintf("剩余%v未成交", left)
+	}
+	t.state.Fills[orderID] = update
+	if text := t.texts[symbol]; text != "" {
+		t.state.ClientOrders[text] = orderID
+	}
+	expired := fmt.Sprintf("paper-%d", t.state.NextOrderID-paperFillHistory)
+	delete(t.state.Fills, expired)
+	for text, id := range t.state.ClientOrders {
+		if id == expired {
+			delete(t.state.ClientOrders, text)
+		}
+	}
+	t.save()
+	order := map[string]interface{}{"orderId": orderID, "symbol": symbol, "status": "FILLED"}
+	if left > 0 {
+		order = withFill(order, quantity, price, left)
+	}
+	return order
+}
+
+// open 模拟市价开仓
+func (t *PaperTrader) open(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	executed, fault := t.faults.orderFault("open_" + side)
+	if !executed {
+		return nil, fault
+	}
+	order, err := t.openMarket(symbol, side, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return order, fault
+}
+
+// openMarket 按最新价格模拟开仓（浸泡测试注入部分成交时只成交一部分）
+func (t *PaperTrader) openMarket(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("开仓数量必须大于0: %w", ErrOrderTooSmall)
+	}
+	quantity, err := t.orderSize(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if leverage <= 0 {
+		leverage = 1
+	}
+	left := 0.0
+	if fraction := t.faults.partialFill(); fraction > 0 {
+		if partial, err := t.orderSize(symbol, quantity*fraction); err == nil && partial < quantity {
+			paperLog.Info("浸泡测试: 模拟部分成交", "symbol", symbol, "side", side, "quantity", quantity, "filled", partial)
+			quantity, left = partial, quantity-partial
+		}
+	}
+	price, err := t.market.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := t.balance()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	margin := t.value(symbol, quantity, price) / float64(leverage)
+	_, takerRate := t.feeRates(symbol)
+	fee := t.value(symbol, quantity, price) * takerRate
+	if available := balance["availableBalance"].(float64); margin+fee > available {
+		return nil, fmt.Errorf("需要%.2f USDT，可用%.2f USDT: %w", margin+fee, available, ErrInsufficientMargin)
+	}
+
+	key := symbol + "_" + side
+	pos, ok := t.state.Positions[key]
+	if !ok {
+		pos = &paperPosition{Symbol: symbol, Side: side, Leverage: leverage, FundingAt: time.Now().Unix()}
+		t.state.Positions[key] = pos
+	}
+	pos.EntryPrice = (pos.EntryPrice*pos.Quantity + price*quantity) / (pos.Quantity + quantity)
+	pos.Quantity += quantity
+	pos.Leverage = leverage
+	orderID := t.nextOrderID()
+	t.state.WalletBalance -= t.chargeTaker(symbol, map[string]string{"long": "buy", "short": "sell"}[side], quantity, price, orderID)
+
+	paperLog.Info("模拟开仓", "symbol", symbol, "side", side, "quantity", quantity, "price", price, "leverage", leverage, "fee", fee)
+	t.faults.recordEntry(key)
+	return t.record(orderID, symbol, quantity, left, price), nil
+}
+
+// close 模拟市价平仓（quantity=0表示全部平仓）
+func (t *PaperTrader) close(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	executed, fault := t.faults.orderFault("close_" + side)
+	if !executed {
+		return nil, fault
+	}
+	order, err := t.closeMarket(symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return order, fault
+}
+
+// closeMarket 按最新价格模拟平仓
+func (t *PaperTrader) closeMarket(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	price, err := t.market.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := symbol + "_" + side
+	pos, ok := t.state.Positions[key]
+	if !ok {
+		return nil, fmt.Errorf("没有找到 %s 的%s仓: %w", symbol, map[string]string{"long": "多", "short": "空"}[side], ErrPositionNotFound)
+	}
+	if quantity > 0 && quantity < pos.Quantity {
+		if quantity, err = t.orderSize(symbol, quantity); err != nil {
+			return nil, err
+		}
+	}
+	if quantity <= 0 || quantity > pos.Quantity {
+		quantity = pos.Quantity
+	}
+
+	paperLog.Info("模拟平仓", "symbol", symbol, "side", side, "quantity", quantity, "price", price)
+	orderID := t.nextOrderID()
+	t.fill(key, pos, quantity, price, orderID)
+	return t.record(orderID, symbol, quantity, 0, price), nil
+}
+
+// orderSize 按真实交易所的下单精度取整（与实盘下单一致：不足最小张数时按最小张数；超出单笔上限的市价单实盘拆分下单，这里按总数量成交）
+func (t *PaperTrader) orderSize(symbol string, quantity float64) (float64, error) {
+	formatted, err := t.market.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseFloat(formatted, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s 下单数量格式无效: %q", symbol, formatted)
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("%s 数量%.8f按交易所精度取整后为0: %w", symbol, quantity, ErrOrderTooSmall)
+	}
+	return size, nil
+}
+
+// OpenLong 模拟开多仓
+func (t *PaperTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.open(symbol, "long", quantity, leverage)
+}
+
+// OpenShort 模拟开空仓
+func (t *PaperTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.open(symbol, "short", quantity, leverage)
+}
+
+// CloseLong 模拟平多仓
+func (t *PaperTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.close(symbol, "long", quantity)
+}
+
+// CloseShort 模拟平空仓
+func (t *PaperTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.close(symbol, "short", quantity)
+}
+
+// SetLeverage 模拟交易以开仓参数为准，无需设置
+func (t *PaperTrader) SetLeverage(symbol string, leverage int) error {
+	return nil
+}
+
+// GetMarketPrice 真实市场价格
+func (t *PaperTrader) GetMarketPrice(symbol string) (float64, error) {
+	if err := t.faults.apiFault("get_market_price"); err != nil {
+		return 0, err
+	}
+	return t.market.GetMarketPrice(symbol)
+}
+
+// setTrigger 设置条件单（同一持仓同类条件单只保留最新一个）
+func (t *PaperTrader) setTrigger(symbol, positionSide, kind string, price float64) error {
+	executed, fault := t.faults.orderFault(kind)
+	if !executed {
+		return fault
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	side := strings.ToLower(positionSide)
+	kept := t.state.Triggers[:0]
+	for _, trigger := range t.state.Triggers {
+		if trigger.Symbol != symbol || trigger.PositionSide != side || trigger.Kind != kind {
+			kept = append(kept, trigger)
+		}
+	}
+	t.state.Triggers = append(kept, TriggerOrder{Symbol: symbol, PositionSide: side, Kind: kind, TriggerPrice: t.spec(symbol).RoundPrice(price)})
+	t.save()
+	return fault
+}
+
+// SetStopLoss 模拟止损单
+func (t *PaperTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return t.setTrigger(symbol, positionSide, "stop_loss", stopPrice)
+}
+
+// SetTakeProfit 模拟止盈单
+func (t *PaperTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return t.setTrigger(symbol, positionSide, "take_profit", takeProfitPrice)
+}
+
+// SetTakeProfitLadder 模拟分批止盈单（替换该持仓已有的止盈单）
+func (t *PaperTrader) SetTakeProfitLadder(symbol, positionSide string, quantity float64, levels []TPLevel) error {
+	executed, fault := t.faults.orderFault("take_profit_ladder")
+	if !executed {
+		return fault
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	side := strings.ToLower(positionSide)
+	kept := t.state.Triggers[:0]
+	for _, trigger := range t.state.Triggers {
+		if trigger.Symbol != symbol || trigger.PositionSide != side || trigger.Kind != "take_profit" {
+			kept = append(kept, trigger)
+		}
+	}
+	for _, level := range levels {
+		kept = append(kept, TriggerOrder{Symbol: symbol, PositionSide: side, Kind: "take_profit",
+			TriggerPrice: t.spec(symbol).RoundPrice(level.Price), Quantity: quantity * level.Fraction})
+	}
+	t.state.Triggers = kept
+	t.save()
+	return fault
+}
+
+// CancelAllOrders 撤销该币种的模拟条件单
+func (t *PaperTrader) CancelAllOrders(symbol string) error {
+	executed, fault := t.faults.orderFault("cancel_orders")
+	if !executed {
+		return fault
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.removeTriggers(symbol, "")
+	t.save()
+	return fault
+}
+
+// FormatQuantity 按真实交易所精度格式化数量
+func (t *PaperTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return t.market.FormatQuantity(symbol, quantity)
+}
+
+// GetOrderStatus 模拟订单立即全部成交（订单跟踪据此记录成交价）
+func (t *PaperTrader) GetOrderStatus(symbol string, orderID string) (store.OrderUpdate, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	update, ok := t.state.Fills[orderID]
+	if !ok {
+		return store.OrderUpdate{}, fmt.Errorf("模拟订单 %s 不存在", orderID)
+	}
+	return update, nil
+}
+
+// GetOpenOrders 模拟交易没有未成交的普通委托单
+func (t *PaperTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	return nil, nil
+}
+
+// GetOpenTriggerOrders 生效中的模拟条件单
+func (t *PaperTrader) GetOpenTriggerOrders() ([]TriggerOrder, error) {
+	if err := t.faults.apiFault("get_trigger_orders"); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TriggerOrder(nil), t.state.Triggers...), nil
+}
+
+// SetOrderText 设置该币种后续模拟订单的文本（与实盘一致，可按订单文本查询订单）
+func (t *PaperTrader) SetOrderText(symbol, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if text == "" {
+		delete(t.texts, symbol)
+		return
+	}
+	t.texts[symbol] = text
+}
+
+// FindOrderByClientID 按订单文本查询模拟订单（未找到时返回的OrderID为空）
+func (t *PaperTrader) FindOrderByClientID(symbol, clientID string) (store.OrderUpdate, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if orderID, ok := t.state.ClientOrders[clientID]; ok {
+		return t.state.Fills[orderID], nil
+	}
+	return store.OrderUpdate{}, nil
+}
+
+// StartKlineStream 转发真实交易所的K线推送（浸泡测试注入断线时丢弃推送）
+func (t *PaperTrader) StartKlineStream(stop <-chan struct{}, symbols []string, interval string, handler func(BarClose)) error {
+	stream, ok := t.market.(KlineStream)
+	if !ok {
+		return fmt.Errorf("交易所不支持K线推送")
+	}
+	return stream.StartKlineStream(stop, symbols, interval, func(bar BarClose) {
+		if t.faults.streamDropped() {
+			return
+		}
+		handler(bar)
+	})
+}
+
+// KlineStreamStatus 真实交易所的K线推送连接状态（浸泡测试注入断线期间报告为断开）
+func (t *PaperTrader) KlineStreamStatus() (bool, time.Time) {
+	stream, ok := t.market.(KlineStream)
+	if !ok {
+		return false, time.Time{}
+	}
+	connected, last := stream.KlineStreamStatus()
+	return connected && !t.faults.streamDropped(), last
+}
+
+// dryRunNotifier 在通知标题前标注模拟交易
+type dryRunNotifier struct {
+	notify.Notifier
+}
+
+func (n dryRunNotifier) Notify(e notify.Event) {
+	e.Title = "[模拟] " + e.Title
+	n.Notifier.Notify(e)
+}
+
+// 编译期检查
+var (
+	_ Trader            = (*PaperTrader)(nil)
+	_ OrderStatusSource = (*PaperTrader)(nil)
+	_ OpenOrderSource   = (*PaperTrader)(nil)
+
+	_ MaintenanceTierSource = (*PaperTrader)(nil)
+	_ FeeRateSource         = (*PaperTrader)(nil)
+	_ TradeHistorySource    = (*PaperTrader)(nil)
+
+	_ TakeProfitLadderSupport = (*PaperTrader)(nil)
+	_ OrderTagger             = (*PaperTrader)(nil)
+	_ ClientOrderLookup       = (*PaperTrader)(nil)
+	_ KlineStream             = (*PaperTrader)(nil)
+)
@@ -0,0 +1,183 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/scheduler"
+	"strings"
+	"time"
+)
+
+// AI决策周期按流水线执行：快照 → 决策 → 风控 → 执行 → 确认，每个阶段有独立的超时（schedule.stage_timeouts）
+// 和失败处理方式：
+//   - snapshot/decide 失败或超时：结束本周期
+//   - risk 失败：跳过该决策，继续下一个
+//   - execute 失败：记录后继续下一个；超时：不再执行剩余决策
+//   - confirm 失败或超时：只记录和告警
+//
+// 超时的阶段无法被强行终止，调用仍在后台运行：会读写AutoTrader状态的阶段超时后不再执行其他此类阶段，
+// 周期结束时周期锁交给后台调用，调用结束后才释放（下一周期不会与它并发修改状态）
+//
+// decide阶段（LLM调用，可能长达数十秒）运行期间释放周期锁，策略看守的止损、ADL减仓等保护性平仓不必等待AI决策；
+// 之后的风控和执行阶段重新取得锁，按交易所当前持仓检查（决策期间持仓可能已被看守平掉）
+
+// errStageTimeout 阶段超时
+var errStageTimeout = errors.New("阶段超时")
+
+// cycleStage 流水线阶段
+type cycleStage struct {
+	name     string
+	shared   bool // 会读写AutoTrader状态（需要持有cycleMu）
+	unlocked bool // 运行期间释放cycleMu（只用于不读写状态、耗时较长的阶段）
+}
+
+var (
+	stageSnapshot = cycleStage{name: scheduler.StageSnapshot, shared: true}
+	stageDecide   = cycleStage{name: scheduler.StageDecide, unlocked: true}
+	stageRisk     = cycleStage{name: scheduler.StageRisk, shared: true}
+	stageExecute  = cycleStage{name: scheduler.StageExecute, shared: true}
+	stageConfirm  = cycleStage{name: scheduler.StageConfirm}
+)
+
+// cyclePipeline 一个决策周期的阶段执行器，阶段结果写入决策记录
+type cyclePipeline struct {
+	at       *AutoTrader
+	record   *logger.DecisionRecord
+	timeouts scheduler.StageTimeouts
+
+	straggler      chan struct{} // 超时后仍在后台运行的读写状态的阶段（结束时关闭）
+	stragglerStage string
+}
+
+// lockCycle 获取周期锁并创建本周期的阶段执行器，周期结束时调用release释放
+func (at *AutoTrader) lockCycle() *cyclePipeline {
+	at.cycleMu.Lock()
+	return &cyclePipeline{at: at, timeouts: at.config.Schedule.StageTimeouts}
+}
+
+// stalled 是否有读写状态的阶段超时后仍在后台运行（此时不能再执行读写状态的阶段）
+func (p *cyclePipeline) stalled() bool {
+	return p.straggler != nil
+}
+
+// release 释放周期锁；有超时阶段仍在后台运行时，等它结束后再释放
+func (p *cyclePipeline) release() {
+	if p.straggler == nil {
+		p.at.cycleMu.Unlock()
+		return
+	}
+	p.at.log.Warn("超时的阶段仍在后台运行，结束后才开始下一周期", "stage", p.stragglerStage)
+	start := time.Now()
+	go func() {
+		<-p.straggler
+		p.at.log.Warn("超时的阶段已结束", "stage", p.stragglerStage, "elapsed", time.Since(start).Round(time.Second))
+		p.at.cycleMu.Unlock()
+	}()
+}
+
+// runStage 在独立的goroutine中运行阶段fn（ctx在阶段结束或超时后取消），超过阶段超时后不再等待，
+// 返回零值和包装errStageTimeout的错误；fn出错时仍返回其结果（如出错时的AI思维链）；超时后fn的结果被丢弃，调用方不能再读写fn使用的变量
+func runStage[T any](ctx context.Context, p *cyclePipeline, stage cycleStage, symbol string, fn func(ctx context.Context) (T, error)) (T, error) {
+	timeout := p.timeouts.Timeout(stage.name)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	if stage.unlocked && !p.stalled() {
+		p.at.cycleMu.Unlock()
+		defer p.at.cycleMu.Lock()
+	}
+
+	done := make(chan outcome, 1)
+	finished := make(chan struct{})
+	start := p.at.clock.Now()
+	go func() {
+		defer close(finished)
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("%s阶段panic: %v", stage.name, r)}
+			}
+		}()
+		value, err := fn(ctx)
+		done <- outcome{value, err}
+	}()
+
+	result := logger.StageResult{Stage: stage.name, Symbol: symbol, Status: "ok"}
+	var out outcome
+	select {
+	case out = <-done:
+		if out.err != nil {
+			result.Status = "error"
+		}
+	case <-p.at.clock.After(timeout):
+		out.err = fmt.Errorf("%s阶段超过%s未完成: %w", stage.name, timeout, errStageTimeout)
+		result.Status = "timeout"
+		if stage.shared {
+			p.straggler, p.stragglerStage = finished, stage.name
+		}
+	}
+	if out.err != nil {
+		result.Error = out.err.Error()
+	}
+	result.DurationMs = p.at.clock.Now().Sub(start).Milliseconds()
+	if p.record != nil {
+		p.record.Stages = append(p.record.Stages, result)
+	}
+
+	if result.Status == "timeout" {
+		p.at.log.Warn("阶段超时", "stage", stage.name, "symbol", symbol, "timeout", timeout)
+	} else {
+		p.at.log.Debug("阶段完成", "stage", stage.name, "symbol", symbol, "status", result.Status, "duration_ms", result.DurationMs)
+	}
+	return out.value, out.err
+}
+
+// stageAction 风控和执行阶段处理的决策和执行记录
+type stageAction struct {
+	decision decision.Decision
+	record   logger.DecisionAction
+}
+
+// runActionStage 在决策和执行记录的副本上运行风控/执行阶段（可能修改杠杆、成交数量等），超时时返回原值
+func runActionStage(ctx context.Context, p *cyclePipeline, stage cycleStage, action stageAction,
+	fn func(context.Context, *decision.Decision, *logger.DecisionAction) error) (stageAction, error) {
+	result, err := runStage(ctx, p, stage, action.decision.Symbol, func(stageCtx context.Context) (stageAction, error) {
+		a := action
+		err := fn(stageCtx, &a.decision, &a.record)
+		return a, err
+	})
+	if errors.Is(err, errStageTimeout) {
+		return action, err
+	}
+	return result, err
+}
+
+// confirmProtection 确认阶段：本周期市价开仓成功的持仓在交易所上都已挂出止损单
+// （订单本身的成交确认在下单时完成，这里检查成交后设置的保护单）
+func (at *AutoTrader) confirmProtection(opened []logger.DecisionAction) error {
+	source, ok := at.trader.(OpenOrderSource)
+	if !ok || len(opened) == 0 {
+		return nil
+	}
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return fmt.Errorf("查询条件单失败: %w", err)
+	}
+	var missing []string
+	for _, a := range opened {
+		side := strings.TrimPrefix(a.Action, "open_")
+		if !hasTriggerOrder(triggers, TriggerOrder{Symbol: a.Symbol, PositionSide: side, Kind: "stop_loss"}) {
+			missing = append(missing, a.Symbol+" "+side)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("新开持仓未挂止损单: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/risk"
+	"nofx/symbols"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// previewSlippageLookback 估计滑点使用的历史成交时长
+const previewSlippageLookback = 30 * 24 * time.Hour
+
+// OrderPreviewRequest 下单预览请求（字段与开仓决策一致）
+type OrderPreviewRequest struct {
+	Symbol          string  `json:"symbol"`
+	Side            string  `json:"side"`               // long/short
+	PositionSizeUSD float64 `json:"position_size_usd"`  // 目标仓位价值（USDT）
+	Leverage        int     `json:"leverage"`           // 0表示使用该币种的杠杆上限
+	Price           float64 `json:"price,omitempty"`    // 限价（0表示按当前价市价开仓）
+	Strategy        string  `json:"strategy,omitempty"` // 决策来源（决定适用的风控参数，默认ai）
+}
+
+// PreviewExposure 账户敞口（名义价值按合约乘数换算）
+type PreviewExposure struct {
+	Notional          float64 `json:"notional"`           // 所有持仓总名义价值
+	SymbolNotional    float64 `json:"symbol_notional"`    // 该币种同方向持仓的名义价值
+	MarginUsed        float64 `json:"margin_used"`        // 已占用保证金
+	MarginUsedPct     float64 `json:"margin_used_pct"`    // 保证金使用率（%）
+	EffectiveLeverage float64 `json:"effective_leverage"` // 有效杠杆（总名义价值 / 净值）
+}
+
+// OrderPreview 下单预览：按交易所精度和当前行情计算的下单数量、名义价值、保证金、费用和开仓前后的账户敞口，不下单
+type OrderPreview struct {
+	TraderID          string          `json:"trader_id"`
+	Symbol            string          `json:"symbol"`
+	Side              string          `json:"side"`
+	Price             float64         `json:"price"` // 参考价（限价或当前价）
+	Leverage          int             `json:"leverage"`
+	Contracts         string          `json:"contracts"`          // 按交易所精度取整后的下单数量（张），无法下单时为空
+	Notional          float64         `json:"notional"`           // 实际名义价值（张数 × 合约乘数 × 参考价）
+	RequiredMargin    float64         `json:"required_margin"`    // 起始保证金
+	Route             string          `json:"route"`              // 预计执行方式 maker/taker/chase/limit
+	FeeRate           float64         `json:"fee_rate"`           // 预计手续费率（交易器不提供费率时为0）
+	EstimatedFee      float64         `json:"estimated_fee"`      // 预计开仓手续费（USDT）
+	SlippageBps       float64         `json:"slippage_bps"`       // 估计滑点（近30天该币种开仓成交的平均滑点，基点）
+	SlippageSamples   int             `json:"slippage_samples"`   // 估计滑点使用的成交笔数（0表示没有记录）
+	EstimatedSlippage float64         `json:"estimated_slippage"` // 估计滑点成本（USDT）
+	Equity            float64         `json:"equity"`             // 账户净值
+	Available         float64         `json:"available"`          // 可用余额
+	Before            PreviewExposure `json:"before"`             // 开仓前
+	After             PreviewExposure `json:"after"`              // 开仓后
+	Warnings          []string        `json:"warnings,omitempty"` // 实际执行时会被拒绝或需要注意的问题
+}
+
+// contractSpec 交易器的合约规格（不提供或查询失败时按1张=1个币计算）
+func contractSpec(t Trader, symbol string) ContractSpec {
+	if source, ok := t.(ContractSpecSource); ok {
+		if spec, err := source.GetContractSpec(symbol); err == nil {
+			return spec
+		}
+	}
+	return defaultContractSpec
+}
+
+// PreviewOrder 预览开仓：按与实际开仓相同的数量换算、取整和执行方式选择计算下单结果，并列出会被风控拒绝的原因
+// 只查询行情、账户和交易日志，不下单、不修改杠杆
+func (at *AutoTrader) PreviewOrder(req OrderPreviewRequest) (*OrderPreview, error) {
+	if strings.TrimSpace(req.Symbol) == "" {
+		return nil, fmt.Errorf("symbol不能为空")
+	}
+	symbol := symbols.Canonical(req.Symbol)
+	if req.Side != "long" && req.Side != "short" {
+		return nil, fmt.Errorf("side必须是long或short: %q", req.Side)
+	}
+	if req.PositionSizeUSD <= 0 || math.IsNaN(req.PositionSizeUSD) || math.IsInf(req.PositionSizeUSD, 0) {
+		return nil, fmt.Errorf("position_size_usd必须为正数")
+	}
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = "ai"
+	}
+
+	p := &OrderPreview{TraderID: at.id, Symbol: symbol, Side: req.Side, Price: req.Price, Leverage: req.Leverage}
+	profile := at.riskProfile(strategy, symbol)
+	if p.Leverage <= 0 {
+		p.Leverage = profile.MaxLeverage
+	} else if p.Leverage > profile.MaxLeverage && !at.config.AutoLeverage.Enabled {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("杠杆%dx超过配置上限%dx", p.Leverage, profile.MaxLeverage))
+	}
+	if p.Price <= 0 {
+		price, err := at.trader.GetMarketPrice(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 当前价失败: %w", symbol, err)
+		}
+		p.Price = price
+	}
+	if err := at.checkEntryAllowed(); err != nil {
+		p.Warnings = append(p.Warnings, err.Error())
+	}
+
+	// 数量与executeOpenLong/executeOpenShort一致：目标价值 / 参考价，再按交易所精度和取整策略取整
+	spec := contractSpec(at.trader, symbol)
+	formatted, err := at.trader.FormatQuantity(symbol, req.PositionSizeUSD/p.Price)
+	if err != nil {
+		p.Warnings = append(p.Warnings, err.Error())
+	} else {
+		p.Contracts = formatted
+		contracts, _ := strconv.ParseFloat(formatted, 64)
+		p.Notional = contracts * spec.Multiplier * p.Price
+		if p.Notional > req.PositionSizeUSD*1.5 {
+			p.Warnings = append(p.Warnings, fmt.Sprintf("取整后名义价值%.2f USDT是目标%.2f USDT的%.1f倍",
+				p.Notional, req.PositionSizeUSD, p.Notional/req.PositionSizeUSD))
+		}
+	}
+	if p.Leverage > 0 {
+		p.RequiredMargin = p.Notional / float64(p.Leverage)
+	}
+
+	// 执行方式和费率：限价开仓按挂单费率，市价开仓按entry_routing的选择
+	var makerFee, takerFee float64
+	var feeKnown bool
+	if req.Price > 0 {
+		p.Route = "limit"
+		if source, ok := at.trader.(FeeRateSource); ok {
+			makerFee, takerFee, err = source.GetFeeRates(symbol)
+			feeKnown = err == nil
+		}
+	} else {
+		p.Route, makerFee, takerFee, feeKnown = at.chooseEntryRoute(symbol)
+	}
+	switch {
+	case !feeKnown:
+		p.Warnings = append(p.Warnings, "交易器不提供费率，未估算手续费")
+	case p.Route == risk.RouteMaker || p.Route == "limit":
+		p.FeeRate = makerFee
+	default:
+		p.FeeRate = takerFee // 追价开仓可能部分以市价成交，按吃单费率保守估算
+	}
+	p.EstimatedFee = p.Notional * p.FeeRate
+
+	if p.Route != "limit" {
+		p.SlippageBps, p.SlippageSamples = at.historicalSlippage(symbol)
+		p.EstimatedSlippage = p.Notional * p.SlippageBps / 1e4
+	}
+
+	if err := at.previewExposure(p); err != nil {
+		return nil, err
+	}
+	if p.RequiredMargin+p.EstimatedFee > p.Available {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("可用余额%.2f USDT不足以支付保证金和手续费%.2f USDT", p.Available, p.RequiredMargin+p.EstimatedFee))
+	}
+	if maxValue := p.Equity * profile.MaxPositionMultiple; p.After.SymbolNotional > maxValue {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("开仓后%s仓位%.2f USDT超过敞口上限%.2f USDT", symbol, p.After.SymbolNotional, maxValue))
+	}
+	if limits := at.config.AccountLimits; limits.Enabled() {
+		if err := limits.CheckAdd(p.Before.Notional, p.Before.MarginUsed, p.Equity, p.Notional, p.RequiredMargin); err != nil {
+			p.Warnings = append(p.Warnings, err.Error())
+		}
+	}
+	return p, nil
+}
+
+// previewExposure 计算开仓前后的账户敞口（名义价值按合约乘数换算）
+func (at *AutoTrader) previewExposure(p *OrderPreview) error {
+	snapshot, err := cachedAccountSnapshot(at.trader)
+	if err != nil {
+		return err
+	}
+	p.Equity, p.Available = snapshot.Equity, snapshot.Available
+
+	before := PreviewExposure{MarginUsed: snapshot.MarginUsed}
+	for _, pos := range snapshot.Positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity, _ := pos["positionAmt"].(float64)
+		markPrice, _ := pos["markPrice"].(float64)
+		notional := math.Abs(quantity) * contractSpec(at.trader, symbol).Multiplier * markPrice
+		before.Notional += notional
+		if symbol == p.Symbol && side == p.Side {
+			before.SymbolNotional += notional
+			p.Warnings = append(p.Warnings, fmt.Sprintf("%s 已有%s仓，开仓会被拒绝", symbol, side))
+		}
+	}
+	after := PreviewExposure{
+		Notional:       before.Notional + p.Notional,
+		SymbolNotional: before.SymbolNotional + p.Notional,
+		MarginUsed:     before.MarginUsed + p.RequiredMargin,
+	}
+	for _, e := range []*PreviewExposure{&before, &after} {
+		e.MarginUsedPct = risk.MarginUtilizationPct(e.MarginUsed, p.Equity)
+		e.EffectiveLeverage = risk.EffectiveLeverage(e.Notional, p.Equity)
+	}
+	p.Before, p.After = before, after
+	return nil
+}
+
+// historicalSlippage 近30天该币种开仓成交的平均滑点（基点）和成交笔数（交易日志未启用或没有记录时为0）
+func (at *AutoTrader) historicalSlippage(symbol string) (bps float64, samples int) {
+	orders, err := at.journal.ListExecutedOrders(at.id, at.clock.Now().Add(-previewSlippageLookback))
+	if err != nil {
+		at.log.Warn("查询历史成交失败，未估算滑点", "err", err)
+		return 0, 0
+	}
+	var total float64
+	for _, o := range orders {
+		if o.Symbol == symbol && strings.HasPrefix(o.Action, "open_") {
+			total += o.SlippageBps
+			samples++
+		}
+	}
+	if samples == 0 {
+		return 0, 0
+	}
+	return total / float64(samples), samples
+}
+
+// Summary 预览的简要文本（用于通知和命令行）
+func (p *OrderPreview) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s %dx @ %.6g：%s张，名义价值 %.2f USDT，保证金 %.2f USDT\n",
+		p.Symbol, p.Side, p.Leverage, p.Price, p.Contracts, p.Notional, p.RequiredMargin))
+	sb.WriteString(fmt.Sprintf("执行方式 %s，手续费≈%.2f USDT（%.3f%%），滑点≈%.2f USDT（%.1fbps，%d笔）\n",
+		p.Route, p.EstimatedFee, p.FeeRate*100, p.EstimatedSlippage, p.SlippageBps, p.SlippageSamples))
+	sb.WriteString(fmt.Sprintf("开仓后: 有效杠杆 %.2fx → %.2fx，保证金使用率 %.1f%% → %.1f%%",
+		p.Before.EffectiveLeverage, p.After.EffectiveLeverage, p.Before.MarginUsedPct, p.After.MarginUsedPct))
+	for _, w := range p.Warnings {
+		sb.WriteString("\n⚠ " + w)
+	}
+	return sb.String()
+}
@@ -0,0 +1,220 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"nofx/notify"
+	"strings"
+	"time"
+)
+
+// PositionUpdate 交易所推送的持仓数量变化
+type PositionUpdate struct {
+	Symbol   string
+	Side     string  // long/short（数量为0时可能为空，表示该币种已无持仓）
+	Quantity float64 // 持仓数量（正数，单位与GetPositions的positionAmt一致）
+}
+
+// PositionStream 可推送持仓变化的交易器（WebSocket）
+type PositionStream interface {
+	// StartPositionStream 订阅持仓推送，断线后自动重连（重连后按最新持仓补发一次），直到stop关闭
+	StartPositionStream(stop <-chan struct{}, handler func(PositionUpdate)) error
+}
+
+// TriggerOrderCanceller 可单独撤销条件单的交易器（CancelAllOrders只撤普通委托或会撤掉全部条件单时使用）
+type TriggerOrderCanceller interface {
+	CancelTriggerOrder(symbol, orderID string) error
+}
+
+// startPositionStream 启动持仓推送：持仓数量变化（部分平仓、加仓、部分成交）时按新数量调整止损/止盈单
+func (at *AutoTrader) startPositionStream() {
+	stream, ok := at.trader.(PositionStream)
+	_, canResize := at.trader.(TriggerOrderCanceller)
+	if !ok || !canResize {
+		at.log.Warn("交易器不支持持仓推送或单独撤销条件单，止损止盈数量不会自动调整", "exchange", at.exchange)
+		return
+	}
+	if err := stream.StartPositionStream(at.stopCh, at.onPositionUpdate); err != nil {
+		at.log.Warn("启动持仓推送失败，止损止盈数量不会自动调整", "err", err)
+		return
+	}
+	at.positionStreaming.Store(true)
+	go at.resizeWorker()
+}
+
+// onPositionUpdate 持仓推送回调：同一持仓的连续变化只保留最新数量，交给resizeWorker串行处理
+func (at *AutoTrader) onPositionUpdate(update PositionUpdate) {
+	if update.Quantity <= 0 {
+		return // 已平仓：交易所的只减仓条件单不会再成交，由平仓流程和对账处理
+	}
+	at.resizeMu.Lock()
+	at.pendingResize[update.Symbol+"_"+update.Side] = update
+	at.resizeMu.Unlock()
+	select {
+	case at.resizeCh <- struct{}{}:
+	default:
+	}
+}
+
+// resizeWorker 处理持仓数量变化（与AI决策、策略看守互斥，避免与开仓后的止损止盈设置交错）
+func (at *AutoTrader) resizeWorker() {
+	for {
+		select {
+		case <-at.stopCh:
+			return
+		case <-at.resizeCh:
+		}
+
+		at.resizeMu.Lock()
+		pending := at.pendingResize
+		at.pendingResize = make(map[string]PositionUpdate)
+		at.resizeMu.Unlock()
+
+		at.cycleMu.Lock()
+		for _, update := range pending {
+			if err := at.resizeProtectiveOrders(update.Symbol, update.Side, update.Quantity, false); err != nil {
+				at.log.Warn("调整止损止盈数量失败", "symbol", update.Symbol, "side", update.Side, "err", err)
+				at.notify(notify.KindError, update.Symbol, "止损止盈数量调整失败", err.Error())
+			}
+		}
+		at.cycleMu.Unlock()
+	}
+}
+
+// resizeProtectiveOrders 按持仓数量重新挂出数量不符的止损/止盈单（撤单后按原触发价、有效期和触发价格类型重挂）
+// 止损始终覆盖全部持仓；单一止盈同样覆盖全部持仓；多个止盈（分批止盈）只在总数量超过持仓时按比例缩小，
+// growLadder为true时（限价开仓单陆续成交）也按比例放大到持仓数量
+func (at *AutoTrader) resizeProtectiveOrders(symbol, side string, quantity float64, growLadder bool) error {
+	source, ok := at.trader.(OpenOrderSource)
+	canceller, ok2 := at.trader.(TriggerOrderCanceller)
+	if !ok || !ok2 {
+		return nil
+	}
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return err
+	}
+
+	var stops, takeProfits []TriggerOrder
+	tpTotal := 0.0
+	for _, trigger := range triggers {
+		if trigger.Symbol != symbol || trigger.PositionSide != side {
+			continue
+		}
+		if trigger.Kind == "stop_loss" {
+			stops = append(stops, trigger)
+		} else {
+			takeProfits = append(takeProfits, trigger)
+			tpTotal += trigger.Quantity
+		}
+	}
+
+	type resize struct {
+		order    TriggerOrder
+		quantity float64
+	}
+	var resizes []resize
+	for _, stop := range stops {
+		if !sameQuantity(stop.Quantity, quantity) {
+			resizes = append(resizes, resize{stop, quantity})
+		}
+	}
+	switch {
+	case len(takeProfits) == 1 && !sameQuantity(tpTotal, quantity):
+		resizes = append(resizes, resize{takeProfits[0], quantity})
+	case len(takeProfits) > 1 && (tpTotal > quantity || growLadder) && !sameQuantity(tpTotal, quantity):
+		for _, tp := range takeProfits {
+			resizes = append(resizes, resize{tp, tp.Quantity * quantity / tpTotal})
+		}
+	}
+	if len(resizes) == 0 {
+		return nil
+	}
+
+	positionSide := strings.ToUpper(side)
+	var errs []error
+	for _, r := range resizes {
+		// 先检查原触发价，未通过时保留原单（撤单后无法重挂会使持仓失去保护）
+		price, err := at.orders.checkTriggerPrice(symbol, positionSide, r.order.Kind, r.order.TriggerPrice)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := canceller.CancelTriggerOrder(symbol, r.order.OrderID); err != nil {
+			errs = append(errs, fmt.Errorf("撤销%s单%s失败: %w", r.order.Kind, r.order.OrderID, err))
+			continue
+		}
+		opts := TriggerOptions{Expiration: time.Duration(r.order.Expiration) * time.Second, PriceType: r.order.PriceType}
+		if r.order.Kind == "stop_loss" {
+			err = at.placeStopLoss(symbol, positionSide, r.quantity, price, opts)
+		} else {
+			err = at.placeTakeProfit(symbol, positionSide, r.quantity, price, opts)
+		}
+		recordProtectiveOrder(at.journal, at.id, symbol, positionSide, r.order.Kind, r.quantity, price, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("重新设置%s单(%.4f)失败: %w", r.order.Kind, price, err))
+			continue
+		}
+		at.log.Info("止损止盈数量已调整", "symbol", symbol, "side", side, "kind", r.order.Kind,
+			"trigger_price", price, "from", r.order.Quantity, "to", r.quantity)
+	}
+	return errors.Join(errs...)
+}
+
+// replaceStopLoss 加仓成交后替换止损：先按新数量和止损价挂新止损，成功后再撤销该持仓原有的止损条件单
+// （先撤后挂在挂单失败时会使持仓失去保护），止盈条件单按新数量调整。交易所不支持查询或单独撤销条件单时只挂新止损
+func (at *AutoTrader) replaceStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	side := strings.ToLower(positionSide)
+	source, ok := at.trader.(OpenOrderSource)
+	canceller, ok2 := at.trader.(TriggerOrderCanceller)
+	replaceable := ok && ok2
+	var stops []TriggerOrder
+	if replaceable {
+		triggers, err := source.GetOpenTriggerOrders()
+		if err != nil {
+			return fmt.Errorf("获取条件单失败: %w", err)
+		}
+		for _, trigger := range triggers {
+			if trigger.Symbol == symbol && trigger.PositionSide == side && trigger.Kind == "stop_loss" {
+				stops = append(stops, trigger)
+			}
+		}
+	}
+
+	price, err := at.orders.checkTriggerPrice(symbol, positionSide, "stop_loss", stopPrice)
+	if err != nil {
+		return err
+	}
+	// 沿用原止损的有效期和触发价格类型
+	var opts TriggerOptions
+	if len(stops) > 0 {
+		opts = TriggerOptions{Expiration: time.Duration(stops[0].Expiration) * time.Second, PriceType: stops[0].PriceType}
+	}
+	err = at.placeStopLoss(symbol, positionSide, quantity, price, opts)
+	recordProtectiveOrder(at.journal, at.id, symbol, positionSide, "stop_loss", quantity, price, err)
+	if err != nil {
+		return fmt.Errorf("设置止损单(%.4f)失败，原止损保留: %w", price, err)
+	}
+	if !replaceable {
+		return nil
+	}
+
+	var errs []error
+	for _, stop := range stops {
+		if err := canceller.CancelTriggerOrder(symbol, stop.OrderID); err != nil {
+			errs = append(errs, fmt.Errorf("撤销原止损单%s失败: %w", stop.OrderID, err))
+		}
+	}
+	at.log.Info("止损已替换", "symbol", symbol, "side", side, "trigger_price", price, "quantity", quantity, "replaced", len(stops))
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	// 原止损已撤销，剩余数量不符的只有止盈单
+	return at.resizeProtectiveOrders(symbol, side, quantity, true)
+}
+
+// sameQuantity 数量是否一致（允许浮点误差）
+func sameQuantity(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,178 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"nofx/notify"
+	"nofx/store"
+	"strings"
+)
+
+// SnapshotProtectiveOrders 保存交易所上所有生效中的止损/止盈条件单
+// 在改杠杆、切换持仓模式、紧急撤单等会撤掉条件单的操作之前调用，之后用RestoreProtectiveOrders重建；
+// 快照同时写入交易日志，重启后仍可恢复
+func (at *AutoTrader) SnapshotProtectiveOrders() ([]TriggerOrder, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+	return at.snapshotProtectiveOrders()
+}
+
+// RestoreProtectiveOrders 按最近一次快照重新挂出已不存在的止损/止盈单，返回重新挂出的数量
+// 仍在交易所上的条件单和持仓已平掉的跳过，数量不超过当前持仓；全部恢复后删除快照
+func (at *AutoTrader) RestoreProtectiveOrders() (int, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+	return at.restoreProtectiveOrders()
+}
+
+// CancelOrdersKeepingProtection 撤销指定币种的全部挂单后按快照重建止损/止盈单（持仓不会长时间失去保护）
+func (at *AutoTrader) CancelOrdersKeepingProtection(symbol string) (int, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	return at.withProtectiveOrders(func() error {
+		if err := at.trader.CancelAllOrders(symbol); err != nil {
+			return err
+		}
+		at.log.Warn("已人工撤销挂单", "symbol", symbol)
+		return nil
+	})
+}
+
+// withProtectiveOrders 保存条件单快照后执行operation，无论成功与否都按快照恢复（调用方持有cycleMu）
+func (at *AutoTrader) withProtectiveOrders(operation func() error) (int, error) {
+	if _, err := at.snapshotProtectiveOrders(); err != nil {
+		return 0, fmt.Errorf("保存条件单快照失败，未执行操作: %w", err)
+	}
+	opErr := operation()
+	restored, err := at.restoreProtectiveOrders()
+	return restored, errors.Join(opErr, err)
+}
+
+// snapshotProtectiveOrders 读取交易所上的条件单并保存为快照（调用方持有cycleMu）
+func (at *AutoTrader) snapshotProtectiveOrders() ([]TriggerOrder, error) {
+	source, ok := at.trader.(OpenOrderSource)
+	if !ok {
+		return nil, fmt.Errorf("%s 交易器不支持查询条件单", at.exchange)
+	}
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]store.ProtectiveOrder, 0, len(triggers))
+	for _, trigger := range triggers {
+		orders = append(orders, store.ProtectiveOrder{
+			TraderID:     at.id,
+			Symbol:       trigger.Symbol,
+			Side:         trigger.PositionSide,
+			Kind:         trigger.Kind,
+			TriggerPrice: trigger.TriggerPrice,
+			Quantity:     trigger.Quantity,
+		})
+	}
+	if err := at.journal.SaveProtectiveSnapshot(at.id, orders, at.clock.Now()); err != nil {
+		return nil, err
+	}
+	at.protectiveSnapshot = triggers
+	at.log.Info("已保存止损止盈快照", "orders", len(triggers))
+	return triggers, nil
+}
+
+// restoreProtectiveOrders 按快照重建缺失的条件单（调用方持有cycleMu）
+func (at *AutoTrader) restoreProtectiveOrders() (int, error) {
+	snapshot := at.protectiveSnapshot
+	if snapshot == nil {
+		saved, _, err := at.journal.LoadProtectiveSnapshot(at.id)
+		if err != nil {
+			return 0, err
+		}
+		for _, o := range saved {
+			snapshot = append(snapshot, TriggerOrder{Symbol: o.Symbol, PositionSide: o.Side, Kind: o.Kind,
+				TriggerPrice: o.TriggerPrice, Quantity: o.Quantity})
+		}
+	}
+	if len(snapshot) == 0 {
+		return 0, nil
+	}
+
+	source, ok := at.trader.(OpenOrderSource)
+	if !ok {
+		return 0, fmt.Errorf("%s 交易器不支持查询条件单", at.exchange)
+	}
+	current, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return 0, err
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	held := make(map[string]float64)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		if quantity := math.Abs(floatValue(pos["positionAmt"])); symbol != "" && quantity > 0 {
+			held[symbol+"_"+side] = quantity
+		}
+	}
+
+	restored := 0
+	var errs []error
+	for _, o := range snapshot {
+		quantity, ok := held[o.Symbol+"_"+o.PositionSide]
+		if !ok {
+			at.log.Info("持仓已不存在，跳过恢复条件单", "symbol", o.Symbol, "side", o.PositionSide, "kind", o.Kind)
+			continue
+		}
+		if hasTriggerOrder(current, o) {
+			continue
+		}
+		if o.Quantity > 0 && o.Quantity < quantity {
+			quantity = o.Quantity
+		}
+
+		positionSide := strings.ToUpper(o.PositionSide)
+		price, err := at.orders.checkTriggerPrice(o.Symbol, positionSide, o.Kind, o.TriggerPrice)
+		if err == nil {
+			if o.Kind == "stop_loss" {
+				err = at.trader.SetStopLoss(o.Symbol, positionSide, quantity, price)
+			} else {
+				err = at.trader.SetTakeProfit(o.Symbol, positionSide, quantity, price)
+			}
+			recordProtectiveOrder(at.journal, at.id, o.Symbol, positionSide, o.Kind, quantity, price, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("恢复 %s %s %s单(%.4f)失败: %w", o.Symbol, o.PositionSide, o.Kind, o.TriggerPrice, err))
+			continue
+		}
+		restored++
+		at.log.Info("已恢复条件单", "symbol", o.Symbol, "side", o.PositionSide, "kind", o.Kind,
+			"trigger_price", price, "quantity", quantity)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		at.notify(notify.KindError, "", "止损止盈恢复失败", err.Error())
+		return restored, err
+	}
+	at.protectiveSnapshot = nil
+	if err := at.journal.ClearProtectiveSnapshot(at.id); err != nil {
+		at.log.Warn("删除条件单快照失败", "err", err)
+	}
+	return restored, nil
+}
+
+// hasTriggerOrder 交易所上是否已有对应的条件单：止损只要该持仓已有任意止损单即可（避免按快照中的旧价格重复挂止损），
+// 止盈要求触发价一致（分批止盈有多个价格）
+func hasTriggerOrder(orders []TriggerOrder, target TriggerOrder) bool {
+	for _, o := range orders {
+		if o.Symbol != target.Symbol || o.PositionSide != target.PositionSide || o.Kind != target.Kind {
+			continue
+		}
+		if target.Kind == "stop_loss" || math.Abs(o.TriggerPrice-target.TriggerPrice) <= 1e-6*math.Abs(target.TriggerPrice) {
+			return true
+		}
+	}
+	return false
+}
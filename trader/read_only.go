@@ -0,0 +1,89 @@
+package trader
+
+import (
+	"fmt"
+	"time"
+)
+
+// readOnlyTrader 只读交易器（观察模式）：查询照常转发给交易所，所有下单、改杠杆、止损止盈、撤单都被拒绝
+// 拦截在交易器层，AI决策、策略、人工平仓（管理接口/Telegram）都无法绕过
+type readOnlyTrader struct {
+	Trader
+}
+
+// blocked 记录被拦截的操作并返回ErrReadOnly
+func (r readOnlyTrader) blocked(action, symbol string, args ...interface{}) error {
+	orderLog.Info("只读模式已拦截", append([]interface{}{"action", action, "symbol", symbol}, args...)...)
+	return ErrReadOnly
+}
+
+func (r readOnlyTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return nil, r.blocked("open_long", symbol, "quantity", quantity, "leverage", leverage)
+}
+
+func (r readOnlyTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return nil, r.blocked("open_short", symbol, "quantity", quantity, "leverage", leverage)
+}
+
+func (r readOnlyTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return nil, r.blocked("close_long", symbol, "quantity", quantity)
+}
+
+func (r readOnlyTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return nil, r.blocked("close_short", symbol, "quantity", quantity)
+}
+
+func (r readOnlyTrader) SetLeverage(symbol string, leverage int) error {
+	return r.blocked("set_leverage", symbol, "leverage", leverage)
+}
+
+func (r readOnlyTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return r.blocked("stop_loss", symbol, "side", positionSide, "price", stopPrice)
+}
+
+func (r readOnlyTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return r.blocked("take_profit", symbol, "side", positionSide, "price", takeProfitPrice)
+}
+
+func (r readOnlyTrader) CancelAllOrders(symbol string) error {
+	return r.blocked("cancel_orders", symbol)
+}
+
+// GetOpenOrders 查询挂单（只读操作，交易器不支持时返回错误）
+func (r readOnlyTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	source, ok := r.Trader.(OpenOrderSource)
+	if !ok {
+		return nil, fmt.Errorf("交易器不支持查询挂单")
+	}
+	return source.GetOpenOrders(symbol)
+}
+
+// Probe 探测交易所连通性和时钟偏差（只读操作，交易器不支持时返回错误）
+func (r readOnlyTrader) Probe() (ProbeResult, error) {
+	prober, ok := r.Trader.(ExchangeProber)
+	if !ok {
+		return ProbeResult{}, fmt.Errorf("交易器不支持探测交易所")
+	}
+	return prober.Probe()
+}
+
+// SetClockOffset 校正签名时间戳（查询请求同样需要签名）
+func (r readOnlyTrader) SetClockOffset(offset time.Duration) {
+	if compensator, ok := r.Trader.(ClockCompensator); ok {
+		compensator.SetClockOffset(offset)
+	}
+}
+
+// GetAccountSnapshot 查询账户快照（只读操作）
+func (r readOnlyTrader) GetAccountSnapshot() (AccountSnapshot, error) {
+	return fetchAccountSnapshot(r.Trader)
+}
+
+// GetOpenTriggerOrders 查询条件单（只读操作，交易器不支持时返回错误）
+func (r readOnlyTrader) GetOpenTriggerOrders() ([]TriggerOrder, error) {
+	source, ok := r.Trader.(OpenOrderSource)
+	if !ok {
+		return nil, fmt.Errorf("交易器不支持查询挂单")
+	}
+	return source.GetOpenTriggerOrders()
+}
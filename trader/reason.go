@@ -0,0 +1,83 @@
+package trader
+
+import (
+	"context"
+	"strings"
+)
+
+// 原因代码：每次自动下单、系统撤单和风控干预的机器可读原因，写入交易日志（订单、订单事件、风控事件）
+// 并附在通知末尾（#代码），用于按原因筛选和统计（GET /api/reasons）
+const (
+	ReasonAIDecision     = "AI_DECISION"         // AI决策开平仓
+	ReasonExternalSignal = "EXTERNAL_SIGNAL"     // 外部信号（TradingView等Webhook）
+	ReasonManual         = "MANUAL"              // 人工平仓（Telegram、管理接口、命令行）
+	ReasonDCAAdd         = "DCA_ADD"             // DCA加仓
+	ReasonDCAStop        = "DCA_STOP"            // DCA总体止损平仓
+	ReasonPyramidAdd     = "PYRAMID_ADD"         // 顺势加仓
+	ReasonFundingHarvest = "FUNDING_HARVEST"     // 资金费率套利
+	ReasonBasis          = "BASIS_ARBITRAGE"     // 期现基差套利
+	ReasonHedge          = "HEDGE"               // 相关性对冲腿
+	ReasonTimeExit       = "WATCHDOG_TIME_EXIT"  // 策略看守按持仓时间平仓
+	ReasonLimitExpired   = "LIMIT_ORDER_EXPIRED" // 限价开仓单超时撤单
+	ReasonPauseCancel    = "PAUSE_CANCEL"        // 暂停交易时撤销挂单
+	ReasonPaused         = "RISK_PAUSED"         // 人工暂停中拒绝开仓
+	ReasonDrawdown       = "RISK_DRAWDOWN"       // 回撤熔断（及熔断期间拒绝开仓）
+	ReasonSchedule       = "RISK_SCHEDULE"       // 禁止开仓时段（资金费结算前、周末等）
+)
+
+// strategyReasons 策略 → 订单的默认原因代码（未列出的策略按外部信号处理）
+var strategyReasons = map[string]string{
+	"ai":              ReasonAIDecision,
+	"webhook":         ReasonExternalSignal,
+	"manual":          ReasonManual,
+	"dca":             ReasonDCAAdd,
+	"pyramid":         ReasonPyramidAdd,
+	"funding_harvest": ReasonFundingHarvest,
+	"basis":           ReasonBasis,
+	"hedge":           ReasonHedge,
+	"time_exit":       ReasonTimeExit,
+	"adl":             riskReason("adl"),
+	"degradation":     riskReason("degradation"),
+}
+
+// reasonKey 原因代码在context中的键
+type reasonKey struct{}
+
+// withReason 指定本次下单的原因代码（覆盖按策略推断的默认值）
+func withReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+// orderReason 订单的原因代码：context中指定的优先，否则按策略和动作推断
+func orderReason(ctx context.Context, strategy, action string) string {
+	if reason, _ := ctx.Value(reasonKey{}).(string); reason != "" {
+		return reason
+	}
+	if strategy == "dca" && strings.HasPrefix(action, "close") {
+		return ReasonDCAStop // DCA只在总体止损时平仓
+	}
+	if reason, ok := strategyReasons[strategy]; ok {
+		return reason
+	}
+	return ReasonExternalSignal
+}
+
+// riskReason 风控规则的原因代码（RISK_<规则>，如 RISK_THROTTLE、RISK_ENTRY_LIMIT）
+func riskReason(rule string) string {
+	return "RISK_" + strings.ToUpper(rule)
+}
+
+// entryBlockedReason 禁止开仓的具体原因代码（与checkEntryAllowed的判断顺序一致）
+func (at *AutoTrader) entryBlockedReason() string {
+	switch {
+	case at.IsPaused():
+		return ReasonPaused
+	case at.clock.Now().Before(at.stopUntil):
+		return ReasonDrawdown
+	default:
+		if blocked, _ := at.config.Schedule.EntryBlocked(at.clock.Now()); !blocked && at.degradation.entriesBlocked() != nil {
+			return riskReason("degradation")
+		}
+		return ReasonSchedule
+	}
+}
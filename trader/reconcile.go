@@ -0,0 +1,355 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/events"
+	"nofx/fx"
+	"nofx/logging"
+	"nofx/notify"
+	"nofx/store"
+	"strings"
+	"time"
+)
+
+// reconcileLog 启动对账日志
+var reconcileLog = logging.For("reconcile")
+
+// OpenOrder 交易所上未成交的普通委托单
+type OpenOrder struct {
+	OrderID    string  `json:"order_id"`
+	Symbol     string  `json:"symbol"`
+	Quantity   float64 `json:"quantity"` // 正数买入，负数卖出
+	Price      float64 `json:"price"`
+	ReduceOnly bool    `json:"reduce_only,omitempty"` // 只减仓（分批止盈等平仓挂单）
+	Text       string  `json:"text,omitempty"`        // 下单时的自定义文本（订单归因标记）
+}
+
+// TriggerOrder 交易所上生效中的条件单（止损/止盈）
+type TriggerOrder struct {
+	OrderID      string  `json:"order_id,omitempty"`
+	Symbol       string  `json:"symbol"`
+	PositionSide string  `json:"position_side"` // long/short
+	Kind         string  `json:"kind"`          // stop_loss/take_profit
+	TriggerPrice float64 `json:"trigger_price"`
+	Quantity     float64 `json:"quantity,omitempty"`   // 条件单数量（0表示全部持仓）
+	Expiration   int64   `json:"expiration,omitempty"` // 有效期（秒，0表示不过期或交易所未返回）
+	ExpiresAt    int64   `json:"expires_at,omitempty"` // 到期时间（Unix秒）
+	PriceType    string  `json:"price_type,omitempty"` // 触发价格类型（mark/last/index，交易所未返回时为空）
+	Text         string  `json:"text,omitempty"`       // 下单时的自定义文本（订单归因标记）
+}
+
+// OpenOrderSource 可查询挂单和条件单的交易器（用于启动对账）
+type OpenOrderSource interface {
+	// GetOpenOrders 获取指定币种未成交的普通委托单
+	GetOpenOrders(symbol string) ([]OpenOrder, error)
+
+	// GetOpenTriggerOrders 获取所有生效中的止损/止盈条件单
+	GetOpenTriggerOrders() ([]TriggerOrder, error)
+}
+
+// reconcile 启动对账：比较交易所持仓/挂单/条件单与交易日志，
+// 接管未记录的持仓、关闭已不存在的持仓记录，并恢复DCA和资金费率套利等策略状态
+// 返回需要人工关注的问题列表
+func (at *AutoTrader) reconcile() []string {
+	logger := reconcileLog.With("trader", at.id)
+	logger.Info("启动对账: 比较交易所状态与交易日志")
+
+	var issues []string
+	alert := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		logger.Warn(msg)
+		issues = append(issues, msg)
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		alert("获取交易所持仓失败，跳过对账: %v", err)
+		return issues
+	}
+
+	// 1. 交易所持仓 vs 交易日志
+	exchangeKeys := make(map[string]bool)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity := math.Abs(floatValue(pos["positionAmt"]))
+		entryPrice := floatValue(pos["entryPrice"])
+		if symbol == "" || quantity == 0 {
+			continue
+		}
+		posKey := symbol + "_" + side
+		// 交易所持仓为合约张数，交易日志按基础币数量记录
+		baseQty := contractSpec(at.trader, symbol).BaseQuantity(quantity)
+		exchangeKeys[posKey] = true
+
+		journaled, err := at.journal.GetOpenPosition(at.id, symbol, side)
+		if err != nil {
+			alert("读取 %s 持仓记录失败: %v", posKey, err)
+			continue
+		}
+
+		if journaled == nil {
+			// 未记录的持仓（手动开仓或存储启用前的持仓），接管并继续管理
+			alert("发现交易日志中没有的持仓 %s %s 数量%.4f 均价%.4f，已接管", symbol, side, quantity, entryPrice)
+			if at.journal != nil {
+				if err := at.journal.OpenPosition(store.Position{
+					TraderID:   at.id,
+					Symbol:     symbol,
+					Side:       side,
+					Quantity:   baseQty,
+					Contracts:  quantity,
+					EntryPrice: entryPrice,
+					Leverage:   int(floatValue(pos["leverage"])),
+					Strategy:   "adopted",
+				}); err != nil {
+					alert("接管 %s 持仓失败: %v", posKey, err)
+				}
+			}
+			continue
+		}
+
+		if math.Abs(journaled.Quantity-baseQty) > baseQty*0.01 {
+			logger.Info("持仓数量与记录不一致", "position", posKey, "exchange_qty", baseQty, "journal_qty", journaled.Quantity)
+		}
+
+		// 恢复持仓时长（AI上下文使用）
+		at.positionFirstSeenTime[posKey] = journaled.OpenedAt.UnixMilli()
+
+		at.restoreStrategyState(journaled)
+	}
+
+	// 2. 交易日志中未平仓、但交易所已不存在的持仓（停机期间止损/止盈触发或手动平仓）
+	for _, msg := range at.closeVanishedPositions(positions, 0) {
+		alert("停机期间%s", msg)
+	}
+
+	// 3. 未到终态的订单：向交易所确认最终状态
+	activeOrders, err := at.journal.ListActiveOrders(at.id)
+	if err != nil {
+		alert("读取未完成订单失败: %v", err)
+	}
+	source, canConfirm := at.trader.(OrderStatusSource)
+	for _, order := range activeOrders {
+		if order.Status == store.OrderCreated && order.SentAt == nil {
+			// 下单意图未发送：重启前停在下单前检查，订单不可能到达交易所
+			at.orders.transition(order.ID, store.OrderUpdate{State: store.OrderRejected, Detail: "重启前未发送到交易所"})
+			logger.Info("重启前未发送的订单已关闭", "ref", order.ID, "symbol", order.Symbol, "action", order.Action)
+			continue
+		}
+		if at.resolveClientOrder(&order) && order.OrderID == "" {
+			at.orders.transition(order.ID, store.OrderUpdate{State: store.OrderRejected, Detail: "重启前发送的订单未到达交易所"})
+			alert("订单 #%d (%s %s) 重启前已发送但未到达交易所（按客户端订单ID确认），标记为%s",
+				order.ID, order.Symbol, order.Action, store.OrderRejected)
+			continue
+		}
+		if !canConfirm || order.OrderID == "" {
+			update := store.OrderUpdate{State: store.OrderRejected, Detail: "重启时订单状态未知"}
+			if order.Status != store.OrderCreated {
+				update.State = store.OrderCancelled
+			}
+			at.orders.transition(order.ID, update)
+			alert("订单 #%d (%s %s) 状态无法确认，标记为%s", order.ID, order.Symbol, order.Action, update.State)
+			continue
+		}
+		update, err := source.GetOrderStatus(order.Symbol, order.OrderID)
+		if err != nil {
+			alert("确认订单 %s 状态失败: %v", order.OrderID, err)
+			continue
+		}
+		at.orders.transition(order.ID, update)
+		logger.Info("订单状态已确认", "order_id", order.OrderID, "symbol", order.Symbol, "action", order.Action,
+			"state", update.State, "filled", update.FilledQty)
+	}
+
+	// 4. 挂单与条件单：每个持仓都应有止损
+	if orderSource, ok := at.trader.(OpenOrderSource); ok {
+		triggers, err := orderSource.GetOpenTriggerOrders()
+		if err != nil {
+			alert("获取条件单失败: %v", err)
+		} else {
+			hasStop := make(map[string]bool)
+			for _, trigger := range triggers {
+				if trigger.Kind == "stop_loss" {
+					hasStop[trigger.Symbol+"_"+trigger.PositionSide] = true
+				}
+			}
+			for posKey := range exchangeKeys {
+				if !hasStop[posKey] {
+					alert("持仓 %s 没有生效中的止损单，请检查", strings.Replace(posKey, "_", " ", 1))
+				}
+			}
+		}
+
+		for posKey := range exchangeKeys {
+			symbol := posKey[:strings.LastIndex(posKey, "_")]
+			openOrders, err := orderSource.GetOpenOrders(symbol)
+			if err != nil {
+				continue
+			}
+			for _, order := range openOrders {
+				logger.Info("存在未成交委托", "symbol", symbol, "order_id", order.OrderID, "quantity", order.Quantity, "price", order.Price)
+			}
+		}
+	}
+
+	// 5. 交易日志中没有的开仓挂单（重启前刚提交、记录未写入）：不接管，在其成交或撤销前该币种同方向不会重复开仓
+	if orderSource, ok := at.trader.(OpenOrderSource); ok && at.journal != nil {
+		openOrders, err := orderSource.GetOpenOrders("")
+		if err != nil {
+			alert("获取未成交委托失败: %v", err)
+		}
+		for _, order := range openOrders {
+			side := entrySide(order)
+			if side == "" {
+				continue
+			}
+			if journaled, err := at.journal.GetOrderByOrderID(at.id, order.OrderID); err != nil || journaled != nil {
+				continue
+			}
+			alert("发现交易日志中没有的开仓挂单 %s %s 数量%v 价格%v（订单%s），成交或撤销前不会再开%s仓",
+				order.Symbol, side, math.Abs(order.Quantity), order.Price, order.OrderID, sideName(side))
+		}
+	}
+
+	if len(issues) == 0 {
+		logger.Info("对账完成: 交易所状态与交易日志一致", "positions", len(exchangeKeys))
+	} else {
+		logger.Warn("对账完成: 发现问题", "issues", len(issues))
+		at.notify(notify.KindRisk, "", fmt.Sprintf("启动对账发现%d个问题", len(issues)), strings.Join(issues, "\n"))
+	}
+	return issues
+}
+
+// closeVanishedPositions 关闭交易日志中未平仓、但交易所已不存在的持仓（止损/止盈条件单触发或手动平仓），
+// 按当前价格记录出场并推送通知；grace内新开的持仓跳过（交易器的持仓缓存可能尚未包含）
+func (at *AutoTrader) closeVanishedPositions(positions []map[string]interface{}, grace time.Duration) []string {
+	openRecords, err := at.journal.ListOpenPositions(at.id)
+	if err != nil {
+		return []string{fmt.Sprintf("读取未平仓记录失败: %v", err)}
+	}
+
+	exchangeKeys := make(map[string]bool)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		if math.Abs(floatValue(pos["positionAmt"])) > 0 {
+			exchangeKeys[symbol+"_"+side] = true
+		}
+	}
+
+	var messages []string
+	for _, record := range openRecords {
+		if exchangeKeys[record.Symbol+"_"+record.Side] || time.Since(record.OpenedAt) < grace {
+			continue
+		}
+		exitPrice, _ := at.trader.GetMarketPrice(record.Symbol)
+		if err := at.journal.ClosePosition(at.id, record.Symbol, record.Side, exitPrice); err != nil {
+			messages = append(messages, fmt.Sprintf("关闭 %s 持仓记录失败: %v", record.Symbol, err))
+			continue
+		}
+
+		kind, reason := triggeredExit(record, exitPrice)
+		msg := fmt.Sprintf("%s %s %s，按当前价格%.4f关闭记录", record.Symbol, record.Side, reason, exitPrice)
+		messages = append(messages, msg)
+		reconcileLog.Info("持仓已在交易所平仓", "trader", at.id, "symbol", record.Symbol, "side", record.Side,
+			"reason", reason, "exit_price", exitPrice)
+		at.events.Publish(events.Event{Type: events.TypePosition, TraderID: at.id, Symbol: record.Symbol,
+			Position: &events.PositionEvent{Change: "close", Side: record.Side, Strategy: record.Strategy,
+				Quantity: positionContracts(at.trader, &record), Price: exitPrice, Leverage: record.Leverage, EntryPrice: record.EntryPrice, GrossPnL: record.GrossPnL(exitPrice),
+				Reason: reason}})
+		at.notify(kind, record.Symbol, fmt.Sprintf("%s %s %s", record.Symbol, strings.ToUpper(record.Side), reason),
+			fmt.Sprintf("入场: %.4f → 出场: %.4f | 毛盈亏: %+.2f USDT%s",
+				record.EntryPrice, exitPrice, record.GrossPnL(exitPrice), fx.Suffix(record.GrossPnL(exitPrice), true)))
+	}
+	return messages
+}
+
+// triggeredExit 根据出场价判断是止损还是止盈触发（无法判断时视为外部平仓）
+func triggeredExit(record store.Position, exitPrice float64) (notify.Kind, string) {
+	hitStop := record.StopLoss > 0 && ((record.Side == "long" && exitPrice <= record.StopLoss) ||
+		(record.Side == "short" && exitPrice >= record.StopLoss))
+	hitTarget := record.TakeProfit > 0 && ((record.Side == "long" && exitPrice >= record.TakeProfit) ||
+		(record.Side == "short" && exitPrice <= record.TakeProfit))
+
+	switch {
+	case hitStop:
+		return notify.KindStopLoss, "止损触发"
+	case hitTarget:
+		return notify.KindTakeProfit, "止盈触发"
+	case record.StopLoss > 0 && record.TakeProfit > 0:
+		// 触发后价格已回到区间内，按距离较近的一侧判断
+		if math.Abs(exitPrice-record.StopLoss) < math.Abs(exitPrice-record.TakeProfit) {
+			return notify.KindStopLoss, "止损触发"
+		}
+		return notify.KindTakeProfit, "止盈触发"
+	default:
+		return notify.KindExit, "已在交易所平仓"
+	}
+}
+
+// restoreStrategyState 根据交易日志恢复策略状态（DCA/顺势加仓次数、资金费率套利持仓）
+// 策略状态和成交记录（FilledQty）为合约张数
+func (at *AutoTrader) restoreStrategyState(position *store.Position) {
+	action := "open_" + position.Side
+	contracts := positionContracts(at.trader, position)
+
+	if at.dcaManager.Enabled() {
+		adds, err := at.journal.ListStrategyFills(at.id, "dca", position.Symbol, action, position.OpenedAt)
+		if err == nil && len(adds) > 0 {
+			added := 0.0
+			for _, add := range adds {
+				added += add.FilledQty
+			}
+			last := adds[len(adds)-1]
+			at.dcaManager.Restore(position.Symbol, position.Side, contracts-added, len(adds), last.AvgPrice)
+			reconcileLog.Info("恢复DCA状态", "trader", at.id, "symbol", position.Symbol, "side", position.Side, "adds", len(adds))
+		}
+	}
+
+	// 顺势加仓需要初始止损计算R：首仓数量和均价由当前持仓扣除加仓成交得到
+	if at.pyramidManager.Enabled() && position.StopLoss > 0 {
+		adds, err := at.journal.ListStrategyFills(at.id, "pyramid", position.Symbol, action, position.OpenedAt)
+		if err == nil {
+			baseQty, baseCost, lastAddPrice := contracts, contracts*position.EntryPrice, position.EntryPrice
+			for _, add := range adds {
+				baseQty -= add.FilledQty
+				baseCost -= add.FilledQty * add.AvgPrice
+				lastAddPrice = add.AvgPrice
+			}
+			if baseQty > 0 {
+				at.pyramidManager.Restore(position.Symbol, position.Side, baseQty, baseCost/baseQty, position.StopLoss,
+					position.TakeProfit, len(adds), lastAddPrice)
+				reconcileLog.Info("恢复顺势加仓状态", "trader", at.id, "symbol", position.Symbol, "side", position.Side, "adds", len(adds))
+			}
+		}
+	}
+
+	if at.fundingHarvester.Enabled() && position.Strategy == "funding_harvest" && position.Side == "short" {
+		if err := at.fundingHarvester.Restore(position.Symbol, contracts, position.EntryPrice, position.OpenedAt); err != nil {
+			reconcileLog.Warn("恢复资金费率套利持仓失败", "trader", at.id, "symbol", position.Symbol, "err", err)
+		} else {
+			reconcileLog.Info("恢复资金费率套利持仓", "trader", at.id, "symbol", position.Symbol, "contracts", contracts)
+		}
+	}
+
+	if at.basisMonitor.Enabled() && position.Strategy == "basis" && position.Side == "short" {
+		if err := at.basisMonitor.Restore(position.Symbol, contracts, position.OpenedAt); err != nil {
+			reconcileLog.Warn("恢复期现套利持仓失败", "trader", at.id, "symbol", position.Symbol, "err", err)
+		} else {
+			reconcileLog.Info("恢复期现套利持仓", "trader", at.id, "symbol", position.Symbol, "contracts", contracts)
+		}
+	}
+
+	if at.hedgeManager.Enabled() && position.Strategy == "hedge" {
+		at.restoreHedges(position)
+	}
+}
+
+// floatValue 从interface{}中读取float64
+func floatValue(v interface{}) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// checkRegime 开仓前按币种当前的行情状态过滤（获取不到行情或K线不足以判断时不检查）
+func (at *AutoTrader) checkRegime(d *decision.Decision) error {
+	filter := at.config.RegimeFilter
+	if !filter.Enabled() {
+		return nil
+	}
+	data, err := market.Get(d.Symbol)
+	if err != nil || data.Regime == nil {
+		at.log.Warn("获取行情状态失败，跳过行情状态过滤", "symbol", d.Symbol, "err", err)
+		return nil
+	}
+	if err := filter.Check(string(data.Regime.Regime)); err != nil {
+		return fmt.Errorf("%w: %v", ErrRegimeBlocked, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/risk"
+	"nofx/scheduler"
+	"nofx/store"
+	"nofx/strategy"
+	"nofx/webhook"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RuntimeConfig 可在运行时热加载的配置
+// 只包含不影响交易器连接和任务调度的参数，持仓跟踪、DCA/顺势加仓次数、对冲持仓等内存状态在更新后保留
+type RuntimeConfig struct {
+	BTCETHLeverage  int
+	AltcoinLeverage int
+	AutoLeverage    risk.AutoLeverageConfig
+	Liquidation     risk.LiquidationGuard
+	AccountLimits   risk.AccountLimits
+	MaxDailyLoss    float64
+	MaxDrawdown     float64
+	StopTradingTime time.Duration
+	DCA             strategy.DCAConfig
+	Pyramid         strategy.PyramidConfig
+	FundingHarvest  strategy.FundingHarvestConfig
+	Basis           strategy.BasisConfig
+	Hedge           strategy.HedgeConfig
+	EntryRules      scheduler.EntryRules
+	ExitRules       scheduler.ExitRules
+	Cadence         scheduler.Cadence
+	Webhook         webhook.Config
+	Calendar        risk.EconomicCalendar
+	SoftClose       risk.SoftClose
+	EntryConfirm    risk.EntryConfirm
+	Flatten         risk.Flatten
+	DowntimeQueue   risk.DowntimeQueue
+	EntryLimit      risk.EntryLimit
+	TradeLossCap    risk.TradeLossCap
+	Staleness       risk.Staleness
+	BalanceAnomaly  risk.BalanceAnomaly
+	OrderRateLimit  risk.OrderRateLimit
+	RiskProfiles    risk.RiskProfiles
+	ADLGuard        risk.ADLGuard
+}
+
+// ApplyRuntimeConfig 应用热加载的配置，返回变更说明（无变化时为空）
+// 与AI决策、策略看守串行执行，因此在两个周期之间生效
+func (at *AutoTrader) ApplyRuntimeConfig(rc RuntimeConfig) []string {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+	at.configMu.Lock()
+	defer at.configMu.Unlock()
+
+	var changes []string
+	changed := func(name string, old, new interface{}) bool {
+		if reflect.DeepEqual(old, new) {
+			return false
+		}
+		changes = append(changes, fmt.Sprintf("%s: %v → %v", name, old, new))
+		return true
+	}
+
+	if changed("leverage.btc_eth_leverage", at.config.BTCETHLeverage, rc.BTCETHLeverage) {
+		at.config.BTCETHLeverage = rc.BTCETHLeverage
+	}
+	if changed("leverage.altcoin_leverage", at.config.AltcoinLeverage, rc.AltcoinLeverage) {
+		at.config.AltcoinLeverage = rc.AltcoinLeverage
+	}
+	if changed("leverage.auto", at.config.AutoLeverage, rc.AutoLeverage) {
+		at.config.AutoLeverage = rc.AutoLeverage
+	}
+	if changed("leverage.liquidation", at.config.LiquidationGuard, rc.Liquidation) {
+		at.config.LiquidationGuard = rc.Liquidation
+	}
+	if changed("leverage.account", at.config.AccountLimits, rc.AccountLimits) {
+		at.config.AccountLimits = rc.AccountLimits
+	}
+	if changed("max_daily_loss", at.config.MaxDailyLoss, rc.MaxDailyLoss) {
+		at.config.MaxDailyLoss = rc.MaxDailyLoss
+	}
+	if changed("max_drawdown", at.config.MaxDrawdown, rc.MaxDrawdown) {
+		at.config.MaxDrawdown = rc.MaxDrawdown
+	}
+	if changed("stop_trading_minutes", at.config.StopTradingTime, rc.StopTradingTime) {
+		at.config.StopTradingTime = rc.StopTradingTime
+	}
+	if changed("dca", at.config.DCA, rc.DCA) {
+		at.config.DCA = rc.DCA
+		at.dcaManager.SetConfig(rc.DCA)
+	}
+	if changed("pyramid", at.config.Pyramid, rc.Pyramid) {
+		at.config.Pyramid = rc.Pyramid
+		at.pyramidManager.SetConfig(rc.Pyramid)
+	}
+	if !reflect.DeepEqual(at.config.FundingHarvest, rc.FundingHarvest) {
+		if at.fundingHarvester == nil && rc.FundingHarvest.Enabled {
+			// 启动时未启用则没有现货执行器，需要重启创建
+			changes = append(changes, "funding_harvest: 启用资金费率套利需要重启才能生效")
+		} else {
+			changed("funding_harvest", at.config.FundingHarvest, rc.FundingHarvest)
+			at.config.FundingHarvest = rc.FundingHarvest
+			if at.fundingHarvester != nil {
+				at.fundingHarvester.SetConfig(rc.FundingHarvest)
+			}
+		}
+	}
+	if !reflect.DeepEqual(at.config.Basis, rc.Basis) {
+		if at.basisMonitor == nil && rc.Basis.Enabled {
+			changes = append(changes, "basis: 启用期现基差监控需要重启才能生效")
+		} else {
+			changed("basis", at.config.Basis, rc.Basis)
+			at.config.Basis = rc.Basis
+			if at.basisMonitor != nil {
+				at.basisMonitor.SetConfig(rc.Basis)
+			}
+		}
+	}
+	if !reflect.DeepEqual(at.config.Hedge, rc.Hedge) {
+		if at.hedgeManager == nil && rc.Hedge.Enabled {
+			changes = append(changes, "hedge: 启用相关性对冲需要重启才能生效")
+		} else {
+			changed("hedge", at.config.Hedge, rc.Hedge)
+			at.config.Hedge = rc.Hedge
+			if at.hedgeManager != nil {
+				at.hedgeManager.SetConfig(rc.Hedge)
+			}
+		}
+	}
+	if changed("schedule(禁止开仓规则)", at.config.Schedule.EntryRules, rc.EntryRules) {
+		at.config.Schedule.EntryRules = rc.EntryRules
+	}
+	if changed("schedule(按时间平仓规则)", at.config.Schedule.ExitRules, rc.ExitRules) {
+		at.config.Schedule.ExitRules = rc.ExitRules
+	}
+	if changed("schedule.cadence", at.config.Schedule.Cadence, rc.Cadence) {
+		at.config.Schedule.Cadence = rc.Cadence
+	}
+	if !reflect.DeepEqual(at.config.Webhook, rc.Webhook) {
+		// 不打印具体值（包含签名密钥）
+		changes = append(changes, "webhook: 已更新")
+		at.config.Webhook = rc.Webhook
+	}
+	if !reflect.DeepEqual(at.config.Calendar, rc.Calendar) {
+		changes = append(changes, fmt.Sprintf("economic_calendar: 已更新（%d个事件）", len(rc.Calendar.Events)))
+		at.config.Calendar = rc.Calendar
+	}
+	if changed("soft_close", at.config.SoftClose, rc.SoftClose) {
+		at.config.SoftClose = rc.SoftClose
+	}
+	if changed("confirm_entries", at.config.EntryConfirm, rc.EntryConfirm) {
+		at.config.EntryConfirm = rc.EntryConfirm
+	}
+	if changed("flatten", at.config.Flatten, rc.Flatten) {
+		at.config.Flatten = rc.Flatten
+	}
+	if changed("downtime_queue", at.config.DowntimeQueue, rc.DowntimeQueue) {
+		at.config.DowntimeQueue = rc.DowntimeQueue
+		at.orders.downtime.setConfig(rc.DowntimeQueue)
+	}
+	if changed("entry_limit", at.config.EntryLimit, rc.EntryLimit) {
+		at.config.EntryLimit = rc.EntryLimit
+		at.entryLimit.setConfig(rc.EntryLimit)
+	}
+	if changed("max_trade_loss", at.config.TradeLossCap, rc.TradeLossCap) {
+		at.config.TradeLossCap = rc.TradeLossCap
+	}
+	if changed("staleness", at.config.Staleness, rc.Staleness) {
+		at.config.Staleness = rc.Staleness
+	}
+	if changed("balance_anomaly", at.config.BalanceAnomaly, rc.BalanceAnomaly) {
+		at.config.BalanceAnomaly = rc.BalanceAnomaly
+	}
+	if changed("order_rate_limit", at.config.OrderRateLimit, rc.OrderRateLimit) {
+		at.config.OrderRateLimit = rc.OrderRateLimit
+		at.orders.rate.setLimits(rc.OrderRateLimit)
+	}
+	if changed("risk_profiles", at.config.RiskProfiles, rc.RiskProfiles) {
+		at.config.RiskProfiles = rc.RiskProfiles
+	}
+	if changed("adl_guard", at.config.ADLGuard, rc.ADLGuard) {
+		at.config.ADLGuard = rc.ADLGuard
+	}
+
+	if len(changes) > 0 {
+		at.log.Info("配置已热加载", "changes", changes)
+	}
+	now := at.clock.Now()
+	for _, change := range changes {
+		name, _, _ := strings.Cut(change, ":")
+		at.recordTimeline(store.TimelineConfig, "", name, "配置已热加载 "+change, now, nil)
+	}
+	return changes
+}
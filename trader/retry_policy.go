@@ -0,0 +1,108 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// RetryPolicy 归一化的重试策略：各交易器把交易所错误标签映射到策略，重试逻辑只按策略处理，不再识别具体交易所的错误文本
+type RetryPolicy string
+
+const (
+	RetryFatal   RetryPolicy = "fatal"           // 不重试：交易所明确拒绝（保证金不足、数量过小、持仓不存在等）
+	RetryNow     RetryPolicy = "retry"           // 可重试：结果不确定（超时、连接中断、网关5xx），下单需先确认原订单未到达交易所
+	RetryBackoff RetryPolicy = "backoff"         // 退避后重试：交易所已拒绝本次请求但稍后可能成功（限流、杠杆切换冷却）
+	RetryReauth  RetryPolicy = "requires_reauth" // 需要修改密钥设置：重试和重启都无法恢复（密钥无效、IP白名单、权限不足）
+)
+
+// retryMaxDelay 退避等待上限
+const retryMaxDelay = 30 * time.Second
+
+// ErrorRule 交易所错误标签的归一化规则
+type ErrorRule struct {
+	Kind   error       // 错误分类（调用方用errors.Is判断）
+	Policy RetryPolicy // 重试策略（为空时使用分类的默认策略）
+}
+
+// ErrorTable 交易器的错误标签表（标签 → 规则）；新增交易所只需提供该表，重试逻辑无需修改
+type ErrorTable map[string]ErrorRule
+
+// classify 按标签生成已分类的交易所错误（标签未覆盖时使用fallback分类，仍无法分类时返回nil）
+func (t ErrorTable) classify(label, message string, fallback error, err error) *ExchangeError {
+	rule, ok := t[label]
+	if !ok {
+		rule = ErrorRule{Kind: fallback}
+	}
+	if rule.Kind == nil {
+		return nil
+	}
+	if rule.Policy == "" {
+		rule.Policy = kindPolicy(rule.Kind)
+	}
+	return &ExchangeError{Kind: rule.Kind, Label: label, Message: message, Policy: rule.Policy, Err: err}
+}
+
+// kindPolicies 错误分类的默认重试策略（未列出的分类不重试）
+var kindPolicies = []struct {
+	kind   error
+	policy RetryPolicy
+}{
+	{ErrExchangeUnavailable, RetryNow},
+	{ErrRateLimited, RetryBackoff},
+	{ErrLeverageCooldown, RetryBackoff},
+	{ErrRequestExpired, RetryNow}, // 时钟同步会修正时间戳，重新签名即可
+	{ErrInvalidAPIKey, RetryReauth},
+	{ErrIPNotWhitelisted, RetryReauth},
+	{ErrKeyPermission, RetryReauth},
+	{ErrNetworkMismatch, RetryReauth},
+}
+
+// kindPolicy 错误分类的默认重试策略
+func kindPolicy(kind error) RetryPolicy {
+	for _, p := range kindPolicies {
+		if errors.Is(kind, p.kind) {
+			return p.policy
+		}
+	}
+	return RetryFatal
+}
+
+// retryPolicyOf 错误的重试策略：已分类的交易所错误使用交易器给出的策略，其他错误按分类，
+// 网络错误（超时、连接中断）视为结果不确定，其余不重试
+func retryPolicyOf(err error) RetryPolicy {
+	if err == nil {
+		return RetryFatal
+	}
+	var exchangeErr *ExchangeError
+	if errors.As(err, &exchangeErr) && exchangeErr.Policy != "" {
+		return exchangeErr.Policy
+	}
+	if policy := kindPolicy(err); policy != RetryFatal {
+		return policy
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return RetryNow
+	}
+	return RetryFatal
+}
+
+// delay 第attempt次（从1开始）重试前的等待时间：可重试按次数线性递增，退避按2的幂递增（不超过retryMaxDelay）；
+// 不应重试时返回false
+func (p RetryPolicy) delay(attempt int, base time.Duration) (time.Duration, bool) {
+	switch p {
+	case RetryNow:
+		return time.Duration(attempt) * base, true
+	case RetryBackoff:
+		if d := base << attempt; attempt < 16 && d < retryMaxDelay {
+			return d, true
+		}
+		return retryMaxDelay, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,55 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/risk"
+)
+
+// riskProfile 策略在该币种上生效的风控参数：全局杠杆和敞口上限，再按risk_profiles逐层覆盖
+func (at *AutoTrader) riskProfile(strategy, symbol string) risk.RiskProfile {
+	base := risk.RiskProfile{
+		MaxLeverage:         risk.MaxLeverage(symbol, at.config.BTCETHLeverage, at.config.AltcoinLeverage),
+		MaxPositionMultiple: at.exposureLimits.MaxPositionValue(symbol, 1),
+	}
+	return at.config.RiskProfiles.Resolve(base, strategy, symbol)
+}
+
+// addExposureLimits 策略加仓（如DCA）使用的敞口限制：单币种上限为该策略在该币种上生效的max_position_multiple
+// （全局上限按risk_profiles覆盖），总名义价值上限为leverage.account.max_effective_leverage
+func (at *AutoTrader) addExposureLimits(strategy, symbol string) risk.ExposureLimits {
+	multiple := at.riskProfile(strategy, symbol).MaxPositionMultiple
+	return risk.ExposureLimits{
+		BTCETHMaxMultiple:  multiple,
+		AltcoinMaxMultiple: multiple,
+		TotalMaxMultiple:   at.config.AccountLimits.MaxEffectiveLeverage,
+	}
+}
+
+// checkRiskProfile 开仓前按当前决策来源和币种的风控参数检查杠杆、仓位、止损距离和单笔风险（未配置risk_profiles时不检查）
+// 入场价取限价，市价开仓取当前价
+func (at *AutoTrader) checkRiskProfile(d *decision.Decision) error {
+	if !at.config.RiskProfiles.Enabled() {
+		return nil
+	}
+	entry := d.LimitPrice
+	if entry <= 0 {
+		price, err := at.trader.GetMarketPrice(d.Symbol)
+		if err != nil {
+			return fmt.Errorf("获取价格失败: %w", err)
+		}
+		entry = price
+	}
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+
+	profile := at.riskProfile(at.execSource, d.Symbol)
+	if err := profile.Check(d.Leverage, d.PositionSizeUSD, entry, d.StopLoss, wallet+unrealized); err != nil {
+		return fmt.Errorf("%w（%s %s）: %v", ErrRiskProfile, at.execSource, d.Symbol, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"nofx/risk"
+	"nofx/store"
+	"time"
+)
+
+// FeeRateSource 可查询账户当前费率的交易器（费率为小数，挂单返佣时maker为负数）
+type FeeRateSource interface {
+	GetFeeRates(symbol string) (maker, taker float64, err error)
+}
+
+// MakerEntrySupport 支持只挂单开仓的交易器
+type MakerEntrySupport interface {
+	// GetBestQuote 买一/卖一价
+	GetBestQuote(symbol string) (bid, ask float64, err error)
+	// OpenPostOnly 在买一（开多）/卖一（开空）挂只挂单，等待timeout后撤销未成交部分，完全未成交时返回ErrMakerNotFilled
+	OpenPostOnly(symbol, side string, quantity float64, leverage int, timeout time.Duration) (map[string]interface{}, error)
+}
+
+// ChaseEntrySupport 支持追价限价开仓的交易器
+type ChaseEntrySupport interface {
+	// OpenChase 在买一（开多）/卖一（开空）挂只挂单，每隔interval检查盘口，价格移动时撤单按新价重挂（最多maxReprices次），
+	// timeout后剩余数量市价成交；返回结果包含多笔订单汇总的filledQty/avgPrice和只挂单成交数量makerQty
+	OpenChase(symbol, side string, quantity float64, leverage int, maxReprices int, interval, timeout time.Duration) (map[string]interface{}, error)
+}
+
+// chooseEntryRoute 选择开仓执行方式，返回执行方式和当前费率（交易器不提供费率时ok为false）
+func (at *AutoTrader) chooseEntryRoute(symbol string) (route string, makerFee, takerFee float64, ok bool) {
+	if source, isSource := at.trader.(FeeRateSource); isSource {
+		var err error
+		if makerFee, takerFee, err = source.GetFeeRates(symbol); err != nil {
+			at.log.Warn("获取费率失败", "symbol", symbol, "err", err)
+		} else {
+			ok = true
+		}
+	}
+
+	routing := at.config.EntryRouting
+	maker, isMaker := at.trader.(MakerEntrySupport)
+	if !routing.Enabled() || !isMaker || (routing.Mode == "auto" && !ok) {
+		return risk.RouteTaker, makerFee, takerFee, ok
+	}
+	if routing.Mode == "chase" {
+		if _, isChase := at.trader.(ChaseEntrySupport); isChase {
+			return risk.RouteChase, makerFee, takerFee, ok
+		}
+		return risk.RouteTaker, makerFee, takerFee, ok
+	}
+
+	bid, ask, err := maker.GetBestQuote(symbol)
+	if err != nil || bid <= 0 || ask < bid {
+		at.log.Warn("获取盘口失败，使用市价开仓", "symbol", symbol, "err", err)
+		return risk.RouteTaker, makerFee, takerFee, ok
+	}
+	spreadBps := (ask - bid) / ((ask + bid) / 2) * 1e4
+	route, makerBps, takerBps := routing.Choose(makerFee, takerFee, spreadBps)
+	at.log.Info("开仓执行方式", "symbol", symbol, "route", route, "maker_cost_bps", makerBps,
+		"taker_cost_bps", takerBps, "spread_bps", spreadBps)
+	return route, makerFee, takerFee, ok
+}
+
+// openSubmit 开仓下单：配置了entry_routing时按预期成本选择只挂单或市价，只挂单完全未成交时改为市价
+// 返回结果中的route/feeRate供订单跟踪器记录实际执行成本
+func (at *AutoTrader) openSubmit(symbol, side string, quantity float64, leverage int) func() (map[string]interface{}, error) {
+	return func() (map[string]interface{}, error) {
+		route, makerFee, takerFee, feeKnown := at.chooseEntryRoute(symbol)
+		if route == risk.RouteChase {
+			routing := at.config.EntryRouting
+			order, err := at.trader.(ChaseEntrySupport).OpenChase(symbol, side, quantity, leverage,
+				routing.ChaseReprices(), routing.ChaseInterval(), routing.MakerTimeout())
+			if err != nil {
+				return nil, err
+			}
+			// 全部以只挂单成交时记为maker，否则记为chase，费率按挂单/市价成交数量加权
+			filled, _ := order["filledQty"].(float64)
+			makerQty, _ := order["makerQty"].(float64)
+			order["route"] = risk.RouteChase
+			if filled > 0 && makerQty >= filled {
+				order["route"] = risk.RouteMaker
+			}
+			if feeKnown && filled > 0 {
+				order["feeRate"] = (makerQty*makerFee + (filled-makerQty)*takerFee) / filled
+			}
+			return order, nil
+		}
+		if route == risk.RouteMaker {
+			timeout := at.config.EntryRouting.MakerTimeout()
+			order, err := at.trader.(MakerEntrySupport).OpenPostOnly(symbol, side, quantity, leverage, timeout)
+			if err == nil {
+				order["route"] = risk.RouteMaker
+				if feeKnown {
+					order["feeRate"] = makerFee
+				}
+				return order, nil
+			}
+			if !errors.Is(err, ErrMakerNotFilled) {
+				return nil, err
+			}
+			at.log.Info("只挂单未成交，改为市价开仓", "symbol", symbol, "side", side, "err", err)
+		}
+
+		var order map[string]interface{}
+		var err error
+		if side == "long" {
+			order, err = at.trader.OpenLong(symbol, quantity, leverage)
+		} else {
+			order, err = at.trader.OpenShort(symbol, quantity, leverage)
+		}
+		if err == nil && order != nil {
+			order["route"] = risk.RouteTaker
+			if feeKnown {
+				order["feeRate"] = takerFee
+			}
+		}
+		return order, err
+	}
+}
+
+// zeroFillRetryDelay 开仓完全未成交后重试前的等待时间（盘口暂时稀薄时等待补充）
+const zeroFillRetryDelay = time.Second
+
+// placeEntry 开仓下单并确认成交；完全未成交时按entry_routing.zero_fill处理：market按开仓执行方式重新下单
+// （最多zero_fill_retries次），limit在买一/卖一挂只挂单（等待maker_timeout_sec），abort（默认）放弃，由订单跟踪器告警
+func (at *AutoTrader) placeEntry(ctx context.Context, symbol, side string, quantity, price float64, leverage int) (map[string]interface{}, store.OrderUpdate, error) {
+	action := "open_" + side
+	order, fill, err := at.orders.Place(ctx, at.execSource, symbol, action, quantity, price, leverage,
+		at.openSubmit(symbol, side, quantity, leverage))
+
+	routing := at.config.EntryRouting
+	for attempt := 1; errors.Is(err, ErrOrderNotFilled) && attempt <= routing.ZeroFillAttempts(); attempt++ {
+		submit := at.openSubmit(symbol, side, quantity, leverage)
+		if maker, ok := at.trader.(MakerEntrySupport); ok && routing.ZeroFill == risk.ZeroFillLimit {
+			submit = func() (map[string]interface{}, error) {
+				order, err := maker.OpenPostOnly(symbol, side, quantity, leverage, routing.MakerTimeout())
+				if err == nil {
+					order["route"] = risk.RouteMaker
+				}
+				return order, err
+			}
+		}
+		at.log.Warn("开仓完全未成交，重试", "symbol", symbol, "side", side, "policy", routing.ZeroFill, "attempt", attempt, "err", err)
+		at.clock.Sleep(zeroFillRetryDelay)
+		order, fill, err = at.orders.Place(ctx, at.execSource, symbol, action, quantity, price, leverage, submit)
+	}
+	return order, fill, err
+}
+
+// arrivalPriceKey 决策时价格在context中的键
+type arrivalPriceKey struct{}
+
+// withArrivalPrice 记录决策时价格（AI决策使用决策快照中的价格），用于计算成交相对决策的滑点
+func withArrivalPrice(ctx context.Context, price float64) context.Context {
+	if price <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, arrivalPriceKey{}, price)
+}
+
+// arrivalPrice 决策时价格（未记录时返回0）
+func arrivalPrice(ctx context.Context) float64 {
+	price, _ := ctx.Value(arrivalPriceKey{}).(float64)
+	return price
+}
+
+// slippageBps 成交均价相对基准价的滑点（基点，正数表示不利：买入成交价高于基准价、卖出成交价低于基准价）
+func slippageBps(action string, base, fill float64) float64 {
+	if base <= 0 {
+		return 0
+	}
+	bps := (fill - base) / base * 1e4
+	if action == "open_short" || action == "close_long" {
+		bps = -bps
+	}
+	return bps
+}
+
+// recordExecution 记录每笔成交的执行情况：决策时价格、提交价格、成交均价，以及执行方式对应的手续费率
+// 决策时价格未记录时（外部信号、手动平仓等）使用参考价；提交价格为限价单的委托价，市价单为参考价
+func (t *orderTracker) recordExecution(ctx context.Context, ref int64, symbol, action string, order map[string]interface{},
+	refPrice float64, update store.OrderUpdate) {
+	if update.FilledQty <= 0 || update.AvgPrice <= 0 || refPrice <= 0 {
+		return
+	}
+	route, _ := order["route"].(string)
+	feeRate, _ := order["feeRate"].(float64)
+	arrival := arrivalPrice(ctx)
+	if arrival <= 0 {
+		arrival = refPrice
+	}
+	submitted, _ := order["price"].(float64)
+	if submitted <= 0 {
+		submitted = refPrice
+	}
+
+	slippage := slippageBps(action, refPrice, update.AvgPrice)
+	costBps := feeRate*1e4 + slippage
+	orderLog.Info("执行成本", "trader", t.traderID, "symbol", symbol, "action", action, "route", route,
+		"arrival_price", arrival, "submitted_price", submitted, "avg_price", update.AvgPrice,
+		"arrival_slippage_bps", slippageBps(action, arrival, update.AvgPrice),
+		"fee_bps", feeRate*1e4, "slippage_bps", slippage, "cost_bps", costBps,
+		"cost", update.FilledQty*update.AvgPrice*costBps/1e4)
+
+	if err := t.journal.RecordExecution(ref, store.Execution{Route: route, FeeRate: feeRate, SlippageBps: slippage,
+		ArrivalPrice: arrival, SubmittedPrice: submitted}); err != nil {
+		orderLog.Warn("写入交易日志失败", "trader", t.traderID, "symbol", symbol, "err", err)
+	}
+}
@@ -0,0 +1,155 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"nofx/i18n"
+	"time"
+)
+
+// 启动自检项
+const (
+	CheckExchange         = "exchange"          // 交易所连通性
+	CheckAPIKey           = "api_key"           // API密钥有效性（能否查询账户余额）
+	CheckClockSkew        = "clock_skew"        // 本地时钟与交易所的偏差
+	CheckContractMetadata = "contract_metadata" // 合约元数据（数量精度等）
+	CheckTradePermission  = "trade_permission"  // API密钥的合约交易权限
+	CheckNetwork          = "network"           // API密钥所属网络与配置一致（测试网/主网，Gate.io）
+	CheckStream           = "websocket"         // WebSocket推送地址可达（仅诊断命令检查）
+)
+
+// TradePermissionProber 可在不下单的情况下确认API密钥交易权限的交易器
+type TradePermissionProber interface {
+	CheckTradePermission() error
+}
+
+// NetworkChecker 区分测试网和主网的交易器（两个网络的API密钥互不通用）
+type NetworkChecker interface {
+	// Testnet 是否连接测试网
+	Testnet() bool
+	// CheckNetwork 密钥属于另一个网络时返回ErrNetworkMismatch
+	CheckNetwork() error
+}
+
+// selfCheckHint 自检失败的处理建议（无法识别的错误返回空）
+func selfCheckHint(err error) string {
+	switch {
+	case errors.Is(err, ErrNetworkMismatch):
+		return i18n.T("修改gate_testnet使其与密钥所属的网络一致，或换用对应网络的密钥（测试网密钥在测试网页面的API管理中创建）")
+	case errors.Is(err, ErrIPNotWhitelisted):
+		return i18n.T("API密钥设置了IP白名单，本机出口IP不在其中：在交易所API管理页面添加本机公网IP")
+	case errors.Is(err, ErrKeyPermission):
+		return i18n.T("API密钥缺少所需权限：在交易所API管理页面为该密钥开启合约的读取和交易权限")
+	case errors.Is(err, ErrInvalidAPIKey):
+		return i18n.T("检查api_key和secret_key是否正确、密钥是否已删除或过期，以及是否误用了测试网/主网的密钥")
+	case errors.Is(err, ErrRequestExpired):
+		return i18n.T("本地时钟与交易所相差过大，签名被拒绝：同步系统时间（NTP）")
+	}
+	return ""
+}
+
+// isPermanentKeyError 是否为需要修改密钥设置的错误（重试策略为requires_reauth）
+func isPermanentKeyError(err error) bool {
+	return retryPolicyOf(err) == RetryReauth
+}
+
+// selfCheckSymbol 检查合约元数据使用的币种（所有交易所都支持）
+const selfCheckSymbol = "BTCUSDT"
+
+// CheckResult 单项启动自检结果
+type CheckResult struct {
+	TraderID  string `json:"trader_id"`
+	Check     string `json:"check"`
+	OK        bool   `json:"ok"`
+	Detail    string `json:"detail,omitempty"`
+	Permanent bool   `json:"permanent,omitempty"` // 失败属于配置问题（如密钥无效），重启无法恢复
+	Warning   bool   `json:"warning,omitempty"`   // 检查通过但需要注意（如测试网账户没有资金）
+}
+
+// SelfCheck 启动自检：交易所连通性、API密钥有效性和交易权限、时钟偏差、合约元数据
+func (at *AutoTrader) SelfCheck() []CheckResult {
+	return CheckTrader(at.id, at.trader)
+}
+
+// CheckTrader 对交易器执行自检（启动自检和诊断命令共用）
+// 交易所不可达时跳过依赖交易所的其余检查项；失败项附带处理建议（IP白名单、权限、密钥、时钟）
+func CheckTrader(traderID string, t Trader) []CheckResult {
+	result := func(check string, err error, detail string) CheckResult {
+		r := CheckResult{TraderID: traderID, Check: check, OK: err == nil, Detail: detail}
+		if err != nil {
+			r.Detail = err.Error()
+			if hint := selfCheckHint(err); hint != "" {
+				r.Detail += " — " + hint
+			}
+		}
+		return r
+	}
+
+	var probe ProbeResult
+	var err error
+	if prober, ok := t.(ExchangeProber); ok {
+		probe, err = prober.Probe()
+	} else {
+		start := time.Now()
+		_, err = t.GetMarketPrice(selfCheckSymbol)
+		probe.Latency = time.Since(start)
+	}
+	results := []CheckResult{result(CheckExchange, err, fmt.Sprintf(i18n.T("延迟%dms"), probe.Latency.Milliseconds()))}
+	if err != nil {
+		return results
+	}
+
+	// 交易所可达但查询余额失败，通常是密钥错误、过期、IP不在白名单或没有读取权限
+	balance, err := t.GetBalance()
+	keyCheck := result(CheckAPIKey, err, "")
+	keyCheck.Permanent = err != nil
+	results = append(results, keyCheck)
+
+	// 测试网和主网的密钥互不通用：密钥无效时检查是否属于另一个网络，测试网账户没有资金时提醒领取测试资金
+	if checker, ok := t.(NetworkChecker); ok {
+		var netErr error
+		if !keyCheck.OK {
+			netErr = checker.CheckNetwork()
+		}
+		if keyCheck.OK || netErr != nil {
+			name := i18n.T("主网")
+			if checker.Testnet() {
+				name = i18n.T("测试网")
+			}
+			network := result(CheckNetwork, netErr, name)
+			network.Permanent = netErr != nil
+			wallet, _ := balance["totalWalletBalance"].(float64)
+			unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+			if keyCheck.OK && checker.Testnet() && wallet+unrealized <= 0 {
+				network.Warning = true
+				network.Detail += " — " + i18n.T("测试网合约账户没有资金，开仓都会因保证金不足失败：在Gate.io测试网页面领取测试资金并划转到USDT合约账户")
+			}
+			results = append(results, network)
+		}
+	}
+
+	// 交易权限（只读模式不需要；密钥无效时不再重复检查）
+	if prober, ok := t.(TradePermissionProber); ok && keyCheck.OK {
+		err := prober.CheckTradePermission()
+		tradeCheck := result(CheckTradePermission, err, "")
+		tradeCheck.Permanent = isPermanentKeyError(err)
+		results = append(results, tradeCheck)
+	}
+
+	if probe.SkewKnown {
+		skew := probe.ClockSkew
+		var skewErr error
+		if skew > maxClockSkew || skew < -maxClockSkew {
+			skewErr = i18n.Errorf("本地时钟偏差%dms超过%v，请同步系统时间（NTP）", skew.Milliseconds(), maxClockSkew)
+		}
+		results = append(results, result(CheckClockSkew, skewErr, fmt.Sprintf(i18n.T("偏差%dms"), skew.Milliseconds())))
+	}
+
+	quantity, err := t.FormatQuantity(selfCheckSymbol, 0.001)
+	if errors.Is(err, ErrOrderTooSmall) {
+		// quantity_rounding.below_min=reject时拒绝低于最小下单数量的数量，说明已取得合约元数据
+		quantity, err = i18n.T("低于最小下单数量，拒绝"), nil
+	}
+	results = append(results, result(CheckContractMetadata, err, fmt.Sprintf("%s 0.001 → %s", selfCheckSymbol, quantity)))
+	return results
+}
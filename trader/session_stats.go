@@ -0,0 +1,190 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/events"
+	"nofx/fx"
+	"nofx/mcp"
+	"nofx/store"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionStats 本次进程启动以来的交易统计（只保存在内存中，重启后清零）
+type SessionStats struct {
+	TraderID  string    `json:"trader_id"`
+	StartedAt time.Time `json:"started_at"`
+	UptimeSec float64   `json:"uptime_sec"`
+
+	Cycles      int `json:"cycles"`       // 已完成的AI决策周期
+	CycleErrors int `json:"cycle_errors"` // 执行失败的AI决策周期
+
+	Decisions       map[string]int `json:"decisions"`        // 按动作统计已执行的决策（AI和外部信号，含hold/wait）
+	DecisionsFailed int            `json:"decisions_failed"` // 被风控拒绝或执行失败的决策
+
+	OrdersPlaced    int `json:"orders_placed"`    // 已提交到交易所的订单
+	OrdersFilled    int `json:"orders_filled"`    // 完全成交的订单
+	OrdersRejected  int `json:"orders_rejected"`  // 下单前被拦截或被交易所拒绝的订单
+	OrdersCancelled int `json:"orders_cancelled"` // 已撤销的订单（可能带部分成交）
+
+	ClosedTrades int     `json:"closed_trades"` // 已平仓的交易
+	GrossPnL     float64 `json:"gross_pnl"`     // 平仓毛盈亏（按成交均价）
+	Fees         float64 `json:"fees"`          // 手续费（按交易日志中已平仓交易归集的费用，未启用交易日志时为0）
+	Funding      float64 `json:"funding"`       // 资金费（同上）
+	NetPnL       float64 `json:"net_pnl"`       // 净盈亏（启用交易日志时为已实现盈亏 - 手续费 + 资金费，否则等于毛盈亏）
+
+	LLMCalls         int     `json:"llm_calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	LLMCost          float64 `json:"llm_cost"`       // 按内置模型单价估算的AI成本（美元）
+	LLMCostKnown     bool    `json:"llm_cost_known"` // 所有模型都有单价时为true
+}
+
+// sessionStats 会话统计计数器（由事件总线、决策周期和AI调用回调更新）
+type sessionStats struct {
+	mu    sync.Mutex
+	stats SessionStats
+}
+
+// newSessionStats 创建会话统计
+func newSessionStats(traderID string, startedAt time.Time) *sessionStats {
+	return &sessionStats{stats: SessionStats{TraderID: traderID, StartedAt: startedAt,
+		Decisions: make(map[string]int), LLMCostKnown: true}}
+}
+
+// cycle 记录一个AI决策周期的结果
+func (s *sessionStats) cycle(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Cycles++
+	if err != nil {
+		s.stats.CycleErrors++
+	}
+}
+
+// llm 记录一次AI调用的token消耗
+func (s *sessionStats) llm(model string, usage mcp.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.LLMCalls++
+	s.stats.PromptTokens += usage.PromptTokens
+	s.stats.CompletionTokens += usage.CompletionTokens
+	if price, ok := mcp.LookupPrice(model, nil); ok {
+		s.stats.LLMCost += price.Cost(usage.PromptTokens, usage.CompletionTokens)
+	} else {
+		s.stats.LLMCostKnown = false
+	}
+}
+
+// observe 按事件更新决策、订单和平仓统计
+func (s *sessionStats) observe(e events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case e.Decision != nil:
+		s.stats.Decisions[e.Decision.Action]++
+		if !e.Decision.Success {
+			s.stats.DecisionsFailed++
+		}
+	case e.Order != nil:
+		switch e.Order.State {
+		case store.OrderSubmitted:
+			s.stats.OrdersPlaced++
+		case store.OrderFilled:
+			s.stats.OrdersFilled++
+		case store.OrderRejected:
+			s.stats.OrdersRejected++
+		case store.OrderCancelled:
+			s.stats.OrdersCancelled++
+		}
+	case e.Position != nil && e.Position.Change == "close":
+		s.stats.ClosedTrades++
+		s.stats.GrossPnL += e.Position.GrossPnL
+	}
+}
+
+// snapshot 当前统计的副本
+func (s *sessionStats) snapshot() SessionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Decisions = make(map[string]int, len(s.stats.Decisions))
+	for action, n := range s.stats.Decisions {
+		stats.Decisions[action] = n
+	}
+	return stats
+}
+
+// subscribeSessionStats 订阅本trader的决策、订单和持仓事件，并在AI调用的token回调中累计会话统计
+func (at *AutoTrader) subscribeSessionStats() {
+	at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypeDecision, events.TypeOrder, events.TypePosition}},
+		at.session.observe)
+
+	recordUsage := at.mcpClient.OnUsage
+	at.mcpClient.OnUsage = func(model string, usage mcp.Usage) {
+		at.session.llm(model, usage)
+		if recordUsage != nil {
+			recordUsage(model, usage)
+		}
+	}
+}
+
+// GetSessionStats 本次进程启动以来的统计：决策周期、按动作的决策数、订单提交/成交/拒绝、盈亏、手续费和AI消耗
+// 启用交易日志时盈亏和费用按启动后平仓的交易计算（含交易所侧止损止盈平仓），否则按本进程平仓成交的毛盈亏计算
+func (at *AutoTrader) GetSessionStats() SessionStats {
+	stats := at.session.snapshot()
+	stats.UptimeSec = at.clock.Now().Sub(stats.StartedAt).Seconds()
+	stats.NetPnL = stats.GrossPnL
+	if at.journal == nil {
+		return stats
+	}
+
+	positions, err := at.journal.ListClosedPositions(at.id, stats.StartedAt)
+	if err != nil {
+		at.log.Warn("读取会话内平仓交易失败", "err", err)
+		return stats
+	}
+	stats.ClosedTrades, stats.GrossPnL, stats.NetPnL = len(positions), 0, 0
+	for _, p := range positions {
+		stats.GrossPnL += p.RealizedPnL
+		stats.Fees += p.Fees
+		stats.Funding += p.Funding
+		stats.NetPnL += p.NetPnL()
+	}
+	return stats
+}
+
+// String 格式化为文本（用于Telegram /pnl session）
+func (s SessionStats) String() string {
+	var sb strings.Builder
+	uptime := time.Duration(s.UptimeSec * float64(time.Second)).Round(time.Minute)
+	fmt.Fprintf(&sb, "运行: %s（自 %s）\n", uptime, s.StartedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&sb, "决策周期: %d（失败 %d）\n", s.Cycles, s.CycleErrors)
+
+	actions := make([]string, 0, len(s.Decisions))
+	for action := range s.Decisions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	counts := make([]string, 0, len(actions))
+	for _, action := range actions {
+		counts = append(counts, fmt.Sprintf("%s %d", action, s.Decisions[action]))
+	}
+	if len(counts) == 0 {
+		counts = append(counts, "无")
+	}
+	fmt.Fprintf(&sb, "决策: %s（失败 %d）\n", strings.Join(counts, " / "), s.DecisionsFailed)
+
+	fmt.Fprintf(&sb, "订单: 提交 %d | 成交 %d | 拒绝 %d | 撤销 %d\n", s.OrdersPlaced, s.OrdersFilled, s.OrdersRejected, s.OrdersCancelled)
+	fmt.Fprintf(&sb, "平仓: %d笔 | 净盈亏 %+.2f USDT%s（毛盈亏 %+.2f / 手续费 %.2f / 资金费 %+.2f）\n",
+		s.ClosedTrades, s.NetPnL, fx.Suffix(s.NetPnL, true), s.GrossPnL, s.Fees, s.Funding)
+
+	cost := fmt.Sprintf("$%.4f", s.LLMCost)
+	if !s.LLMCostKnown {
+		cost += "（部分模型无单价，未计入）"
+	}
+	fmt.Fprintf(&sb, "AI: %d次调用 | %d tokens | 成本 %s", s.LLMCalls, s.PromptTokens+s.CompletionTokens, cost)
+	return sb.String()
+}
@@ -0,0 +1,344 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"nofx/clock"
+	"nofx/logging"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/scheduler"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// soakLog 浸泡测试日志
+var soakLog = logging.For("soak")
+
+// PauseSourceSoak 浸泡测试不变量被破坏触发的暂停（halt_on_violation）
+const PauseSourceSoak = "soak"
+
+// 浸泡测试检查的不变量
+const (
+	invariantNakedPosition = "naked_position" // 持仓没有止损单
+	invariantDoubleEntry   = "double_entry"   // 同一决策周期内同一持仓开仓多次
+)
+
+// unprotectedStrategies 不要求止损单的策略（资金费率套利、期现基差和对冲的持仓由策略整体管理风险）
+var unprotectedStrategies = map[string]bool{"funding_harvest": true, "basis": true, "hedge": true}
+
+// soakWindow 一类故障的注入窗口
+type soakWindow struct {
+	armed bool      // 窗口已开始且尚未结束（单次注入时尚未被消耗）
+	until time.Time // 窗口结束时间（单次注入时为零值）
+}
+
+// soakInjector 浸泡测试：按计划向模拟交易器和AI输出注入故障，决策周期结束后检查不变量（方法在nil上调用时不做任何事）
+type soakInjector struct {
+	cfg   risk.Soak
+	paper *PaperTrader
+	clock clock.Clock
+
+	mu         sync.Mutex
+	rng        *rand.Rand
+	windows    []soakWindow    // 与cfg.Faults一一对应
+	injected   map[string]int  // 故障类型 → 注入次数
+	violations map[string]int  // 不变量 → 被破坏次数
+	entries    map[string]int  // 当前决策周期内的开仓次数 (symbol_side)
+	inCycle    bool            // 是否在决策周期中（只统计周期内的开仓）
+	naked      map[string]bool // 已告警的无止损持仓（恢复后清除，避免每个周期重复告警；只在决策任务中访问）
+	started    time.Time
+}
+
+// newSoakInjector 创建浸泡测试（种子为0时按当前时间）
+func newSoakInjector(cfg risk.Soak, paper *PaperTrader, c clock.Clock) *soakInjector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	soakLog.Info("浸泡测试已启用", "faults", len(cfg.Faults), "seed", seed)
+	s := &soakInjector{
+		cfg:        cfg,
+		paper:      paper,
+		clock:      clock.Or(c),
+		rng:        rand.New(rand.NewSource(seed)),
+		windows:    make([]soakWindow, len(cfg.Faults)),
+		injected:   make(map[string]int),
+		violations: make(map[string]int),
+		entries:    make(map[string]int),
+		naked:      make(map[string]bool),
+	}
+	s.started = s.clock.Now()
+	paper.faults = s
+	return s
+}
+
+// schedule 注册各故障的注入窗口和每小时的统计日志
+func (s *soakInjector) schedule(sched *scheduler.Scheduler, name string) error {
+	if s == nil {
+		return nil
+	}
+	for i, fault := range s.cfg.Faults {
+		i := i
+		if err := sched.AddJob(fmt.Sprintf("%s 故障注入 %s", name, fault.Kind), fault.Schedule, nil, func() { s.arm(i) }); err != nil {
+			return err
+		}
+	}
+	return sched.AddJob(name+" 浸泡测试统计", "@every 1h", nil, s.report)
+}
+
+// arm 开始一个注入窗口
+func (s *soakInjector) arm(i int) {
+	fault := s.cfg.Faults[i]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	window := soakWindow{armed: true}
+	if fault.DurationSeconds > 0 {
+		window.until = s.clock.Now().Add(time.Duration(fault.DurationSeconds) * time.Second)
+	}
+	s.windows[i] = window
+	soakLog.Info("故障注入窗口开始", "kind", fault.Kind, "duration_seconds", fault.DurationSeconds, "probability", fault.Probability)
+}
+
+// fire 本次调用是否注入该类故障（单次注入的窗口在第一次调用时消耗）
+func (s *soakInjector) fire(kind string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for i, fault := range s.cfg.Faults {
+		window := &s.windows[i]
+		if fault.Kind != kind || !window.armed {
+			continue
+		}
+		if window.until.IsZero() {
+			window.armed = false
+		} else if !now.Before(window.until) {
+			window.armed = false
+			continue
+		}
+		if fault.Probability == 0 || s.rng.Float64() < fault.Probability {
+			s.injected[kind]++
+			return true
+		}
+	}
+	return false
+}
+
+// apiFault 查询类调用注入的超时错误（不注入时返回nil）
+func (s *soakInjector) apiFault(op string) error {
+	if !s.fire(risk.FaultAPITimeout) {
+		return nil
+	}
+	soakLog.Info("注入API超时", "op", op)
+	return s.timeout(op)
+}
+
+// orderFault 下单类调用注入的超时：executed为false时调用方直接返回err（请求未到达交易所），
+// 为true时照常执行后返回err（已执行但响应超时，结果不确定，需按客户端订单ID确认）
+func (s *soakInjector) orderFault(op string) (executed bool, err error) {
+	if !s.fire(risk.FaultAPITimeout) {
+		return true, nil
+	}
+	s.mu.Lock()
+	executed = s.rng.Intn(2) == 0
+	s.mu.Unlock()
+	soakLog.Info("注入下单超时", "op", op, "executed", executed)
+	return executed, s.timeout(op)
+}
+
+// timeout 与实盘网络超时相同分类的错误（结果不确定，可确认后重试）
+func (s *soakInjector) timeout(op string) error {
+	return &ExchangeError{Kind: ErrExchangeUnavailable, Label: "SOAK_TIMEOUT", Message: "浸泡测试注入的超时",
+		Policy: RetryNow, Err: fmt.Errorf("%s: %w", op, context.DeadlineExceeded)}
+}
+
+// partialFill 开仓单注入部分成交时的成交比例（0表示全部成交）
+func (s *soakInjector) partialFill() float64 {
+	if !s.fire(risk.FaultPartialFill) {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return 0.2 + s.rng.Float64()*0.6
+}
+
+// streamDropped WebSocket推送是否处于注入的断线窗口中
+func (s *soakInjector) streamDropped() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for i, fault := range s.cfg.Faults {
+		if fault.Kind == risk.FaultWSDrop && s.windows[i].armed && now.Before(s.windows[i].until) {
+			return true
+		}
+	}
+	return false
+}
+
+// mangle 注入畸形的AI输出（截断的JSON、纯文本、空响应、字段类型错误、重复的决策）
+func (s *soakInjector) mangle(content string) string {
+	if !s.fire(risk.FaultMalformedLLM) {
+		return content
+	}
+	s.mu.Lock()
+	variant := s.rng.Intn(5)
+	s.mu.Unlock()
+
+	mangled := content[:len(content)/2]
+	switch variant {
+	case 1:
+		mangled = "抱歉，当前行情不明朗，暂不给出交易决策。"
+	case 2:
+		mangled = ""
+	case 3:
+		if strings.Contains(content, `"leverage":`) {
+			mangled = strings.ReplaceAll(content, `"leverage":`, `"leverage": "高", "_":`)
+		}
+	case 4:
+		start, end := strings.Index(content, "["), strings.LastIndex(content, "]")
+		if start >= 0 && end > start && strings.TrimSpace(content[start+1:end]) != "" {
+			mangled = content[:end] + "," + content[start+1:end] + content[end:]
+		}
+	}
+	soakLog.Info("注入畸形AI输出", "variant", variant, "length", len(mangled))
+	return mangled
+}
+
+// recordEntry 记录一次成功的开仓（只统计决策周期内的开仓）
+func (s *soakInjector) recordEntry(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inCycle {
+		s.entries[key]++
+	}
+}
+
+// beginCycle 决策周期开始，清空本周期的开仓统计
+func (s *soakInjector) beginCycle() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]int)
+	s.inCycle = true
+}
+
+// checkSoakInvariants 决策周期结束后检查不变量：持仓都有止损单、同一周期内同一持仓只开仓一次（直接读取模拟账户，不注入故障）
+func (at *AutoTrader) checkSoakInvariants() {
+	s := at.soak
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.inCycle = false
+	entries := s.entries
+	s.mu.Unlock()
+
+	at.cycleMu.Lock()
+	positions, triggers := s.paper.snapshot()
+	at.cycleMu.Unlock()
+
+	protected := make(map[string]bool)
+	for _, trigger := range triggers {
+		if trigger.Kind == "stop_loss" {
+			protected[trigger.Symbol+"_"+trigger.PositionSide] = true
+		}
+	}
+	var violations []string
+	count := make(map[string]int)
+	open := make(map[string]bool)
+	for _, pos := range positions {
+		key := pos.Symbol + "_" + pos.Side
+		open[key] = true
+		if protected[key] || s.naked[key] {
+			continue
+		}
+		if record, err := at.journal.GetOpenPosition(at.id, pos.Symbol, pos.Side); err == nil && record != nil && unprotectedStrategies[record.Strategy] {
+			continue
+		}
+		s.naked[key] = true
+		count[invariantNakedPosition]++
+		violations = append(violations, fmt.Sprintf("%s: %s %s 持仓%v没有止损单", invariantNakedPosition, pos.Symbol, pos.Side, pos.Quantity))
+	}
+	for key := range s.naked {
+		if protected[key] || !open[key] {
+			delete(s.naked, key)
+		}
+	}
+	for key, n := range entries {
+		if n > 1 {
+			count[invariantDoubleEntry]++
+			violations = append(violations, fmt.Sprintf("%s: %s 同一决策周期内开仓%d次", invariantDoubleEntry, key, n))
+		}
+	}
+	if len(violations) == 0 {
+		return
+	}
+	sort.Strings(violations)
+
+	s.mu.Lock()
+	for name, n := range count {
+		s.violations[name] += n
+	}
+	s.mu.Unlock()
+	message := strings.Join(violations, "\n")
+	at.log.Error("浸泡测试不变量被破坏", "violations", violations)
+	at.notify(notify.KindError, "", "浸泡测试: 不变量被破坏", message)
+	if s.cfg.HaltOnViolation {
+		if _, err := at.Pause(PauseSourceSoak, "浸泡测试不变量被破坏", false); err != nil {
+			at.log.Warn("浸泡测试暂停交易失败", "err", err)
+		}
+	}
+}
+
+// Status 注入次数和不变量被破坏次数（未启用时返回nil）
+func (s *soakInjector) Status() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	injected := make(map[string]int, len(s.injected))
+	for kind, n := range s.injected {
+		injected[kind] = n
+	}
+	violations := make(map[string]int, len(s.violations))
+	for name, n := range s.violations {
+		violations[name] = n
+	}
+	return map[string]interface{}{
+		"started":    s.started.Format(time.RFC3339),
+		"injected":   injected,
+		"violations": violations,
+	}
+}
+
+// report 定时输出浸泡测试统计
+func (s *soakInjector) report() {
+	status := s.Status()
+	soakLog.Info("浸泡测试统计", "since", status["started"], "injected", status["injected"], "violations", status["violations"])
+}
+
+// snapshot 模拟持仓和条件单的副本（不按价格检查触发，不注入故障）
+func (t *PaperTrader) snapshot() ([]paperPosition, []TriggerOrder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	positions := make([]paperPosition, 0, len(t.state.Positions))
+	for _, pos := range t.state.Positions {
+		positions = append(positions, *pos)
+	}
+	return positions, append([]TriggerOrder(nil), t.state.Triggers...)
+}
@@ -0,0 +1,212 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nofx/notify"
+	"nofx/risk"
+	"nofx/strategy"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// softCloseStrategies 软平仓规则执行平仓时在交易日志中使用的策略标记
+var softCloseStrategies = map[string]string{
+	"time_exit": "time_exit",
+	"dca_stop":  "dca",
+}
+
+// recommendationSeq 平仓建议ID（所有trader共用，Telegram命令按ID查找时不需要指定trader）
+var recommendationSeq atomic.Int64
+
+// CloseRecommendation 待人工确认的平仓建议
+type CloseRecommendation struct {
+	ID        int64     `json:"id"`
+	TraderID  string    `json:"trader_id"`
+	Symbol    string    `json:"symbol"`
+	Side      string    `json:"side"`
+	Rule      string    `json:"rule"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	dismissed bool      // 已忽略：持仓平掉前同一规则不再生成建议
+}
+
+// softCloseQueue 平仓建议队列（symbol_side → 建议，同一持仓只保留一条；只在内存中，重启后规则再次触发时重新生成）
+type softCloseQueue struct {
+	mu    sync.Mutex
+	items map[string]*CloseRecommendation
+}
+
+// softCloseExecutor 策略执行器包装：规则需要人工确认时，平仓请求进入建议队列并返回strategy.ErrCloseDeferred
+type softCloseExecutor struct {
+	Trader
+	at   *AutoTrader
+	rule string
+}
+
+// CloseLong 平多仓（需要确认时只生成建议）
+func (e softCloseExecutor) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	if e.at.deferClose(symbol, "long", e.rule, risk.SoftCloseRules[e.rule]) {
+		return nil, strategy.ErrCloseDeferred
+	}
+	return e.Trader.CloseLong(symbol, quantity)
+}
+
+// CloseShort 平空仓（需要确认时只生成建议）
+func (e softCloseExecutor) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	if e.at.deferClose(symbol, "short", e.rule, risk.SoftCloseRules[e.rule]) {
+		return nil, strategy.ErrCloseDeferred
+	}
+	return e.Trader.CloseShort(symbol, quantity)
+}
+
+// ReplaceStopLoss 加仓后替换止损（撤销原止损条件单，止盈按新数量调整）
+func (e softCloseExecutor) ReplaceStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	return e.at.replaceStopLoss(symbol, positionSide, quantity, stopPrice)
+}
+
+// deferClose 规则触发平仓时检查是否需要人工确认：需要时生成平仓建议（已有建议时不重复生成）并返回true
+// 建议超过timeout_minutes仍未处理时返回false，由规则照常平仓；已忽略的建议在持仓平掉前一直返回true
+func (at *AutoTrader) deferClose(symbol, side, rule, reason string) bool {
+	cfg := at.config.SoftClose
+	if !cfg.Applies(rule) {
+		return false
+	}
+	key := symbol + "_" + side
+
+	at.softClose.mu.Lock()
+	rec, exists := at.softClose.items[key]
+	if exists {
+		expired := !rec.dismissed && cfg.TimeoutMinutes > 0 &&
+			at.clock.Now().Sub(rec.CreatedAt) >= time.Duration(cfg.TimeoutMinutes)*time.Minute
+		if expired {
+			delete(at.softClose.items, key)
+		}
+		at.softClose.mu.Unlock()
+		if expired {
+			at.log.Warn("平仓建议超时未处理，自动平仓", "id", rec.ID, "symbol", symbol, "side", side, "rule", rule)
+		}
+		return !expired
+	}
+	rec = &CloseRecommendation{
+		ID:        recommendationSeq.Add(1),
+		TraderID:  at.id,
+		Symbol:    symbol,
+		Side:      side,
+		Rule:      rule,
+		Reason:    reason,
+		CreatedAt: at.clock.Now(),
+	}
+	at.softClose.items[key] = rec
+	at.softClose.mu.Unlock()
+
+	detail := fmt.Sprintf("#%d %s %s: %s", rec.ID, symbol, side, reason)
+	at.log.Warn("平仓需要人工确认", "id", rec.ID, "symbol", symbol, "side", side, "rule", rule, "reason", reason)
+	at.publishRisk("soft_close", symbol, "close_"+side, detail)
+
+	message := fmt.Sprintf("%s\n确认平仓: /confirm %d\n忽略: /dismiss %d", detail, rec.ID, rec.ID)
+	if cfg.TimeoutMinutes > 0 {
+		message += fmt.Sprintf("\n%d分钟内未处理将自动平仓", cfg.TimeoutMinutes)
+	}
+	publishNotice(at.events, notify.Event{
+		Kind:     notify.KindRisk,
+		TraderID: at.id,
+		Symbol:   symbol,
+		Title:    "平仓建议待确认",
+		Message:  message,
+		Reason:   riskReason("soft_close"),
+		Actions: []notify.Action{
+			{Label: "✅ 确认平仓", Command: fmt.Sprintf("/confirm %d", rec.ID)},
+			{Label: "✖ 忽略", Command: fmt.Sprintf("/dismiss %d", rec.ID)},
+		},
+	})
+	return true
+}
+
+// pruneCloseRecommendations 删除持仓已平掉的建议（策略看守周期调用）
+func (at *AutoTrader) pruneCloseRecommendations(positions []map[string]interface{}) {
+	open := make(map[string]bool)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		open[symbol+"_"+side] = true
+	}
+	at.softClose.mu.Lock()
+	defer at.softClose.mu.Unlock()
+	for key := range at.softClose.items {
+		if !open[key] {
+			delete(at.softClose.items, key)
+		}
+	}
+}
+
+// CloseRecommendations 待确认的平仓建议（按ID排序，不含已忽略的）
+func (at *AutoTrader) CloseRecommendations() []CloseRecommendation {
+	at.softClose.mu.Lock()
+	defer at.softClose.mu.Unlock()
+	list := make([]CloseRecommendation, 0, len(at.softClose.items))
+	for _, rec := range at.softClose.items {
+		if !rec.dismissed {
+			list = append(list, *rec)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// takeRecommendation 按ID取出待确认的建议（remove为true时从队列删除，否则标记为已忽略）
+func (at *AutoTrader) takeRecommendation(id int64, remove bool) (*CloseRecommendation, bool) {
+	at.softClose.mu.Lock()
+	defer at.softClose.mu.Unlock()
+	for key, rec := range at.softClose.items {
+		if rec.ID != id || rec.dismissed {
+			continue
+		}
+		if remove {
+			delete(at.softClose.items, key)
+		} else {
+			rec.dismissed = true
+		}
+		return rec, true
+	}
+	return nil, false
+}
+
+// ConfirmClose 确认平仓建议并市价平仓（found为false表示该trader没有这条建议）
+func (at *AutoTrader) ConfirmClose(id int64) (rec *CloseRecommendation, found bool, err error) {
+	rec, found = at.takeRecommendation(id, true)
+	if !found {
+		return nil, false, nil
+	}
+
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+	price, _ := at.trader.GetMarketPrice(rec.Symbol)
+	_, _, err = at.orders.Place(context.Background(), softCloseStrategies[rec.Rule], rec.Symbol, "close_"+rec.Side, 0, price, 0,
+		func() (map[string]interface{}, error) {
+			if rec.Side == "long" {
+				return at.trader.CloseLong(rec.Symbol, 0)
+			}
+			return at.trader.CloseShort(rec.Symbol, 0)
+		})
+	if errors.Is(err, ErrPositionNotFound) {
+		return rec, true, fmt.Errorf("%s %s 持仓已不存在", rec.Symbol, rec.Side)
+	}
+	if err != nil {
+		return rec, true, fmt.Errorf("平仓 %s %s 失败: %w", rec.Symbol, rec.Side, err)
+	}
+	at.log.Info("已确认平仓建议", "id", id, "symbol", rec.Symbol, "side", rec.Side, "rule", rec.Rule)
+	return rec, true, nil
+}
+
+// DismissClose 忽略平仓建议（持仓平掉前同一持仓不再生成建议，found为false表示该trader没有这条建议）
+func (at *AutoTrader) DismissClose(id int64) (*CloseRecommendation, bool) {
+	rec, found := at.takeRecommendation(id, false)
+	if found {
+		at.log.Info("已忽略平仓建议", "id", id, "symbol", rec.Symbol, "side", rec.Side, "rule", rec.Rule)
+	}
+	return rec, found
+}
@@ -0,0 +1,47 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// snapshotKey 决策所用快照在context中的键
+type snapshotKey struct{}
+
+// withSnapshot 记录AI决策所用的行情和账户快照（用于决策过期保护）
+func withSnapshot(ctx context.Context, snapshot *market.MarketSnapshot) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, snapshot)
+}
+
+// decisionSnapshot 决策所用的快照（外部信号等没有快照的决策返回nil）
+func decisionSnapshot(ctx context.Context) *market.MarketSnapshot {
+	snapshot, _ := ctx.Value(snapshotKey{}).(*market.MarketSnapshot)
+	return snapshot
+}
+
+// checkStaleness 决策过期保护：行情快照距现在超过max_age_seconds、或价格相对快照价格变动超过max_move_pct时拒绝执行
+// （只检查带行情快照的AI决策；平仓只在include_closes时检查）
+func (at *AutoTrader) checkStaleness(ctx context.Context, d *decision.Decision) error {
+	config := at.config.Staleness
+	snapshot := decisionSnapshot(ctx)
+	if !config.Enabled() || snapshot == nil || (!isOpenAction(d.Action) && !config.IncludeCloses) {
+		return nil
+	}
+
+	var price float64
+	snapshotPrice := snapshot.Price(d.Symbol)
+	if config.MaxMovePct > 0 && snapshotPrice > 0 {
+		current, err := at.trader.GetMarketPrice(d.Symbol)
+		if err != nil {
+			at.log.Warn("决策过期检查获取价格失败，只检查快照时间", "symbol", d.Symbol, "err", err)
+		} else {
+			price = current
+		}
+	}
+	if reason := config.Check(at.clock.Now().Sub(snapshot.TakenAt), snapshotPrice, price); reason != "" {
+		return fmt.Errorf("%w: %s", ErrDecisionStale, reason)
+	}
+	return nil
+}
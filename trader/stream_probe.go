@@ -0,0 +1,37 @@
+package trader
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// StreamProber 可探测WebSocket推送地址是否可达的交易器（诊断用，不订阅频道）
+type StreamProber interface {
+	ProbeStream() (time.Duration, error)
+}
+
+// streamProbeTimeout 探测WebSocket握手的超时时间
+const streamProbeTimeout = 10 * time.Second
+
+// probeStream 建立一次WebSocket连接后立即关闭，返回握手耗时
+func probeStream(url string) (time.Duration, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: streamProbeTimeout, Proxy: websocket.DefaultDialer.Proxy}
+	start := time.Now()
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		return 0, err
+	}
+	conn.Close()
+	return time.Since(start), nil
+}
+
+// ProbeStream 探测行情/持仓推送地址
+func (t *GateTrader) ProbeStream() (time.Duration, error) {
+	return probeStream(t.stream.url)
+}
+
+// ProbeStream 探测行情推送地址
+func (t *HyperliquidTrader) ProbeStream() (time.Duration, error) {
+	return probeStream(t.klineStream.url)
+}
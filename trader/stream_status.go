@@ -0,0 +1,32 @@
+package trader
+
+import (
+	"sync"
+	"time"
+)
+
+// streamStatus WebSocket推送的连接状态（用于健康检查）
+type streamStatus struct {
+	mu          sync.Mutex
+	connected   bool
+	lastMessage time.Time
+}
+
+// status 连接状态和最近一次收到消息的时间
+func (s *streamStatus) status() (bool, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected, s.lastMessage
+}
+
+func (s *streamStatus) setConnected(connected bool) {
+	s.mu.Lock()
+	s.connected = connected
+	s.mu.Unlock()
+}
+
+func (s *streamStatus) touch() {
+	s.mu.Lock()
+	s.lastMessage = time.Now()
+	s.mu.Unlock()
+}
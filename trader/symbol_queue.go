@@ -0,0 +1,60 @@
+package trader
+
+import "sync"
+
+// symbolQueue 按币种的执行队列：同一币种的操作按到达顺序逐个执行（先到先执行，不会被后到的插队），
+// 不同币种互不阻塞（零值可用）
+type symbolQueue struct {
+	mu    sync.Mutex
+	lanes map[string]*symbolLane
+}
+
+// symbolLane 一个币种的队列：正在执行的操作结束后唤醒队首的等待者
+type symbolLane struct {
+	waiting []chan struct{}
+}
+
+// enter 排队直到轮到该操作，返回结束函数（用法：defer q.enter(symbol)()）
+func (q *symbolQueue) enter(symbol string) func() {
+	q.mu.Lock()
+	if q.lanes == nil {
+		q.lanes = make(map[string]*symbolLane)
+	}
+	lane, busy := q.lanes[symbol]
+	if !busy {
+		q.lanes[symbol] = &symbolLane{}
+		q.mu.Unlock()
+		return func() { q.leave(symbol) }
+	}
+	turn := make(chan struct{})
+	lane.waiting = append(lane.waiting, turn)
+	q.mu.Unlock()
+
+	<-turn
+	return func() { q.leave(symbol) }
+}
+
+// leave 结束当前操作：有等待者时交给队首，否则删除空闲的队列
+func (q *symbolQueue) leave(symbol string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[symbol]
+	if len(lane.waiting) == 0 {
+		delete(q.lanes, symbol)
+		return
+	}
+	next := lane.waiting[0]
+	lane.waiting = lane.waiting[1:]
+	close(next)
+}
+
+// depth 币种正在执行和排队等待的操作数
+func (q *symbolQueue) depth(symbol string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane, ok := q.lanes[symbol]
+	if !ok {
+		return 0
+	}
+	return 1 + len(lane.waiting)
+}
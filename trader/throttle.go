@@ -0,0 +1,51 @@
+package trader
+
+import (
+	"sync"
+	"time"
+)
+
+// signalThrottle 最近执行的信号（进程内存，重启后清空）
+type signalThrottle struct {
+	mu         sync.Mutex
+	lastAction map[string]time.Time // 来源_币种_动作 → 最近一次成功执行的时间
+	lastEntry  map[string]time.Time // 币种 → 最近一次成功开仓的时间
+}
+
+func newSignalThrottle() *signalThrottle {
+	return &signalThrottle{lastAction: make(map[string]time.Time), lastEntry: make(map[string]time.Time)}
+}
+
+// last 同一来源同一动作和该币种开仓的最近执行时间
+func (s *signalThrottle) last(source, symbol, action string) (time.Time, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastAction[source+"_"+symbol+"_"+action], s.lastEntry[symbol]
+}
+
+// record 记录一次成功执行的信号
+func (s *signalThrottle) record(source, symbol, action string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAction[source+"_"+symbol+"_"+action] = at
+	if action == "open_long" || action == "open_short" {
+		s.lastEntry[symbol] = at
+	}
+}
+
+// checkSignalThrottle 信号去重和开仓节流检查（hold/wait不受限制）
+func (at *AutoTrader) checkSignalThrottle(symbol, action string) error {
+	if !at.config.SignalThrottle.Enabled() || action == "hold" || action == "wait" {
+		return nil
+	}
+	lastSame, lastEntry := at.throttle.last(at.execSource, symbol, action)
+	return at.config.SignalThrottle.Check(action, at.clock.Now(), lastSame, lastEntry)
+}
+
+// recordSignal 信号执行成功后记录（去重窗口和开仓间隔从此时开始计算）
+func (at *AutoTrader) recordSignal(symbol, action string) {
+	if action == "hold" || action == "wait" {
+		return
+	}
+	at.throttle.record(at.execSource, symbol, action, at.clock.Now())
+}
@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// positionOpenedAt 持仓开仓时间：优先使用交易日志中持久化的开仓时间（重启后仍然准确），
+// 未启用交易日志时使用本次运行首次发现该持仓的时间
+func (at *AutoTrader) positionOpenedAt(symbol, side string) time.Time {
+	posKey := symbol + "_" + side
+	if record, err := at.journal.GetOpenPosition(at.id, symbol, side); err != nil {
+		at.log.Warn("读取持仓开仓时间失败", "symbol", symbol, "side", side, "err", err)
+	} else if record != nil {
+		return record.OpenedAt
+	}
+	if _, exists := at.positionFirstSeenTime[posKey]; !exists {
+		at.positionFirstSeenTime[posKey] = at.clock.Now().UnixMilli()
+	}
+	return time.UnixMilli(at.positionFirstSeenTime[posKey])
+}
+
+// enforceTimeExits 按最长持仓时间、周末前平仓规则市价平仓（策略看守周期调用）
+func (at *AutoTrader) enforceTimeExits(ctx context.Context) []string {
+	rules := at.config.Schedule.ExitRules
+	if !rules.Enabled() {
+		return nil
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return []string{fmt.Sprintf("⚠ 时间平仓检查获取持仓失败: %v", err)}
+	}
+
+	var logs []string
+	now := at.clock.Now()
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		if symbol == "" || math.Abs(floatValue(pos["positionAmt"])) == 0 {
+			continue
+		}
+		due, reason := rules.ExitDue(at.positionOpenedAt(symbol, side), now)
+		if !due || at.deferClose(symbol, side, "time_exit", reason) {
+			continue
+		}
+
+		_, _, err := at.orders.Place(ctx, "time_exit", symbol, "close_"+side, 0, floatValue(pos["markPrice"]), 0,
+			func() (map[string]interface{}, error) {
+				if side == "long" {
+					return at.trader.CloseLong(symbol, 0)
+				}
+				return at.trader.CloseShort(symbol, 0)
+			})
+		if errors.Is(err, ErrPositionNotFound) {
+			continue // 持仓在获取后已被止损/止盈平掉
+		}
+		if err != nil {
+			logs = append(logs, fmt.Sprintf("❌ %s %s %s，平仓失败: %v", symbol, side, reason, err))
+			continue
+		}
+		logs = append(logs, fmt.Sprintf("⏰ %s %s %s，已市价平仓", symbol, side, reason))
+	}
+	return logs
+}
@@ -0,0 +1,95 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/events"
+	"nofx/store"
+	"strings"
+	"time"
+)
+
+// recordTimeline 写入一条时间线（未启用交易日志时忽略；data为原始事件，可为nil）
+func (at *AutoTrader) recordTimeline(kind, symbol, action, summary string, t time.Time, data interface{}) {
+	if at.journal == nil {
+		return
+	}
+	entry := store.TimelineEntry{TraderID: at.id, Symbol: symbol, Kind: kind, Action: action, Summary: summary, Time: t}
+	if data != nil {
+		if raw, err := json.Marshal(data); err == nil {
+			entry.Data = raw
+		}
+	}
+	if err := at.journal.RecordTimeline(entry); err != nil {
+		journalLog.Warn("写入时间线失败", "trader", at.id, "kind", kind, "err", err)
+	}
+}
+
+// subscribeTimeline 订阅本trader的决策、订单、持仓和风控事件写入时间线
+// （配置热加载和决策周期失败不经过事件总线，在ApplyRuntimeConfig和决策任务中直接写入）
+func (at *AutoTrader) subscribeTimeline() {
+	if at.journal == nil {
+		return
+	}
+	at.events.Handle(events.Filter{TraderID: at.id, Types: []events.Type{events.TypeDecision, events.TypeOrder, events.TypePosition, events.TypeRisk}},
+		func(e events.Event) {
+			kind, action, summary := timelineSummary(e)
+			at.recordTimeline(kind, e.Symbol, action, summary, e.Time, e)
+		})
+}
+
+// timelineSummary 事件对应的时间线类型、动作和一行说明
+func timelineSummary(e events.Event) (kind, action, summary string) {
+	switch {
+	case e.Decision != nil:
+		d := e.Decision
+		summary = fmt.Sprintf("决策(%s) %s %s", d.Source, d.Action, e.Symbol)
+		if d.SizeUSD > 0 {
+			summary += fmt.Sprintf(" %.2f USDT", d.SizeUSD)
+		}
+		if d.Leverage > 0 {
+			summary += fmt.Sprintf(" %dx", d.Leverage)
+		}
+		if d.Success {
+			summary += " 成功"
+		} else {
+			summary += " 失败: " + d.Error
+		}
+		return store.TimelineDecision, d.Action, summary
+	case e.Order != nil:
+		o := e.Order
+		summary = fmt.Sprintf("订单 %s %s %s %.4f", o.Action, e.Symbol, o.State, o.Quantity)
+		if o.FilledQty > 0 {
+			summary += fmt.Sprintf("（成交 %.4f @ %.4f）", o.FilledQty, o.AvgPrice)
+		}
+		if o.Reason != "" {
+			summary += " [" + o.Reason + "]"
+		}
+		if o.Error != "" {
+			summary += " " + o.Error
+		}
+		return store.TimelineOrder, o.Action, summary
+	case e.Position != nil:
+		p := e.Position
+		change := map[string]string{"open": "开仓", "close": "平仓"}[p.Change]
+		summary = fmt.Sprintf("%s %s %s %.4f @ %.4f", change, e.Symbol, p.Side, p.Quantity, p.Price)
+		if p.Change == "close" && p.EntryPrice > 0 {
+			summary += fmt.Sprintf(" 毛盈亏 %+.2f", p.GrossPnL)
+		}
+		if p.Reason != "" {
+			summary += "（" + p.Reason + "）"
+		}
+		return store.TimelineFill, p.Change + "_" + strings.ToLower(p.Side), summary
+	case e.Risk != nil:
+		r := e.Risk
+		summary = fmt.Sprintf("风控[%s]", r.Rule)
+		if r.Action != "" {
+			summary += " " + r.Action
+		}
+		if e.Symbol != "" {
+			summary += " " + e.Symbol
+		}
+		return store.TimelineRisk, r.Rule, summary + " " + r.Detail
+	}
+	return string(e.Type), "", ""
+}
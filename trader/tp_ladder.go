@@ -0,0 +1,163 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TPLevel 分批止盈档位
+type TPLevel struct {
+	Price    float64 `json:"price"`
+	Fraction float64 `json:"fraction"` // 该档平仓数量占持仓的比例（0-1）
+}
+
+// TakeProfitLadderSupport 支持分批止盈的交易器（每档一个只减仓的条件单）
+type TakeProfitLadderSupport interface {
+	// SetTakeProfitLadder 按档位设置多个止盈单，quantity为当前持仓数量
+	SetTakeProfitLadder(symbol, positionSide string, quantity float64, levels []TPLevel) error
+}
+
+// placeLadderOrders 逐档设置部分数量的止盈单（适用于止盈单按数量只减仓的交易器）
+func placeLadderOrders(t Trader, symbol, positionSide string, quantity float64, levels []TPLevel) error {
+	return placeLadder(quantity, levels, func(quantity, price float64) error {
+		return t.SetTakeProfit(symbol, positionSide, quantity, price)
+	})
+}
+
+// placeLadder 逐档调用set设置部分数量的止盈单
+func placeLadder(quantity float64, levels []TPLevel, set func(quantity, price float64) error) error {
+	var errs []error
+	for i, level := range levels {
+		if err := set(quantity*level.Fraction, level.Price); err != nil {
+			errs = append(errs, fmt.Errorf("第%d档(%.4f): %w", i+1, level.Price, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// tpLadder 持仓的分批止盈进度（随持仓数量减少推进）
+type tpLadder struct {
+	levels     []TPLevel
+	initialQty float64 // 设置止盈时的持仓数量
+	filled     int     // 已成交的档位数
+}
+
+// takeProfitLevels 按配置计算分批止盈档位，剩余比例挂在AI给出的止盈价（未配置或止损无效时返回nil）
+func (at *AutoTrader) takeProfitLevels(side string, entryPrice, stopLoss, takeProfit float64) []TPLevel {
+	prices := at.config.TakeProfitLadder.Prices(side, entryPrice, stopLoss)
+	if prices == nil {
+		return nil
+	}
+	levels := make([]TPLevel, 0, len(prices)+1)
+	remaining := 1.0
+	for i, price := range prices {
+		fraction := at.config.TakeProfitLadder[i].Fraction
+		levels = append(levels, TPLevel{Price: price, Fraction: fraction})
+		remaining -= fraction
+	}
+	if remaining > 1e-9 && takeProfit > 0 {
+		levels = append(levels, TPLevel{Price: takeProfit, Fraction: remaining})
+	}
+	return levels
+}
+
+// setTakeProfit 设置开仓后的止盈：配置了分批止盈时按档位挂多个止盈单，否则挂AI给出的单一止盈
+// trigger为条件单参数（零值表示默认有效期和触发价格类型）
+func (at *AutoTrader) setTakeProfit(symbol, side string, quantity, entryPrice, stopLoss, takeProfit float64, trigger TriggerOptions) error {
+	positionSide := strings.ToUpper(side)
+	levels := at.takeProfitLevels(side, entryPrice, stopLoss, takeProfit)
+	ladder, ok := at.trader.(TakeProfitLadderSupport)
+	if levels != nil && !ok {
+		at.log.Warn("交易器不支持分批止盈，使用单一止盈", "symbol", symbol, "exchange", at.exchange)
+	}
+	if levels == nil || !ok {
+		price, err := at.orders.checkTriggerPrice(symbol, positionSide, "take_profit", takeProfit)
+		if err == nil {
+			err = at.placeTakeProfit(symbol, positionSide, quantity, price, trigger)
+		} else {
+			price = takeProfit
+		}
+		recordProtectiveOrder(at.journal, at.id, symbol, positionSide, "take_profit", quantity, price, err)
+		return err
+	}
+
+	// 逐档检查价格，未通过检查的档位不挂单
+	var errs []error
+	checked := make([]TPLevel, 0, len(levels))
+	for _, level := range levels {
+		price, err := at.orders.checkTriggerPrice(symbol, positionSide, "take_profit", level.Price)
+		if err != nil {
+			recordProtectiveOrder(at.journal, at.id, symbol, positionSide, "take_profit", quantity*level.Fraction, level.Price, err)
+			errs = append(errs, err)
+			continue
+		}
+		checked = append(checked, TPLevel{Price: price, Fraction: level.Fraction})
+	}
+	if len(checked) == 0 {
+		return errors.Join(errs...)
+	}
+
+	var err error
+	if at.customTrigger(trigger) {
+		// 指定有效期或触发价格类型时逐档挂单（SetTakeProfitLadder使用默认参数）
+		err = placeLadder(quantity, checked, func(quantity, price float64) error {
+			return at.placeTakeProfit(symbol, positionSide, quantity, price, trigger)
+		})
+	} else {
+		err = ladder.SetTakeProfitLadder(symbol, positionSide, quantity, checked)
+	}
+	for _, level := range checked {
+		recordProtectiveOrder(at.journal, at.id, symbol, positionSide, "take_profit", quantity*level.Fraction, level.Price, err)
+	}
+	at.tpLadderMu.Lock()
+	at.tpLadders[symbol+"_"+side] = &tpLadder{levels: checked, initialQty: quantity}
+	at.tpLadderMu.Unlock()
+	at.log.Info("分批止盈已设置", "symbol", symbol, "side", side, "levels", len(checked))
+	return errors.Join(append(errs, err)...)
+}
+
+// updateTakeProfitLadders 按持仓数量的减少推进分批止盈进度，持仓已平时移除跟踪
+func (at *AutoTrader) updateTakeProfitLadders(positions []map[string]interface{}) {
+	current := make(map[string]float64)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		current[symbol+"_"+side] = math.Abs(floatValue(pos["positionAmt"]))
+	}
+
+	at.tpLadderMu.Lock()
+	defer at.tpLadderMu.Unlock()
+	for key, ladder := range at.tpLadders {
+		remaining, ok := current[key]
+		if !ok || remaining == 0 {
+			delete(at.tpLadders, key)
+			continue
+		}
+		// 累计平仓数量覆盖到的档位视为已成交（允许1%的数量取整误差）
+		closed := ladder.initialQty - remaining
+		filled, cumulative := 0, 0.0
+		for _, level := range ladder.levels {
+			cumulative += level.Fraction * ladder.initialQty
+			if closed < cumulative*0.99 {
+				break
+			}
+			filled++
+		}
+		if filled > ladder.filled {
+			ladder.filled = filled
+			at.log.Info("分批止盈已成交", "position", key, "filled", filled, "levels", len(ladder.levels), "remaining", remaining)
+		}
+	}
+}
+
+// takeProfitLadderProgress 持仓的分批止盈进度（未设置分批止盈时total为0）
+func (at *AutoTrader) takeProfitLadderProgress(symbol, side string) (filled, total int) {
+	at.tpLadderMu.Lock()
+	defer at.tpLadderMu.Unlock()
+	if ladder, ok := at.tpLadders[symbol+"_"+side]; ok {
+		return ladder.filled, len(ladder.levels)
+	}
+	return 0, 0
+}
@@ -0,0 +1,78 @@
+package trader
+
+import (
+	"nofx/store"
+	"time"
+)
+
+// ClosedPositionSource 可查询历史平仓记录的交易器（首次运行时导入交易日志）
+type ClosedPositionSource interface {
+	// GetClosedPositions 获取指定时间之后平仓的交易（含开平仓均价、毛盈亏、手续费和资金费）
+	GetClosedPositions(since time.Time) ([]store.Position, error)
+}
+
+// importCostTolerance 导入交易平仓后这段时间内的费用流水视为已计入导入的交易（与交易日志归集费用的容差一致）
+const importCostTolerance = 2 * time.Minute
+
+// importTradeHistory 首次运行（交易日志中还没有该trader的交易）时从交易所导入最近history_import_days天的平仓记录和成交，
+// 使盈亏报告和依赖历史交易的统计从第一天起可用；需在启动对账之前调用（对账接管持仓后交易日志不再为空）
+func (at *AutoTrader) importTradeHistory() {
+	days := at.config.HistoryImportDays
+	if days <= 0 || at.journal == nil {
+		return
+	}
+	source, ok := at.trader.(ClosedPositionSource)
+	if !ok {
+		journalLog.Warn("交易器不支持查询平仓历史，跳过历史交易导入", "trader", at.id, "exchange", at.exchange)
+		return
+	}
+	imported, err := at.journal.HasPositions(at.id)
+	if err != nil {
+		journalLog.Warn("检查交易日志失败，跳过历史交易导入", "trader", at.id, "err", err)
+		return
+	}
+	if imported {
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	positions, err := source.GetClosedPositions(since)
+	if err != nil {
+		journalLog.Warn("获取平仓历史失败，跳过历史交易导入", "trader", at.id, "err", err)
+		return
+	}
+	var latest time.Time
+	for i := range positions {
+		positions[i].TraderID = at.id
+		if closed := positions[i].ClosedAt; closed != nil && closed.After(latest) {
+			latest = *closed
+		}
+	}
+	if err := at.journal.ImportClosedPositions(positions); err != nil {
+		journalLog.Warn("导入历史交易失败", "trader", at.id, "err", err)
+		return
+	}
+
+	// 成交记录只用于成交分析，不向交易所逐笔查询订单文本归因
+	fills := 0
+	if history, ok := at.trader.(TradeHistorySource); ok {
+		records, err := history.GetFills(since)
+		if err != nil {
+			journalLog.Warn("获取历史成交失败", "trader", at.id, "err", err)
+		}
+		for _, fill := range records {
+			fill.TraderID = at.id
+			if err := at.journal.RecordFill(fill); err != nil {
+				journalLog.Warn("写入历史成交失败", "trader", at.id, "symbol", fill.Symbol, "err", err)
+				continue
+			}
+			fills++
+		}
+	}
+
+	// 导入的交易已包含手续费和资金费，首次费用同步（默认回溯24小时）不早于最后一笔导入交易，避免按部分流水重新汇总
+	if sync := latest.Add(importCostTolerance); sync.After(at.startTime.Add(-24 * time.Hour)) {
+		at.lastCostSync = sync
+	}
+	journalLog.Info("已从交易所导入历史交易", "trader", at.id, "days", days, "positions", len(positions), "fills", fills)
+}
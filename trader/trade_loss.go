@@ -0,0 +1,196 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/risk"
+	"strconv"
+)
+
+// tradeLossAddStrategies 下单时按持仓现有止损检查单笔亏损上限的加仓策略（其他策略的开仓不带止损，如资金费套利、对冲腿）
+var tradeLossAddStrategies = map[string]bool{
+	"dca":     true,
+	"pyramid": true,
+}
+
+// checkTradeLoss 单笔最大亏损硬上限：开仓必须带止损，按止损距离和手续费估算的亏损超过max_trade_loss时拒绝，
+// 或在resize时把决策的仓位缩小到上限内（入场价取限价，市价开仓取当前价；配置了止损限价偏移时按偏移后的最差成交价估算）
+// 按交易所取整后的数量复核，最小下单数量的亏损已超过上限时即使resize也拒绝
+func (at *AutoTrader) checkTradeLoss(d *decision.Decision) error {
+	limit := at.config.TradeLossCap
+	if !limit.Enabled() {
+		return nil
+	}
+	entry := d.LimitPrice
+	if entry <= 0 {
+		price, err := at.trader.GetMarketPrice(d.Symbol)
+		if err != nil {
+			return fmt.Errorf("%w: 获取价格失败: %v", ErrTradeLossCap, err)
+		}
+		entry = price
+	}
+	side := "long"
+	if d.Action == "open_short" {
+		side = "short"
+	}
+
+	stopLoss := at.worstStopPrice(side, d.StopLoss)
+	entryFee, exitFee := at.tradeLossFees(d.Symbol, d.LimitPrice > 0 || at.makerEntryRoute())
+	size, err := limit.Check(d.PositionSizeUSD, entry, stopLoss, entryFee, exitFee)
+	if err == nil {
+		size, err = at.checkRoundedTradeLoss(d.Symbol, size, entry, stopLoss, entryFee, exitFee)
+	}
+	if err != nil {
+		return fmt.Errorf("%w（%s %s）: %v", ErrTradeLossCap, at.execSource, d.Symbol, err)
+	}
+	if size < d.PositionSizeUSD {
+		at.log.Warn("止损亏损超过单笔上限，缩小仓位", "symbol", d.Symbol, "action", d.Action,
+			"requested", d.PositionSizeUSD, "size_usd", size, "max_loss", limit.MaxLossUSDT, "stop_loss", d.StopLoss)
+		at.publishRisk("trade_loss", d.Symbol, "resized",
+			fmt.Sprintf("%s %s 仓位 %.2f → %.2f USDT（单笔亏损上限%.2f USDT）", d.Symbol, d.Action, d.PositionSizeUSD, size, limit.MaxLossUSDT))
+		d.PositionSizeUSD = size
+	}
+	return nil
+}
+
+// checkRoundedTradeLoss 按交易所精度和取整策略取整后的数量复核止损亏损（取整可能进位或补到最小下单数量）
+// 超出上限时拒绝；resize模式下按整数张向下缩小（数量与executeOpenLong/executeOpenShort一致：仓位价值 / 入场价）
+func (at *AutoTrader) checkRoundedTradeLoss(symbol string, sizeUSD, entry, stopLoss, entryFee, exitFee float64) (float64, error) {
+	limit := at.config.TradeLossCap
+	_, hasSpec := at.trader.(ContractSpecSource)
+	spec := contractSpec(at.trader, symbol)
+	if hasSpec {
+		minSize := math.Max(spec.OrderSizeMin, 1)
+		if loss := risk.WorstLoss(spec.BaseQuantity(minSize)*entry, entry, stopLoss, entryFee, exitFee); loss > limit.MaxLossUSDT {
+			return 0, fmt.Errorf("最小下单数量%.0f张止损时亏损%.2f USDT（含手续费），超过单笔亏损上限%.2f USDT",
+				minSize, loss, limit.MaxLossUSDT)
+		}
+	}
+
+	notional, err := at.roundedNotional(symbol, sizeUSD/entry, entry)
+	if err != nil {
+		return sizeUSD, nil // 数量无法取整（如低于最小下单数量且配置为拒绝）时由开仓本身报错
+	}
+	loss := risk.WorstLoss(notional, entry, stopLoss, entryFee, exitFee)
+	if loss <= limit.MaxLossUSDT {
+		return sizeUSD, nil
+	}
+	if !limit.Resize() || !hasSpec {
+		return 0, fmt.Errorf("取整后止损时亏损%.2f USDT（含手续费），超过单笔亏损上限%.2f USDT", loss, limit.MaxLossUSDT)
+	}
+	contracts := math.Floor(spec.Contracts(limit.MaxSize(entry, stopLoss, entryFee, exitFee)/entry) + 1e-9)
+	return contracts * entry, nil
+}
+
+// checkOrderTradeLoss 加仓单下单前检查单笔亏损上限（订单跟踪器调用）：按取整后的加仓数量和持仓现有的止损单估算加仓部分的亏损，
+// 持仓没有止损单时拒绝；AI和外部信号的开仓已在checkTradeLoss中检查，其他策略的开仓不检查
+func (at *AutoTrader) checkOrderTradeLoss(strategy, symbol, side string, quantity, price float64) error {
+	limit := at.config.TradeLossCap
+	if !limit.Enabled() || !tradeLossAddStrategies[strategy] {
+		return nil
+	}
+	if price <= 0 {
+		var err error
+		if price, err = at.trader.GetMarketPrice(symbol); err != nil {
+			return fmt.Errorf("%w（%s %s）: 获取价格失败: %v", ErrTradeLossCap, strategy, symbol, err)
+		}
+	}
+	stopLoss, err := at.positionStopPrice(symbol, side)
+	if err != nil {
+		return fmt.Errorf("%w（%s %s）: %v", ErrTradeLossCap, strategy, symbol, err)
+	}
+	stopLoss = at.worstStopPrice(side, stopLoss)
+
+	notional, err := at.roundedNotional(symbol, quantity, price)
+	if err != nil {
+		return nil // 数量无法取整时由下单本身报错
+	}
+	entryFee, exitFee := at.tradeLossFees(symbol, false)
+	if loss := risk.WorstLoss(notional, price, stopLoss, entryFee, exitFee); loss > limit.MaxLossUSDT {
+		return fmt.Errorf("%w（%s %s）: 加仓部分止损时亏损%.2f USDT（含手续费），超过单笔亏损上限%.2f USDT",
+			ErrTradeLossCap, strategy, symbol, loss, limit.MaxLossUSDT)
+	}
+	return nil
+}
+
+// positionStopPrice 持仓现有止损单中最后触发的止损价（多仓取最低、空仓取最高）
+func (at *AutoTrader) positionStopPrice(symbol, side string) (float64, error) {
+	source, ok := at.trader.(OpenOrderSource)
+	if !ok {
+		return 0, fmt.Errorf("交易器不支持查询条件单，无法确认加仓的止损")
+	}
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return 0, fmt.Errorf("获取条件单失败: %v", err)
+	}
+	stop := 0.0
+	for _, trigger := range triggers {
+		if trigger.Symbol != symbol || trigger.PositionSide != side || trigger.Kind != "stop_loss" || trigger.TriggerPrice <= 0 {
+			continue
+		}
+		if stop == 0 || (side == "long" && trigger.TriggerPrice < stop) || (side == "short" && trigger.TriggerPrice > stop) {
+			stop = trigger.TriggerPrice
+		}
+	}
+	if stop == 0 {
+		return 0, fmt.Errorf("持仓没有止损单，加仓必须有止损")
+	}
+	return stop, nil
+}
+
+// worstStopPrice 配置了止损限价偏移时按偏移后的最差成交价估算止损
+func (at *AutoTrader) worstStopPrice(side string, stopLoss float64) float64 {
+	offset := at.config.StopLimitOffsetPct
+	if offset <= 0 || stopLoss <= 0 {
+		return stopLoss
+	}
+	if side == "long" {
+		return stopLoss * (1 - offset/100)
+	}
+	return stopLoss * (1 + offset/100)
+}
+
+// tradeLossFees 估算止损亏损使用的开仓和平仓费率：止损按吃单平仓，只挂单开仓（限价单、maker/chase执行方式）按挂单费率
+// 交易器不提供费率时都按fee_pct估算
+func (at *AutoTrader) tradeLossFees(symbol string, makerEntry bool) (entryFee, exitFee float64) {
+	fee := at.config.TradeLossCap.Fee()
+	entryFee, exitFee = fee, fee
+	if source, ok := at.trader.(FeeRateSource); ok {
+		if maker, taker, err := source.GetFeeRates(symbol); err == nil && taker > 0 {
+			entryFee, exitFee = taker, taker
+			if makerEntry {
+				entryFee = maker
+			}
+		}
+	}
+	return entryFee, exitFee
+}
+
+// makerEntryRoute 市价开仓是否固定以只挂单执行（entry_routing为maker或chase且交易器支持；auto按盘口选择，按吃单估算）
+func (at *AutoTrader) makerEntryRoute() bool {
+	if _, ok := at.trader.(MakerEntrySupport); !ok {
+		return false
+	}
+	switch at.config.EntryRouting.Mode {
+	case "maker":
+		return true
+	case "chase":
+		_, ok := at.trader.(ChaseEntrySupport)
+		return ok
+	}
+	return false
+}
+
+// roundedNotional 按交易所精度和取整策略取整后的下单名义价值（与预览一致：张数×合约乘数×价格）
+func (at *AutoTrader) roundedNotional(symbol string, quantity, price float64) (float64, error) {
+	formatted, err := at.trader.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return 0, err
+	}
+	contracts, err := strconv.ParseFloat(formatted, 64)
+	if err != nil {
+		return 0, err
+	}
+	return contractSpec(at.trader, symbol).BaseQuantity(contracts) * price, nil
+}
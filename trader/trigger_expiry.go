@@ -0,0 +1,164 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"nofx/notify"
+	"strings"
+	"time"
+)
+
+const (
+	triggerRenewInterval = time.Hour      // 条件单有效期检查间隔
+	triggerRenewBefore   = 24 * time.Hour // 剩余有效期不足该值时续期（有效期较短时为有效期的1/4）
+)
+
+// TriggerExpirationSupport 可指定止损/止盈条件单有效期的交易器（条件单到期后由交易所自动撤销）
+type TriggerExpirationSupport interface {
+	// SetTriggerExpiration 设置SetStopLoss/SetTakeProfit使用的默认有效期
+	SetTriggerExpiration(ttl time.Duration)
+	// SetStopLossWithExpiration 设置指定有效期的止损单（ttl为0时使用默认有效期）
+	SetStopLossWithExpiration(symbol, positionSide string, quantity, stopPrice float64, ttl time.Duration) error
+	// SetTakeProfitWithExpiration 设置指定有效期的止盈单（ttl为0时使用默认有效期）
+	SetTakeProfitWithExpiration(symbol, positionSide string, quantity, takeProfitPrice float64, ttl time.Duration) error
+}
+
+// placeStopLoss 设置止损单，指定了有效期或触发价格类型且交易器支持时按指定参数挂单
+func (at *AutoTrader) placeStopLoss(symbol, positionSide string, quantity, price float64, opts TriggerOptions) error {
+	if priced, ok := at.trader.(TriggerPriceTypeSupport); ok && opts.PriceType != "" {
+		return priced.SetStopLossWithOptions(symbol, positionSide, quantity, price, opts)
+	}
+	if expiring, ok := at.trader.(TriggerExpirationSupport); ok && opts.Expiration > 0 {
+		return expiring.SetStopLossWithExpiration(symbol, positionSide, quantity, price, opts.Expiration)
+	}
+	return at.trader.SetStopLoss(symbol, positionSide, quantity, price)
+}
+
+// placeTakeProfit 设置止盈单，指定了有效期或触发价格类型且交易器支持时按指定参数挂单
+func (at *AutoTrader) placeTakeProfit(symbol, positionSide string, quantity, price float64, opts TriggerOptions) error {
+	if priced, ok := at.trader.(TriggerPriceTypeSupport); ok && opts.PriceType != "" {
+		return priced.SetTakeProfitWithOptions(symbol, positionSide, quantity, price, opts)
+	}
+	if expiring, ok := at.trader.(TriggerExpirationSupport); ok && opts.Expiration > 0 {
+		return expiring.SetTakeProfitWithExpiration(symbol, positionSide, quantity, price, opts.Expiration)
+	}
+	return at.trader.SetTakeProfit(symbol, positionSide, quantity, price)
+}
+
+// customTrigger 是否需要逐单指定条件单参数（交易器的批量接口只使用默认参数）
+func (at *AutoTrader) customTrigger(opts TriggerOptions) bool {
+	_, expiring := at.trader.(TriggerExpirationSupport)
+	_, priced := at.trader.(TriggerPriceTypeSupport)
+	return (expiring && opts.Expiration > 0) || (priced && opts.PriceType != "")
+}
+
+// renewWindow 剩余有效期不足该值时续期
+func renewWindow(expiration time.Duration) time.Duration {
+	if window := expiration / 4; window < triggerRenewBefore {
+		return window
+	}
+	return triggerRenewBefore
+}
+
+// monitorTriggerExpiry 定时检查持仓的止损/止盈条件单，临近到期时按原触发价、数量和有效期重挂，
+// 避免持仓超过条件单有效期（默认30天）后失去保护
+func (at *AutoTrader) monitorTriggerExpiry() {
+	_, ok := at.trader.(OpenOrderSource)
+	_, canCancel := at.trader.(TriggerOrderCanceller)
+	_, canExpire := at.trader.(TriggerExpirationSupport)
+	if !ok || !canCancel || !canExpire {
+		return
+	}
+
+	for {
+		if !at.maintenance.active() {
+			at.cycleMu.Lock()
+			renewed, err := at.renewExpiringTriggers()
+			at.cycleMu.Unlock()
+			if err != nil {
+				at.log.Warn("条件单续期失败", "renewed", renewed, "err", err)
+				at.notify(notify.KindError, "", "条件单续期失败", err.Error())
+			}
+		}
+
+		select {
+		case <-at.stopCh:
+			return
+		case <-at.clock.After(triggerRenewInterval):
+		}
+	}
+}
+
+// renewExpiringTriggers 续期临近到期的条件单：先挂新单再撤旧单（避免持仓短暂失去保护），返回续期数量
+// 触发价沿用交易所上的原单，不再经过价格合理性检查（检查未通过时原单到期后持仓同样失去保护）
+func (at *AutoTrader) renewExpiringTriggers() (int, error) {
+	source := at.trader.(OpenOrderSource)
+	canceller := at.trader.(TriggerOrderCanceller)
+
+	triggers, err := source.GetOpenTriggerOrders()
+	if err != nil {
+		return 0, err
+	}
+	now := at.clock.Now()
+	var due []TriggerOrder
+	for _, trigger := range triggers {
+		if trigger.ExpiresAt <= 0 {
+			continue
+		}
+		expiration := time.Duration(trigger.Expiration) * time.Second
+		if time.Unix(trigger.ExpiresAt, 0).Sub(now) <= renewWindow(expiration) {
+			due = append(due, trigger)
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	held := make(map[string]float64)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		if quantity := math.Abs(floatValue(pos["positionAmt"])); symbol != "" && quantity > 0 {
+			held[symbol+"_"+side] = quantity
+		}
+	}
+
+	renewed := 0
+	var errs []error
+	for _, o := range due {
+		quantity, ok := held[o.Symbol+"_"+o.PositionSide]
+		if !ok {
+			continue // 持仓已不存在，条件单到期后由交易所撤销
+		}
+		if o.Quantity > 0 && o.Quantity < quantity {
+			quantity = o.Quantity
+		}
+
+		positionSide := strings.ToUpper(o.PositionSide)
+		ttl := time.Duration(o.Expiration) * time.Second
+		// 沿用原单的触发价格类型（可能由信号逐单指定，与默认值不同）
+		opts := TriggerOptions{Expiration: ttl, PriceType: o.PriceType}
+		if o.Kind == "stop_loss" {
+			err = at.placeStopLoss(o.Symbol, positionSide, quantity, o.TriggerPrice, opts)
+		} else {
+			err = at.placeTakeProfit(o.Symbol, positionSide, quantity, o.TriggerPrice, opts)
+		}
+		recordProtectiveOrder(at.journal, at.id, o.Symbol, positionSide, o.Kind, quantity, o.TriggerPrice, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("续期 %s %s %s单(%.4f)失败: %w", o.Symbol, o.PositionSide, o.Kind, o.TriggerPrice, err))
+			continue
+		}
+		renewed++
+		if err := canceller.CancelTriggerOrder(o.Symbol, o.OrderID); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s单已续期，但撤销即将到期的原单%s失败: %w", o.Symbol, o.Kind, o.OrderID, err))
+		}
+		at.log.Info("条件单即将到期，已续期", "symbol", o.Symbol, "side", o.PositionSide, "kind", o.Kind,
+			"trigger_price", o.TriggerPrice, "quantity", quantity, "expires_at", time.Unix(o.ExpiresAt, 0), "ttl", ttl)
+	}
+	return renewed, errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package trader
+
+import (
+	"nofx/decision"
+	"time"
+)
+
+// TriggerOptions 单个止损/止盈条件单的参数（零值表示使用交易器的默认值）
+type TriggerOptions struct {
+	Expiration time.Duration // 有效期（0表示默认有效期）
+	PriceType  string        // 触发价格类型（mark/last/index，为空表示默认类型）
+}
+
+// triggerOptions 决策指定的条件单参数（外部信号可逐单指定有效期和触发价格类型）
+func triggerOptions(d *decision.Decision) TriggerOptions {
+	return TriggerOptions{
+		Expiration: time.Duration(d.TriggerExpiration) * time.Second,
+		PriceType:  d.TriggerPriceType,
+	}
+}
+
+// TriggerPriceTypeSupport 可选择止损/止盈条件单触发价格类型的交易器（标记价格、最新成交价或指数价格）
+type TriggerPriceTypeSupport interface {
+	// SetTriggerPriceType 设置SetStopLoss/SetTakeProfit使用的默认触发价格类型
+	SetTriggerPriceType(priceType string)
+	// SetStopLossWithOptions 按指定有效期和触发价格类型设置止损单
+	SetStopLossWithOptions(symbol, positionSide string, quantity, stopPrice float64, opts TriggerOptions) error
+	// SetTakeProfitWithOptions 按指定有效期和触发价格类型设置止盈单
+	SetTakeProfitWithOptions(symbol, positionSide string, quantity, takeProfitPrice float64, opts TriggerOptions) error
+}
+
+// Gate.io条件单的price_type取值
+var gatePriceTypes = map[string]int32{
+	decision.TriggerPriceLast:  0,
+	decision.TriggerPriceMark:  1,
+	decision.TriggerPriceIndex: 2,
+}
+
+// gatePriceTypeName Gate.io条件单price_type对应的触发价格类型
+func gatePriceTypeName(priceType int32) string {
+	for name, v := range gatePriceTypes {
+		if v == priceType {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package trader
+
+import (
+	"nofx/market"
+	"nofx/pool"
+	"strings"
+	"time"
+)
+
+// warmStart 第一个决策周期之前预加载持仓和候选币种的K线历史，使首个周期的指标（如4小时EMA50、3分钟MACD）已完整预热
+// 之后每个周期只查询最近几根K线接到已保存的历史后面；预加载失败不影响启动（首个周期按需完整查询）
+func (at *AutoTrader) warmStart() {
+	seen := make(map[string]bool)
+	var symbols []string
+	add := func(symbol string) {
+		if symbol = market.Normalize(symbol); !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if positions, err := at.trader.GetPositions(); err == nil {
+		for _, pos := range positions {
+			if symbol, ok := pos["symbol"].(string); ok {
+				add(symbol)
+			}
+		}
+	} else {
+		at.log.Warn("指标预热获取持仓失败", "err", err)
+	}
+	if merged, err := pool.GetMergedCoinPool(ai500Limit); err == nil {
+		for _, symbol := range merged.AllSymbols {
+			add(symbol)
+		}
+	} else {
+		at.log.Warn("指标预热获取候选币种池失败", "err", err)
+	}
+	for _, tier := range at.config.Schedule.Cadence.Tiers {
+		for _, symbol := range tier.Symbols {
+			add(symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return
+	}
+
+	start := at.clock.Now()
+	partial, err := market.Warmup(symbols)
+	if err != nil {
+		at.log.Warn("部分币种指标预热失败，首个决策周期时重新查询", "err", err)
+	}
+	at.log.Info("指标预热完成", "symbols", len(symbols), "bars_3m", market.WarmupBars("3m"), "bars_4h", market.WarmupBars("4h"),
+		"elapsed", at.clock.Now().Sub(start).Round(time.Millisecond))
+	if len(partial) > 0 {
+		at.log.Warn("K线历史不足以完整预热指标（新上线合约），不作为开仓候选", "symbols", strings.Join(partial, ", "))
+	}
+}
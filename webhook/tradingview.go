@@ -0,0 +1,311 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnauthorized 签名或口令校验失败
+var ErrUnauthorized = errors.New("webhook签名校验失败")
+
+// ErrReplayed 信号时间戳超出有效窗口，或同一信号已处理过（重放）
+var ErrReplayed = errors.New("webhook信号已过期或重复")
+
+// defaultTimestampWindow 未配置timestamp_window_sec时信号时间戳与当前时间允许的偏差
+const defaultTimestampWindow = 2 * time.Minute
+
+// Config 外部信号Webhook配置
+type Config struct {
+	Enabled            bool    `json:"enabled"`              // 是否接收外部信号
+	Secret             string  `json:"secret"`               // 共享密钥：用于HMAC签名（X-Signature头），开启allow_passphrase时也是payload中的passphrase
+	AllowPassphrase    bool    `json:"allow_passphrase"`     // 允许不带签名、按payload中的passphrase校验（TradingView无法自定义请求头时开启）
+	TimestampWindowSec int     `json:"timestamp_window_sec"` // 信号时间戳与当前时间允许的偏差（秒，默认120）
+	PositionSizeUSD    float64 `json:"position_size_usd"`    // 信号未指定仓位时的默认仓位（USDT）
+	Leverage           int     `json:"leverage"`             // 信号未指定杠杆时的默认杠杆
+	StopLossPct        float64 `json:"stop_loss_pct"`        // 信号未指定止损时，按入场价的百分比计算（如2表示2%）
+	TakeProfitPct      float64 `json:"take_profit_pct"`      // 信号未指定止盈时，按入场价的百分比计算
+}
+
+// Validate 验证Webhook配置
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Secret) < 16 {
+		return fmt.Errorf("webhook.secret长度至少16个字符")
+	}
+	if c.Leverage <= 0 {
+		c.Leverage = 1
+	}
+	if c.StopLossPct < 0 || c.TakeProfitPct < 0 {
+		return fmt.Errorf("webhook.stop_loss_pct和take_profit_pct不能为负数")
+	}
+	if c.TimestampWindowSec < 0 {
+		return fmt.Errorf("webhook.timestamp_window_sec不能为负数")
+	}
+	return nil
+}
+
+// TimestampWindow 信号时间戳允许的偏差
+func (c Config) TimestampWindow() time.Duration {
+	if c.TimestampWindowSec <= 0 {
+		return defaultTimestampWindow
+	}
+	return time.Duration(c.TimestampWindowSec) * time.Second
+}
+
+// Alert TradingView警报payload（在TradingView警报消息中填写JSON模板）
+//
+//	{"passphrase":"...","timestamp":"{{timenow}}","ticker":"{{ticker}}","action":"{{strategy.order.action}}",
+//	 "market_position":"{{strategy.market_position}}","price":{{close}}}
+//
+// order_type为limit时按price挂GTC限价单（如 "price":{{strategy.order.price}}），超时未成交自动撤单
+// timestamp必填（RFC3339或Unix秒/毫秒），id可选：同一id（未填时为整个payload）在时间戳窗口内只接受一次
+type Alert struct {
+	Passphrase      string    `json:"passphrase"`
+	Timestamp       Timestamp `json:"timestamp"`
+	ID              string    `json:"id"`
+	Ticker          string    `json:"ticker"`          // 如 "BTCUSDT"、"BINANCE:BTCUSDT.P"
+	Action          string    `json:"action"`          // buy/sell 或 open_long/open_short/close_long/close_short
+	MarketPosition  string    `json:"market_position"` // long/short/flat（TradingView策略的持仓状态）
+	Price           float64   `json:"price"`
+	PositionSizeUSD float64   `json:"position_size_usd"`
+	Leverage        int       `json:"leverage"`
+	StopLoss        float64   `json:"stop_loss"`
+	TakeProfit      float64   `json:"take_profit"`
+	Comment         string    `json:"comment"`
+	OrderType       string    `json:"order_type"` // market（默认）/limit
+
+	TriggerExpiration int    `json:"trigger_expiration"` // 止损止盈条件单有效期（秒，0使用配置的默认值）
+	TriggerPriceType  string `json:"trigger_price_type"` // 止损止盈条件单的触发价格类型（mark/last/index，为空使用配置的默认值）
+}
+
+// Timestamp 信号时间：接受RFC3339字符串（TradingView的{{timenow}}）或Unix秒/毫秒（数字或数字字符串）
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON 解析RFC3339或Unix时间戳
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		if n > 1e12 {
+			t.Time = time.UnixMilli(int64(n))
+		} else {
+			t.Time = time.Unix(int64(n), 0)
+		}
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return fmt.Errorf("无效的timestamp: %s", raw)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Receiver 外部信号接收器：在Parse的基础上检查时间戳窗口，并记住窗口内已接受的信号拒绝重放
+type Receiver struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // key: scope + 信号ID → 信号时间
+	now  func() time.Time
+}
+
+// NewReceiver 创建外部信号接收器
+func NewReceiver() *Receiver {
+	return &Receiver{seen: make(map[string]time.Time), now: time.Now}
+}
+
+// Parse 校验签名/口令、时间戳窗口和重放后解析信号（scope区分不同的接收方，如trader ID）
+func (r *Receiver) Parse(scope string, cfg Config, body []byte, signature string) (*decision.Decision, error) {
+	d, alert, err := parse(cfg, body, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	window := cfg.TimestampWindow()
+	if alert.Timestamp.IsZero() {
+		return nil, fmt.Errorf("%w: payload缺少timestamp", ErrReplayed)
+	}
+	if skew := now.Sub(alert.Timestamp.Time); skew > window || skew < -window {
+		return nil, fmt.Errorf("%w: timestamp %s 超出%v窗口", ErrReplayed, alert.Timestamp.Format(time.RFC3339), window)
+	}
+
+	id := alert.ID
+	if id == "" {
+		sum := sha256.Sum256(body)
+		id = hex.EncodeToString(sum[:])
+	}
+	key := scope + "|" + id
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, ts := range r.seen {
+		if now.Sub(ts) > window {
+			delete(r.seen, k)
+		}
+	}
+	if _, dup := r.seen[key]; dup {
+		return nil, fmt.Errorf("%w: 同一信号已处理过（id: %s）", ErrReplayed, id)
+	}
+	r.seen[key] = alert.Timestamp.Time
+	return d, nil
+}
+
+// Sign 计算payload的HMAC-SHA256签名（十六进制）
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Parse 校验并解析外部信号，转换为与AI决策相同的Decision（不检查时间戳和重放，见Receiver）
+// signature 为请求头中的HMAC签名；只有开启allow_passphrase时才接受不带签名、按payload中passphrase校验的请求
+// （TradingView无法自定义请求头）
+func Parse(cfg Config, body []byte, signature string) (*decision.Decision, error) {
+	d, _, err := parse(cfg, body, signature)
+	return d, err
+}
+
+// parse 校验签名/口令并解析信号，同时返回原始payload
+func parse(cfg Config, body []byte, signature string) (*decision.Decision, *Alert, error) {
+	var alert Alert
+	if err := json.Unmarshal(body, &alert); err != nil {
+		return nil, nil, fmt.Errorf("解析webhook payload失败: %w", err)
+	}
+
+	switch {
+	case signature != "":
+		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(Sign(cfg.Secret, body))) {
+			return nil, nil, ErrUnauthorized
+		}
+	case !cfg.AllowPassphrase:
+		return nil, nil, fmt.Errorf("%w: 缺少X-Signature（未开启allow_passphrase）", ErrUnauthorized)
+	case alert.Passphrase == "" || subtle.ConstantTimeCompare([]byte(alert.Passphrase), []byte(cfg.Secret)) != 1:
+		return nil, nil, ErrUnauthorized
+	}
+
+	symbol := normalizeTicker(alert.Ticker)
+	if symbol == "" {
+		return nil, nil, fmt.Errorf("webhook payload缺少ticker")
+	}
+
+	action, err := resolveAction(alert.Action, alert.MarketPosition)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d := &decision.Decision{
+		Symbol:    symbol,
+		Action:    action,
+		Reasoning: decision.ShortReasoning(fmt.Sprintf("外部信号(TradingView): %s %s", alert.Action, alert.Comment)),
+	}
+
+	if action == "open_long" || action == "open_short" {
+		d.Leverage = alert.Leverage
+		if d.Leverage <= 0 {
+			d.Leverage = cfg.Leverage
+		}
+		d.PositionSizeUSD = alert.PositionSizeUSD
+		if d.PositionSizeUSD <= 0 {
+			d.PositionSizeUSD = cfg.PositionSizeUSD
+		}
+		if d.PositionSizeUSD <= 0 {
+			return nil, nil, fmt.Errorf("信号未指定position_size_usd且未配置默认仓位")
+		}
+
+		d.StopLoss, d.TakeProfit = alert.StopLoss, alert.TakeProfit
+		if alert.Price > 0 {
+			sign := 1.0
+			if action == "open_short" {
+				sign = -1.0
+			}
+			if d.StopLoss <= 0 && cfg.StopLossPct > 0 {
+				d.StopLoss = alert.Price * (1 - sign*cfg.StopLossPct/100)
+			}
+			if d.TakeProfit <= 0 && cfg.TakeProfitPct > 0 {
+				d.TakeProfit = alert.Price * (1 + sign*cfg.TakeProfitPct/100)
+			}
+		}
+		if d.StopLoss <= 0 || d.TakeProfit <= 0 {
+			return nil, nil, fmt.Errorf("开仓信号必须包含止损止盈（或提供price并配置stop_loss_pct/take_profit_pct）")
+		}
+		if err := decision.CheckTriggerExpiration(alert.TriggerExpiration); err != nil {
+			return nil, nil, err
+		}
+		d.TriggerExpiration = alert.TriggerExpiration
+		priceType := strings.ToLower(alert.TriggerPriceType)
+		if err := decision.CheckTriggerPriceType(priceType); err != nil {
+			return nil, nil, err
+		}
+		d.TriggerPriceType = priceType
+
+		switch strings.ToLower(alert.OrderType) {
+		case "", "market":
+		case "limit":
+			if alert.Price <= 0 {
+				return nil, nil, fmt.Errorf("限价信号必须包含price")
+			}
+			d.LimitPrice = alert.Price
+		default:
+			return nil, nil, fmt.Errorf("无效的order_type: %s（可选: market/limit）", alert.OrderType)
+		}
+	}
+
+	return d, &alert, nil
+}
+
+// normalizeTicker 转换TradingView的ticker格式
+// 例如: "BINANCE:BTCUSDT.P" -> "BTCUSDT", "GATEIO:ETH_USDT" -> "ETHUSDT"
+func normalizeTicker(ticker string) string {
+	ticker = strings.TrimSpace(ticker)
+	if idx := strings.LastIndex(ticker, ":"); idx >= 0 {
+		ticker = ticker[idx+1:]
+	}
+	ticker = strings.TrimSuffix(strings.ToUpper(ticker), ".P")
+	ticker = strings.ReplaceAll(ticker, "_", "")
+	ticker = strings.ReplaceAll(ticker, "/", "")
+	if ticker == "" {
+		return ""
+	}
+	return market.Normalize(ticker)
+}
+
+// resolveAction 将TradingView的action/market_position映射为决策动作
+func resolveAction(action, marketPosition string) (string, error) {
+	action = strings.ToLower(strings.TrimSpace(action))
+	marketPosition = strings.ToLower(strings.TrimSpace(marketPosition))
+
+	switch action {
+	case "open_long", "open_short", "close_long", "close_short":
+		return action, nil
+	case "buy", "long":
+		// 买入后策略仍为空头或变为flat表示买入平空
+		if marketPosition == "flat" || marketPosition == "short" {
+			return "close_short", nil
+		}
+		return "open_long", nil
+	case "sell", "short":
+		if marketPosition == "flat" || marketPosition == "long" {
+			return "close_long", nil
+		}
+		return "open_short", nil
+	default:
+		return "", fmt.Errorf("不支持的信号action: %s", action)
+	}
+}
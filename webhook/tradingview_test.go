@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef"
+
+func TestParse(t *testing.T) {
+	cfg := Config{Enabled: true, Secret: testSecret, Leverage: 3, PositionSizeUSD: 100, StopLossPct: 2, TakeProfitPct: 4}
+	passphrase := cfg
+	passphrase.AllowPassphrase = true
+
+	open := `{"ticker":"BINANCE:BTCUSDT.P","action":"buy","market_position":"long","price":100}`
+	tests := []struct {
+		name      string
+		cfg       Config
+		body      string
+		signature string // "sign"/"SIGN"表示按body计算正确签名（小写/大写）
+		wantErr   error  // 为nil时期望成功
+		want      string // 期望的动作
+	}{
+		{name: "HMAC签名", cfg: cfg, body: open, signature: "sign", want: "open_long"},
+		{name: "大写签名", cfg: cfg, body: open, signature: "SIGN", want: "open_long"},
+		{name: "签名错误", cfg: cfg, body: open, signature: "deadbeef", wantErr: ErrUnauthorized},
+		{name: "签名正确但body被改", cfg: cfg, body: open + " ", signature: Sign(testSecret, []byte(open)), wantErr: ErrUnauthorized},
+		{name: "未开启口令时缺少签名", cfg: cfg, body: `{"passphrase":"` + testSecret + `","ticker":"BTCUSDT","action":"close_long"}`, wantErr: ErrUnauthorized},
+		{name: "口令正确", cfg: passphrase, body: `{"passphrase":"` + testSecret + `","ticker":"BTCUSDT","action":"sell","market_position":"flat"}`, want: "close_long"},
+		{name: "口令错误", cfg: passphrase, body: `{"passphrase":"wrong","ticker":"BTCUSDT","action":"close_long"}`, wantErr: ErrUnauthorized},
+		{name: "缺少口令", cfg: passphrase, body: `{"ticker":"BTCUSDT","action":"close_long"}`, wantErr: ErrUnauthorized},
+		{name: "开启口令时仍校验签名", cfg: passphrase, body: `{"passphrase":"` + testSecret + `","ticker":"BTCUSDT","action":"close_long"}`, signature: "deadbeef", wantErr: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signature := tt.signature
+			switch signature {
+			case "sign":
+				signature = Sign(testSecret, []byte(tt.body))
+			case "SIGN":
+				signature = strings.ToUpper(Sign(testSecret, []byte(tt.body)))
+			}
+			d, err := Parse(tt.cfg, []byte(tt.body), signature)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse错误 = %v，期望 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if d.Symbol != "BTCUSDT" || d.Action != tt.want {
+				t.Fatalf("决策 = %s %s，期望 BTCUSDT %s", d.Symbol, d.Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOpenDefaults(t *testing.T) {
+	cfg := Config{Enabled: true, Secret: testSecret, Leverage: 3, PositionSizeUSD: 100, StopLossPct: 2, TakeProfitPct: 4}
+	body := []byte(`{"ticker":"GATEIO:ETH_USDT","action":"sell","market_position":"short","price":100}`)
+	d, err := Parse(cfg, body, Sign(testSecret, body))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if d.Symbol != "ETHUSDT" || d.Action != "open_short" || d.Leverage != 3 || d.PositionSizeUSD != 100 {
+		t.Fatalf("决策 = %+v", d)
+	}
+	// 空头止损在入场价上方，止盈在下方
+	if d.StopLoss != 102 || d.TakeProfit != 96 {
+		t.Fatalf("止损止盈 = %v/%v，期望 102/96", d.StopLoss, d.TakeProfit)
+	}
+
+	noStops := Config{Enabled: true, Secret: testSecret, PositionSizeUSD: 100}
+	if _, err := Parse(noStops, body, Sign(testSecret, body)); err == nil {
+		t.Fatal("开仓信号没有止损止盈时应拒绝")
+	}
+}
+
+func TestReceiverParse(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	cfg := Config{Enabled: true, Secret: testSecret, AllowPassphrase: true}
+	alert := func(timestamp, id string) []byte {
+		return []byte(fmt.Sprintf(`{"passphrase":%q,"timestamp":%s,"id":%q,"ticker":"BTCUSDT","action":"close_long"}`,
+			testSecret, timestamp, id))
+	}
+
+	tests := []struct {
+		name    string
+		scope   string
+		body    []byte
+		wantErr error
+	}{
+		{name: "RFC3339时间戳", scope: "t1", body: alert(`"2026-03-02T12:00:30Z"`, "a")},
+		{name: "同一id重放", scope: "t1", body: alert(`"2026-03-02T12:00:31Z"`, "a"), wantErr: ErrReplayed},
+		{name: "同一id不同trader", scope: "t2", body: alert(`"2026-03-02T12:00:31Z"`, "a")},
+		{name: "Unix秒", scope: "t1", body: alert(fmt.Sprint(now.Add(-time.Minute).Unix()), "b")},
+		{name: "Unix毫秒字符串", scope: "t1", body: alert(fmt.Sprintf(`"%d"`, now.Add(90*time.Second).UnixMilli()), "c")},
+		{name: "过期", scope: "t1", body: alert(`"2026-03-02T11:57:59Z"`, "d"), wantErr: ErrReplayed},
+		{name: "来自未来", scope: "t1", body: alert(`"2026-03-02T12:02:01Z"`, "e"), wantErr: ErrReplayed},
+		{name: "缺少时间戳", scope: "t1", body: []byte(`{"passphrase":"` + testSecret + `","ticker":"BTCUSDT","action":"close_long"}`), wantErr: ErrReplayed},
+		{name: "没有id时按body去重", scope: "t1", body: alert(`"2026-03-02T12:00:00Z"`, "")},
+		{name: "没有id的相同body", scope: "t1", body: alert(`"2026-03-02T12:00:00Z"`, ""), wantErr: ErrReplayed},
+		{name: "口令错误优先于时间戳", scope: "t1", body: []byte(`{"passphrase":"wrong","timestamp":"2026-03-02T12:00:00Z","ticker":"BTCUSDT","action":"close_long"}`), wantErr: ErrUnauthorized},
+	}
+
+	r := NewReceiver()
+	r.now = func() time.Time { return now }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Parse(tt.scope, cfg, tt.body, "")
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse错误 = %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 窗口过后已接受的id被清理，可以再次使用
+	r.now = func() time.Time { return now.Add(10 * time.Minute) }
+	if _, err := r.Parse("t1", cfg, alert(fmt.Sprint(now.Add(10*time.Minute).Unix()), "a"), ""); err != nil {
+		t.Fatalf("窗口过后重新使用id: %v", err)
+	}
+}